@@ -1,49 +1,182 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
-
+	"github.com/devfullcycle/imersao22/go-gateway/internal/config"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/repository/postgres"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
 	"github.com/devfullcycle/imersao22/go-gateway/internal/web"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/handlers"
 )
 
-func getEnv(key, def string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to an optional YAML config file")
+	printConfig := flag.Bool("print-config", false, "print the resolved configuration (secrets masked) and exit")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
 	}
-	return def
-}
 
-func main() {
-	// Load .env if present
-	_ = godotenv.Load()
-
-	// Build Postgres connection string
-	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		getEnv("DB_HOST", "db"),
-		getEnv("DB_PORT", "5432"),
-		getEnv("DB_USER", "postgres"),
-		getEnv("DB_PASSWORD", "postgres"),
-		getEnv("DB_NAME", "gateway"),
-		getEnv("DB_SSL_MODE", "disable"),
-	)
-
-	db, err := sql.Open("postgres", connStr)
+	if *printConfig {
+		out, err := cfg.YAML()
+		if err != nil {
+			log.Fatalf("print config: %v", err)
+		}
+		fmt.Print(string(out))
+		return
+	}
+
+	db, err := postgres.Open(context.Background(), cfg.DB.DSN(), postgres.PoolConfig{
+		MaxOpenConns:    cfg.DB.MaxOpenConns,
+		MaxIdleConns:    cfg.DB.MaxIdleConns,
+		ConnMaxLifetime: cfg.DB.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.DB.ConnMaxIdleTime,
+		ConnectAttempts: cfg.DB.ConnectAttempts,
+		ConnectBackoff:  cfg.DB.ConnectBackoff,
+	})
 	if err != nil {
 		log.Fatalf("db open: %v", err)
 	}
 	defer db.Close()
 
-	port := getEnv("PORT", "8080")
-	srv := web.NewServer(db, port)
-	log.Printf("HTTP server listening on :%s", port)
-	if err := srv.Start(); err != nil {
-		log.Fatalf("server error: %v", err)
+	if args := flag.Args(); len(args) > 0 {
+		if args[0] != "migrate" {
+			log.Fatalf("unknown command %q", args[0])
+		}
+		if err := runMigrate(context.Background(), db, args[1:], os.Stdout); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	if cfg.Features.AutoMigrate {
+		if err := runMigrate(context.Background(), db, []string{"up"}, os.Stdout); err != nil {
+			log.Fatalf("auto-migrate: %v", err)
+		}
+	}
+
+	// The rules of every account are loaded, like the reloads of the worker
+	risk, err := riskService(tenant.WithPrivileged(context.Background()), db, cfg, *configPath)
+	if err != nil {
+		log.Fatalf("risk rules: %v", err)
+	}
+
+	opts := append(serverOptions(cfg), web.WithRiskRules(risk, cfg.Risk.ReloadInterval))
+	srv := web.NewServer(db, serverConfig(cfg), opts...)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("HTTP server listening on :%s", cfg.HTTP.Port)
+		errCh <- srv.Start()
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			log.Fatalf("server error: %v", err)
+		}
+		return
+	case <-ctx.Done():
+	}
+
+	// Stop accepting new connections, drain in-flight requests and workers
+	log.Printf("shutting down (timeout %s)", cfg.HTTP.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Stop(shutdownCtx); err != nil {
+		log.Printf("graceful shutdown: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		log.Printf("server error: %v", err)
+	}
+	log.Printf("server stopped")
+}
+
+func serverConfig(cfg *config.Config) web.ServerConfig {
+	return web.ServerConfig{
+		Port:              cfg.HTTP.Port,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+		MaxBodyBytes:      cfg.HTTP.MaxBodyBytes,
+		ShutdownDelay:     cfg.HTTP.ShutdownDelay,
+	}
+}
+
+func serverOptions(cfg *config.Config) []web.Option {
+	opts := []web.Option{
+		web.WithInvoiceProcessor(domain.NewDefaultInvoiceProcessorWithConfig(cfg.Processor.ApprovalRate, domain.SystemClock)),
+		web.WithLegacySunset(cfg.API.LegacyDeprecatedAt, cfg.API.LegacySunset),
+		web.WithAdminToken(cfg.Admin.APIKey),
+		web.WithPayoutPolicy(domain.PayoutPolicy{
+			MinAmount: cfg.Payout.MinAmount, ReserveRate: cfg.Payout.ReserveRate}),
+		web.WithPayoutProcessing(cfg.Payout.ProcessInterval),
+		web.WithDefaultFeePlan(cfg.Fees.DefaultPercent, cfg.Fees.DefaultFixed),
+		web.WithSettlementSchedule(domain.SettlementSchedule{
+			Delays: cfg.Settlement.Delays, Default: cfg.Settlement.DefaultDelayDays}),
+		web.WithSettlementRelease(cfg.Settlement.ReleaseInterval),
+		web.WithSubscriptionBilling(cfg.Subscription.BillingInterval,
+			domain.DunningPolicy{RetryDelays: cfg.Subscription.RetryDelays}),
+		web.WithDisputeExpiry(cfg.Dispute.ExpiryInterval),
+		web.WithInvoiceBatch(cfg.Batch.MaxItems, cfg.Batch.Workers),
+	}
+	if len(cfg.Kafka.Brokers) > 0 {
+		opts = append(opts, web.WithHealthCheck(handlers.BrokerCheck(cfg.Kafka.Brokers)))
+	}
+	return opts
+}
+
+// riskService loads the risk rules from the risk_rules table or from the
+// configuration. The configuration file is read again on every reload, so
+// both sources can be changed without a restart.
+func riskService(ctx context.Context, db *sql.DB, cfg *config.Config, configPath string) (*service.RiskService, error) {
+	var source domain.RiskRuleRepository = postgres.NewPostgresRiskRuleRepository(db)
+	if cfg.Risk.Source == config.RiskSourceConfig {
+		source = service.RiskRuleFunc(func(ctx context.Context) ([]domain.RiskRule, error) {
+			c, err := config.Load(configPath)
+			if err != nil {
+				return nil, err
+			}
+			return riskRules(c.Risk), nil
+		})
+	}
+	risk := service.NewRiskService(source)
+	return risk, risk.Reload(ctx)
+}
+
+// riskRules converts the configured rules. The amount threshold comes first
+// and holds invoices for review.
+func riskRules(c config.RiskConfig) []domain.RiskRule {
+	var rules []domain.RiskRule
+	if c.AmountThreshold > 0 {
+		rules = append(rules, domain.RiskRule{Kind: domain.RiskAmountThreshold, MaxAmount: c.AmountThreshold, Action: domain.RiskReview})
+	}
+	for _, r := range c.Rules {
+		rules = append(rules, domain.RiskRule{
+			Kind:           domain.RiskRuleKind(r.Kind),
+			AccountID:      r.AccountID,
+			PaymentType:    r.PaymentType,
+			MaxAmount:      r.MaxAmount,
+			MaxPerHour:     r.MaxPerHour,
+			AccountAgeDays: r.AccountAgeDays,
+			Action:         domain.RiskDecision(r.Action),
+		})
 	}
+	return rules
 }
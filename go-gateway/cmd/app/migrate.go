@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/migrate"
+	"github.com/devfullcycle/imersao22/go-gateway/migrations"
+)
+
+const migrateUsage = "usage: gateway migrate up|down|status|version"
+
+// runMigrate implements the `migrate` subcommand.
+func runMigrate(ctx context.Context, db *sql.DB, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	m, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "applied %d migration(s)\n", n)
+	case "down":
+		if err := m.Down(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "rolled back 1 migration")
+	case "status":
+		st, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range st {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(out, "%06d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	case "version":
+		v, dirty, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			fmt.Fprintf(out, "%d (dirty)\n", v)
+		} else {
+			fmt.Fprintf(out, "%d\n", v)
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
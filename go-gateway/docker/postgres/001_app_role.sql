@@ -0,0 +1,11 @@
+-- Development login of the gateway (see .env). It is neither superuser nor
+-- table owner, so row-level security applies to it; migrations run as
+-- postgres. The grants come from migration 000017.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'gateway_app') THEN
+        CREATE ROLE gateway_app NOLOGIN NOINHERIT;
+    END IF;
+END
+$$;
+CREATE ROLE gateway LOGIN PASSWORD 'gateway' NOSUPERUSER NOBYPASSRLS IN ROLE gateway_app;
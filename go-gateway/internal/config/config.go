@@ -0,0 +1,496 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the typed application configuration.
+//
+// Values are resolved in this order, later sources overriding earlier ones:
+// defaults, the optional YAML file, the .env file and the process environment.
+type Config struct {
+	DB           DBConfig           `yaml:"db"`
+	HTTP         HTTPConfig         `yaml:"http"`
+	Kafka        KafkaConfig        `yaml:"kafka"`
+	Processor    ProcessorConfig    `yaml:"processor"`
+	Payout       PayoutConfig       `yaml:"payout"`
+	Fees         FeeConfig          `yaml:"fees"`
+	Settlement   SettlementConfig   `yaml:"settlement"`
+	Risk         RiskConfig         `yaml:"risk"`
+	Subscription SubscriptionConfig `yaml:"subscription"`
+	Dispute      DisputeConfig      `yaml:"dispute"`
+	Batch        BatchConfig        `yaml:"batch"`
+	Features     FeatureFlags       `yaml:"features"`
+	API          APIConfig          `yaml:"api"`
+	Admin        AdminConfig        `yaml:"admin"`
+}
+
+// DBConfig holds the Postgres connection settings.
+type DBConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"ssl_mode"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	ConnectAttempts int           `yaml:"connect_attempts"`
+	ConnectBackoff  time.Duration `yaml:"connect_backoff"`
+}
+
+// DSN builds the lib/pq connection string.
+func (c DBConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		c.Host, c.Port, c.User, c.Password, c.Name, c.SSLMode)
+}
+
+// HTTPConfig holds the HTTP server settings.
+type HTTPConfig struct {
+	Port              string        `yaml:"port"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay"`
+	MaxBodyBytes      int64         `yaml:"max_body_bytes"`
+}
+
+// KafkaConfig holds the message broker settings.
+type KafkaConfig struct {
+	Brokers []string `yaml:"brokers"`
+}
+
+// ProcessorConfig configures the default invoice processor.
+type ProcessorConfig struct {
+	ApprovalRate float64 `yaml:"approval_rate"`
+}
+
+// PayoutConfig configures merchant withdrawals. ProcessInterval is how often
+// pending payouts are sent to the bank; zero disables the worker.
+type PayoutConfig struct {
+	MinAmount       float64       `yaml:"min_amount"`
+	ReserveRate     float64       `yaml:"reserve_rate"`
+	ProcessInterval time.Duration `yaml:"process_interval"`
+}
+
+// FeeConfig is the default fee plan, charged on approved invoices of accounts
+// without a plan for the payment type. Percent is in percentage points.
+type FeeConfig struct {
+	DefaultPercent float64 `yaml:"default_percent"`
+	DefaultFixed   float64 `yaml:"default_fixed"`
+}
+
+// SettlementConfig is the settlement schedule of approved money. Delays maps a
+// payment type to its delay in days (D+N); other payment types use
+// DefaultDelayDays. ReleaseInterval is how often due settlements are
+// released; zero disables the worker.
+type SettlementConfig struct {
+	Delays           map[string]int `yaml:"delays"`
+	DefaultDelayDays int            `yaml:"default_delay_days"`
+	ReleaseInterval  time.Duration  `yaml:"release_interval"`
+}
+
+// SubscriptionConfig configures recurring billing. BillingInterval is how
+// often due subscriptions are charged; zero disables the worker. A failed
+// charge is retried after each of RetryDelays in turn, and the subscription
+// is canceled when the last retry fails.
+type SubscriptionConfig struct {
+	BillingInterval time.Duration   `yaml:"billing_interval"`
+	RetryDelays     []time.Duration `yaml:"retry_delays"`
+}
+
+// DisputeConfig configures the dispute lifecycle. ExpiryInterval is how often
+// disputes left without evidence past their deadline are marked lost; zero
+// disables the worker.
+type DisputeConfig struct {
+	ExpiryInterval time.Duration `yaml:"expiry_interval"`
+}
+
+// BatchConfig limits POST /invoices/batch: MaxItems invoices per request,
+// Workers of them created at a time.
+type BatchConfig struct {
+	MaxItems int `yaml:"max_items"`
+	Workers  int `yaml:"workers"`
+}
+
+// Sources of the risk rules.
+const (
+	RiskSourceConfig   = "config"
+	RiskSourceDatabase = "database"
+)
+
+// RiskConfig configures the risk rules evaluated before processing invoices.
+// With the config source, invoices above AmountThreshold are held for review
+// (zero disables it) and Rules are added to that; with the database source
+// the rules come from the risk_rules table. Either way they are reloaded
+// every ReloadInterval; zero loads them only at startup.
+type RiskConfig struct {
+	Source          string           `yaml:"source"`
+	ReloadInterval  time.Duration    `yaml:"reload_interval"`
+	AmountThreshold float64          `yaml:"amount_threshold"`
+	Rules           []RiskRuleConfig `yaml:"rules"`
+}
+
+// RiskRuleConfig is one risk rule; the fields used depend on Kind
+// (amount_threshold, card_velocity, new_account or payment_type_block).
+type RiskRuleConfig struct {
+	Kind           string  `yaml:"kind"`
+	AccountID      string  `yaml:"account_id,omitempty"`
+	PaymentType    string  `yaml:"payment_type,omitempty"`
+	MaxAmount      float64 `yaml:"max_amount,omitempty"`
+	MaxPerHour     int     `yaml:"max_per_hour,omitempty"`
+	AccountAgeDays int     `yaml:"account_age_days,omitempty"`
+	Action         string  `yaml:"action"`
+}
+
+// APIConfig configures API versioning. The unversioned legacy routes are
+// served with Deprecation and Sunset headers pointing clients to /v1.
+type APIConfig struct {
+	LegacyDeprecatedAt time.Time `yaml:"legacy_deprecated_at"`
+	LegacySunset       time.Time `yaml:"legacy_sunset"`
+}
+
+// AdminConfig holds the credential of the admin API. The admin API rejects
+// every request while APIKey is empty.
+type AdminConfig struct {
+	APIKey string `yaml:"api_key"`
+}
+
+// FeatureFlags toggles optional subsystems.
+type FeatureFlags struct {
+	AutoMigrate bool `yaml:"auto_migrate"` // apply embedded migrations on start
+}
+
+// Default returns the configuration used when nothing else is set. It has no
+// database credentials on purpose: they must be provided explicitly.
+func Default() *Config {
+	return &Config{
+		DB: DBConfig{
+			Host:    "db",
+			Port:    "5432",
+			Name:    "gateway",
+			SSLMode: "disable",
+
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectAttempts: 10,
+			ConnectBackoff:  500 * time.Millisecond,
+		},
+		HTTP: HTTPConfig{
+			Port:              "8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+			ShutdownDelay:     5 * time.Second,
+			MaxBodyBytes:      1 << 20,
+		},
+		Processor: ProcessorConfig{
+			ApprovalRate: 0.7,
+		},
+		Payout: PayoutConfig{
+			MinAmount:       10,
+			ReserveRate:     0.1,
+			ProcessInterval: 30 * time.Second,
+		},
+		Settlement: SettlementConfig{
+			Delays:          map[string]int{"credit_card": 30},
+			ReleaseInterval: time.Minute,
+		},
+		Risk: RiskConfig{
+			Source:          RiskSourceConfig,
+			ReloadInterval:  30 * time.Second,
+			AmountThreshold: 10000,
+		},
+		Subscription: SubscriptionConfig{
+			BillingInterval: time.Minute,
+			RetryDelays:     []time.Duration{24 * time.Hour, 72 * time.Hour, 120 * time.Hour},
+		},
+		Dispute: DisputeConfig{
+			ExpiryInterval: time.Minute,
+		},
+		Batch: BatchConfig{
+			MaxItems: 500,
+			Workers:  8,
+		},
+		API: APIConfig{
+			LegacyDeprecatedAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+			LegacySunset:       time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+// Load reads the configuration from yamlPath (optional), .env and the
+// environment, then validates it.
+func Load(yamlPath string) (*Config, error) {
+	cfg := Default()
+
+	if yamlPath != "" {
+		raw, err := os.ReadFile(yamlPath)
+		if err != nil {
+			return nil, fmt.Errorf("config: read %s: %w", yamlPath, err)
+		}
+		if err := yaml.Unmarshal(raw, cfg); err != nil {
+			return nil, fmt.Errorf("config: parse %s: %w", yamlPath, err)
+		}
+	}
+
+	// .env never overrides variables already set in the environment
+	_ = godotenv.Load()
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides fields with environment variables. The first key found
+// wins, so legacy names can be listed after the canonical one.
+func (c *Config) applyEnv() error {
+	e := &envReader{}
+
+	e.str(&c.DB.Host, "DB_HOST")
+	e.str(&c.DB.Port, "DB_PORT")
+	e.str(&c.DB.User, "DB_USER")
+	e.str(&c.DB.Password, "DB_PASSWORD")
+	e.str(&c.DB.Name, "DB_NAME")
+	e.str(&c.DB.SSLMode, "DB_SSLMODE", "DB_SSL_MODE")
+	e.int(&c.DB.MaxOpenConns, "DB_MAX_OPEN_CONNS")
+	e.int(&c.DB.MaxIdleConns, "DB_MAX_IDLE_CONNS")
+	e.duration(&c.DB.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME")
+	e.duration(&c.DB.ConnMaxIdleTime, "DB_CONN_MAX_IDLE_TIME")
+	e.int(&c.DB.ConnectAttempts, "DB_CONNECT_ATTEMPTS")
+	e.duration(&c.DB.ConnectBackoff, "DB_CONNECT_BACKOFF")
+
+	e.str(&c.HTTP.Port, "HTTP_PORT", "PORT")
+	e.duration(&c.HTTP.ReadHeaderTimeout, "HTTP_READ_HEADER_TIMEOUT")
+	e.duration(&c.HTTP.ReadTimeout, "HTTP_READ_TIMEOUT")
+	e.duration(&c.HTTP.WriteTimeout, "HTTP_WRITE_TIMEOUT")
+	e.duration(&c.HTTP.IdleTimeout, "HTTP_IDLE_TIMEOUT")
+	e.duration(&c.HTTP.ShutdownTimeout, "HTTP_SHUTDOWN_TIMEOUT")
+	e.duration(&c.HTTP.ShutdownDelay, "HTTP_SHUTDOWN_DELAY")
+	e.int64(&c.HTTP.MaxBodyBytes, "HTTP_MAX_BODY_BYTES")
+
+	e.list(&c.Kafka.Brokers, "KAFKA_BROKERS")
+
+	e.float(&c.Processor.ApprovalRate, "PROCESSOR_APPROVAL_RATE")
+
+	e.float(&c.Payout.MinAmount, "PAYOUT_MIN_AMOUNT")
+	e.float(&c.Payout.ReserveRate, "PAYOUT_RESERVE_RATE")
+	e.duration(&c.Payout.ProcessInterval, "PAYOUT_PROCESS_INTERVAL")
+
+	e.float(&c.Fees.DefaultPercent, "FEE_DEFAULT_PERCENT")
+	e.float(&c.Fees.DefaultFixed, "FEE_DEFAULT_FIXED")
+
+	e.intMap(&c.Settlement.Delays, "SETTLEMENT_DELAYS")
+	e.int(&c.Settlement.DefaultDelayDays, "SETTLEMENT_DEFAULT_DELAY_DAYS")
+	e.duration(&c.Settlement.ReleaseInterval, "SETTLEMENT_RELEASE_INTERVAL")
+
+	e.str(&c.Risk.Source, "RISK_SOURCE")
+	e.duration(&c.Risk.ReloadInterval, "RISK_RELOAD_INTERVAL")
+	e.float(&c.Risk.AmountThreshold, "RISK_AMOUNT_THRESHOLD", "PROCESSOR_PENDING_THRESHOLD")
+
+	e.duration(&c.Subscription.BillingInterval, "SUBSCRIPTION_BILLING_INTERVAL")
+	e.durations(&c.Subscription.RetryDelays, "SUBSCRIPTION_RETRY_DELAYS")
+
+	e.duration(&c.Dispute.ExpiryInterval, "DISPUTE_EXPIRY_INTERVAL")
+
+	e.int(&c.Batch.MaxItems, "BATCH_MAX_ITEMS")
+	e.int(&c.Batch.Workers, "BATCH_WORKERS")
+
+	e.bool(&c.Features.AutoMigrate, "FEATURE_AUTO_MIGRATE")
+
+	e.date(&c.API.LegacyDeprecatedAt, "API_LEGACY_DEPRECATED_AT")
+	e.date(&c.API.LegacySunset, "API_LEGACY_SUNSET")
+
+	e.str(&c.Admin.APIKey, "ADMIN_API_KEY")
+
+	return errors.Join(e.errs...)
+}
+
+// Validate checks the configuration and reports every problem at once.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("config: "+format, args...))
+	}
+
+	if c.DB.Host == "" {
+		fail("db.host is required")
+	}
+	if !isPort(c.DB.Port) {
+		fail("db.port %q is not a valid port", c.DB.Port)
+	}
+	if c.DB.User == "" {
+		fail("db.user is required")
+	}
+	if c.DB.Password == "" {
+		fail("db.password is required")
+	}
+	if c.DB.Name == "" {
+		fail("db.name is required")
+	}
+	switch c.DB.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		fail("db.ssl_mode %q is not supported", c.DB.SSLMode)
+	}
+	if c.DB.MaxOpenConns < 1 {
+		fail("db.max_open_conns must be at least 1")
+	}
+	if c.DB.MaxIdleConns < 0 || c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		fail("db.max_idle_conns must be between 0 and db.max_open_conns")
+	}
+	if c.DB.ConnMaxLifetime < 0 || c.DB.ConnMaxIdleTime < 0 {
+		fail("db connection lifetimes must not be negative")
+	}
+	if c.DB.ConnectAttempts < 1 {
+		fail("db.connect_attempts must be at least 1")
+	}
+	if c.DB.ConnectBackoff <= 0 {
+		fail("db.connect_backoff must be positive")
+	}
+
+	if !isPort(c.HTTP.Port) {
+		fail("http.port %q is not a valid port", c.HTTP.Port)
+	}
+	for name, d := range map[string]time.Duration{
+		"read_header_timeout": c.HTTP.ReadHeaderTimeout,
+		"read_timeout":        c.HTTP.ReadTimeout,
+		"write_timeout":       c.HTTP.WriteTimeout,
+		"idle_timeout":        c.HTTP.IdleTimeout,
+		"shutdown_timeout":    c.HTTP.ShutdownTimeout,
+	} {
+		if d <= 0 {
+			fail("http.%s must be positive", name)
+		}
+	}
+	if c.HTTP.ShutdownDelay < 0 || c.HTTP.ShutdownDelay >= c.HTTP.ShutdownTimeout {
+		fail("http.shutdown_delay must be zero or positive and shorter than http.shutdown_timeout")
+	}
+	if c.HTTP.MaxBodyBytes <= 0 {
+		fail("http.max_body_bytes must be positive")
+	}
+
+	for _, b := range c.Kafka.Brokers {
+		if !strings.Contains(b, ":") {
+			fail("kafka broker %q must be host:port", b)
+		}
+	}
+
+	if c.Processor.ApprovalRate < 0 || c.Processor.ApprovalRate > 1 {
+		fail("processor.approval_rate must be between 0 and 1")
+	}
+
+	if c.Payout.MinAmount <= 0 {
+		fail("payout.min_amount must be positive")
+	}
+	if c.Payout.ReserveRate < 0 || c.Payout.ReserveRate >= 1 {
+		fail("payout.reserve_rate must be at least 0 and below 1")
+	}
+	if c.Payout.ProcessInterval < 0 {
+		fail("payout.process_interval must not be negative")
+	}
+
+	if c.Fees.DefaultPercent < 0 || c.Fees.DefaultPercent > 100 {
+		fail("fees.default_percent must be between 0 and 100")
+	}
+	if c.Fees.DefaultFixed < 0 {
+		fail("fees.default_fixed must not be negative")
+	}
+
+	for paymentType, days := range c.Settlement.Delays {
+		if days < 0 {
+			fail(fmt.Sprintf("settlement.delays.%s must not be negative", paymentType))
+		}
+	}
+	if c.Settlement.DefaultDelayDays < 0 {
+		fail("settlement.default_delay_days must not be negative")
+	}
+	if c.Settlement.ReleaseInterval < 0 {
+		fail("settlement.release_interval must not be negative")
+	}
+
+	if c.Risk.Source != RiskSourceConfig && c.Risk.Source != RiskSourceDatabase {
+		fail("risk.source must be config or database")
+	}
+	if c.Risk.ReloadInterval < 0 {
+		fail("risk.reload_interval must not be negative")
+	}
+	if c.Risk.AmountThreshold < 0 {
+		fail("risk.amount_threshold must not be negative")
+	}
+
+	if c.Subscription.BillingInterval < 0 {
+		fail("subscription.billing_interval must not be negative")
+	}
+	for _, d := range c.Subscription.RetryDelays {
+		if d <= 0 {
+			fail("subscription.retry_delays must be positive")
+			break
+		}
+	}
+
+	if c.Dispute.ExpiryInterval < 0 {
+		fail("dispute.expiry_interval must not be negative")
+	}
+
+	if c.Batch.MaxItems <= 0 {
+		fail("batch.max_items must be positive")
+	}
+	if c.Batch.Workers <= 0 {
+		fail("batch.workers must be positive")
+	}
+
+	if c.Admin.APIKey != "" && len(c.Admin.APIKey) < 32 {
+		fail("admin.api_key must have at least 32 characters")
+	}
+
+	if !c.API.LegacySunset.IsZero() && !c.API.LegacySunset.After(c.API.LegacyDeprecatedAt) {
+		fail("api.legacy_sunset must be after api.legacy_deprecated_at")
+	}
+
+	return errors.Join(errs...)
+}
+
+// Masked returns a copy of the configuration with secrets redacted.
+func (c *Config) Masked() *Config {
+	m := *c
+	if m.DB.Password != "" {
+		m.DB.Password = "****"
+	}
+	if m.Admin.APIKey != "" {
+		m.Admin.APIKey = "****"
+	}
+	m.Kafka.Brokers = append([]string(nil), c.Kafka.Brokers...)
+	return &m
+}
+
+// YAML renders the configuration with secrets masked, for --print-config.
+func (c *Config) YAML() ([]byte, error) {
+	return yaml.Marshal(c.Masked())
+}
+
+func isPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0 && n <= 65535
+}
@@ -0,0 +1,366 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("DB_USER", "gateway")
+	t.Setenv("DB_PASSWORD", "s3cret")
+}
+
+func TestLoad_FromEnv(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_SSL_MODE", "require")
+	t.Setenv("PORT", "9090")
+	t.Setenv("HTTP_READ_TIMEOUT", "3s")
+	t.Setenv("KAFKA_BROKERS", "kafka:9092, kafka2:9092")
+	t.Setenv("FEATURE_AUTO_MIGRATE", "true")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DB.Host != "localhost" || cfg.DB.SSLMode != "require" {
+		t.Errorf("unexpected db config: %+v", cfg.DB)
+	}
+	if cfg.HTTP.Port != "9090" {
+		t.Errorf("expected port 9090 got %s", cfg.HTTP.Port)
+	}
+	if cfg.HTTP.ReadTimeout != 3*time.Second {
+		t.Errorf("expected read timeout 3s got %s", cfg.HTTP.ReadTimeout)
+	}
+	if len(cfg.Kafka.Brokers) != 2 || cfg.Kafka.Brokers[1] != "kafka2:9092" {
+		t.Errorf("unexpected brokers: %v", cfg.Kafka.Brokers)
+	}
+	if !cfg.Features.AutoMigrate {
+		t.Error("expected auto-migrate feature enabled")
+	}
+}
+
+func TestLoad_YAMLWithEnvOverride(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("HTTP_PORT", "7070")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yml := `
+db:
+  host: yaml-host
+http:
+  port: "6060"
+  write_timeout: 45s
+processor:
+  approval_rate: 0.5
+`
+	if err := os.WriteFile(path, []byte(yml), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DB.Host != "yaml-host" {
+		t.Errorf("expected host from yaml got %s", cfg.DB.Host)
+	}
+	if cfg.HTTP.Port != "7070" {
+		t.Errorf("expected env to override yaml port, got %s", cfg.HTTP.Port)
+	}
+	if cfg.HTTP.WriteTimeout != 45*time.Second {
+		t.Errorf("expected write timeout 45s got %s", cfg.HTTP.WriteTimeout)
+	}
+	if cfg.Processor.ApprovalRate != 0.5 {
+		t.Errorf("expected approval rate 0.5 got %v", cfg.Processor.ApprovalRate)
+	}
+	// Defaults survive for fields the yaml does not set
+	if cfg.HTTP.ReadTimeout != 15*time.Second {
+		t.Errorf("expected default read timeout got %s", cfg.HTTP.ReadTimeout)
+	}
+}
+
+func TestLoad_AggregatesErrors(t *testing.T) {
+	t.Setenv("DB_USER", "")
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("HTTP_PORT", "not-a-port")
+	t.Setenv("HTTP_IDLE_TIMEOUT", "forever")
+
+	_, err := Load("")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	// Parse errors are reported before validation runs
+	if !strings.Contains(err.Error(), "HTTP_IDLE_TIMEOUT") {
+		t.Errorf("expected parse error, got %v", err)
+	}
+
+	t.Setenv("HTTP_IDLE_TIMEOUT", "")
+	_, err = Load("")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"db.user", "db.password", "http.port"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error, got %v", want, err)
+		}
+	}
+}
+
+func TestConfig_YAMLMasksSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.DB.User = "gateway"
+	cfg.DB.Password = "s3cret"
+
+	out, err := cfg.YAML()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(out), "s3cret") {
+		t.Errorf("password leaked in output:\n%s", out)
+	}
+	if !strings.Contains(string(out), "****") {
+		t.Errorf("expected masked password in output:\n%s", out)
+	}
+	if cfg.DB.Password != "s3cret" {
+		t.Error("masking must not modify the original config")
+	}
+}
+
+func TestLoad_APILegacyDates(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("API_LEGACY_SUNSET", "2030-01-31")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.API.LegacySunset.Equal(time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected sunset %s", cfg.API.LegacySunset)
+	}
+
+	t.Setenv("API_LEGACY_SUNSET", "2020-01-01")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "api.legacy_sunset") {
+		t.Errorf("expected sunset before deprecation to fail, got %v", err)
+	}
+
+	t.Setenv("API_LEGACY_SUNSET", "soon")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "API_LEGACY_SUNSET") {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
+
+func TestLoad_AdminAPIKey(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("ADMIN_API_KEY", "short")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "admin.api_key") {
+		t.Fatalf("expected short admin key to fail, got %v", err)
+	}
+
+	key := strings.Repeat("k", 32)
+	t.Setenv("ADMIN_API_KEY", key)
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, _ := cfg.YAML()
+	if strings.Contains(string(out), key) {
+		t.Errorf("admin key leaked in output:\n%s", out)
+	}
+}
+
+func TestLoad_Payout(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("PAYOUT_MIN_AMOUNT", "25.5")
+	t.Setenv("PAYOUT_PROCESS_INTERVAL", "1m")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Payout.MinAmount != 25.5 || cfg.Payout.ReserveRate != 0.1 || cfg.Payout.ProcessInterval != time.Minute {
+		t.Fatalf("unexpected payout config: %+v", cfg.Payout)
+	}
+
+	t.Setenv("PAYOUT_RESERVE_RATE", "1")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "payout.reserve_rate") {
+		t.Fatalf("expected reserve rate error, got %v", err)
+	}
+}
+
+func TestLoad_Fees(t *testing.T) {
+	setRequiredEnv(t)
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Fees != (FeeConfig{}) {
+		t.Fatalf("expected no default fee, got %+v", cfg.Fees)
+	}
+
+	t.Setenv("FEE_DEFAULT_PERCENT", "3.99")
+	t.Setenv("FEE_DEFAULT_FIXED", "0.39")
+	if cfg, err = Load(""); err != nil || cfg.Fees.DefaultPercent != 3.99 || cfg.Fees.DefaultFixed != 0.39 {
+		t.Fatalf("unexpected fee config: %+v %v", cfg.Fees, err)
+	}
+
+	t.Setenv("FEE_DEFAULT_PERCENT", "120")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "fees.default_percent") {
+		t.Fatalf("expected default percent error, got %v", err)
+	}
+}
+
+func TestLoad_Settlement(t *testing.T) {
+	setRequiredEnv(t)
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Settlement.Delays["credit_card"] != 30 || cfg.Settlement.DefaultDelayDays != 0 || cfg.Settlement.ReleaseInterval != time.Minute {
+		t.Fatalf("unexpected default settlement config: %+v", cfg.Settlement)
+	}
+
+	t.Setenv("SETTLEMENT_DELAYS", "credit_card=14, pix=0")
+	t.Setenv("SETTLEMENT_DEFAULT_DELAY_DAYS", "2")
+	if cfg, err = Load(""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Settlement.Delays) != 2 || cfg.Settlement.Delays["credit_card"] != 14 || cfg.Settlement.Delays["pix"] != 0 || cfg.Settlement.DefaultDelayDays != 2 {
+		t.Fatalf("unexpected settlement config: %+v", cfg.Settlement)
+	}
+
+	t.Setenv("SETTLEMENT_DELAYS", "credit_card=-1")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "settlement.delays.credit_card") {
+		t.Fatalf("expected delay error, got %v", err)
+	}
+
+	t.Setenv("SETTLEMENT_DELAYS", "credit_card")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "SETTLEMENT_DELAYS") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
+
+func TestLoad_Subscription(t *testing.T) {
+	setRequiredEnv(t)
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Subscription.BillingInterval != time.Minute || len(cfg.Subscription.RetryDelays) != 3 {
+		t.Fatalf("unexpected default subscription config: %+v", cfg.Subscription)
+	}
+
+	t.Setenv("SUBSCRIPTION_BILLING_INTERVAL", "5m")
+	t.Setenv("SUBSCRIPTION_RETRY_DELAYS", "12h, 48h")
+	if cfg, err = Load(""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Subscription.BillingInterval != 5*time.Minute || len(cfg.Subscription.RetryDelays) != 2 || cfg.Subscription.RetryDelays[1] != 48*time.Hour {
+		t.Fatalf("unexpected subscription config: %+v", cfg.Subscription)
+	}
+
+	t.Setenv("SUBSCRIPTION_RETRY_DELAYS", "12h,-1h")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "subscription.retry_delays") {
+		t.Fatalf("expected retry delay error, got %v", err)
+	}
+
+	t.Setenv("SUBSCRIPTION_RETRY_DELAYS", "daily")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "SUBSCRIPTION_RETRY_DELAYS") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
+
+func TestLoad_Dispute(t *testing.T) {
+	setRequiredEnv(t)
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Dispute.ExpiryInterval != time.Minute {
+		t.Fatalf("unexpected default dispute config: %+v", cfg.Dispute)
+	}
+
+	t.Setenv("DISPUTE_EXPIRY_INTERVAL", "-1m")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "dispute.expiry_interval") {
+		t.Fatalf("expected expiry interval error, got %v", err)
+	}
+}
+
+func TestLoad_Batch(t *testing.T) {
+	setRequiredEnv(t)
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Batch.MaxItems != 500 || cfg.Batch.Workers != 8 {
+		t.Fatalf("unexpected default batch config: %+v", cfg.Batch)
+	}
+
+	t.Setenv("BATCH_WORKERS", "0")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "batch.workers") {
+		t.Fatalf("expected workers error, got %v", err)
+	}
+}
+
+func TestLoad_ShutdownDelay(t *testing.T) {
+	setRequiredEnv(t)
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.HTTP.ShutdownDelay != 5*time.Second {
+		t.Fatalf("unexpected default shutdown delay: %v", cfg.HTTP.ShutdownDelay)
+	}
+
+	t.Setenv("HTTP_SHUTDOWN_DELAY", "20s")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "http.shutdown_delay") {
+		t.Fatalf("expected shutdown delay error, got %v", err)
+	}
+}
+
+func TestLoad_Risk(t *testing.T) {
+	setRequiredEnv(t)
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Risk.Source != RiskSourceConfig || cfg.Risk.AmountThreshold != 10000 || cfg.Risk.ReloadInterval != 30*time.Second {
+		t.Fatalf("unexpected default risk config: %+v", cfg.Risk)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yml := `
+risk:
+  amount_threshold: 5000
+  rules:
+    - kind: card_velocity
+      max_per_hour: 5
+      action: reject
+    - kind: payment_type_block
+      payment_type: boleto
+      action: reject
+`
+	if err := os.WriteFile(path, []byte(yml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Risk.AmountThreshold != 5000 || len(cfg.Risk.Rules) != 2 || cfg.Risk.Rules[0].MaxPerHour != 5 || cfg.Risk.Rules[1].PaymentType != "boleto" {
+		t.Fatalf("unexpected risk config: %+v", cfg.Risk)
+	}
+
+	// The processor threshold is still read as the amount threshold
+	t.Setenv("PROCESSOR_PENDING_THRESHOLD", "2000")
+	if cfg, err = Load(""); err != nil || cfg.Risk.AmountThreshold != 2000 {
+		t.Fatalf("expected the legacy threshold, got %+v %v", cfg.Risk, err)
+	}
+
+	t.Setenv("RISK_SOURCE", "file")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "risk.source") {
+		t.Fatalf("expected source error, got %v", err)
+	}
+}
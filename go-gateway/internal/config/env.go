@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envReader assigns environment variables to config fields and collects
+// parse errors instead of stopping at the first one.
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) lookup(keys []string) (string, string, bool) {
+	for _, k := range keys {
+		if v, ok := os.LookupEnv(k); ok && v != "" {
+			return k, v, true
+		}
+	}
+	return "", "", false
+}
+
+func (e *envReader) fail(key string, err error) {
+	e.errs = append(e.errs, fmt.Errorf("config: invalid %s: %w", key, err))
+}
+
+func (e *envReader) str(dst *string, keys ...string) {
+	if _, v, ok := e.lookup(keys); ok {
+		*dst = v
+	}
+}
+
+func (e *envReader) list(dst *[]string, keys ...string) {
+	if _, v, ok := e.lookup(keys); ok {
+		var out []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+		*dst = out
+	}
+}
+
+// intMap parses comma separated key=value pairs (credit_card=30,pix=0) and
+// replaces dst.
+func (e *envReader) intMap(dst *map[string]int, keys ...string) {
+	if k, v, ok := e.lookup(keys); ok {
+		out := make(map[string]int)
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			key, raw, found := strings.Cut(item, "=")
+			n, err := strconv.Atoi(strings.TrimSpace(raw))
+			if !found || strings.TrimSpace(key) == "" || err != nil {
+				e.fail(k, fmt.Errorf("%q is not key=integer", item))
+				return
+			}
+			out[strings.TrimSpace(key)] = n
+		}
+		*dst = out
+	}
+}
+
+func (e *envReader) duration(dst *time.Duration, keys ...string) {
+	if k, v, ok := e.lookup(keys); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			e.fail(k, err)
+			return
+		}
+		*dst = d
+	}
+}
+
+// durations parses comma separated durations (24h,72h) and replaces dst.
+func (e *envReader) durations(dst *[]time.Duration, keys ...string) {
+	if k, v, ok := e.lookup(keys); ok {
+		var out []time.Duration
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			d, err := time.ParseDuration(item)
+			if err != nil {
+				e.fail(k, err)
+				return
+			}
+			out = append(out, d)
+		}
+		*dst = out
+	}
+}
+
+func (e *envReader) int(dst *int, keys ...string) {
+	if k, v, ok := e.lookup(keys); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			e.fail(k, err)
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envReader) int64(dst *int64, keys ...string) {
+	if k, v, ok := e.lookup(keys); ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			e.fail(k, err)
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envReader) float(dst *float64, keys ...string) {
+	if k, v, ok := e.lookup(keys); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			e.fail(k, err)
+			return
+		}
+		*dst = f
+	}
+}
+
+func (e *envReader) bool(dst *bool, keys ...string) {
+	if k, v, ok := e.lookup(keys); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			e.fail(k, err)
+			return
+		}
+		*dst = b
+	}
+}
+
+// date accepts 2006-01-02 or RFC 3339 timestamps.
+func (e *envReader) date(dst *time.Time, keys ...string) {
+	if k, v, ok := e.lookup(keys); ok {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			t, err = time.Parse(time.RFC3339, v)
+		}
+		if err != nil {
+			e.fail(k, err)
+			return
+		}
+		*dst = t.UTC()
+	}
+}
@@ -9,45 +9,99 @@ import (
 )
 
 var (
-	ErrInvalidName   = errors.New("account: invalid name")
-	ErrInvalidEmail  = errors.New("account: invalid email")
-	ErrNegativeValue = errors.New("account: amount must be positive")
+	ErrInvalidName             = errors.New("account: invalid name")
+	ErrInvalidEmail            = errors.New("account: invalid email")
+	ErrNegativeValue           = errors.New("account: amount must be positive")
+	ErrInsufficientFunds       = errors.New("account: insufficient funds")
+	ErrInsufficientPending     = errors.New("account: settlement exceeds the pending balance")
+	ErrInvalidStatusTransition = errors.New("account: invalid status transition")
+	ErrInvalidAccountStatus    = errors.New("account: invalid status")
+	ErrAccountSuspended        = errors.New("account: suspended")
+	ErrAccountClosed           = errors.New("account: closed")
 )
 
+// AccountStatus is the lifecycle state of an account. Active accounts can do
+// everything, suspended accounts can only read and closed accounts can no
+// longer authenticate. Closing is permanent.
+type AccountStatus string
+
+const (
+	AccountActive    AccountStatus = "active"
+	AccountSuspended AccountStatus = "suspended"
+	AccountClosed    AccountStatus = "closed"
+)
+
+// CanRead reports whether the account may still use its API key.
+func (s AccountStatus) CanRead() error {
+	if s == AccountClosed {
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// CanWrite reports whether the account may charge or otherwise change data.
+func (s AccountStatus) CanWrite() error {
+	switch s {
+	case AccountActive:
+		return nil
+	case AccountSuspended:
+		return ErrAccountSuspended
+	}
+	return ErrAccountClosed
+}
+
 // Account represents a client account that owns invoices and holds a balance
-// increased when invoices are approved.
+// increased when invoices are approved. Balance is available for payouts;
+// PendingBalance is approved money waiting for its settlement date.
 type Account struct {
-	ID        string
-	Name      string
-	Email     string
-	APIKey    string
-	Balance   float64
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	mu        sync.RWMutex
-}
-
-// NewAccount creates a new Account with generated IDs and timestamps.
-func NewAccount(name, email string) (*Account, error) {
-	if len(name) < 2 {
-		return nil, ErrInvalidName
-	}
-	// NOTE: keeping email validation minimal for now; can be enhanced.
-	if len(email) < 5 || !containsAt(email) {
-		return nil, ErrInvalidEmail
-	}
-	now := time.Now().UTC()
+	ID             string
+	Name           string
+	Email          string
+	APIKey         string
+	Balance        float64
+	PendingBalance float64
+	Status         AccountStatus
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	mu             sync.RWMutex
+	clock          Clock // stamps changes; nil means SystemClock
+}
+
+// NewAccount creates a new Account with generated IDs and timestamps taken
+// from clock. Every invalid field is reported in a single *ValidationError.
+func NewAccount(name, email string, clock Clock) (*Account, error) {
+	verr := &ValidationError{}
+	if !lengthBetween(name, MinNameLength, MaxNameLength) {
+		verr.Add("name", ErrInvalidName)
+	}
+	if !isValidEmail(email) {
+		verr.Add("email", ErrInvalidEmail)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	now := clock.Now()
 	return &Account{
 		ID:        uuid.New().String(),
 		Name:      name,
 		Email:     email,
 		APIKey:    uuid.New().String(),
 		Balance:   0,
+		Status:    AccountActive,
 		CreatedAt: now,
 		UpdatedAt: now,
+		clock:     clock,
 	}, nil
 }
 
+// SetClock sets the clock that stamps changes of an account loaded from
+// storage.
+func (a *Account) SetClock(c Clock) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.clock = c
+}
+
 // AddBalance increments the balance by a positive amount and updates UpdatedAt.
 func (a *Account) AddBalance(amount float64) error {
 	a.mu.Lock()
@@ -55,17 +109,135 @@ func (a *Account) AddBalance(amount float64) error {
 	if amount <= 0 {
 		return ErrNegativeValue
 	}
-	a.Balance += amount
-	a.UpdatedAt = time.Now().UTC()
+	a.Balance = roundCents(a.Balance + amount)
+	a.UpdatedAt = clockOrSystem(a.clock).Now()
+	return nil
+}
+
+// SubtractBalance decrements the balance by a positive amount. It is the
+// counterpart of AddBalance used by payouts.
+func (a *Account) SubtractBalance(amount float64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if amount <= 0 {
+		return ErrNegativeValue
+	}
+	if amount > a.Balance {
+		return ErrInsufficientFunds
+	}
+	a.Balance = roundCents(a.Balance - amount)
+	a.UpdatedAt = clockOrSystem(a.clock).Now()
+	return nil
+}
+
+// AddPending holds a positive amount until it settles.
+func (a *Account) AddPending(amount float64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if amount <= 0 {
+		return ErrNegativeValue
+	}
+	a.PendingBalance = roundCents(a.PendingBalance + amount)
+	a.UpdatedAt = clockOrSystem(a.clock).Now()
 	return nil
 }
 
-// containsAt is a small helper to avoid importing regexp now.
-func containsAt(s string) bool {
-	for i := 0; i < len(s); i++ {
-		if s[i] == '@' {
-			return true
+// Settle moves a settled amount from the pending to the available balance.
+func (a *Account) Settle(amount float64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if amount <= 0 {
+		return ErrNegativeValue
+	}
+	if amount > a.PendingBalance {
+		return ErrInsufficientPending
+	}
+	a.PendingBalance = roundCents(a.PendingBalance - amount)
+	a.Balance = roundCents(a.Balance + amount)
+	a.UpdatedAt = clockOrSystem(a.clock).Now()
+	return nil
+}
+
+// Freeze takes a disputed amount out of the available balance until the
+// dispute is resolved. Unlike a payout it may leave the balance negative:
+// the merchant owes the chargeback even if it has already withdrawn the funds.
+func (a *Account) Freeze(amount float64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if amount <= 0 {
+		return ErrNegativeValue
+	}
+	a.Balance = roundCents(a.Balance - amount)
+	a.UpdatedAt = clockOrSystem(a.clock).Now()
+	return nil
+}
+
+// Unfreeze returns the amount of a dispute won by the merchant to the
+// available balance.
+func (a *Account) Unfreeze(amount float64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if amount <= 0 {
+		return ErrNegativeValue
+	}
+	a.Balance = roundCents(a.Balance + amount)
+	a.UpdatedAt = clockOrSystem(a.clock).Now()
+	return nil
+}
+
+// AdjustBalance applies a manual credit (positive) or debit (negative). A
+// debit cannot leave the balance negative; a credit is always applied, even
+// if it only partly covers a balance already negative, e.g. after a dispute.
+func (a *Account) AdjustBalance(delta float64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if delta == 0 {
+		return ErrInvalidAdjustmentAmount
+	}
+	balance := roundCents(a.Balance + delta)
+	if delta < 0 && balance < 0 {
+		return ErrInsufficientFunds
+	}
+	a.Balance = balance
+	a.UpdatedAt = clockOrSystem(a.clock).Now()
+	return nil
+}
+
+// Suspend blocks an active account.
+func (a *Account) Suspend() error {
+	return a.transition(AccountSuspended, AccountActive)
+}
+
+// Reactivate unblocks a suspended account.
+func (a *Account) Reactivate() error {
+	return a.transition(AccountActive, AccountSuspended)
+}
+
+// Close permanently shuts down an active or suspended account.
+func (a *Account) Close() error {
+	return a.transition(AccountClosed, AccountActive, AccountSuspended)
+}
+
+// transition moves the account to status to when its current status is one
+// of from.
+func (a *Account) transition(to AccountStatus, from ...AccountStatus) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, f := range from {
+		if a.Status == f {
+			a.Status = to
+			a.UpdatedAt = clockOrSystem(a.clock).Now()
+			return nil
 		}
 	}
-	return false
+	return ErrInvalidStatusTransition
+}
+
+// ParseAccountStatus validates a status received from clients.
+func ParseAccountStatus(s string) (AccountStatus, error) {
+	switch st := AccountStatus(s); st {
+	case AccountActive, AccountSuspended, AccountClosed:
+		return st, nil
+	}
+	return "", ErrInvalidAccountStatus
 }
@@ -1,13 +1,36 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // AccountRepository defines persistence operations for Account.
 type AccountRepository interface {
 	Create(ctx context.Context, a *Account) error
 	GetByID(ctx context.Context, id string) (*Account, error)
 	GetByAPIKey(ctx context.Context, apiKey string) (*Account, error)
-	UpdateBalance(ctx context.Context, a *Account) error
+	// AddBalance atomically adds a positive amount to the available balance,
+	// so concurrent payouts, disputes and settlements are never overwritten.
+	AddBalance(ctx context.Context, accountID string, amount float64, at time.Time) error
+
+	// List returns a page of accounts matching f and the total number of
+	// matches, ordered by creation date (newest first).
+	List(ctx context.Context, f AccountFilter) ([]*Account, int, error)
+	UpdateStatus(ctx context.Context, a *Account) error
+	// AdjustBalance atomically applies adj to its account, records it and
+	// returns the updated account.
+	AdjustBalance(ctx context.Context, adj *BalanceAdjustment) (*Account, error)
+	ListAdjustments(ctx context.Context, accountID string) ([]*BalanceAdjustment, error)
+}
+
+// AccountFilter selects accounts in admin listings. Query matches name or
+// email (case-insensitive substring); an empty Status matches every status.
+type AccountFilter struct {
+	Query  string
+	Status AccountStatus
+	Limit  int
+	Offset int
 }
 
 // Domain-level errors for repository implementations.
@@ -1,9 +1,12 @@
 package domain
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestNewAccount(t *testing.T) {
-	a, err := NewAccount("John Doe", "john@example.com")
+	a, err := NewAccount("John Doe", "john@example.com", SystemClock)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -16,7 +19,7 @@ func TestNewAccount(t *testing.T) {
 }
 
 func TestAddBalance(t *testing.T) {
-	a, _ := NewAccount("Jane", "jane@example.com")
+	a, _ := NewAccount("Jane", "jane@example.com", SystemClock)
 	if err := a.AddBalance(100); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -27,3 +30,149 @@ func TestAddBalance(t *testing.T) {
 		t.Fatalf("expected error for negative amount")
 	}
 }
+
+func TestAccount_StatusTransitions(t *testing.T) {
+	a, _ := NewAccount("Jane", "jane@example.com", SystemClock)
+	if a.Status != AccountActive {
+		t.Fatalf("expected new account active, got %s", a.Status)
+	}
+	if err := a.Reactivate(); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatalf("expected invalid transition reactivating an active account, got %v", err)
+	}
+	if err := a.Suspend(); err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+	if a.Status != AccountSuspended {
+		t.Fatalf("expected suspended got %s", a.Status)
+	}
+	if err := a.Suspend(); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Fatalf("expected invalid transition suspending twice, got %v", err)
+	}
+	if err := a.Reactivate(); err != nil || a.Status != AccountActive {
+		t.Fatalf("expected reactivated, got %s %v", a.Status, err)
+	}
+	if err := a.Close(); err != nil || a.Status != AccountClosed {
+		t.Fatalf("expected closed, got %s %v", a.Status, err)
+	}
+	for name, transition := range map[string]func() error{"suspend": a.Suspend, "reactivate": a.Reactivate, "close": a.Close} {
+		if err := transition(); !errors.Is(err, ErrInvalidStatusTransition) {
+			t.Fatalf("%s: expected closed to be final, got %v", name, err)
+		}
+	}
+}
+
+func TestAccountStatus_Permissions(t *testing.T) {
+	tests := []struct {
+		status   AccountStatus
+		readErr  error
+		writeErr error
+	}{
+		{AccountActive, nil, nil},
+		{AccountSuspended, nil, ErrAccountSuspended},
+		{AccountClosed, ErrAccountClosed, ErrAccountClosed},
+	}
+	for _, tt := range tests {
+		if err := tt.status.CanRead(); !errors.Is(err, tt.readErr) {
+			t.Errorf("%s: CanRead = %v, want %v", tt.status, err, tt.readErr)
+		}
+		if err := tt.status.CanWrite(); !errors.Is(err, tt.writeErr) {
+			t.Errorf("%s: CanWrite = %v, want %v", tt.status, err, tt.writeErr)
+		}
+	}
+}
+
+func TestAccount_SubtractBalance(t *testing.T) {
+	a, _ := NewAccount("Jane", "jane@example.com", SystemClock)
+	_ = a.AddBalance(100.3)
+	if err := a.SubtractBalance(0); !errors.Is(err, ErrNegativeValue) {
+		t.Fatalf("expected ErrNegativeValue, got %v", err)
+	}
+	if err := a.SubtractBalance(100.31); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected ErrInsufficientFunds, got %v", err)
+	}
+	if err := a.SubtractBalance(0.1); err != nil || a.Balance != 100.2 {
+		t.Fatalf("expected balance 100.2, got %v %v", a.Balance, err)
+	}
+}
+
+func TestAccount_AdjustBalance(t *testing.T) {
+	a, _ := NewAccount("Jane", "jane@example.com", SystemClock)
+	if err := a.AdjustBalance(100); err != nil {
+		t.Fatalf("credit: %v", err)
+	}
+	if err := a.AdjustBalance(-40); err != nil {
+		t.Fatalf("debit: %v", err)
+	}
+	if a.Balance != 60 {
+		t.Fatalf("expected balance 60 got %v", a.Balance)
+	}
+	if err := a.AdjustBalance(-60.01); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+	if err := a.AdjustBalance(0); !errors.Is(err, ErrInvalidAdjustmentAmount) {
+		t.Fatalf("expected invalid amount, got %v", err)
+	}
+	if a.Balance != 60 {
+		t.Fatalf("failed adjustments must not change the balance, got %v", a.Balance)
+	}
+
+	// A credit may partly cover a negative balance; a debit cannot deepen it
+	a.Balance = -100
+	if err := a.AdjustBalance(30); err != nil || a.Balance != -70 {
+		t.Fatalf("expected the credit applied, got %v %v", a.Balance, err)
+	}
+	if err := a.AdjustBalance(-1); !errors.Is(err, ErrInsufficientFunds) || a.Balance != -70 {
+		t.Fatalf("expected insufficient funds, got %v %v", a.Balance, err)
+	}
+
+	// Adjustments stay in cents, like every other balance change
+	a.Balance = 0.1
+	if err := a.AdjustBalance(0.2); err != nil || a.Balance != 0.3 {
+		t.Fatalf("expected balance 0.3, got %v %v", a.Balance, err)
+	}
+	if err := a.AdjustBalance(-0.3); err != nil || a.Balance != 0 {
+		t.Fatalf("expected balance 0, got %v %v", a.Balance, err)
+	}
+}
+
+func TestParseAccountStatus(t *testing.T) {
+	if st, err := ParseAccountStatus("suspended"); err != nil || st != AccountSuspended {
+		t.Fatalf("unexpected %s %v", st, err)
+	}
+	if _, err := ParseAccountStatus("deleted"); !errors.Is(err, ErrInvalidAccountStatus) {
+		t.Fatalf("expected ErrInvalidAccountStatus got %v", err)
+	}
+}
+
+func TestAccount_PendingBalance(t *testing.T) {
+	a, _ := NewAccount("Jane", "jane@example.com", SystemClock)
+	if err := a.AddPending(0); !errors.Is(err, ErrNegativeValue) {
+		t.Fatalf("expected ErrNegativeValue, got %v", err)
+	}
+	_ = a.AddPending(100.1)
+	_ = a.AddPending(0.2)
+	if a.PendingBalance != 100.3 || a.Balance != 0 {
+		t.Fatalf("expected 100.3 pending and nothing available, got %v %v", a.PendingBalance, a.Balance)
+	}
+	if err := a.Settle(100.31); !errors.Is(err, ErrInsufficientPending) {
+		t.Fatalf("expected ErrInsufficientPending, got %v", err)
+	}
+	if err := a.Settle(100.1); err != nil || a.PendingBalance != 0.2 || a.Balance != 100.1 {
+		t.Fatalf("expected 0.2 pending and 100.1 available, got %v %v %v", a.PendingBalance, a.Balance, err)
+	}
+}
+
+func TestAccount_Freeze(t *testing.T) {
+	a, _ := NewAccount("Jane", "jane@example.com", SystemClock)
+	_ = a.AddBalance(30)
+	if err := a.Freeze(0); !errors.Is(err, ErrNegativeValue) {
+		t.Fatalf("expected ErrNegativeValue, got %v", err)
+	}
+	// A chargeback may exceed what is left after payouts
+	if err := a.Freeze(50.5); err != nil || a.Balance != -20.5 {
+		t.Fatalf("expected a -20.5 balance, got %v %v", a.Balance, err)
+	}
+	if err := a.Unfreeze(50.5); err != nil || a.Balance != 30 {
+		t.Fatalf("expected the balance back to 30, got %v %v", a.Balance, err)
+	}
+}
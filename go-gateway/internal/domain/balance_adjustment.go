@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidAdjustmentAmount = errors.New("adjustment: amount must not be zero")
+	ErrInvalidReason           = errors.New("adjustment: invalid reason")
+	ErrInvalidActor            = errors.New("adjustment: invalid actor")
+)
+
+// Limits of manual balance adjustments.
+const (
+	MinReasonLength = 3
+	MaxReasonLength = 255
+	MaxActorLength  = 100
+)
+
+// BalanceAdjustment is a manual credit or debit made by the operations team.
+// It is recorded together with the balance change for auditing.
+type BalanceAdjustment struct {
+	ID           string
+	AccountID    string
+	Amount       float64 // positive credits, negative debits
+	BalanceAfter float64
+	Reason       string
+	Actor        string
+	CreatedAt    time.Time
+}
+
+// NewBalanceAdjustment validates and builds an adjustment stamped by clock.
+// BalanceAfter is set when it is applied to the account.
+func NewBalanceAdjustment(accountID string, amount float64, reason, actor string, clock Clock) (*BalanceAdjustment, error) {
+	verr := &ValidationError{}
+	if accountID == "" {
+		verr.Add("account_id", ErrAccountIDRequired)
+	}
+	switch {
+	case amount == 0:
+		verr.Add("amount", ErrInvalidAdjustmentAmount)
+	case math.Abs(amount) > MaxInvoiceAmount:
+		verr.Add("amount", ErrAmountTooLarge)
+	case !hasAtMostTwoDecimals(amount):
+		verr.Add("amount", ErrAmountPrecision)
+	}
+	if !lengthBetween(reason, MinReasonLength, MaxReasonLength) {
+		verr.Add("reason", ErrInvalidReason)
+	}
+	if !lengthBetween(actor, 1, MaxActorLength) {
+		verr.Add("actor", ErrInvalidActor)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	return &BalanceAdjustment{
+		ID:        uuid.New().String(),
+		AccountID: accountID,
+		Amount:    amount,
+		Reason:    strings.TrimSpace(reason),
+		Actor:     strings.TrimSpace(actor),
+		CreatedAt: clock.Now(),
+	}, nil
+}
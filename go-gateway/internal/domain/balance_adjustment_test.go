@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewBalanceAdjustment(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	adj, err := NewBalanceAdjustment("acc-1", -25.5, "  chargeback fee  ", "ops@example.com", NewFakeClock(now))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if adj.ID == "" || adj.Reason != "chargeback fee" || adj.Amount != -25.5 || !adj.CreatedAt.Equal(now) {
+		t.Fatalf("unexpected adjustment: %+v", adj)
+	}
+}
+
+func TestNewBalanceAdjustment_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount float64
+		reason string
+		actor  string
+		want   error
+	}{
+		{"zero amount", 0, "manual credit", "ops", ErrInvalidAdjustmentAmount},
+		{"precision", 1.001, "manual credit", "ops", ErrAmountPrecision},
+		{"too large", -(MaxInvoiceAmount + 1), "manual credit", "ops", ErrAmountTooLarge},
+		{"short reason", 10, "ab", "ops", ErrInvalidReason},
+		{"missing actor", 10, "manual credit", " ", ErrInvalidActor},
+		{"long actor", 10, "manual credit", strings.Repeat("a", MaxActorLength+1), ErrInvalidActor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewBalanceAdjustment("acc-1", tt.amount, tt.reason, tt.actor, SystemClock)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v got %v", tt.want, err)
+			}
+		})
+	}
+}
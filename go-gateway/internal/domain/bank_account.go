@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidBankCode      = errors.New("bank account: bank code must have 3 digits")
+	ErrInvalidBranch        = errors.New("bank account: branch must have 1 to 5 digits")
+	ErrInvalidAccountNumber = errors.New("bank account: invalid account number")
+	ErrInvalidHolderName    = errors.New("bank account: invalid holder name")
+)
+
+// BankAccount is a merchant's account at a bank (COMPE code, branch and
+// number) that receives payouts.
+type BankAccount struct {
+	ID         string
+	AccountID  string
+	BankCode   string
+	Branch     string
+	Number     string // digits with an optional check digit, e.g. 12345-6
+	HolderName string
+	CreatedAt  time.Time
+}
+
+// NewBankAccount validates and builds a bank account stamped by clock. Every
+// invalid field is reported in a single *ValidationError.
+func NewBankAccount(accountID, bankCode, branch, number, holderName string, clock Clock) (*BankAccount, error) {
+	verr := &ValidationError{}
+	if accountID == "" {
+		verr.Add("account_id", ErrAccountIDRequired)
+	}
+	if !isDigits(bankCode, 3, 3) {
+		verr.Add("bank_code", ErrInvalidBankCode)
+	}
+	if !isDigits(branch, 1, 5) {
+		verr.Add("branch", ErrInvalidBranch)
+	}
+	if !isBankAccountNumber(number) {
+		verr.Add("number", ErrInvalidAccountNumber)
+	}
+	if !lengthBetween(holderName, MinNameLength, MaxNameLength) {
+		verr.Add("holder_name", ErrInvalidHolderName)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	return &BankAccount{
+		ID:         uuid.New().String(),
+		AccountID:  accountID,
+		BankCode:   bankCode,
+		Branch:     branch,
+		Number:     strings.ToUpper(number),
+		HolderName: strings.TrimSpace(holderName),
+		CreatedAt:  clock.Now(),
+	}, nil
+}
+
+// isBankAccountNumber accepts 1 to 12 digits optionally followed by a dash
+// and a check digit (0-9 or X).
+func isBankAccountNumber(s string) bool {
+	digits, check, hasCheck := strings.Cut(s, "-")
+	if !isDigits(digits, 1, 12) {
+		return false
+	}
+	if !hasCheck {
+		return true
+	}
+	return len(check) == 1 && (isDigits(check, 1, 1) || check == "X" || check == "x")
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewBankAccount(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	b, err := NewBankAccount("acc-1", "341", "0001", "12345-x", " Jane Doe ", NewFakeClock(now))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.Number != "12345-X" || b.HolderName != "Jane Doe" || b.ID == "" || !b.CreatedAt.Equal(now) {
+		t.Fatalf("unexpected bank account: %+v", b)
+	}
+
+	_, err = NewBankAccount("acc-1", "34", "000001", "12-34", "J", SystemClock)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 4 {
+		t.Fatalf("expected four field errors, got %v", err)
+	}
+	for _, want := range []error{ErrInvalidBankCode, ErrInvalidBranch, ErrInvalidAccountNumber, ErrInvalidHolderName} {
+		if !errors.Is(err, want) {
+			t.Errorf("expected %v in %v", want, err)
+		}
+	}
+}
+
+func TestIsBankAccountNumber(t *testing.T) {
+	tests := map[string]bool{
+		"1":             true,
+		"123456789012":  true,
+		"12345-6":       true,
+		"12345-X":       true,
+		"1234567890123": false,
+		"12345-":        false,
+		"12345-67":      false,
+		"-6":            false,
+		"12a45":         false,
+		"":              false,
+	}
+	for in, want := range tests {
+		if got := isBankAccountNumber(in); got != want {
+			t.Errorf("isBankAccountNumber(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
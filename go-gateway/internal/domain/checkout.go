@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidPaymentTypes   = errors.New("checkout: payment types must list 1 to 10 payment types")
+	ErrInvalidSuccessURL     = errors.New("checkout: success URL must be an absolute http(s) URL")
+	ErrInvalidCancelURL      = errors.New("checkout: cancel URL must be an absolute http(s) URL")
+	ErrInvalidExpiry         = errors.New("checkout: expiry must be in the future and at most 7 days away")
+	ErrPaymentTypeNotAllowed = errors.New("checkout: payment type is not accepted by this session")
+	ErrCheckoutExpired       = errors.New("checkout: session has expired")
+	ErrCheckoutCompleted     = errors.New("checkout: session was already paid")
+)
+
+// Checkout session limits.
+const (
+	DefaultCheckoutTTL      = 24 * time.Hour
+	MaxCheckoutTTL          = 7 * 24 * time.Hour
+	MaxCheckoutPaymentTypes = 10
+	maxPaymentTypeLength    = 50
+	maxCheckoutURLLength    = 2048
+)
+
+// CheckoutStatus is the state of a checkout session: open until paid or
+// expired. Expiry is not stored; an open session past ExpiresAt is expired.
+type CheckoutStatus string
+
+const (
+	CheckoutOpen      CheckoutStatus = "open"
+	CheckoutCompleted CheckoutStatus = "completed"
+	CheckoutExpired   CheckoutStatus = "expired"
+)
+
+// CheckoutSession is a payment link: a fixed charge a payer completes on the
+// hosted checkout, on behalf of the merchant. Its unguessable ID is the only
+// credential of the public checkout routes, so the API key is never shared.
+type CheckoutSession struct {
+	ID           string
+	AccountID    string
+	Amount       float64
+	Description  string
+	PaymentTypes []string // payment types the payer may choose from
+	SuccessURL   string
+	CancelURL    string // optional
+	Status       CheckoutStatus
+	InvoiceID    string
+	// PaymentKey is the idempotency key of the invoice paying the current
+	// claim. Paying an unlinked session again under it returns the invoice
+	// already charged, if any, instead of charging twice.
+	PaymentKey  string
+	ExpiresAt   time.Time
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// NewCheckoutSession validates and builds a session created at now. A zero
+// expiresAt expires it DefaultCheckoutTTL after now.
+func NewCheckoutSession(accountID, description string, amount float64, paymentTypes []string, successURL, cancelURL string, expiresAt, now time.Time) (*CheckoutSession, error) {
+	verr := &ValidationError{}
+	if accountID == "" {
+		verr.Add("account_id", ErrAccountIDRequired)
+	}
+	if !lengthBetween(description, MinDescriptionLength, MaxDescriptionLength) {
+		verr.Add("description", ErrInvalidDescription)
+	}
+	switch {
+	case amount <= 0:
+		verr.Add("amount", ErrInvoiceNegativeValue)
+	case amount > MaxInvoiceAmount:
+		verr.Add("amount", ErrAmountTooLarge)
+	case !hasAtMostTwoDecimals(amount):
+		verr.Add("amount", ErrAmountPrecision)
+	}
+	if !validPaymentTypes(paymentTypes) {
+		verr.Add("payment_types", ErrInvalidPaymentTypes)
+	}
+	if !isRedirectURL(successURL) {
+		verr.Add("success_url", ErrInvalidSuccessURL)
+	}
+	if cancelURL != "" && !isRedirectURL(cancelURL) {
+		verr.Add("cancel_url", ErrInvalidCancelURL)
+	}
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(DefaultCheckoutTTL)
+	} else if !expiresAt.After(now) || expiresAt.Sub(now) > MaxCheckoutTTL {
+		verr.Add("expires_at", ErrInvalidExpiry)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	return &CheckoutSession{
+		ID:           uuid.New().String(),
+		AccountID:    accountID,
+		Amount:       amount,
+		Description:  description,
+		PaymentTypes: append([]string(nil), paymentTypes...),
+		SuccessURL:   successURL,
+		CancelURL:    cancelURL,
+		Status:       CheckoutOpen,
+		ExpiresAt:    expiresAt,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, nil
+}
+
+// StatusAt returns the status of the session at now.
+func (s *CheckoutSession) StatusAt(now time.Time) CheckoutStatus {
+	if s.Status == CheckoutOpen && !now.Before(s.ExpiresAt) {
+		return CheckoutExpired
+	}
+	return s.Status
+}
+
+// Claim marks the session completed by a payment of paymentType at now. The
+// session is single-use: a completed or expired session cannot be claimed.
+// Each claim gets a new PaymentKey.
+func (s *CheckoutSession) Claim(paymentType string, now time.Time) error {
+	switch s.StatusAt(now) {
+	case CheckoutCompleted:
+		return ErrCheckoutCompleted
+	case CheckoutExpired:
+		return ErrCheckoutExpired
+	}
+	if paymentType == "" {
+		return ErrInvalidPaymentType
+	}
+	if !s.Accepts(paymentType) {
+		return ErrPaymentTypeNotAllowed
+	}
+	s.Status = CheckoutCompleted
+	s.PaymentKey = "checkout/" + s.ID + "/" + uuid.New().String()
+	s.CompletedAt = &now
+	s.UpdatedAt = now
+	return nil
+}
+
+// Unlinked reports whether a payment claimed the session but its invoice
+// was never linked to it, e.g. because the update after the charge failed.
+func (s *CheckoutSession) Unlinked() bool {
+	return s.Status == CheckoutCompleted && s.InvoiceID == "" && s.PaymentKey != ""
+}
+
+// Release reopens a claimed session whose payment did not go through, so
+// the payer can try again until it expires.
+func (s *CheckoutSession) Release(now time.Time) {
+	s.Status = CheckoutOpen
+	s.CompletedAt = nil
+	s.InvoiceID = ""
+	s.PaymentKey = ""
+	s.UpdatedAt = now
+}
+
+// Accepts reports whether the payer may pay with paymentType.
+func (s *CheckoutSession) Accepts(paymentType string) bool {
+	for _, t := range s.PaymentTypes {
+		if t == paymentType {
+			return true
+		}
+	}
+	return false
+}
+
+func validPaymentTypes(types []string) bool {
+	if len(types) == 0 || len(types) > MaxCheckoutPaymentTypes {
+		return false
+	}
+	seen := make(map[string]bool, len(types))
+	for _, t := range types {
+		if !lengthBetween(t, 1, maxPaymentTypeLength) || seen[t] {
+			return false
+		}
+		seen[t] = true
+	}
+	return true
+}
+
+// isRedirectURL accepts absolute http and https URLs the payer can be sent
+// back to.
+func isRedirectURL(raw string) bool {
+	if len(raw) > maxCheckoutURLLength {
+		return false
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
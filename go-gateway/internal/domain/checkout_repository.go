@@ -0,0 +1,22 @@
+package domain
+
+import "context"
+
+// CheckoutSessionRepository defines persistence operations for checkout
+// sessions. Merchant lookups are scoped to the owning account; the public
+// checkout looks sessions up by ID alone.
+type CheckoutSessionRepository interface {
+	Create(ctx context.Context, s *CheckoutSession) error
+	// GetByID returns a session of any account.
+	GetByID(ctx context.Context, id string) (*CheckoutSession, error)
+	GetForAccount(ctx context.Context, accountID, id string) (*CheckoutSession, error)
+	// Claim persists a session claimed by CheckoutSession.Claim if it is
+	// still open and not expired in storage, so a single payment completes
+	// it. Otherwise it returns ErrCheckoutCompleted.
+	Claim(ctx context.Context, s *CheckoutSession) error
+	// Update persists the status, invoice and payment key of s.
+	Update(ctx context.Context, s *CheckoutSession) error
+}
+
+// Domain-level errors for repository implementations.
+var ErrCheckoutSessionNotFound = Err("checkout: session not found")
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewCheckoutSession_Validation(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s, err := NewCheckoutSession("acc-1", "Order #42", 99.9, []string{"pix", "credit_card"}, "https://shop.example.com/ok", "", time.Time{}, now)
+	if err != nil {
+		t.Fatalf("expected a valid session, got %v", err)
+	}
+	if s.Status != CheckoutOpen || !s.ExpiresAt.Equal(now.Add(DefaultCheckoutTTL)) {
+		t.Fatalf("unexpected session %+v", s)
+	}
+
+	_, err = NewCheckoutSession("acc-1", "O", 0, []string{"pix", "pix"}, "ftp://shop", "/cancel", now.Add(8*24*time.Hour), now)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 6 {
+		t.Fatalf("expected 6 field errors, got %v", err)
+	}
+	if _, err := NewCheckoutSession("acc-1", "Order", 10, nil, "https://shop.example.com", "", now.Add(-time.Minute), now); !errors.Is(err, ErrInvalidPaymentTypes) || !errors.Is(err, ErrInvalidExpiry) {
+		t.Fatalf("expected payment types and expiry errors, got %v", err)
+	}
+}
+
+func TestCheckoutSession_Claim(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s, _ := NewCheckoutSession("acc-1", "Order #42", 99.9, []string{"pix"}, "https://shop.example.com/ok", "", now.Add(time.Hour), now)
+
+	if err := s.Claim("credit_card", now); !errors.Is(err, ErrPaymentTypeNotAllowed) {
+		t.Fatalf("expected payment type not allowed, got %v", err)
+	}
+	if err := s.Claim("pix", now); err != nil || s.Status != CheckoutCompleted || s.CompletedAt == nil || s.PaymentKey == "" {
+		t.Fatalf("expected the session completed, got %v %+v", err, s)
+	}
+	if !s.Unlinked() {
+		t.Fatal("expected the claim unlinked until its invoice is set")
+	}
+	if err := s.Claim("pix", now); !errors.Is(err, ErrCheckoutCompleted) {
+		t.Fatalf("expected a single use, got %v", err)
+	}
+
+	first := s.PaymentKey
+	s.Release(now)
+	if s.Status != CheckoutOpen || s.CompletedAt != nil || s.PaymentKey != "" || s.Unlinked() {
+		t.Fatalf("expected the session reopened, got %+v", s)
+	}
+	// Another attempt at the same instant is another charge
+	if err := s.Claim("pix", now); err != nil || s.PaymentKey == first {
+		t.Fatalf("expected a new payment key, got %q %v", s.PaymentKey, err)
+	}
+	s.Release(now)
+	later := now.Add(time.Hour)
+	if s.StatusAt(later) != CheckoutExpired {
+		t.Fatalf("expected expired at %v", later)
+	}
+	if err := s.Claim("pix", later); !errors.Is(err, ErrCheckoutExpired) {
+		t.Fatalf("expected expired, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	if !c.Now().Equal(start) {
+		t.Fatalf("expected %v, got %v", start, c.Now())
+	}
+	c.Advance(90 * time.Minute)
+	if want := start.Add(90 * time.Minute); !c.Now().Equal(want) {
+		t.Fatalf("expected %v after advance, got %v", want, c.Now())
+	}
+	later := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	c.Set(later)
+	if !c.Now().Equal(later) {
+		t.Fatalf("expected %v after set, got %v", later, c.Now())
+	}
+}
+
+func TestSystemClock_IsUTC(t *testing.T) {
+	if loc := SystemClock.Now().Location(); loc != time.UTC {
+		t.Fatalf("expected UTC, got %v", loc)
+	}
+}
+
+func TestClock_StampsAccountsAndInvoices(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+
+	a, _ := NewAccount("Jane", "jane@example.com", c)
+	if !a.CreatedAt.Equal(start) || !a.UpdatedAt.Equal(start) {
+		t.Fatalf("expected account stamped at %v, got %v/%v", start, a.CreatedAt, a.UpdatedAt)
+	}
+	c.Advance(time.Hour)
+	_ = a.AddBalance(10)
+	if want := start.Add(time.Hour); !a.UpdatedAt.Equal(want) {
+		t.Fatalf("expected balance change at %v, got %v", want, a.UpdatedAt)
+	}
+
+	p := NewTestInvoiceProcessor()
+	p.SetClock(c)
+	i, _ := NewInvoiceWithProcessor("acc-1", "Test", "credit_card", 100, "1234", p, c)
+	if !i.CreatedAt.Equal(start.Add(time.Hour)) {
+		t.Fatalf("expected invoice created at %v, got %v", start.Add(time.Hour), i.CreatedAt)
+	}
+	c.Advance(time.Minute)
+	_ = i.Process()
+	if want := start.Add(time.Hour + time.Minute); !i.UpdatedAt.Equal(want) {
+		t.Fatalf("expected invoice processed at %v, got %v", want, i.UpdatedAt)
+	}
+	if s := NewSettlement(i, i.UpdatedAt.AddDate(0, 0, 30)); !s.CreatedAt.Equal(i.UpdatedAt) {
+		t.Fatalf("expected settlement created at %v, got %v", i.UpdatedAt, s.CreatedAt)
+	}
+}
+
+func TestDefaultInvoiceProcessor_UsesClock(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := NewFakeClock(at)
+	i, _ := NewInvoice("acc-1", "Test", "credit_card", 100, "1234", c)
+	if err := i.Process(); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if !i.UpdatedAt.Equal(at) {
+		t.Fatalf("expected processed at %v, got %v", at, i.UpdatedAt)
+	}
+}
@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCustomerName    = errors.New("customer: name must have between 2 and 100 characters")
+	ErrInvalidCustomerEmail   = errors.New("customer: invalid email")
+	ErrInvalidDocument        = errors.New("customer: document must be a valid CPF")
+	ErrCustomerExists         = errors.New("customer: document already registered for this account")
+	ErrInvalidSavedCardToken  = errors.New("customer: card token must have between 8 and 255 characters")
+	ErrInvalidSavedCardDigits = errors.New("customer: card last digits must be exactly 4 digits")
+	ErrCustomerRequired       = errors.New("invoice: customer ID is required to charge a saved card")
+	ErrUnknownCustomer        = errors.New("invoice: customer does not exist for this account")
+	ErrUnknownSavedCard       = errors.New("invoice: saved card does not exist for this customer")
+	ErrSavedCardPaymentType   = errors.New("invoice: saved cards can only be charged as credit_card")
+	ErrSavedCardMismatch      = errors.New("invoice: card last digits do not match the saved card")
+)
+
+// Customer is a payer of a merchant account, identified by its CPF.
+type Customer struct {
+	ID        string
+	AccountID string
+	Name      string
+	Email     string
+	Document  string // CPF, digits only
+	CreatedAt time.Time
+}
+
+// NewCustomer validates and builds a customer created at now. The document
+// may be formatted (123.456.789-09); it is stored as digits. Every invalid
+// field is reported in a single *ValidationError.
+func NewCustomer(accountID, name, email, document string, now time.Time) (*Customer, error) {
+	verr := &ValidationError{}
+	if accountID == "" {
+		verr.Add("account_id", ErrAccountIDRequired)
+	}
+	if !lengthBetween(name, MinNameLength, MaxNameLength) {
+		verr.Add("name", ErrInvalidCustomerName)
+	}
+	if !isValidEmail(email) {
+		verr.Add("email", ErrInvalidCustomerEmail)
+	}
+	cpf, ok := normalizeCPF(document)
+	if !ok {
+		verr.Add("document", ErrInvalidDocument)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	return &Customer{
+		ID:        uuid.New().String(),
+		AccountID: accountID,
+		Name:      strings.TrimSpace(name),
+		Email:     email,
+		Document:  cpf,
+		CreatedAt: now,
+	}, nil
+}
+
+// SavedCard is a card a customer keeps on file. Only the token issued by the
+// card vault is stored, never the card number.
+type SavedCard struct {
+	ID             string
+	AccountID      string
+	CustomerID     string
+	CardToken      string
+	CardLastDigits string
+	CreatedAt      time.Time
+}
+
+// NewSavedCard validates and builds a card of customer saved at now.
+func NewSavedCard(customer *Customer, cardToken, cardLastDigits string, now time.Time) (*SavedCard, error) {
+	verr := &ValidationError{}
+	if !lengthBetween(cardToken, 8, 255) {
+		verr.Add("card_token", ErrInvalidSavedCardToken)
+	}
+	if !isCardLastDigits(cardLastDigits) {
+		verr.Add("card_last_digits", ErrInvalidSavedCardDigits)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	return &SavedCard{
+		ID:             uuid.New().String(),
+		AccountID:      customer.AccountID,
+		CustomerID:     customer.ID,
+		CardToken:      cardToken,
+		CardLastDigits: cardLastDigits,
+		CreatedAt:      now,
+	}, nil
+}
+
+// normalizeCPF strips the punctuation of a CPF and checks its two check
+// digits. A single digit repeated eleven times passes the check but is not a
+// real CPF, so it is rejected.
+func normalizeCPF(s string) (string, bool) {
+	digits := strings.NewReplacer(".", "", "-", "").Replace(strings.TrimSpace(s))
+	if !isDigits(digits, 11, 11) || strings.Count(digits, digits[:1]) == 11 {
+		return "", false
+	}
+	// The first check digit weighs the 9 digits before it from 10 down to 2,
+	// the second the 10 before it from 11 down to 2
+	for n := 9; n <= 10; n++ {
+		sum := 0
+		for k := 0; k < n; k++ {
+			sum += int(digits[k]-'0') * (n + 1 - k)
+		}
+		check := sum * 10 % 11 % 10
+		if int(digits[n]-'0') != check {
+			return "", false
+		}
+	}
+	return digits, true
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "context"
+
+// CustomerRepository defines persistence operations for customers and their
+// saved cards. Every lookup is scoped to the owning account.
+type CustomerRepository interface {
+	// Create returns ErrCustomerExists when the account already has a
+	// customer with the same document.
+	Create(ctx context.Context, c *Customer) error
+	GetByID(ctx context.Context, accountID, id string) (*Customer, error)
+	// ListByAccount returns the customers of an account, newest first.
+	ListByAccount(ctx context.Context, accountID string) ([]*Customer, error)
+
+	AddCard(ctx context.Context, card *SavedCard) error
+	GetCard(ctx context.Context, accountID, customerID, id string) (*SavedCard, error)
+	// ListCards returns the saved cards of a customer, newest first.
+	ListCards(ctx context.Context, accountID, customerID string) ([]*SavedCard, error)
+	// DeleteCard forgets a saved card. Invoices charged to it keep their
+	// card last digits.
+	DeleteCard(ctx context.Context, accountID, customerID, id string) error
+}
+
+// Domain-level errors for repository implementations.
+var (
+	ErrCustomerNotFound  = Err("customer: not found")
+	ErrSavedCardNotFound = Err("customer: saved card not found")
+)
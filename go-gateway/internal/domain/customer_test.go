@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewCustomer(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		document string
+		want     string
+		err      error
+	}{
+		{"formatted CPF", "529.982.247-25", "52998224725", nil},
+		{"digits only", "52998224725", "52998224725", nil},
+		{"check digit zero", "111.444.777-35", "11144477735", nil},
+		{"wrong first check digit", "529.982.247-35", "", ErrInvalidDocument},
+		{"wrong second check digit", "529.982.247-26", "", ErrInvalidDocument},
+		{"repeated digit", "111.111.111-11", "", ErrInvalidDocument},
+		{"too short", "5299822472", "", ErrInvalidDocument},
+		{"letters", "529.982.247-2X", "", ErrInvalidDocument},
+		{"empty", "", "", ErrInvalidDocument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCustomer("acc-1", "Maria Silva", "maria@example.com", tt.document, now)
+			if tt.err != nil {
+				var verr *ValidationError
+				if !errors.As(err, &verr) || !errors.Is(err, tt.err) {
+					t.Fatalf("expected a validation error with %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c.Document != tt.want || c.AccountID != "acc-1" || c.ID == "" || !c.CreatedAt.Equal(now) {
+				t.Fatalf("unexpected customer %+v", c)
+			}
+		})
+	}
+}
+
+func TestNewCustomer_ReportsEveryField(t *testing.T) {
+	_, err := NewCustomer("", "M", "not-an-email", "123", time.Now())
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 4 {
+		t.Fatalf("expected 4 invalid fields, got %v", err)
+	}
+}
+
+func TestNewSavedCard(t *testing.T) {
+	now := time.Now().UTC()
+	c, _ := NewCustomer("acc-1", "Maria Silva", "maria@example.com", "52998224725", now)
+
+	card, err := NewSavedCard(c, "tok_12345678", "4242", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if card.CustomerID != c.ID || card.AccountID != "acc-1" {
+		t.Fatalf("expected the card to belong to the customer, got %+v", card)
+	}
+
+	_, err = NewSavedCard(c, "tok", "42", now)
+	if !errors.Is(err, ErrInvalidSavedCardToken) || !errors.Is(err, ErrInvalidSavedCardDigits) {
+		t.Fatalf("expected token and digits errors, got %v", err)
+	}
+}
+
+func TestInvoice_SetCustomer(t *testing.T) {
+	now := time.Now().UTC()
+	c, _ := NewCustomer("acc-1", "Maria Silva", "maria@example.com", "52998224725", now)
+	card, _ := NewSavedCard(c, "tok_12345678", "4242", now)
+	other, _ := NewCustomer("acc-1", "João Souza", "joao@example.com", "11144477735", now)
+
+	inv, _ := NewInvoice("acc-1", "Order 42", "credit_card", 50, "", NewFakeClock(now))
+	if err := inv.SetCustomer(c, card); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inv.CustomerID != c.ID || inv.SavedCardID != card.ID || inv.CardLastDigits != "4242" {
+		t.Fatalf("expected the saved card to be charged, got %+v", inv)
+	}
+
+	pix, _ := NewInvoice("acc-1", "Order 43", "pix", 50, "", NewFakeClock(now))
+	if err := pix.SetCustomer(c, nil); err != nil || pix.CustomerID != c.ID || pix.SavedCardID != "" {
+		t.Fatalf("expected a customer without card, got %+v %v", pix, err)
+	}
+	if err := pix.SetCustomer(c, card); !errors.Is(err, ErrSavedCardPaymentType) {
+		t.Fatalf("expected ErrSavedCardPaymentType, got %v", err)
+	}
+
+	typed, _ := NewInvoice("acc-1", "Order 44", "credit_card", 50, "1111", NewFakeClock(now))
+	if err := typed.SetCustomer(c, card); !errors.Is(err, ErrSavedCardMismatch) {
+		t.Fatalf("expected ErrSavedCardMismatch, got %v", err)
+	}
+	if err := typed.SetCustomer(other, card); !errors.Is(err, ErrUnknownSavedCard) {
+		t.Fatalf("expected ErrUnknownSavedCard, got %v", err)
+	}
+
+	foreign, _ := NewInvoice("acc-2", "Order 45", "credit_card", 50, "", NewFakeClock(now))
+	if err := foreign.SetCustomer(c, nil); !errors.Is(err, ErrUnknownCustomer) {
+		t.Fatalf("expected ErrUnknownCustomer, got %v", err)
+	}
+}
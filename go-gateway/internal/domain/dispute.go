@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDisputeRequiresApproval  = errors.New("dispute: only approved invoices can be disputed")
+	ErrInvalidDisputeAmount     = errors.New("dispute: amount must be positive, not above the invoice total and have at most 2 decimal places")
+	ErrInvalidDisputeReason     = errors.New("dispute: reason must be fraudulent, duplicate, product_not_received, product_unacceptable, unrecognized or general")
+	ErrInvalidEvidence          = errors.New("dispute: evidence must have between 10 and 5000 characters")
+	ErrEvidenceDeadlinePassed   = errors.New("dispute: the deadline to submit evidence has passed")
+	ErrInvalidDisputeOutcome    = errors.New("dispute: outcome must be won or lost")
+	ErrInvalidDisputeTransition = errors.New("dispute: invalid status transition")
+	ErrDisputeExists            = errors.New("dispute: invoice already disputed")
+)
+
+// DisputeResponseDays is how long a merchant has to submit evidence after a
+// dispute is opened. Disputes without evidence by then are lost.
+const DisputeResponseDays = 7
+
+// DisputeReason is why the cardholder disputes a charge.
+type DisputeReason string
+
+const (
+	DisputeFraudulent          DisputeReason = "fraudulent"
+	DisputeDuplicate           DisputeReason = "duplicate"
+	DisputeProductNotReceived  DisputeReason = "product_not_received"
+	DisputeProductUnacceptable DisputeReason = "product_unacceptable"
+	DisputeUnrecognized        DisputeReason = "unrecognized"
+	DisputeGeneral             DisputeReason = "general"
+)
+
+func (r DisputeReason) valid() bool {
+	switch r {
+	case DisputeFraudulent, DisputeDuplicate, DisputeProductNotReceived, DisputeProductUnacceptable, DisputeUnrecognized, DisputeGeneral:
+		return true
+	}
+	return false
+}
+
+// DisputeStatus is the lifecycle state of a dispute:
+// needs_response → under_review → won | lost. A dispute without evidence by
+// its deadline is lost.
+type DisputeStatus string
+
+const (
+	DisputeNeedsResponse DisputeStatus = "needs_response"
+	DisputeUnderReview   DisputeStatus = "under_review"
+	DisputeWon           DisputeStatus = "won"
+	DisputeLost          DisputeStatus = "lost"
+)
+
+// Open reports whether the dispute still waits for a resolution.
+func (s DisputeStatus) Open() bool {
+	return s == DisputeNeedsResponse || s == DisputeUnderReview
+}
+
+// Dispute is a chargeback requested by the cardholder on an approved
+// invoice. Its amount is frozen from the available balance of the account
+// while it is open; a won dispute releases it and a lost one keeps it
+// debited.
+type Dispute struct {
+	ID                  string
+	AccountID           string
+	InvoiceID           string
+	Amount              float64
+	Reason              DisputeReason
+	Status              DisputeStatus
+	Evidence            string
+	EvidenceDueBy       time.Time
+	EvidenceSubmittedAt *time.Time
+	ResolvedAt          *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// NewDispute opens a dispute of amount on an approved invoice at now. The
+// merchant has DisputeResponseDays to submit evidence.
+func NewDispute(invoice *Invoice, amount float64, reason DisputeReason, now time.Time) (*Dispute, error) {
+	if invoice.Status != StatusApproved {
+		return nil, ErrDisputeRequiresApproval
+	}
+	verr := &ValidationError{}
+	if amount <= 0 || amount > invoice.TotalAmount || !hasAtMostTwoDecimals(amount) {
+		verr.Add("amount", ErrInvalidDisputeAmount)
+	}
+	if !reason.valid() {
+		verr.Add("reason", ErrInvalidDisputeReason)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	return &Dispute{
+		ID:            uuid.New().String(),
+		AccountID:     invoice.AccountID,
+		InvoiceID:     invoice.ID,
+		Amount:        amount,
+		Reason:        reason,
+		Status:        DisputeNeedsResponse,
+		EvidenceDueBy: now.AddDate(0, 0, DisputeResponseDays),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}, nil
+}
+
+// SubmitEvidence records the merchant's defense and sends the dispute to
+// review. Evidence is accepted once and only until EvidenceDueBy.
+func (d *Dispute) SubmitEvidence(evidence string, now time.Time) error {
+	if d.Status != DisputeNeedsResponse {
+		return ErrInvalidDisputeTransition
+	}
+	if now.After(d.EvidenceDueBy) {
+		return ErrEvidenceDeadlinePassed
+	}
+	if !lengthBetween(evidence, 10, 5000) {
+		verr := &ValidationError{}
+		verr.Add("evidence", ErrInvalidEvidence)
+		return verr
+	}
+	d.Evidence = evidence
+	d.EvidenceSubmittedAt = &now
+	d.Status = DisputeUnderReview
+	d.UpdatedAt = now
+	return nil
+}
+
+// Overdue reports whether the deadline to submit evidence passed at now
+// without a response.
+func (d *Dispute) Overdue(now time.Time) bool {
+	return d.Status == DisputeNeedsResponse && now.After(d.EvidenceDueBy)
+}
+
+// Resolve closes an open dispute as won or lost.
+func (d *Dispute) Resolve(outcome DisputeStatus, now time.Time) error {
+	if outcome != DisputeWon && outcome != DisputeLost {
+		return ErrInvalidDisputeOutcome
+	}
+	if !d.Status.Open() {
+		return ErrInvalidDisputeTransition
+	}
+	d.Status = outcome
+	d.ResolvedAt = &now
+	d.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// DisputeRepository defines persistence operations for disputes. Merchant
+// lookups are scoped to the owning account.
+type DisputeRepository interface {
+	// Open stores d and freezes its amount from the available balance of its
+	// account in one transaction. It returns ErrDisputeExists when the
+	// invoice already has a dispute.
+	Open(ctx context.Context, d *Dispute) error
+	GetByID(ctx context.Context, id string) (*Dispute, error)
+	GetByIDForAccount(ctx context.Context, accountID, id string) (*Dispute, error)
+	// ListByAccount returns the disputes of an account, newest first.
+	ListByAccount(ctx context.Context, accountID string) ([]*Dispute, error)
+	// ListOverdue returns up to limit disputes still waiting for evidence
+	// after their deadline, oldest deadline first.
+	ListOverdue(ctx context.Context, now time.Time, limit int) ([]*Dispute, error)
+	// SubmitEvidence persists the evidence of d if the dispute still waits
+	// for it; otherwise it returns ErrInvalidDisputeTransition.
+	SubmitEvidence(ctx context.Context, d *Dispute) error
+	// Resolve persists the outcome of d if the dispute is still open, and
+	// releases its amount to the available balance when the merchant won, in
+	// one transaction. Otherwise it returns ErrInvalidDisputeTransition.
+	Resolve(ctx context.Context, d *Dispute) error
+}
+
+// Domain-level errors for repository implementations.
+var (
+	ErrDisputeNotFound = Err("dispute: not found")
+)
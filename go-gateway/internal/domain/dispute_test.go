@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func approvedInvoice() *Invoice {
+	return &Invoice{ID: "inv-1", AccountID: "acc-1", Amount: 100, TotalAmount: 100, Status: StatusApproved}
+}
+
+func TestNewDispute_Validation(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	pending := approvedInvoice()
+	pending.Status = StatusPending
+	if _, err := NewDispute(pending, 10, DisputeFraudulent, now); !errors.Is(err, ErrDisputeRequiresApproval) {
+		t.Fatalf("expected ErrDisputeRequiresApproval, got %v", err)
+	}
+
+	_, err := NewDispute(approvedInvoice(), 100.01, "changed_mind", now)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 2 {
+		t.Fatalf("expected 2 field errors, got %v", err)
+	}
+
+	d, err := NewDispute(approvedInvoice(), 100, DisputeFraudulent, now)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if d.Status != DisputeNeedsResponse || d.AccountID != "acc-1" || !d.EvidenceDueBy.Equal(now.AddDate(0, 0, 7)) {
+		t.Fatalf("unexpected dispute %+v", d)
+	}
+}
+
+func TestDispute_SubmitEvidence(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	d, _ := NewDispute(approvedInvoice(), 50, DisputeProductNotReceived, now)
+
+	var verr *ValidationError
+	if err := d.SubmitEvidence("short", now); !errors.As(err, &verr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if err := d.SubmitEvidence(strings.Repeat("x", 20), d.EvidenceDueBy.Add(time.Second)); !errors.Is(err, ErrEvidenceDeadlinePassed) {
+		t.Fatalf("expected ErrEvidenceDeadlinePassed, got %v", err)
+	}
+	if !d.Overdue(d.EvidenceDueBy.Add(time.Second)) {
+		t.Fatal("expected an overdue dispute after its deadline")
+	}
+
+	if err := d.SubmitEvidence("tracking code BR123456789", d.EvidenceDueBy); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if d.Status != DisputeUnderReview || d.EvidenceSubmittedAt == nil || d.Overdue(d.EvidenceDueBy.Add(time.Hour)) {
+		t.Fatalf("unexpected dispute %+v", d)
+	}
+	if err := d.SubmitEvidence("tracking code BR123456789", now); !errors.Is(err, ErrInvalidDisputeTransition) {
+		t.Fatalf("expected ErrInvalidDisputeTransition, got %v", err)
+	}
+}
+
+func TestDispute_Resolve(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	d, _ := NewDispute(approvedInvoice(), 50, DisputeGeneral, now)
+
+	if err := d.Resolve(DisputeUnderReview, now); !errors.Is(err, ErrInvalidDisputeOutcome) {
+		t.Fatalf("expected ErrInvalidDisputeOutcome, got %v", err)
+	}
+	if err := d.Resolve(DisputeWon, now); err != nil || d.Status != DisputeWon || d.ResolvedAt == nil {
+		t.Fatalf("expected a won dispute, got %+v %v", d, err)
+	}
+	if err := d.Resolve(DisputeLost, now); !errors.Is(err, ErrInvalidDisputeTransition) {
+		t.Fatalf("expected ErrInvalidDisputeTransition, got %v", err)
+	}
+}
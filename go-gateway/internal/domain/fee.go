@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidFeePercent   = errors.New("fee: percent must be between 0 and 100 with at most 2 decimal places")
+	ErrInvalidFeeFixed     = errors.New("fee: fixed amount must be zero or positive with at most 2 decimal places")
+	ErrInvalidFeePeriod    = errors.New("fee: period end must be after its start")
+	ErrFeeRequiresApproval = errors.New("invoice: fees apply only to approved invoices")
+)
+
+// MaxPaymentTypeLength matches the invoices.payment_type column.
+const MaxPaymentTypeLength = 50
+
+// FeePlan is what the platform charges an account for each approved invoice
+// of a payment type: a percentage of the gross amount plus a fixed amount.
+type FeePlan struct {
+	AccountID   string
+	PaymentType string
+	Percent     float64 // 2.99 means 2.99% of the gross amount
+	Fixed       float64
+	UpdatedAt   time.Time
+}
+
+// NewFeePlan validates and builds the fee plan of an account for a payment
+// type, stamped by clock.
+func NewFeePlan(accountID, paymentType string, percent, fixed float64, clock Clock) (*FeePlan, error) {
+	verr := &ValidationError{}
+	if accountID == "" {
+		verr.Add("account_id", ErrAccountIDRequired)
+	}
+	if !lengthBetween(paymentType, 1, MaxPaymentTypeLength) {
+		verr.Add("payment_type", ErrInvalidPaymentType)
+	}
+	if percent < 0 || percent > 100 || !hasAtMostTwoDecimals(percent) {
+		verr.Add("percent", ErrInvalidFeePercent)
+	}
+	if fixed < 0 || fixed > MaxInvoiceAmount || !hasAtMostTwoDecimals(fixed) {
+		verr.Add("fixed", ErrInvalidFeeFixed)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	return &FeePlan{
+		AccountID:   accountID,
+		PaymentType: paymentType,
+		Percent:     percent,
+		Fixed:       fixed,
+		UpdatedAt:   clock.Now(),
+	}, nil
+}
+
+// Fee returns the fee charged on a gross amount, rounded to the cent. It
+// never exceeds the gross amount.
+func (p FeePlan) Fee(gross float64) float64 {
+	fee := roundCents(gross*p.Percent/100 + p.Fixed)
+	if fee > gross {
+		return gross
+	}
+	return fee
+}
+
+// InvoiceFee is the platform revenue of an approved invoice, recorded apart
+// from the merchant balance for reporting.
+type InvoiceFee struct {
+	ID          string
+	InvoiceID   string
+	AccountID   string
+	PaymentType string
+	Gross       float64
+	Fee         float64
+	CreatedAt   time.Time
+}
+
+// NewInvoiceFee records the fee applied to an invoice at the time of clock.
+// Gross is the total the fee was charged on, installment interest included.
+func NewInvoiceFee(i *Invoice, clock Clock) *InvoiceFee {
+	return &InvoiceFee{
+		ID:          uuid.New().String(),
+		InvoiceID:   i.ID,
+		AccountID:   i.AccountID,
+		PaymentType: i.PaymentType,
+		Gross:       i.TotalAmount,
+		Fee:         i.Fee,
+		CreatedAt:   clock.Now(),
+	}
+}
+
+// RevenueLine sums the fees collected on one payment type.
+type RevenueLine struct {
+	PaymentType string
+	Invoices    int
+	Gross       float64
+	Fees        float64
+}
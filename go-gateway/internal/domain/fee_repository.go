@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// FeeRepository stores the fee plans of the accounts and the fees collected.
+type FeeRepository interface {
+	// GetPlan returns the plan of an account for a payment type, or
+	// ErrFeePlanNotFound when the account uses the default plan.
+	GetPlan(ctx context.Context, accountID, paymentType string) (*FeePlan, error)
+	ListPlans(ctx context.Context, accountID string) ([]*FeePlan, error)
+	// SavePlan creates or replaces the plan of an account for a payment type.
+	SavePlan(ctx context.Context, p *FeePlan) error
+	Record(ctx context.Context, f *InvoiceFee) error
+	// Revenue sums the fees recorded in [from, to) per payment type.
+	Revenue(ctx context.Context, from, to time.Time) ([]RevenueLine, error)
+}
+
+var (
+	ErrFeePlanNotFound = Err("fee: plan not found")
+)
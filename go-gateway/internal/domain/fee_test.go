@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewFeePlan(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	p, err := NewFeePlan("acc-1", "pix", 0.99, 0, NewFakeClock(now))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.AccountID != "acc-1" || p.PaymentType != "pix" || p.Percent != 0.99 || !p.UpdatedAt.Equal(now) {
+		t.Fatalf("unexpected plan %+v", p)
+	}
+
+	_, err = NewFeePlan("", "", 100.01, -1, SystemClock)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 4 {
+		t.Fatalf("expected four field errors, got %v", err)
+	}
+	for _, want := range []error{ErrAccountIDRequired, ErrInvalidPaymentType, ErrInvalidFeePercent, ErrInvalidFeeFixed} {
+		if !errors.Is(err, want) {
+			t.Errorf("expected %v in %v", want, err)
+		}
+	}
+	if _, err := NewFeePlan("acc-1", "pix", 1.999, 0.001, SystemClock); !errors.Is(err, ErrInvalidFeePercent) || !errors.Is(err, ErrInvalidFeeFixed) {
+		t.Fatalf("expected precision errors, got %v", err)
+	}
+}
+
+func TestFeePlan_Fee(t *testing.T) {
+	tests := []struct {
+		plan  FeePlan
+		gross float64
+		want  float64
+	}{
+		{FeePlan{}, 100, 0},
+		{FeePlan{Percent: 3.99, Fixed: 0.39}, 100, 4.38},
+		{FeePlan{Percent: 2.5}, 10.01, 0.25}, // 0.25025 rounds to the cent
+		{FeePlan{Fixed: 5}, 3, 3},            // never above the gross amount
+	}
+	for _, tt := range tests {
+		if got := tt.plan.Fee(tt.gross); got != tt.want {
+			t.Errorf("%+v.Fee(%v) = %v, want %v", tt.plan, tt.gross, got, tt.want)
+		}
+	}
+}
+
+func TestInvoice_ApplyFee(t *testing.T) {
+	plan := FeePlan{Percent: 3.99, Fixed: 0.39}
+	i, _ := NewInvoiceWithProcessor("acc-1", "Order 1", "credit_card", 100, "", NewTestInvoiceProcessor(), SystemClock)
+	if err := i.ApplyFee(plan); !errors.Is(err, ErrFeeRequiresApproval) {
+		t.Fatalf("expected ErrFeeRequiresApproval on a pending invoice, got %v", err)
+	}
+
+	_ = i.Process()
+	if err := i.ApplyFee(plan); err != nil {
+		t.Fatalf("apply fee: %v", err)
+	}
+	if i.Fee != 4.38 || i.NetAmount != 95.62 || i.Amount != 100 {
+		t.Fatalf("expected gross 100, fee 4.38, net 95.62, got %v %v %v", i.Amount, i.Fee, i.NetAmount)
+	}
+}
+
+func TestNewInvoiceFee(t *testing.T) {
+	i, _ := NewInvoiceWithProcessor("acc-1", "Order 1", "pix", 50, "", NewTestInvoiceProcessor(), SystemClock)
+	_ = i.Process()
+	_ = i.ApplyFee(FeePlan{Percent: 1})
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	f := NewInvoiceFee(i, NewFakeClock(now))
+	if f.ID == "" || f.InvoiceID != i.ID || f.AccountID != "acc-1" || f.PaymentType != "pix" || f.Gross != 50 || f.Fee != 0.5 || !f.CreatedAt.Equal(now) {
+		t.Fatalf("unexpected fee record %+v", f)
+	}
+
+	// Installment interest is part of what the fee was charged on
+	card, _ := NewInvoiceWithProcessor("acc-1", "Order 2", "credit_card", 90, "1234", NewTestInvoiceProcessor(), SystemClock)
+	_ = card.SetInstallments(3, InstallmentRules{MaxInstallments: 12, FreeInstallments: 1, MonthlyInterest: 1.99})
+	_ = card.Process()
+	_ = card.ApplyFee(FeePlan{Percent: 1})
+	if f := NewInvoiceFee(card, SystemClock); f.Gross != 93.6 || f.Fee != 0.94 {
+		t.Fatalf("expected the fee on the total of 93.60, got %+v", f)
+	}
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	ErrInvalidInstallments        = errors.New("invoice: installments must be between 1 and 12")
+	ErrInstallmentsNotAllowed     = errors.New("invoice: only credit card invoices can be paid in installments")
+	ErrInstallmentsAboveLimit     = errors.New("invoice: installments exceed the maximum accepted by the merchant")
+	ErrInstallmentsRequirePending = errors.New("invoice: installments can only be set on pending invoices")
+	ErrInstallmentTotalTooLarge   = errors.New("invoice: total with installment interest exceeds the maximum amount")
+	ErrInvalidMaxInstallments     = errors.New("installment: max installments must be between 1 and 12")
+	ErrInvalidFreeInstallments    = errors.New("installment: interest-free installments must be between 1 and max installments")
+	ErrInvalidInterestRate        = errors.New("installment: monthly interest must be between 0 and 10 with at most 2 decimal places")
+)
+
+// Installment limits.
+const (
+	MaxInstallments    = 12
+	MaxMonthlyInterest = 10.0
+)
+
+// InstallmentPaymentType is the only payment type that can be split into
+// installments.
+const InstallmentPaymentType = "credit_card"
+
+// InstallmentRules is how an account sells in installments (parcelamento):
+// buyers choose up to MaxInstallments; up to FreeInstallments carry no
+// interest, above that MonthlyInterest is charged on the price table.
+type InstallmentRules struct {
+	AccountID        string
+	MaxInstallments  int
+	FreeInstallments int
+	MonthlyInterest  float64 // 1.99 means 1.99% a month
+	UpdatedAt        time.Time
+}
+
+// DefaultInstallmentRules accepts up to 12 interest-free installments: the
+// merchant absorbs the cost until it sets its own rules.
+func DefaultInstallmentRules(accountID string) InstallmentRules {
+	return InstallmentRules{AccountID: accountID, MaxInstallments: MaxInstallments, FreeInstallments: MaxInstallments}
+}
+
+// NewInstallmentRules validates and builds the installment rules of an account.
+func NewInstallmentRules(accountID string, maxInstallments, freeInstallments int, monthlyInterest float64, now time.Time) (*InstallmentRules, error) {
+	verr := &ValidationError{}
+	if accountID == "" {
+		verr.Add("account_id", ErrAccountIDRequired)
+	}
+	if maxInstallments < 1 || maxInstallments > MaxInstallments {
+		verr.Add("max_installments", ErrInvalidMaxInstallments)
+	}
+	if freeInstallments < 1 || freeInstallments > maxInstallments {
+		verr.Add("free_installments", ErrInvalidFreeInstallments)
+	}
+	if monthlyInterest < 0 || monthlyInterest > MaxMonthlyInterest || !hasAtMostTwoDecimals(monthlyInterest) {
+		verr.Add("monthly_interest", ErrInvalidInterestRate)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	return &InstallmentRules{
+		AccountID:        accountID,
+		MaxInstallments:  maxInstallments,
+		FreeInstallments: freeInstallments,
+		MonthlyInterest:  monthlyInterest,
+		UpdatedAt:        now,
+	}, nil
+}
+
+// Installment is one monthly part of an invoice paid in installments.
+// Installment n is due n-1 months after the invoice.
+type Installment struct {
+	Number int
+	Amount float64
+	DueAt  time.Time
+}
+
+// Schedule splits amount into n monthly installments starting at start.
+// Interest-free installments split the amount evenly, the leftover cents on
+// the first ones; above FreeInstallments every installment is the fixed
+// payment of the price table (tabela Price), so the total includes interest.
+func (r InstallmentRules) Schedule(amount float64, n int, start time.Time) ([]Installment, error) {
+	if n < 1 || n > MaxInstallments {
+		return nil, ErrInvalidInstallments
+	}
+	if n > r.MaxInstallments {
+		return nil, ErrInstallmentsAboveLimit
+	}
+
+	var amounts []float64
+	if n <= r.FreeInstallments || r.MonthlyInterest == 0 {
+		amounts = splitCents(amount, n)
+	} else {
+		rate := r.MonthlyInterest / 100
+		payment := roundCents(amount * rate / (1 - math.Pow(1+rate, -float64(n))))
+		amounts = make([]float64, n)
+		for k := range amounts {
+			amounts[k] = payment
+		}
+	}
+
+	out := make([]Installment, n)
+	for k := range out {
+		out[k] = Installment{Number: k + 1, Amount: amounts[k], DueAt: IntervalMonth.add(start, k)}
+	}
+	return out, nil
+}
+
+// splitCents splits amount into n parts that differ by at most a cent, the
+// leftover cents going to the first parts.
+func splitCents(amount float64, n int) []float64 {
+	cents := int64(math.Round(amount * 100))
+	base, rest := cents/int64(n), cents%int64(n)
+	out := make([]float64, n)
+	for k := range out {
+		c := base
+		if int64(k) < rest {
+			c++
+		}
+		out[k] = float64(c) / 100
+	}
+	return out
+}
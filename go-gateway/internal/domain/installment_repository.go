@@ -0,0 +1,16 @@
+package domain
+
+import "context"
+
+// InstallmentRulesRepository stores the installment rules of the accounts.
+type InstallmentRulesRepository interface {
+	// Get returns the rules of an account, or ErrInstallmentRulesNotFound
+	// when the account uses the default rules.
+	Get(ctx context.Context, accountID string) (*InstallmentRules, error)
+	// Save creates or replaces the rules of an account.
+	Save(ctx context.Context, r *InstallmentRules) error
+}
+
+var (
+	ErrInstallmentRulesNotFound = Err("installment: rules not found")
+)
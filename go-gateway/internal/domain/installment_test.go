@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewInstallmentRules(t *testing.T) {
+	now := time.Now().UTC()
+	r, err := NewInstallmentRules("acc-1", 10, 3, 1.99, now)
+	if err != nil || r.MaxInstallments != 10 || r.FreeInstallments != 3 || r.MonthlyInterest != 1.99 || !r.UpdatedAt.Equal(now) {
+		t.Fatalf("unexpected rules %+v %v", r, err)
+	}
+
+	_, err = NewInstallmentRules("", 13, 0, 10.5, now)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 4 {
+		t.Fatalf("expected four field errors, got %v", err)
+	}
+	for _, want := range []error{ErrAccountIDRequired, ErrInvalidMaxInstallments, ErrInvalidFreeInstallments, ErrInvalidInterestRate} {
+		if !errors.Is(err, want) {
+			t.Errorf("expected %v in %v", want, err)
+		}
+	}
+	if _, err := NewInstallmentRules("acc-1", 3, 4, 0, now); !errors.Is(err, ErrInvalidFreeInstallments) {
+		t.Fatalf("expected free installments above max rejected, got %v", err)
+	}
+	if _, err := NewInstallmentRules("acc-1", 3, 1, 1.999, now); !errors.Is(err, ErrInvalidInterestRate) {
+		t.Fatalf("expected interest precision rejected, got %v", err)
+	}
+}
+
+func TestInstallmentRules_Schedule(t *testing.T) {
+	start := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+	rules := InstallmentRules{MaxInstallments: 6, FreeInstallments: 3, MonthlyInterest: 1.99}
+
+	free, err := rules.Schedule(100, 3, start)
+	if err != nil || len(free) != 3 {
+		t.Fatalf("unexpected schedule %+v %v", free, err)
+	}
+	for k, want := range []float64{33.34, 33.33, 33.33} {
+		if free[k].Number != k+1 || free[k].Amount != want {
+			t.Errorf("installment %d = %+v, want %v", k+1, free[k], want)
+		}
+	}
+	// Due dates keep the day of the invoice, clamped to shorter months
+	if !free[1].DueAt.Equal(time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)) || !free[2].DueAt.Equal(time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected due dates %v %v", free[1].DueAt, free[2].DueAt)
+	}
+
+	// Above the interest-free installments the price table applies
+	withInterest, err := rules.Schedule(90, 4, start)
+	if err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	for _, in := range withInterest {
+		if in.Amount != 23.63 {
+			t.Fatalf("expected fixed payments of 23.63, got %+v", withInterest)
+		}
+	}
+
+	if _, err := rules.Schedule(90, 7, start); !errors.Is(err, ErrInstallmentsAboveLimit) {
+		t.Fatalf("expected ErrInstallmentsAboveLimit, got %v", err)
+	}
+	if _, err := rules.Schedule(90, 0, start); !errors.Is(err, ErrInvalidInstallments) {
+		t.Fatalf("expected ErrInvalidInstallments, got %v", err)
+	}
+}
+
+func TestInvoice_SetInstallments(t *testing.T) {
+	rules := InstallmentRules{MaxInstallments: 12, FreeInstallments: 1, MonthlyInterest: 1.99}
+	i, _ := NewInvoiceWithProcessor("acc-1", "Order 1", "credit_card", 90, "1234", NewTestInvoiceProcessor(), SystemClock)
+	if i.Installments != 1 || i.TotalAmount != 90 || i.Schedule != nil {
+		t.Fatalf("expected a single payment by default, got %+v", i)
+	}
+
+	if err := i.SetInstallments(3, rules); err != nil {
+		t.Fatalf("set installments: %v", err)
+	}
+	if i.Installments != 3 || i.TotalAmount != 93.6 || len(i.Schedule) != 3 {
+		t.Fatalf("expected 3 x 31.20, got %+v", i)
+	}
+
+	// The fee is charged on what the customer pays
+	_ = i.Process()
+	_ = i.ApplyFee(FeePlan{Percent: 1})
+	if i.Fee != 0.94 || i.NetAmount != 92.66 {
+		t.Fatalf("expected the fee on the total, got fee %v net %v", i.Fee, i.NetAmount)
+	}
+	if err := i.SetInstallments(2, rules); !errors.Is(err, ErrInstallmentsRequirePending) {
+		t.Fatalf("expected ErrInstallmentsRequirePending, got %v", err)
+	}
+
+	pix, _ := NewInvoiceWithProcessor("acc-1", "Order 2", "pix", 90, "", NewTestInvoiceProcessor(), SystemClock)
+	var verr *ValidationError
+	if err := pix.SetInstallments(2, rules); !errors.As(err, &verr) || verr.Fields[0].Field != "installments" || !errors.Is(err, ErrInstallmentsNotAllowed) {
+		t.Fatalf("expected ErrInstallmentsNotAllowed on installments, got %v", err)
+	}
+	if err := pix.SetInstallments(1, rules); err != nil || pix.TotalAmount != 90 {
+		t.Fatalf("expected a single pix payment accepted, got %+v %v", pix, err)
+	}
+
+	// The interest must not push the total past what the columns hold
+	big, _ := NewInvoiceWithProcessor("acc-1", "Order 3", "credit_card", MaxInvoiceAmount, "1234", NewTestInvoiceProcessor(), SystemClock)
+	if err := big.SetInstallments(6, rules); !errors.As(err, &verr) || verr.Fields[0].Field != "installments" || !errors.Is(err, ErrInstallmentTotalTooLarge) {
+		t.Fatalf("expected ErrInstallmentTotalTooLarge on installments, got %v", err)
+	}
+	if big.Installments != 1 || big.TotalAmount != MaxInvoiceAmount || big.Schedule != nil {
+		t.Fatalf("expected the invoice unchanged, got %+v", big)
+	}
+}
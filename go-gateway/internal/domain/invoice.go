@@ -15,6 +15,10 @@ var (
 	ErrInvalidPaymentType   = errors.New("invoice: invalid payment type")
 	ErrInvalidStatus        = errors.New("invoice: invalid status")
 	ErrInvoiceNegativeValue = errors.New("invoice: amount must be positive")
+	ErrAccountIDRequired    = errors.New("invoice: account ID is required")
+	ErrAmountTooLarge       = errors.New("invoice: amount exceeds the maximum allowed")
+	ErrAmountPrecision      = errors.New("invoice: amount must have at most 2 decimal places")
+	ErrInvalidCardDigits    = errors.New("invoice: card last digits must be exactly 4 digits")
 )
 
 // Status represents the possible states of an invoice
@@ -31,6 +35,7 @@ type TestInvoiceProcessor struct {
 	nextStatus Status
 	shouldErr  bool
 	err        error
+	clock      Clock
 }
 
 // NewTestInvoiceProcessor creates a new test processor
@@ -39,9 +44,15 @@ func NewTestInvoiceProcessor() *TestInvoiceProcessor {
 		nextStatus: StatusApproved,
 		shouldErr:  false,
 		err:        nil,
+		clock:      SystemClock,
 	}
 }
 
+// SetClock sets the clock that stamps processed invoices
+func (p *TestInvoiceProcessor) SetClock(c Clock) {
+	p.clock = c
+}
+
 // SetNextStatus sets the next status that will be returned
 func (p *TestInvoiceProcessor) SetNextStatus(status Status) {
 	p.nextStatus = status
@@ -69,13 +80,8 @@ func (p *TestInvoiceProcessor) ProcessInvoice(invoice *Invoice) error {
 		return errors.New("invoice: can only process pending invoices")
 	}
 
-	// Apply the same business rule: invoices with amount > 10000 stay pending
-	if invoice.Amount > 10000 {
-		return nil
-	}
-
 	invoice.Status = p.nextStatus
-	invoice.UpdatedAt = time.Now().UTC()
+	invoice.UpdatedAt = clockOrSystem(p.clock).Now()
 
 	return nil
 }
@@ -85,121 +91,150 @@ type InvoiceProcessor interface {
 	ProcessInvoice(invoice *Invoice) error
 }
 
+// DefaultApprovalRate is the share of invoices approved when none is configured.
+const DefaultApprovalRate = 0.7
+
 // DefaultInvoiceProcessor implements the default random processing logic
 type DefaultInvoiceProcessor struct {
+	mu           sync.Mutex // rand.Rand is not safe for concurrent use
 	randomSource *rand.Rand
+	approvalRate float64
+	clock        Clock
 }
 
 // NewDefaultInvoiceProcessor creates a new default processor with current time seed
 func NewDefaultInvoiceProcessor() *DefaultInvoiceProcessor {
-	return &DefaultInvoiceProcessor{
-		randomSource: rand.New(rand.NewSource(time.Now().Unix())),
-	}
+	return NewDefaultInvoiceProcessorWithConfig(DefaultApprovalRate, SystemClock)
 }
 
 // NewDefaultInvoiceProcessorWithSeed creates a new default processor with a specific seed
 func NewDefaultInvoiceProcessorWithSeed(seed int64) *DefaultInvoiceProcessor {
 	return &DefaultInvoiceProcessor{
 		randomSource: rand.New(rand.NewSource(seed)),
+		approvalRate: DefaultApprovalRate,
+		clock:        SystemClock,
 	}
 }
 
-// ProcessInvoice processes an invoice using random logic (70% approved, 30% rejected)
-func (p *DefaultInvoiceProcessor) ProcessInvoice(invoice *Invoice) error {
-	if invoice.Amount > 10000 {
-		return nil
+// NewDefaultInvoiceProcessorWithConfig creates a default processor that approves
+// approvalRate of the invoices and stamps them with clock. Which invoices
+// reach it is up to the risk rules.
+func NewDefaultInvoiceProcessorWithConfig(approvalRate float64, clock Clock) *DefaultInvoiceProcessor {
+	return &DefaultInvoiceProcessor{
+		randomSource: rand.New(rand.NewSource(clock.Now().UnixNano())),
+		approvalRate: approvalRate,
+		clock:        clock,
 	}
+}
 
+// ProcessInvoice processes an invoice using random logic (70% approved, 30% rejected by default)
+func (p *DefaultInvoiceProcessor) ProcessInvoice(invoice *Invoice) error {
 	if invoice.Status != StatusPending {
 		return errors.New("invoice: can only process pending invoices")
 	}
 
+	p.mu.Lock()
+	roll := p.randomSource.Float64()
+	p.mu.Unlock()
+
 	var newStatus Status
-	if p.randomSource.Float64() <= 0.7 {
+	if roll <= p.approvalRate {
 		newStatus = StatusApproved
 	} else {
 		newStatus = StatusRejected
 	}
 
 	invoice.Status = newStatus
-	invoice.UpdatedAt = time.Now().UTC()
+	invoice.UpdatedAt = clockOrSystem(p.clock).Now()
 
 	return nil
 }
 
 // Invoice represents a payment invoice that belongs to an account
 type Invoice struct {
-	ID             string
-	AccountID      string
-	Amount         float64
-	Status         Status
-	Description    string
-	PaymentType    string
-	CardLastDigits string
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	mu             sync.RWMutex
-	processor      InvoiceProcessor // Processor for this invoice
+	ID                string
+	AccountID         string
+	Amount            float64       // price of the purchase
+	Installments      int           // 1 for a single payment
+	TotalAmount       float64       // gross amount charged to the customer: Amount plus installment interest
+	Schedule          []Installment // set only when paid in more than one installment
+	Fee               float64       // platform fee, set at approval
+	NetAmount         float64       // TotalAmount minus Fee, credited to the account
+	Status            Status
+	Description       string
+	PaymentType       string
+	CardLastDigits    string
+	CustomerID        string     // payer, optional
+	SavedCardID       string     // saved card of the customer charged, optional
+	Items             []LineItem // optional; their total matches Amount
+	Metadata          map[string]string
+	ExternalReference string       // merchant's own ID, unique per account; optional
+	IdempotencyKey    string       // client key that replays the creation; unique per account, optional
+	RiskDecision      RiskDecision // set before processing; review stays pending
+	RiskReasons       []string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	mu                sync.RWMutex
+	processor         InvoiceProcessor // Processor for this invoice
+	clock             Clock            // stamps changes; nil means SystemClock
 }
 
-// NewInvoice creates a new Invoice with generated ID and timestamps.
-func NewInvoice(accountID, description, paymentType string, amount float64, cardLastDigits string) (*Invoice, error) {
-	if len(accountID) == 0 {
-		return nil, errors.New("invoice: account ID is required")
-	}
-	if len(description) < 3 {
-		return nil, ErrInvalidDescription
-	}
-	if len(paymentType) == 0 {
-		return nil, ErrInvalidPaymentType
-	}
-	if amount <= 0 {
-		return nil, ErrInvoiceNegativeValue
+// NewInvoice creates a new Invoice with generated ID and timestamps taken
+// from clock.
+func NewInvoice(accountID, description, paymentType string, amount float64, cardLastDigits string, clock Clock) (*Invoice, error) {
+	return NewInvoiceWithProcessor(accountID, description, paymentType, amount, cardLastDigits,
+		NewDefaultInvoiceProcessorWithConfig(DefaultApprovalRate, clock), clock)
+}
+
+// NewInvoiceWithProcessor creates a new Invoice with a custom processor.
+// Every invalid field is reported in a single *ValidationError.
+func NewInvoiceWithProcessor(accountID, description, paymentType string, amount float64, cardLastDigits string, processor InvoiceProcessor, clock Clock) (*Invoice, error) {
+	if err := validateInvoice(accountID, description, paymentType, amount, cardLastDigits); err != nil {
+		return nil, err
 	}
 
-	now := time.Now().UTC()
+	now := clock.Now()
 	return &Invoice{
 		ID:             uuid.New().String(),
 		AccountID:      accountID,
 		Amount:         amount,
+		Installments:   1,
+		TotalAmount:    amount,
 		Status:         StatusPending,
 		Description:    description,
 		PaymentType:    paymentType,
 		CardLastDigits: cardLastDigits,
+		RiskDecision:   RiskApprove,
 		CreatedAt:      now,
 		UpdatedAt:      now,
-		processor:      NewDefaultInvoiceProcessor(),
+		processor:      processor,
+		clock:          clock,
 	}, nil
 }
 
-// NewInvoiceWithProcessor creates a new Invoice with a custom processor
-func NewInvoiceWithProcessor(accountID, description, paymentType string, amount float64, cardLastDigits string, processor InvoiceProcessor) (*Invoice, error) {
+func validateInvoice(accountID, description, paymentType string, amount float64, cardLastDigits string) error {
+	verr := &ValidationError{}
 	if len(accountID) == 0 {
-		return nil, errors.New("invoice: account ID is required")
+		verr.Add("account_id", ErrAccountIDRequired)
 	}
-	if len(description) < 3 {
-		return nil, ErrInvalidDescription
+	if !lengthBetween(description, MinDescriptionLength, MaxDescriptionLength) {
+		verr.Add("description", ErrInvalidDescription)
 	}
 	if len(paymentType) == 0 {
-		return nil, ErrInvalidPaymentType
+		verr.Add("payment_type", ErrInvalidPaymentType)
 	}
-	if amount <= 0 {
-		return nil, ErrInvoiceNegativeValue
+	switch {
+	case amount <= 0:
+		verr.Add("amount", ErrInvoiceNegativeValue)
+	case amount > MaxInvoiceAmount:
+		verr.Add("amount", ErrAmountTooLarge)
+	case !hasAtMostTwoDecimals(amount):
+		verr.Add("amount", ErrAmountPrecision)
 	}
-
-	now := time.Now().UTC()
-	return &Invoice{
-		ID:             uuid.New().String(),
-		AccountID:      accountID,
-		Amount:         amount,
-		Status:         StatusPending,
-		Description:    description,
-		PaymentType:    paymentType,
-		CardLastDigits: cardLastDigits,
-		CreatedAt:      now,
-		UpdatedAt:      now,
-		processor:      processor,
-	}, nil
+	if cardLastDigits != "" && !isCardLastDigits(cardLastDigits) {
+		verr.Add("card_last_digits", ErrInvalidCardDigits)
+	}
+	return verr.OrNil()
 }
 
 // SetProcessor allows changing the processor for an invoice
@@ -209,18 +244,135 @@ func (i *Invoice) SetProcessor(processor InvoiceProcessor) {
 	i.processor = processor
 }
 
+// SetClock sets the clock that stamps changes of an invoice loaded from
+// storage.
+func (i *Invoice) SetClock(c Clock) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.clock = c
+}
+
 // Process updates the invoice status using the configured processor
 func (i *Invoice) Process() error {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
 	if i.processor == nil {
-		i.processor = NewDefaultInvoiceProcessor()
+		i.processor = NewDefaultInvoiceProcessorWithConfig(DefaultApprovalRate, clockOrSystem(i.clock))
 	}
 
 	return i.processor.ProcessInvoice(i)
 }
 
+// ApplyRisk records the risk assessment of a pending invoice. A rejection
+// rejects it; a review keeps it pending. Only approved ones should be
+// processed afterwards.
+func (i *Invoice) ApplyRisk(a RiskAssessment) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.Status != StatusPending {
+		return ErrRiskRequiresPending
+	}
+	i.RiskDecision = a.Decision
+	i.RiskReasons = append([]string(nil), a.Reasons...)
+	if a.Decision == RiskReject {
+		i.Status = StatusRejected
+		i.UpdatedAt = clockOrSystem(i.clock).Now()
+	}
+	return nil
+}
+
+// ApplyFee charges the fee of plan on an approved invoice. Only the net
+// amount is credited to the account.
+func (i *Invoice) ApplyFee(plan FeePlan) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.Status != StatusApproved {
+		return ErrFeeRequiresApproval
+	}
+	i.Fee = plan.Fee(i.TotalAmount)
+	i.NetAmount = roundCents(i.TotalAmount - i.Fee)
+	return nil
+}
+
+// SetInstallments splits a pending invoice into n monthly installments under
+// the rules of its account. Only credit card invoices can have more than one;
+// the interest of the rules is added to TotalAmount, which must still fit
+// MaxInvoiceAmount.
+func (i *Invoice) SetInstallments(n int, rules InstallmentRules) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.Status != StatusPending {
+		return ErrInstallmentsRequirePending
+	}
+	verr := &ValidationError{}
+	if n > 1 && i.PaymentType != InstallmentPaymentType {
+		verr.Add("installments", ErrInstallmentsNotAllowed)
+		return verr
+	}
+	schedule, err := rules.Schedule(i.Amount, n, i.CreatedAt)
+	if err != nil {
+		verr.Add("installments", err)
+		return verr
+	}
+
+	total := 0.0
+	for _, in := range schedule {
+		total += in.Amount
+	}
+	total = roundCents(total)
+	if total > MaxInvoiceAmount {
+		verr.Add("installments", ErrInstallmentTotalTooLarge)
+		return verr
+	}
+
+	i.Installments = n
+	i.Schedule = nil
+	i.TotalAmount = total
+	if n > 1 {
+		i.Schedule = schedule
+	}
+	return nil
+}
+
+// SetCustomer records the payer of a pending invoice. With a saved card of
+// that customer the invoice is charged to it: the card must be charged as
+// credit_card and its last digits are taken from the card.
+func (i *Invoice) SetCustomer(c *Customer, card *SavedCard) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	verr := &ValidationError{}
+	if c == nil || c.AccountID != i.AccountID {
+		verr.Add("customer_id", ErrUnknownCustomer)
+		return verr
+	}
+	if card != nil {
+		if card.CustomerID != c.ID {
+			verr.Add("saved_card_id", ErrUnknownSavedCard)
+		}
+		if i.PaymentType != InstallmentPaymentType {
+			verr.Add("payment_type", ErrSavedCardPaymentType)
+		}
+		if i.CardLastDigits != "" && i.CardLastDigits != card.CardLastDigits {
+			verr.Add("card_last_digits", ErrSavedCardMismatch)
+		}
+	}
+	if err := verr.OrNil(); err != nil {
+		return err
+	}
+
+	i.CustomerID = c.ID
+	if card != nil {
+		i.SavedCardID = card.ID
+		i.CardLastDigits = card.CardLastDigits
+	}
+	return nil
+}
+
 // UpdateStatus updates the invoice status
 func (i *Invoice) UpdateStatus(newStatus Status) error {
 	i.mu.Lock()
@@ -229,7 +381,7 @@ func (i *Invoice) UpdateStatus(newStatus Status) error {
 	switch newStatus {
 	case StatusPending, StatusApproved, StatusRejected:
 		i.Status = newStatus
-		i.UpdatedAt = time.Now().UTC()
+		i.UpdatedAt = clockOrSystem(i.clock).Now()
 		return nil
 	default:
 		return ErrInvalidStatus
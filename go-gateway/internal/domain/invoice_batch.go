@@ -0,0 +1,36 @@
+package domain
+
+import "errors"
+
+var (
+	ErrInvalidIdempotencyKey   = errors.New("invoice: idempotency key must have between 1 and 255 characters")
+	ErrIdempotencyKeyReused    = errors.New("invoice: idempotency key already used for a different invoice")
+	ErrIdempotencyKeyInUse     = errors.New("invoice: idempotency key used by a concurrent request")
+	ErrDuplicateIdempotencyKey = errors.New("invoice batch: idempotency key repeated in the batch")
+	ErrEmptyBatch              = errors.New("invoice batch: at least one invoice is required")
+	ErrBatchTooLarge           = errors.New("invoice batch: too many invoices")
+)
+
+// MaxIdempotencyKeyLength is the longest idempotency key accepted.
+const MaxIdempotencyKeyLength = 255
+
+// Defaults of the bulk invoice creation.
+const (
+	DefaultBatchMaxItems = 500
+	DefaultBatchWorkers  = 8
+)
+
+// SetIdempotencyKey records the client key of a new invoice. Creating an
+// invoice again with the same key returns the stored one instead.
+func (i *Invoice) SetIdempotencyKey(key string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !lengthBetween(key, 1, MaxIdempotencyKeyLength) {
+		verr := &ValidationError{}
+		verr.Add("idempotency_key", ErrInvalidIdempotencyKey)
+		return verr
+	}
+	i.IdempotencyKey = key
+	return nil
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInvoice_SetIdempotencyKey(t *testing.T) {
+	inv, _ := NewInvoice("acc-1", "Order 42", "pix", 60, "", NewFakeClock(time.Now()))
+	if err := inv.SetIdempotencyKey("batch-1/42"); err != nil || inv.IdempotencyKey != "batch-1/42" {
+		t.Fatalf("expected the key set, got %q %v", inv.IdempotencyKey, err)
+	}
+
+	for _, key := range []string{"", "   ", strings.Repeat("k", MaxIdempotencyKeyLength+1)} {
+		var verr *ValidationError
+		err := inv.SetIdempotencyKey(key)
+		if !errors.As(err, &verr) || verr.Fields[0].Field != "idempotency_key" || !errors.Is(err, ErrInvalidIdempotencyKey) {
+			t.Errorf("key %q: expected an idempotency_key validation error, got %v", key, err)
+		}
+	}
+	if inv.IdempotencyKey != "batch-1/42" {
+		t.Fatalf("expected an invalid key to keep the previous one, got %q", inv.IdempotencyKey)
+	}
+}
@@ -1,13 +1,47 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // InvoiceRepository defines persistence operations for Invoice.
 type InvoiceRepository interface {
+	// Create returns ErrExternalReferenceExists or ErrIdempotencyKeyInUse
+	// when another invoice of the account has the reference or key.
 	Create(ctx context.Context, i *Invoice) error
+	// CreateWithCharge stores the invoice like Create and, in the same
+	// transaction, applies its charge. A duplicate reference or key fails
+	// before any money moves.
+	CreateWithCharge(ctx context.Context, i *Invoice, charge InvoiceCharge) error
 	GetByID(ctx context.Context, id string) (*Invoice, error)
+	// GetByIDForAccount returns ErrInvoiceNotFound when the invoice belongs
+	// to another account, so its existence is not disclosed.
+	GetByIDForAccount(ctx context.Context, accountID, id string) (*Invoice, error)
+	// GetByAccountID may leave the installment schedules unloaded.
 	GetByAccountID(ctx context.Context, accountID string) ([]*Invoice, error)
+	// GetByCustomer returns the invoices of a customer of the account,
+	// newest first. It may leave the installment schedules unloaded.
+	GetByCustomer(ctx context.Context, accountID, customerID string) ([]*Invoice, error)
+	// GetByExternalReference returns the invoice of the account with the
+	// merchant reference, or ErrInvoiceNotFound.
+	GetByExternalReference(ctx context.Context, accountID, ref string) (*Invoice, error)
+	// GetByIdempotencyKey returns the invoice of the account created with
+	// the idempotency key, or ErrInvoiceNotFound.
+	GetByIdempotencyKey(ctx context.Context, accountID, key string) (*Invoice, error)
 	UpdateStatus(ctx context.Context, id string, status Status) error
+	// CountByCardSince counts the invoices of an account charged to a card
+	// since a moment, for the card velocity risk rule.
+	CountByCardSince(ctx context.Context, accountID, cardLastDigits string, since time.Time) (int, error)
+}
+
+// InvoiceCharge is the money an approved invoice moves: the amount credited
+// to the balance right away, the installments held until they settle and the
+// fee kept by the gateway.
+type InvoiceCharge struct {
+	Credit      float64
+	Settlements []*Settlement
+	Fee         *InvoiceFee
 }
 
 // Domain-level errors for repository implementations.
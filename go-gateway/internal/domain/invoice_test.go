@@ -83,7 +83,7 @@ func TestNewInvoice(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			invoice, err := NewInvoice(tt.accountID, tt.description, tt.paymentType, tt.amount, tt.cardLastDigits)
+			invoice, err := NewInvoice(tt.accountID, tt.description, tt.paymentType, tt.amount, tt.cardLastDigits, SystemClock)
 
 			if tt.expectedError {
 				if err == nil {
@@ -130,7 +130,7 @@ func TestNewInvoice(t *testing.T) {
 
 func TestInvoice_Process(t *testing.T) {
 	// Test with default processor (random behavior)
-	invoice, err := NewInvoice("test-account-id", "Test invoice", "credit_card", 100.50, "1234")
+	invoice, err := NewInvoice("test-account-id", "Test invoice", "credit_card", 100.50, "1234", SystemClock)
 	if err != nil {
 		t.Fatalf("failed to create test invoice: %v", err)
 	}
@@ -168,7 +168,7 @@ func TestInvoice_Process_WithTestProcessor(t *testing.T) {
 	// Test with controlled test processor
 	testProcessor := NewTestInvoiceProcessor()
 
-	invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor)
+	invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor, SystemClock)
 	if err != nil {
 		t.Fatalf("failed to create test invoice: %v", err)
 	}
@@ -214,70 +214,12 @@ func TestInvoice_Process_WithTestProcessor(t *testing.T) {
 	}
 }
 
-func TestInvoice_Process_HighValuePending(t *testing.T) {
-	// Test that invoices with amount > 10000 stay pending
-	testProcessor := NewTestInvoiceProcessor()
-
-	// Test with amount > 10000 - should stay pending regardless of processor setting
-	testProcessor.SetNextStatus(StatusApproved)
-
-	highValueInvoice, err := NewInvoiceWithProcessor("test-account-id", "High value invoice", "credit_card", 15000.00, "1234", testProcessor)
-	if err != nil {
-		t.Fatalf("failed to create high value invoice: %v", err)
-	}
-
-	// Process should not change status for high value invoices
-	err = highValueInvoice.Process()
-	if err != nil {
-		t.Errorf("failed to process high value invoice: %v", err)
-	}
-
-	// Status should remain pending for amounts > 10000
-	if highValueInvoice.Status != StatusPending {
-		t.Errorf("expected status to remain pending for amount > 10000, got %s", highValueInvoice.Status)
-	}
-
-	// Test with amount exactly 10000 - should be processed normally
-	testProcessor.SetNextStatus(StatusRejected)
-	exactValueInvoice, err := NewInvoiceWithProcessor("test-account-id", "Exact value invoice", "credit_card", 10000.00, "1234", testProcessor)
-	if err != nil {
-		t.Fatalf("failed to create exact value invoice: %v", err)
-	}
-
-	err = exactValueInvoice.Process()
-	if err != nil {
-		t.Errorf("failed to process exact value invoice: %v", err)
-	}
-
-	// Status should be processed for amount = 10000
-	if exactValueInvoice.Status != StatusRejected {
-		t.Errorf("expected status to be processed for amount = 10000, got %s", exactValueInvoice.Status)
-	}
-
-	// Test with amount < 10000 - should be processed normally
-	testProcessor.SetNextStatus(StatusApproved)
-	lowValueInvoice, err := NewInvoiceWithProcessor("test-account-id", "Low value invoice", "credit_card", 9999.99, "1234", testProcessor)
-	if err != nil {
-		t.Fatalf("failed to create low value invoice: %v", err)
-	}
-
-	err = lowValueInvoice.Process()
-	if err != nil {
-		t.Errorf("failed to process low value invoice: %v", err)
-	}
-
-	// Status should be processed for amount < 10000
-	if lowValueInvoice.Status != StatusApproved {
-		t.Errorf("expected status to be processed for amount < 10000, got %s", lowValueInvoice.Status)
-	}
-}
-
 func TestInvoice_Process_WithSeedProcessor(t *testing.T) {
 	// Test with processor using specific seed for deterministic behavior
 	seed := int64(12345)
 	processor := NewDefaultInvoiceProcessorWithSeed(seed)
 
-	invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", processor)
+	invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", processor, SystemClock)
 	if err != nil {
 		t.Fatalf("failed to create test invoice: %v", err)
 	}
@@ -294,7 +236,7 @@ func TestInvoice_Process_WithSeedProcessor(t *testing.T) {
 	// Create another invoice with same seed and verify same result
 	// Note: We need to create a new processor with the same seed for the second invoice
 	processor2 := NewDefaultInvoiceProcessorWithSeed(seed)
-	invoice2, err := NewInvoiceWithProcessor("test-account-id-2", "Test invoice 2", "credit_card", 200.00, "5678", processor2)
+	invoice2, err := NewInvoiceWithProcessor("test-account-id-2", "Test invoice 2", "credit_card", 200.00, "5678", processor2, SystemClock)
 	if err != nil {
 		t.Fatalf("failed to create second test invoice: %v", err)
 	}
@@ -318,7 +260,7 @@ func TestInvoice_Process_RejectedCase(t *testing.T) {
 	for i := 0; i < 7; i++ {
 		testProcessor := NewTestInvoiceProcessor()
 		testProcessor.SetNextStatus(StatusApproved)
-		invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor)
+		invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor, SystemClock)
 		if err != nil {
 			t.Fatalf("failed to create test invoice %d: %v", i, err)
 		}
@@ -329,7 +271,7 @@ func TestInvoice_Process_RejectedCase(t *testing.T) {
 	for i := 0; i < 3; i++ {
 		testProcessor := NewTestInvoiceProcessor()
 		testProcessor.SetNextStatus(StatusRejected)
-		invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor)
+		invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor, SystemClock)
 		if err != nil {
 			t.Fatalf("failed to create test invoice %d: %v", i+7, err)
 		}
@@ -398,7 +340,7 @@ func TestInvoice_Process_ForceRejection(t *testing.T) {
 	for i := 0; i < 20; i++ {
 		testProcessor := NewTestInvoiceProcessor()
 		testProcessor.SetNextStatus(StatusRejected)
-		invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor)
+		invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor, SystemClock)
 		if err != nil {
 			t.Fatalf("failed to create test invoice %d: %v", i, err)
 		}
@@ -467,7 +409,7 @@ func TestInvoice_Process_RejectionLogic(t *testing.T) {
 	// Test with controlled processor to verify rejection logic
 	testProcessor := NewTestInvoiceProcessor()
 
-	invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor)
+	invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor, SystemClock)
 	if err != nil {
 		t.Fatalf("failed to create test invoice: %v", err)
 	}
@@ -502,7 +444,7 @@ func TestInvoice_Process_RejectionLogic(t *testing.T) {
 	}
 
 	// Test the specific rejection scenario by creating a new invoice and forcing it to rejected status
-	invoice2, err := NewInvoiceWithProcessor("test-account-id-2", "Test invoice 2", "credit_card", 200.00, "5678", testProcessor)
+	invoice2, err := NewInvoiceWithProcessor("test-account-id-2", "Test invoice 2", "credit_card", 200.00, "5678", testProcessor, SystemClock)
 	if err != nil {
 		t.Fatalf("failed to create second test invoice: %v", err)
 	}
@@ -555,7 +497,7 @@ func TestInvoice_Process_RejectionCondition(t *testing.T) {
 	for i := 0; i < 35; i++ {
 		testProcessor := NewTestInvoiceProcessor()
 		testProcessor.SetNextStatus(StatusApproved)
-		invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor)
+		invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor, SystemClock)
 		if err != nil {
 			t.Fatalf("failed to create test invoice %d: %v", i, err)
 		}
@@ -566,7 +508,7 @@ func TestInvoice_Process_RejectionCondition(t *testing.T) {
 	for i := 0; i < 15; i++ {
 		testProcessor := NewTestInvoiceProcessor()
 		testProcessor.SetNextStatus(StatusRejected)
-		invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor)
+		invoice, err := NewInvoiceWithProcessor("test-account-id", "Test invoice", "credit_card", 100.50, "1234", testProcessor, SystemClock)
 		if err != nil {
 			t.Fatalf("failed to create test invoice %d: %v", i+35, err)
 		}
@@ -653,7 +595,7 @@ func TestInvoice_Process_RejectionCondition(t *testing.T) {
 }
 
 func TestInvoice_UpdateStatus(t *testing.T) {
-	invoice, err := NewInvoice("test-account-id", "Test invoice", "credit_card", 100.50, "1234")
+	invoice, err := NewInvoice("test-account-id", "Test invoice", "credit_card", 100.50, "1234", SystemClock)
 	if err != nil {
 		t.Fatalf("failed to create test invoice: %v", err)
 	}
@@ -693,7 +635,7 @@ func TestInvoice_UpdateStatus(t *testing.T) {
 }
 
 func TestInvoice_StatusChecks(t *testing.T) {
-	invoice, err := NewInvoice("test-account-id", "Test invoice", "credit_card", 100.50, "1234")
+	invoice, err := NewInvoice("test-account-id", "Test invoice", "credit_card", 100.50, "1234", SystemClock)
 	if err != nil {
 		t.Fatalf("failed to create test invoice: %v", err)
 	}
@@ -745,7 +687,7 @@ func TestInvoice_StatusChecks(t *testing.T) {
 func TestInvoice_Timestamps(t *testing.T) {
 	beforeCreation := time.Now().UTC()
 
-	invoice, err := NewInvoice("test-account-id", "Test invoice", "credit_card", 100.50, "1234")
+	invoice, err := NewInvoice("test-account-id", "Test invoice", "credit_card", 100.50, "1234", SystemClock)
 	if err != nil {
 		t.Fatalf("failed to create test invoice: %v", err)
 	}
@@ -778,7 +720,7 @@ func TestInvoice_Timestamps(t *testing.T) {
 }
 
 func TestInvoice_Concurrency(t *testing.T) {
-	invoice, err := NewInvoice("test-account-id", "Test invoice", "credit_card", 100.50, "1234")
+	invoice, err := NewInvoice("test-account-id", "Test invoice", "credit_card", 100.50, "1234", SystemClock)
 	if err != nil {
 		t.Fatalf("failed to create test invoice: %v", err)
 	}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidItemSKU          = errors.New("invoice: item sku must have between 1 and 64 characters")
+	ErrInvalidItemQuantity     = errors.New("invoice: item quantity must be between 1 and 10000")
+	ErrInvalidItemUnitPrice    = errors.New("invoice: item unit price must be positive with at most 2 decimal places")
+	ErrTooManyItems            = errors.New("invoice: at most 100 items are allowed")
+	ErrItemsTotalMismatch      = errors.New("invoice: items total does not match the amount")
+	ErrInvalidMetadata         = errors.New("invoice: metadata allows up to 50 keys of at most 40 characters with values of at most 500 characters")
+	ErrInvalidExternalRef      = errors.New("invoice: external reference must have between 1 and 100 characters")
+	ErrExternalReferenceExists = errors.New("invoice: external reference already used by another invoice of the account")
+)
+
+// Limits of the merchant details of an invoice.
+const (
+	MaxLineItems               = 100
+	MaxItemQuantity            = 10000
+	MaxSKULength               = 64
+	MaxMetadataKeys            = 50
+	MaxMetadataKeyLength       = 40
+	MaxMetadataValueLength     = 500
+	MaxExternalReferenceLength = 100
+)
+
+// LineItem is one product of an invoice.
+type LineItem struct {
+	SKU       string
+	Quantity  int
+	UnitPrice float64
+}
+
+// Total is the price of the item: quantity times unit price.
+func (l LineItem) Total() float64 {
+	return roundCents(float64(l.Quantity) * l.UnitPrice)
+}
+
+// SetDetails records the line items, metadata and merchant reference of an
+// invoice. All of them are optional; when items are given their total must
+// match Amount. Every invalid field is reported in a single *ValidationError.
+func (i *Invoice) SetDetails(items []LineItem, metadata map[string]string, externalReference string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	verr := &ValidationError{}
+	if len(items) > MaxLineItems {
+		verr.Add("items", ErrTooManyItems)
+	} else if len(items) > 0 {
+		validateItems(items, i.Amount, verr)
+	}
+	if !validMetadata(metadata) {
+		verr.Add("metadata", ErrInvalidMetadata)
+	}
+	if externalReference != "" && !lengthBetween(externalReference, 1, MaxExternalReferenceLength) {
+		verr.Add("external_reference", ErrInvalidExternalRef)
+	}
+	if err := verr.OrNil(); err != nil {
+		return err
+	}
+
+	i.Items = append([]LineItem(nil), items...)
+	i.Metadata = nil
+	if len(metadata) > 0 {
+		i.Metadata = make(map[string]string, len(metadata))
+		for k, v := range metadata {
+			i.Metadata[k] = v
+		}
+	}
+	i.ExternalReference = externalReference
+	return nil
+}
+
+// validateItems checks each item and, when they are all valid, that they add
+// up to amount.
+func validateItems(items []LineItem, amount float64, verr *ValidationError) {
+	before := len(verr.Fields)
+	var total float64
+	for k, it := range items {
+		field := fmt.Sprintf("items[%d].", k)
+		if !lengthBetween(it.SKU, 1, MaxSKULength) {
+			verr.Add(field+"sku", ErrInvalidItemSKU)
+		}
+		if it.Quantity < 1 || it.Quantity > MaxItemQuantity {
+			verr.Add(field+"quantity", ErrInvalidItemQuantity)
+		}
+		if it.UnitPrice <= 0 || it.UnitPrice > MaxInvoiceAmount || !hasAtMostTwoDecimals(it.UnitPrice) {
+			verr.Add(field+"unit_price", ErrInvalidItemUnitPrice)
+		}
+		total += it.Total()
+	}
+	if len(verr.Fields) == before && roundCents(total) != roundCents(amount) {
+		verr.Add("items", ErrItemsTotalMismatch)
+	}
+}
+
+func validMetadata(metadata map[string]string) bool {
+	if len(metadata) > MaxMetadataKeys {
+		return false
+	}
+	for k, v := range metadata {
+		if !lengthBetween(k, 1, MaxMetadataKeyLength) || utf8.RuneCountInString(v) > MaxMetadataValueLength {
+			return false
+		}
+	}
+	return true
+}
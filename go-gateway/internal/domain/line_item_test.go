@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInvoice_SetDetails(t *testing.T) {
+	now := time.Now().UTC()
+	items := []LineItem{{SKU: "SKU-1", Quantity: 3, UnitPrice: 19.9}, {SKU: "SKU-2", Quantity: 1, UnitPrice: 0.3}}
+
+	inv, _ := NewInvoice("acc-1", "Order 42", "pix", 60, "", NewFakeClock(now))
+	metadata := map[string]string{"channel": "web"}
+	if err := inv.SetDetails(items, metadata, "order-42"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	metadata["channel"] = "changed"
+	items[0].Quantity = 99
+	if len(inv.Items) != 2 || inv.Items[0].Quantity != 3 || inv.Metadata["channel"] != "web" || inv.ExternalReference != "order-42" {
+		t.Fatalf("expected the details copied, got %+v", inv)
+	}
+
+	// Everything is optional
+	plain, _ := NewInvoice("acc-1", "Order 43", "pix", 60, "", NewFakeClock(now))
+	if err := plain.SetDetails(nil, nil, ""); err != nil || plain.Items != nil || plain.Metadata != nil {
+		t.Fatalf("expected no details, got %+v %v", plain, err)
+	}
+
+	tooMany := make(map[string]string, MaxMetadataKeys+1)
+	for k := 0; k <= MaxMetadataKeys; k++ {
+		tooMany[fmt.Sprintf("key-%d", k)] = "v"
+	}
+	tests := []struct {
+		name     string
+		items    []LineItem
+		metadata map[string]string
+		ref      string
+		field    string
+		err      error
+	}{
+		{"total mismatch", []LineItem{{SKU: "SKU-1", Quantity: 2, UnitPrice: 20}}, nil, "", "items", ErrItemsTotalMismatch},
+		{"empty sku", []LineItem{{SKU: " ", Quantity: 3, UnitPrice: 20}}, nil, "", "items[0].sku", ErrInvalidItemSKU},
+		{"zero quantity", []LineItem{{SKU: "SKU-1", Quantity: 0, UnitPrice: 60}}, nil, "", "items[0].quantity", ErrInvalidItemQuantity},
+		{"fractional cents", []LineItem{{SKU: "SKU-1", Quantity: 1, UnitPrice: 59.999}, {SKU: "SKU-2", Quantity: 1, UnitPrice: -1}}, nil, "", "items[0].unit_price", ErrInvalidItemUnitPrice},
+		{"too many items", make([]LineItem, MaxLineItems+1), nil, "", "items", ErrTooManyItems},
+		{"empty metadata key", nil, map[string]string{"": "x"}, "", "metadata", ErrInvalidMetadata},
+		{"long metadata value", nil, map[string]string{"note": strings.Repeat("x", MaxMetadataValueLength+1)}, "", "metadata", ErrInvalidMetadata},
+		{"too many metadata keys", nil, tooMany, "", "metadata", ErrInvalidMetadata},
+		{"long reference", nil, nil, strings.Repeat("r", MaxExternalReferenceLength+1), "external_reference", ErrInvalidExternalRef},
+		{"blank reference", nil, nil, "  ", "external_reference", ErrInvalidExternalRef},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv, _ := NewInvoice("acc-1", "Order 42", "pix", 60, "", NewFakeClock(now))
+			err := inv.SetDetails(tt.items, tt.metadata, tt.ref)
+			var verr *ValidationError
+			if !errors.As(err, &verr) || verr.Fields[0].Field != tt.field || !errors.Is(err, tt.err) {
+				t.Fatalf("expected %s: %v, got %v", tt.field, tt.err, err)
+			}
+			if inv.Items != nil || inv.Metadata != nil || inv.ExternalReference != "" {
+				t.Fatalf("expected nothing set on error, got %+v", inv)
+			}
+		})
+	}
+}
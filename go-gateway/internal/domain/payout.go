@@ -0,0 +1,228 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrBankAccountRequired     = errors.New("payout: bank account ID is required")
+	ErrPayoutBelowMinimum      = errors.New("payout: amount is below the minimum")
+	ErrInvalidPayoutTransition = errors.New("payout: invalid status transition")
+)
+
+// PayoutStatus is the lifecycle state of a payout:
+// pending → processing → paid | failed. Pending payouts can also fail.
+type PayoutStatus string
+
+const (
+	PayoutPending    PayoutStatus = "pending"
+	PayoutProcessing PayoutStatus = "processing"
+	PayoutPaid       PayoutStatus = "paid"
+	PayoutFailed     PayoutStatus = "failed"
+)
+
+// Payout is a withdrawal from the account balance to one of its bank
+// accounts. The amount is debited when the payout is created and returned to
+// the balance if it fails.
+type Payout struct {
+	ID                string
+	AccountID         string
+	BankAccountID     string
+	Amount            float64
+	Status            PayoutStatus
+	FailureReason     string
+	TransferReference string // set by the bank rail when the payout is paid
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	mu                sync.Mutex
+	clock             Clock
+}
+
+// NewPayout validates and builds a pending payout whose timestamps are taken
+// from clock.
+func NewPayout(accountID, bankAccountID string, amount float64, clock Clock) (*Payout, error) {
+	verr := &ValidationError{}
+	if accountID == "" {
+		verr.Add("account_id", ErrAccountIDRequired)
+	}
+	if bankAccountID == "" {
+		verr.Add("bank_account_id", ErrBankAccountRequired)
+	}
+	switch {
+	case amount <= 0:
+		verr.Add("amount", ErrNegativeValue)
+	case amount > MaxInvoiceAmount:
+		verr.Add("amount", ErrAmountTooLarge)
+	case !hasAtMostTwoDecimals(amount):
+		verr.Add("amount", ErrAmountPrecision)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	now := clock.Now()
+	return &Payout{
+		ID:            uuid.New().String(),
+		AccountID:     accountID,
+		BankAccountID: bankAccountID,
+		Amount:        amount,
+		Status:        PayoutPending,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		clock:         clock,
+	}, nil
+}
+
+// SetClock sets the clock that stamps changes of a payout loaded from
+// storage.
+func (p *Payout) SetClock(c Clock) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clock = c
+}
+
+// StartProcessing hands a pending payout to the bank rail.
+func (p *Payout) StartProcessing() error {
+	return p.transition(PayoutProcessing, PayoutPending)
+}
+
+// MarkPaid confirms a payout accepted by the bank under reference.
+func (p *Payout) MarkPaid(reference string) error {
+	if err := p.transition(PayoutPaid, PayoutProcessing); err != nil {
+		return err
+	}
+	p.TransferReference = reference
+	return nil
+}
+
+// MarkFailed records why a pending or processing payout did not go through.
+func (p *Payout) MarkFailed(reason string) error {
+	if err := p.transition(PayoutFailed, PayoutPending, PayoutProcessing); err != nil {
+		return err
+	}
+	p.FailureReason = reason
+	return nil
+}
+
+func (p *Payout) transition(to PayoutStatus, from ...PayoutStatus) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, f := range from {
+		if p.Status == f {
+			p.Status = to
+			p.UpdatedAt = clockOrSystem(p.clock).Now()
+			return nil
+		}
+	}
+	return ErrInvalidPayoutTransition
+}
+
+// Default payout rules used when none are configured.
+const (
+	DefaultPayoutMinAmount   = 10
+	DefaultPayoutReserveRate = 0.1
+)
+
+// PayoutPolicy holds the rules checked before debiting a payout: a minimum
+// amount and a reserve, the share of the balance that is kept in the account
+// to cover refunds and chargebacks.
+type PayoutPolicy struct {
+	MinAmount   float64
+	ReserveRate float64 // 0.1 keeps 10% of the balance
+}
+
+// DefaultPayoutPolicy returns the policy used when none is configured.
+func DefaultPayoutPolicy() PayoutPolicy {
+	return PayoutPolicy{MinAmount: DefaultPayoutMinAmount, ReserveRate: DefaultPayoutReserveRate}
+}
+
+// Withdrawable is the part of balance that can be paid out.
+func (pol PayoutPolicy) Withdrawable(balance float64) float64 {
+	if balance <= 0 {
+		return 0
+	}
+	return roundCents(balance * (1 - pol.ReserveRate))
+}
+
+// Debit checks amount against the policy and subtracts it from a.
+func (pol PayoutPolicy) Debit(a *Account, amount float64) error {
+	if amount < pol.MinAmount {
+		return ErrPayoutBelowMinimum
+	}
+	if amount > pol.Withdrawable(a.Balance) {
+		return ErrInsufficientFunds
+	}
+	return a.SubtractBalance(amount)
+}
+
+// BankRail transfers payouts to bank accounts and returns the reference of
+// the transfer. A *TransferRejectedError means the bank refused the transfer;
+// any other error, such as a timeout, leaves its outcome unknown. Transfers
+// are identified by the payout ID: sending a payout again returns the
+// reference of the transfer already made instead of paying twice.
+type BankRail interface {
+	Transfer(ctx context.Context, p *Payout, to *BankAccount) (string, error)
+}
+
+// TransferRejectedError is a transfer the bank refused for good. Reason is
+// recorded as the failure reason of the payout.
+type TransferRejectedError struct {
+	Reason string
+}
+
+func (e *TransferRejectedError) Error() string { return e.Reason }
+
+// SimulatedBankRail is a BankRail for development and tests. Transfers succeed
+// unless the destination account number was registered with Fail.
+type SimulatedBankRail struct {
+	mu        sync.Mutex
+	failures  map[string]string
+	transfers map[string]string // reference by payout ID
+	calls     int
+}
+
+// NewSimulatedBankRail creates a rail that accepts every transfer.
+func NewSimulatedBankRail() *SimulatedBankRail {
+	return &SimulatedBankRail{failures: make(map[string]string), transfers: make(map[string]string)}
+}
+
+// Fail makes transfers to the account number fail with reason.
+func (r *SimulatedBankRail) Fail(number, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures[number] = reason
+}
+
+// Calls returns how many transfers were attempted.
+func (r *SimulatedBankRail) Calls() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+// Transfers returns how many distinct payouts were paid.
+func (r *SimulatedBankRail) Transfers() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.transfers)
+}
+
+// Transfer implements BankRail.
+func (r *SimulatedBankRail) Transfer(_ context.Context, p *Payout, to *BankAccount) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if ref, ok := r.transfers[p.ID]; ok {
+		return ref, nil
+	}
+	if reason, ok := r.failures[to.Number]; ok {
+		return "", &TransferRejectedError{Reason: reason}
+	}
+	ref := "sim-" + uuid.New().String()
+	r.transfers[p.ID] = ref
+	return ref, nil
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PayoutRepository defines persistence operations for payouts and the bank
+// accounts they are sent to. Lookups are scoped to the owning account.
+type PayoutRepository interface {
+	CreateBankAccount(ctx context.Context, b *BankAccount) error
+	GetBankAccount(ctx context.Context, accountID, id string) (*BankAccount, error)
+	ListBankAccounts(ctx context.Context, accountID string) ([]*BankAccount, error)
+
+	// Create locks the account, debits p.Amount according to policy and
+	// stores p in a single transaction. It returns the updated account.
+	Create(ctx context.Context, p *Payout, policy PayoutPolicy) (*Account, error)
+	GetByID(ctx context.Context, accountID, id string) (*Payout, error)
+	// ListByAccount returns the payouts of an account, newest first.
+	ListByAccount(ctx context.Context, accountID string) ([]*Payout, error)
+	// ListByStatus returns up to limit payouts in status, oldest first.
+	ListByStatus(ctx context.Context, status PayoutStatus, limit int) ([]*Payout, error)
+	// ListStuck returns up to limit payouts processing since before, oldest
+	// first.
+	ListStuck(ctx context.Context, before time.Time, limit int) ([]*Payout, error)
+	// UpdateStatus persists the status and transfer reference of p if it is
+	// still from, so two workers never move the same payout. Otherwise it
+	// returns ErrInvalidPayoutTransition.
+	UpdateStatus(ctx context.Context, p *Payout, from PayoutStatus) error
+	// Fail persists a failed payout (still in status from) and returns its
+	// amount to the account balance in the same transaction.
+	Fail(ctx context.Context, p *Payout, from PayoutStatus) error
+}
+
+// Domain-level errors for repository implementations.
+var (
+	ErrPayoutNotFound      = Err("payout: not found")
+	ErrBankAccountNotFound = Err("bank account: not found")
+)
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewPayout_Validation(t *testing.T) {
+	p, err := NewPayout("acc-1", "bank-1", 50.25, SystemClock)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Status != PayoutPending {
+		t.Fatalf("expected pending, got %s", p.Status)
+	}
+
+	_, err = NewPayout("acc-1", "", 10.001, SystemClock)
+	if !errors.Is(err, ErrBankAccountRequired) || !errors.Is(err, ErrAmountPrecision) {
+		t.Fatalf("expected bank account and precision errors, got %v", err)
+	}
+	if _, err := NewPayout("acc-1", "bank-1", 0, SystemClock); !errors.Is(err, ErrNegativeValue) {
+		t.Fatalf("expected ErrNegativeValue, got %v", err)
+	}
+}
+
+func TestPayout_Lifecycle(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	p, _ := NewPayout("acc-1", "bank-1", 50, clock)
+	if !p.CreatedAt.Equal(clock.Now()) || !p.UpdatedAt.Equal(clock.Now()) {
+		t.Fatalf("expected the timestamps of the clock, got %+v", p)
+	}
+	if err := p.MarkPaid("ref-1"); !errors.Is(err, ErrInvalidPayoutTransition) {
+		t.Fatalf("expected pending payouts not to be paid directly, got %v", err)
+	}
+	clock.Advance(time.Minute)
+	if err := p.StartProcessing(); err != nil || !p.UpdatedAt.Equal(clock.Now()) {
+		t.Fatalf("start: %+v %v", p, err)
+	}
+	if err := p.MarkPaid("ref-1"); err != nil || p.Status != PayoutPaid || p.TransferReference != "ref-1" {
+		t.Fatalf("expected paid with its reference, got %+v %v", p, err)
+	}
+	if err := p.MarkFailed("late"); !errors.Is(err, ErrInvalidPayoutTransition) {
+		t.Fatalf("expected paid to be final, got %v", err)
+	}
+
+	p, _ = NewPayout("acc-1", "bank-1", 50, SystemClock)
+	_ = p.StartProcessing()
+	if err := p.MarkFailed("account closed"); err != nil || p.Status != PayoutFailed || p.FailureReason != "account closed" {
+		t.Fatalf("expected failed with reason, got %+v %v", p, err)
+	}
+}
+
+func TestPayoutPolicy_Debit(t *testing.T) {
+	pol := PayoutPolicy{MinAmount: 10, ReserveRate: 0.1}
+	a, _ := NewAccount("Jane", "jane@example.com", SystemClock)
+	_ = a.AddBalance(100)
+
+	if got := pol.Withdrawable(a.Balance); got != 90 {
+		t.Fatalf("expected 90 withdrawable, got %v", got)
+	}
+	if err := pol.Debit(a, 5); !errors.Is(err, ErrPayoutBelowMinimum) {
+		t.Fatalf("expected ErrPayoutBelowMinimum, got %v", err)
+	}
+	if err := pol.Debit(a, 90.01); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected reserve to be kept, got %v", err)
+	}
+	if err := pol.Debit(a, 90); err != nil || a.Balance != 10 {
+		t.Fatalf("expected balance 10, got %v %v", a.Balance, err)
+	}
+}
+
+func TestSimulatedBankRail(t *testing.T) {
+	rail := NewSimulatedBankRail()
+	rail.Fail("999-9", "account does not exist")
+	p, _ := NewPayout("acc-1", "bank-1", 50, SystemClock)
+
+	ref, err := rail.Transfer(context.Background(), p, &BankAccount{Number: "123-4"})
+	if err != nil || ref == "" {
+		t.Fatalf("expected success with a reference, got %q %v", ref, err)
+	}
+	// Sending the same payout again returns the first transfer
+	if again, err := rail.Transfer(context.Background(), p, &BankAccount{Number: "123-4"}); err != nil || again != ref {
+		t.Fatalf("expected the first transfer %q, got %q %v", ref, again, err)
+	}
+
+	other, _ := NewPayout("acc-1", "bank-1", 50, SystemClock)
+	_, err = rail.Transfer(context.Background(), other, &BankAccount{Number: "999-9"})
+	var rejected *TransferRejectedError
+	if !errors.As(err, &rejected) || rejected.Reason != "account does not exist" {
+		t.Fatalf("expected configured rejection, got %v", err)
+	}
+	if rail.Calls() != 3 || rail.Transfers() != 1 {
+		t.Fatalf("expected 3 calls and 1 transfer, got %d %d", rail.Calls(), rail.Transfers())
+	}
+}
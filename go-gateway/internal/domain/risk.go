@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidRiskKind     = errors.New("risk: unknown rule kind")
+	ErrInvalidRiskAction   = errors.New("risk: action must be review or reject")
+	ErrInvalidRiskLimit    = errors.New("risk: rule limit must be positive")
+	ErrRiskAmountPrecision = errors.New("risk: amount limit must have at most 2 decimal places")
+	ErrRiskPaymentType     = errors.New("risk: payment type is required to block it")
+	ErrRiskRequiresPending = errors.New("invoice: risk is assessed only on pending invoices")
+)
+
+// RiskDecision is the outcome of the risk rules for a new invoice.
+type RiskDecision string
+
+const (
+	RiskApprove RiskDecision = "approve" // sent to the processor
+	RiskReview  RiskDecision = "review"  // held pending for manual review
+	RiskReject  RiskDecision = "reject"  // rejected without processing
+)
+
+// severity orders decisions so the strictest matching rule wins.
+func (d RiskDecision) severity() int {
+	switch d {
+	case RiskReject:
+		return 2
+	case RiskReview:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// RiskRuleKind identifies what a risk rule checks.
+type RiskRuleKind string
+
+const (
+	// RiskAmountThreshold flags invoices above MaxAmount. A rule for an
+	// account replaces the rules for every account.
+	RiskAmountThreshold RiskRuleKind = "amount_threshold"
+	// RiskCardVelocity flags a card used MaxPerHour times in the last hour.
+	RiskCardVelocity RiskRuleKind = "card_velocity"
+	// RiskNewAccount flags invoices above MaxAmount from accounts younger
+	// than AccountAgeDays.
+	RiskNewAccount RiskRuleKind = "new_account"
+	// RiskPaymentTypeBlock flags every invoice of PaymentType.
+	RiskPaymentTypeBlock RiskRuleKind = "payment_type_block"
+)
+
+// DefaultRiskThreshold is the amount above which invoices are held for
+// review when no other rule is configured.
+const DefaultRiskThreshold = 10000
+
+// RiskRule is one check evaluated before an invoice is processed.
+type RiskRule struct {
+	Kind           RiskRuleKind
+	AccountID      string // empty applies the rule to every account
+	PaymentType    string
+	MaxAmount      float64
+	MaxPerHour     int
+	AccountAgeDays int
+	Action         RiskDecision
+}
+
+// DefaultRiskRules holds invoices above DefaultRiskThreshold for review.
+func DefaultRiskRules() []RiskRule {
+	return []RiskRule{{Kind: RiskAmountThreshold, MaxAmount: DefaultRiskThreshold, Action: RiskReview}}
+}
+
+// Validate reports every invalid field of the rule in a *ValidationError.
+func (r RiskRule) Validate() error {
+	verr := &ValidationError{}
+	switch r.Kind {
+	case RiskAmountThreshold:
+		validateRiskAmount(verr, r.MaxAmount)
+	case RiskCardVelocity:
+		if r.MaxPerHour <= 0 {
+			verr.Add("max_per_hour", ErrInvalidRiskLimit)
+		}
+	case RiskNewAccount:
+		validateRiskAmount(verr, r.MaxAmount)
+		if r.AccountAgeDays <= 0 {
+			verr.Add("account_age_days", ErrInvalidRiskLimit)
+		}
+	case RiskPaymentTypeBlock:
+		if !lengthBetween(r.PaymentType, 1, MaxPaymentTypeLength) {
+			verr.Add("payment_type", ErrRiskPaymentType)
+		}
+	default:
+		verr.Add("kind", ErrInvalidRiskKind)
+	}
+	if r.Action != RiskReview && r.Action != RiskReject {
+		verr.Add("action", ErrInvalidRiskAction)
+	}
+	return verr.OrNil()
+}
+
+func validateRiskAmount(verr *ValidationError, amount float64) {
+	switch {
+	case amount <= 0:
+		verr.Add("max_amount", ErrInvalidRiskLimit)
+	case !hasAtMostTwoDecimals(amount):
+		verr.Add("max_amount", ErrRiskAmountPrecision)
+	}
+}
+
+// RiskInput is what the risk rules know about a new invoice.
+type RiskInput struct {
+	AccountID        string
+	AccountCreatedAt time.Time
+	Amount           float64
+	PaymentType      string
+	CardLastDigits   string
+	// CardUsesLastHour counts the invoices of the account with the same card
+	// in the hour before this one.
+	CardUsesLastHour int
+	Now              time.Time
+}
+
+// RiskAssessment is the decision of the risk rules and why it was taken.
+type RiskAssessment struct {
+	Decision RiskDecision
+	Reasons  []string
+}
+
+// UsesCardVelocity reports whether any rule needs RiskInput.CardUsesLastHour,
+// which costs a query to compute.
+func UsesCardVelocity(rules []RiskRule) bool {
+	for _, r := range rules {
+		if r.Kind == RiskCardVelocity {
+			return true
+		}
+	}
+	return false
+}
+
+// EvaluateRisk runs the rules that apply to the account of the invoice. The
+// strictest action among the matching rules wins and each match adds a
+// reason; no match approves the invoice.
+func EvaluateRisk(rules []RiskRule, in RiskInput) RiskAssessment {
+	ownThreshold := false
+	for _, r := range rules {
+		if r.Kind == RiskAmountThreshold && r.AccountID == in.AccountID {
+			ownThreshold = true
+		}
+	}
+
+	a := RiskAssessment{Decision: RiskApprove}
+	for _, r := range rules {
+		if r.AccountID != "" && r.AccountID != in.AccountID {
+			continue
+		}
+		var reason string
+		switch r.Kind {
+		case RiskAmountThreshold:
+			if r.AccountID == "" && ownThreshold {
+				continue
+			}
+			if in.Amount > r.MaxAmount {
+				reason = fmt.Sprintf("amount %.2f above the limit of %.2f", in.Amount, r.MaxAmount)
+			}
+		case RiskCardVelocity:
+			if in.CardLastDigits != "" && in.CardUsesLastHour >= r.MaxPerHour {
+				reason = fmt.Sprintf("card %s used %d times in the last hour (limit %d)", in.CardLastDigits, in.CardUsesLastHour, r.MaxPerHour)
+			}
+		case RiskNewAccount:
+			age := in.Now.Sub(in.AccountCreatedAt)
+			if age < time.Duration(r.AccountAgeDays)*24*time.Hour && in.Amount > r.MaxAmount {
+				reason = fmt.Sprintf("amount %.2f above the limit of %.2f for accounts younger than %d days", in.Amount, r.MaxAmount, r.AccountAgeDays)
+			}
+		case RiskPaymentTypeBlock:
+			if in.PaymentType == r.PaymentType {
+				reason = fmt.Sprintf("payment type %s is blocked", r.PaymentType)
+			}
+		}
+		if reason == "" {
+			continue
+		}
+		a.Reasons = append(a.Reasons, string(r.Kind)+": "+reason)
+		if r.Action.severity() > a.Decision.severity() {
+			a.Decision = r.Action
+		}
+	}
+	return a
+}
@@ -0,0 +1,8 @@
+package domain
+
+import "context"
+
+// RiskRuleRepository loads the risk rules evaluated on new invoices.
+type RiskRuleRepository interface {
+	ListRules(ctx context.Context) ([]RiskRule, error)
+}
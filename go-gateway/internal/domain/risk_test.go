@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRiskRule_Validate(t *testing.T) {
+	valid := []RiskRule{
+		{Kind: RiskAmountThreshold, MaxAmount: 10000, Action: RiskReview},
+		{Kind: RiskCardVelocity, MaxPerHour: 5, Action: RiskReject},
+		{Kind: RiskNewAccount, MaxAmount: 500, AccountAgeDays: 7, Action: RiskReview},
+		{Kind: RiskPaymentTypeBlock, PaymentType: "boleto", Action: RiskReject},
+	}
+	for _, r := range valid {
+		if err := r.Validate(); err != nil {
+			t.Errorf("%+v: unexpected error %v", r, err)
+		}
+	}
+
+	tests := []struct {
+		rule RiskRule
+		want []error
+	}{
+		{RiskRule{Kind: "unknown", Action: RiskApprove}, []error{ErrInvalidRiskKind, ErrInvalidRiskAction}},
+		{RiskRule{Kind: RiskAmountThreshold, MaxAmount: 10.001, Action: RiskReview}, []error{ErrRiskAmountPrecision}},
+		{RiskRule{Kind: RiskCardVelocity, Action: RiskReview}, []error{ErrInvalidRiskLimit}},
+		{RiskRule{Kind: RiskNewAccount, MaxAmount: 100, Action: RiskReview}, []error{ErrInvalidRiskLimit}},
+		{RiskRule{Kind: RiskPaymentTypeBlock, Action: RiskReject}, []error{ErrRiskPaymentType}},
+	}
+	for _, tt := range tests {
+		err := tt.rule.Validate()
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Fields) != len(tt.want) {
+			t.Errorf("%+v: expected %d field errors, got %v", tt.rule, len(tt.want), err)
+			continue
+		}
+		for _, want := range tt.want {
+			if !errors.Is(err, want) {
+				t.Errorf("%+v: expected %v in %v", tt.rule, want, err)
+			}
+		}
+	}
+}
+
+func TestEvaluateRisk(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	base := RiskInput{AccountID: "acc-1", AccountCreatedAt: now.AddDate(-1, 0, 0), Amount: 100, PaymentType: "credit_card", CardLastDigits: "1234", Now: now}
+	with := func(f func(*RiskInput)) RiskInput {
+		in := base
+		f(&in)
+		return in
+	}
+
+	rules := []RiskRule{
+		{Kind: RiskAmountThreshold, MaxAmount: 10000, Action: RiskReview},
+		{Kind: RiskAmountThreshold, AccountID: "acc-2", MaxAmount: 50000, Action: RiskReview},
+		{Kind: RiskCardVelocity, MaxPerHour: 3, Action: RiskReject},
+		{Kind: RiskNewAccount, MaxAmount: 500, AccountAgeDays: 7, Action: RiskReview},
+		{Kind: RiskPaymentTypeBlock, PaymentType: "boleto", Action: RiskReject},
+	}
+	tests := []struct {
+		name    string
+		in      RiskInput
+		want    RiskDecision
+		reasons []string
+	}{
+		{"no rule matches", base, RiskApprove, nil},
+		{"amount at the threshold", with(func(in *RiskInput) { in.Amount = 10000 }), RiskApprove, nil},
+		{"amount above the threshold", with(func(in *RiskInput) { in.Amount = 10000.01 }), RiskReview,
+			[]string{"amount_threshold: amount 10000.01 above the limit of 10000.00"}},
+		{"account threshold replaces the global one", with(func(in *RiskInput) { in.AccountID = "acc-2"; in.Amount = 20000 }), RiskApprove, nil},
+		{"card used too often", with(func(in *RiskInput) { in.CardUsesLastHour = 3 }), RiskReject,
+			[]string{"card_velocity: card 1234 used 3 times in the last hour (limit 3)"}},
+		{"velocity needs a card", with(func(in *RiskInput) { in.CardLastDigits = ""; in.CardUsesLastHour = 3 }), RiskApprove, nil},
+		{"new account above its limit", with(func(in *RiskInput) { in.AccountCreatedAt = now.AddDate(0, 0, -2); in.Amount = 600 }), RiskReview,
+			[]string{"new_account: amount 600.00 above the limit of 500.00 for accounts younger than 7 days"}},
+		{"old account above the new account limit", with(func(in *RiskInput) { in.Amount = 600 }), RiskApprove, nil},
+		{"strictest action wins", with(func(in *RiskInput) { in.PaymentType = "boleto"; in.Amount = 20000 }), RiskReject,
+			[]string{"amount_threshold: amount 20000.00 above the limit of 10000.00", "payment_type_block: payment type boleto is blocked"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EvaluateRisk(rules, tt.in)
+			if got.Decision != tt.want || !reflect.DeepEqual(got.Reasons, tt.reasons) {
+				t.Fatalf("got %v %q, want %v %q", got.Decision, got.Reasons, tt.want, tt.reasons)
+			}
+		})
+	}
+
+	if !UsesCardVelocity(rules) || UsesCardVelocity(DefaultRiskRules()) {
+		t.Fatal("UsesCardVelocity should only report rule sets with a card velocity rule")
+	}
+}
+
+func TestInvoice_ApplyRisk(t *testing.T) {
+	processor := NewTestInvoiceProcessor()
+	i, _ := NewInvoiceWithProcessor("acc-1", "High value invoice", "credit_card", 15000, "1234", processor, SystemClock)
+	if i.RiskDecision != RiskApprove {
+		t.Fatalf("expected new invoices to be approved by default, got %v", i.RiskDecision)
+	}
+
+	review := EvaluateRisk(DefaultRiskRules(), RiskInput{AccountID: "acc-1", Amount: i.Amount})
+	if err := i.ApplyRisk(review); err != nil {
+		t.Fatalf("apply risk: %v", err)
+	}
+	if i.Status != StatusPending || i.RiskDecision != RiskReview || len(i.RiskReasons) != 1 {
+		t.Fatalf("expected a pending invoice under review, got %v %v %q", i.Status, i.RiskDecision, i.RiskReasons)
+	}
+
+	rejected, _ := NewInvoiceWithProcessor("acc-1", "Blocked invoice", "boleto", 50, "", processor, SystemClock)
+	if err := rejected.ApplyRisk(RiskAssessment{Decision: RiskReject, Reasons: []string{"blocked"}}); err != nil {
+		t.Fatalf("apply risk: %v", err)
+	}
+	if rejected.Status != StatusRejected {
+		t.Fatalf("expected a rejected invoice, got %v", rejected.Status)
+	}
+	if err := rejected.ApplyRisk(RiskAssessment{Decision: RiskApprove}); !errors.Is(err, ErrRiskRequiresPending) {
+		t.Fatalf("expected ErrRiskRequiresPending, got %v", err)
+	}
+}
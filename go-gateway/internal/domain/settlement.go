@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSettlementNotPending = errors.New("settlement: already settled")
+)
+
+// SettlementStatus is the state of approved money held until its settlement
+// date.
+type SettlementStatus string
+
+const (
+	SettlementPending SettlementStatus = "pending"
+	SettlementSettled SettlementStatus = "settled"
+)
+
+// Settlement holds the net amount of an approved invoice, or of one of its
+// installments, in the pending balance of its account until AvailableAt.
+type Settlement struct {
+	ID          string
+	AccountID   string
+	InvoiceID   string
+	Installment int // 1 for invoices paid at once
+	Amount      float64
+	Status      SettlementStatus
+	AvailableAt time.Time
+	CreatedAt   time.Time
+	SettledAt   *time.Time
+}
+
+// NewSettlement holds the net amount of an approved invoice until availableAt.
+func NewSettlement(i *Invoice, availableAt time.Time) *Settlement {
+	return &Settlement{
+		ID:          uuid.New().String(),
+		AccountID:   i.AccountID,
+		InvoiceID:   i.ID,
+		Installment: 1,
+		Amount:      i.NetAmount,
+		Status:      SettlementPending,
+		AvailableAt: availableAt,
+		CreatedAt:   clockOrSystem(i.clock).Now(),
+	}
+}
+
+// NewInstallmentSettlements splits the net amount of an approved invoice into
+// one settlement per installment, in cents that differ by at most one. Each
+// installment is approved on its due date, or at approval for those already
+// due, and becomes available according to schedule. Some may already be
+// available at approval: their money needs no settlement.
+func NewInstallmentSettlements(i *Invoice, schedule SettlementSchedule) []*Settlement {
+	n := len(i.Schedule)
+	if n == 0 {
+		n = 1
+	}
+	amounts := splitCents(i.NetAmount, n)
+	out := make([]*Settlement, 0, n)
+	for k, amount := range amounts {
+		if amount <= 0 {
+			continue
+		}
+		approvedAt := i.UpdatedAt
+		if k < len(i.Schedule) && i.Schedule[k].DueAt.After(approvedAt) {
+			approvedAt = i.Schedule[k].DueAt
+		}
+		s := NewSettlement(i, schedule.AvailableAt(i.PaymentType, approvedAt))
+		s.Installment = k + 1
+		s.Amount = amount
+		out = append(out, s)
+	}
+	return out
+}
+
+// MarkSettled records that the amount was released to the available balance
+// at now.
+func (s *Settlement) MarkSettled(now time.Time) error {
+	if s.Status != SettlementPending {
+		return ErrSettlementNotPending
+	}
+	s.Status = SettlementSettled
+	s.SettledAt = &now
+	return nil
+}
+
+// SettlementSchedule is the settlement delay (D+N, in days) of each payment
+// type. Payment types not listed use Default.
+type SettlementSchedule struct {
+	Delays  map[string]int
+	Default int
+}
+
+// Delay returns the number of days approved money of paymentType is held.
+func (s SettlementSchedule) Delay(paymentType string) int {
+	if d, ok := s.Delays[paymentType]; ok {
+		return d
+	}
+	return s.Default
+}
+
+// AvailableAt returns when money approved at approvedAt becomes available:
+// immediately for D+0, otherwise at the start (UTC) of the Nth day after.
+func (s SettlementSchedule) AvailableAt(paymentType string, approvedAt time.Time) time.Time {
+	days := s.Delay(paymentType)
+	if days <= 0 {
+		return approvedAt
+	}
+	y, m, d := approvedAt.UTC().Date()
+	return time.Date(y, m, d+days, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// SettlementRepository stores settlements together with the pending balance
+// of their account.
+type SettlementRepository interface {
+	// Create adds the settlement amount to the pending balance of its account.
+	Create(ctx context.Context, s *Settlement) error
+	// ListDue returns up to limit pending settlements available at now,
+	// oldest first.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*Settlement, error)
+	// Release moves the amount of a settlement marked settled from the
+	// pending to the available balance. It returns ErrSettlementNotPending
+	// when the settlement was already released.
+	Release(ctx context.Context, s *Settlement) error
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSettlementSchedule_AvailableAt(t *testing.T) {
+	schedule := SettlementSchedule{Delays: map[string]int{"credit_card": 30, "pix": 0}, Default: 2}
+	approved := time.Date(2026, 10, 18, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		paymentType string
+		want        time.Time
+	}{
+		{"pix", approved},
+		{"credit_card", time.Date(2026, 11, 17, 0, 0, 0, 0, time.UTC)},
+		{"boleto", time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := schedule.AvailableAt(tt.paymentType, approved); !got.Equal(tt.want) {
+			t.Errorf("AvailableAt(%s) = %v, want %v", tt.paymentType, got, tt.want)
+		}
+	}
+}
+
+func TestSettlement_MarkSettled(t *testing.T) {
+	i, _ := NewInvoiceWithProcessor("acc-1", "Order 1", "credit_card", 100, "", NewTestInvoiceProcessor(), SystemClock)
+	_ = i.Process()
+	_ = i.ApplyFee(FeePlan{Percent: 1})
+	availableAt := time.Now().Add(24 * time.Hour)
+
+	s := NewSettlement(i, availableAt)
+	if s.Amount != 99 || s.Status != SettlementPending || s.InvoiceID != i.ID || !s.AvailableAt.Equal(availableAt) {
+		t.Fatalf("unexpected settlement %+v", s)
+	}
+	if err := s.MarkSettled(time.Now().UTC()); err != nil || s.Status != SettlementSettled || s.SettledAt == nil {
+		t.Fatalf("expected settled, got %+v %v", s, err)
+	}
+	if err := s.MarkSettled(time.Now().UTC()); !errors.Is(err, ErrSettlementNotPending) {
+		t.Fatalf("expected ErrSettlementNotPending, got %v", err)
+	}
+}
+
+func TestNewInstallmentSettlements(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC))
+	i, _ := NewInvoiceWithProcessor("acc-1", "Order 1", "credit_card", 100, "1234", NewTestInvoiceProcessor(), clock)
+	_ = i.SetInstallments(3, DefaultInstallmentRules("acc-1"))
+	_ = i.Process()
+	_ = i.ApplyFee(FeePlan{Percent: 1})
+
+	schedule := SettlementSchedule{Delays: map[string]int{"credit_card": 30}}
+	got := NewInstallmentSettlements(i, schedule)
+	if len(got) != 3 {
+		t.Fatalf("expected one settlement per installment, got %d", len(got))
+	}
+	want := []struct {
+		amount      float64
+		availableAt time.Time
+	}{
+		{33, time.Date(2026, 11, 17, 0, 0, 0, 0, time.UTC)},
+		{33, time.Date(2026, 12, 18, 0, 0, 0, 0, time.UTC)},
+		{33, time.Date(2027, 1, 17, 0, 0, 0, 0, time.UTC)},
+	}
+	for k, s := range got {
+		if s.Installment != k+1 || s.Amount != want[k].amount || !s.AvailableAt.Equal(want[k].availableAt) || s.InvoiceID != i.ID {
+			t.Errorf("settlement %d = %+v, want %+v", k+1, s, want[k])
+		}
+	}
+
+	// Paid at once, the whole net amount settles together
+	single, _ := NewInvoiceWithProcessor("acc-1", "Order 2", "credit_card", 100, "1234", NewTestInvoiceProcessor(), clock)
+	_ = single.Process()
+	_ = single.ApplyFee(FeePlan{Percent: 1})
+	if got := NewInstallmentSettlements(single, schedule); len(got) != 1 || got[0].Amount != 99 || got[0].Installment != 1 {
+		t.Fatalf("expected a single settlement, got %+v", got)
+	}
+}
@@ -0,0 +1,283 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidPlanName               = errors.New("plan: name must have between 3 and 100 characters")
+	ErrInvalidInterval               = errors.New("plan: interval must be day, week, month or year")
+	ErrInvalidIntervalCount          = errors.New("plan: interval count must be between 1 and 12")
+	ErrInvalidTrialDays              = errors.New("plan: trial days must be between 0 and 365")
+	ErrPlanRequired                  = errors.New("subscription: plan ID is required")
+	ErrInvalidCardToken              = errors.New("subscription: card token must have between 8 and 255 characters")
+	ErrCardDigitsRequired            = errors.New("subscription: card last digits must be exactly 4 digits")
+	ErrInvalidAnchor                 = errors.New("subscription: anchor date must not be before today")
+	ErrInvalidSubscriptionTransition = errors.New("subscription: invalid status transition")
+	ErrSubscriptionClaimed           = errors.New("subscription: cycle already claimed")
+)
+
+// Plan limits.
+const (
+	MaxIntervalCount = 12
+	MaxTrialDays     = 365
+)
+
+// SubscriptionPaymentType is the payment type of the invoices of a
+// subscription, charged to its tokenized card.
+const SubscriptionPaymentType = "credit_card"
+
+// BillingInterval is the unit of a plan's billing cycle.
+type BillingInterval string
+
+const (
+	IntervalDay   BillingInterval = "day"
+	IntervalWeek  BillingInterval = "week"
+	IntervalMonth BillingInterval = "month"
+	IntervalYear  BillingInterval = "year"
+)
+
+func (i BillingInterval) valid() bool {
+	switch i {
+	case IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+		return true
+	}
+	return false
+}
+
+// add returns t plus n intervals. Months and years keep the day of t and
+// fall back to the last day of shorter months, so a cycle anchored on
+// January 31 bills on February 28 and then March 31.
+func (i BillingInterval) add(t time.Time, n int) time.Time {
+	switch i {
+	case IntervalDay:
+		return t.AddDate(0, 0, n)
+	case IntervalWeek:
+		return t.AddDate(0, 0, 7*n)
+	case IntervalYear:
+		n *= 12
+	}
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// Plan is a recurring price a merchant charges its subscribers.
+type Plan struct {
+	ID            string
+	AccountID     string
+	Name          string
+	Amount        float64
+	Interval      BillingInterval
+	IntervalCount int // bills every IntervalCount intervals
+	TrialDays     int
+	CreatedAt     time.Time
+}
+
+// NewPlan validates and builds a plan created at now.
+func NewPlan(accountID, name string, amount float64, interval BillingInterval, intervalCount, trialDays int, now time.Time) (*Plan, error) {
+	verr := &ValidationError{}
+	if accountID == "" {
+		verr.Add("account_id", ErrAccountIDRequired)
+	}
+	if !lengthBetween(name, 3, 100) {
+		verr.Add("name", ErrInvalidPlanName)
+	}
+	switch {
+	case amount <= 0:
+		verr.Add("amount", ErrInvoiceNegativeValue)
+	case amount > MaxInvoiceAmount:
+		verr.Add("amount", ErrAmountTooLarge)
+	case !hasAtMostTwoDecimals(amount):
+		verr.Add("amount", ErrAmountPrecision)
+	}
+	if !interval.valid() {
+		verr.Add("interval", ErrInvalidInterval)
+	}
+	if intervalCount < 1 || intervalCount > MaxIntervalCount {
+		verr.Add("interval_count", ErrInvalidIntervalCount)
+	}
+	if trialDays < 0 || trialDays > MaxTrialDays {
+		verr.Add("trial_days", ErrInvalidTrialDays)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	return &Plan{
+		ID:            uuid.New().String(),
+		AccountID:     accountID,
+		Name:          name,
+		Amount:        amount,
+		Interval:      interval,
+		IntervalCount: intervalCount,
+		TrialDays:     trialDays,
+		CreatedAt:     now,
+	}, nil
+}
+
+// SubscriptionStatus is the lifecycle state of a subscription:
+// trialing → active ⇄ past_due → canceled. Any state can be canceled.
+type SubscriptionStatus string
+
+const (
+	SubscriptionTrialing SubscriptionStatus = "trialing"
+	SubscriptionActive   SubscriptionStatus = "active"
+	SubscriptionPastDue  SubscriptionStatus = "past_due"
+	SubscriptionCanceled SubscriptionStatus = "canceled"
+)
+
+// Subscription charges the price of a plan to a tokenized card at every
+// cycle. The price is copied from the plan when subscribing.
+type Subscription struct {
+	ID             string
+	AccountID      string
+	PlanID         string
+	Description    string // plan name, used on the invoices
+	Amount         float64
+	Interval       BillingInterval
+	IntervalCount  int
+	CardToken      string // issued by the card vault; the card number is never stored
+	CardLastDigits string
+	Status         SubscriptionStatus
+	// AnchorAt is when the first cycle starts; cycle n bills at AnchorAt plus
+	// n times the plan interval.
+	AnchorAt       time.Time
+	TrialEndsAt    *time.Time
+	Cycle          int // next cycle to bill
+	NextBillingAt  time.Time
+	FailedAttempts int // charges of the current cycle that failed
+	LastInvoiceID  string
+	CanceledAt     *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// NewSubscription subscribes a card to plan at now. A zero anchor starts the
+// first cycle at now; a trial postpones the first charge to the first cycle
+// after it ends.
+func NewSubscription(plan *Plan, cardToken, cardLastDigits string, anchor, now time.Time) (*Subscription, error) {
+	verr := &ValidationError{}
+	if plan == nil {
+		verr.Add("plan_id", ErrPlanRequired)
+	}
+	if !lengthBetween(cardToken, 8, 255) {
+		verr.Add("card_token", ErrInvalidCardToken)
+	}
+	if !isCardLastDigits(cardLastDigits) {
+		verr.Add("card_last_digits", ErrCardDigitsRequired)
+	}
+	if anchor.IsZero() {
+		anchor = now
+	} else if anchor.Before(now.Truncate(24 * time.Hour)) {
+		verr.Add("anchor_date", ErrInvalidAnchor)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	s := &Subscription{
+		ID:             uuid.New().String(),
+		AccountID:      plan.AccountID,
+		PlanID:         plan.ID,
+		Description:    plan.Name,
+		Amount:         plan.Amount,
+		Interval:       plan.Interval,
+		IntervalCount:  plan.IntervalCount,
+		CardToken:      cardToken,
+		CardLastDigits: cardLastDigits,
+		Status:         SubscriptionActive,
+		AnchorAt:       anchor,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if plan.TrialDays > 0 {
+		trialEnd := now.AddDate(0, 0, plan.TrialDays)
+		s.Status = SubscriptionTrialing
+		s.TrialEndsAt = &trialEnd
+		for s.CycleAt(s.Cycle).Before(trialEnd) {
+			s.Cycle++
+		}
+	}
+	s.NextBillingAt = s.CycleAt(s.Cycle)
+	return s, nil
+}
+
+// CycleAt returns when cycle n bills.
+func (s *Subscription) CycleAt(n int) time.Time {
+	return s.Interval.add(s.AnchorAt, n*s.IntervalCount)
+}
+
+// ChargeKey is the idempotency key of the invoice charging the current
+// attempt of the current cycle. A replica retrying a charge left unrecorded
+// by one that died gets the invoice already created instead of charging
+// again.
+func (s *Subscription) ChargeKey() string {
+	return fmt.Sprintf("subscription/%s/%d/%d", s.ID, s.Cycle, s.FailedAttempts)
+}
+
+// Due reports whether the subscription has a charge to make at now.
+func (s *Subscription) Due(now time.Time) bool {
+	return s.Status != SubscriptionCanceled && !s.NextBillingAt.After(now)
+}
+
+// RecordCharge applies the outcome of the charge of the current cycle. A paid
+// cycle moves the subscription to the next one; a failed charge makes it
+// past due and is retried according to the dunning policy, which cancels the
+// subscription once its retries are exhausted.
+func (s *Subscription) RecordCharge(invoiceID string, paid bool, policy DunningPolicy, now time.Time) error {
+	if s.Status == SubscriptionCanceled {
+		return ErrInvalidSubscriptionTransition
+	}
+	if invoiceID != "" {
+		s.LastInvoiceID = invoiceID
+	}
+	s.UpdatedAt = now
+	if paid {
+		s.Status = SubscriptionActive
+		s.FailedAttempts = 0
+		s.Cycle++
+		s.NextBillingAt = s.CycleAt(s.Cycle)
+		return nil
+	}
+
+	s.FailedAttempts++
+	if s.FailedAttempts > len(policy.RetryDelays) {
+		s.Status = SubscriptionCanceled
+		s.CanceledAt = &now
+		return nil
+	}
+	s.Status = SubscriptionPastDue
+	s.NextBillingAt = now.Add(policy.RetryDelays[s.FailedAttempts-1])
+	return nil
+}
+
+// Cancel stops the subscription; no further cycle is charged.
+func (s *Subscription) Cancel(now time.Time) error {
+	if s.Status == SubscriptionCanceled {
+		return ErrInvalidSubscriptionTransition
+	}
+	s.Status = SubscriptionCanceled
+	s.CanceledAt = &now
+	s.UpdatedAt = now
+	return nil
+}
+
+// DunningPolicy decides how failed subscription charges are retried.
+// RetryDelays[i] is the wait after the (i+1)-th consecutive failure; the
+// subscription is canceled when a charge fails after the last retry.
+type DunningPolicy struct {
+	RetryDelays []time.Duration
+}
+
+// DefaultDunningPolicy retries a failed charge after 1, 3 and 5 days.
+func DefaultDunningPolicy() DunningPolicy {
+	return DunningPolicy{RetryDelays: []time.Duration{24 * time.Hour, 72 * time.Hour, 120 * time.Hour}}
+}
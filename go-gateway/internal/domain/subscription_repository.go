@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// SubscriptionRepository defines persistence operations for plans and
+// subscriptions. Merchant lookups are scoped to the owning account.
+type SubscriptionRepository interface {
+	CreatePlan(ctx context.Context, p *Plan) error
+	GetPlan(ctx context.Context, accountID, id string) (*Plan, error)
+	// ListPlans returns the plans of an account, newest first.
+	ListPlans(ctx context.Context, accountID string) ([]*Plan, error)
+
+	Create(ctx context.Context, s *Subscription) error
+	GetByID(ctx context.Context, accountID, id string) (*Subscription, error)
+	// ListByAccount returns the subscriptions of an account, newest first.
+	ListByAccount(ctx context.Context, accountID string) ([]*Subscription, error)
+	// ListDue returns up to limit subscriptions with a charge due at now,
+	// oldest first.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*Subscription, error)
+	// Claim postpones the next billing of s to until if it is still
+	// s.NextBillingAt, so a single worker charges each cycle. Otherwise it
+	// returns ErrSubscriptionClaimed. s is left unchanged.
+	Claim(ctx context.Context, s *Subscription, until time.Time) error
+	// Update persists the billing state and status of s unless the stored
+	// subscription is canceled, so a charge that raced a cancellation cannot
+	// reactivate it; then it returns ErrInvalidSubscriptionTransition.
+	Update(ctx context.Context, s *Subscription) error
+}
+
+// Domain-level errors for repository implementations.
+var (
+	ErrPlanNotFound         = Err("plan: not found")
+	ErrSubscriptionNotFound = Err("subscription: not found")
+)
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewPlan_Validation(t *testing.T) {
+	if _, err := NewPlan("acc-1", "Pro", 49.9, IntervalMonth, 1, 7, time.Now().UTC()); err != nil {
+		t.Fatalf("expected a valid plan, got %v", err)
+	}
+
+	_, err := NewPlan("acc-1", "P", 0, "fortnight", 13, -1, time.Now().UTC())
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 5 {
+		t.Fatalf("expected 5 field errors, got %v", err)
+	}
+}
+
+func TestBillingInterval_MonthEnd(t *testing.T) {
+	anchor := time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC)
+	s := &Subscription{Interval: IntervalMonth, IntervalCount: 1, AnchorAt: anchor}
+
+	want := []time.Time{
+		anchor,
+		time.Date(2026, 2, 28, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 4, 30, 9, 0, 0, 0, time.UTC),
+	}
+	for n, w := range want {
+		if got := s.CycleAt(n); !got.Equal(w) {
+			t.Errorf("CycleAt(%d) = %v, want %v", n, got, w)
+		}
+	}
+
+	leap := &Subscription{Interval: IntervalYear, IntervalCount: 1, AnchorAt: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)}
+	if got := leap.CycleAt(1); !got.Equal(time.Date(2029, 2, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected February 28 after a leap year, got %v", got)
+	}
+}
+
+func TestNewSubscription_Trial(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	plan, _ := NewPlan("acc-1", "Pro", 49.9, IntervalWeek, 1, 10, time.Now().UTC())
+
+	s, err := NewSubscription(plan, "tok_12345678", "4242", time.Time{}, now)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if s.Status != SubscriptionTrialing || s.TrialEndsAt == nil || s.AccountID != "acc-1" || s.Amount != 49.9 {
+		t.Fatalf("unexpected subscription %+v", s)
+	}
+	// The trial ends on day 10, so the first charge is the cycle of day 14
+	if s.Cycle != 2 || !s.NextBillingAt.Equal(now.AddDate(0, 0, 14)) {
+		t.Fatalf("expected the first charge after the trial, got cycle %d at %v", s.Cycle, s.NextBillingAt)
+	}
+	if s.Due(now) {
+		t.Fatal("expected nothing due during the trial")
+	}
+}
+
+func TestNewSubscription_Validation(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	plan, _ := NewPlan("acc-1", "Pro", 49.9, IntervalMonth, 1, 0, time.Now().UTC())
+
+	_, err := NewSubscription(plan, "short", "42", now.AddDate(0, 0, -2), now)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 3 {
+		t.Fatalf("expected 3 field errors, got %v", err)
+	}
+
+	// An anchor earlier today is accepted and bills right away
+	s, err := NewSubscription(plan, "tok_12345678", "4242", now.Add(-time.Hour), now)
+	if err != nil || !s.Due(now) {
+		t.Fatalf("expected a due subscription, got %+v %v", s, err)
+	}
+}
+
+func TestSubscription_Dunning(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	plan, _ := NewPlan("acc-1", "Pro", 49.9, IntervalMonth, 1, 0, time.Now().UTC())
+	s, _ := NewSubscription(plan, "tok_12345678", "4242", time.Time{}, now)
+	policy := DunningPolicy{RetryDelays: []time.Duration{24 * time.Hour, 72 * time.Hour}}
+
+	_ = s.RecordCharge("inv-1", false, policy, now)
+	if s.Status != SubscriptionPastDue || s.FailedAttempts != 1 || !s.NextBillingAt.Equal(now.Add(24*time.Hour)) || s.Cycle != 0 {
+		t.Fatalf("expected a retry in 1 day, got %+v", s)
+	}
+
+	// A paid retry resumes the original schedule
+	_ = s.RecordCharge("inv-2", true, policy, now.Add(24*time.Hour))
+	if s.Status != SubscriptionActive || s.FailedAttempts != 0 || s.Cycle != 1 || !s.NextBillingAt.Equal(time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the next cycle, got %+v", s)
+	}
+
+	for i := 0; i < 2; i++ {
+		_ = s.RecordCharge("", false, policy, now)
+	}
+	if s.Status != SubscriptionPastDue || s.FailedAttempts != 2 {
+		t.Fatalf("expected a second retry, got %+v", s)
+	}
+	_ = s.RecordCharge("", false, policy, now)
+	if s.Status != SubscriptionCanceled || s.CanceledAt == nil || s.Due(now.AddDate(1, 0, 0)) {
+		t.Fatalf("expected the subscription to be canceled after the last retry, got %+v", s)
+	}
+	if err := s.RecordCharge("", true, policy, now); !errors.Is(err, ErrInvalidSubscriptionTransition) {
+		t.Fatalf("expected ErrInvalidSubscriptionTransition, got %v", err)
+	}
+	if err := s.Cancel(now); !errors.Is(err, ErrInvalidSubscriptionTransition) {
+		t.Fatalf("expected ErrInvalidSubscriptionTransition, got %v", err)
+	}
+}
+
+func TestSubscription_ChargeKey(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	plan, _ := NewPlan("acc-1", "Pro", 49.9, IntervalMonth, 1, 0, now)
+	s, _ := NewSubscription(plan, "tok_12345678", "4242", time.Time{}, now)
+	policy := DunningPolicy{RetryDelays: []time.Duration{24 * time.Hour}}
+
+	first := s.ChargeKey()
+	if first != "subscription/"+s.ID+"/0/0" || s.ChargeKey() != first {
+		t.Fatalf("expected a stable key for cycle 0, got %q", first)
+	}
+	_ = s.RecordCharge("inv-1", false, policy, now)
+	retry := s.ChargeKey()
+	if retry == first {
+		t.Fatal("expected a retry to get its own key")
+	}
+	_ = s.RecordCharge("inv-2", true, policy, now)
+	if next := s.ChargeKey(); next == first || next == retry {
+		t.Fatalf("expected the next cycle to get its own key, got %q", next)
+	}
+}
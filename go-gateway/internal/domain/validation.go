@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"math"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError is a validation failure on a single input field. Err is one of
+// the package sentinel errors (ErrInvalidName, ErrInvalidEmail...).
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+// ValidationError reports every invalid field at once instead of stopping at
+// the first failure. It unwraps to the sentinel errors, so errors.Is keeps
+// working for callers that check a specific rule.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Err.Error()
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i, f := range e.Fields {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// Add records an invalid field.
+func (e *ValidationError) Add(field string, err error) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Err: err})
+}
+
+// OrNil returns e when it holds any field error, nil otherwise.
+func (e *ValidationError) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Field limits shared by accounts and invoices.
+const (
+	MinNameLength        = 2
+	MaxNameLength        = 100
+	MaxEmailLength       = 254
+	MinDescriptionLength = 3
+	MaxDescriptionLength = 255
+	// MaxInvoiceAmount is the largest value accepted by the DECIMAL(10,2) columns.
+	MaxInvoiceAmount = 99999999.99
+)
+
+// isValidEmail accepts plain RFC 5322 addresses (no display name) whose
+// domain has at least one dot.
+func isValidEmail(email string) bool {
+	if len(email) > MaxEmailLength {
+		return false
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return false
+	}
+	at := strings.LastIndex(email, "@")
+	domain := email[at+1:]
+	return strings.Contains(domain, ".") && !strings.HasPrefix(domain, ".") && !strings.HasSuffix(domain, ".")
+}
+
+// lengthBetween checks the number of characters (not bytes) of s.
+func lengthBetween(s string, min, max int) bool {
+	n := utf8.RuneCountInString(strings.TrimSpace(s))
+	return n >= min && n <= max
+}
+
+// hasAtMostTwoDecimals reports whether amount has no fractional cents.
+func hasAtMostTwoDecimals(amount float64) bool {
+	cents := amount * 100
+	return math.Abs(cents-math.Round(cents)) < 1e-6
+}
+
+// isCardLastDigits reports whether s is exactly four ASCII digits.
+func isCardLastDigits(s string) bool {
+	if len(s) != 4 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigits reports whether s has between min and max ASCII digits and nothing else.
+func isDigits(s string, min, max int) bool {
+	if len(s) < min || len(s) > max {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// roundCents rounds amount to the nearest cent.
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
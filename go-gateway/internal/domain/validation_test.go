@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIsValidEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		valid bool
+	}{
+		{"john@example.com", true},
+		{"john.doe+tag@mail.example.com.br", true},
+		{"john@example", false},
+		{"john@.example.com", false},
+		{"john@example.com.", false},
+		{"@example.com", false},
+		{"john", false},
+		{"John <john@example.com>", false},
+		{"john@@example.com", false},
+		{strings.Repeat("a", 250) + "@example.com", false},
+	}
+	for _, tt := range tests {
+		if got := isValidEmail(tt.email); got != tt.valid {
+			t.Errorf("isValidEmail(%q) = %v, want %v", tt.email, got, tt.valid)
+		}
+	}
+}
+
+func TestIsCardLastDigits(t *testing.T) {
+	for s, want := range map[string]bool{"1234": true, "0000": true, "123": false, "12345": false, "12a4": false, "": false} {
+		if got := isCardLastDigits(s); got != want {
+			t.Errorf("isCardLastDigits(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestHasAtMostTwoDecimals(t *testing.T) {
+	for amount, want := range map[float64]bool{100: true, 10.5: true, 0.1 + 0.2: true, 99.99: true, 10.001: false, 1.999: false} {
+		if got := hasAtMostTwoDecimals(amount); got != want {
+			t.Errorf("hasAtMostTwoDecimals(%v) = %v, want %v", amount, got, want)
+		}
+	}
+}
+
+func TestNewAccount_ValidationErrors(t *testing.T) {
+	_, err := NewAccount("A", "not-an-email", SystemClock)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError got %v", err)
+	}
+	if len(verr.Fields) != 2 {
+		t.Fatalf("expected 2 field errors got %+v", verr.Fields)
+	}
+	if !errors.Is(err, ErrInvalidName) || !errors.Is(err, ErrInvalidEmail) {
+		t.Fatalf("expected both sentinels, got %v", err)
+	}
+
+	if _, err := NewAccount(strings.Repeat("x", MaxNameLength+1), "a@example.com", SystemClock); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected ErrInvalidName for long name, got %v", err)
+	}
+}
+
+func TestNewInvoice_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount float64
+		desc   string
+		digits string
+		want   error
+	}{
+		{"too large", MaxInvoiceAmount + 1, "Valid description", "1234", ErrAmountTooLarge},
+		{"precision", 10.001, "Valid description", "1234", ErrAmountPrecision},
+		{"long description", 10, strings.Repeat("d", MaxDescriptionLength+1), "1234", ErrInvalidDescription},
+		{"card digits", 10, "Valid description", "12a4", ErrInvalidCardDigits},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewInvoice("acc-1", tt.desc, "credit_card", tt.amount, tt.digits, SystemClock)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v got %v", tt.want, err)
+			}
+		})
+	}
+
+	_, err := NewInvoice("", "ab", "", -1, "", SystemClock)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) < 4 {
+		t.Fatalf("expected every invalid field reported, got %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+// Package migrate applies the embedded SQL migrations. It keeps its state in
+// the same schema_migrations table used by golang-migrate, so databases
+// migrated with the CLI keep working.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// lockID is the Postgres advisory lock key that serializes migrations across
+// replicas starting at the same time.
+const lockID int64 = 7346021958
+
+var (
+	ErrDirty       = errors.New("migrate: database is dirty, fix it manually and force the version")
+	ErrNoMigration = errors.New("migrate: no migration to roll back")
+)
+
+var fileRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a versioned pair of up/down scripts.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied.
+type Status struct {
+	Version uint64 `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// Migrator runs migrations against a Postgres database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New loads the migrations from fsys, sorted by version.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, e := range entries {
+		m := fileRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: %s: %w", e.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migrate: version %d has no up script", mig.Version)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Migrations returns the known migrations in order.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up applies every pending migration and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+		for _, mig := range m.migrations {
+			if mig.Version <= current {
+				continue
+			}
+			if err := apply(ctx, conn, mig.Up, mig.Version, true); err != nil {
+				return fmt.Errorf("migrate: up %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+
+		idx := -1
+		for i, mig := range m.migrations {
+			if mig.Version == current {
+				idx = i
+			}
+		}
+		if current == 0 || idx < 0 {
+			return ErrNoMigration
+		}
+
+		var previous uint64
+		if idx > 0 {
+			previous = m.migrations[idx-1].Version
+		}
+		mig := m.migrations[idx]
+		if err := apply(ctx, conn, mig.Down, previous, previous > 0); err != nil {
+			return fmt.Errorf("migrate: down %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		return nil
+	})
+}
+
+// Version returns the current schema version (0 when nothing is applied).
+func (m *Migrator) Version(ctx context.Context) (uint64, bool, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer conn.Close()
+	if err := ensureTable(ctx, conn); err != nil {
+		return 0, false, err
+	}
+	return readVersion(ctx, conn)
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	current, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		out = append(out, Status{Version: mig.Version, Name: mig.Name, Applied: mig.Version <= current})
+	}
+	return out, nil
+}
+
+// locked runs fn on a dedicated connection holding the advisory lock.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("migrate: acquire lock: %w", err)
+	}
+	defer func() {
+		// Use a fresh context so the lock is released even if ctx was canceled
+		_, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+	}()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func ensureTable(ctx context.Context, conn *sql.Conn) error {
+	const q = `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`
+	_, err := conn.ExecContext(ctx, q)
+	return err
+}
+
+func readVersion(ctx context.Context, conn *sql.Conn) (uint64, bool, error) {
+	const q = `SELECT version, dirty FROM schema_migrations LIMIT 1`
+	var version int64
+	var dirty bool
+	if err := conn.QueryRowContext(ctx, q).Scan(&version, &dirty); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return uint64(version), dirty, nil
+}
+
+// apply runs script and records the resulting version in one transaction.
+// When record is false the version table is left empty (fully rolled back).
+func apply(ctx context.Context, conn *sql.Conn, script string, version uint64, record bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if record {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, int64(version)); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
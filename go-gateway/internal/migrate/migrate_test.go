@@ -0,0 +1,174 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/devfullcycle/imersao22/go-gateway/migrations"
+)
+
+var testFS = fstest.MapFS{
+	"000001_create_accounts.up.sql":   {Data: []byte("CREATE TABLE accounts (id UUID);")},
+	"000001_create_accounts.down.sql": {Data: []byte("DROP TABLE accounts;")},
+	"000002_create_invoices.up.sql":   {Data: []byte("CREATE TABLE invoices (id UUID);")},
+	"000002_create_invoices.down.sql": {Data: []byte("DROP TABLE invoices;")},
+	"README.md":                       {Data: []byte("ignored")},
+}
+
+const (
+	lockQ    = "SELECT pg_advisory_lock($1)"
+	unlockQ  = "SELECT pg_advisory_unlock($1)"
+	tableQ   = "CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)"
+	versionQ = "SELECT version, dirty FROM schema_migrations LIMIT 1"
+	deleteQ  = "DELETE FROM schema_migrations"
+	insertQ  = "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)"
+)
+
+func expectApply(mock sqlmock.Sqlmock, script string, version int64) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(script)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(deleteQ)).WillReturnResult(sqlmock.NewResult(0, 1))
+	if version > 0 {
+		mock.ExpectExec(regexp.QuoteMeta(insertQ)).WithArgs(version).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func TestNew_ParsesAndSortsMigrations(t *testing.T) {
+	m, err := New(nil, testFS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := m.Migrations()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 migrations got %d", len(got))
+	}
+	if got[0].Version != 1 || got[1].Version != 2 || got[1].Name != "create_invoices" {
+		t.Fatalf("unexpected migrations: %+v", got)
+	}
+}
+
+func TestNew_EmbeddedMigrations(t *testing.T) {
+	m, err := New(nil, migrations.FS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Migrations()) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	for _, mig := range m.Migrations() {
+		if mig.Down == "" {
+			t.Errorf("migration %d has no down script", mig.Version)
+		}
+	}
+}
+
+func TestMigrator_Up_AppliesPendingUnderLock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(lockQ)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(tableQ)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(versionQ)).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false))
+	expectApply(mock, "CREATE TABLE invoices (id UUID);", 2)
+	mock.ExpectExec(regexp.QuoteMeta(unlockQ)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	m, _ := New(db, testFS)
+	n, err := m.Up(context.Background())
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 migration applied got %d", n)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestMigrator_Up_RefusesDirty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(lockQ)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(tableQ)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(versionQ)).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, true))
+	mock.ExpectExec(regexp.QuoteMeta(unlockQ)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	m, _ := New(db, testFS)
+	if _, err := m.Up(context.Background()); !errors.Is(err, ErrDirty) {
+		t.Fatalf("expected ErrDirty got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// 2 -> 1
+	mock.ExpectExec(regexp.QuoteMeta(lockQ)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(tableQ)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(versionQ)).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, false))
+	expectApply(mock, "DROP TABLE invoices;", 1)
+	mock.ExpectExec(regexp.QuoteMeta(unlockQ)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// 1 -> nothing applied
+	mock.ExpectExec(regexp.QuoteMeta(lockQ)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(tableQ)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(versionQ)).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false))
+	expectApply(mock, "DROP TABLE accounts;", 0)
+	mock.ExpectExec(regexp.QuoteMeta(unlockQ)).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	m, _ := New(db, testFS)
+	if err := m.Down(context.Background()); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if err := m.Down(context.Background()); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta(tableQ)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(versionQ)).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false))
+
+	m, _ := New(db, testFS)
+	st, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !st[0].Applied || st[1].Applied {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+}
@@ -2,22 +2,30 @@ package memory
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
 )
 
 // InMemoryAccountRepository is a thread-safe in-memory repository.
 type InMemoryAccountRepository struct {
+	// mu also stands in for the account row lock: the memory repositories
+	// that move money hold it while they change a balance.
 	mu       sync.RWMutex
 	byID     map[string]*domain.Account
 	byAPIKey map[string]*domain.Account
+	// adjustments by account ID, oldest first
+	adjustments map[string][]*domain.BalanceAdjustment
 }
 
 func NewInMemoryAccountRepository() *InMemoryAccountRepository {
 	return &InMemoryAccountRepository{
-		byID:     make(map[string]*domain.Account),
-		byAPIKey: make(map[string]*domain.Account),
+		byID:        make(map[string]*domain.Account),
+		byAPIKey:    make(map[string]*domain.Account),
+		adjustments: make(map[string][]*domain.BalanceAdjustment),
 	}
 }
 
@@ -47,25 +55,91 @@ func (r *InMemoryAccountRepository) GetByAPIKey(ctx context.Context, apiKey stri
 	return nil, domain.ErrAccountNotFound
 }
 
-func (r *InMemoryAccountRepository) UpdateBalance(ctx context.Context, a *domain.Account) error {
+func (r *InMemoryAccountRepository) AddBalance(ctx context.Context, accountID string, amount float64, at time.Time) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-
-	// Find the account in memory
-	storedAccount, ok := r.byID[a.ID]
+	a, ok := r.byID[accountID]
 	if !ok {
 		return domain.ErrAccountNotFound
 	}
+	if err := a.AddBalance(amount); err != nil {
+		return err
+	}
+	a.UpdatedAt = at
+	return nil
+}
+
+func (r *InMemoryAccountRepository) List(ctx context.Context, f domain.AccountFilter) ([]*domain.Account, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	// Update the stored account with the new balance and updated_at
-	storedAccount.Balance = a.Balance
-	storedAccount.UpdatedAt = a.UpdatedAt
+	query := strings.ToLower(f.Query)
+	var matches []*domain.Account
+	for _, a := range r.byID {
+		if f.Status != "" && a.Status != f.Status {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(a.Name), query) && !strings.Contains(strings.ToLower(a.Email), query) {
+			continue
+		}
+		matches = append(matches, a)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].CreatedAt.Equal(matches[j].CreatedAt) {
+			return matches[i].ID < matches[j].ID
+		}
+		return matches[i].CreatedAt.After(matches[j].CreatedAt)
+	})
 
-	// Also update the API key map
-	if storedAccount.APIKey != a.APIKey {
-		delete(r.byAPIKey, storedAccount.APIKey)
-		r.byAPIKey[a.APIKey] = storedAccount
+	total := len(matches)
+	if f.Offset >= total {
+		return []*domain.Account{}, total, nil
+	}
+	end := total
+	if f.Limit > 0 && f.Offset+f.Limit < total {
+		end = f.Offset + f.Limit
 	}
+	return matches[f.Offset:end], total, nil
+}
 
+func (r *InMemoryAccountRepository) UpdateStatus(ctx context.Context, a *domain.Account) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.byID[a.ID]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	stored.Status = a.Status
+	stored.UpdatedAt = a.UpdatedAt
 	return nil
 }
+
+func (r *InMemoryAccountRepository) AdjustBalance(ctx context.Context, adj *domain.BalanceAdjustment) (*domain.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.byID[adj.AccountID]
+	if !ok {
+		return nil, domain.ErrAccountNotFound
+	}
+	if err := stored.AdjustBalance(adj.Amount); err != nil {
+		return nil, err
+	}
+	adj.BalanceAfter = stored.Balance
+	r.adjustments[adj.AccountID] = append(r.adjustments[adj.AccountID], adj)
+	return stored, nil
+}
+
+func (r *InMemoryAccountRepository) ListAdjustments(ctx context.Context, accountID string) ([]*domain.BalanceAdjustment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.byID[accountID]; !ok {
+		return nil, domain.ErrAccountNotFound
+	}
+	// Newest first, like the Postgres repository
+	list := r.adjustments[accountID]
+	out := make([]*domain.BalanceAdjustment, len(list))
+	for i, adj := range list {
+		out[len(list)-1-i] = adj
+	}
+	return out, nil
+}
@@ -2,7 +2,10 @@ package memory
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
 )
@@ -11,7 +14,7 @@ func TestInMemoryAccountRepository(t *testing.T) {
 	repo := NewInMemoryAccountRepository()
 	ctx := context.Background()
 
-	a, err := domain.NewAccount("Acme", "acme@example.com")
+	a, err := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
 	if err != nil {
 		t.Fatalf("new account: %v", err)
 	}
@@ -36,19 +39,8 @@ func TestInMemoryAccountRepository(t *testing.T) {
 		t.Fatalf("expected same apikey")
 	}
 
-	// Test UpdateBalance with account
-	updatedAccount := &domain.Account{
-		ID:        a.ID,
-		Name:      a.Name,
-		Email:     a.Email,
-		APIKey:    a.APIKey,
-		Balance:   50.0,
-		CreatedAt: a.CreatedAt,
-		UpdatedAt: a.UpdatedAt,
-	}
-
-	if err := repo.UpdateBalance(ctx, updatedAccount); err != nil {
-		t.Fatalf("update balance: %v", err)
+	if err := repo.AddBalance(ctx, a.ID, 50.0, time.Now().UTC()); err != nil {
+		t.Fatalf("add balance: %v", err)
 	}
 
 	// Verify the balance was updated
@@ -64,3 +56,70 @@ func TestInMemoryAccountRepository(t *testing.T) {
 		t.Fatalf("expected not found error")
 	}
 }
+
+func TestInMemoryAccountRepository_List(t *testing.T) {
+	repo := NewInMemoryAccountRepository()
+	ctx := context.Background()
+
+	base := time.Now().UTC()
+	for i, name := range []string{"Acme Corp", "Globex", "Acme Labs"} {
+		a, _ := domain.NewAccount(name, strings.ToLower(strings.ReplaceAll(name, " ", ""))+"@example.com", domain.SystemClock)
+		a.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if i == 2 {
+			_ = a.Suspend()
+		}
+		_ = repo.Create(ctx, a)
+	}
+
+	got, total, err := repo.List(ctx, domain.AccountFilter{Query: "acme", Limit: 10})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if total != 2 || len(got) != 2 || got[0].Name != "Acme Labs" {
+		t.Fatalf("expected 2 acme accounts newest first, got %d %+v", total, got)
+	}
+
+	got, total, _ = repo.List(ctx, domain.AccountFilter{Status: domain.AccountSuspended, Limit: 10})
+	if total != 1 || got[0].Name != "Acme Labs" {
+		t.Fatalf("expected the suspended account, got %d %+v", total, got)
+	}
+
+	got, total, _ = repo.List(ctx, domain.AccountFilter{Limit: 2, Offset: 2})
+	if total != 3 || len(got) != 1 || got[0].Name != "Acme Corp" {
+		t.Fatalf("unexpected last page: %d %+v", total, got)
+	}
+
+	got, _, _ = repo.List(ctx, domain.AccountFilter{Limit: 2, Offset: 10})
+	if len(got) != 0 {
+		t.Fatalf("expected empty page, got %+v", got)
+	}
+}
+
+func TestInMemoryAccountRepository_AdjustBalance(t *testing.T) {
+	repo := NewInMemoryAccountRepository()
+	ctx := context.Background()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = repo.Create(ctx, a)
+
+	credit, _ := domain.NewBalanceAdjustment(a.ID, 100, "manual credit", "ops", domain.SystemClock)
+	if _, err := repo.AdjustBalance(ctx, credit); err != nil {
+		t.Fatalf("credit: %v", err)
+	}
+	debit, _ := domain.NewBalanceAdjustment(a.ID, -150, "manual debit", "ops", domain.SystemClock)
+	if _, err := repo.AdjustBalance(ctx, debit); !errors.Is(err, domain.ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+
+	list, err := repo.ListAdjustments(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list) != 1 || list[0].BalanceAfter != 100 {
+		t.Fatalf("expected only the credit recorded, got %+v", list)
+	}
+
+	missing, _ := domain.NewBalanceAdjustment("missing", 1, "manual credit", "ops", domain.SystemClock)
+	if _, err := repo.AdjustBalance(ctx, missing); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// CheckoutSessionRepositoryMemory is a thread-safe in-memory checkout
+// session repository. It stores copies, so callers never share state with it.
+type CheckoutSessionRepositoryMemory struct {
+	mu       sync.RWMutex
+	sessions map[string]*domain.CheckoutSession
+}
+
+func NewCheckoutSessionRepositoryMemory() *CheckoutSessionRepositoryMemory {
+	return &CheckoutSessionRepositoryMemory{sessions: make(map[string]*domain.CheckoutSession)}
+}
+
+func (r *CheckoutSessionRepositoryMemory) Create(ctx context.Context, s *domain.CheckoutSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[s.ID] = cloneCheckoutSession(s)
+	return nil
+}
+
+func (r *CheckoutSessionRepositoryMemory) GetByID(ctx context.Context, id string) (*domain.CheckoutSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if s, ok := r.sessions[id]; ok {
+		return cloneCheckoutSession(s), nil
+	}
+	return nil, domain.ErrCheckoutSessionNotFound
+}
+
+func (r *CheckoutSessionRepositoryMemory) GetForAccount(ctx context.Context, accountID, id string) (*domain.CheckoutSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if s, ok := r.sessions[id]; ok && s.AccountID == accountID {
+		return cloneCheckoutSession(s), nil
+	}
+	return nil, domain.ErrCheckoutSessionNotFound
+}
+
+func (r *CheckoutSessionRepositoryMemory) Claim(ctx context.Context, s *domain.CheckoutSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.sessions[s.ID]
+	if !ok {
+		return domain.ErrCheckoutSessionNotFound
+	}
+	if stored.Status != domain.CheckoutOpen || s.CompletedAt == nil || !s.CompletedAt.Before(stored.ExpiresAt) {
+		return domain.ErrCheckoutCompleted
+	}
+	r.sessions[s.ID] = cloneCheckoutSession(s)
+	return nil
+}
+
+func (r *CheckoutSessionRepositoryMemory) Update(ctx context.Context, s *domain.CheckoutSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.sessions[s.ID]; !ok {
+		return domain.ErrCheckoutSessionNotFound
+	}
+	r.sessions[s.ID] = cloneCheckoutSession(s)
+	return nil
+}
+
+func cloneCheckoutSession(s *domain.CheckoutSession) *domain.CheckoutSession {
+	c := *s
+	c.PaymentTypes = append([]string(nil), s.PaymentTypes...)
+	if s.CompletedAt != nil {
+		t := *s.CompletedAt
+		c.CompletedAt = &t
+	}
+	return &c
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+func TestCheckoutSessionRepositoryMemory(t *testing.T) {
+	repo := NewCheckoutSessionRepositoryMemory()
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s, _ := domain.NewCheckoutSession("acc-1", "Order #42", 99.9, []string{"pix"}, "https://shop.example.com/ok", "", time.Time{}, now)
+	if err := repo.Create(ctx, s); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := repo.GetForAccount(ctx, "acc-2", s.ID); !errors.Is(err, domain.ErrCheckoutSessionNotFound) {
+		t.Fatalf("expected another account not to see the session, got %v", err)
+	}
+
+	// Two payers loaded the open session
+	first, _ := repo.GetByID(ctx, s.ID)
+	second, _ := repo.GetByID(ctx, s.ID)
+	_ = first.Claim("pix", now)
+	_ = second.Claim("pix", now)
+	if err := repo.Claim(ctx, first); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if err := repo.Claim(ctx, second); !errors.Is(err, domain.ErrCheckoutCompleted) {
+		t.Fatalf("expected a single payment, got %v", err)
+	}
+
+	first.InvoiceID = "inv-1"
+	if err := repo.Update(ctx, first); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, _ := repo.GetForAccount(ctx, "acc-1", s.ID)
+	if got.Status != domain.CheckoutCompleted || got.InvoiceID != "inv-1" {
+		t.Fatalf("expected the payment to be stored, got %+v", got)
+	}
+}
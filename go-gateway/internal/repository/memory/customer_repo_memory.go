@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// CustomerRepositoryMemory is a thread-safe in-memory customer and saved
+// card repository. It stores copies, so callers never share state with it.
+type CustomerRepositoryMemory struct {
+	mu        sync.RWMutex
+	customers map[string]*domain.Customer
+	cards     map[string]*domain.SavedCard
+}
+
+func NewCustomerRepositoryMemory() *CustomerRepositoryMemory {
+	return &CustomerRepositoryMemory{
+		customers: make(map[string]*domain.Customer),
+		cards:     make(map[string]*domain.SavedCard),
+	}
+}
+
+func (r *CustomerRepositoryMemory) Create(ctx context.Context, c *domain.Customer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.customers {
+		if stored.AccountID == c.AccountID && stored.Document == c.Document {
+			return domain.ErrCustomerExists
+		}
+	}
+	cp := *c
+	r.customers[c.ID] = &cp
+	return nil
+}
+
+func (r *CustomerRepositoryMemory) GetByID(ctx context.Context, accountID, id string) (*domain.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if c, ok := r.customers[id]; ok && c.AccountID == accountID {
+		cp := *c
+		return &cp, nil
+	}
+	return nil, domain.ErrCustomerNotFound
+}
+
+func (r *CustomerRepositoryMemory) ListByAccount(ctx context.Context, accountID string) ([]*domain.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.Customer{}
+	for _, c := range r.customers {
+		if c.AccountID == accountID {
+			cp := *c
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *CustomerRepositoryMemory) AddCard(ctx context.Context, card *domain.SavedCard) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *card
+	r.cards[card.ID] = &cp
+	return nil
+}
+
+func (r *CustomerRepositoryMemory) GetCard(ctx context.Context, accountID, customerID, id string) (*domain.SavedCard, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if card, ok := r.cards[id]; ok && card.AccountID == accountID && card.CustomerID == customerID {
+		cp := *card
+		return &cp, nil
+	}
+	return nil, domain.ErrSavedCardNotFound
+}
+
+func (r *CustomerRepositoryMemory) ListCards(ctx context.Context, accountID, customerID string) ([]*domain.SavedCard, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.SavedCard{}
+	for _, card := range r.cards {
+		if card.AccountID == accountID && card.CustomerID == customerID {
+			cp := *card
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *CustomerRepositoryMemory) DeleteCard(ctx context.Context, accountID, customerID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if card, ok := r.cards[id]; ok && card.AccountID == accountID && card.CustomerID == customerID {
+		delete(r.cards, id)
+		return nil
+	}
+	return domain.ErrSavedCardNotFound
+}
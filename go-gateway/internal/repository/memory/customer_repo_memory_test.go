@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+func TestCustomerRepositoryMemory(t *testing.T) {
+	repo := NewCustomerRepositoryMemory()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	c, _ := domain.NewCustomer("acc-1", "Maria Silva", "maria@example.com", "529.982.247-25", now)
+	if err := repo.Create(ctx, c); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	dup, _ := domain.NewCustomer("acc-1", "Maria S.", "maria.s@example.com", "52998224725", now)
+	if err := repo.Create(ctx, dup); !errors.Is(err, domain.ErrCustomerExists) {
+		t.Fatalf("expected ErrCustomerExists, got %v", err)
+	}
+	// Another account may register the same person
+	other, _ := domain.NewCustomer("acc-2", "Maria Silva", "maria@example.com", "52998224725", now)
+	if err := repo.Create(ctx, other); err != nil {
+		t.Fatalf("create on another account: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "acc-2", c.ID); !errors.Is(err, domain.ErrCustomerNotFound) {
+		t.Fatalf("expected another account not to see the customer, got %v", err)
+	}
+	if list, _ := repo.ListByAccount(ctx, "acc-1"); len(list) != 1 || list[0].ID != c.ID {
+		t.Fatalf("expected one customer, got %+v", list)
+	}
+
+	card, _ := domain.NewSavedCard(c, "tok_12345678", "4242", now)
+	if err := repo.AddCard(ctx, card); err != nil {
+		t.Fatalf("add card: %v", err)
+	}
+	if _, err := repo.GetCard(ctx, "acc-2", other.ID, card.ID); !errors.Is(err, domain.ErrSavedCardNotFound) {
+		t.Fatalf("expected another customer not to see the card, got %v", err)
+	}
+	if list, _ := repo.ListCards(ctx, "acc-1", c.ID); len(list) != 1 {
+		t.Fatalf("expected one card, got %+v", list)
+	}
+	if err := repo.DeleteCard(ctx, "acc-1", c.ID, card.ID); err != nil {
+		t.Fatalf("delete card: %v", err)
+	}
+	if err := repo.DeleteCard(ctx, "acc-1", c.ID, card.ID); !errors.Is(err, domain.ErrSavedCardNotFound) {
+		t.Fatalf("expected ErrSavedCardNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// DisputeRepositoryMemory is a thread-safe in-memory dispute repository. It
+// freezes and releases funds on the accounts stored in the given account
+// repository.
+type DisputeRepositoryMemory struct {
+	mu       sync.RWMutex
+	accounts *InMemoryAccountRepository
+	disputes map[string]*domain.Dispute
+}
+
+func NewDisputeRepositoryMemory(accounts *InMemoryAccountRepository) *DisputeRepositoryMemory {
+	return &DisputeRepositoryMemory{
+		accounts: accounts,
+		disputes: make(map[string]*domain.Dispute),
+	}
+}
+
+func (r *DisputeRepositoryMemory) Open(ctx context.Context, d *domain.Dispute) error {
+	r.accounts.mu.Lock()
+	defer r.accounts.mu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.disputes {
+		if stored.InvoiceID == d.InvoiceID {
+			return domain.ErrDisputeExists
+		}
+	}
+	a, ok := r.accounts.byID[d.AccountID]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	if err := a.Freeze(d.Amount); err != nil {
+		return err
+	}
+	r.disputes[d.ID] = cloneDispute(d)
+	return nil
+}
+
+func (r *DisputeRepositoryMemory) GetByID(ctx context.Context, id string) (*domain.Dispute, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if d, ok := r.disputes[id]; ok {
+		return cloneDispute(d), nil
+	}
+	return nil, domain.ErrDisputeNotFound
+}
+
+func (r *DisputeRepositoryMemory) GetByIDForAccount(ctx context.Context, accountID, id string) (*domain.Dispute, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if d, ok := r.disputes[id]; ok && d.AccountID == accountID {
+		return cloneDispute(d), nil
+	}
+	return nil, domain.ErrDisputeNotFound
+}
+
+func (r *DisputeRepositoryMemory) ListByAccount(ctx context.Context, accountID string) ([]*domain.Dispute, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.Dispute{}
+	for _, d := range r.disputes {
+		if d.AccountID == accountID {
+			out = append(out, cloneDispute(d))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *DisputeRepositoryMemory) ListOverdue(ctx context.Context, now time.Time, limit int) ([]*domain.Dispute, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.Dispute{}
+	for _, d := range r.disputes {
+		if d.Overdue(now) {
+			out = append(out, cloneDispute(d))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].EvidenceDueBy.Before(out[j].EvidenceDueBy) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *DisputeRepositoryMemory) SubmitEvidence(ctx context.Context, d *domain.Dispute) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.disputes[d.ID]
+	if !ok || stored.Status != domain.DisputeNeedsResponse {
+		return domain.ErrInvalidDisputeTransition
+	}
+	r.disputes[d.ID] = cloneDispute(d)
+	return nil
+}
+
+func (r *DisputeRepositoryMemory) Resolve(ctx context.Context, d *domain.Dispute) error {
+	r.accounts.mu.Lock()
+	defer r.accounts.mu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.disputes[d.ID]
+	if !ok || !stored.Status.Open() {
+		return domain.ErrInvalidDisputeTransition
+	}
+	if d.Status == domain.DisputeWon {
+		a, ok := r.accounts.byID[d.AccountID]
+		if !ok {
+			return domain.ErrAccountNotFound
+		}
+		if err := a.Unfreeze(d.Amount); err != nil {
+			return err
+		}
+	}
+	r.disputes[d.ID] = cloneDispute(d)
+	return nil
+}
+
+func cloneDispute(d *domain.Dispute) *domain.Dispute {
+	c := *d
+	if d.EvidenceSubmittedAt != nil {
+		t := *d.EvidenceSubmittedAt
+		c.EvidenceSubmittedAt = &t
+	}
+	if d.ResolvedAt != nil {
+		t := *d.ResolvedAt
+		c.ResolvedAt = &t
+	}
+	return &c
+}
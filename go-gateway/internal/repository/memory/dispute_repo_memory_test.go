@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+func TestDisputeRepositoryMemory(t *testing.T) {
+	accounts := NewInMemoryAccountRepository()
+	repo := NewDisputeRepositoryMemory(accounts)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = a.AddBalance(100)
+	_ = accounts.Create(ctx, a)
+
+	invoice := &domain.Invoice{ID: "inv-1", AccountID: a.ID, Amount: 80, TotalAmount: 80, Status: domain.StatusApproved}
+	d, _ := domain.NewDispute(invoice, 80, domain.DisputeFraudulent, now)
+	if err := repo.Open(ctx, d); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if a.Balance != 20 {
+		t.Fatalf("expected 80 frozen, got balance %v", a.Balance)
+	}
+	again, _ := domain.NewDispute(invoice, 10, domain.DisputeDuplicate, now)
+	if err := repo.Open(ctx, again); !errors.Is(err, domain.ErrDisputeExists) {
+		t.Fatalf("expected ErrDisputeExists, got %v", err)
+	}
+
+	if _, err := repo.GetByIDForAccount(ctx, "other", d.ID); !errors.Is(err, domain.ErrDisputeNotFound) {
+		t.Fatalf("expected not found for another account, got %v", err)
+	}
+	if list, _ := repo.ListOverdue(ctx, d.EvidenceDueBy.Add(time.Second), 10); len(list) != 1 {
+		t.Fatalf("expected the overdue dispute, got %+v", list)
+	}
+
+	_ = d.SubmitEvidence("signed delivery receipt", now)
+	if err := repo.SubmitEvidence(ctx, d); err != nil {
+		t.Fatalf("submit evidence: %v", err)
+	}
+	if err := repo.SubmitEvidence(ctx, d); !errors.Is(err, domain.ErrInvalidDisputeTransition) {
+		t.Fatalf("expected ErrInvalidDisputeTransition, got %v", err)
+	}
+
+	_ = d.Resolve(domain.DisputeWon, now)
+	if err := repo.Resolve(ctx, d); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if a.Balance != 100 {
+		t.Fatalf("expected the frozen amount released, got balance %v", a.Balance)
+	}
+	if err := repo.Resolve(ctx, d); !errors.Is(err, domain.ErrInvalidDisputeTransition) {
+		t.Fatalf("expected ErrInvalidDisputeTransition, got %v", err)
+	}
+	got, _ := repo.GetByID(ctx, d.ID)
+	if got.Status != domain.DisputeWon || got.Evidence == "" {
+		t.Fatalf("unexpected dispute %+v", got)
+	}
+}
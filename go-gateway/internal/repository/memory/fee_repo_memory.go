@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// FeeRepositoryMemory is a thread-safe in-memory fee repository.
+type FeeRepositoryMemory struct {
+	mu    sync.RWMutex
+	plans map[string]*domain.FeePlan // keyed by account ID and payment type
+	fees  []*domain.InvoiceFee
+}
+
+func NewFeeRepositoryMemory() *FeeRepositoryMemory {
+	return &FeeRepositoryMemory{plans: make(map[string]*domain.FeePlan)}
+}
+
+func planKey(accountID, paymentType string) string {
+	return accountID + "/" + paymentType
+}
+
+func (r *FeeRepositoryMemory) GetPlan(ctx context.Context, accountID, paymentType string) (*domain.FeePlan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if p, ok := r.plans[planKey(accountID, paymentType)]; ok {
+		return p, nil
+	}
+	return nil, domain.ErrFeePlanNotFound
+}
+
+func (r *FeeRepositoryMemory) ListPlans(ctx context.Context, accountID string) ([]*domain.FeePlan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.FeePlan{}
+	for _, p := range r.plans {
+		if p.AccountID == accountID {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PaymentType < out[j].PaymentType })
+	return out, nil
+}
+
+func (r *FeeRepositoryMemory) SavePlan(ctx context.Context, p *domain.FeePlan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.plans[planKey(p.AccountID, p.PaymentType)] = p
+	return nil
+}
+
+func (r *FeeRepositoryMemory) Record(ctx context.Context, f *domain.InvoiceFee) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fees = append(r.fees, f)
+	return nil
+}
+
+func (r *FeeRepositoryMemory) Revenue(ctx context.Context, from, to time.Time) ([]domain.RevenueLine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	byType := make(map[string]*domain.RevenueLine)
+	for _, f := range r.fees {
+		if f.CreatedAt.Before(from) || !f.CreatedAt.Before(to) {
+			continue
+		}
+		line, ok := byType[f.PaymentType]
+		if !ok {
+			line = &domain.RevenueLine{PaymentType: f.PaymentType}
+			byType[f.PaymentType] = line
+		}
+		line.Invoices++
+		line.Gross += f.Gross
+		line.Fees += f.Fee
+	}
+	out := make([]domain.RevenueLine, 0, len(byType))
+	for _, line := range byType {
+		out = append(out, *line)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PaymentType < out[j].PaymentType })
+	return out, nil
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+func TestFeeRepositoryMemory_Plans(t *testing.T) {
+	repo := NewFeeRepositoryMemory()
+	ctx := context.Background()
+
+	if _, err := repo.GetPlan(ctx, "acc-1", "pix"); !errors.Is(err, domain.ErrFeePlanNotFound) {
+		t.Fatalf("expected ErrFeePlanNotFound, got %v", err)
+	}
+
+	pix, _ := domain.NewFeePlan("acc-1", "pix", 0.99, 0, domain.SystemClock)
+	card, _ := domain.NewFeePlan("acc-1", "credit_card", 3.99, 0.39, domain.SystemClock)
+	other, _ := domain.NewFeePlan("acc-2", "pix", 1, 0, domain.SystemClock)
+	for _, p := range []*domain.FeePlan{pix, card, other} {
+		_ = repo.SavePlan(ctx, p)
+	}
+	replaced, _ := domain.NewFeePlan("acc-1", "pix", 0.5, 0, domain.SystemClock)
+	_ = repo.SavePlan(ctx, replaced)
+
+	got, err := repo.GetPlan(ctx, "acc-1", "pix")
+	if err != nil || got.Percent != 0.5 {
+		t.Fatalf("expected the replaced plan, got %+v %v", got, err)
+	}
+	list, _ := repo.ListPlans(ctx, "acc-1")
+	if len(list) != 2 || list[0].PaymentType != "credit_card" {
+		t.Fatalf("expected the two acc-1 plans by payment type, got %+v", list)
+	}
+}
+
+func TestFeeRepositoryMemory_Revenue(t *testing.T) {
+	repo := NewFeeRepositoryMemory()
+	ctx := context.Background()
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, f := range []*domain.InvoiceFee{
+		{PaymentType: "pix", Gross: 100, Fee: 1, CreatedAt: day},
+		{PaymentType: "pix", Gross: 50, Fee: 0.5, CreatedAt: day.Add(time.Hour)},
+		{PaymentType: "credit_card", Gross: 100, Fee: 4.38, CreatedAt: day.Add(2 * time.Hour)},
+		{PaymentType: "pix", Gross: 10, Fee: 0.1, CreatedAt: day.AddDate(0, 0, 1)}, // end is exclusive
+	} {
+		_ = repo.Record(ctx, f)
+	}
+
+	lines, err := repo.Revenue(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("revenue: %v", err)
+	}
+	want := []domain.RevenueLine{
+		{PaymentType: "credit_card", Invoices: 1, Gross: 100, Fees: 4.38},
+		{PaymentType: "pix", Invoices: 2, Gross: 150, Fees: 1.5},
+	}
+	if len(lines) != len(want) || lines[0] != want[0] || lines[1] != want[1] {
+		t.Fatalf("expected %+v, got %+v", want, lines)
+	}
+}
@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// InstallmentRulesRepositoryMemory is a thread-safe in-memory installment
+// rules repository.
+type InstallmentRulesRepositoryMemory struct {
+	mu    sync.RWMutex
+	rules map[string]domain.InstallmentRules // keyed by account ID
+}
+
+func NewInstallmentRulesRepositoryMemory() *InstallmentRulesRepositoryMemory {
+	return &InstallmentRulesRepositoryMemory{rules: make(map[string]domain.InstallmentRules)}
+}
+
+func (r *InstallmentRulesRepositoryMemory) Get(ctx context.Context, accountID string) (*domain.InstallmentRules, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if rules, ok := r.rules[accountID]; ok {
+		return &rules, nil
+	}
+	return nil, domain.ErrInstallmentRulesNotFound
+}
+
+func (r *InstallmentRulesRepositoryMemory) Save(ctx context.Context, rules *domain.InstallmentRules) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules[rules.AccountID] = *rules
+	return nil
+}
@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+func TestInstallmentRulesRepositoryMemory(t *testing.T) {
+	repo := NewInstallmentRulesRepositoryMemory()
+	ctx := context.Background()
+
+	if _, err := repo.Get(ctx, "acc-1"); !errors.Is(err, domain.ErrInstallmentRulesNotFound) {
+		t.Fatalf("expected ErrInstallmentRulesNotFound, got %v", err)
+	}
+
+	first, _ := domain.NewInstallmentRules("acc-1", 6, 3, 1.99, time.Now())
+	replaced, _ := domain.NewInstallmentRules("acc-1", 10, 10, 0, time.Now())
+	other, _ := domain.NewInstallmentRules("acc-2", 2, 1, 5, time.Now())
+	for _, r := range []*domain.InstallmentRules{first, replaced, other} {
+		_ = repo.Save(ctx, r)
+	}
+
+	got, err := repo.Get(ctx, "acc-1")
+	if err != nil || got.MaxInstallments != 10 || got.MonthlyInterest != 0 {
+		t.Fatalf("expected the replaced rules, got %+v %v", got, err)
+	}
+	got.MaxInstallments = 1
+	if again, _ := repo.Get(ctx, "acc-1"); again.MaxInstallments != 10 {
+		t.Fatalf("expected a copy, got %+v", again)
+	}
+}
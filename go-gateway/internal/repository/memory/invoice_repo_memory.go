@@ -3,7 +3,10 @@ package memory
 import (
 	"context"
 	"errors"
+	"maps"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
 )
@@ -12,27 +15,109 @@ import (
 type InvoiceRepositoryMemory struct {
 	invoices map[string]*domain.Invoice
 	mu       sync.RWMutex
+
+	// Where charges are applied; nil keeps only the invoices
+	accounts    *InMemoryAccountRepository
+	settlements *SettlementRepositoryMemory
+	fees        *FeeRepositoryMemory
 }
 
-// NewInvoiceRepositoryMemory creates a new in-memory invoice repository.
+// NewInvoiceRepositoryMemory creates a new in-memory invoice repository. It
+// keeps the invoices only and drops the charges given to CreateWithCharge.
 func NewInvoiceRepositoryMemory() *InvoiceRepositoryMemory {
 	return &InvoiceRepositoryMemory{
 		invoices: make(map[string]*domain.Invoice),
 	}
 }
 
+// NewInvoiceRepositoryMemoryWithLedger creates an in-memory invoice
+// repository that applies charges to the given accounts, settlements and
+// fees.
+func NewInvoiceRepositoryMemoryWithLedger(accounts *InMemoryAccountRepository, settlements *SettlementRepositoryMemory, fees *FeeRepositoryMemory) *InvoiceRepositoryMemory {
+	r := NewInvoiceRepositoryMemory()
+	r.accounts, r.settlements, r.fees = accounts, settlements, fees
+	return r
+}
+
 // Create stores a new invoice in memory.
 func (r *InvoiceRepositoryMemory) Create(ctx context.Context, i *domain.Invoice) error {
+	return r.CreateWithCharge(ctx, i, domain.InvoiceCharge{})
+}
+
+// CreateWithCharge stores a new invoice and applies its charge, all or
+// nothing.
+func (r *InvoiceRepositoryMemory) CreateWithCharge(ctx context.Context, i *domain.Invoice, charge domain.InvoiceCharge) error {
+	if r.accounts != nil {
+		r.accounts.mu.Lock()
+		defer r.accounts.mu.Unlock()
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, exists := r.invoices[i.ID]; exists {
 		return errors.New("invoice: already exists")
 	}
+	for _, stored := range r.invoices {
+		if stored.AccountID != i.AccountID {
+			continue
+		}
+		if i.ExternalReference != "" && stored.ExternalReference == i.ExternalReference {
+			return domain.ErrExternalReferenceExists
+		}
+		if i.IdempotencyKey != "" && stored.IdempotencyKey == i.IdempotencyKey {
+			return domain.ErrIdempotencyKeyInUse
+		}
+	}
+
+	if err := r.applyCharge(i, charge); err != nil {
+		return err
+	}
 
 	// Create a copy to avoid external modifications
-	invoiceCopy := *i
-	r.invoices[i.ID] = &invoiceCopy
+	r.invoices[i.ID] = cloneInvoice(i)
+	return nil
+}
+
+// applyCharge moves the money of a new invoice. The caller holds the account
+// lock.
+func (r *InvoiceRepositoryMemory) applyCharge(i *domain.Invoice, charge domain.InvoiceCharge) error {
+	if r.accounts == nil {
+		return nil
+	}
+	a, ok := r.accounts.byID[i.AccountID]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	var pending float64
+	for _, st := range charge.Settlements {
+		pending += st.Amount
+	}
+	// Checked first so a failure leaves the account untouched
+	if charge.Credit < 0 || pending < 0 {
+		return domain.ErrNegativeValue
+	}
+	if charge.Credit > 0 {
+		if err := a.AddBalance(charge.Credit); err != nil {
+			return err
+		}
+		a.UpdatedAt = i.UpdatedAt
+	}
+	if len(charge.Settlements) > 0 {
+		if err := a.AddPending(pending); err != nil {
+			return err
+		}
+		r.settlements.mu.Lock()
+		for _, st := range charge.Settlements {
+			stored := *st
+			r.settlements.settlements[st.ID] = &stored
+		}
+		r.settlements.mu.Unlock()
+	}
+	if charge.Fee != nil {
+		r.fees.mu.Lock()
+		r.fees.fees = append(r.fees.fees, charge.Fee)
+		r.fees.mu.Unlock()
+	}
 	return nil
 }
 
@@ -47,8 +132,21 @@ func (r *InvoiceRepositoryMemory) GetByID(ctx context.Context, id string) (*doma
 	}
 
 	// Return a copy to avoid external modifications
-	invoiceCopy := *invoice
-	return &invoiceCopy, nil
+	return cloneInvoice(invoice), nil
+}
+
+// GetByIDForAccount retrieves an invoice by its ID only if it belongs to the
+// account.
+func (r *InvoiceRepositoryMemory) GetByIDForAccount(ctx context.Context, accountID, id string) (*domain.Invoice, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invoice, exists := r.invoices[id]
+	if !exists || invoice.AccountID != accountID {
+		return nil, domain.ErrInvoiceNotFound
+	}
+
+	return cloneInvoice(invoice), nil
 }
 
 // GetByAccountID retrieves all invoices for a specific account.
@@ -60,14 +158,57 @@ func (r *InvoiceRepositoryMemory) GetByAccountID(ctx context.Context, accountID
 	for _, invoice := range r.invoices {
 		if invoice.AccountID == accountID {
 			// Create a copy to avoid external modifications
-			invoiceCopy := *invoice
-			invoices = append(invoices, &invoiceCopy)
+			invoices = append(invoices, cloneInvoice(invoice))
 		}
 	}
 
 	return invoices, nil
 }
 
+// GetByCustomer retrieves the invoices of a customer of the account, newest
+// first.
+func (r *InvoiceRepositoryMemory) GetByCustomer(ctx context.Context, accountID, customerID string) ([]*domain.Invoice, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invoices := []*domain.Invoice{}
+	for _, invoice := range r.invoices {
+		if invoice.AccountID == accountID && invoice.CustomerID == customerID {
+			invoices = append(invoices, cloneInvoice(invoice))
+		}
+	}
+	sort.Slice(invoices, func(i, j int) bool { return invoices[i].CreatedAt.After(invoices[j].CreatedAt) })
+	return invoices, nil
+}
+
+// GetByExternalReference retrieves the invoice of the account with the
+// merchant reference.
+func (r *InvoiceRepositoryMemory) GetByExternalReference(ctx context.Context, accountID, ref string) (*domain.Invoice, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, invoice := range r.invoices {
+		if invoice.AccountID == accountID && invoice.ExternalReference == ref {
+			return cloneInvoice(invoice), nil
+		}
+	}
+	return nil, domain.ErrInvoiceNotFound
+}
+
+// GetByIdempotencyKey retrieves the invoice of the account created with the
+// idempotency key.
+func (r *InvoiceRepositoryMemory) GetByIdempotencyKey(ctx context.Context, accountID, key string) (*domain.Invoice, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, invoice := range r.invoices {
+		if invoice.AccountID == accountID && invoice.IdempotencyKey == key {
+			return cloneInvoice(invoice), nil
+		}
+	}
+	return nil, domain.ErrInvoiceNotFound
+}
+
 // UpdateStatus updates the status of an existing invoice.
 func (r *InvoiceRepositoryMemory) UpdateStatus(ctx context.Context, id string, status domain.Status) error {
 	r.mu.Lock()
@@ -80,3 +221,47 @@ func (r *InvoiceRepositoryMemory) UpdateStatus(ctx context.Context, id string, s
 
 	return invoice.UpdateStatus(status)
 }
+
+// CountByCardSince counts the invoices of an account charged to a card since
+// a moment.
+func (r *InvoiceRepositoryMemory) CountByCardSince(ctx context.Context, accountID, cardLastDigits string, since time.Time) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for _, invoice := range r.invoices {
+		if invoice.AccountID == accountID && invoice.CardLastDigits == cardLastDigits && !invoice.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// cloneInvoice copies the exported fields of an invoice. Copying the struct
+// directly would also copy its mutex.
+func cloneInvoice(i *domain.Invoice) *domain.Invoice {
+	return &domain.Invoice{
+		ID:                i.ID,
+		AccountID:         i.AccountID,
+		Amount:            i.Amount,
+		Installments:      i.Installments,
+		TotalAmount:       i.TotalAmount,
+		Schedule:          append([]domain.Installment(nil), i.Schedule...),
+		Fee:               i.Fee,
+		NetAmount:         i.NetAmount,
+		Status:            i.Status,
+		Description:       i.Description,
+		PaymentType:       i.PaymentType,
+		CardLastDigits:    i.CardLastDigits,
+		CustomerID:        i.CustomerID,
+		SavedCardID:       i.SavedCardID,
+		Items:             append([]domain.LineItem(nil), i.Items...),
+		Metadata:          maps.Clone(i.Metadata),
+		ExternalReference: i.ExternalReference,
+		IdempotencyKey:    i.IdempotencyKey,
+		RiskDecision:      i.RiskDecision,
+		RiskReasons:       append([]string(nil), i.RiskReasons...),
+		CreatedAt:         i.CreatedAt,
+		UpdatedAt:         i.UpdatedAt,
+	}
+}
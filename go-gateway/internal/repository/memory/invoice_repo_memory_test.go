@@ -2,6 +2,9 @@ package memory
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -223,6 +226,103 @@ func TestInvoiceRepositoryMemory_UpdateStatus(t *testing.T) {
 	}
 }
 
+func TestInvoiceRepositoryMemory_CountByCardSince(t *testing.T) {
+	repo := NewInvoiceRepositoryMemory()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for i, c := range []struct {
+		account, card string
+		age           time.Duration
+	}{
+		{"acc-1", "1234", time.Minute},
+		{"acc-1", "1234", 30 * time.Minute},
+		{"acc-1", "1234", 2 * time.Hour}, // outside the window
+		{"acc-1", "9999", time.Minute},   // another card
+		{"acc-2", "1234", time.Minute},   // another account
+	} {
+		_ = repo.Create(ctx, &domain.Invoice{
+			ID: string(rune('a' + i)), AccountID: c.account, CardLastDigits: c.card, CreatedAt: now.Add(-c.age),
+		})
+	}
+
+	n, err := repo.CountByCardSince(ctx, "acc-1", "1234", now.Add(-time.Hour))
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 invoices, got %d %v", n, err)
+	}
+}
+
+func TestInvoiceRepositoryMemory_GetByCustomer(t *testing.T) {
+	repo := NewInvoiceRepositoryMemory()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	_ = repo.Create(ctx, &domain.Invoice{ID: "old", AccountID: "acc-1", CustomerID: "cus-1", SavedCardID: "card-1", CreatedAt: now.Add(-time.Hour)})
+	_ = repo.Create(ctx, &domain.Invoice{ID: "new", AccountID: "acc-1", CustomerID: "cus-1", CreatedAt: now})
+	_ = repo.Create(ctx, &domain.Invoice{ID: "other", AccountID: "acc-1", CustomerID: "cus-2", CreatedAt: now})
+	_ = repo.Create(ctx, &domain.Invoice{ID: "foreign", AccountID: "acc-2", CustomerID: "cus-1", CreatedAt: now})
+
+	got, err := repo.GetByCustomer(ctx, "acc-1", "cus-1")
+	if err != nil || len(got) != 2 || got[0].ID != "new" || got[1].SavedCardID != "card-1" {
+		t.Fatalf("expected the 2 invoices of the customer, newest first, got %+v %v", got, err)
+	}
+	if got, _ := repo.GetByCustomer(ctx, "acc-1", "cus-9"); got == nil || len(got) != 0 {
+		t.Fatalf("expected an empty list, got %v", got)
+	}
+}
+
+func TestInvoiceRepositoryMemory_ExternalReference(t *testing.T) {
+	repo := NewInvoiceRepositoryMemory()
+	ctx := context.Background()
+
+	inv := &domain.Invoice{ID: "inv-1", AccountID: "acc-1", ExternalReference: "order-42", Metadata: map[string]string{"channel": "web"},
+		Items: []domain.LineItem{{SKU: "SKU-1", Quantity: 2, UnitPrice: 5}}}
+	if err := repo.Create(ctx, inv); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := repo.Create(ctx, &domain.Invoice{ID: "inv-2", AccountID: "acc-1", ExternalReference: "order-42"}); !errors.Is(err, domain.ErrExternalReferenceExists) {
+		t.Fatalf("expected ErrExternalReferenceExists, got %v", err)
+	}
+	// References are unique per account only
+	if err := repo.Create(ctx, &domain.Invoice{ID: "inv-3", AccountID: "acc-2", ExternalReference: "order-42"}); err != nil {
+		t.Fatalf("create on another account: %v", err)
+	}
+
+	got, err := repo.GetByExternalReference(ctx, "acc-1", "order-42")
+	if err != nil || got.ID != "inv-1" || got.Metadata["channel"] != "web" || len(got.Items) != 1 {
+		t.Fatalf("expected inv-1 with its details, got %+v %v", got, err)
+	}
+	got.Metadata["channel"] = "changed"
+	if again, _ := repo.GetByExternalReference(ctx, "acc-1", "order-42"); again.Metadata["channel"] != "web" {
+		t.Fatalf("expected the stored metadata not shared with callers")
+	}
+	if _, err := repo.GetByExternalReference(ctx, "acc-1", "order-99"); !errors.Is(err, domain.ErrInvoiceNotFound) {
+		t.Fatalf("expected ErrInvoiceNotFound, got %v", err)
+	}
+}
+
+func TestInvoiceRepositoryMemory_IdempotencyKey(t *testing.T) {
+	repo := NewInvoiceRepositoryMemory()
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, &domain.Invoice{ID: "inv-1", AccountID: "acc-1", IdempotencyKey: "key-42"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := repo.Create(ctx, &domain.Invoice{ID: "inv-2", AccountID: "acc-1", IdempotencyKey: "key-42"}); !errors.Is(err, domain.ErrIdempotencyKeyInUse) {
+		t.Fatalf("expected ErrIdempotencyKeyInUse, got %v", err)
+	}
+	if err := repo.Create(ctx, &domain.Invoice{ID: "inv-3", AccountID: "acc-2", IdempotencyKey: "key-42"}); err != nil {
+		t.Fatalf("create on another account: %v", err)
+	}
+
+	if got, err := repo.GetByIdempotencyKey(ctx, "acc-1", "key-42"); err != nil || got.ID != "inv-1" || got.IdempotencyKey != "key-42" {
+		t.Fatalf("expected inv-1, got %+v %v", got, err)
+	}
+	if _, err := repo.GetByIdempotencyKey(ctx, "acc-1", "key-99"); !errors.Is(err, domain.ErrInvoiceNotFound) {
+		t.Fatalf("expected ErrInvoiceNotFound, got %v", err)
+	}
+}
+
 func TestInvoiceRepositoryMemory_Concurrency(t *testing.T) {
 	repo := NewInvoiceRepositoryMemory()
 	ctx := context.Background()
@@ -266,3 +366,54 @@ func TestInvoiceRepositoryMemory_Concurrency(t *testing.T) {
 		t.Errorf("expected 10 concurrent invoices, got %d", len(invoices))
 	}
 }
+
+func TestInvoiceRepositoryMemory_CreateWithCharge(t *testing.T) {
+	ctx := context.Background()
+	accounts := NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(ctx, a)
+	settlements := NewSettlementRepositoryMemory(accounts)
+	fees := NewFeeRepositoryMemory()
+	repo := NewInvoiceRepositoryMemoryWithLedger(accounts, settlements, fees)
+	now := time.Now().UTC()
+
+	// Concurrent invoices of one account credit it without losing any
+	var wg sync.WaitGroup
+	for k := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inv := &domain.Invoice{ID: fmt.Sprintf("inv-%d", k), AccountID: a.ID, Amount: 10, PaymentType: "pix", CreatedAt: now, UpdatedAt: now}
+			if err := repo.CreateWithCharge(ctx, inv, domain.InvoiceCharge{Credit: 10}); err != nil {
+				t.Errorf("create %d: %v", k, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if a.Balance != 500 {
+		t.Fatalf("expected 50 credits of 10, got %v", a.Balance)
+	}
+
+	inv := &domain.Invoice{ID: "inv-card", AccountID: a.ID, Amount: 100, Fee: 1, NetAmount: 99, PaymentType: "credit_card", IdempotencyKey: "key-42", CreatedAt: now, UpdatedAt: now}
+	held := domain.NewSettlement(inv, now.AddDate(0, 0, 30))
+	charge := domain.InvoiceCharge{Settlements: []*domain.Settlement{held}, Fee: domain.NewInvoiceFee(inv, domain.SystemClock)}
+	if err := repo.CreateWithCharge(ctx, inv, charge); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if a.PendingBalance != 99 {
+		t.Fatalf("expected the settlement held, got %v", a.PendingBalance)
+	}
+
+	// A reused key moves no money
+	again := &domain.Invoice{ID: "inv-again", AccountID: a.ID, IdempotencyKey: "key-42", CreatedAt: now, UpdatedAt: now}
+	if err := repo.CreateWithCharge(ctx, again, domain.InvoiceCharge{Credit: 99}); !errors.Is(err, domain.ErrIdempotencyKeyInUse) {
+		t.Fatalf("expected ErrIdempotencyKeyInUse, got %v", err)
+	}
+	if a.Balance != 500 || a.PendingBalance != 99 {
+		t.Fatalf("expected the balances untouched, got %v %v", a.Balance, a.PendingBalance)
+	}
+	revenue, _ := fees.Revenue(ctx, time.Time{}, now.Add(time.Minute))
+	if len(revenue) != 1 || revenue[0].Fees != 1 {
+		t.Fatalf("expected the fee recorded once, got %+v", revenue)
+	}
+}
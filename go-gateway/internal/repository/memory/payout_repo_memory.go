@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// PayoutRepositoryMemory is a thread-safe in-memory payout repository. It
+// debits and refunds the accounts stored in the given account repository.
+type PayoutRepositoryMemory struct {
+	mu           sync.RWMutex
+	accounts     *InMemoryAccountRepository
+	payouts      map[string]*domain.Payout
+	bankAccounts map[string]*domain.BankAccount
+}
+
+func NewPayoutRepositoryMemory(accounts *InMemoryAccountRepository) *PayoutRepositoryMemory {
+	return &PayoutRepositoryMemory{
+		accounts:     accounts,
+		payouts:      make(map[string]*domain.Payout),
+		bankAccounts: make(map[string]*domain.BankAccount),
+	}
+}
+
+func (r *PayoutRepositoryMemory) CreateBankAccount(ctx context.Context, b *domain.BankAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bankAccounts[b.ID] = b
+	return nil
+}
+
+func (r *PayoutRepositoryMemory) GetBankAccount(ctx context.Context, accountID, id string) (*domain.BankAccount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if b, ok := r.bankAccounts[id]; ok && b.AccountID == accountID {
+		return b, nil
+	}
+	return nil, domain.ErrBankAccountNotFound
+}
+
+func (r *PayoutRepositoryMemory) ListBankAccounts(ctx context.Context, accountID string) ([]*domain.BankAccount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.BankAccount{}
+	for _, b := range r.bankAccounts {
+		if b.AccountID == accountID {
+			out = append(out, b)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *PayoutRepositoryMemory) Create(ctx context.Context, p *domain.Payout, policy domain.PayoutPolicy) (*domain.Account, error) {
+	r.accounts.mu.Lock()
+	defer r.accounts.mu.Unlock()
+	a, ok := r.accounts.byID[p.AccountID]
+	if !ok {
+		return nil, domain.ErrAccountNotFound
+	}
+	if err := policy.Debit(a, p.Amount); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payouts[p.ID] = p
+	return a, nil
+}
+
+func (r *PayoutRepositoryMemory) GetByID(ctx context.Context, accountID, id string) (*domain.Payout, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if p, ok := r.payouts[id]; ok && p.AccountID == accountID {
+		return p, nil
+	}
+	return nil, domain.ErrPayoutNotFound
+}
+
+func (r *PayoutRepositoryMemory) ListByAccount(ctx context.Context, accountID string) ([]*domain.Payout, error) {
+	return r.list(func(p *domain.Payout) bool { return p.AccountID == accountID }, func(a, b *domain.Payout) bool {
+		return a.CreatedAt.After(b.CreatedAt)
+	}, 0), nil
+}
+
+func (r *PayoutRepositoryMemory) ListByStatus(ctx context.Context, status domain.PayoutStatus, limit int) ([]*domain.Payout, error) {
+	return r.list(func(p *domain.Payout) bool { return p.Status == status }, func(a, b *domain.Payout) bool {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}, limit), nil
+}
+
+func (r *PayoutRepositoryMemory) ListStuck(ctx context.Context, before time.Time, limit int) ([]*domain.Payout, error) {
+	return r.list(func(p *domain.Payout) bool {
+		return p.Status == domain.PayoutProcessing && p.UpdatedAt.Before(before)
+	}, func(a, b *domain.Payout) bool {
+		return a.UpdatedAt.Before(b.UpdatedAt)
+	}, limit), nil
+}
+
+func (r *PayoutRepositoryMemory) list(match func(*domain.Payout) bool, less func(a, b *domain.Payout) bool, limit int) []*domain.Payout {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.Payout{}
+	for _, p := range r.payouts {
+		if match(p) {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return less(out[i], out[j]) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (r *PayoutRepositoryMemory) UpdateStatus(ctx context.Context, p *domain.Payout, from domain.PayoutStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.update(p, from)
+}
+
+func (r *PayoutRepositoryMemory) Fail(ctx context.Context, p *domain.Payout, from domain.PayoutStatus) error {
+	r.accounts.mu.Lock()
+	defer r.accounts.mu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.accounts.byID[p.AccountID]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	if err := r.update(p, from); err != nil {
+		return err
+	}
+	return a.AddBalance(p.Amount)
+}
+
+// update stores p unless the stored payout already left status from. Callers
+// hold r.mu.
+func (r *PayoutRepositoryMemory) update(p *domain.Payout, from domain.PayoutStatus) error {
+	stored, ok := r.payouts[p.ID]
+	if !ok {
+		return domain.ErrPayoutNotFound
+	}
+	if stored != p && stored.Status != from {
+		return domain.ErrInvalidPayoutTransition
+	}
+	r.payouts[p.ID] = p
+	return nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+func TestPayoutRepositoryMemory(t *testing.T) {
+	accounts := NewInMemoryAccountRepository()
+	repo := NewPayoutRepositoryMemory(accounts)
+	ctx := context.Background()
+	policy := domain.PayoutPolicy{MinAmount: 1, ReserveRate: 0.1}
+
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = a.AddBalance(100)
+	_ = accounts.Create(ctx, a)
+
+	b, _ := domain.NewBankAccount(a.ID, "341", "0001", "12345-6", "Acme", domain.SystemClock)
+	_ = repo.CreateBankAccount(ctx, b)
+	if _, err := repo.GetBankAccount(ctx, "other", b.ID); !errors.Is(err, domain.ErrBankAccountNotFound) {
+		t.Fatalf("expected bank accounts scoped to their owner, got %v", err)
+	}
+
+	p, _ := domain.NewPayout(a.ID, b.ID, 60, domain.SystemClock)
+	if _, err := repo.Create(ctx, p, policy); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if a.Balance != 40 {
+		t.Fatalf("expected balance 40, got %v", a.Balance)
+	}
+
+	// 40 - 10% reserve leaves 36 withdrawable
+	p2, _ := domain.NewPayout(a.ID, b.ID, 37, domain.SystemClock)
+	if _, err := repo.Create(ctx, p2, policy); !errors.Is(err, domain.ErrInsufficientFunds) {
+		t.Fatalf("expected ErrInsufficientFunds, got %v", err)
+	}
+
+	pending, _ := repo.ListByStatus(ctx, domain.PayoutPending, 10)
+	if len(pending) != 1 || pending[0].ID != p.ID {
+		t.Fatalf("expected the pending payout, got %+v", pending)
+	}
+
+	_ = p.MarkFailed("bank offline")
+	if err := repo.Fail(ctx, p, domain.PayoutPending); err != nil {
+		t.Fatalf("fail: %v", err)
+	}
+	if a.Balance != 100 {
+		t.Fatalf("expected refund to 100, got %v", a.Balance)
+	}
+	if _, err := repo.GetByID(ctx, "other", p.ID); !errors.Is(err, domain.ErrPayoutNotFound) {
+		t.Fatalf("expected payouts scoped to their owner, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// SettlementRepositoryMemory is a thread-safe in-memory settlement
+// repository. It moves money between the balances of the accounts stored in
+// the given account repository.
+type SettlementRepositoryMemory struct {
+	mu          sync.RWMutex
+	accounts    *InMemoryAccountRepository
+	settlements map[string]*domain.Settlement
+}
+
+func NewSettlementRepositoryMemory(accounts *InMemoryAccountRepository) *SettlementRepositoryMemory {
+	return &SettlementRepositoryMemory{
+		accounts:    accounts,
+		settlements: make(map[string]*domain.Settlement),
+	}
+}
+
+func (r *SettlementRepositoryMemory) Create(ctx context.Context, s *domain.Settlement) error {
+	r.accounts.mu.Lock()
+	defer r.accounts.mu.Unlock()
+	a, ok := r.accounts.byID[s.AccountID]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	if err := a.AddPending(s.Amount); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *s
+	r.settlements[s.ID] = &stored
+	return nil
+}
+
+func (r *SettlementRepositoryMemory) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Settlement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.Settlement{}
+	for _, s := range r.settlements {
+		if s.Status == domain.SettlementPending && !s.AvailableAt.After(now) {
+			c := *s
+			out = append(out, &c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AvailableAt.Before(out[j].AvailableAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *SettlementRepositoryMemory) Release(ctx context.Context, s *domain.Settlement) error {
+	r.accounts.mu.Lock()
+	defer r.accounts.mu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.settlements[s.ID]
+	if !ok || stored.Status != domain.SettlementPending {
+		return domain.ErrSettlementNotPending
+	}
+	a, ok := r.accounts.byID[s.AccountID]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	if err := a.Settle(s.Amount); err != nil {
+		return err
+	}
+	*stored = *s
+	return nil
+}
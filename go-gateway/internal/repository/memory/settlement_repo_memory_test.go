@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+func TestSettlementRepositoryMemory(t *testing.T) {
+	accounts := NewInMemoryAccountRepository()
+	repo := NewSettlementRepositoryMemory(accounts)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(ctx, a)
+
+	due := &domain.Settlement{ID: "s-1", AccountID: a.ID, Amount: 60, Status: domain.SettlementPending, AvailableAt: now.Add(-time.Hour)}
+	later := &domain.Settlement{ID: "s-2", AccountID: a.ID, Amount: 40, Status: domain.SettlementPending, AvailableAt: now.Add(time.Hour)}
+	for _, s := range []*domain.Settlement{due, later} {
+		if err := repo.Create(ctx, s); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	if a.PendingBalance != 100 || a.Balance != 0 {
+		t.Fatalf("expected 100 pending, got %v %v", a.PendingBalance, a.Balance)
+	}
+
+	list, _ := repo.ListDue(ctx, now, 10)
+	if len(list) != 1 || list[0].ID != "s-1" {
+		t.Fatalf("expected only the due settlement, got %+v", list)
+	}
+
+	_ = list[0].MarkSettled(now)
+	if err := repo.Release(ctx, list[0]); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if a.PendingBalance != 40 || a.Balance != 60 {
+		t.Fatalf("expected 40 pending and 60 available, got %v %v", a.PendingBalance, a.Balance)
+	}
+	if err := repo.Release(ctx, list[0]); !errors.Is(err, domain.ErrSettlementNotPending) {
+		t.Fatalf("expected a second release to fail, got %v", err)
+	}
+	if list, _ := repo.ListDue(ctx, now, 10); len(list) != 0 {
+		t.Fatalf("expected nothing due, got %+v", list)
+	}
+
+	missing := &domain.Settlement{ID: "s-3", AccountID: "missing", Amount: 1}
+	if err := repo.Create(ctx, missing); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Fatalf("expected ErrAccountNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// SubscriptionRepositoryMemory is a thread-safe in-memory plan and
+// subscription repository. It stores copies, so callers never share state
+// with it.
+type SubscriptionRepositoryMemory struct {
+	mu            sync.RWMutex
+	plans         map[string]*domain.Plan
+	subscriptions map[string]*domain.Subscription
+}
+
+func NewSubscriptionRepositoryMemory() *SubscriptionRepositoryMemory {
+	return &SubscriptionRepositoryMemory{
+		plans:         make(map[string]*domain.Plan),
+		subscriptions: make(map[string]*domain.Subscription),
+	}
+}
+
+func (r *SubscriptionRepositoryMemory) CreatePlan(ctx context.Context, p *domain.Plan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := *p
+	r.plans[p.ID] = &c
+	return nil
+}
+
+func (r *SubscriptionRepositoryMemory) GetPlan(ctx context.Context, accountID, id string) (*domain.Plan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if p, ok := r.plans[id]; ok && p.AccountID == accountID {
+		c := *p
+		return &c, nil
+	}
+	return nil, domain.ErrPlanNotFound
+}
+
+func (r *SubscriptionRepositoryMemory) ListPlans(ctx context.Context, accountID string) ([]*domain.Plan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.Plan{}
+	for _, p := range r.plans {
+		if p.AccountID == accountID {
+			c := *p
+			out = append(out, &c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *SubscriptionRepositoryMemory) Create(ctx context.Context, s *domain.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscriptions[s.ID] = cloneSubscription(s)
+	return nil
+}
+
+func (r *SubscriptionRepositoryMemory) GetByID(ctx context.Context, accountID, id string) (*domain.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if s, ok := r.subscriptions[id]; ok && s.AccountID == accountID {
+		return cloneSubscription(s), nil
+	}
+	return nil, domain.ErrSubscriptionNotFound
+}
+
+func (r *SubscriptionRepositoryMemory) ListByAccount(ctx context.Context, accountID string) ([]*domain.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.Subscription{}
+	for _, s := range r.subscriptions {
+		if s.AccountID == accountID {
+			out = append(out, cloneSubscription(s))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *SubscriptionRepositoryMemory) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []*domain.Subscription{}
+	for _, s := range r.subscriptions {
+		if s.Due(now) {
+			out = append(out, cloneSubscription(s))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].NextBillingAt.Before(out[j].NextBillingAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *SubscriptionRepositoryMemory) Claim(ctx context.Context, s *domain.Subscription, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.subscriptions[s.ID]
+	if !ok {
+		return domain.ErrSubscriptionNotFound
+	}
+	if stored.Status == domain.SubscriptionCanceled || !stored.NextBillingAt.Equal(s.NextBillingAt) {
+		return domain.ErrSubscriptionClaimed
+	}
+	stored.NextBillingAt = until
+	return nil
+}
+
+func (r *SubscriptionRepositoryMemory) Update(ctx context.Context, s *domain.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.subscriptions[s.ID]
+	if !ok {
+		return domain.ErrSubscriptionNotFound
+	}
+	if stored.Status == domain.SubscriptionCanceled {
+		return domain.ErrInvalidSubscriptionTransition
+	}
+	r.subscriptions[s.ID] = cloneSubscription(s)
+	return nil
+}
+
+func cloneSubscription(s *domain.Subscription) *domain.Subscription {
+	c := *s
+	if s.TrialEndsAt != nil {
+		t := *s.TrialEndsAt
+		c.TrialEndsAt = &t
+	}
+	if s.CanceledAt != nil {
+		t := *s.CanceledAt
+		c.CanceledAt = &t
+	}
+	return &c
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+func TestSubscriptionRepositoryMemory(t *testing.T) {
+	repo := NewSubscriptionRepositoryMemory()
+	ctx := context.Background()
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	plan, _ := domain.NewPlan("acc-1", "Pro", 49.9, domain.IntervalMonth, 1, 0, time.Now().UTC())
+	if err := repo.CreatePlan(ctx, plan); err != nil {
+		t.Fatalf("create plan: %v", err)
+	}
+	if _, err := repo.GetPlan(ctx, "acc-2", plan.ID); !errors.Is(err, domain.ErrPlanNotFound) {
+		t.Fatalf("expected another account not to see the plan, got %v", err)
+	}
+
+	s, _ := domain.NewSubscription(plan, "tok_12345678", "4242", time.Time{}, now)
+	if err := repo.Create(ctx, s); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "acc-2", s.ID); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+		t.Fatalf("expected another account not to see the subscription, got %v", err)
+	}
+	if list, _ := repo.ListByAccount(ctx, "acc-1"); len(list) != 1 {
+		t.Fatalf("expected one subscription, got %+v", list)
+	}
+
+	due, _ := repo.ListDue(ctx, now, 10)
+	if len(due) != 1 {
+		t.Fatalf("expected the subscription to be due, got %+v", due)
+	}
+	if err := repo.Claim(ctx, due[0], now.Add(time.Minute)); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	// A second replica read the same billing date
+	if err := repo.Claim(ctx, due[0], now.Add(time.Minute)); !errors.Is(err, domain.ErrSubscriptionClaimed) {
+		t.Fatalf("expected ErrSubscriptionClaimed, got %v", err)
+	}
+	if due, _ := repo.ListDue(ctx, now, 10); len(due) != 0 {
+		t.Fatalf("expected a claimed subscription not to be due, got %+v", due)
+	}
+
+	_ = due[0].RecordCharge("inv-1", true, domain.DefaultDunningPolicy(), now)
+	if err := repo.Update(ctx, due[0]); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, _ := repo.GetByID(ctx, "acc-1", s.ID)
+	if got.Cycle != 1 || got.LastInvoiceID != "inv-1" {
+		t.Fatalf("expected the charge to be stored, got %+v", got)
+	}
+
+	// A charge loaded before a cancellation does not reactivate it
+	charging := *got
+	_ = got.Cancel(now)
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	_ = charging.RecordCharge("inv-2", true, domain.DefaultDunningPolicy(), now)
+	if err := repo.Update(ctx, &charging); !errors.Is(err, domain.ErrInvalidSubscriptionTransition) {
+		t.Fatalf("expected ErrInvalidSubscriptionTransition, got %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, "acc-1", s.ID); got.Status != domain.SubscriptionCanceled {
+		t.Fatalf("expected the cancellation to stand, got %+v", got)
+	}
+}
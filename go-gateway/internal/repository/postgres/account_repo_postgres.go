@@ -4,36 +4,43 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
 )
 
 // PostgresAccountRepository implements AccountRepository using database/sql.
 type PostgresAccountRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	retry retryPolicy
 }
 
 func NewPostgresAccountRepository(db *sql.DB) *PostgresAccountRepository {
-	return &PostgresAccountRepository{db: db}
+	return &PostgresAccountRepository{db: db, retry: defaultRetry}
 }
 
 func (r *PostgresAccountRepository) Create(ctx context.Context, a *domain.Account) error {
 	const q = `
-		INSERT INTO accounts (id, name, email, api_key, balance, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO accounts (id, name, email, api_key, balance, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
-	_, err := r.db.ExecContext(ctx, q, a.ID, a.Name, a.Email, a.APIKey, a.Balance, a.CreatedAt, a.UpdatedAt)
-	return err
+	return r.retry.do(ctx, func() error {
+		_, err := r.db.ExecContext(ctx, q, a.ID, a.Name, a.Email, a.APIKey, a.Balance, a.Status, a.CreatedAt, a.UpdatedAt)
+		return err
+	})
 }
 
 func (r *PostgresAccountRepository) GetByID(ctx context.Context, id string) (*domain.Account, error) {
 	const q = `
-		SELECT id, name, email, api_key, balance, created_at, updated_at
+		SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at
 		FROM accounts WHERE id = $1
 	`
-	row := r.db.QueryRowContext(ctx, q, id)
 	var a domain.Account
-	if err := scanAccount(row, &a); err != nil {
+	err := r.retry.do(ctx, func() error {
+		return scanAccount(r.db.QueryRowContext(ctx, q, id), &a)
+	})
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrAccountNotFound
 		}
@@ -44,12 +51,14 @@ func (r *PostgresAccountRepository) GetByID(ctx context.Context, id string) (*do
 
 func (r *PostgresAccountRepository) GetByAPIKey(ctx context.Context, apiKey string) (*domain.Account, error) {
 	const q = `
-		SELECT id, name, email, api_key, balance, created_at, updated_at
+		SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at
 		FROM accounts WHERE api_key = $1
 	`
-	row := r.db.QueryRowContext(ctx, q, apiKey)
 	var a domain.Account
-	if err := scanAccount(row, &a); err != nil {
+	err := r.retry.do(ctx, func() error {
+		return scanAccount(r.db.QueryRowContext(ctx, q, apiKey), &a)
+	})
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrAccountNotFound
 		}
@@ -58,13 +67,122 @@ func (r *PostgresAccountRepository) GetByAPIKey(ctx context.Context, apiKey stri
 	return &a, nil
 }
 
-func (r *PostgresAccountRepository) UpdateBalance(ctx context.Context, account *domain.Account) error {
-	// Perform balance update inside a transaction and lock the row until commit
-	tx, err := r.db.BeginTx(ctx, nil)
+// AddBalance credits amount with creditBalance. Only conflicts are retried.
+func (r *PostgresAccountRepository) AddBalance(ctx context.Context, accountID string, amount float64, at time.Time) error {
+	return r.retry.conflictsOnly().do(ctx, func() error {
+		return creditBalance(ctx, r.db, accountID, amount, at)
+	})
+}
+
+// execer runs statements on a *sql.DB or inside a *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// creditBalance adds amount to the balance of an account in a single
+// statement. The database applies the increment atomically, so it needs no
+// row lock and never overwrites a concurrent change to the balance.
+func creditBalance(ctx context.Context, db execer, accountID string, amount float64, at time.Time) error {
+	const q = `UPDATE accounts SET balance = balance + $1, updated_at = $2 WHERE id = $3`
+	res, err := db.ExecContext(ctx, q, amount, at, accountID)
 	if err != nil {
 		return err
 	}
-	// Ensure rollback on any error before successful commit
+	return affected(res, domain.ErrAccountNotFound)
+}
+
+// List returns a page of accounts matching f and the total number of matches.
+func (r *PostgresAccountRepository) List(ctx context.Context, f domain.AccountFilter) ([]*domain.Account, int, error) {
+	const where = `
+		WHERE ($1 = '' OR name ILIKE $1 OR email ILIKE $1)
+		  AND ($2 = '' OR status = $2)
+	`
+	const countQ = `SELECT COUNT(*) FROM accounts` + where
+	const pageQ = `
+		SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at
+		FROM accounts` + where + `
+		ORDER BY created_at DESC, id
+		LIMIT $3 OFFSET $4
+	`
+
+	pattern := ""
+	if f.Query != "" {
+		pattern = "%" + likeEscaper.Replace(f.Query) + "%"
+	}
+	status := string(f.Status)
+
+	var (
+		total    int
+		accounts []*domain.Account
+	)
+	err := r.retry.do(ctx, func() error {
+		accounts = []*domain.Account{}
+		if err := r.db.QueryRowContext(ctx, countQ, pattern, status).Scan(&total); err != nil {
+			return err
+		}
+		rows, err := r.db.QueryContext(ctx, pageQ, pattern, status, f.Limit, f.Offset)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var a domain.Account
+			if err := scanAccount(rows, &a); err != nil {
+				return err
+			}
+			accounts = append(accounts, &a)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return accounts, total, nil
+}
+
+// likeEscaper escapes LIKE wildcards so searches match them literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// UpdateStatus persists the account status.
+func (r *PostgresAccountRepository) UpdateStatus(ctx context.Context, a *domain.Account) error {
+	const q = `UPDATE accounts SET status = $1, updated_at = $2 WHERE id = $3`
+	return r.retry.do(ctx, func() error {
+		res, err := r.db.ExecContext(ctx, q, a.Status, a.UpdatedAt, a.ID)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return domain.ErrAccountNotFound
+		}
+		return nil
+	})
+}
+
+// AdjustBalance locks the account row, applies the adjustment through the
+// domain rules, then stores the new balance and the adjustment record in the
+// same transaction.
+func (r *PostgresAccountRepository) AdjustBalance(ctx context.Context, adj *domain.BalanceAdjustment) (*domain.Account, error) {
+	var account *domain.Account
+	err := r.retry.do(ctx, func() error {
+		var err error
+		account, err = r.adjustBalance(ctx, adj)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (r *PostgresAccountRepository) adjustBalance(ctx context.Context, adj *domain.BalanceAdjustment) (*domain.Account, error) {
+	tx, err := beginTenantTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
 	committed := false
 	defer func() {
 		if !committed {
@@ -72,40 +190,73 @@ func (r *PostgresAccountRepository) UpdateBalance(ctx context.Context, account *
 		}
 	}()
 
-	// Lock the account row to avoid concurrent updates
-	const lockQ = `SELECT id FROM accounts WHERE id = $1 FOR UPDATE`
-	var lockedID string
-	if err := tx.QueryRowContext(ctx, lockQ, account.ID).Scan(&lockedID); err != nil {
+	const lockQ = `
+		SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at
+		FROM accounts WHERE id = $1 FOR UPDATE
+	`
+	var a domain.Account
+	if err := scanAccount(tx.QueryRowContext(ctx, lockQ, adj.AccountID), &a); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return domain.ErrAccountNotFound
+			return nil, domain.ErrAccountNotFound
 		}
-		return err
+		return nil, err
 	}
-
-	const updQ = `
-		UPDATE accounts
-		SET balance = $1, updated_at = $2
-		WHERE id = $3
-	`
-	res, err := tx.ExecContext(ctx, updQ, account.Balance, account.UpdatedAt, account.ID)
-	if err != nil {
-		return err
+	if err := a.AdjustBalance(adj.Amount); err != nil {
+		return nil, err
 	}
-	n, err := res.RowsAffected()
-	if err != nil {
-		return err
+	adj.BalanceAfter = a.Balance
+
+	const updQ = `UPDATE accounts SET balance = $1, updated_at = $2 WHERE id = $3`
+	if _, err := tx.ExecContext(ctx, updQ, a.Balance, a.UpdatedAt, a.ID); err != nil {
+		return nil, err
 	}
-	if n == 0 {
-		return domain.ErrAccountNotFound
+	const insQ = `
+		INSERT INTO balance_adjustments (id, account_id, amount, balance_after, reason, actor, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	if _, err := tx.ExecContext(ctx, insQ, adj.ID, adj.AccountID, adj.Amount, adj.BalanceAfter, adj.Reason, adj.Actor, adj.CreatedAt); err != nil {
+		return nil, err
 	}
 	if err := tx.Commit(); err != nil {
-		return err
+		return nil, err
 	}
 	committed = true
-	return nil
+	return &a, nil
+}
+
+// ListAdjustments returns the adjustments of an account, newest first.
+func (r *PostgresAccountRepository) ListAdjustments(ctx context.Context, accountID string) ([]*domain.BalanceAdjustment, error) {
+	const q = `
+		SELECT id, account_id, amount, balance_after, reason, actor, created_at
+		FROM balance_adjustments WHERE account_id = $1
+		ORDER BY created_at DESC, id
+	`
+	var out []*domain.BalanceAdjustment
+	err := r.retry.do(ctx, func() error {
+		out = []*domain.BalanceAdjustment{}
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, q, accountID)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var adj domain.BalanceAdjustment
+				if err := rows.Scan(&adj.ID, &adj.AccountID, &adj.Amount, &adj.BalanceAfter, &adj.Reason, &adj.Actor, &adj.CreatedAt); err != nil {
+					return err
+				}
+				out = append(out, &adj)
+			}
+			return rows.Err()
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
 // scanAccount scans a single row into Account.
 func scanAccount(row interface{ Scan(dest ...any) error }, a *domain.Account) error {
-	return row.Scan(&a.ID, &a.Name, &a.Email, &a.APIKey, &a.Balance, &a.CreatedAt, &a.UpdatedAt)
+	return row.Scan(&a.ID, &a.Name, &a.Email, &a.APIKey, &a.Balance, &a.PendingBalance, &a.Status, &a.CreatedAt, &a.UpdatedAt)
 }
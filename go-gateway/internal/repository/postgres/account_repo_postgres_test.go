@@ -3,12 +3,14 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
 )
 
 func TestPostgresAccountRepository_CreateAndGet(t *testing.T) {
@@ -27,22 +29,23 @@ func TestPostgresAccountRepository_CreateAndGet(t *testing.T) {
 		Email:     "acme@example.com",
 		APIKey:    "key-1",
 		Balance:   0,
+		Status:    domain.AccountActive,
 		CreatedAt: time.Now().UTC(),
 		UpdatedAt: time.Now().UTC(),
 	}
 
-	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO accounts (id, name, email, api_key, balance, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)")).
-		WithArgs(a.ID, a.Name, a.Email, a.APIKey, a.Balance, a.CreatedAt, a.UpdatedAt).
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO accounts (id, name, email, api_key, balance, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)")).
+		WithArgs(a.ID, a.Name, a.Email, a.APIKey, a.Balance, a.Status, a.CreatedAt, a.UpdatedAt).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	if err := repo.Create(ctx, a); err != nil {
 		t.Fatalf("create: %v", err)
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "name", "email", "api_key", "balance", "created_at", "updated_at"}).
-		AddRow(a.ID, a.Name, a.Email, a.APIKey, a.Balance, a.CreatedAt, a.UpdatedAt)
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "api_key", "balance", "pending_balance", "status", "created_at", "updated_at"}).
+		AddRow(a.ID, a.Name, a.Email, a.APIKey, a.Balance, 0.0, a.Status, a.CreatedAt, a.UpdatedAt)
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, created_at, updated_at FROM accounts WHERE id = $1")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE id = $1")).
 		WithArgs(a.ID).WillReturnRows(rows)
 
 	got, err := repo.GetByID(ctx, a.ID)
@@ -68,7 +71,7 @@ func TestPostgresAccountRepository_GetByAPIKey_NotFound(t *testing.T) {
 	repo := NewPostgresAccountRepository(db)
 	ctx := context.Background()
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, created_at, updated_at FROM accounts WHERE api_key = $1")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE api_key = $1")).
 		WithArgs("nope").WillReturnError(sql.ErrNoRows)
 
 	_, err = repo.GetByAPIKey(ctx, "nope")
@@ -84,7 +87,7 @@ func TestPostgresAccountRepository_GetByAPIKey_NotFound(t *testing.T) {
 	}
 }
 
-func TestPostgresAccountRepository_UpdateBalance(t *testing.T) {
+func TestPostgresAccountRepository_AddBalance(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("sqlmock: %v", err)
@@ -93,52 +96,121 @@ func TestPostgresAccountRepository_UpdateBalance(t *testing.T) {
 
 	repo := NewPostgresAccountRepository(db)
 	ctx := context.Background()
+	now := time.Now().UTC()
 
-	account := &domain.Account{
-		ID:        "acc-1",
-		Name:      "Acme",
-		Email:     "acme@example.com",
-		APIKey:    "key-1",
-		Balance:   150.0, // Updated balance
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
+	// The increment is computed by the database, not from a balance read earlier
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = balance + $1, updated_at = $2 WHERE id = $3")).
+		WithArgs(50.0, now, "acc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.AddBalance(ctx, "acc-1", 50, now); err != nil {
+		t.Fatalf("add balance: %v", err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = balance + $1, updated_at = $2 WHERE id = $3")).
+		WithArgs(50.0, now, "missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := repo.AddBalance(ctx, "missing", 50, now); err != domain.ErrAccountNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresAccountRepository_List(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresAccountRepository(db)
+	now := time.Now().UTC()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM accounts WHERE ($1 = '' OR name ILIKE $1 OR email ILIKE $1) AND ($2 = '' OR status = $2)")).
+		WithArgs(`%50\%%`, "suspended").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE ($1 = '' OR name ILIKE $1 OR email ILIKE $1) AND ($2 = '' OR status = $2) ORDER BY created_at DESC, id LIMIT $3 OFFSET $4")).
+		WithArgs(`%50\%%`, "suspended", 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "api_key", "balance", "pending_balance", "status", "created_at", "updated_at"}).
+			AddRow("acc-3", "50% Off", "off@example.com", "key-3", 0.0, 0.0, "suspended", now, now))
+
+	got, total, err := repo.List(context.Background(), domain.AccountFilter{Query: "50%", Status: domain.AccountSuspended, Limit: 2, Offset: 2})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if total != 3 || len(got) != 1 || got[0].Status != domain.AccountSuspended {
+		t.Fatalf("unexpected page: %d %+v", total, got)
 	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
 
-	mock.ExpectBegin()
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM accounts WHERE id = $1 FOR UPDATE")).
-		WithArgs("acc-1").
-		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("acc-1"))
+func TestPostgresAccountRepository_UpdateStatus_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresAccountRepository(db)
+	a := &domain.Account{ID: "missing", Status: domain.AccountSuspended, UpdatedAt: time.Now().UTC()}
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET status = $1, updated_at = $2 WHERE id = $3")).
+		WithArgs("suspended", a.UpdatedAt, "missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := repo.UpdateStatus(context.Background(), a); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestPostgresAccountRepository_AdjustBalance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresAccountRepository(db)
+	ctx := tenant.WithPrivileged(context.Background())
+	now := time.Now().UTC()
+	lockQ := regexp.QuoteMeta("SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE id = $1 FOR UPDATE")
+	row := func(balance float64) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "email", "api_key", "balance", "pending_balance", "status", "created_at", "updated_at"}).
+			AddRow("acc-1", "Acme", "acme@example.com", "key-1", balance, 0.0, "active", now, now)
+	}
+
+	adj, _ := domain.NewBalanceAdjustment("acc-1", -30, "refund of duplicated charge", "ops@example.com", domain.SystemClock)
+
+	expectPrivilegedTx(mock)
+	mock.ExpectQuery(lockQ).WithArgs("acc-1").WillReturnRows(row(100))
 	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2 WHERE id = $3")).
-		WithArgs(150.0, account.UpdatedAt, "acc-1").
+		WithArgs(70.0, sqlmock.AnyArg(), "acc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO balance_adjustments (id, account_id, amount, balance_after, reason, actor, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)")).
+		WithArgs(adj.ID, "acc-1", -30.0, 70.0, "refund of duplicated charge", "ops@example.com", adj.CreatedAt).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectCommit()
 
-	if err := repo.UpdateBalance(ctx, account); err != nil {
-		t.Fatalf("update balance: %v", err)
+	a, err := repo.AdjustBalance(ctx, adj)
+	if err != nil {
+		t.Fatalf("adjust: %v", err)
 	}
-
-	// Not found: lock query returns no rows
-	missingAccount := &domain.Account{
-		ID:        "missing",
-		Name:      "Missing",
-		Email:     "missing@example.com",
-		APIKey:    "key-missing",
-		Balance:   50.0,
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
+	if a.Balance != 70 || adj.BalanceAfter != 70 {
+		t.Fatalf("expected balance 70, got %v / %v", a.Balance, adj.BalanceAfter)
 	}
 
-	mock.ExpectBegin()
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM accounts WHERE id = $1 FOR UPDATE")).
-		WithArgs("missing").
-		WillReturnError(sql.ErrNoRows)
+	// Debits beyond the balance roll back without writing
+	expectPrivilegedTx(mock)
+	mock.ExpectQuery(lockQ).WithArgs("acc-1").WillReturnRows(row(10))
 	mock.ExpectRollback()
 
-	err = repo.UpdateBalance(ctx, missingAccount)
-	if err != domain.ErrAccountNotFound {
-		t.Fatalf("expected not found, got %v", err)
+	if _, err := repo.AdjustBalance(ctx, adj); !errors.Is(err, domain.ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
 	}
-
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("unmet: %v", err)
 	}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/lib/pq"
+)
+
+// PostgresCheckoutSessionRepository implements
+// domain.CheckoutSessionRepository using PostgreSQL. Checkout sessions are
+// protected by row-level security, so the public lookup by ID needs a
+// privileged context.
+type PostgresCheckoutSessionRepository struct {
+	db    *sql.DB
+	retry retryPolicy
+}
+
+func NewPostgresCheckoutSessionRepository(db *sql.DB) *PostgresCheckoutSessionRepository {
+	return &PostgresCheckoutSessionRepository{db: db, retry: defaultRetry}
+}
+
+// invoice_id is NULL until the session is paid
+const checkoutSessionColumns = `id, account_id, amount, description, payment_types, success_url, cancel_url, status, ` +
+	`COALESCE(invoice_id::text, ''), payment_key, expires_at, completed_at, created_at, updated_at`
+
+func (r *PostgresCheckoutSessionRepository) Create(ctx context.Context, s *domain.CheckoutSession) error {
+	const q = `
+		INSERT INTO checkout_sessions (id, account_id, amount, description, payment_types, success_url, cancel_url, status,
+			invoice_id, payment_key, expires_at, completed_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')::uuid, $10, $11, $12, $13, $14)
+	`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, q, s.ID, s.AccountID, s.Amount, s.Description, pq.Array(s.PaymentTypes), s.SuccessURL, s.CancelURL, s.Status,
+				s.InvoiceID, s.PaymentKey, s.ExpiresAt, s.CompletedAt, s.CreatedAt, s.UpdatedAt)
+			return err
+		})
+	})
+}
+
+func (r *PostgresCheckoutSessionRepository) GetByID(ctx context.Context, id string) (*domain.CheckoutSession, error) {
+	const q = `SELECT ` + checkoutSessionColumns + ` FROM checkout_sessions WHERE id = $1`
+	return r.get(ctx, q, id)
+}
+
+func (r *PostgresCheckoutSessionRepository) GetForAccount(ctx context.Context, accountID, id string) (*domain.CheckoutSession, error) {
+	const q = `SELECT ` + checkoutSessionColumns + ` FROM checkout_sessions WHERE id = $1 AND account_id = $2`
+	return r.get(ctx, q, id, accountID)
+}
+
+func (r *PostgresCheckoutSessionRepository) get(ctx context.Context, q string, args ...any) (*domain.CheckoutSession, error) {
+	var s domain.CheckoutSession
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			return scanCheckoutSession(tx.QueryRowContext(ctx, q, args...), &s)
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrCheckoutSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Claim is a conditional update on the stored status and expiry, so a single
+// payment completes the session.
+func (r *PostgresCheckoutSessionRepository) Claim(ctx context.Context, s *domain.CheckoutSession) error {
+	const q = `
+		UPDATE checkout_sessions SET status = $1, completed_at = $2, updated_at = $3, payment_key = $4
+		WHERE id = $5 AND status = 'open' AND expires_at > $2
+	`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(ctx, q, s.Status, s.CompletedAt, s.UpdatedAt, s.PaymentKey, s.ID)
+			if err != nil {
+				return err
+			}
+			return affected(res, domain.ErrCheckoutCompleted)
+		})
+	})
+}
+
+func (r *PostgresCheckoutSessionRepository) Update(ctx context.Context, s *domain.CheckoutSession) error {
+	const q = `
+		UPDATE checkout_sessions
+		SET status = $1, invoice_id = NULLIF($2, '')::uuid, payment_key = $3, completed_at = $4, updated_at = $5
+		WHERE id = $6
+	`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(ctx, q, s.Status, s.InvoiceID, s.PaymentKey, s.CompletedAt, s.UpdatedAt, s.ID)
+			if err != nil {
+				return err
+			}
+			return affected(res, domain.ErrCheckoutSessionNotFound)
+		})
+	})
+}
+
+func scanCheckoutSession(row interface{ Scan(dest ...any) error }, s *domain.CheckoutSession) error {
+	return row.Scan(&s.ID, &s.AccountID, &s.Amount, &s.Description, pq.Array(&s.PaymentTypes), &s.SuccessURL, &s.CancelURL, &s.Status,
+		&s.InvoiceID, &s.PaymentKey, &s.ExpiresAt, &s.CompletedAt, &s.CreatedAt, &s.UpdatedAt)
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+var checkoutSessionCols = []string{"id", "account_id", "amount", "description", "payment_types", "success_url", "cancel_url", "status",
+	"invoice_id", "payment_key", "expires_at", "completed_at", "created_at", "updated_at"}
+
+func TestPostgresCheckoutSessionRepository_CreateAndGet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresCheckoutSessionRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+	expires := now.Add(time.Hour)
+	s := &domain.CheckoutSession{ID: "cs-1", AccountID: "acc-1", Amount: 99.9, Description: "Order #42", PaymentTypes: []string{"pix", "credit_card"},
+		SuccessURL: "https://shop.example.com/ok", Status: domain.CheckoutOpen, ExpiresAt: expires, CreatedAt: now, UpdatedAt: now}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO checkout_sessions")).
+		WithArgs("cs-1", "acc-1", 99.9, "Order #42", "{\"pix\",\"credit_card\"}", "https://shop.example.com/ok", "", "open",
+			"", "", expires, nil, now, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Create(ctx, s); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("COALESCE(invoice_id::text, ''), payment_key, expires_at, completed_at, created_at, updated_at FROM checkout_sessions WHERE id = $1 AND account_id = $2")).
+		WithArgs("cs-1", "acc-1").
+		WillReturnRows(sqlmock.NewRows(checkoutSessionCols).AddRow("cs-1", "acc-1", 99.9, "Order #42", "{pix,credit_card}", "https://shop.example.com/ok", "",
+			"completed", "inv-1", "checkout/cs-1/k1", expires, now, now, now))
+	mock.ExpectCommit()
+	got, err := repo.GetForAccount(ctx, "acc-1", "cs-1")
+	if err != nil || got.Status != domain.CheckoutCompleted || got.InvoiceID != "inv-1" || len(got.PaymentTypes) != 2 || got.CompletedAt == nil || got.PaymentKey != "checkout/cs-1/k1" {
+		t.Fatalf("unexpected session %+v %v", got, err)
+	}
+
+	// The public checkout reads sessions of any account
+	privileged := tenant.WithPrivileged(context.Background())
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SET LOCAL ROLE gateway_admin")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM checkout_sessions WHERE id = $1")).WithArgs("cs-2").
+		WillReturnRows(sqlmock.NewRows(checkoutSessionCols))
+	mock.ExpectRollback()
+	if _, err := repo.GetByID(privileged, "cs-2"); !errors.Is(err, domain.ErrCheckoutSessionNotFound) {
+		t.Fatalf("expected ErrCheckoutSessionNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresCheckoutSessionRepository_ClaimAndUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresCheckoutSessionRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+	s := &domain.CheckoutSession{ID: "cs-1", AccountID: "acc-1", PaymentTypes: []string{"pix"}, Status: domain.CheckoutOpen, ExpiresAt: now.Add(time.Hour)}
+	_ = s.Claim("pix", now)
+
+	claimQ := regexp.QuoteMeta("UPDATE checkout_sessions SET status = $1, completed_at = $2, updated_at = $3, payment_key = $4 WHERE id = $5 AND status = 'open' AND expires_at > $2")
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(claimQ).WithArgs("completed", now, now, s.PaymentKey, "cs-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Claim(ctx, s); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	// Paid by another request in the meantime
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(claimQ).WithArgs("completed", now, now, s.PaymentKey, "cs-1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	if err := repo.Claim(ctx, s); !errors.Is(err, domain.ErrCheckoutCompleted) {
+		t.Fatalf("expected ErrCheckoutCompleted, got %v", err)
+	}
+
+	s.InvoiceID = "inv-1"
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE checkout_sessions SET status = $1, invoice_id = NULLIF($2, '')::uuid, payment_key = $3, completed_at = $4, updated_at = $5 WHERE id = $6")).
+		WithArgs("completed", "inv-1", s.PaymentKey, now, now, "cs-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Update(ctx, s); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/lib/pq"
+)
+
+// PostgresCustomerRepository implements domain.CustomerRepository using
+// PostgreSQL. Customers and saved cards are protected by row-level security,
+// so every call runs in a transaction bound to the tenant of its context.
+type PostgresCustomerRepository struct {
+	db    *sql.DB
+	retry retryPolicy
+}
+
+func NewPostgresCustomerRepository(db *sql.DB) *PostgresCustomerRepository {
+	return &PostgresCustomerRepository{db: db, retry: defaultRetry}
+}
+
+const (
+	customerColumns  = `id, account_id, name, email, document, created_at`
+	savedCardColumns = `id, account_id, customer_id, card_token, card_last_digits, created_at`
+)
+
+func (r *PostgresCustomerRepository) Create(ctx context.Context, c *domain.Customer) error {
+	const q = `INSERT INTO customers (` + customerColumns + `) VALUES ($1, $2, $3, $4, $5, $6)`
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, q, c.ID, c.AccountID, c.Name, c.Email, c.Document, c.CreatedAt)
+			return err
+		})
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return domain.ErrCustomerExists
+	}
+	return err
+}
+
+func (r *PostgresCustomerRepository) GetByID(ctx context.Context, accountID, id string) (*domain.Customer, error) {
+	const q = `SELECT ` + customerColumns + ` FROM customers WHERE id = $1 AND account_id = $2`
+	var c domain.Customer
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			return scanCustomer(tx.QueryRowContext(ctx, q, id, accountID), &c)
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *PostgresCustomerRepository) ListByAccount(ctx context.Context, accountID string) ([]*domain.Customer, error) {
+	const q = `SELECT ` + customerColumns + ` FROM customers WHERE account_id = $1 ORDER BY created_at DESC, id`
+	var out []*domain.Customer
+	err := r.retry.do(ctx, func() error {
+		out = []*domain.Customer{}
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, q, accountID)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var c domain.Customer
+				if err := scanCustomer(rows, &c); err != nil {
+					return err
+				}
+				out = append(out, &c)
+			}
+			return rows.Err()
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *PostgresCustomerRepository) AddCard(ctx context.Context, card *domain.SavedCard) error {
+	const q = `INSERT INTO saved_cards (` + savedCardColumns + `) VALUES ($1, $2, $3, $4, $5, $6)`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, q, card.ID, card.AccountID, card.CustomerID, card.CardToken, card.CardLastDigits, card.CreatedAt)
+			return err
+		})
+	})
+}
+
+func (r *PostgresCustomerRepository) GetCard(ctx context.Context, accountID, customerID, id string) (*domain.SavedCard, error) {
+	const q = `SELECT ` + savedCardColumns + ` FROM saved_cards WHERE id = $1 AND account_id = $2 AND customer_id = $3`
+	var card domain.SavedCard
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			return scanSavedCard(tx.QueryRowContext(ctx, q, id, accountID, customerID), &card)
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrSavedCardNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+func (r *PostgresCustomerRepository) ListCards(ctx context.Context, accountID, customerID string) ([]*domain.SavedCard, error) {
+	const q = `SELECT ` + savedCardColumns + ` FROM saved_cards WHERE account_id = $1 AND customer_id = $2 ORDER BY created_at DESC, id`
+	var out []*domain.SavedCard
+	err := r.retry.do(ctx, func() error {
+		out = []*domain.SavedCard{}
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, q, accountID, customerID)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var card domain.SavedCard
+				if err := scanSavedCard(rows, &card); err != nil {
+					return err
+				}
+				out = append(out, &card)
+			}
+			return rows.Err()
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteCard removes a saved card; the invoices charged to it lose the
+// reference through ON DELETE SET NULL.
+func (r *PostgresCustomerRepository) DeleteCard(ctx context.Context, accountID, customerID, id string) error {
+	const q = `DELETE FROM saved_cards WHERE id = $1 AND account_id = $2 AND customer_id = $3`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(ctx, q, id, accountID, customerID)
+			if err != nil {
+				return err
+			}
+			return affected(res, domain.ErrSavedCardNotFound)
+		})
+	})
+}
+
+func scanCustomer(row interface{ Scan(dest ...any) error }, c *domain.Customer) error {
+	return row.Scan(&c.ID, &c.AccountID, &c.Name, &c.Email, &c.Document, &c.CreatedAt)
+}
+
+func scanSavedCard(row interface{ Scan(dest ...any) error }, card *domain.SavedCard) error {
+	return row.Scan(&card.ID, &card.AccountID, &card.CustomerID, &card.CardToken, &card.CardLastDigits, &card.CreatedAt)
+}
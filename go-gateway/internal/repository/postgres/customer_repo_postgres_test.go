@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+	"github.com/lib/pq"
+)
+
+var (
+	customerCols  = []string{"id", "account_id", "name", "email", "document", "created_at"}
+	savedCardCols = []string{"id", "account_id", "customer_id", "card_token", "card_last_digits", "created_at"}
+)
+
+func TestPostgresCustomerRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresCustomerRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+	c := &domain.Customer{ID: "cus-1", AccountID: "acc-1", Name: "Maria Silva", Email: "maria@example.com", Document: "52998224725", CreatedAt: now}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO customers (id, account_id, name, email, document, created_at) VALUES ($1, $2, $3, $4, $5, $6)")).
+		WithArgs("cus-1", "acc-1", "Maria Silva", "maria@example.com", "52998224725", now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Create(ctx, c); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO customers")).WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+	if err := repo.Create(ctx, c); !errors.Is(err, domain.ErrCustomerExists) {
+		t.Fatalf("expected ErrCustomerExists, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresCustomerRepository_GetAndList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresCustomerRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM customers WHERE id = $1 AND account_id = $2")).WithArgs("cus-1", "acc-1").
+		WillReturnRows(sqlmock.NewRows(customerCols).AddRow("cus-1", "acc-1", "Maria Silva", "maria@example.com", "52998224725", now))
+	mock.ExpectCommit()
+	if c, err := repo.GetByID(ctx, "acc-1", "cus-1"); err != nil || c.Document != "52998224725" {
+		t.Fatalf("unexpected customer %+v %v", c, err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM customers WHERE id = $1 AND account_id = $2")).WithArgs("cus-2", "acc-1").
+		WillReturnRows(sqlmock.NewRows(customerCols))
+	mock.ExpectRollback()
+	if _, err := repo.GetByID(ctx, "acc-1", "cus-2"); !errors.Is(err, domain.ErrCustomerNotFound) {
+		t.Fatalf("expected ErrCustomerNotFound, got %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM customers WHERE account_id = $1 ORDER BY created_at DESC, id")).WithArgs("acc-1").
+		WillReturnRows(sqlmock.NewRows(customerCols))
+	mock.ExpectCommit()
+	if list, err := repo.ListByAccount(ctx, "acc-1"); err != nil || list == nil || len(list) != 0 {
+		t.Fatalf("expected an empty list, got %v %v", list, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresCustomerRepository_Cards(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresCustomerRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+	card := &domain.SavedCard{ID: "card-1", AccountID: "acc-1", CustomerID: "cus-1", CardToken: "tok_12345678", CardLastDigits: "4242", CreatedAt: now}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO saved_cards (id, account_id, customer_id, card_token, card_last_digits, created_at) VALUES ($1, $2, $3, $4, $5, $6)")).
+		WithArgs("card-1", "acc-1", "cus-1", "tok_12345678", "4242", now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.AddCard(ctx, card); err != nil {
+		t.Fatalf("add card: %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM saved_cards WHERE id = $1 AND account_id = $2 AND customer_id = $3")).WithArgs("card-1", "acc-1", "cus-2").
+		WillReturnRows(sqlmock.NewRows(savedCardCols))
+	mock.ExpectRollback()
+	if _, err := repo.GetCard(ctx, "acc-1", "cus-2", "card-1"); !errors.Is(err, domain.ErrSavedCardNotFound) {
+		t.Fatalf("expected another customer not to see the card, got %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM saved_cards WHERE account_id = $1 AND customer_id = $2 ORDER BY created_at DESC, id")).WithArgs("acc-1", "cus-1").
+		WillReturnRows(sqlmock.NewRows(savedCardCols).AddRow("card-1", "acc-1", "cus-1", "tok_12345678", "4242", now))
+	mock.ExpectCommit()
+	if list, err := repo.ListCards(ctx, "acc-1", "cus-1"); err != nil || len(list) != 1 || list[0].CardToken != "tok_12345678" {
+		t.Fatalf("expected one card, got %v %v", list, err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM saved_cards WHERE id = $1 AND account_id = $2 AND customer_id = $3")).WithArgs("card-1", "acc-1", "cus-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	if err := repo.DeleteCard(ctx, "acc-1", "cus-1", "card-1"); !errors.Is(err, domain.ErrSavedCardNotFound) {
+		t.Fatalf("expected ErrSavedCardNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// PoolConfig tunes the database/sql connection pool and the startup check.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	ConnectAttempts int           // pings before giving up at startup
+	ConnectBackoff  time.Duration // first wait between pings, doubled each attempt
+}
+
+// maxConnectBackoff caps the wait between startup pings.
+const maxConnectBackoff = 10 * time.Second
+
+// Open opens a Postgres pool, applies cfg and waits until the database answers.
+func Open(ctx context.Context, dsn string, cfg PoolConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	ConfigurePool(db, cfg)
+	if err := WaitForDB(ctx, db, cfg.ConnectAttempts, cfg.ConnectBackoff); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// ConfigurePool applies the pool limits to db.
+func ConfigurePool(db *sql.DB, cfg PoolConfig) {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
+
+// WaitForDB pings db up to attempts times with exponential backoff.
+func WaitForDB(ctx context.Context, db *sql.DB, attempts int, backoff time.Duration) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = db.PingContext(ctx); err == nil {
+			return nil
+		}
+		if i == attempts-1 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+	return fmt.Errorf("postgres: database not reachable after %d attempt(s): %w", attempts, err)
+}
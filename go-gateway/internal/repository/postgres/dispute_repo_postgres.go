@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/lib/pq"
+)
+
+// PostgresDisputeRepository implements domain.DisputeRepository using
+// PostgreSQL. Disputes are protected by row-level security; Open and Resolve
+// run as the owning account, so a dispute never lands on another tenant.
+type PostgresDisputeRepository struct {
+	db    *sql.DB
+	retry retryPolicy
+}
+
+func NewPostgresDisputeRepository(db *sql.DB) *PostgresDisputeRepository {
+	return &PostgresDisputeRepository{db: db, retry: defaultRetry}
+}
+
+const disputeColumns = `id, account_id, invoice_id, amount, reason, status, evidence, evidence_due_by, evidence_submitted_at, resolved_at, created_at, updated_at`
+
+// uniqueViolation is the SQLSTATE of a duplicate key.
+const uniqueViolation = "23505"
+
+func (r *PostgresDisputeRepository) Open(ctx context.Context, d *domain.Dispute) error {
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			const insQ = `INSERT INTO disputes (` + disputeColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+			if _, err := tx.ExecContext(ctx, insQ, d.ID, d.AccountID, d.InvoiceID, d.Amount, d.Reason, d.Status, d.Evidence,
+				d.EvidenceDueBy, d.EvidenceSubmittedAt, d.ResolvedAt, d.CreatedAt, d.UpdatedAt); err != nil {
+				return err
+			}
+			// Unlike a payout, the dispute may take the balance below zero
+			const freezeQ = `UPDATE accounts SET balance = balance - $1, updated_at = $2 WHERE id = $3`
+			res, err := tx.ExecContext(ctx, freezeQ, d.Amount, d.CreatedAt, d.AccountID)
+			if err != nil {
+				return err
+			}
+			return affected(res, domain.ErrAccountNotFound)
+		})
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return domain.ErrDisputeExists
+	}
+	return err
+}
+
+func (r *PostgresDisputeRepository) GetByID(ctx context.Context, id string) (*domain.Dispute, error) {
+	const q = `SELECT ` + disputeColumns + ` FROM disputes WHERE id = $1`
+	return r.get(ctx, q, id)
+}
+
+func (r *PostgresDisputeRepository) GetByIDForAccount(ctx context.Context, accountID, id string) (*domain.Dispute, error) {
+	const q = `SELECT ` + disputeColumns + ` FROM disputes WHERE id = $1 AND account_id = $2`
+	return r.get(ctx, q, id, accountID)
+}
+
+func (r *PostgresDisputeRepository) get(ctx context.Context, q string, args ...any) (*domain.Dispute, error) {
+	var d domain.Dispute
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			return scanDispute(tx.QueryRowContext(ctx, q, args...), &d)
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrDisputeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *PostgresDisputeRepository) ListByAccount(ctx context.Context, accountID string) ([]*domain.Dispute, error) {
+	const q = `SELECT ` + disputeColumns + ` FROM disputes WHERE account_id = $1 ORDER BY created_at DESC, id`
+	return r.list(ctx, q, accountID)
+}
+
+func (r *PostgresDisputeRepository) ListOverdue(ctx context.Context, now time.Time, limit int) ([]*domain.Dispute, error) {
+	const q = `
+		SELECT ` + disputeColumns + `
+		FROM disputes WHERE status = 'needs_response' AND evidence_due_by < $1
+		ORDER BY evidence_due_by, id LIMIT $2
+	`
+	return r.list(ctx, q, now, limit)
+}
+
+func (r *PostgresDisputeRepository) list(ctx context.Context, q string, args ...any) ([]*domain.Dispute, error) {
+	var out []*domain.Dispute
+	err := r.retry.do(ctx, func() error {
+		out = []*domain.Dispute{}
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, q, args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var d domain.Dispute
+				if err := scanDispute(rows, &d); err != nil {
+					return err
+				}
+				out = append(out, &d)
+			}
+			return rows.Err()
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SubmitEvidence is a conditional update on the status, so evidence racing
+// the expiry job is either recorded or rejected.
+func (r *PostgresDisputeRepository) SubmitEvidence(ctx context.Context, d *domain.Dispute) error {
+	const q = `
+		UPDATE disputes SET status = $1, evidence = $2, evidence_submitted_at = $3, updated_at = $4
+		WHERE id = $5 AND status = 'needs_response'
+	`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(ctx, q, d.Status, d.Evidence, d.EvidenceSubmittedAt, d.UpdatedAt, d.ID)
+			if err != nil {
+				return err
+			}
+			return affected(res, domain.ErrInvalidDisputeTransition)
+		})
+	})
+}
+
+// Resolve is a conditional update on the status, so a dispute is resolved
+// and its funds released once.
+func (r *PostgresDisputeRepository) Resolve(ctx context.Context, d *domain.Dispute) error {
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			const resolveQ = `
+				UPDATE disputes SET status = $1, resolved_at = $2, updated_at = $3
+				WHERE id = $4 AND status IN ('needs_response', 'under_review')
+			`
+			res, err := tx.ExecContext(ctx, resolveQ, d.Status, d.ResolvedAt, d.UpdatedAt, d.ID)
+			if err != nil {
+				return err
+			}
+			if err := affected(res, domain.ErrInvalidDisputeTransition); err != nil {
+				return err
+			}
+			if d.Status != domain.DisputeWon {
+				return nil
+			}
+			return creditBalance(ctx, tx, d.AccountID, d.Amount, d.UpdatedAt)
+		})
+	})
+}
+
+func scanDispute(row interface{ Scan(dest ...any) error }, d *domain.Dispute) error {
+	return row.Scan(&d.ID, &d.AccountID, &d.InvoiceID, &d.Amount, &d.Reason, &d.Status, &d.Evidence, &d.EvidenceDueBy,
+		&d.EvidenceSubmittedAt, &d.ResolvedAt, &d.CreatedAt, &d.UpdatedAt)
+}
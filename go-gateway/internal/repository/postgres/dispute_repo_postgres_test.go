@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+	"github.com/lib/pq"
+)
+
+var disputeCols = []string{"id", "account_id", "invoice_id", "amount", "reason", "status", "evidence", "evidence_due_by", "evidence_submitted_at", "resolved_at", "created_at", "updated_at"}
+
+func TestPostgresDisputeRepository_Open(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresDisputeRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+	d := &domain.Dispute{ID: "dsp-1", AccountID: "acc-1", InvoiceID: "inv-1", Amount: 80, Reason: domain.DisputeFraudulent,
+		Status: domain.DisputeNeedsResponse, EvidenceDueBy: now.AddDate(0, 0, 7), CreatedAt: now, UpdatedAt: now}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO disputes ("+disputeColumns+")")).
+		WithArgs("dsp-1", "acc-1", "inv-1", 80.0, domain.DisputeFraudulent, domain.DisputeNeedsResponse, "", d.EvidenceDueBy, nil, nil, now, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = balance - $1, updated_at = $2 WHERE id = $3")).
+		WithArgs(80.0, now, "acc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Open(ctx, d); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO disputes")).WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+	if err := repo.Open(ctx, d); !errors.Is(err, domain.ErrDisputeExists) {
+		t.Fatalf("expected ErrDisputeExists, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresDisputeRepository_GetAndList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresDisputeRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM disputes WHERE id = $1 AND account_id = $2")).WithArgs("dsp-1", "acc-1").
+		WillReturnRows(sqlmock.NewRows(disputeCols).
+			AddRow("dsp-1", "acc-1", "inv-1", 80.0, "fraudulent", "under_review", "receipt", now, now, nil, now, now))
+	mock.ExpectCommit()
+	got, err := repo.GetByIDForAccount(ctx, "acc-1", "dsp-1")
+	if err != nil || got.Status != domain.DisputeUnderReview || got.EvidenceSubmittedAt == nil || got.ResolvedAt != nil {
+		t.Fatalf("unexpected dispute %+v %v", got, err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM disputes WHERE id = $1 AND account_id = $2")).WithArgs("dsp-2", "acc-1").
+		WillReturnRows(sqlmock.NewRows(disputeCols))
+	mock.ExpectRollback()
+	if _, err := repo.GetByIDForAccount(ctx, "acc-1", "dsp-2"); !errors.Is(err, domain.ErrDisputeNotFound) {
+		t.Fatalf("expected ErrDisputeNotFound, got %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM disputes WHERE account_id = $1 ORDER BY created_at DESC, id")).WithArgs("acc-1").
+		WillReturnRows(sqlmock.NewRows(disputeCols))
+	mock.ExpectCommit()
+	if list, err := repo.ListByAccount(ctx, "acc-1"); err != nil || list == nil || len(list) != 0 {
+		t.Fatalf("expected an empty list, got %v %v", list, err)
+	}
+
+	admin := tenant.WithPrivileged(context.Background())
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SET LOCAL ROLE gateway_admin")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM disputes WHERE status = 'needs_response' AND evidence_due_by < $1 ORDER BY evidence_due_by, id LIMIT $2")).
+		WithArgs(now, 10).
+		WillReturnRows(sqlmock.NewRows(disputeCols).
+			AddRow("dsp-3", "acc-2", "inv-3", 15.5, "general", "needs_response", "", now.Add(-time.Hour), nil, nil, now, now))
+	mock.ExpectCommit()
+	if list, err := repo.ListOverdue(admin, now, 10); err != nil || len(list) != 1 || list[0].AccountID != "acc-2" {
+		t.Fatalf("expected one overdue dispute, got %v %v", list, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresDisputeRepository_EvidenceAndResolve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresDisputeRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+	d := &domain.Dispute{ID: "dsp-1", AccountID: "acc-1", Amount: 80, Status: domain.DisputeUnderReview, Evidence: "signed receipt",
+		EvidenceSubmittedAt: &now, UpdatedAt: now}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE disputes SET status = $1, evidence = $2, evidence_submitted_at = $3, updated_at = $4 WHERE id = $5 AND status = 'needs_response'")).
+		WithArgs(domain.DisputeUnderReview, "signed receipt", d.EvidenceSubmittedAt, now, "dsp-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	if err := repo.SubmitEvidence(ctx, d); !errors.Is(err, domain.ErrInvalidDisputeTransition) {
+		t.Fatalf("expected ErrInvalidDisputeTransition, got %v", err)
+	}
+
+	d.Status = domain.DisputeWon
+	d.ResolvedAt = &now
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE disputes SET status = $1, resolved_at = $2, updated_at = $3 WHERE id = $4 AND status IN ('needs_response', 'under_review')")).
+		WithArgs(domain.DisputeWon, d.ResolvedAt, now, "dsp-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = balance + $1, updated_at = $2 WHERE id = $3")).
+		WithArgs(80.0, now, "acc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Resolve(ctx, d); err != nil {
+		t.Fatalf("resolve won: %v", err)
+	}
+
+	// A lost dispute keeps its amount debited
+	d.Status = domain.DisputeLost
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE disputes SET status = $1")).
+		WithArgs(domain.DisputeLost, d.ResolvedAt, now, "dsp-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Resolve(ctx, d); err != nil {
+		t.Fatalf("resolve lost: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
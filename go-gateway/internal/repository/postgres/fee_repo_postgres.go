@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// PostgresFeeRepository implements domain.FeeRepository using PostgreSQL.
+type PostgresFeeRepository struct {
+	db    *sql.DB
+	retry retryPolicy
+}
+
+func NewPostgresFeeRepository(db *sql.DB) *PostgresFeeRepository {
+	return &PostgresFeeRepository{db: db, retry: defaultRetry}
+}
+
+const feePlanColumns = `account_id, payment_type, percent, fixed, updated_at`
+
+func (r *PostgresFeeRepository) GetPlan(ctx context.Context, accountID, paymentType string) (*domain.FeePlan, error) {
+	const q = `SELECT ` + feePlanColumns + ` FROM fee_plans WHERE account_id = $1 AND payment_type = $2`
+	var p domain.FeePlan
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			return scanFeePlan(tx.QueryRowContext(ctx, q, accountID, paymentType), &p)
+		})
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrFeePlanNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PostgresFeeRepository) ListPlans(ctx context.Context, accountID string) ([]*domain.FeePlan, error) {
+	const q = `SELECT ` + feePlanColumns + ` FROM fee_plans WHERE account_id = $1 ORDER BY payment_type`
+	var out []*domain.FeePlan
+	err := r.retry.do(ctx, func() error {
+		out = []*domain.FeePlan{}
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, q, accountID)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var p domain.FeePlan
+				if err := scanFeePlan(rows, &p); err != nil {
+					return err
+				}
+				out = append(out, &p)
+			}
+			return rows.Err()
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *PostgresFeeRepository) SavePlan(ctx context.Context, p *domain.FeePlan) error {
+	const q = `
+		INSERT INTO fee_plans (account_id, payment_type, percent, fixed, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (account_id, payment_type)
+		DO UPDATE SET percent = EXCLUDED.percent, fixed = EXCLUDED.fixed, updated_at = EXCLUDED.updated_at
+	`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, q, p.AccountID, p.PaymentType, p.Percent, p.Fixed, p.UpdatedAt)
+			return err
+		})
+	})
+}
+
+const recordFeeQ = `
+	INSERT INTO invoice_fees (id, invoice_id, account_id, payment_type, gross, fee, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+func (r *PostgresFeeRepository) Record(ctx context.Context, f *domain.InvoiceFee) error {
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, recordFeeQ, f.ID, f.InvoiceID, f.AccountID, f.PaymentType, f.Gross, f.Fee, f.CreatedAt)
+			return err
+		})
+	})
+}
+
+func (r *PostgresFeeRepository) Revenue(ctx context.Context, from, to time.Time) ([]domain.RevenueLine, error) {
+	const q = `
+		SELECT payment_type, COUNT(*), SUM(gross), SUM(fee)
+		FROM invoice_fees WHERE created_at >= $1 AND created_at < $2
+		GROUP BY payment_type ORDER BY payment_type
+	`
+	var out []domain.RevenueLine
+	err := r.retry.do(ctx, func() error {
+		out = []domain.RevenueLine{}
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, q, from, to)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var l domain.RevenueLine
+				if err := rows.Scan(&l.PaymentType, &l.Invoices, &l.Gross, &l.Fees); err != nil {
+					return err
+				}
+				out = append(out, l)
+			}
+			return rows.Err()
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func scanFeePlan(row interface{ Scan(dest ...any) error }, p *domain.FeePlan) error {
+	return row.Scan(&p.AccountID, &p.PaymentType, &p.Percent, &p.Fixed, &p.UpdatedAt)
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+func TestPostgresFeeRepository_Plans(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresFeeRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+	getQ := regexp.QuoteMeta("SELECT account_id, payment_type, percent, fixed, updated_at FROM fee_plans WHERE account_id = $1 AND payment_type = $2")
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(getQ).WithArgs("acc-1", "pix").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	if _, err := repo.GetPlan(ctx, "acc-1", "pix"); !errors.Is(err, domain.ErrFeePlanNotFound) {
+		t.Fatalf("expected ErrFeePlanNotFound, got %v", err)
+	}
+
+	p, _ := domain.NewFeePlan("acc-1", "pix", 0.99, 0.1, domain.SystemClock)
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO fee_plans (account_id, payment_type, percent, fixed, updated_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (account_id, payment_type) DO UPDATE")).
+		WithArgs("acc-1", "pix", 0.99, 0.1, p.UpdatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.SavePlan(ctx, p); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(getQ).WithArgs("acc-1", "pix").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "payment_type", "percent", "fixed", "updated_at"}).
+			AddRow("acc-1", "pix", 0.99, 0.1, now))
+	mock.ExpectCommit()
+	got, err := repo.GetPlan(ctx, "acc-1", "pix")
+	if err != nil || got.Percent != 0.99 || got.Fixed != 0.1 {
+		t.Fatalf("unexpected plan %+v %v", got, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresFeeRepository_RecordAndRevenue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresFeeRepository(db)
+
+	f := &domain.InvoiceFee{ID: "fee-1", InvoiceID: "inv-1", AccountID: "acc-1", PaymentType: "pix", Gross: 100, Fee: 1, CreatedAt: time.Now().UTC()}
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoice_fees (id, invoice_id, account_id, payment_type, gross, fee, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)")).
+		WithArgs("fee-1", "inv-1", "acc-1", "pix", 100.0, 1.0, f.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Record(tenant.WithAccount(context.Background(), "acc-1"), f); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	// Revenue spans every account, so only the admin API reads it
+	expectPrivilegedTx(mock)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT payment_type, COUNT(*), SUM(gross), SUM(fee) FROM invoice_fees WHERE created_at >= $1 AND created_at < $2 GROUP BY payment_type")).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"payment_type", "count", "sum", "sum"}).
+			AddRow("credit_card", 3, 300.0, 13.14).
+			AddRow("pix", 1, 100.0, 1.0))
+	mock.ExpectCommit()
+	lines, err := repo.Revenue(tenant.WithPrivileged(context.Background()), from, to)
+	if err != nil {
+		t.Fatalf("revenue: %v", err)
+	}
+	if len(lines) != 2 || lines[0] != (domain.RevenueLine{PaymentType: "credit_card", Invoices: 3, Gross: 300, Fees: 13.14}) {
+		t.Fatalf("unexpected revenue %+v", lines)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// PostgresInstallmentRulesRepository implements
+// domain.InstallmentRulesRepository using PostgreSQL. Rules are protected by
+// row-level security like invoices.
+type PostgresInstallmentRulesRepository struct {
+	db    *sql.DB
+	retry retryPolicy
+}
+
+func NewPostgresInstallmentRulesRepository(db *sql.DB) *PostgresInstallmentRulesRepository {
+	return &PostgresInstallmentRulesRepository{db: db, retry: defaultRetry}
+}
+
+const installmentRulesColumns = `account_id, max_installments, free_installments, monthly_interest, updated_at`
+
+func (r *PostgresInstallmentRulesRepository) Get(ctx context.Context, accountID string) (*domain.InstallmentRules, error) {
+	const q = `SELECT ` + installmentRulesColumns + ` FROM installment_rules WHERE account_id = $1`
+	var rules domain.InstallmentRules
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			return tx.QueryRowContext(ctx, q, accountID).Scan(
+				&rules.AccountID, &rules.MaxInstallments, &rules.FreeInstallments, &rules.MonthlyInterest, &rules.UpdatedAt)
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrInstallmentRulesNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+func (r *PostgresInstallmentRulesRepository) Save(ctx context.Context, rules *domain.InstallmentRules) error {
+	const q = `
+		INSERT INTO installment_rules (` + installmentRulesColumns + `)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (account_id)
+		DO UPDATE SET max_installments = EXCLUDED.max_installments, free_installments = EXCLUDED.free_installments,
+			monthly_interest = EXCLUDED.monthly_interest, updated_at = EXCLUDED.updated_at
+	`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, q, rules.AccountID, rules.MaxInstallments, rules.FreeInstallments, rules.MonthlyInterest, rules.UpdatedAt)
+			return err
+		})
+	})
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+func TestPostgresInstallmentRulesRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresInstallmentRulesRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+	getQ := regexp.QuoteMeta("SELECT account_id, max_installments, free_installments, monthly_interest, updated_at FROM installment_rules WHERE account_id = $1")
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(getQ).WithArgs("acc-1").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	if _, err := repo.Get(ctx, "acc-1"); !errors.Is(err, domain.ErrInstallmentRulesNotFound) {
+		t.Fatalf("expected ErrInstallmentRulesNotFound, got %v", err)
+	}
+
+	rules, _ := domain.NewInstallmentRules("acc-1", 10, 3, 1.99, now)
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO installment_rules (account_id, max_installments, free_installments, monthly_interest, updated_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (account_id) DO UPDATE")).
+		WithArgs("acc-1", 10, 3, 1.99, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Save(ctx, rules); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(getQ).WithArgs("acc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "max_installments", "free_installments", "monthly_interest", "updated_at"}).
+			AddRow("acc-1", 10, 3, 1.99, now))
+	mock.ExpectCommit()
+	got, err := repo.Get(ctx, "acc-1")
+	if err != nil || got.MaxInstallments != 10 || got.FreeInstallments != 3 || got.MonthlyInterest != 1.99 {
+		t.Fatalf("unexpected rules %+v %v", got, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
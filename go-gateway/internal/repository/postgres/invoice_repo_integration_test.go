@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/testdb"
+)
+
+func TestInvoiceCharge_Integration(t *testing.T) {
+	owner, app := testdb.Open(t)
+	accounts := NewPostgresAccountRepository(app)
+	invoices := NewPostgresInvoiceRepository(app)
+	a := createTestAccount(t, owner, accounts, "charge")
+	ctx := tenant.WithAccount(context.Background(), a.ID)
+
+	// Concurrent invoices of one account credit it without losing any
+	var wg sync.WaitGroup
+	for range 20 {
+		inv := newTestInvoice(t, a.ID)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := invoices.CreateWithCharge(ctx, inv, domain.InvoiceCharge{Credit: 10}); err != nil {
+				t.Errorf("create: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := invoices.CreateWithCharge(ctx, newKeyedInvoice(t, a.ID, "key-42"), domain.InvoiceCharge{Credit: 10}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// A reused key rolls the credit back with the invoice
+	if err := invoices.CreateWithCharge(ctx, newKeyedInvoice(t, a.ID, "key-42"), domain.InvoiceCharge{Credit: 10}); !errors.Is(err, domain.ErrIdempotencyKeyInUse) {
+		t.Fatalf("expected ErrIdempotencyKeyInUse, got %v", err)
+	}
+
+	got, err := accounts.GetByID(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("get account: %v", err)
+	}
+	if got.Balance != 210 {
+		t.Fatalf("expected 21 credits of 10, got %v", got.Balance)
+	}
+}
+
+func newKeyedInvoice(t *testing.T, accountID, key string) *domain.Invoice {
+	t.Helper()
+	i := newTestInvoice(t, accountID)
+	if err := i.SetIdempotencyKey(key); err != nil {
+		t.Fatalf("set key: %v", err)
+	}
+	return i
+}
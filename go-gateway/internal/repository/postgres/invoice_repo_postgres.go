@@ -3,50 +3,150 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/lib/pq"
 )
 
 // PostgresInvoiceRepository implements domain.InvoiceRepository using PostgreSQL.
+// Invoices are protected by row-level security, so every call runs in a
+// transaction bound to the tenant of its context.
 type PostgresInvoiceRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	retry retryPolicy
 }
 
+// invoiceColumns are read by scanInvoice. customer_id and saved_card_id are
+// NULL for invoices without a payer, external_reference and idempotency_key
+// without one.
+const invoiceColumns = `id, account_id, amount, installments, total_amount, fee, net_amount, status, description, payment_type, card_last_digits, ` +
+	`customer_id, saved_card_id, items, metadata, external_reference, idempotency_key, risk_decision, risk_reasons, created_at, updated_at`
+
 // NewPostgresInvoiceRepository creates a new PostgreSQL invoice repository.
 func NewPostgresInvoiceRepository(db *sql.DB) *PostgresInvoiceRepository {
-	return &PostgresInvoiceRepository{db: db}
+	return &PostgresInvoiceRepository{db: db, retry: defaultRetry}
 }
 
-// Create stores a new invoice in PostgreSQL.
+// Create stores a new invoice in PostgreSQL. A merchant reference or
+// idempotency key already used by the account is reported as
+// domain.ErrExternalReferenceExists or domain.ErrIdempotencyKeyInUse.
 func (r *PostgresInvoiceRepository) Create(ctx context.Context, i *domain.Invoice) error {
+	return r.CreateWithCharge(ctx, i, domain.InvoiceCharge{})
+}
+
+// CreateWithCharge inserts the invoice first, so a unique violation rolls
+// the transaction back before the balance, the settlements and the fee are
+// written.
+func (r *PostgresInvoiceRepository) CreateWithCharge(ctx context.Context, i *domain.Invoice, charge domain.InvoiceCharge) error {
 	query := `
-		INSERT INTO invoices (id, account_id, amount, status, description, payment_type, card_last_digits, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO invoices (id, account_id, amount, installments, total_amount, fee, net_amount, status, description, payment_type, card_last_digits,
+			customer_id, saved_card_id, items, metadata, external_reference, idempotency_key, risk_decision, risk_reasons, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, '')::uuid, NULLIF($13, '')::uuid, $14, $15, NULLIF($16, ''), NULLIF($17, ''), $18, $19, $20, $21)
 	`
+	const installmentQ = `INSERT INTO invoice_installments (invoice_id, account_id, number, amount, due_at) VALUES ($1, $2, $3, $4, $5)`
 
-	_, err := r.db.ExecContext(ctx, query,
-		i.ID, i.AccountID, i.Amount, i.Status, i.Description, i.PaymentType, i.CardLastDigits, i.CreatedAt, i.UpdatedAt)
-
+	items, metadata, err := encodeDetails(i)
 	if err != nil {
 		return err
 	}
+	err = r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, query,
+				i.ID, i.AccountID, i.Amount, i.Installments, i.TotalAmount, i.Fee, i.NetAmount, i.Status, i.Description, i.PaymentType, i.CardLastDigits,
+				i.CustomerID, i.SavedCardID, items, metadata, i.ExternalReference, i.IdempotencyKey, i.RiskDecision, pq.Array(riskReasons(i)), i.CreatedAt, i.UpdatedAt)
+			if err != nil {
+				return err
+			}
+			for _, in := range i.Schedule {
+				if _, err := tx.ExecContext(ctx, installmentQ, i.ID, i.AccountID, in.Number, in.Amount, in.DueAt); err != nil {
+					return err
+				}
+			}
+			return applyCharge(ctx, tx, i, charge)
+		})
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		switch pqErr.Constraint {
+		case "idx_invoices_external_reference":
+			return domain.ErrExternalReferenceExists
+		case "idx_invoices_idempotency_key":
+			return domain.ErrIdempotencyKeyInUse
+		}
+	}
+	return err
+}
 
+// applyCharge credits the balance, holds the settlements and records the fee
+// of a stored invoice.
+func applyCharge(ctx context.Context, tx *sql.Tx, i *domain.Invoice, charge domain.InvoiceCharge) error {
+	if charge.Credit > 0 {
+		if err := creditBalance(ctx, tx, i.AccountID, charge.Credit, i.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	for _, st := range charge.Settlements {
+		if err := insertSettlement(ctx, tx, st); err != nil {
+			return err
+		}
+	}
+	if f := charge.Fee; f != nil {
+		if _, err := tx.ExecContext(ctx, recordFeeQ, f.ID, f.InvoiceID, f.AccountID, f.PaymentType, f.Gross, f.Fee, f.CreatedAt); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // GetByID retrieves an invoice by its ID from PostgreSQL.
 func (r *PostgresInvoiceRepository) GetByID(ctx context.Context, id string) (*domain.Invoice, error) {
 	query := `
-		SELECT id, account_id, amount, status, description, payment_type, card_last_digits, created_at, updated_at
+		SELECT ` + invoiceColumns + `
 		FROM invoices
 		WHERE id = $1
 	`
 
 	var invoice domain.Invoice
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&invoice.ID, &invoice.AccountID, &invoice.Amount, &invoice.Status, &invoice.Description,
-		&invoice.PaymentType, &invoice.CardLastDigits, &invoice.CreatedAt, &invoice.UpdatedAt)
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			if err := scanInvoice(tx.QueryRowContext(ctx, query, id), &invoice); err != nil {
+				return err
+			}
+			return loadSchedule(ctx, tx, &invoice)
+		})
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+
+	return &invoice, nil
+}
+
+// GetByIDForAccount retrieves an invoice by its ID only if it belongs to the
+// account.
+func (r *PostgresInvoiceRepository) GetByIDForAccount(ctx context.Context, accountID, id string) (*domain.Invoice, error) {
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE id = $1 AND account_id = $2
+	`
+
+	var invoice domain.Invoice
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			if err := scanInvoice(tx.QueryRowContext(ctx, query, id, accountID), &invoice); err != nil {
+				return err
+			}
+			return loadSchedule(ctx, tx, &invoice)
+		})
+	})
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -61,33 +161,109 @@ func (r *PostgresInvoiceRepository) GetByID(ctx context.Context, id string) (*do
 // GetByAccountID retrieves all invoices for a specific account from PostgreSQL.
 func (r *PostgresInvoiceRepository) GetByAccountID(ctx context.Context, accountID string) ([]*domain.Invoice, error) {
 	query := `
-		SELECT id, account_id, amount, status, description, payment_type, card_last_digits, created_at, updated_at
+		SELECT ` + invoiceColumns + `
 		FROM invoices
 		WHERE account_id = $1
 		ORDER BY created_at DESC
 	`
+	return r.list(ctx, query, accountID)
+}
+
+// GetByCustomer retrieves the invoices of a customer of the account, newest
+// first.
+func (r *PostgresInvoiceRepository) GetByCustomer(ctx context.Context, accountID, customerID string) ([]*domain.Invoice, error) {
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE account_id = $1 AND customer_id = $2
+		ORDER BY created_at DESC
+	`
+	return r.list(ctx, query, accountID, customerID)
+}
+
+// GetByExternalReference retrieves the invoice of the account with the
+// merchant reference.
+func (r *PostgresInvoiceRepository) GetByExternalReference(ctx context.Context, accountID, ref string) (*domain.Invoice, error) {
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE account_id = $1 AND external_reference = $2
+	`
+
+	var invoice domain.Invoice
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			if err := scanInvoice(tx.QueryRowContext(ctx, query, accountID, ref), &invoice); err != nil {
+				return err
+			}
+			return loadSchedule(ctx, tx, &invoice)
+		})
+	})
 
-	rows, err := r.db.QueryContext(ctx, query, accountID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrInvoiceNotFound
+		}
 		return nil, err
 	}
-	defer rows.Close()
 
-	var invoices []*domain.Invoice
-	for rows.Next() {
-		var invoice domain.Invoice
-		err := rows.Scan(
-			&invoice.ID, &invoice.AccountID, &invoice.Amount, &invoice.Status, &invoice.Description,
-			&invoice.PaymentType, &invoice.CardLastDigits, &invoice.CreatedAt, &invoice.UpdatedAt)
+	return &invoice, nil
+}
 
-		if err != nil {
-			return nil, err
-		}
+// GetByIdempotencyKey retrieves the invoice of the account created with the
+// idempotency key.
+func (r *PostgresInvoiceRepository) GetByIdempotencyKey(ctx context.Context, accountID, key string) (*domain.Invoice, error) {
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE account_id = $1 AND idempotency_key = $2
+	`
+
+	var invoice domain.Invoice
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			if err := scanInvoice(tx.QueryRowContext(ctx, query, accountID, key), &invoice); err != nil {
+				return err
+			}
+			return loadSchedule(ctx, tx, &invoice)
+		})
+	})
 
-		invoices = append(invoices, &invoice)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrInvoiceNotFound
+		}
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
+	return &invoice, nil
+}
+
+// list runs an invoice query without loading installment schedules.
+func (r *PostgresInvoiceRepository) list(ctx context.Context, query string, args ...any) ([]*domain.Invoice, error) {
+	var invoices []*domain.Invoice
+	err := r.retry.do(ctx, func() error {
+		invoices = nil
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, query, args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var invoice domain.Invoice
+				if err := scanInvoice(rows, &invoice); err != nil {
+					return err
+				}
+
+				invoices = append(invoices, &invoice)
+			}
+
+			return rows.Err()
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -102,7 +278,14 @@ func (r *PostgresInvoiceRepository) UpdateStatus(ctx context.Context, id string,
 		WHERE id = $2
 	`
 
-	result, err := r.db.ExecContext(ctx, query, status, id)
+	var result sql.Result
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			var err error
+			result, err = tx.ExecContext(ctx, query, status, id)
+			return err
+		})
+	})
 	if err != nil {
 		return err
 	}
@@ -118,3 +301,119 @@ func (r *PostgresInvoiceRepository) UpdateStatus(ctx context.Context, id string,
 
 	return nil
 }
+
+// CountByCardSince counts the invoices of an account charged to a card since
+// a moment.
+func (r *PostgresInvoiceRepository) CountByCardSince(ctx context.Context, accountID, cardLastDigits string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM invoices
+		WHERE account_id = $1 AND card_last_digits = $2 AND created_at >= $3
+	`
+
+	var n int
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			return tx.QueryRowContext(ctx, query, accountID, cardLastDigits, since).Scan(&n)
+		})
+	})
+	return n, err
+}
+
+// scanInvoice reads the invoiceColumns of a row.
+func scanInvoice(row interface{ Scan(dest ...any) error }, i *domain.Invoice) error {
+	var customerID, savedCardID, externalReference, idempotencyKey sql.NullString
+	var items, metadata []byte
+	err := row.Scan(&i.ID, &i.AccountID, &i.Amount, &i.Installments, &i.TotalAmount, &i.Fee, &i.NetAmount, &i.Status, &i.Description,
+		&i.PaymentType, &i.CardLastDigits, &customerID, &savedCardID, &items, &metadata, &externalReference, &idempotencyKey, &i.RiskDecision, pq.Array(&i.RiskReasons),
+		&i.CreatedAt, &i.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	i.CustomerID = customerID.String
+	i.SavedCardID = savedCardID.String
+	i.ExternalReference = externalReference.String
+	i.IdempotencyKey = idempotencyKey.String
+	return decodeDetails(items, metadata, i)
+}
+
+// loadSchedule reads the installments of an invoice paid in more than one.
+func loadSchedule(ctx context.Context, tx *sql.Tx, i *domain.Invoice) error {
+	if i.Installments <= 1 {
+		return nil
+	}
+	const q = `SELECT number, amount, due_at FROM invoice_installments WHERE invoice_id = $1 ORDER BY number`
+	rows, err := tx.QueryContext(ctx, q, i.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	i.Schedule = nil
+	for rows.Next() {
+		var in domain.Installment
+		if err := rows.Scan(&in.Number, &in.Amount, &in.DueAt); err != nil {
+			return err
+		}
+		i.Schedule = append(i.Schedule, in)
+	}
+	return rows.Err()
+}
+
+// lineItemJSON is the stored form of a domain.LineItem.
+type lineItemJSON struct {
+	SKU       string  `json:"sku"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// encodeDetails returns the items and metadata columns of an invoice. Absent
+// details are stored as an empty array and object, never NULL.
+func encodeDetails(i *domain.Invoice) (items, metadata []byte, err error) {
+	rows := make([]lineItemJSON, len(i.Items))
+	for k, it := range i.Items {
+		rows[k] = lineItemJSON{SKU: it.SKU, Quantity: it.Quantity, UnitPrice: it.UnitPrice}
+	}
+	if items, err = json.Marshal(rows); err != nil {
+		return nil, nil, err
+	}
+	m := i.Metadata
+	if m == nil {
+		m = map[string]string{}
+	}
+	if metadata, err = json.Marshal(m); err != nil {
+		return nil, nil, err
+	}
+	return items, metadata, nil
+}
+
+// decodeDetails reads the items and metadata columns. Empty ones leave the
+// fields nil.
+func decodeDetails(items, metadata []byte, i *domain.Invoice) error {
+	i.Items, i.Metadata = nil, nil
+	if len(items) > 0 {
+		var rows []lineItemJSON
+		if err := json.Unmarshal(items, &rows); err != nil {
+			return err
+		}
+		for _, r := range rows {
+			i.Items = append(i.Items, domain.LineItem{SKU: r.SKU, Quantity: r.Quantity, UnitPrice: r.UnitPrice})
+		}
+	}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &i.Metadata); err != nil {
+			return err
+		}
+		if len(i.Metadata) == 0 {
+			i.Metadata = nil
+		}
+	}
+	return nil
+}
+
+// riskReasons never returns nil: a nil array is stored as NULL.
+func riskReasons(i *domain.Invoice) []string {
+	if i.RiskReasons == nil {
+		return []string{}
+	}
+	return i.RiskReasons
+}
@@ -3,12 +3,15 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+	"github.com/lib/pq"
 )
 
 func TestPostgresInvoiceRepository_Create(t *testing.T) {
@@ -19,7 +22,7 @@ func TestPostgresInvoiceRepository_Create(t *testing.T) {
 	defer db.Close()
 
 	repo := NewPostgresInvoiceRepository(db)
-	ctx := context.Background()
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
 
 	invoice := &domain.Invoice{
 		ID:             "inv-1",
@@ -29,13 +32,17 @@ func TestPostgresInvoiceRepository_Create(t *testing.T) {
 		Description:    "Test invoice",
 		PaymentType:    "credit_card",
 		CardLastDigits: "1234",
+		RiskDecision:   domain.RiskReview,
+		RiskReasons:    []string{"amount_threshold: amount 100.50 above the limit of 100.00"},
 		CreatedAt:      time.Now().UTC(),
 		UpdatedAt:      time.Now().UTC(),
 	}
 
-	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoices (id, account_id, amount, status, description, payment_type, card_last_digits, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)")).
-		WithArgs(invoice.ID, invoice.AccountID, invoice.Amount, invoice.Status, invoice.Description, invoice.PaymentType, invoice.CardLastDigits, invoice.CreatedAt, invoice.UpdatedAt).
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoices (id, account_id, amount, installments, total_amount, fee, net_amount, status, description, payment_type, card_last_digits, customer_id, saved_card_id, items, metadata, external_reference, idempotency_key, risk_decision, risk_reasons, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, '')::uuid, NULLIF($13, '')::uuid, $14, $15, NULLIF($16, ''), NULLIF($17, ''), $18, $19, $20, $21)")).
+		WithArgs(invoice.ID, invoice.AccountID, invoice.Amount, invoice.Installments, invoice.TotalAmount, invoice.Fee, invoice.NetAmount, invoice.Status, invoice.Description, invoice.PaymentType, invoice.CardLastDigits, "", "", []byte("[]"), []byte("{}"), "", "", domain.RiskReview, `{"amount_threshold: amount 100.50 above the limit of 100.00"}`, invoice.CreatedAt, invoice.UpdatedAt).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	if err := repo.Create(ctx, invoice); err != nil {
 		t.Fatalf("create: %v", err)
@@ -46,6 +53,64 @@ func TestPostgresInvoiceRepository_Create(t *testing.T) {
 	}
 }
 
+func TestPostgresInvoiceRepository_CreateWithCharge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresInvoiceRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+	clock := domain.NewFakeClock(now)
+
+	invoice, _ := domain.NewInvoice("acc-1", "Test invoice", "credit_card", 100, "1234", clock)
+	_ = invoice.SetIdempotencyKey("key-42")
+	invoice.Status = domain.StatusApproved
+	_ = invoice.ApplyFee(domain.FeePlan{Fixed: 1})
+	held := domain.NewSettlement(invoice, now.AddDate(0, 0, 30))
+	held.Amount = 40
+	charge := domain.InvoiceCharge{Credit: 59, Settlements: []*domain.Settlement{held}, Fee: domain.NewInvoiceFee(invoice, clock)}
+
+	// The invoice goes in first; the money moves in the same transaction
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoices")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = balance + $1, updated_at = $2 WHERE id = $3")).WithArgs(59.0, now, "acc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO settlements")).WithArgs(held.ID, "acc-1", invoice.ID, 1, 40.0, domain.SettlementPending, held.AvailableAt, held.CreatedAt, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET pending_balance = pending_balance + $1")).WithArgs(40.0, held.CreatedAt, "acc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoice_fees")).WithArgs(charge.Fee.ID, invoice.ID, "acc-1", "credit_card", 100.0, 1.0, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.CreateWithCharge(ctx, invoice, charge); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// A reused key rolls back before any money moves
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoices")).WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_invoices_idempotency_key"})
+	mock.ExpectRollback()
+	if err := repo.CreateWithCharge(ctx, invoice, charge); !errors.Is(err, domain.ErrIdempotencyKeyInUse) {
+		t.Fatalf("expected ErrIdempotencyKeyInUse, got %v", err)
+	}
+
+	// A missing account fails the whole invoice
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoices")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = balance + $1")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	if err := repo.CreateWithCharge(ctx, invoice, charge); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Fatalf("expected ErrAccountNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
 func TestPostgresInvoiceRepository_GetByID(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -54,7 +119,7 @@ func TestPostgresInvoiceRepository_GetByID(t *testing.T) {
 	defer db.Close()
 
 	repo := NewPostgresInvoiceRepository(db)
-	ctx := context.Background()
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
 
 	invoice := &domain.Invoice{
 		ID:             "inv-1",
@@ -68,11 +133,13 @@ func TestPostgresInvoiceRepository_GetByID(t *testing.T) {
 		UpdatedAt:      time.Now().UTC(),
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "status", "description", "payment_type", "card_last_digits", "created_at", "updated_at"}).
-		AddRow(invoice.ID, invoice.AccountID, invoice.Amount, invoice.Status, invoice.Description, invoice.PaymentType, invoice.CardLastDigits, invoice.CreatedAt, invoice.UpdatedAt)
+	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "installments", "total_amount", "fee", "net_amount", "status", "description", "payment_type", "card_last_digits", "customer_id", "saved_card_id", "items", "metadata", "external_reference", "idempotency_key", "risk_decision", "risk_reasons", "created_at", "updated_at"}).
+		AddRow(invoice.ID, invoice.AccountID, invoice.Amount, invoice.Installments, invoice.TotalAmount, invoice.Fee, invoice.NetAmount, invoice.Status, invoice.Description, invoice.PaymentType, invoice.CardLastDigits, nil, nil, nil, nil, nil, nil, "review", `{"amount_threshold: amount 100.50 above the limit of 100.00"}`, invoice.CreatedAt, invoice.UpdatedAt)
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, account_id, amount, status, description, payment_type, card_last_digits, created_at, updated_at FROM invoices WHERE id = $1")).
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, account_id, amount, installments, total_amount, fee, net_amount, status, description, payment_type, card_last_digits, customer_id, saved_card_id, items, metadata, external_reference, idempotency_key, risk_decision, risk_reasons, created_at, updated_at FROM invoices WHERE id = $1")).
 		WithArgs(invoice.ID).WillReturnRows(rows)
+	mock.ExpectCommit()
 
 	got, err := repo.GetByID(ctx, invoice.ID)
 	if err != nil {
@@ -81,6 +148,9 @@ func TestPostgresInvoiceRepository_GetByID(t *testing.T) {
 	if got.ID != invoice.ID {
 		t.Fatalf("expected same id")
 	}
+	if got.RiskDecision != domain.RiskReview || len(got.RiskReasons) != 1 || got.RiskReasons[0] != "amount_threshold: amount 100.50 above the limit of 100.00" {
+		t.Fatalf("unexpected risk assessment %v %q", got.RiskDecision, got.RiskReasons)
+	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("unmet: %v", err)
@@ -95,10 +165,12 @@ func TestPostgresInvoiceRepository_GetByID_NotFound(t *testing.T) {
 	defer db.Close()
 
 	repo := NewPostgresInvoiceRepository(db)
-	ctx := context.Background()
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, account_id, amount, status, description, payment_type, card_last_digits, created_at, updated_at FROM invoices WHERE id = $1")).
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, account_id, amount, installments, total_amount, fee, net_amount, status, description, payment_type, card_last_digits, customer_id, saved_card_id, items, metadata, external_reference, idempotency_key, risk_decision, risk_reasons, created_at, updated_at FROM invoices WHERE id = $1")).
 		WithArgs("nope").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
 
 	_, err = repo.GetByID(ctx, "nope")
 	if err == nil {
@@ -121,7 +193,7 @@ func TestPostgresInvoiceRepository_GetByAccountID(t *testing.T) {
 	defer db.Close()
 
 	repo := NewPostgresInvoiceRepository(db)
-	ctx := context.Background()
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
 
 	accountID := "acc-1"
 	invoices := []*domain.Invoice{
@@ -149,13 +221,15 @@ func TestPostgresInvoiceRepository_GetByAccountID(t *testing.T) {
 		},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "status", "description", "payment_type", "card_last_digits", "created_at", "updated_at"})
+	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "installments", "total_amount", "fee", "net_amount", "status", "description", "payment_type", "card_last_digits", "customer_id", "saved_card_id", "items", "metadata", "external_reference", "idempotency_key", "risk_decision", "risk_reasons", "created_at", "updated_at"})
 	for _, invoice := range invoices {
-		rows.AddRow(invoice.ID, invoice.AccountID, invoice.Amount, invoice.Status, invoice.Description, invoice.PaymentType, invoice.CardLastDigits, invoice.CreatedAt, invoice.UpdatedAt)
+		rows.AddRow(invoice.ID, invoice.AccountID, invoice.Amount, invoice.Installments, invoice.TotalAmount, invoice.Fee, invoice.NetAmount, invoice.Status, invoice.Description, invoice.PaymentType, invoice.CardLastDigits, nil, nil, nil, nil, nil, nil, "approve", "{}", invoice.CreatedAt, invoice.UpdatedAt)
 	}
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, account_id, amount, status, description, payment_type, card_last_digits, created_at, updated_at FROM invoices WHERE account_id = $1 ORDER BY created_at DESC")).
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, account_id, amount, installments, total_amount, fee, net_amount, status, description, payment_type, card_last_digits, customer_id, saved_card_id, items, metadata, external_reference, idempotency_key, risk_decision, risk_reasons, created_at, updated_at FROM invoices WHERE account_id = $1 ORDER BY created_at DESC")).
 		WithArgs(accountID).WillReturnRows(rows)
+	mock.ExpectCommit()
 
 	got, err := repo.GetByAccountID(ctx, accountID)
 	if err != nil {
@@ -178,14 +252,16 @@ func TestPostgresInvoiceRepository_GetByAccountID_Empty(t *testing.T) {
 	defer db.Close()
 
 	repo := NewPostgresInvoiceRepository(db)
-	ctx := context.Background()
+	ctx := tenant.WithAccount(context.Background(), "acc-2")
 
 	accountID := "acc-2"
 
-	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "status", "description", "payment_type", "card_last_digits", "created_at", "updated_at"})
+	rows := sqlmock.NewRows([]string{"id", "account_id", "amount", "installments", "total_amount", "fee", "net_amount", "status", "description", "payment_type", "card_last_digits", "customer_id", "saved_card_id", "items", "metadata", "external_reference", "idempotency_key", "risk_decision", "risk_reasons", "created_at", "updated_at"})
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, account_id, amount, status, description, payment_type, card_last_digits, created_at, updated_at FROM invoices WHERE account_id = $1 ORDER BY created_at DESC")).
+	expectTenantTx(mock, "acc-2")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, account_id, amount, installments, total_amount, fee, net_amount, status, description, payment_type, card_last_digits, customer_id, saved_card_id, items, metadata, external_reference, idempotency_key, risk_decision, risk_reasons, created_at, updated_at FROM invoices WHERE account_id = $1 ORDER BY created_at DESC")).
 		WithArgs(accountID).WillReturnRows(rows)
+	mock.ExpectCommit()
 
 	got, err := repo.GetByAccountID(ctx, accountID)
 	if err != nil {
@@ -208,14 +284,16 @@ func TestPostgresInvoiceRepository_UpdateStatus(t *testing.T) {
 	defer db.Close()
 
 	repo := NewPostgresInvoiceRepository(db)
-	ctx := context.Background()
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
 
 	invoiceID := "inv-1"
 	newStatus := domain.StatusApproved
 
+	expectTenantTx(mock, "acc-1")
 	mock.ExpectExec(regexp.QuoteMeta("UPDATE invoices SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2")).
 		WithArgs(newStatus, invoiceID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	if err := repo.UpdateStatus(ctx, invoiceID, newStatus); err != nil {
 		t.Fatalf("update status: %v", err)
@@ -234,14 +312,16 @@ func TestPostgresInvoiceRepository_UpdateStatus_NotFound(t *testing.T) {
 	defer db.Close()
 
 	repo := NewPostgresInvoiceRepository(db)
-	ctx := context.Background()
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
 
 	invoiceID := "missing"
 	newStatus := domain.StatusApproved
 
+	expectTenantTx(mock, "acc-1")
 	mock.ExpectExec(regexp.QuoteMeta("UPDATE invoices SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2")).
 		WithArgs(newStatus, invoiceID).
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
 
 	updateErr := repo.UpdateStatus(ctx, invoiceID, newStatus)
 	if updateErr == nil {
@@ -255,3 +335,201 @@ func TestPostgresInvoiceRepository_UpdateStatus_NotFound(t *testing.T) {
 		t.Fatalf("unmet: %v", err)
 	}
 }
+
+func TestPostgresInvoiceRepository_CountByCardSince(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresInvoiceRepository(db)
+	since := time.Now().UTC().Add(-time.Hour)
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM invoices WHERE account_id = $1 AND card_last_digits = $2 AND created_at >= $3")).
+		WithArgs("acc-1", "1234", since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectCommit()
+
+	n, err := repo.CountByCardSince(tenant.WithAccount(context.Background(), "acc-1"), "acc-1", "1234", since)
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 invoices, got %d %v", n, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresInvoiceRepository_GetByCustomer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresInvoiceRepository(db)
+	now := time.Now().UTC()
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM invoices WHERE account_id = $1 AND customer_id = $2 ORDER BY created_at DESC")).
+		WithArgs("acc-1", "cus-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "amount", "installments", "total_amount", "fee", "net_amount", "status", "description", "payment_type", "card_last_digits", "customer_id", "saved_card_id", "items", "metadata", "external_reference", "idempotency_key", "risk_decision", "risk_reasons", "created_at", "updated_at"}).
+			AddRow("inv-1", "acc-1", 50.0, 1, 50.0, 0.0, 0.0, "approved", "Order 42", "credit_card", "4242", "cus-1", "card-1", "[]", "{}", nil, nil, "approve", "{}", now, now).
+			AddRow("inv-2", "acc-1", 20.0, 1, 20.0, 0.0, 0.0, "approved", "Order 43", "pix", "", "cus-1", nil, "[]", "{}", nil, nil, "approve", "{}", now, now))
+	mock.ExpectCommit()
+
+	got, err := repo.GetByCustomer(tenant.WithAccount(context.Background(), "acc-1"), "acc-1", "cus-1")
+	if err != nil || len(got) != 2 {
+		t.Fatalf("expected 2 invoices, got %v %v", got, err)
+	}
+	if got[0].CustomerID != "cus-1" || got[0].SavedCardID != "card-1" || got[1].SavedCardID != "" {
+		t.Fatalf("expected the customer and saved card read, got %+v %+v", got[0], got[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresInvoiceRepository_GetByIDForAccount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresInvoiceRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-2")
+
+	expectTenantTx(mock, "acc-2")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, account_id, amount, installments, total_amount, fee, net_amount, status, description, payment_type, card_last_digits, customer_id, saved_card_id, items, metadata, external_reference, idempotency_key, risk_decision, risk_reasons, created_at, updated_at FROM invoices WHERE id = $1 AND account_id = $2")).
+		WithArgs("inv-1", "acc-2").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	if _, err := repo.GetByIDForAccount(ctx, "acc-2", "inv-1"); err != domain.ErrInvoiceNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresInvoiceRepository_Installments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresInvoiceRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+
+	invoice, _ := domain.NewInvoice("acc-1", "Test invoice", "credit_card", 100, "1234", domain.NewFakeClock(now))
+	_ = invoice.SetInstallments(2, domain.DefaultInstallmentRules("acc-1"))
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoices")).
+		WithArgs(invoice.ID, "acc-1", 100.0, 2, 100.0, 0.0, 0.0, domain.StatusPending, "Test invoice", "credit_card", "1234", "", "", []byte("[]"), []byte("{}"), "", "", domain.RiskApprove, "{}", now, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	insQ := regexp.QuoteMeta("INSERT INTO invoice_installments (invoice_id, account_id, number, amount, due_at) VALUES ($1, $2, $3, $4, $5)")
+	mock.ExpectExec(insQ).WithArgs(invoice.ID, "acc-1", 1, 50.0, now).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insQ).WithArgs(invoice.ID, "acc-1", 2, 50.0, now.AddDate(0, 1, 0)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Create(ctx, invoice); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM invoices WHERE id = $1 AND account_id = $2")).WithArgs(invoice.ID, "acc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "amount", "installments", "total_amount", "fee", "net_amount", "status", "description", "payment_type", "card_last_digits", "customer_id", "saved_card_id", "items", "metadata", "external_reference", "idempotency_key", "risk_decision", "risk_reasons", "created_at", "updated_at"}).
+			AddRow(invoice.ID, "acc-1", 100.0, 2, 100.0, 0.0, 0.0, "pending", "Test invoice", "credit_card", "1234", nil, nil, nil, nil, nil, nil, "approve", "{}", now, now))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT number, amount, due_at FROM invoice_installments WHERE invoice_id = $1 ORDER BY number")).WithArgs(invoice.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"number", "amount", "due_at"}).
+			AddRow(1, 50.0, now).AddRow(2, 50.0, now.AddDate(0, 1, 0)))
+	mock.ExpectCommit()
+
+	got, err := repo.GetByIDForAccount(ctx, "acc-1", invoice.ID)
+	if err != nil || got.Installments != 2 || len(got.Schedule) != 2 || got.Schedule[1].Number != 2 || got.Schedule[1].Amount != 50 {
+		t.Fatalf("expected the schedule loaded, got %+v %v", got, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresInvoiceRepository_Details(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresInvoiceRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+
+	invoice, _ := domain.NewInvoice("acc-1", "Test invoice", "pix", 25, "", domain.NewFakeClock(now))
+	_ = invoice.SetDetails([]domain.LineItem{{SKU: "SKU-1", Quantity: 2, UnitPrice: 10}, {SKU: "SKU-2", Quantity: 1, UnitPrice: 5}},
+		map[string]string{"channel": "web"}, "order-42")
+	items := []byte(`[{"sku":"SKU-1","quantity":2,"unit_price":10},{"sku":"SKU-2","quantity":1,"unit_price":5}]`)
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoices")).
+		WithArgs(invoice.ID, "acc-1", 25.0, 1, 25.0, 0.0, 0.0, domain.StatusPending, "Test invoice", "pix", "", "", "", items, []byte(`{"channel":"web"}`), "order-42", "", domain.RiskApprove, "{}", now, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Create(ctx, invoice); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoices")).WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_invoices_external_reference"})
+	mock.ExpectRollback()
+	if err := repo.Create(ctx, invoice); !errors.Is(err, domain.ErrExternalReferenceExists) {
+		t.Fatalf("expected ErrExternalReferenceExists, got %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoices")).WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_invoices_idempotency_key"})
+	mock.ExpectRollback()
+	if err := repo.Create(ctx, invoice); !errors.Is(err, domain.ErrIdempotencyKeyInUse) {
+		t.Fatalf("expected ErrIdempotencyKeyInUse, got %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM invoices WHERE account_id = $1 AND idempotency_key = $2")).WithArgs("acc-1", "key-42").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "amount", "installments", "total_amount", "fee", "net_amount", "status", "description", "payment_type", "card_last_digits", "customer_id", "saved_card_id", "items", "metadata", "external_reference", "idempotency_key", "risk_decision", "risk_reasons", "created_at", "updated_at"}).
+			AddRow(invoice.ID, "acc-1", 25.0, 1, 25.0, 0.0, 0.0, "pending", "Test invoice", "pix", "", nil, nil, "[]", "{}", nil, "key-42", "approve", "{}", now, now))
+	mock.ExpectCommit()
+	if got, err := repo.GetByIdempotencyKey(ctx, "acc-1", "key-42"); err != nil || got.ID != invoice.ID || got.IdempotencyKey != "key-42" {
+		t.Fatalf("expected the invoice created with the key, got %+v %v", got, err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM invoices WHERE account_id = $1 AND external_reference = $2")).WithArgs("acc-1", "order-42").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "amount", "installments", "total_amount", "fee", "net_amount", "status", "description", "payment_type", "card_last_digits", "customer_id", "saved_card_id", "items", "metadata", "external_reference", "idempotency_key", "risk_decision", "risk_reasons", "created_at", "updated_at"}).
+			AddRow(invoice.ID, "acc-1", 25.0, 1, 25.0, 0.0, 0.0, "pending", "Test invoice", "pix", "", nil, nil, items, `{"channel":"web"}`, "order-42", nil, "approve", "{}", now, now))
+	mock.ExpectCommit()
+	got, err := repo.GetByExternalReference(ctx, "acc-1", "order-42")
+	if err != nil || got.ExternalReference != "order-42" || got.Metadata["channel"] != "web" || len(got.Items) != 2 || got.Items[0].Total() != 20 {
+		t.Fatalf("expected the details read, got %+v %v", got, err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM invoices WHERE account_id = $1 AND external_reference = $2")).WithArgs("acc-1", "order-99").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	if _, err := repo.GetByExternalReference(ctx, "acc-1", "order-99"); !errors.Is(err, domain.ErrInvoiceNotFound) {
+		t.Fatalf("expected ErrInvoiceNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
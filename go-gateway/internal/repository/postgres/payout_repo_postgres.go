@@ -0,0 +1,284 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// PostgresPayoutRepository implements domain.PayoutRepository using PostgreSQL.
+type PostgresPayoutRepository struct {
+	db    *sql.DB
+	retry retryPolicy
+}
+
+func NewPostgresPayoutRepository(db *sql.DB) *PostgresPayoutRepository {
+	return &PostgresPayoutRepository{db: db, retry: defaultRetry}
+}
+
+const payoutColumns = `id, account_id, bank_account_id, amount, status, failure_reason, transfer_reference, created_at, updated_at`
+
+func (r *PostgresPayoutRepository) CreateBankAccount(ctx context.Context, b *domain.BankAccount) error {
+	const q = `
+		INSERT INTO bank_accounts (id, account_id, bank_code, branch, number, holder_name, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, q, b.ID, b.AccountID, b.BankCode, b.Branch, b.Number, b.HolderName, b.CreatedAt)
+			return err
+		})
+	})
+}
+
+func (r *PostgresPayoutRepository) GetBankAccount(ctx context.Context, accountID, id string) (*domain.BankAccount, error) {
+	const q = `
+		SELECT id, account_id, bank_code, branch, number, holder_name, created_at
+		FROM bank_accounts WHERE id = $1 AND account_id = $2
+	`
+	var b domain.BankAccount
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			return scanBankAccount(tx.QueryRowContext(ctx, q, id, accountID), &b)
+		})
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrBankAccountNotFound
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *PostgresPayoutRepository) ListBankAccounts(ctx context.Context, accountID string) ([]*domain.BankAccount, error) {
+	const q = `
+		SELECT id, account_id, bank_code, branch, number, holder_name, created_at
+		FROM bank_accounts WHERE account_id = $1
+		ORDER BY created_at DESC, id
+	`
+	var out []*domain.BankAccount
+	err := r.retry.do(ctx, func() error {
+		out = []*domain.BankAccount{}
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, q, accountID)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var b domain.BankAccount
+				if err := scanBankAccount(rows, &b); err != nil {
+					return err
+				}
+				out = append(out, &b)
+			}
+			return rows.Err()
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Create locks the account row, debits the payout through the policy, then
+// stores the new balance and the payout in the same transaction.
+func (r *PostgresPayoutRepository) Create(ctx context.Context, p *domain.Payout, policy domain.PayoutPolicy) (*domain.Account, error) {
+	var account *domain.Account
+	err := r.retry.do(ctx, func() error {
+		var err error
+		account, err = r.create(ctx, p, policy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (r *PostgresPayoutRepository) create(ctx context.Context, p *domain.Payout, policy domain.PayoutPolicy) (*domain.Account, error) {
+	tx, err := beginTenantTx(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	const lockQ = `
+		SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at
+		FROM accounts WHERE id = $1 FOR UPDATE
+	`
+	var a domain.Account
+	if err := scanAccount(tx.QueryRowContext(ctx, lockQ, p.AccountID), &a); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrAccountNotFound
+		}
+		return nil, err
+	}
+	if err := policy.Debit(&a, p.Amount); err != nil {
+		return nil, err
+	}
+
+	const updQ = `UPDATE accounts SET balance = $1, updated_at = $2 WHERE id = $3`
+	if _, err := tx.ExecContext(ctx, updQ, a.Balance, a.UpdatedAt, a.ID); err != nil {
+		return nil, err
+	}
+	const insQ = `
+		INSERT INTO payouts (` + payoutColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	if _, err := tx.ExecContext(ctx, insQ, p.ID, p.AccountID, p.BankAccountID, p.Amount, p.Status, p.FailureReason, p.TransferReference, p.CreatedAt, p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	committed = true
+	return &a, nil
+}
+
+func (r *PostgresPayoutRepository) GetByID(ctx context.Context, accountID, id string) (*domain.Payout, error) {
+	const q = `SELECT ` + payoutColumns + ` FROM payouts WHERE id = $1 AND account_id = $2`
+	var p domain.Payout
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			return scanPayout(tx.QueryRowContext(ctx, q, id, accountID), &p)
+		})
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrPayoutNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PostgresPayoutRepository) ListByAccount(ctx context.Context, accountID string) ([]*domain.Payout, error) {
+	const q = `SELECT ` + payoutColumns + ` FROM payouts WHERE account_id = $1 ORDER BY created_at DESC, id`
+	return r.list(ctx, q, accountID)
+}
+
+func (r *PostgresPayoutRepository) ListByStatus(ctx context.Context, status domain.PayoutStatus, limit int) ([]*domain.Payout, error) {
+	const q = `SELECT ` + payoutColumns + ` FROM payouts WHERE status = $1 ORDER BY created_at, id LIMIT $2`
+	return r.list(ctx, q, status, limit)
+}
+
+func (r *PostgresPayoutRepository) ListStuck(ctx context.Context, before time.Time, limit int) ([]*domain.Payout, error) {
+	const q = `
+		SELECT ` + payoutColumns + ` FROM payouts
+		WHERE status = 'processing' AND updated_at < $1
+		ORDER BY updated_at, id LIMIT $2
+	`
+	return r.list(ctx, q, before, limit)
+}
+
+func (r *PostgresPayoutRepository) list(ctx context.Context, q string, args ...any) ([]*domain.Payout, error) {
+	var out []*domain.Payout
+	err := r.retry.do(ctx, func() error {
+		out = []*domain.Payout{}
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, q, args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				p := &domain.Payout{}
+				if err := scanPayout(rows, p); err != nil {
+					return err
+				}
+				out = append(out, p)
+			}
+			return rows.Err()
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+const updatePayoutQ = `
+	UPDATE payouts SET status = $1, failure_reason = $2, transfer_reference = $3, updated_at = $4
+	WHERE id = $5 AND status = $6
+`
+
+// UpdateStatus persists the payout status, with the transfer reference of a
+// paid payout, only if it is still from.
+func (r *PostgresPayoutRepository) UpdateStatus(ctx context.Context, p *domain.Payout, from domain.PayoutStatus) error {
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(ctx, updatePayoutQ, p.Status, p.FailureReason, p.TransferReference, p.UpdatedAt, p.ID, from)
+			if err != nil {
+				return err
+			}
+			return expectOneRow(res)
+		})
+	})
+}
+
+// Fail marks the payout failed and credits its amount back in one transaction.
+func (r *PostgresPayoutRepository) Fail(ctx context.Context, p *domain.Payout, from domain.PayoutStatus) error {
+	return r.retry.do(ctx, func() error {
+		return r.fail(ctx, p, from)
+	})
+}
+
+func (r *PostgresPayoutRepository) fail(ctx context.Context, p *domain.Payout, from domain.PayoutStatus) error {
+	tx, err := beginTenantTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, updatePayoutQ, p.Status, p.FailureReason, p.TransferReference, p.UpdatedAt, p.ID, from)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(res); err != nil {
+		return err
+	}
+	if err := creditBalance(ctx, tx, p.AccountID, p.Amount, p.UpdatedAt); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// expectOneRow turns a conditional update that matched nothing into
+// ErrInvalidPayoutTransition.
+func expectOneRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrInvalidPayoutTransition
+	}
+	return nil
+}
+
+func scanBankAccount(row interface{ Scan(dest ...any) error }, b *domain.BankAccount) error {
+	return row.Scan(&b.ID, &b.AccountID, &b.BankCode, &b.Branch, &b.Number, &b.HolderName, &b.CreatedAt)
+}
+
+func scanPayout(row interface{ Scan(dest ...any) error }, p *domain.Payout) error {
+	return row.Scan(&p.ID, &p.AccountID, &p.BankAccountID, &p.Amount, &p.Status, &p.FailureReason, &p.TransferReference, &p.CreatedAt, &p.UpdatedAt)
+}
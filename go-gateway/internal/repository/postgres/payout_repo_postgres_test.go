@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+func TestPostgresPayoutRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresPayoutRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+	policy := domain.PayoutPolicy{MinAmount: 10, ReserveRate: 0.1}
+	lockQ := regexp.QuoteMeta("SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE id = $1 FOR UPDATE")
+	row := func(balance float64) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "email", "api_key", "balance", "pending_balance", "status", "created_at", "updated_at"}).
+			AddRow("acc-1", "Acme", "acme@example.com", "key-1", balance, 0.0, "active", now, now)
+	}
+
+	p, _ := domain.NewPayout("acc-1", "bank-1", 80, domain.SystemClock)
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(lockQ).WithArgs("acc-1").WillReturnRows(row(100))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2 WHERE id = $3")).
+		WithArgs(20.0, sqlmock.AnyArg(), "acc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO payouts (id, account_id, bank_account_id, amount, status, failure_reason, transfer_reference, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)")).
+		WithArgs(p.ID, "acc-1", "bank-1", 80.0, "pending", "", "", p.CreatedAt, p.UpdatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	a, err := repo.Create(ctx, p, policy)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if a.Balance != 20 {
+		t.Fatalf("expected balance 20, got %v", a.Balance)
+	}
+
+	// The reserve keeps 10% of the balance: nothing is written
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(lockQ).WithArgs("acc-1").WillReturnRows(row(85))
+	mock.ExpectRollback()
+
+	if _, err := repo.Create(ctx, p, policy); !errors.Is(err, domain.ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresPayoutRepository_GetByID_ScopedToAccount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresPayoutRepository(db)
+	expectTenantTx(mock, "acc-2")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, account_id, bank_account_id, amount, status, failure_reason, transfer_reference, created_at, updated_at FROM payouts WHERE id = $1 AND account_id = $2")).
+		WithArgs("pay-1", "acc-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	if _, err := repo.GetByID(tenant.WithAccount(context.Background(), "acc-2"), "acc-2", "pay-1"); !errors.Is(err, domain.ErrPayoutNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresPayoutRepository_UpdateStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresPayoutRepository(db)
+	ctx := tenant.WithPrivileged(context.Background())
+	p, _ := domain.NewPayout("acc-1", "bank-1", 50, domain.SystemClock)
+	_ = p.StartProcessing()
+	updQ := regexp.QuoteMeta("UPDATE payouts SET status = $1, failure_reason = $2, transfer_reference = $3, updated_at = $4 WHERE id = $5 AND status = $6")
+
+	expectPrivilegedTx(mock)
+	mock.ExpectExec(updQ).WithArgs("processing", "", "", p.UpdatedAt, p.ID, "pending").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.UpdateStatus(ctx, p, domain.PayoutPending); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	// Another worker already moved it
+	expectPrivilegedTx(mock)
+	mock.ExpectExec(updQ).WithArgs("processing", "", "", p.UpdatedAt, p.ID, "pending").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	if err := repo.UpdateStatus(ctx, p, domain.PayoutPending); !errors.Is(err, domain.ErrInvalidPayoutTransition) {
+		t.Fatalf("expected ErrInvalidPayoutTransition, got %v", err)
+	}
+
+	// The reference is stored with the paid status
+	_ = p.MarkPaid("ref-1")
+	expectPrivilegedTx(mock)
+	mock.ExpectExec(updQ).WithArgs("paid", "", "ref-1", p.UpdatedAt, p.ID, "processing").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.UpdateStatus(ctx, p, domain.PayoutProcessing); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresPayoutRepository_Fail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresPayoutRepository(db)
+	p, _ := domain.NewPayout("acc-1", "bank-1", 50, domain.SystemClock)
+	_ = p.StartProcessing()
+	_ = p.MarkFailed("account closed at the bank")
+
+	expectPrivilegedTx(mock)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE payouts SET status = $1, failure_reason = $2, transfer_reference = $3, updated_at = $4 WHERE id = $5 AND status = $6")).
+		WithArgs("failed", "account closed at the bank", "", p.UpdatedAt, p.ID, "processing").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = balance + $1, updated_at = $2 WHERE id = $3")).
+		WithArgs(50.0, p.UpdatedAt, "acc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Fail(tenant.WithPrivileged(context.Background()), p, domain.PayoutProcessing); err != nil {
+		t.Fatalf("fail: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresPayoutRepository_ListStuck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresPayoutRepository(db)
+	now := time.Now().UTC()
+	before := now.Add(-10 * time.Minute)
+	expectPrivilegedTx(mock)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, account_id, bank_account_id, amount, status, failure_reason, transfer_reference, created_at, updated_at FROM payouts WHERE status = 'processing' AND updated_at < $1 ORDER BY updated_at, id LIMIT $2")).
+		WithArgs(before, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "bank_account_id", "amount", "status", "failure_reason", "transfer_reference", "created_at", "updated_at"}).
+			AddRow("pay-1", "acc-1", "bank-1", 50.0, "processing", "", "", before, before))
+	mock.ExpectCommit()
+
+	got, err := repo.ListStuck(tenant.WithPrivileged(context.Background()), before, 50)
+	if err != nil || len(got) != 1 || got[0].Status != domain.PayoutProcessing {
+		t.Fatalf("expected the stuck payout, got %+v %v", got, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
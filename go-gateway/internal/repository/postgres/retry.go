@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// retryableCodes are the SQLSTATEs worth retrying: the transaction lost a
+// serialization race or a deadlock, or the connection dropped.
+var retryableCodes = map[pq.ErrorCode]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"08006": true, // connection_failure
+	"08003": true, // connection_does_not_exist
+}
+
+// retryPolicy controls how transient errors are retried.
+type retryPolicy struct {
+	attempts  int
+	baseDelay time.Duration
+	// retryable overrides isRetryable when set.
+	retryable func(error) bool
+}
+
+var defaultRetry = retryPolicy{attempts: 3, baseDelay: 25 * time.Millisecond}
+
+// isRetryable reports whether err is a transient database error.
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return retryableCodes[pqErr.Code]
+	}
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// isConflict reports whether err is a serialization failure or a deadlock.
+// The database rolled the work back, so running it again cannot apply it
+// twice.
+func isConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && (pqErr.Code == "40001" || pqErr.Code == "40P01")
+}
+
+// conflictsOnly returns p retrying conflicts only, for writes that are not
+// idempotent: a connection that drops may have lost the reply to a commit,
+// and running the write again would apply it twice.
+func (p retryPolicy) conflictsOnly() retryPolicy {
+	p.retryable = isConflict
+	return p
+}
+
+// do runs fn, retrying transient errors with exponential backoff. fn must be
+// safe to run again, e.g. a whole transaction.
+func (p retryPolicy) do(ctx context.Context, fn func() error) error {
+	retryable := p.retryable
+	if retryable == nil {
+		retryable = isRetryable
+	}
+	delay := p.baseDelay
+	var err error
+	for i := 0; i < p.attempts; i++ {
+		if err = fn(); err == nil || !retryable(err) {
+			return err
+		}
+		if i == p.attempts-1 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pq.Error{Code: "40001"}, true},
+		{"deadlock", &pq.Error{Code: "40P01"}, true},
+		{"connection failure", &pq.Error{Code: "08006"}, true},
+		{"wrapped", fmt.Errorf("update: %w", &pq.Error{Code: "40001"}), true},
+		{"bad conn", driver.ErrBadConn, true},
+		{"connection reset", syscall.ECONNRESET, true},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+		{"no rows", sql.ErrNoRows, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_GivesUpAfterAttempts(t *testing.T) {
+	p := retryPolicy{attempts: 3, baseDelay: time.Millisecond}
+	calls := 0
+	err := p.do(context.Background(), func() error {
+		calls++
+		return &pq.Error{Code: "40P01"}
+	})
+	if err == nil || calls != 3 {
+		t.Fatalf("expected 3 calls and an error, got %d calls, err %v", calls, err)
+	}
+
+	calls = 0
+	_ = p.do(context.Background(), func() error {
+		calls++
+		return errors.New("permanent")
+	})
+	if calls != 1 {
+		t.Fatalf("expected non-retryable error to stop after 1 call, got %d", calls)
+	}
+}
+
+func TestPostgresAccountRepository_AddBalance_RetriesSerializationFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresAccountRepository(db)
+	repo.retry = retryPolicy{attempts: 3, baseDelay: time.Millisecond}
+	now := time.Now().UTC()
+
+	// The first attempt loses a serialization race; replaying the increment
+	// applies it once, on top of whatever won the race
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = balance + $1, updated_at = $2 WHERE id = $3")).
+		WithArgs(50.0, now, "acc-1").
+		WillReturnError(&pq.Error{Code: "40001"})
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = balance + $1, updated_at = $2 WHERE id = $3")).
+		WithArgs(50.0, now, "acc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.AddBalance(context.Background(), "acc-1", 50, now); err != nil {
+		t.Fatalf("add balance: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresAccountRepository_AddBalance_DoesNotRetryDroppedConnection(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresAccountRepository(db)
+	repo.retry = retryPolicy{attempts: 3, baseDelay: time.Millisecond}
+	now := time.Now().UTC()
+
+	// The credit may have committed before the connection dropped, so it is
+	// not sent again
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = balance + $1, updated_at = $2 WHERE id = $3")).
+		WithArgs(50.0, now, "acc-1").
+		WillReturnError(&pq.Error{Code: "08006"})
+
+	var pqErr *pq.Error
+	if err := repo.AddBalance(context.Background(), "acc-1", 50, now); !errors.As(err, &pqErr) || pqErr.Code != "08006" {
+		t.Fatalf("expected the connection failure, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// PostgresRiskRuleRepository implements domain.RiskRuleRepository using
+// the risk_rules table.
+type PostgresRiskRuleRepository struct {
+	db    *sql.DB
+	retry retryPolicy
+}
+
+func NewPostgresRiskRuleRepository(db *sql.DB) *PostgresRiskRuleRepository {
+	return &PostgresRiskRuleRepository{db: db, retry: defaultRetry}
+}
+
+// ListRules returns the enabled rules. Columns a kind does not use are NULL.
+func (r *PostgresRiskRuleRepository) ListRules(ctx context.Context) ([]domain.RiskRule, error) {
+	const q = `
+		SELECT kind, COALESCE(account_id::text, ''), COALESCE(payment_type, ''), COALESCE(max_amount, 0),
+			COALESCE(max_per_hour, 0), COALESCE(account_age_days, 0), action
+		FROM risk_rules
+		WHERE enabled
+		ORDER BY created_at, id
+	`
+	var out []domain.RiskRule
+	err := r.retry.do(ctx, func() error {
+		out = []domain.RiskRule{}
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, q)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var rule domain.RiskRule
+				if err := rows.Scan(&rule.Kind, &rule.AccountID, &rule.PaymentType, &rule.MaxAmount,
+					&rule.MaxPerHour, &rule.AccountAgeDays, &rule.Action); err != nil {
+					return err
+				}
+				out = append(out, rule)
+			}
+			return rows.Err()
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+func TestPostgresRiskRuleRepository_ListRules(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	expectPrivilegedTx(mock)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM risk_rules WHERE enabled ORDER BY created_at, id")).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "account_id", "payment_type", "max_amount", "max_per_hour", "account_age_days", "action"}).
+			AddRow("amount_threshold", "", "", 10000.0, 0, 0, "review").
+			AddRow("card_velocity", "acc-1", "", 0.0, 5, 0, "reject"))
+	mock.ExpectCommit()
+
+	rules, err := NewPostgresRiskRuleRepository(db).ListRules(tenant.WithPrivileged(context.Background()))
+	if err != nil {
+		t.Fatalf("list rules: %v", err)
+	}
+	want := []domain.RiskRule{
+		{Kind: domain.RiskAmountThreshold, MaxAmount: 10000, Action: domain.RiskReview},
+		{Kind: domain.RiskCardVelocity, AccountID: "acc-1", MaxPerHour: 5, Action: domain.RiskReject},
+	}
+	if len(rules) != len(want) || rules[0] != want[0] || rules[1] != want[1] {
+		t.Fatalf("got %+v, want %+v", rules, want)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
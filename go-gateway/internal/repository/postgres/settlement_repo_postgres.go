@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// PostgresSettlementRepository implements domain.SettlementRepository using
+// PostgreSQL.
+type PostgresSettlementRepository struct {
+	db    *sql.DB
+	retry retryPolicy
+}
+
+func NewPostgresSettlementRepository(db *sql.DB) *PostgresSettlementRepository {
+	return &PostgresSettlementRepository{db: db, retry: defaultRetry}
+}
+
+const settlementColumns = `id, account_id, invoice_id, installment, amount, status, available_at, created_at, settled_at`
+
+// Create stores the settlement and adds its amount to the pending balance in
+// one transaction.
+func (r *PostgresSettlementRepository) Create(ctx context.Context, s *domain.Settlement) error {
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			return insertSettlement(ctx, tx, s)
+		})
+	})
+}
+
+// insertSettlement stores a settlement and holds its amount in the pending
+// balance of the account.
+func insertSettlement(ctx context.Context, tx *sql.Tx, s *domain.Settlement) error {
+	const insQ = `
+		INSERT INTO settlements (` + settlementColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	if _, err := tx.ExecContext(ctx, insQ, s.ID, s.AccountID, s.InvoiceID, s.Installment, s.Amount, s.Status, s.AvailableAt, s.CreatedAt, s.SettledAt); err != nil {
+		return err
+	}
+	const holdQ = `UPDATE accounts SET pending_balance = pending_balance + $1, updated_at = $2 WHERE id = $3`
+	res, err := tx.ExecContext(ctx, holdQ, s.Amount, s.CreatedAt, s.AccountID)
+	if err != nil {
+		return err
+	}
+	return affected(res, domain.ErrAccountNotFound)
+}
+
+func (r *PostgresSettlementRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Settlement, error) {
+	const q = `
+		SELECT ` + settlementColumns + `
+		FROM settlements WHERE status = 'pending' AND available_at <= $1
+		ORDER BY available_at, id LIMIT $2
+	`
+	var out []*domain.Settlement
+	err := r.retry.do(ctx, func() error {
+		out = []*domain.Settlement{}
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, q, now, limit)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var s domain.Settlement
+				if err := scanSettlement(rows, &s); err != nil {
+					return err
+				}
+				out = append(out, &s)
+			}
+			return rows.Err()
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Release marks the settlement settled and moves its amount to the available
+// balance in one transaction. The conditional update lets a single replica
+// release each settlement.
+func (r *PostgresSettlementRepository) Release(ctx context.Context, s *domain.Settlement) error {
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			const markQ = `UPDATE settlements SET status = $1, settled_at = $2 WHERE id = $3 AND status = 'pending'`
+			res, err := tx.ExecContext(ctx, markQ, s.Status, s.SettledAt, s.ID)
+			if err != nil {
+				return err
+			}
+			if err := affected(res, domain.ErrSettlementNotPending); err != nil {
+				return err
+			}
+			const moveQ = `
+				UPDATE accounts
+				SET pending_balance = pending_balance - $1, balance = balance + $1, updated_at = $2
+				WHERE id = $3
+			`
+			res, err = tx.ExecContext(ctx, moveQ, s.Amount, s.SettledAt, s.AccountID)
+			if err != nil {
+				return err
+			}
+			return affected(res, domain.ErrAccountNotFound)
+		})
+	})
+}
+
+// affected returns notFound when res matched no row.
+func affected(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func scanSettlement(row interface{ Scan(dest ...any) error }, s *domain.Settlement) error {
+	return row.Scan(&s.ID, &s.AccountID, &s.InvoiceID, &s.Installment, &s.Amount, &s.Status, &s.AvailableAt, &s.CreatedAt, &s.SettledAt)
+}
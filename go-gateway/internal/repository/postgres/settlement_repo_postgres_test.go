@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+func TestPostgresSettlementRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresSettlementRepository(db)
+	s := &domain.Settlement{ID: "s-1", AccountID: "acc-1", InvoiceID: "inv-1", Installment: 1, Amount: 95.62, Status: domain.SettlementPending,
+		AvailableAt: time.Now().UTC().Add(24 * time.Hour), CreatedAt: time.Now().UTC()}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO settlements (id, account_id, invoice_id, installment, amount, status, available_at, created_at, settled_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)")).
+		WithArgs("s-1", "acc-1", "inv-1", 1, 95.62, "pending", s.AvailableAt, s.CreatedAt, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET pending_balance = pending_balance + $1, updated_at = $2 WHERE id = $3")).
+		WithArgs(95.62, s.CreatedAt, "acc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.Create(tenant.WithAccount(context.Background(), "acc-1"), s); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresSettlementRepository_ListDueAndRelease(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresSettlementRepository(db)
+	ctx := tenant.WithPrivileged(context.Background())
+	now := time.Now().UTC()
+
+	expectPrivilegedTx(mock)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, account_id, invoice_id, installment, amount, status, available_at, created_at, settled_at FROM settlements WHERE status = 'pending' AND available_at <= $1 ORDER BY available_at, id LIMIT $2")).
+		WithArgs(now, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "invoice_id", "installment", "amount", "status", "available_at", "created_at", "settled_at"}).
+			AddRow("s-1", "acc-1", "inv-1", 1, 95.62, "pending", now, now, nil))
+	mock.ExpectCommit()
+	due, err := repo.ListDue(ctx, now, 50)
+	if err != nil || len(due) != 1 || due[0].Amount != 95.62 || due[0].SettledAt != nil {
+		t.Fatalf("unexpected due settlements %+v %v", due, err)
+	}
+
+	s := due[0]
+	_ = s.MarkSettled(time.Now().UTC())
+	markQ := regexp.QuoteMeta("UPDATE settlements SET status = $1, settled_at = $2 WHERE id = $3 AND status = 'pending'")
+	expectPrivilegedTx(mock)
+	mock.ExpectExec(markQ).WithArgs("settled", s.SettledAt, "s-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET pending_balance = pending_balance - $1, balance = balance + $1, updated_at = $2 WHERE id = $3")).
+		WithArgs(95.62, s.SettledAt, "acc-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Release(ctx, s); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+
+	// Released by another replica: the balance is not touched
+	expectPrivilegedTx(mock)
+	mock.ExpectExec(markQ).WithArgs("settled", s.SettledAt, "s-1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	if err := repo.Release(ctx, s); !errors.Is(err, domain.ErrSettlementNotPending) {
+		t.Fatalf("expected ErrSettlementNotPending, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
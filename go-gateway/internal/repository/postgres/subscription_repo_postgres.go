@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// PostgresSubscriptionRepository implements domain.SubscriptionRepository
+// using PostgreSQL. Plans and subscriptions are protected by row-level
+// security, so every call runs in a transaction bound to the tenant of its
+// context.
+type PostgresSubscriptionRepository struct {
+	db    *sql.DB
+	retry retryPolicy
+}
+
+func NewPostgresSubscriptionRepository(db *sql.DB) *PostgresSubscriptionRepository {
+	return &PostgresSubscriptionRepository{db: db, retry: defaultRetry}
+}
+
+const (
+	planColumns = `id, account_id, name, amount, billing_interval, interval_count, trial_days, created_at`
+	// last_invoice_id is NULL until the first charge
+	subscriptionColumns = `id, account_id, plan_id, description, amount, billing_interval, interval_count, card_token, card_last_digits, ` +
+		`status, anchor_at, trial_ends_at, cycle, next_billing_at, failed_attempts, COALESCE(last_invoice_id::text, ''), canceled_at, created_at, updated_at`
+)
+
+func (r *PostgresSubscriptionRepository) CreatePlan(ctx context.Context, p *domain.Plan) error {
+	const q = `INSERT INTO plans (` + planColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, q, p.ID, p.AccountID, p.Name, p.Amount, p.Interval, p.IntervalCount, p.TrialDays, p.CreatedAt)
+			return err
+		})
+	})
+}
+
+func (r *PostgresSubscriptionRepository) GetPlan(ctx context.Context, accountID, id string) (*domain.Plan, error) {
+	const q = `SELECT ` + planColumns + ` FROM plans WHERE id = $1 AND account_id = $2`
+	var p domain.Plan
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			return scanPlan(tx.QueryRowContext(ctx, q, id, accountID), &p)
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PostgresSubscriptionRepository) ListPlans(ctx context.Context, accountID string) ([]*domain.Plan, error) {
+	const q = `SELECT ` + planColumns + ` FROM plans WHERE account_id = $1 ORDER BY created_at DESC, id`
+	var out []*domain.Plan
+	err := r.retry.do(ctx, func() error {
+		out = []*domain.Plan{}
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, q, accountID)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var p domain.Plan
+				if err := scanPlan(rows, &p); err != nil {
+					return err
+				}
+				out = append(out, &p)
+			}
+			return rows.Err()
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *PostgresSubscriptionRepository) Create(ctx context.Context, s *domain.Subscription) error {
+	const q = `
+		INSERT INTO subscriptions (id, account_id, plan_id, description, amount, billing_interval, interval_count, card_token, card_last_digits,
+			status, anchor_at, trial_ends_at, cycle, next_billing_at, failed_attempts, last_invoice_id, canceled_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NULLIF($16, '')::uuid, $17, $18, $19)
+	`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, q, s.ID, s.AccountID, s.PlanID, s.Description, s.Amount, s.Interval, s.IntervalCount, s.CardToken, s.CardLastDigits,
+				s.Status, s.AnchorAt, s.TrialEndsAt, s.Cycle, s.NextBillingAt, s.FailedAttempts, s.LastInvoiceID, s.CanceledAt, s.CreatedAt, s.UpdatedAt)
+			return err
+		})
+	})
+}
+
+func (r *PostgresSubscriptionRepository) GetByID(ctx context.Context, accountID, id string) (*domain.Subscription, error) {
+	const q = `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1 AND account_id = $2`
+	var s domain.Subscription
+	err := r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			return scanSubscription(tx.QueryRowContext(ctx, q, id, accountID), &s)
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *PostgresSubscriptionRepository) ListByAccount(ctx context.Context, accountID string) ([]*domain.Subscription, error) {
+	const q = `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE account_id = $1 ORDER BY created_at DESC, id`
+	return r.list(ctx, q, accountID)
+}
+
+func (r *PostgresSubscriptionRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.Subscription, error) {
+	const q = `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions WHERE status <> 'canceled' AND next_billing_at <= $1
+		ORDER BY next_billing_at, id LIMIT $2
+	`
+	return r.list(ctx, q, now, limit)
+}
+
+func (r *PostgresSubscriptionRepository) list(ctx context.Context, q string, args ...any) ([]*domain.Subscription, error) {
+	var out []*domain.Subscription
+	err := r.retry.do(ctx, func() error {
+		out = []*domain.Subscription{}
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, q, args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var s domain.Subscription
+				if err := scanSubscription(rows, &s); err != nil {
+					return err
+				}
+				out = append(out, &s)
+			}
+			return rows.Err()
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Claim is a conditional update on the billing date read by ListDue, so a
+// single replica wins each cycle.
+func (r *PostgresSubscriptionRepository) Claim(ctx context.Context, s *domain.Subscription, until time.Time) error {
+	const q = `
+		UPDATE subscriptions SET next_billing_at = $1
+		WHERE id = $2 AND next_billing_at = $3 AND status <> 'canceled'
+	`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(ctx, q, until, s.ID, s.NextBillingAt)
+			if err != nil {
+				return err
+			}
+			return affected(res, domain.ErrSubscriptionClaimed)
+		})
+	})
+}
+
+func (r *PostgresSubscriptionRepository) Update(ctx context.Context, s *domain.Subscription) error {
+	const q = `
+		UPDATE subscriptions
+		SET status = $1, cycle = $2, next_billing_at = $3, failed_attempts = $4, last_invoice_id = NULLIF($5, '')::uuid,
+			canceled_at = $6, updated_at = $7
+		WHERE id = $8 AND status <> 'canceled'
+	`
+	return r.retry.do(ctx, func() error {
+		return inTenantTx(ctx, r.db, func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(ctx, q, s.Status, s.Cycle, s.NextBillingAt, s.FailedAttempts, s.LastInvoiceID, s.CanceledAt, s.UpdatedAt, s.ID)
+			if err != nil {
+				return err
+			}
+			return affected(res, domain.ErrInvalidSubscriptionTransition)
+		})
+	})
+}
+
+func scanPlan(row interface{ Scan(dest ...any) error }, p *domain.Plan) error {
+	return row.Scan(&p.ID, &p.AccountID, &p.Name, &p.Amount, &p.Interval, &p.IntervalCount, &p.TrialDays, &p.CreatedAt)
+}
+
+func scanSubscription(row interface{ Scan(dest ...any) error }, s *domain.Subscription) error {
+	return row.Scan(&s.ID, &s.AccountID, &s.PlanID, &s.Description, &s.Amount, &s.Interval, &s.IntervalCount, &s.CardToken, &s.CardLastDigits,
+		&s.Status, &s.AnchorAt, &s.TrialEndsAt, &s.Cycle, &s.NextBillingAt, &s.FailedAttempts, &s.LastInvoiceID, &s.CanceledAt, &s.CreatedAt, &s.UpdatedAt)
+}
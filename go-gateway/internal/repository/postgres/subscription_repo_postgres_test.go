@@ -0,0 +1,183 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+var subscriptionCols = []string{"id", "account_id", "plan_id", "description", "amount", "billing_interval", "interval_count", "card_token", "card_last_digits",
+	"status", "anchor_at", "trial_ends_at", "cycle", "next_billing_at", "failed_attempts", "last_invoice_id", "canceled_at", "created_at", "updated_at"}
+
+func TestPostgresSubscriptionRepository_Plans(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresSubscriptionRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	p := &domain.Plan{ID: "plan-1", AccountID: "acc-1", Name: "Pro", Amount: 49.9, Interval: domain.IntervalMonth, IntervalCount: 1, TrialDays: 7, CreatedAt: time.Now().UTC()}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO plans (id, account_id, name, amount, billing_interval, interval_count, trial_days, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)")).
+		WithArgs("plan-1", "acc-1", "Pro", 49.9, "month", 1, 7, p.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.CreatePlan(ctx, p); err != nil {
+		t.Fatalf("create plan: %v", err)
+	}
+
+	planCols := []string{"id", "account_id", "name", "amount", "billing_interval", "interval_count", "trial_days", "created_at"}
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM plans WHERE id = $1 AND account_id = $2")).WithArgs("plan-1", "acc-1").
+		WillReturnRows(sqlmock.NewRows(planCols).AddRow("plan-1", "acc-1", "Pro", 49.9, "month", 1, 7, p.CreatedAt))
+	mock.ExpectCommit()
+	got, err := repo.GetPlan(ctx, "acc-1", "plan-1")
+	if err != nil || got.Interval != domain.IntervalMonth || got.TrialDays != 7 {
+		t.Fatalf("unexpected plan %+v %v", got, err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM plans WHERE id = $1 AND account_id = $2")).WithArgs("plan-2", "acc-1").
+		WillReturnRows(sqlmock.NewRows(planCols))
+	mock.ExpectRollback()
+	if _, err := repo.GetPlan(ctx, "acc-1", "plan-2"); !errors.Is(err, domain.ErrPlanNotFound) {
+		t.Fatalf("expected ErrPlanNotFound, got %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM plans WHERE account_id = $1 ORDER BY created_at DESC, id")).WithArgs("acc-1").
+		WillReturnRows(sqlmock.NewRows(planCols))
+	mock.ExpectCommit()
+	if list, err := repo.ListPlans(ctx, "acc-1"); err != nil || list == nil || len(list) != 0 {
+		t.Fatalf("expected an empty list, got %v %v", list, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresSubscriptionRepository_CreateAndGet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresSubscriptionRepository(db)
+	ctx := tenant.WithAccount(context.Background(), "acc-1")
+	now := time.Now().UTC()
+	s := &domain.Subscription{ID: "sub-1", AccountID: "acc-1", PlanID: "plan-1", Description: "Pro", Amount: 49.9, Interval: domain.IntervalMonth,
+		IntervalCount: 1, CardToken: "tok_12345678", CardLastDigits: "4242", Status: domain.SubscriptionActive, AnchorAt: now,
+		NextBillingAt: now, CreatedAt: now, UpdatedAt: now}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO subscriptions")).
+		WithArgs("sub-1", "acc-1", "plan-1", "Pro", 49.9, "month", 1, "tok_12345678", "4242",
+			"active", now, nil, 0, now, 0, "", nil, now, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Create(ctx, s); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("COALESCE(last_invoice_id::text, ''), canceled_at, created_at, updated_at FROM subscriptions WHERE id = $1 AND account_id = $2")).
+		WithArgs("sub-1", "acc-1").
+		WillReturnRows(sqlmock.NewRows(subscriptionCols).AddRow("sub-1", "acc-1", "plan-1", "Pro", 49.9, "month", 1, "tok_12345678", "4242",
+			"past_due", now, nil, 2, now, 1, "inv-1", nil, now, now))
+	mock.ExpectCommit()
+	got, err := repo.GetByID(ctx, "acc-1", "sub-1")
+	if err != nil || got.Status != domain.SubscriptionPastDue || got.Cycle != 2 || got.LastInvoiceID != "inv-1" || got.TrialEndsAt != nil {
+		t.Fatalf("unexpected subscription %+v %v", got, err)
+	}
+
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM subscriptions WHERE id = $1 AND account_id = $2")).WithArgs("sub-2", "acc-1").
+		WillReturnRows(sqlmock.NewRows(subscriptionCols))
+	mock.ExpectRollback()
+	if _, err := repo.GetByID(ctx, "acc-1", "sub-2"); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+		t.Fatalf("expected ErrSubscriptionNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
+
+func TestPostgresSubscriptionRepository_ClaimAndUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresSubscriptionRepository(db)
+	ctx := tenant.WithPrivileged(context.Background())
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SET LOCAL ROLE gateway_admin")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM subscriptions WHERE status <> 'canceled' AND next_billing_at <= $1 ORDER BY next_billing_at, id LIMIT $2")).
+		WithArgs(now, 50).
+		WillReturnRows(sqlmock.NewRows(subscriptionCols).AddRow("sub-1", "acc-1", "plan-1", "Pro", 49.9, "month", 1, "tok_12345678", "4242",
+			"active", now, nil, 0, now, 0, "", nil, now, now))
+	mock.ExpectCommit()
+	due, err := repo.ListDue(ctx, now, 50)
+	if err != nil || len(due) != 1 {
+		t.Fatalf("unexpected due subscriptions %+v %v", due, err)
+	}
+
+	claimQ := regexp.QuoteMeta("UPDATE subscriptions SET next_billing_at = $1 WHERE id = $2 AND next_billing_at = $3 AND status <> 'canceled'")
+	until := now.Add(10 * time.Minute)
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(claimQ).WithArgs(until, "sub-1", now).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	accCtx := tenant.WithAccount(ctx, "acc-1")
+	if err := repo.Claim(accCtx, due[0], until); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	// Claimed by another replica
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(claimQ).WithArgs(until, "sub-1", now).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	if err := repo.Claim(accCtx, due[0], until); !errors.Is(err, domain.ErrSubscriptionClaimed) {
+		t.Fatalf("expected ErrSubscriptionClaimed, got %v", err)
+	}
+
+	s := due[0]
+	_ = s.RecordCharge("inv-1", true, domain.DefaultDunningPolicy(), now)
+	updateQ := regexp.QuoteMeta("UPDATE subscriptions SET status = $1, cycle = $2, next_billing_at = $3, failed_attempts = $4, last_invoice_id = NULLIF($5, '')::uuid, canceled_at = $6, updated_at = $7 WHERE id = $8 AND status <> 'canceled'")
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(updateQ).
+		WithArgs("active", 1, s.NextBillingAt, 0, "inv-1", nil, now, "sub-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := repo.Update(accCtx, s); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	// Canceled while being charged: the charge does not reactivate it
+	expectTenantTx(mock, "acc-1")
+	mock.ExpectExec(updateQ).
+		WithArgs("active", 1, s.NextBillingAt, 0, "inv-1", nil, now, "sub-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	if err := repo.Update(accCtx, s); !errors.Is(err, domain.ErrInvalidSubscriptionTransition) {
+		t.Fatalf("expected ErrInvalidSubscriptionTransition, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
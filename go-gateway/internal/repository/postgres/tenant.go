@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+// adminRole is the database role assumed by privileged contexts. Its
+// row-level security policies see every account.
+const adminRole = "gateway_admin"
+
+// inTenantTx runs fn in a transaction bound to the tenant of ctx. Tables with
+// row-level security only show the rows of the account in ctx, or every row
+// to a privileged ctx; without either they show no rows at all.
+func inTenantTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := beginTenantTx(ctx, db)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// beginTenantTx begins a transaction bound to the tenant of ctx, for callers
+// that commit it themselves.
+func beginTenantTx(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if tenant.Privileged(ctx) {
+		_, err = tx.ExecContext(ctx, "SET LOCAL ROLE "+adminRole)
+	} else {
+		// set_config(..., true) is SET LOCAL with a bind parameter
+		_, err = tx.ExecContext(ctx, "SELECT set_config('app.account_id', $1, true)", tenant.AccountID(ctx))
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/testdb"
+	"github.com/google/uuid"
+)
+
+// createTestAccount inserts an account and removes it, with its invoices,
+// settlements, fees and bank accounts, when the test ends.
+func createTestAccount(t *testing.T, owner *sql.DB, accounts *PostgresAccountRepository, name string) *domain.Account {
+	t.Helper()
+	a, err := domain.NewAccount(name, name+"-"+uuid.NewString()+"@example.com", domain.SystemClock)
+	if err != nil {
+		t.Fatalf("new account: %v", err)
+	}
+	if err := accounts.Create(context.Background(), a); err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_, _ = owner.ExecContext(ctx, "DELETE FROM invoice_fees WHERE account_id = $1", a.ID)
+		_, _ = owner.ExecContext(ctx, "DELETE FROM settlements WHERE account_id = $1", a.ID)
+		_, _ = owner.ExecContext(ctx, "DELETE FROM invoices WHERE account_id = $1", a.ID)
+		_, _ = owner.ExecContext(ctx, "DELETE FROM bank_accounts WHERE account_id = $1", a.ID)
+		_, _ = owner.ExecContext(ctx, "DELETE FROM accounts WHERE id = $1", a.ID)
+	})
+	return a
+}
+
+func newTestInvoice(t *testing.T, accountID string) *domain.Invoice {
+	t.Helper()
+	i, err := domain.NewInvoice(accountID, "rls test", "credit_card", 10, "4242", domain.SystemClock)
+	if err != nil {
+		t.Fatalf("new invoice: %v", err)
+	}
+	return i
+}
+
+func TestRowLevelSecurity_Integration(t *testing.T) {
+	owner, app := testdb.Open(t)
+	ctx := context.Background()
+
+	accounts := NewPostgresAccountRepository(app)
+	invoices := NewPostgresInvoiceRepository(app)
+	a := createTestAccount(t, owner, accounts, "tenant-a")
+	b := createTestAccount(t, owner, accounts, "tenant-b")
+	ctxA := tenant.WithAccount(ctx, a.ID)
+	ctxB := tenant.WithAccount(ctx, b.ID)
+
+	invA := newTestInvoice(t, a.ID)
+	invB := newTestInvoice(t, b.ID)
+	if err := invoices.Create(ctxA, invA); err != nil {
+		t.Fatalf("create invoice of a: %v", err)
+	}
+	if err := invoices.Create(ctxB, invB); err != nil {
+		t.Fatalf("create invoice of b: %v", err)
+	}
+
+	t.Run("tenant reads its own rows", func(t *testing.T) {
+		if _, err := invoices.GetByID(ctxA, invA.ID); err != nil {
+			t.Fatalf("get own invoice: %v", err)
+		}
+	})
+
+	t.Run("tenant cannot read another tenant's rows", func(t *testing.T) {
+		if _, err := invoices.GetByID(ctxA, invB.ID); err != domain.ErrInvoiceNotFound {
+			t.Fatalf("expected not found, got %v", err)
+		}
+		list, err := invoices.GetByAccountID(ctxA, b.ID)
+		if err != nil || len(list) != 0 {
+			t.Fatalf("expected no invoices of b, got %d %v", len(list), err)
+		}
+	})
+
+	t.Run("tenant cannot write another tenant's rows", func(t *testing.T) {
+		if err := invoices.UpdateStatus(ctxA, invB.ID, domain.StatusApproved); err != domain.ErrInvoiceNotFound {
+			t.Fatalf("expected not found, got %v", err)
+		}
+		if err := invoices.Create(ctxA, newTestInvoice(t, b.ID)); err == nil {
+			t.Fatal("expected the policy to reject an invoice of b")
+		}
+	})
+
+	t.Run("no account sees no rows", func(t *testing.T) {
+		list, err := invoices.GetByAccountID(ctx, a.ID)
+		if err != nil || len(list) != 0 {
+			t.Fatalf("expected no invoices, got %d %v", len(list), err)
+		}
+	})
+
+	t.Run("privileged context sees every tenant", func(t *testing.T) {
+		admin := tenant.WithPrivileged(ctx)
+		for _, id := range []string{invA.ID, invB.ID} {
+			if _, err := invoices.GetByID(admin, id); err != nil {
+				t.Fatalf("get %s as admin: %v", id, err)
+			}
+		}
+	})
+}
+
+func TestRowLevelSecurity_Payouts_Integration(t *testing.T) {
+	owner, app := testdb.Open(t)
+	ctx := context.Background()
+
+	accounts := NewPostgresAccountRepository(app)
+	payouts := NewPostgresPayoutRepository(app)
+	a := createTestAccount(t, owner, accounts, "payout-a")
+	b := createTestAccount(t, owner, accounts, "payout-b")
+
+	bank, err := domain.NewBankAccount(a.ID, "341", "0001", "12345-6", "Tenant A", domain.SystemClock)
+	if err != nil {
+		t.Fatalf("new bank account: %v", err)
+	}
+	if err := payouts.CreateBankAccount(tenant.WithAccount(ctx, a.ID), bank); err != nil {
+		t.Fatalf("create bank account of a: %v", err)
+	}
+	if _, err := payouts.GetBankAccount(tenant.WithAccount(ctx, b.ID), a.ID, bank.ID); err != domain.ErrBankAccountNotFound {
+		t.Fatalf("expected b not to see the bank account of a, got %v", err)
+	}
+	if err := payouts.CreateBankAccount(tenant.WithAccount(ctx, b.ID), bank); err == nil {
+		t.Fatal("expected the policy to reject a bank account of a")
+	}
+}
+
+// TestRowLevelSecurity_EveryAccountTable_Integration fails when a table with
+// an account_id column, including one added later, has no forced row-level
+// security or no policy.
+func TestRowLevelSecurity_EveryAccountTable_Integration(t *testing.T) {
+	owner, _ := testdb.Open(t)
+
+	const q = `
+		SELECT c.relname
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attname = 'account_id' AND NOT a.attisdropped
+		WHERE n.nspname = 'public' AND c.relkind = 'r'
+		  AND (NOT c.relrowsecurity OR NOT c.relforcerowsecurity
+		       OR NOT EXISTS (SELECT 1 FROM pg_policy p WHERE p.polrelid = c.oid))
+		ORDER BY c.relname
+	`
+	rows, err := owner.QueryContext(context.Background(), q)
+	if err != nil {
+		t.Fatalf("query catalog: %v", err)
+	}
+	defer rows.Close()
+	var missing []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		missing = append(missing, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows: %v", err)
+	}
+	if len(missing) > 0 {
+		t.Fatalf("tables without row-level security: %v", missing)
+	}
+}
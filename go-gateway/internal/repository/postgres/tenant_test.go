@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+const setAccountQuery = "SELECT set_config('app.account_id', $1, true)"
+
+// expectTenantTx expects the start of a transaction bound to accountID.
+func expectTenantTx(mock sqlmock.Sqlmock, accountID string) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(setAccountQuery)).WithArgs(accountID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+// expectPrivilegedTx expects the start of a transaction as gateway_admin.
+func expectPrivilegedTx(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SET LOCAL ROLE gateway_admin")).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestInTenantTx_Privileged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresInvoiceRepository(db)
+	ctx := tenant.WithPrivileged(context.Background())
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SET LOCAL ROLE gateway_admin")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM invoices WHERE account_id = $1")).WithArgs("acc-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "amount", "installments", "total_amount", "fee", "net_amount", "status", "description", "payment_type", "card_last_digits", "risk_decision", "risk_reasons", "created_at", "updated_at"}))
+	mock.ExpectCommit()
+
+	if _, err := repo.GetByAccountID(ctx, "acc-1"); err != nil {
+		t.Fatalf("get by account id: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet: %v", err)
+	}
+}
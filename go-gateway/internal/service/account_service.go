@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"database/sql"
-	"fmt"
 
 	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
 	pg "github.com/devfullcycle/imersao22/go-gateway/internal/repository/postgres"
@@ -12,16 +11,22 @@ import (
 // AccountService implements domain.AccountRepository by delegating to a Postgres repository
 // and also provides DTO-based methods for the API/handlers layer.
 type AccountService struct {
-	repo domain.AccountRepository
+	repo  domain.AccountRepository
+	clock domain.Clock
 }
 
 func NewAccountService(db *sql.DB) *AccountService {
-	return &AccountService{repo: pg.NewPostgresAccountRepository(db)}
+	return &AccountService{repo: pg.NewPostgresAccountRepository(db), clock: domain.SystemClock}
+}
+
+// SetClock sets the clock that stamps accounts and their balance changes.
+func (s *AccountService) SetClock(c domain.Clock) {
+	s.clock = c
 }
 
 // Create creates a new account from input DTO and returns an output DTO.
 func (s *AccountService) Create(ctx context.Context, in AccountCreateInput) (*AccountOutput, error) {
-	acc, err := domain.NewAccount(in.Name, in.Email)
+	acc, err := domain.NewAccount(in.Name, in.Email, s.clock)
 	if err != nil {
 		return nil, err
 	}
@@ -40,40 +45,53 @@ func (s *AccountService) GetByID(ctx context.Context, id string) (*AccountOutput
 }
 
 func (s *AccountService) GetByAPIKey(ctx context.Context, apiKey string) (*AccountOutput, error) {
-	fmt.Println("API Key:", apiKey)
-
 	acc, err := s.repo.GetByAPIKey(ctx, apiKey)
 	if err != nil {
 		return nil, err
 	}
+	// Closed accounts can no longer use their API key
+	if err := acc.Status.CanRead(); err != nil {
+		return nil, err
+	}
 	return toAccountOutput(acc), nil
 }
 
-func (s *AccountService) UpdateBalance(ctx context.Context, apiKey string, amount float64) error {
-	// Get the account by API key
-	account, err := s.repo.GetByAPIKey(ctx, apiKey)
+// readableAccount resolves an API key to an account that may still use it.
+func readableAccount(ctx context.Context, accounts domain.AccountRepository, apiKey string) (*domain.Account, error) {
+	a, err := accounts.GetByAPIKey(ctx, apiKey)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	// Apply business logic: add amount to balance
-	if err := account.AddBalance(amount); err != nil {
-		return err
+	if err := a.Status.CanRead(); err != nil {
+		return nil, err
 	}
+	return a, nil
+}
 
-	// Save the updated account to the database
-	return s.repo.UpdateBalance(ctx, account)
+// writableAccount resolves an API key to an account that may charge or
+// otherwise change data.
+func writableAccount(ctx context.Context, accounts domain.AccountRepository, apiKey string) (*domain.Account, error) {
+	a, err := readableAccount(ctx, accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.Status.CanWrite(); err != nil {
+		return nil, err
+	}
+	return a, nil
 }
 
 // toAccountOutput maps domain.Account to output DTO.
 func toAccountOutput(a *domain.Account) *AccountOutput {
 	return &AccountOutput{
-		ID:        a.ID,
-		Name:      a.Name,
-		Email:     a.Email,
-		APIKey:    a.APIKey,
-		Balance:   a.Balance,
-		CreatedAt: a.CreatedAt,
-		UpdatedAt: a.UpdatedAt,
+		ID:             a.ID,
+		Name:           a.Name,
+		Email:          a.Email,
+		APIKey:         a.APIKey,
+		Balance:        a.Balance,
+		PendingBalance: a.PendingBalance,
+		Status:         string(a.Status),
+		CreatedAt:      a.CreatedAt,
+		UpdatedAt:      a.UpdatedAt,
 	}
 }
@@ -3,12 +3,14 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/repository/memory"
 )
 
 func TestAccountService_CreateAndGet(t *testing.T) {
@@ -22,7 +24,7 @@ func TestAccountService_CreateAndGet(t *testing.T) {
 	ctx := context.Background()
 
 	mock.ExpectExec("INSERT INTO accounts").
-		WithArgs(sqlmock.AnyArg(), "Acme", "acme@example.com", sqlmock.AnyArg(), 0.0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(sqlmock.AnyArg(), "Acme", "acme@example.com", sqlmock.AnyArg(), 0.0, "active", sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	out, err := svc.Create(ctx, AccountCreateInput{Name: "Acme", Email: "acme@example.com"})
@@ -33,9 +35,9 @@ func TestAccountService_CreateAndGet(t *testing.T) {
 		t.Fatalf("expected name Acme")
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "name", "email", "api_key", "balance", "created_at", "updated_at"}).
-		AddRow(out.ID, out.Name, out.Email, out.APIKey, out.Balance, time.Now().UTC(), time.Now().UTC())
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, created_at, updated_at FROM accounts WHERE id = $1")).
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "api_key", "balance", "pending_balance", "status", "created_at", "updated_at"}).
+		AddRow(out.ID, out.Name, out.Email, out.APIKey, out.Balance, 0.0, "active", time.Now().UTC(), time.Now().UTC())
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE id = $1")).
 		WithArgs(out.ID).WillReturnRows(rows)
 
 	got, err := svc.GetByID(ctx, out.ID)
@@ -51,41 +53,6 @@ func TestAccountService_CreateAndGet(t *testing.T) {
 	}
 }
 
-func TestAccountService_UpdateBalance(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("sqlmock: %v", err)
-	}
-	defer db.Close()
-
-	svc := NewAccountService(db)
-	ctx := context.Background()
-
-	// Mock GetByAPIKey call
-	rows := sqlmock.NewRows([]string{"id", "name", "email", "api_key", "balance", "created_at", "updated_at"}).
-		AddRow("acc-1", "Acme", "acme@example.com", "key-1", 100.0, time.Now().UTC(), time.Now().UTC())
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, created_at, updated_at FROM accounts WHERE api_key = $1")).
-		WithArgs("key-1").WillReturnRows(rows)
-
-	// Mock UpdateBalance call (transaction)
-	mock.ExpectBegin()
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM accounts WHERE id = $1 FOR UPDATE")).
-		WithArgs("acc-1").
-		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("acc-1"))
-	mock.ExpectExec(regexp.QuoteMeta("UPDATE accounts SET balance = $1, updated_at = $2 WHERE id = $3")).
-		WithArgs(110.0, sqlmock.AnyArg(), "acc-1").
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectCommit()
-
-	if err := svc.UpdateBalance(ctx, "key-1", 10); err != nil {
-		t.Fatalf("update: %v", err)
-	}
-
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Fatalf("unmet: %v", err)
-	}
-}
-
 func TestAccountService_GetByAPIKey_NotFound(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -96,7 +63,7 @@ func TestAccountService_GetByAPIKey_NotFound(t *testing.T) {
 	svc := NewAccountService(db)
 	ctx := context.Background()
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, created_at, updated_at FROM accounts WHERE api_key = $1")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE api_key = $1")).
 		WithArgs("nope").
 		WillReturnError(sql.ErrNoRows)
 
@@ -112,3 +79,25 @@ func TestAccountService_GetByAPIKey_NotFound(t *testing.T) {
 		t.Fatalf("unmet: %v", err)
 	}
 }
+
+func TestAccountService_GetByAPIKey_Status(t *testing.T) {
+	repo := memory.NewInMemoryAccountRepository()
+	svc := &AccountService{repo: repo}
+	ctx := context.Background()
+
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = repo.Create(ctx, a)
+
+	_ = a.Suspend()
+	_ = repo.UpdateStatus(ctx, a)
+	out, err := svc.GetByAPIKey(ctx, a.APIKey)
+	if err != nil || out.Status != "suspended" {
+		t.Fatalf("expected suspended account to be readable, got %+v %v", out, err)
+	}
+
+	_ = a.Close()
+	_ = repo.UpdateStatus(ctx, a)
+	if _, err := svc.GetByAPIKey(ctx, a.APIKey); !errors.Is(err, domain.ErrAccountClosed) {
+		t.Fatalf("expected ErrAccountClosed, got %v", err)
+	}
+}
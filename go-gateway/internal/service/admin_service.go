@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	pg "github.com/devfullcycle/imersao22/go-gateway/internal/repository/postgres"
+)
+
+// Page sizes of admin listings.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// AdminService implements the cross-tenant operations of the admin API.
+type AdminService struct {
+	repo  domain.AccountRepository
+	fees  domain.FeeRepository
+	clock domain.Clock
+}
+
+func NewAdminService(db *sql.DB) *AdminService {
+	return &AdminService{repo: pg.NewPostgresAccountRepository(db), fees: pg.NewPostgresFeeRepository(db), clock: domain.SystemClock}
+}
+
+// SetClock sets the clock that stamps account changes, adjustments and fee
+// plans and picks the default revenue period.
+func (s *AdminService) SetClock(c domain.Clock) {
+	s.clock = c
+}
+
+// ListAccounts searches accounts by name/email and status, one page at a time.
+func (s *AdminService) ListAccounts(ctx context.Context, in AccountListInput) (*AccountListOutput, error) {
+	f := domain.AccountFilter{Query: in.Query, Limit: in.Limit, Offset: in.Offset}
+	if in.Status != "" {
+		st, err := domain.ParseAccountStatus(in.Status)
+		if err != nil {
+			return nil, err
+		}
+		f.Status = st
+	}
+	if f.Limit <= 0 {
+		f.Limit = DefaultPageSize
+	}
+	if f.Limit > MaxPageSize {
+		f.Limit = MaxPageSize
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+
+	accounts, total, err := s.repo.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	out := &AccountListOutput{
+		Items:  make([]*AdminAccountOutput, 0, len(accounts)),
+		Total:  total,
+		Limit:  f.Limit,
+		Offset: f.Offset,
+	}
+	for _, a := range accounts {
+		out.Items = append(out.Items, toAdminAccountOutput(a))
+	}
+	return out, nil
+}
+
+func (s *AdminService) GetAccount(ctx context.Context, id string) (*AdminAccountOutput, error) {
+	a, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toAdminAccountOutput(a), nil
+}
+
+// SuspendAccount blocks an active account.
+func (s *AdminService) SuspendAccount(ctx context.Context, id string) (*AdminAccountOutput, error) {
+	return s.changeStatus(ctx, id, (*domain.Account).Suspend)
+}
+
+// ReactivateAccount unblocks a suspended account.
+func (s *AdminService) ReactivateAccount(ctx context.Context, id string) (*AdminAccountOutput, error) {
+	return s.changeStatus(ctx, id, (*domain.Account).Reactivate)
+}
+
+// CloseAccount permanently closes an active or suspended account.
+func (s *AdminService) CloseAccount(ctx context.Context, id string) (*AdminAccountOutput, error) {
+	return s.changeStatus(ctx, id, (*domain.Account).Close)
+}
+
+func (s *AdminService) changeStatus(ctx context.Context, id string, transition func(*domain.Account) error) (*AdminAccountOutput, error) {
+	a, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	a.SetClock(s.clock)
+	if err := transition(a); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateStatus(ctx, a); err != nil {
+		return nil, err
+	}
+	return toAdminAccountOutput(a), nil
+}
+
+// AdjustBalance credits or debits an account and records who did it and why.
+func (s *AdminService) AdjustBalance(ctx context.Context, in BalanceAdjustmentInput) (*BalanceAdjustmentOutput, error) {
+	adj, err := domain.NewBalanceAdjustment(in.AccountID, in.Amount, in.Reason, in.Actor, s.clock)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.AdjustBalance(ctx, adj); err != nil {
+		return nil, err
+	}
+	return toBalanceAdjustmentOutput(adj), nil
+}
+
+// ListAdjustments returns the adjustment history of an account, newest first.
+func (s *AdminService) ListAdjustments(ctx context.Context, accountID string) ([]*BalanceAdjustmentOutput, error) {
+	if _, err := s.repo.GetByID(ctx, accountID); err != nil {
+		return nil, err
+	}
+	list, err := s.repo.ListAdjustments(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*BalanceAdjustmentOutput, 0, len(list))
+	for _, adj := range list {
+		out = append(out, toBalanceAdjustmentOutput(adj))
+	}
+	return out, nil
+}
+
+// ListFeePlans returns the fee plans of an account. Payment types without a
+// plan are charged the default plan.
+func (s *AdminService) ListFeePlans(ctx context.Context, accountID string) ([]*FeePlanOutput, error) {
+	if _, err := s.repo.GetByID(ctx, accountID); err != nil {
+		return nil, err
+	}
+	plans, err := s.fees.ListPlans(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*FeePlanOutput, 0, len(plans))
+	for _, p := range plans {
+		out = append(out, toFeePlanOutput(p))
+	}
+	return out, nil
+}
+
+// SetFeePlan creates or replaces the fee plan of an account for a payment
+// type. It applies to invoices approved from now on.
+func (s *AdminService) SetFeePlan(ctx context.Context, in FeePlanInput) (*FeePlanOutput, error) {
+	if _, err := s.repo.GetByID(ctx, in.AccountID); err != nil {
+		return nil, err
+	}
+	p, err := domain.NewFeePlan(in.AccountID, in.PaymentType, in.Percent, in.Fixed, s.clock)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fees.SavePlan(ctx, p); err != nil {
+		return nil, err
+	}
+	return toFeePlanOutput(p), nil
+}
+
+// Revenue sums the fees collected in [in.From, in.To) per payment type. A
+// zero From is the start of the current month and a zero To is one month
+// after From.
+func (s *AdminService) Revenue(ctx context.Context, in RevenueInput) (*RevenueOutput, error) {
+	if in.From.IsZero() {
+		now := s.clock.Now()
+		in.From = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	if in.To.IsZero() {
+		in.To = in.From.AddDate(0, 1, 0)
+	}
+	if !in.To.After(in.From) {
+		return nil, domain.ErrInvalidFeePeriod
+	}
+	lines, err := s.fees.Revenue(ctx, in.From, in.To)
+	if err != nil {
+		return nil, err
+	}
+	out := &RevenueOutput{From: in.From, To: in.To, Items: make([]*RevenueLineOutput, 0, len(lines))}
+	for _, l := range lines {
+		out.Items = append(out.Items, &RevenueLineOutput{
+			PaymentType: l.PaymentType,
+			Invoices:    l.Invoices,
+			Gross:       roundCents(l.Gross),
+			Fees:        roundCents(l.Fees),
+		})
+		out.Invoices += l.Invoices
+		out.Gross += l.Gross
+		out.Fees += l.Fees
+	}
+	out.Gross = roundCents(out.Gross)
+	out.Fees = roundCents(out.Fees)
+	return out, nil
+}
+
+func toAdminAccountOutput(a *domain.Account) *AdminAccountOutput {
+	return &AdminAccountOutput{
+		ID:             a.ID,
+		Name:           a.Name,
+		Email:          a.Email,
+		Status:         string(a.Status),
+		Balance:        a.Balance,
+		PendingBalance: a.PendingBalance,
+		CreatedAt:      a.CreatedAt,
+		UpdatedAt:      a.UpdatedAt,
+	}
+}
+
+func toBalanceAdjustmentOutput(adj *domain.BalanceAdjustment) *BalanceAdjustmentOutput {
+	return &BalanceAdjustmentOutput{
+		ID:           adj.ID,
+		AccountID:    adj.AccountID,
+		Amount:       adj.Amount,
+		BalanceAfter: adj.BalanceAfter,
+		Reason:       adj.Reason,
+		Actor:        adj.Actor,
+		CreatedAt:    adj.CreatedAt,
+	}
+}
+
+func toFeePlanOutput(p *domain.FeePlan) *FeePlanOutput {
+	return &FeePlanOutput{
+		AccountID:   p.AccountID,
+		PaymentType: p.PaymentType,
+		Percent:     p.Percent,
+		Fixed:       p.Fixed,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+// roundCents drops the float noise of summed amounts.
+func roundCents(amount float64) float64 {
+	return FromCents(ToCents(amount))
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/repository/memory"
+)
+
+func newAdminServiceWithAccount(t *testing.T) (*AdminService, *domain.Account) {
+	t.Helper()
+	repo := memory.NewInMemoryAccountRepository()
+	a, err := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	if err != nil {
+		t.Fatalf("new account: %v", err)
+	}
+	_ = repo.Create(context.Background(), a)
+	return &AdminService{repo: repo, fees: memory.NewFeeRepositoryMemory(), clock: domain.NewFakeClock(time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC))}, a
+}
+
+func TestAdminService_ListAccounts(t *testing.T) {
+	svc, a := newAdminServiceWithAccount(t)
+	ctx := context.Background()
+
+	out, err := svc.ListAccounts(ctx, AccountListInput{Query: "ACME"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if out.Total != 1 || out.Items[0].ID != a.ID || out.Limit != DefaultPageSize {
+		t.Fatalf("unexpected output: %+v", out)
+	}
+
+	out, _ = svc.ListAccounts(ctx, AccountListInput{Status: "suspended", Limit: 1000})
+	if out.Total != 0 || len(out.Items) != 0 || out.Limit != MaxPageSize {
+		t.Fatalf("unexpected output: %+v", out)
+	}
+
+	if _, err := svc.ListAccounts(ctx, AccountListInput{Status: "gone"}); !errors.Is(err, domain.ErrInvalidAccountStatus) {
+		t.Fatalf("expected invalid status, got %v", err)
+	}
+}
+
+func TestAdminService_SuspendAndReactivate(t *testing.T) {
+	svc, a := newAdminServiceWithAccount(t)
+	ctx := context.Background()
+
+	out, err := svc.SuspendAccount(ctx, a.ID)
+	if err != nil || out.Status != "suspended" {
+		t.Fatalf("suspend: %+v %v", out, err)
+	}
+	if _, err := svc.SuspendAccount(ctx, a.ID); !errors.Is(err, domain.ErrInvalidStatusTransition) {
+		t.Fatalf("expected invalid transition, got %v", err)
+	}
+	out, err = svc.ReactivateAccount(ctx, a.ID)
+	if err != nil || out.Status != "active" {
+		t.Fatalf("reactivate: %+v %v", out, err)
+	}
+	if _, err := svc.SuspendAccount(ctx, "missing"); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestAdminService_AdjustBalance(t *testing.T) {
+	svc, a := newAdminServiceWithAccount(t)
+	ctx := context.Background()
+
+	out, err := svc.AdjustBalance(ctx, BalanceAdjustmentInput{AccountID: a.ID, Amount: 50, Reason: "goodwill credit", Actor: "ops@example.com"})
+	if err != nil {
+		t.Fatalf("adjust: %v", err)
+	}
+	if out.BalanceAfter != 50 || out.Actor != "ops@example.com" {
+		t.Fatalf("unexpected output: %+v", out)
+	}
+
+	_, err = svc.AdjustBalance(ctx, BalanceAdjustmentInput{AccountID: a.ID, Amount: 10, Reason: "x"})
+	var verr *domain.ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 2 {
+		t.Fatalf("expected reason and actor errors, got %v", err)
+	}
+
+	list, err := svc.ListAdjustments(ctx, a.ID)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("expected one adjustment, got %+v %v", list, err)
+	}
+	if _, err := svc.ListAdjustments(ctx, "missing"); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestAdminService_FeePlans(t *testing.T) {
+	svc, a := newAdminServiceWithAccount(t)
+	ctx := context.Background()
+
+	if _, err := svc.SetFeePlan(ctx, FeePlanInput{AccountID: "missing", PaymentType: "pix", Percent: 1}); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Fatalf("expected ErrAccountNotFound, got %v", err)
+	}
+	if _, err := svc.SetFeePlan(ctx, FeePlanInput{AccountID: a.ID, PaymentType: "pix", Percent: 101}); !errors.Is(err, domain.ErrInvalidFeePercent) {
+		t.Fatalf("expected ErrInvalidFeePercent, got %v", err)
+	}
+	out, err := svc.SetFeePlan(ctx, FeePlanInput{AccountID: a.ID, PaymentType: "pix", Percent: 0.99, Fixed: 0.1})
+	if err != nil || out.Percent != 0.99 || out.Fixed != 0.1 {
+		t.Fatalf("unexpected plan %+v %v", out, err)
+	}
+
+	list, err := svc.ListFeePlans(ctx, a.ID)
+	if err != nil || len(list) != 1 || list[0].PaymentType != "pix" {
+		t.Fatalf("expected the pix plan, got %+v %v", list, err)
+	}
+}
+
+func TestAdminService_Revenue(t *testing.T) {
+	svc, _ := newAdminServiceWithAccount(t)
+	ctx := context.Background()
+	now := svc.clock.Now()
+
+	for _, f := range []*domain.InvoiceFee{
+		{PaymentType: "pix", Gross: 0.1, Fee: 0.1, CreatedAt: now},
+		{PaymentType: "pix", Gross: 0.2, Fee: 0.2, CreatedAt: now},
+		{PaymentType: "credit_card", Gross: 100, Fee: 4.38, CreatedAt: now},
+	} {
+		_ = svc.fees.Record(ctx, f)
+	}
+
+	if _, err := svc.Revenue(ctx, RevenueInput{From: now, To: now}); !errors.Is(err, domain.ErrInvalidFeePeriod) {
+		t.Fatalf("expected ErrInvalidFeePeriod, got %v", err)
+	}
+	out, err := svc.Revenue(ctx, RevenueInput{From: now.Add(-time.Hour), To: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("revenue: %v", err)
+	}
+	if out.Invoices != 3 || out.Fees != 4.68 || out.Gross != 100.3 || len(out.Items) != 2 || out.Items[1].Fees != 0.3 {
+		t.Fatalf("unexpected revenue %+v", out)
+	}
+}
+
+func TestAdminService_RevenueDefaultsToCurrentMonth(t *testing.T) {
+	svc, _ := newAdminServiceWithAccount(t)
+	ctx := context.Background()
+	_ = svc.fees.Record(ctx, &domain.InvoiceFee{PaymentType: "pix", Gross: 10, Fee: 0.1, CreatedAt: svc.clock.Now()})
+
+	out, err := svc.Revenue(ctx, RevenueInput{})
+	if err != nil {
+		t.Fatalf("revenue: %v", err)
+	}
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if !out.From.Equal(march) || !out.To.Equal(march.AddDate(0, 1, 0)) || out.Invoices != 1 {
+		t.Fatalf("expected March of the clock, got %+v", out)
+	}
+
+	out, _ = svc.Revenue(ctx, RevenueInput{From: march.AddDate(0, -1, 0)})
+	if !out.To.Equal(march) || out.Invoices != 0 {
+		t.Fatalf("expected one month from From, got %+v", out)
+	}
+}
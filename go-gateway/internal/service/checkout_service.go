@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	pg "github.com/devfullcycle/imersao22/go-gateway/internal/repository/postgres"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+// CheckoutService lets merchants without a backend charge through payment
+// links. The public side of a session acts as the merchant without the
+// payer ever seeing its API key.
+type CheckoutService struct {
+	repo     domain.CheckoutSessionRepository
+	accounts domain.AccountRepository
+	invoices InvoiceCreator
+	clock    domain.Clock
+}
+
+func NewCheckoutService(db *sql.DB, invoices InvoiceCreator) *CheckoutService {
+	return &CheckoutService{
+		repo:     pg.NewPostgresCheckoutSessionRepository(db),
+		accounts: pg.NewPostgresAccountRepository(db),
+		invoices: invoices,
+		clock:    domain.SystemClock,
+	}
+}
+
+// SetClock sets the clock that stamps sessions and decides when they expire.
+func (s *CheckoutService) SetClock(c domain.Clock) {
+	s.clock = c
+}
+
+// CreateSession creates a payment link for the account behind the API key.
+func (s *CheckoutService) CreateSession(ctx context.Context, in CheckoutSessionInput) (*CheckoutSessionOutput, error) {
+	account, err := writableAccount(ctx, s.accounts, in.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	var expiresAt time.Time
+	if in.ExpiresAt != nil {
+		expiresAt = in.ExpiresAt.UTC()
+	}
+	sess, err := domain.NewCheckoutSession(account.ID, in.Description, in.Amount, in.PaymentTypes, in.SuccessURL, in.CancelURL, expiresAt, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, sess); err != nil {
+		return nil, err
+	}
+	return s.toSessionOutput(sess), nil
+}
+
+// GetSession returns a session of the account behind the API key.
+func (s *CheckoutService) GetSession(ctx context.Context, apiKey, id string) (*CheckoutSessionOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := s.repo.GetForAccount(ctx, account.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toSessionOutput(sess), nil
+}
+
+// Get returns the public view of a session.
+func (s *CheckoutService) Get(ctx context.Context, id string) (*CheckoutOutput, error) {
+	sess, ctx, err := s.session(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.accounts.GetByID(ctx, sess.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckoutOutput{
+		ID:           sess.ID,
+		MerchantName: account.Name,
+		Amount:       sess.Amount,
+		Description:  sess.Description,
+		PaymentTypes: sess.PaymentTypes,
+		Status:       string(sess.StatusAt(s.clock.Now())),
+		SuccessURL:   sess.SuccessURL,
+		CancelURL:    sess.CancelURL,
+		ExpiresAt:    sess.ExpiresAt,
+	}, nil
+}
+
+// Pay charges a session as its merchant. The session is claimed before the
+// invoice is created, so concurrent payers cannot both complete it; a
+// payment that is rejected or refused before charging releases it for
+// another attempt. An invoice held for review completes the session.
+//
+// The invoice is keyed by the claim, so when linking it to the session
+// fails, paying again gets the same invoice back and links it.
+func (s *CheckoutService) Pay(ctx context.Context, in CheckoutPayInput) (*CheckoutPayOutput, error) {
+	sess, ctx, err := s.session(ctx, in.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	resumed := sess.Unlinked()
+	if resumed {
+		if !sess.Accepts(in.PaymentType) {
+			return nil, domain.ErrPaymentTypeNotAllowed
+		}
+	} else {
+		if err := sess.Claim(in.PaymentType, s.clock.Now()); err != nil {
+			return nil, err
+		}
+		if err := s.repo.Claim(ctx, sess); err != nil {
+			return nil, err
+		}
+	}
+
+	invoice, err := s.charge(ctx, sess, in)
+	if err != nil {
+		// Other errors may come after the charge went through: the session
+		// stays claimed and the next attempt replays the invoice. A resumed
+		// claim may already have an invoice whatever the error.
+		if !resumed && chargeRefused(err) {
+			_ = s.release(ctx, sess)
+		}
+		return nil, err
+	}
+	if invoice.Status == string(domain.StatusRejected) {
+		if err := s.release(ctx, sess); err != nil {
+			return nil, err
+		}
+		return &CheckoutPayOutput{SessionID: sess.ID, InvoiceID: invoice.ID, Status: invoice.Status}, nil
+	}
+
+	sess.InvoiceID = invoice.ID
+	if err := s.repo.Update(ctx, sess); err != nil {
+		return nil, err
+	}
+	return &CheckoutPayOutput{SessionID: sess.ID, InvoiceID: invoice.ID, Status: invoice.Status, RedirectURL: sess.SuccessURL}, nil
+}
+
+// charge creates the invoice of a claimed session through the merchant's
+// API key, so it goes through the same risk, fee and settlement rules as
+// any other invoice.
+func (s *CheckoutService) charge(ctx context.Context, sess *domain.CheckoutSession, in CheckoutPayInput) (*InvoiceOutput, error) {
+	account, err := s.accounts.GetByID(ctx, sess.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return s.invoices.Create(ctx, InvoiceCreateInput{
+		APIKey:         account.APIKey,
+		Amount:         sess.Amount,
+		Description:    sess.Description,
+		PaymentType:    in.PaymentType,
+		CardLastDigits: in.CardLastDigits,
+		IdempotencyKey: sess.PaymentKey,
+	})
+}
+
+// chargeRefused reports whether err refused an invoice before charging it.
+func chargeRefused(err error) bool {
+	var verr *domain.ValidationError
+	return errors.As(err, &verr) || errors.Is(err, domain.ErrAccountSuspended) || errors.Is(err, domain.ErrAccountClosed)
+}
+
+// release reopens a claimed session whose payment did not go through.
+func (s *CheckoutService) release(ctx context.Context, sess *domain.CheckoutSession) error {
+	sess.Release(s.clock.Now())
+	return s.repo.Update(ctx, sess)
+}
+
+// session looks a session up by ID alone and returns it with a context
+// bound to its account, which the rest of the public checkout acts as.
+func (s *CheckoutService) session(ctx context.Context, id string) (*domain.CheckoutSession, context.Context, error) {
+	sess, err := s.repo.GetByID(tenant.WithPrivileged(ctx), id)
+	if err != nil {
+		return nil, ctx, err
+	}
+	return sess, tenant.WithAccount(ctx, sess.AccountID), nil
+}
+
+func (s *CheckoutService) toSessionOutput(sess *domain.CheckoutSession) *CheckoutSessionOutput {
+	return &CheckoutSessionOutput{
+		ID:           sess.ID,
+		CheckoutPath: "/v1/checkout/" + sess.ID,
+		Amount:       sess.Amount,
+		Description:  sess.Description,
+		PaymentTypes: sess.PaymentTypes,
+		SuccessURL:   sess.SuccessURL,
+		CancelURL:    sess.CancelURL,
+		Status:       string(sess.StatusAt(s.clock.Now())),
+		InvoiceID:    sess.InvoiceID,
+		ExpiresAt:    sess.ExpiresAt,
+		CompletedAt:  sess.CompletedAt,
+		CreatedAt:    sess.CreatedAt,
+	}
+}
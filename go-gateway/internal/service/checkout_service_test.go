@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/repository/memory"
+)
+
+func newCheckoutService(t *testing.T, clock domain.Clock) (*CheckoutService, *domain.Account, *fakeInvoiceCreator) {
+	t.Helper()
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", clock)
+	_ = accounts.Create(context.Background(), a)
+
+	invoices := &fakeInvoiceCreator{}
+	svc := &CheckoutService{
+		repo:     memory.NewCheckoutSessionRepositoryMemory(),
+		accounts: accounts,
+		invoices: invoices,
+		clock:    clock,
+	}
+	return svc, a, invoices
+}
+
+func createCheckout(t *testing.T, svc *CheckoutService, apiKey string) *CheckoutSessionOutput {
+	t.Helper()
+	out, err := svc.CreateSession(context.Background(), CheckoutSessionInput{
+		APIKey: apiKey, Amount: 99.9, Description: "Order #42", PaymentTypes: []string{"pix", "credit_card"},
+		SuccessURL: "https://shop.example.com/ok", CancelURL: "https://shop.example.com/cart",
+	})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	return out
+}
+
+func TestCheckoutService_PayAsMerchant(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	svc, a, invoices := newCheckoutService(t, clock)
+	sess := createCheckout(t, svc, a.APIKey)
+	if sess.Status != "open" || sess.CheckoutPath != "/v1/checkout/"+sess.ID {
+		t.Fatalf("unexpected session %+v", sess)
+	}
+
+	public, err := svc.Get(ctx, sess.ID)
+	if err != nil || public.MerchantName != "Acme" || public.Amount != 99.9 {
+		t.Fatalf("unexpected public view %+v %v", public, err)
+	}
+
+	invoices.statuses = []domain.Status{domain.StatusApproved}
+	out, err := svc.Pay(ctx, CheckoutPayInput{SessionID: sess.ID, PaymentType: "pix"})
+	if err != nil || out.Status != "approved" || out.RedirectURL != "https://shop.example.com/ok" {
+		t.Fatalf("unexpected payment %+v %v", out, err)
+	}
+	call := invoices.calls[0]
+	if call.APIKey != a.APIKey || call.Amount != 99.9 || call.PaymentType != "pix" || invoices.tenants[0] != a.ID {
+		t.Fatalf("expected the invoice charged as the merchant, got %+v as %q", call, invoices.tenants[0])
+	}
+
+	if _, err := svc.Pay(ctx, CheckoutPayInput{SessionID: sess.ID, PaymentType: "pix"}); !errors.Is(err, domain.ErrCheckoutCompleted) {
+		t.Fatalf("expected a single use, got %v", err)
+	}
+	got, _ := svc.GetSession(ctx, a.APIKey, sess.ID)
+	if got.Status != "completed" || got.InvoiceID != out.InvoiceID {
+		t.Fatalf("expected the session completed by the invoice, got %+v", got)
+	}
+}
+
+func TestCheckoutService_RejectedPaymentReopens(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	svc, a, invoices := newCheckoutService(t, clock)
+	sess := createCheckout(t, svc, a.APIKey)
+
+	invoices.statuses = []domain.Status{domain.StatusRejected, domain.StatusPending}
+	out, err := svc.Pay(ctx, CheckoutPayInput{SessionID: sess.ID, PaymentType: "credit_card", CardLastDigits: "4242"})
+	if err != nil || out.Status != "rejected" || out.RedirectURL != "" {
+		t.Fatalf("unexpected payment %+v %v", out, err)
+	}
+	// A review completes the session
+	if out, err := svc.Pay(ctx, CheckoutPayInput{SessionID: sess.ID, PaymentType: "pix"}); err != nil || out.Status != "pending" {
+		t.Fatalf("expected another attempt, got %+v %v", out, err)
+	}
+
+	invoices.err = domain.ErrAccountSuspended
+	other := createCheckout(t, svc, a.APIKey)
+	if _, err := svc.Pay(ctx, CheckoutPayInput{SessionID: other.ID, PaymentType: "pix"}); !errors.Is(err, domain.ErrAccountSuspended) {
+		t.Fatalf("expected the charge error, got %v", err)
+	}
+	if got, _ := svc.Get(ctx, other.ID); got.Status != "open" {
+		t.Fatalf("expected a failed charge to reopen the session, got %+v", got)
+	}
+}
+
+// failingCheckoutRepository fails the next update of the sessions.
+type failingCheckoutRepository struct {
+	domain.CheckoutSessionRepository
+	fail bool
+}
+
+func (r *failingCheckoutRepository) Update(ctx context.Context, s *domain.CheckoutSession) error {
+	if r.fail {
+		r.fail = false
+		return errors.New("connection reset")
+	}
+	return r.CheckoutSessionRepository.Update(ctx, s)
+}
+
+func TestCheckoutService_RetryLinksTheChargedInvoice(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC))
+	svc, a, invoices := newCheckoutService(t, clock)
+	repo := &failingCheckoutRepository{CheckoutSessionRepository: svc.repo, fail: true}
+	svc.repo = repo
+	sess := createCheckout(t, svc, a.APIKey)
+
+	invoices.statuses = []domain.Status{domain.StatusApproved}
+	if _, err := svc.Pay(ctx, CheckoutPayInput{SessionID: sess.ID, PaymentType: "pix"}); err == nil {
+		t.Fatal("expected the failed link to be returned")
+	}
+	got, _ := svc.GetSession(ctx, a.APIKey, sess.ID)
+	if got.Status != "completed" || got.InvoiceID != "" {
+		t.Fatalf("expected the session claimed without an invoice, got %+v", got)
+	}
+
+	clock.Advance(time.Minute)
+	out, err := svc.Pay(ctx, CheckoutPayInput{SessionID: sess.ID, PaymentType: "pix"})
+	if err != nil || out.InvoiceID != "inv-1" || out.RedirectURL == "" {
+		t.Fatalf("expected the retry to link the charged invoice, got %+v %v", out, err)
+	}
+	if len(invoices.calls) != 2 || invoices.calls[1].IdempotencyKey != invoices.calls[0].IdempotencyKey || invoices.calls[0].IdempotencyKey == "" {
+		t.Fatalf("expected the retry to replay the charge by key, got %+v", invoices.calls)
+	}
+	got, _ = svc.GetSession(ctx, a.APIKey, sess.ID)
+	if got.Status != "completed" || got.InvoiceID != "inv-1" {
+		t.Fatalf("expected the session completed by the invoice, got %+v", got)
+	}
+	if _, err := svc.Pay(ctx, CheckoutPayInput{SessionID: sess.ID, PaymentType: "pix"}); !errors.Is(err, domain.ErrCheckoutCompleted) {
+		t.Fatalf("expected a single use, got %v", err)
+	}
+}
+
+func TestCheckoutService_Expiry(t *testing.T) {
+	ctx := context.Background()
+	clock := domain.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	svc, a, invoices := newCheckoutService(t, clock)
+	sess := createCheckout(t, svc, a.APIKey)
+
+	clock.Advance(domain.DefaultCheckoutTTL)
+	if got, _ := svc.Get(ctx, sess.ID); got.Status != "expired" {
+		t.Fatalf("expected the session expired, got %+v", got)
+	}
+	if _, err := svc.Pay(ctx, CheckoutPayInput{SessionID: sess.ID, PaymentType: "pix"}); !errors.Is(err, domain.ErrCheckoutExpired) {
+		t.Fatalf("expected ErrCheckoutExpired, got %v", err)
+	}
+	if len(invoices.calls) != 0 {
+		t.Fatalf("expected no charge, got %+v", invoices.calls)
+	}
+	if _, err := svc.Get(ctx, "missing"); !errors.Is(err, domain.ErrCheckoutSessionNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestCheckoutService_TenantIsolation(t *testing.T) {
+	ctx := context.Background()
+	svc, a, _ := newCheckoutService(t, domain.SystemClock)
+	other, _ := domain.NewAccount("Other", "other@example.com", domain.SystemClock)
+	_ = svc.accounts.Create(ctx, other)
+	sess := createCheckout(t, svc, a.APIKey)
+
+	if _, err := svc.GetSession(ctx, other.APIKey, sess.ID); !errors.Is(err, domain.ErrCheckoutSessionNotFound) {
+		t.Fatalf("expected another account not to see the session, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	pg "github.com/devfullcycle/imersao22/go-gateway/internal/repository/postgres"
+)
+
+// CustomerService manages the customers of merchant accounts and the cards
+// they keep on file. Invoices are attached to customers by InvoiceService.
+type CustomerService struct {
+	repo     domain.CustomerRepository
+	accounts domain.AccountRepository
+	invoices domain.InvoiceRepository
+	clock    domain.Clock
+}
+
+func NewCustomerService(db *sql.DB) *CustomerService {
+	return &CustomerService{
+		repo:     pg.NewPostgresCustomerRepository(db),
+		accounts: pg.NewPostgresAccountRepository(db),
+		invoices: pg.NewPostgresInvoiceRepository(db),
+		clock:    domain.SystemClock,
+	}
+}
+
+// SetClock sets the clock that stamps customers and saved cards.
+func (s *CustomerService) SetClock(c domain.Clock) {
+	s.clock = c
+}
+
+func (s *CustomerService) Create(ctx context.Context, in CustomerInput) (*CustomerOutput, error) {
+	account, err := writableAccount(ctx, s.accounts, in.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	c, err := domain.NewCustomer(account.ID, in.Name, in.Email, in.Document, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, c); err != nil {
+		return nil, err
+	}
+	return toCustomerOutput(c), nil
+}
+
+func (s *CustomerService) List(ctx context.Context, apiKey string) ([]*CustomerOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.repo.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*CustomerOutput, 0, len(list))
+	for _, c := range list {
+		out = append(out, toCustomerOutput(c))
+	}
+	return out, nil
+}
+
+func (s *CustomerService) GetByID(ctx context.Context, apiKey, id string) (*CustomerOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.repo.GetByID(ctx, account.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toCustomerOutput(c), nil
+}
+
+// ListInvoices returns the invoices of a customer, newest first.
+func (s *CustomerService) ListInvoices(ctx context.Context, apiKey, id string) ([]*InvoiceOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	// An unknown customer is a 404, not an empty list
+	if _, err := s.repo.GetByID(ctx, account.ID, id); err != nil {
+		return nil, err
+	}
+	invoices, err := s.invoices.GetByCustomer(ctx, account.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*InvoiceOutput, 0, len(invoices))
+	for _, i := range invoices {
+		out = append(out, toInvoiceOutput(i))
+	}
+	return out, nil
+}
+
+// AddCard saves a tokenized card for a customer so later invoices can be
+// charged to it by saved_card_id.
+func (s *CustomerService) AddCard(ctx context.Context, in SavedCardInput) (*SavedCardOutput, error) {
+	account, err := writableAccount(ctx, s.accounts, in.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.repo.GetByID(ctx, account.ID, in.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	card, err := domain.NewSavedCard(c, in.CardToken, in.CardLastDigits, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.AddCard(ctx, card); err != nil {
+		return nil, err
+	}
+	return toSavedCardOutput(card), nil
+}
+
+func (s *CustomerService) ListCards(ctx context.Context, apiKey, customerID string) ([]*SavedCardOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetByID(ctx, account.ID, customerID); err != nil {
+		return nil, err
+	}
+	list, err := s.repo.ListCards(ctx, account.ID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*SavedCardOutput, 0, len(list))
+	for _, card := range list {
+		out = append(out, toSavedCardOutput(card))
+	}
+	return out, nil
+}
+
+// DeleteCard forgets a saved card. Suspended accounts may still delete
+// cards, since it only removes data.
+func (s *CustomerService) DeleteCard(ctx context.Context, apiKey, customerID, id string) error {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteCard(ctx, account.ID, customerID, id)
+}
+
+func toCustomerOutput(c *domain.Customer) *CustomerOutput {
+	return &CustomerOutput{
+		ID:        c.ID,
+		Name:      c.Name,
+		Email:     c.Email,
+		Document:  c.Document,
+		CreatedAt: c.CreatedAt,
+	}
+}
+
+func toSavedCardOutput(card *domain.SavedCard) *SavedCardOutput {
+	return &SavedCardOutput{
+		ID:             card.ID,
+		CustomerID:     card.CustomerID,
+		CardLastDigits: card.CardLastDigits,
+		CreatedAt:      card.CreatedAt,
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/repository/memory"
+)
+
+func newCustomerService(t *testing.T) (*CustomerService, *domain.Account, *memory.InvoiceRepositoryMemory) {
+	t.Helper()
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(context.Background(), a)
+
+	invoices := memory.NewInvoiceRepositoryMemory()
+	svc := &CustomerService{
+		repo:     memory.NewCustomerRepositoryMemory(),
+		accounts: accounts,
+		invoices: invoices,
+		clock:    domain.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)),
+	}
+	return svc, a, invoices
+}
+
+func TestCustomerService_CreateAndCards(t *testing.T) {
+	svc, a, _ := newCustomerService(t)
+	ctx := context.Background()
+
+	c, err := svc.Create(ctx, CustomerInput{APIKey: a.APIKey, Name: "Maria Silva", Email: "maria@example.com", Document: "529.982.247-25"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if c.Document != "52998224725" {
+		t.Fatalf("expected the CPF stored as digits, got %q", c.Document)
+	}
+	if _, err := svc.Create(ctx, CustomerInput{APIKey: a.APIKey, Name: "Maria S.", Email: "m@example.com", Document: "52998224725"}); !errors.Is(err, domain.ErrCustomerExists) {
+		t.Fatalf("expected ErrCustomerExists, got %v", err)
+	}
+	var verr *domain.ValidationError
+	if _, err := svc.Create(ctx, CustomerInput{APIKey: a.APIKey, Name: "João", Email: "joao@example.com", Document: "529.982.247-26"}); !errors.As(err, &verr) || verr.Fields[0].Field != "document" {
+		t.Fatalf("expected a document validation error, got %v", err)
+	}
+
+	card, err := svc.AddCard(ctx, SavedCardInput{APIKey: a.APIKey, CustomerID: c.ID, CardToken: "tok_12345678", CardLastDigits: "4242"})
+	if err != nil {
+		t.Fatalf("add card: %v", err)
+	}
+	if list, err := svc.ListCards(ctx, a.APIKey, c.ID); err != nil || len(list) != 1 || list[0].ID != card.ID {
+		t.Fatalf("expected the saved card, got %+v %v", list, err)
+	}
+	if _, err := svc.AddCard(ctx, SavedCardInput{APIKey: a.APIKey, CustomerID: "missing", CardToken: "tok_12345678", CardLastDigits: "4242"}); !errors.Is(err, domain.ErrCustomerNotFound) {
+		t.Fatalf("expected ErrCustomerNotFound, got %v", err)
+	}
+	if err := svc.DeleteCard(ctx, a.APIKey, c.ID, card.ID); err != nil {
+		t.Fatalf("delete card: %v", err)
+	}
+	if err := svc.DeleteCard(ctx, a.APIKey, c.ID, card.ID); !errors.Is(err, domain.ErrSavedCardNotFound) {
+		t.Fatalf("expected ErrSavedCardNotFound, got %v", err)
+	}
+}
+
+func TestCustomerService_ListInvoices(t *testing.T) {
+	svc, a, invoices := newCustomerService(t)
+	ctx := context.Background()
+
+	c, _ := svc.Create(ctx, CustomerInput{APIKey: a.APIKey, Name: "Maria Silva", Email: "maria@example.com", Document: "52998224725"})
+	now := time.Now().UTC()
+	_ = invoices.Create(ctx, &domain.Invoice{ID: "inv-1", AccountID: a.ID, CustomerID: c.ID, Amount: 10, Status: domain.StatusApproved, PaymentType: "pix", CreatedAt: now, UpdatedAt: now})
+	_ = invoices.Create(ctx, &domain.Invoice{ID: "inv-2", AccountID: a.ID, Amount: 20, Status: domain.StatusApproved, PaymentType: "pix", CreatedAt: now, UpdatedAt: now})
+
+	list, err := svc.ListInvoices(ctx, a.APIKey, c.ID)
+	if err != nil || len(list) != 1 || list[0].ID != "inv-1" || list[0].CustomerID != c.ID {
+		t.Fatalf("expected only the customer's invoice, got %+v %v", list, err)
+	}
+	if _, err := svc.ListInvoices(ctx, a.APIKey, "missing"); !errors.Is(err, domain.ErrCustomerNotFound) {
+		t.Fatalf("expected ErrCustomerNotFound, got %v", err)
+	}
+}
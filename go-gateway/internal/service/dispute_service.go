@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	pg "github.com/devfullcycle/imersao22/go-gateway/internal/repository/postgres"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+// DefaultDisputeBatchSize is how many overdue disputes an expiry run picks.
+const DefaultDisputeBatchSize = 50
+
+// DisputeService runs the chargeback lifecycle: the card network (the admin
+// API, for now) opens and resolves disputes, merchants answer them with
+// evidence, and disputes left unanswered past their deadline are lost.
+type DisputeService struct {
+	repo     domain.DisputeRepository
+	accounts domain.AccountRepository
+	invoices domain.InvoiceRepository
+	clock    domain.Clock
+}
+
+func NewDisputeService(db *sql.DB) *DisputeService {
+	return &DisputeService{
+		repo:     pg.NewPostgresDisputeRepository(db),
+		accounts: pg.NewPostgresAccountRepository(db),
+		invoices: pg.NewPostgresInvoiceRepository(db),
+		clock:    domain.SystemClock,
+	}
+}
+
+// SetClock sets the clock that stamps disputes and decides which deadlines
+// Run enforces.
+func (s *DisputeService) SetClock(c domain.Clock) {
+	s.clock = c
+}
+
+// Open opens a dispute on an approved invoice of any account and freezes the
+// disputed amount. It runs on a privileged context; the funds are moved as
+// the owning account.
+func (s *DisputeService) Open(ctx context.Context, in DisputeOpenInput) (*DisputeOutput, error) {
+	invoice, err := s.invoices.GetByID(ctx, in.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if in.Amount == 0 {
+		in.Amount = invoice.TotalAmount
+	}
+	d, err := domain.NewDispute(invoice, in.Amount, domain.DisputeReason(in.Reason), s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Open(tenant.WithAccount(ctx, d.AccountID), d); err != nil {
+		return nil, err
+	}
+	return toDisputeOutput(d), nil
+}
+
+// Resolve closes a dispute of any account as won, releasing its amount, or
+// lost, keeping it debited. It runs on a privileged context.
+func (s *DisputeService) Resolve(ctx context.Context, in DisputeResolveInput) (*DisputeOutput, error) {
+	d, err := s.repo.GetByID(ctx, in.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Resolve(domain.DisputeStatus(in.Outcome), s.clock.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Resolve(tenant.WithAccount(ctx, d.AccountID), d); err != nil {
+		return nil, err
+	}
+	return toDisputeOutput(d), nil
+}
+
+func (s *DisputeService) List(ctx context.Context, apiKey string) ([]*DisputeOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.repo.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*DisputeOutput, 0, len(list))
+	for _, d := range list {
+		out = append(out, toDisputeOutput(d))
+	}
+	return out, nil
+}
+
+func (s *DisputeService) GetByID(ctx context.Context, apiKey, id string) (*DisputeOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	d, err := s.repo.GetByIDForAccount(ctx, account.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toDisputeOutput(d), nil
+}
+
+// SubmitEvidence records the merchant's defense of a dispute. Suspended
+// accounts may still answer, since the funds at stake are theirs.
+func (s *DisputeService) SubmitEvidence(ctx context.Context, in DisputeEvidenceInput) (*DisputeOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, in.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	d, err := s.repo.GetByIDForAccount(ctx, account.ID, in.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.SubmitEvidence(in.Evidence, s.clock.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SubmitEvidence(ctx, d); err != nil {
+		return nil, err
+	}
+	return toDisputeOutput(d), nil
+}
+
+// ExpireOverdue loses up to limit disputes left without evidence past their
+// deadline at now and returns how many were lost.
+func (s *DisputeService) ExpireOverdue(ctx context.Context, now time.Time, limit int) (int, error) {
+	overdue, err := s.repo.ListOverdue(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for _, d := range overdue {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := d.Resolve(domain.DisputeLost, now); err != nil {
+			return done, err
+		}
+		err := s.repo.Resolve(tenant.WithAccount(ctx, d.AccountID), d)
+		if errors.Is(err, domain.ErrInvalidDisputeTransition) {
+			continue // answered or resolved meanwhile
+		}
+		if err != nil {
+			return done, err
+		}
+		done++
+	}
+	return done, nil
+}
+
+// Run expires overdue disputes every interval until ctx is canceled.
+func (s *DisputeService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.ExpireOverdue(ctx, s.clock.Now(), DefaultDisputeBatchSize); err != nil {
+				log.Printf("disputes: expired %d: %v", n, err)
+			}
+		}
+	}
+}
+
+func toDisputeOutput(d *domain.Dispute) *DisputeOutput {
+	return &DisputeOutput{
+		ID:                  d.ID,
+		InvoiceID:           d.InvoiceID,
+		Amount:              d.Amount,
+		Reason:              string(d.Reason),
+		Status:              string(d.Status),
+		Evidence:            d.Evidence,
+		EvidenceDueBy:       d.EvidenceDueBy,
+		EvidenceSubmittedAt: d.EvidenceSubmittedAt,
+		ResolvedAt:          d.ResolvedAt,
+		CreatedAt:           d.CreatedAt,
+		UpdatedAt:           d.UpdatedAt,
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/repository/memory"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+func newDisputeService(t *testing.T, now time.Time) (*DisputeService, *domain.Account, *domain.Invoice, *domain.FakeClock) {
+	t.Helper()
+	ctx := context.Background()
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = a.AddBalance(100)
+	_ = accounts.Create(ctx, a)
+
+	invoices := memory.NewInvoiceRepositoryMemory()
+	inv := &domain.Invoice{ID: "inv-1", AccountID: a.ID, Amount: 80, TotalAmount: 80, Installments: 1, Status: domain.StatusApproved,
+		PaymentType: "credit_card", CreatedAt: now, UpdatedAt: now}
+	_ = invoices.Create(ctx, inv)
+
+	clock := domain.NewFakeClock(now)
+	svc := &DisputeService{
+		repo:     memory.NewDisputeRepositoryMemory(accounts),
+		accounts: accounts,
+		invoices: invoices,
+		clock:    clock,
+	}
+	return svc, a, inv, clock
+}
+
+func TestDisputeService_EvidenceAndWin(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc, a, inv, _ := newDisputeService(t, now)
+	admin := tenant.WithPrivileged(context.Background())
+	ctx := context.Background()
+
+	d, err := svc.Open(admin, DisputeOpenInput{InvoiceID: inv.ID, Reason: "product_not_received"})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if d.Amount != 80 || d.Status != "needs_response" || a.Balance != 20 {
+		t.Fatalf("expected the invoice total frozen, got %+v balance %v", d, a.Balance)
+	}
+	if _, err := svc.Open(admin, DisputeOpenInput{InvoiceID: inv.ID, Reason: "general"}); !errors.Is(err, domain.ErrDisputeExists) {
+		t.Fatalf("expected ErrDisputeExists, got %v", err)
+	}
+
+	if _, err := svc.SubmitEvidence(ctx, DisputeEvidenceInput{APIKey: a.APIKey, ID: d.ID, Evidence: "tracking code BR123456789"}); err != nil {
+		t.Fatalf("submit evidence: %v", err)
+	}
+	if list, _ := svc.List(ctx, a.APIKey); len(list) != 1 || list[0].Status != "under_review" {
+		t.Fatalf("expected a dispute under review, got %+v", list)
+	}
+
+	won, err := svc.Resolve(admin, DisputeResolveInput{ID: d.ID, Outcome: "won"})
+	if err != nil || won.Status != "won" || won.ResolvedAt == nil {
+		t.Fatalf("expected a won dispute, got %+v %v", won, err)
+	}
+	if a.Balance != 100 {
+		t.Fatalf("expected the frozen amount released, got balance %v", a.Balance)
+	}
+	if _, err := svc.Resolve(admin, DisputeResolveInput{ID: d.ID, Outcome: "lost"}); !errors.Is(err, domain.ErrInvalidDisputeTransition) {
+		t.Fatalf("expected ErrInvalidDisputeTransition, got %v", err)
+	}
+}
+
+func TestDisputeService_LostKeepsFundsDebited(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc, a, inv, _ := newDisputeService(t, now)
+	admin := tenant.WithPrivileged(context.Background())
+
+	d, err := svc.Open(admin, DisputeOpenInput{InvoiceID: inv.ID, Amount: 30, Reason: "fraudulent"})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := svc.Resolve(admin, DisputeResolveInput{ID: d.ID, Outcome: "lost"}); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if a.Balance != 70 {
+		t.Fatalf("expected 30 debited, got balance %v", a.Balance)
+	}
+}
+
+func TestDisputeService_ExpireOverdue(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc, a, inv, clock := newDisputeService(t, now)
+	admin := tenant.WithPrivileged(context.Background())
+
+	d, _ := svc.Open(admin, DisputeOpenInput{InvoiceID: inv.ID, Reason: "unrecognized"})
+
+	if n, err := svc.ExpireOverdue(admin, d.EvidenceDueBy, 10); err != nil || n != 0 {
+		t.Fatalf("expected nothing overdue on the deadline, got %d %v", n, err)
+	}
+	clock.Set(d.EvidenceDueBy.Add(time.Minute))
+	if _, err := svc.SubmitEvidence(context.Background(), DisputeEvidenceInput{APIKey: a.APIKey, ID: d.ID, Evidence: "late but detailed evidence"}); !errors.Is(err, domain.ErrEvidenceDeadlinePassed) {
+		t.Fatalf("expected ErrEvidenceDeadlinePassed, got %v", err)
+	}
+	if n, err := svc.ExpireOverdue(admin, clock.Now(), 10); err != nil || n != 1 {
+		t.Fatalf("expected one expired dispute, got %d %v", n, err)
+	}
+	got, _ := svc.GetByID(context.Background(), a.APIKey, d.ID)
+	if got.Status != "lost" || a.Balance != 20 {
+		t.Fatalf("expected a lost dispute and the amount debited, got %+v balance %v", got, a.Balance)
+	}
+}
+
+func TestDisputeService_OpenRequiresApprovedInvoice(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc, _, inv, _ := newDisputeService(t, now)
+	admin := tenant.WithPrivileged(context.Background())
+
+	_ = svc.invoices.UpdateStatus(admin, inv.ID, domain.StatusRejected)
+	if _, err := svc.Open(admin, DisputeOpenInput{InvoiceID: inv.ID, Reason: "general"}); !errors.Is(err, domain.ErrDisputeRequiresApproval) {
+		t.Fatalf("expected ErrDisputeRequiresApproval, got %v", err)
+	}
+	if _, err := svc.Open(admin, DisputeOpenInput{InvoiceID: "missing", Reason: "general"}); !errors.Is(err, domain.ErrInvoiceNotFound) {
+		t.Fatalf("expected ErrInvoiceNotFound, got %v", err)
+	}
+}
@@ -1,24 +1,31 @@
 package service
 
 import (
+	"math"
 	"time"
 )
 
+// Input DTOs carry the APIKey of the caller in a field hidden from JSON: it
+// is taken from the X-API-KEY header, never from the body.
+
 // AccountCreateInput is the input DTO to create an account.
 type AccountCreateInput struct {
 	Name  string `json:"name"`
-	Email string `json:"email"`
+	Email string `json:"email" openapi:"format=email"`
 }
 
-// AccountOutput is the output DTO for account responses.
+// AccountOutput is the output DTO for account responses. Balance is
+// available for payouts; PendingBalance waits for its settlement date.
 type AccountOutput struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	APIKey    string    `json:"api_key"`
-	Balance   float64   `json:"balance"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Email          string    `json:"email"`
+	APIKey         string    `json:"api_key"`
+	Balance        float64   `json:"balance"`
+	PendingBalance float64   `json:"pending_balance"`
+	Status         string    `json:"status" openapi:"enum=active|suspended|closed"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 // AddBalanceInput is the input DTO for adding balance.
@@ -29,22 +36,823 @@ type AddBalanceInput struct {
 
 // InvoiceCreateInput is the input DTO to create an invoice.
 type InvoiceCreateInput struct {
-	APIKey         string  `json:"api_key"`
+	APIKey         string  `json:"-"`
 	Amount         float64 `json:"amount"`
 	Description    string  `json:"description"`
 	PaymentType    string  `json:"payment_type"`
 	CardLastDigits string  `json:"card_last_digits,omitempty"`
+	// Installments splits a credit card invoice; zero means a single payment.
+	Installments int `json:"installments,omitempty"`
+	// CustomerID sets the payer; SavedCardID charges one of its saved cards.
+	CustomerID  string `json:"customer_id,omitempty"`
+	SavedCardID string `json:"saved_card_id,omitempty"`
+	// Items, when given, must add up to Amount. ExternalReference is the
+	// merchant's own ID, unique per account. Repeating IdempotencyKey returns
+	// the invoice first created with it instead of charging again.
+	Items             []LineItem        `json:"items,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	ExternalReference string            `json:"external_reference,omitempty"`
+	IdempotencyKey    string            `json:"idempotency_key,omitempty"`
+}
+
+// LineItem is one product of an invoice.
+type LineItem struct {
+	SKU       string  `json:"sku"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
 }
 
-// InvoiceOutput is the output DTO for invoice responses.
+// InvoiceOutput is the output DTO for invoice responses. Amount is the price
+// and TotalAmount what the customer pays, installment interest included;
+// only NetAmount is credited to the account. RiskDecision is the outcome of
+// the risk rules; a review keeps the invoice pending.
 type InvoiceOutput struct {
+	ID                string              `json:"id"`
+	AccountID         string              `json:"account_id"`
+	Amount            float64             `json:"amount"`
+	Installments      int                 `json:"installments"`
+	TotalAmount       float64             `json:"total_amount"`
+	Schedule          []InstallmentOutput `json:"installment_schedule,omitempty"`
+	Fee               float64             `json:"fee"`
+	NetAmount         float64             `json:"net_amount"`
+	Status            string              `json:"status" openapi:"enum=pending|approved|rejected"`
+	Description       string              `json:"description"`
+	PaymentType       string              `json:"payment_type"`
+	CardLastDigits    string              `json:"card_last_digits,omitempty"`
+	CustomerID        string              `json:"customer_id,omitempty"`
+	SavedCardID       string              `json:"saved_card_id,omitempty"`
+	Items             []LineItem          `json:"items,omitempty"`
+	Metadata          map[string]string   `json:"metadata,omitempty"`
+	ExternalReference string              `json:"external_reference,omitempty"`
+	IdempotencyKey    string              `json:"idempotency_key,omitempty"`
+	RiskDecision      string              `json:"risk_decision" openapi:"enum=approve|review|reject"`
+	RiskReasons       []string            `json:"risk_reasons,omitempty"`
+	CreatedAt         time.Time           `json:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at"`
+}
+
+// InvoiceBatchItem is one invoice of a batch. Err is set by the caller when
+// the invoice could not be read; the item then fails with it, uncreated.
+type InvoiceBatchItem struct {
+	Input InvoiceCreateInput
+	Err   error
+}
+
+// InvoiceBatchResult is the outcome of one invoice of a batch: the invoice,
+// or the error that kept it from being created.
+type InvoiceBatchResult struct {
+	Invoice  *InvoiceOutput
+	Replayed bool // the idempotency key matched an invoice created before
+	Err      error
+}
+
+// InstallmentOutput is one installment of an invoice.
+type InstallmentOutput struct {
+	Number int       `json:"number"`
+	Amount float64   `json:"amount"`
+	DueAt  time.Time `json:"due_at"`
+}
+
+// InstallmentRulesInput is the input DTO to set the installment rules of an
+// account.
+type InstallmentRulesInput struct {
+	APIKey           string  `json:"-"`
+	MaxInstallments  int     `json:"max_installments"`
+	FreeInstallments int     `json:"free_installments"`
+	MonthlyInterest  float64 `json:"monthly_interest"`
+}
+
+// InstallmentRulesOutput is the output DTO for installment rules. Above
+// FreeInstallments, MonthlyInterest (percent) is added to the invoice total.
+type InstallmentRulesOutput struct {
+	MaxInstallments  int        `json:"max_installments"`
+	FreeInstallments int        `json:"free_installments"`
+	MonthlyInterest  float64    `json:"monthly_interest"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty"` // unset for the default rules
+}
+
+// V2 DTOs represent money as integer cents instead of decimal floats.
+
+// InvoiceCreateInputV2 is the /v2 input DTO to create an invoice.
+type InvoiceCreateInputV2 struct {
+	AmountCents       int64             `json:"amount_cents"`
+	Description       string            `json:"description"`
+	PaymentType       string            `json:"payment_type"`
+	CardLastDigits    string            `json:"card_last_digits,omitempty"`
+	Installments      int               `json:"installments,omitempty"`
+	CustomerID        string            `json:"customer_id,omitempty"`
+	SavedCardID       string            `json:"saved_card_id,omitempty"`
+	Items             []LineItemV2      `json:"items,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	ExternalReference string            `json:"external_reference,omitempty"`
+	IdempotencyKey    string            `json:"idempotency_key,omitempty"`
+}
+
+// LineItemV2 is the /v2 form of a LineItem.
+type LineItemV2 struct {
+	SKU            string `json:"sku"`
+	Quantity       int    `json:"quantity"`
+	UnitPriceCents int64  `json:"unit_price_cents"`
+}
+
+// ToV1 converts the input to the service input for the given API key.
+func (in InvoiceCreateInputV2) ToV1(apiKey string) InvoiceCreateInput {
+	var items []LineItem
+	for _, it := range in.Items {
+		items = append(items, LineItem{SKU: it.SKU, Quantity: it.Quantity, UnitPrice: FromCents(it.UnitPriceCents)})
+	}
+	return InvoiceCreateInput{
+		APIKey:            apiKey,
+		Amount:            FromCents(in.AmountCents),
+		Description:       in.Description,
+		PaymentType:       in.PaymentType,
+		CardLastDigits:    in.CardLastDigits,
+		Installments:      in.Installments,
+		CustomerID:        in.CustomerID,
+		SavedCardID:       in.SavedCardID,
+		Items:             items,
+		Metadata:          in.Metadata,
+		ExternalReference: in.ExternalReference,
+		IdempotencyKey:    in.IdempotencyKey,
+	}
+}
+
+// AccountOutputV2 is the /v2 output DTO for account responses.
+type AccountOutputV2 struct {
+	ID                  string    `json:"id"`
+	Name                string    `json:"name"`
+	Email               string    `json:"email"`
+	APIKey              string    `json:"api_key"`
+	BalanceCents        int64     `json:"balance_cents"`
+	PendingBalanceCents int64     `json:"pending_balance_cents"`
+	Status              string    `json:"status" openapi:"enum=active|suspended|closed"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// NewAccountOutputV2 converts a v1 account output.
+func NewAccountOutputV2(o *AccountOutput) *AccountOutputV2 {
+	return &AccountOutputV2{
+		ID:                  o.ID,
+		Name:                o.Name,
+		Email:               o.Email,
+		APIKey:              o.APIKey,
+		BalanceCents:        ToCents(o.Balance),
+		PendingBalanceCents: ToCents(o.PendingBalance),
+		Status:              o.Status,
+		CreatedAt:           o.CreatedAt,
+		UpdatedAt:           o.UpdatedAt,
+	}
+}
+
+// InvoiceOutputV2 is the /v2 output DTO for invoice responses.
+type InvoiceOutputV2 struct {
+	ID                string                `json:"id"`
+	AccountID         string                `json:"account_id"`
+	AmountCents       int64                 `json:"amount_cents"`
+	Installments      int                   `json:"installments"`
+	TotalAmountCents  int64                 `json:"total_amount_cents"`
+	Schedule          []InstallmentOutputV2 `json:"installment_schedule,omitempty"`
+	FeeCents          int64                 `json:"fee_cents"`
+	NetAmountCents    int64                 `json:"net_amount_cents"`
+	Status            string                `json:"status" openapi:"enum=pending|approved|rejected"`
+	Description       string                `json:"description"`
+	PaymentType       string                `json:"payment_type"`
+	CardLastDigits    string                `json:"card_last_digits,omitempty"`
+	CustomerID        string                `json:"customer_id,omitempty"`
+	SavedCardID       string                `json:"saved_card_id,omitempty"`
+	Items             []LineItemV2          `json:"items,omitempty"`
+	Metadata          map[string]string     `json:"metadata,omitempty"`
+	ExternalReference string                `json:"external_reference,omitempty"`
+	IdempotencyKey    string                `json:"idempotency_key,omitempty"`
+	RiskDecision      string                `json:"risk_decision" openapi:"enum=approve|review|reject"`
+	RiskReasons       []string              `json:"risk_reasons,omitempty"`
+	CreatedAt         time.Time             `json:"created_at"`
+	UpdatedAt         time.Time             `json:"updated_at"`
+}
+
+// NewInvoiceOutputV2 converts a v1 invoice output.
+func NewInvoiceOutputV2(o *InvoiceOutput) *InvoiceOutputV2 {
+	return &InvoiceOutputV2{
+		ID:                o.ID,
+		AccountID:         o.AccountID,
+		AmountCents:       ToCents(o.Amount),
+		Installments:      o.Installments,
+		TotalAmountCents:  ToCents(o.TotalAmount),
+		Schedule:          newInstallmentOutputsV2(o.Schedule),
+		FeeCents:          ToCents(o.Fee),
+		NetAmountCents:    ToCents(o.NetAmount),
+		Status:            o.Status,
+		Description:       o.Description,
+		PaymentType:       o.PaymentType,
+		CardLastDigits:    o.CardLastDigits,
+		CustomerID:        o.CustomerID,
+		SavedCardID:       o.SavedCardID,
+		Items:             newLineItemsV2(o.Items),
+		Metadata:          o.Metadata,
+		ExternalReference: o.ExternalReference,
+		IdempotencyKey:    o.IdempotencyKey,
+		RiskDecision:      o.RiskDecision,
+		RiskReasons:       o.RiskReasons,
+		CreatedAt:         o.CreatedAt,
+		UpdatedAt:         o.UpdatedAt,
+	}
+}
+
+// InstallmentOutputV2 is the /v2 output DTO of one installment.
+type InstallmentOutputV2 struct {
+	Number      int       `json:"number"`
+	AmountCents int64     `json:"amount_cents"`
+	DueAt       time.Time `json:"due_at"`
+}
+
+func newInstallmentOutputsV2(in []InstallmentOutput) []InstallmentOutputV2 {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]InstallmentOutputV2, len(in))
+	for k, i := range in {
+		out[k] = InstallmentOutputV2{Number: i.Number, AmountCents: ToCents(i.Amount), DueAt: i.DueAt}
+	}
+	return out
+}
+
+func newLineItemsV2(in []LineItem) []LineItemV2 {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]LineItemV2, len(in))
+	for k, it := range in {
+		out[k] = LineItemV2{SKU: it.SKU, Quantity: it.Quantity, UnitPriceCents: ToCents(it.UnitPrice)}
+	}
+	return out
+}
+
+// ToCents converts a decimal amount to cents, rounding to the nearest cent.
+func ToCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// FromCents converts cents to a decimal amount.
+func FromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// AdminAccountOutput is the admin view of an account. The API key is never
+// exposed to operators.
+type AdminAccountOutput struct {
 	ID             string    `json:"id"`
-	AccountID      string    `json:"account_id"`
-	Amount         float64   `json:"amount"`
-	Status         string    `json:"status"`
-	Description    string    `json:"description"`
-	PaymentType    string    `json:"payment_type"`
-	CardLastDigits string    `json:"card_last_digits,omitempty"`
+	Name           string    `json:"name"`
+	Email          string    `json:"email"`
+	Status         string    `json:"status" openapi:"enum=active|suspended|closed"`
+	Balance        float64   `json:"balance"`
+	PendingBalance float64   `json:"pending_balance"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
+
+// AccountListInput filters and paginates the admin account listing.
+type AccountListInput struct {
+	Query  string
+	Status string
+	Limit  int
+	Offset int
+}
+
+// AccountListOutput is a page of the admin account listing.
+type AccountListOutput struct {
+	Items  []*AdminAccountOutput `json:"items"`
+	Total  int                   `json:"total"`
+	Limit  int                   `json:"limit"`
+	Offset int                   `json:"offset"`
+}
+
+// BalanceAdjustmentInput is the input DTO of a manual balance adjustment.
+// AccountID comes from the path and Actor from the X-Admin-Actor header.
+type BalanceAdjustmentInput struct {
+	AccountID string  `json:"-"`
+	Amount    float64 `json:"amount"`
+	Reason    string  `json:"reason"`
+	Actor     string  `json:"-"`
+}
+
+// BalanceAdjustmentOutput is the output DTO of a balance adjustment.
+type BalanceAdjustmentOutput struct {
+	ID           string    `json:"id"`
+	AccountID    string    `json:"account_id"`
+	Amount       float64   `json:"amount"`
+	BalanceAfter float64   `json:"balance_after"`
+	Reason       string    `json:"reason"`
+	Actor        string    `json:"actor"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// FeePlanInput sets the fee plan of an account for a payment type. Both come
+// from the path.
+type FeePlanInput struct {
+	AccountID   string  `json:"-"`
+	PaymentType string  `json:"-"`
+	Percent     float64 `json:"percent"`
+	Fixed       float64 `json:"fixed"`
+}
+
+// FeePlanOutput is the output DTO of a fee plan.
+type FeePlanOutput struct {
+	AccountID   string    `json:"account_id"`
+	PaymentType string    `json:"payment_type"`
+	Percent     float64   `json:"percent"`
+	Fixed       float64   `json:"fixed"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// RevenueInput selects the period [From, To) of the revenue report. Zero
+// values default to the current month.
+type RevenueInput struct {
+	From time.Time
+	To   time.Time
+}
+
+// RevenueLineOutput sums the fees collected on one payment type.
+type RevenueLineOutput struct {
+	PaymentType string  `json:"payment_type"`
+	Invoices    int     `json:"invoices"`
+	Gross       float64 `json:"gross"`
+	Fees        float64 `json:"fees"`
+}
+
+// RevenueOutput is the platform revenue report of a period.
+type RevenueOutput struct {
+	From     time.Time            `json:"from"`
+	To       time.Time            `json:"to"`
+	Items    []*RevenueLineOutput `json:"items"`
+	Invoices int                  `json:"invoices"`
+	Gross    float64              `json:"gross"`
+	Fees     float64              `json:"fees"`
+}
+
+// BankAccountInput is the input DTO to register a payout destination.
+type BankAccountInput struct {
+	APIKey     string `json:"-"`
+	BankCode   string `json:"bank_code"`
+	Branch     string `json:"branch"`
+	Number     string `json:"number"`
+	HolderName string `json:"holder_name"`
+}
+
+// BankAccountOutput is the output DTO for bank account responses.
+type BankAccountOutput struct {
+	ID         string    `json:"id"`
+	BankCode   string    `json:"bank_code"`
+	Branch     string    `json:"branch"`
+	Number     string    `json:"number"`
+	HolderName string    `json:"holder_name"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PayoutCreateInput is the input DTO to withdraw from the balance.
+type PayoutCreateInput struct {
+	APIKey        string  `json:"-"`
+	BankAccountID string  `json:"bank_account_id"`
+	Amount        float64 `json:"amount"`
+}
+
+// PayoutOutput is the output DTO for payout responses.
+type PayoutOutput struct {
+	ID            string    `json:"id"`
+	BankAccountID string    `json:"bank_account_id"`
+	Amount        float64   `json:"amount"`
+	Status        string    `json:"status" openapi:"enum=pending|processing|paid|failed"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PayoutCreateInputV2 is the /v2 input DTO to withdraw from the balance.
+type PayoutCreateInputV2 struct {
+	BankAccountID string `json:"bank_account_id"`
+	AmountCents   int64  `json:"amount_cents"`
+}
+
+// ToV1 converts the input to the service input for the given API key.
+func (in PayoutCreateInputV2) ToV1(apiKey string) PayoutCreateInput {
+	return PayoutCreateInput{APIKey: apiKey, BankAccountID: in.BankAccountID, Amount: FromCents(in.AmountCents)}
+}
+
+// PayoutOutputV2 is the /v2 output DTO for payout responses.
+type PayoutOutputV2 struct {
+	ID            string    `json:"id"`
+	BankAccountID string    `json:"bank_account_id"`
+	AmountCents   int64     `json:"amount_cents"`
+	Status        string    `json:"status" openapi:"enum=pending|processing|paid|failed"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// NewPayoutOutputV2 converts a v1 payout output.
+func NewPayoutOutputV2(o *PayoutOutput) *PayoutOutputV2 {
+	return &PayoutOutputV2{
+		ID:            o.ID,
+		BankAccountID: o.BankAccountID,
+		AmountCents:   ToCents(o.Amount),
+		Status:        o.Status,
+		FailureReason: o.FailureReason,
+		CreatedAt:     o.CreatedAt,
+		UpdatedAt:     o.UpdatedAt,
+	}
+}
+
+// PlanInput is the input DTO to create a subscription plan. IntervalCount
+// defaults to 1.
+type PlanInput struct {
+	APIKey        string  `json:"-"`
+	Name          string  `json:"name"`
+	Amount        float64 `json:"amount"`
+	Interval      string  `json:"interval" openapi:"enum=day|week|month|year"`
+	IntervalCount int     `json:"interval_count,omitempty"`
+	TrialDays     int     `json:"trial_days,omitempty"`
+}
+
+// PlanOutput is the output DTO for plan responses.
+type PlanOutput struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Amount        float64   `json:"amount"`
+	Interval      string    `json:"interval" openapi:"enum=day|week|month|year"`
+	IntervalCount int       `json:"interval_count"`
+	TrialDays     int       `json:"trial_days"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SubscriptionInput is the input DTO to subscribe a tokenized card to a
+// plan. Without an anchor date the first cycle starts now.
+type SubscriptionInput struct {
+	APIKey         string     `json:"-"`
+	PlanID         string     `json:"plan_id"`
+	CardToken      string     `json:"card_token"`
+	CardLastDigits string     `json:"card_last_digits"`
+	AnchorDate     *time.Time `json:"anchor_date,omitempty"`
+}
+
+// SubscriptionOutput is the output DTO for subscription responses. The card
+// token is never echoed back. NextBillingAt is omitted once canceled.
+type SubscriptionOutput struct {
+	ID             string     `json:"id"`
+	PlanID         string     `json:"plan_id"`
+	Description    string     `json:"description"`
+	Amount         float64    `json:"amount"`
+	Interval       string     `json:"interval" openapi:"enum=day|week|month|year"`
+	IntervalCount  int        `json:"interval_count"`
+	CardLastDigits string     `json:"card_last_digits"`
+	Status         string     `json:"status" openapi:"enum=trialing|active|past_due|canceled"`
+	AnchorAt       time.Time  `json:"anchor_at"`
+	TrialEndsAt    *time.Time `json:"trial_ends_at,omitempty"`
+	NextBillingAt  *time.Time `json:"next_billing_at,omitempty"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LastInvoiceID  string     `json:"last_invoice_id,omitempty"`
+	CanceledAt     *time.Time `json:"canceled_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// PlanInputV2 is the /v2 input DTO to create a subscription plan.
+type PlanInputV2 struct {
+	Name          string `json:"name"`
+	AmountCents   int64  `json:"amount_cents"`
+	Interval      string `json:"interval" openapi:"enum=day|week|month|year"`
+	IntervalCount int    `json:"interval_count,omitempty"`
+	TrialDays     int    `json:"trial_days,omitempty"`
+}
+
+// ToV1 converts the input to the service input for the given API key.
+func (in PlanInputV2) ToV1(apiKey string) PlanInput {
+	return PlanInput{
+		APIKey:        apiKey,
+		Name:          in.Name,
+		Amount:        FromCents(in.AmountCents),
+		Interval:      in.Interval,
+		IntervalCount: in.IntervalCount,
+		TrialDays:     in.TrialDays,
+	}
+}
+
+// PlanOutputV2 is the /v2 output DTO for plan responses.
+type PlanOutputV2 struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	AmountCents   int64     `json:"amount_cents"`
+	Interval      string    `json:"interval" openapi:"enum=day|week|month|year"`
+	IntervalCount int       `json:"interval_count"`
+	TrialDays     int       `json:"trial_days"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// NewPlanOutputV2 converts a v1 plan output.
+func NewPlanOutputV2(o *PlanOutput) *PlanOutputV2 {
+	return &PlanOutputV2{
+		ID:            o.ID,
+		Name:          o.Name,
+		AmountCents:   ToCents(o.Amount),
+		Interval:      o.Interval,
+		IntervalCount: o.IntervalCount,
+		TrialDays:     o.TrialDays,
+		CreatedAt:     o.CreatedAt,
+	}
+}
+
+// SubscriptionOutputV2 is the /v2 output DTO for subscription responses.
+type SubscriptionOutputV2 struct {
+	ID             string     `json:"id"`
+	PlanID         string     `json:"plan_id"`
+	Description    string     `json:"description"`
+	AmountCents    int64      `json:"amount_cents"`
+	Interval       string     `json:"interval" openapi:"enum=day|week|month|year"`
+	IntervalCount  int        `json:"interval_count"`
+	CardLastDigits string     `json:"card_last_digits"`
+	Status         string     `json:"status" openapi:"enum=trialing|active|past_due|canceled"`
+	AnchorAt       time.Time  `json:"anchor_at"`
+	TrialEndsAt    *time.Time `json:"trial_ends_at,omitempty"`
+	NextBillingAt  *time.Time `json:"next_billing_at,omitempty"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LastInvoiceID  string     `json:"last_invoice_id,omitempty"`
+	CanceledAt     *time.Time `json:"canceled_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// NewSubscriptionOutputV2 converts a v1 subscription output.
+func NewSubscriptionOutputV2(o *SubscriptionOutput) *SubscriptionOutputV2 {
+	return &SubscriptionOutputV2{
+		ID:             o.ID,
+		PlanID:         o.PlanID,
+		Description:    o.Description,
+		AmountCents:    ToCents(o.Amount),
+		Interval:       o.Interval,
+		IntervalCount:  o.IntervalCount,
+		CardLastDigits: o.CardLastDigits,
+		Status:         o.Status,
+		AnchorAt:       o.AnchorAt,
+		TrialEndsAt:    o.TrialEndsAt,
+		NextBillingAt:  o.NextBillingAt,
+		FailedAttempts: o.FailedAttempts,
+		LastInvoiceID:  o.LastInvoiceID,
+		CanceledAt:     o.CanceledAt,
+		CreatedAt:      o.CreatedAt,
+		UpdatedAt:      o.UpdatedAt,
+	}
+}
+
+// CheckoutSessionInput is the input DTO to create a checkout session.
+// Without an expiry the session expires after 24 hours.
+type CheckoutSessionInput struct {
+	APIKey       string     `json:"-"`
+	Amount       float64    `json:"amount"`
+	Description  string     `json:"description"`
+	PaymentTypes []string   `json:"payment_types"`
+	SuccessURL   string     `json:"success_url" openapi:"format=uri"`
+	CancelURL    string     `json:"cancel_url,omitempty" openapi:"format=uri"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// CheckoutSessionOutput is the merchant view of a checkout session.
+// CheckoutPath is the public page to share with the payer.
+type CheckoutSessionOutput struct {
+	ID           string     `json:"id"`
+	CheckoutPath string     `json:"checkout_path"`
+	Amount       float64    `json:"amount"`
+	Description  string     `json:"description"`
+	PaymentTypes []string   `json:"payment_types"`
+	SuccessURL   string     `json:"success_url"`
+	CancelURL    string     `json:"cancel_url,omitempty"`
+	Status       string     `json:"status" openapi:"enum=open|completed|expired"`
+	InvoiceID    string     `json:"invoice_id,omitempty"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CheckoutOutput is the public view of a checkout session shown to the
+// payer. It never exposes the merchant account or its invoices.
+type CheckoutOutput struct {
+	ID           string    `json:"id"`
+	MerchantName string    `json:"merchant_name"`
+	Amount       float64   `json:"amount"`
+	Description  string    `json:"description"`
+	PaymentTypes []string  `json:"payment_types"`
+	Status       string    `json:"status" openapi:"enum=open|completed|expired"`
+	SuccessURL   string    `json:"success_url"`
+	CancelURL    string    `json:"cancel_url,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// CheckoutPayInput is the input DTO to pay a checkout session.
+type CheckoutPayInput struct {
+	// SessionID is taken from the path, not from the body.
+	SessionID      string `json:"-"`
+	PaymentType    string `json:"payment_type"`
+	CardLastDigits string `json:"card_last_digits,omitempty"`
+}
+
+// CheckoutPayOutput is the outcome of a checkout payment. RedirectURL is the
+// success URL once the session is completed; a rejected payment leaves the
+// session open for another attempt.
+type CheckoutPayOutput struct {
+	SessionID   string `json:"session_id"`
+	InvoiceID   string `json:"invoice_id"`
+	Status      string `json:"status" openapi:"enum=pending|approved|rejected"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+}
+
+// CheckoutSessionInputV2 is the /v2 input DTO to create a checkout session.
+type CheckoutSessionInputV2 struct {
+	AmountCents  int64      `json:"amount_cents"`
+	Description  string     `json:"description"`
+	PaymentTypes []string   `json:"payment_types"`
+	SuccessURL   string     `json:"success_url" openapi:"format=uri"`
+	CancelURL    string     `json:"cancel_url,omitempty" openapi:"format=uri"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// ToV1 converts the input to the service input for the given API key.
+func (in CheckoutSessionInputV2) ToV1(apiKey string) CheckoutSessionInput {
+	return CheckoutSessionInput{
+		APIKey:       apiKey,
+		Amount:       FromCents(in.AmountCents),
+		Description:  in.Description,
+		PaymentTypes: in.PaymentTypes,
+		SuccessURL:   in.SuccessURL,
+		CancelURL:    in.CancelURL,
+		ExpiresAt:    in.ExpiresAt,
+	}
+}
+
+// CheckoutSessionOutputV2 is the /v2 merchant view of a checkout session.
+type CheckoutSessionOutputV2 struct {
+	ID           string     `json:"id"`
+	CheckoutPath string     `json:"checkout_path"`
+	AmountCents  int64      `json:"amount_cents"`
+	Description  string     `json:"description"`
+	PaymentTypes []string   `json:"payment_types"`
+	SuccessURL   string     `json:"success_url"`
+	CancelURL    string     `json:"cancel_url,omitempty"`
+	Status       string     `json:"status" openapi:"enum=open|completed|expired"`
+	InvoiceID    string     `json:"invoice_id,omitempty"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// NewCheckoutSessionOutputV2 converts a v1 checkout session output; the
+// checkout path is moved to /v2.
+func NewCheckoutSessionOutputV2(o *CheckoutSessionOutput) *CheckoutSessionOutputV2 {
+	return &CheckoutSessionOutputV2{
+		ID:           o.ID,
+		CheckoutPath: "/v2/checkout/" + o.ID,
+		AmountCents:  ToCents(o.Amount),
+		Description:  o.Description,
+		PaymentTypes: o.PaymentTypes,
+		SuccessURL:   o.SuccessURL,
+		CancelURL:    o.CancelURL,
+		Status:       o.Status,
+		InvoiceID:    o.InvoiceID,
+		ExpiresAt:    o.ExpiresAt,
+		CompletedAt:  o.CompletedAt,
+		CreatedAt:    o.CreatedAt,
+	}
+}
+
+// CheckoutOutputV2 is the /v2 public view of a checkout session.
+type CheckoutOutputV2 struct {
+	ID           string    `json:"id"`
+	MerchantName string    `json:"merchant_name"`
+	AmountCents  int64     `json:"amount_cents"`
+	Description  string    `json:"description"`
+	PaymentTypes []string  `json:"payment_types"`
+	Status       string    `json:"status" openapi:"enum=open|completed|expired"`
+	SuccessURL   string    `json:"success_url"`
+	CancelURL    string    `json:"cancel_url,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// NewCheckoutOutputV2 converts a v1 public checkout output.
+func NewCheckoutOutputV2(o *CheckoutOutput) *CheckoutOutputV2 {
+	return &CheckoutOutputV2{
+		ID:           o.ID,
+		MerchantName: o.MerchantName,
+		AmountCents:  ToCents(o.Amount),
+		Description:  o.Description,
+		PaymentTypes: o.PaymentTypes,
+		Status:       o.Status,
+		SuccessURL:   o.SuccessURL,
+		CancelURL:    o.CancelURL,
+		ExpiresAt:    o.ExpiresAt,
+	}
+}
+
+// DisputeOpenInput is the input DTO of the admin endpoint that opens a
+// dispute, standing in for the card network. InvoiceID comes from the path;
+// without an amount the whole invoice total is disputed.
+type DisputeOpenInput struct {
+	InvoiceID string  `json:"-"`
+	Amount    float64 `json:"amount,omitempty"`
+	Reason    string  `json:"reason" openapi:"enum=fraudulent|duplicate|product_not_received|product_unacceptable|unrecognized|general"`
+}
+
+// DisputeEvidenceInput is the input DTO of the merchant's defense.
+type DisputeEvidenceInput struct {
+	APIKey   string `json:"-"`
+	ID       string `json:"-"`
+	Evidence string `json:"evidence"`
+}
+
+// DisputeResolveInput is the input DTO to resolve a dispute. ID comes from
+// the path.
+type DisputeResolveInput struct {
+	ID      string `json:"-"`
+	Outcome string `json:"outcome" openapi:"enum=won|lost"`
+}
+
+// DisputeOutput is the output DTO for dispute responses. Amount is frozen
+// from the balance while the dispute is open and given back only if won.
+type DisputeOutput struct {
+	ID                  string     `json:"id"`
+	InvoiceID           string     `json:"invoice_id"`
+	Amount              float64    `json:"amount"`
+	Reason              string     `json:"reason" openapi:"enum=fraudulent|duplicate|product_not_received|product_unacceptable|unrecognized|general"`
+	Status              string     `json:"status" openapi:"enum=needs_response|under_review|won|lost"`
+	Evidence            string     `json:"evidence,omitempty"`
+	EvidenceDueBy       time.Time  `json:"evidence_due_by"`
+	EvidenceSubmittedAt *time.Time `json:"evidence_submitted_at,omitempty"`
+	ResolvedAt          *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// DisputeOutputV2 is the /v2 output DTO for dispute responses.
+type DisputeOutputV2 struct {
+	ID                  string     `json:"id"`
+	InvoiceID           string     `json:"invoice_id"`
+	AmountCents         int64      `json:"amount_cents"`
+	Reason              string     `json:"reason" openapi:"enum=fraudulent|duplicate|product_not_received|product_unacceptable|unrecognized|general"`
+	Status              string     `json:"status" openapi:"enum=needs_response|under_review|won|lost"`
+	Evidence            string     `json:"evidence,omitempty"`
+	EvidenceDueBy       time.Time  `json:"evidence_due_by"`
+	EvidenceSubmittedAt *time.Time `json:"evidence_submitted_at,omitempty"`
+	ResolvedAt          *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// NewDisputeOutputV2 converts a v1 dispute output.
+func NewDisputeOutputV2(o *DisputeOutput) *DisputeOutputV2 {
+	return &DisputeOutputV2{
+		ID:                  o.ID,
+		InvoiceID:           o.InvoiceID,
+		AmountCents:         ToCents(o.Amount),
+		Reason:              o.Reason,
+		Status:              o.Status,
+		Evidence:            o.Evidence,
+		EvidenceDueBy:       o.EvidenceDueBy,
+		EvidenceSubmittedAt: o.EvidenceSubmittedAt,
+		ResolvedAt:          o.ResolvedAt,
+		CreatedAt:           o.CreatedAt,
+		UpdatedAt:           o.UpdatedAt,
+	}
+}
+
+// CustomerInput is the input DTO to register a customer. Document is a CPF,
+// with or without punctuation.
+type CustomerInput struct {
+	APIKey   string `json:"-"`
+	Name     string `json:"name"`
+	Email    string `json:"email" openapi:"format=email"`
+	Document string `json:"document"`
+}
+
+// CustomerOutput is the output DTO for customer responses. Document is the
+// CPF as digits.
+type CustomerOutput struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Document  string    `json:"document"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SavedCardInput is the input DTO to save a tokenized card for a customer.
+// CustomerID comes from the path.
+type SavedCardInput struct {
+	APIKey         string `json:"-"`
+	CustomerID     string `json:"-"`
+	CardToken      string `json:"card_token"`
+	CardLastDigits string `json:"card_last_digits"`
+}
+
+// SavedCardOutput is the output DTO for saved cards. The card token is never
+// echoed back.
+type SavedCardOutput struct {
+	ID             string    `json:"id"`
+	CustomerID     string    `json:"customer_id"`
+	CardLastDigits string    `json:"card_last_digits"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package service
+
+import "testing"
+
+func TestCentsConversion(t *testing.T) {
+	tests := []struct {
+		amount float64
+		cents  int64
+	}{
+		{0, 0},
+		{0.01, 1},
+		{100.5, 10050},
+		{0.1 + 0.2, 30},
+		{1234.56, 123456},
+		{99999999.99, 9999999999},
+	}
+	for _, tt := range tests {
+		if got := ToCents(tt.amount); got != tt.cents {
+			t.Errorf("ToCents(%v) = %d, want %d", tt.amount, got, tt.cents)
+		}
+	}
+	if got := FromCents(10050); got != 100.5 {
+		t.Errorf("FromCents(10050) = %v, want 100.5", got)
+	}
+}
@@ -3,6 +3,10 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"maps"
+	"sync"
+	"time"
 
 	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
 	pg "github.com/devfullcycle/imersao22/go-gateway/internal/repository/postgres"
@@ -11,7 +15,6 @@ import (
 // AccountServicePort defines the interface for AccountService methods needed by InvoiceService
 type AccountServicePort interface {
 	GetByAPIKey(ctx context.Context, apiKey string) (*AccountOutput, error)
-	UpdateBalance(ctx context.Context, apiKey string, amount float64) error
 }
 
 // InvoiceService implements domain.InvoiceRepository by delegating to a Postgres repository
@@ -20,6 +23,15 @@ type InvoiceService struct {
 	repo           domain.InvoiceRepository
 	accountService AccountServicePort
 	processor      domain.InvoiceProcessor // Custom processor for testing
+	fees           domain.FeeRepository
+	defaultFee     domain.FeePlan // charged when the account has no plan for the payment type
+	schedule       domain.SettlementSchedule
+	risk           *RiskService
+	installments   domain.InstallmentRulesRepository
+	customers      domain.CustomerRepository
+	clock          domain.Clock
+	batchMaxItems  int // invoices accepted by CreateBatch
+	batchWorkers   int // invoices of a batch created at a time
 }
 
 func NewInvoiceService(db *sql.DB) *InvoiceService {
@@ -27,6 +39,13 @@ func NewInvoiceService(db *sql.DB) *InvoiceService {
 		repo:           pg.NewPostgresInvoiceRepository(db),
 		accountService: NewAccountService(db),
 		processor:      nil, // Use default processor
+		fees:           pg.NewPostgresFeeRepository(db),
+		risk:           NewRiskService(StaticRiskRules(domain.DefaultRiskRules())),
+		installments:   pg.NewPostgresInstallmentRulesRepository(db),
+		customers:      pg.NewPostgresCustomerRepository(db),
+		clock:          domain.SystemClock,
+		batchMaxItems:  domain.DefaultBatchMaxItems,
+		batchWorkers:   domain.DefaultBatchWorkers,
 	}
 }
 
@@ -36,19 +55,169 @@ func NewInvoiceServiceWithAccountService(db *sql.DB, accountService AccountServi
 		repo:           pg.NewPostgresInvoiceRepository(db),
 		accountService: accountService,
 		processor:      nil, // Use default processor
+		fees:           pg.NewPostgresFeeRepository(db),
+		risk:           NewRiskService(StaticRiskRules(domain.DefaultRiskRules())),
+		installments:   pg.NewPostgresInstallmentRulesRepository(db),
+		customers:      pg.NewPostgresCustomerRepository(db),
+		clock:          domain.SystemClock,
+		batchMaxItems:  domain.DefaultBatchMaxItems,
+		batchWorkers:   domain.DefaultBatchWorkers,
 	}
 }
 
+// SetBatchLimits sets how many invoices CreateBatch accepts and how many of
+// them it creates at a time.
+func (s *InvoiceService) SetBatchLimits(maxItems, workers int) {
+	s.batchMaxItems = maxItems
+	s.batchWorkers = workers
+}
+
 // SetProcessor allows setting a custom processor for testing
 func (s *InvoiceService) SetProcessor(processor domain.InvoiceProcessor) {
 	s.processor = processor
 }
 
+// SetClock sets the clock that stamps new invoices, and the balance changes
+// they make when the account service accepts one.
+func (s *InvoiceService) SetClock(c domain.Clock) {
+	s.clock = c
+	if a, ok := s.accountService.(interface{ SetClock(domain.Clock) }); ok {
+		a.SetClock(c)
+	}
+}
+
+// SetDefaultFeePlan sets the fee charged to accounts without a plan for the
+// payment type of the invoice.
+func (s *InvoiceService) SetDefaultFeePlan(p domain.FeePlan) {
+	s.defaultFee = p
+}
+
+// SetSettlementSchedule sets how long approved money of each payment type
+// stays pending before it becomes available. Without it, it is available
+// immediately.
+func (s *InvoiceService) SetSettlementSchedule(schedule domain.SettlementSchedule) {
+	s.schedule = schedule
+}
+
+// SetRiskService replaces the risk rules evaluated before processing. By
+// default invoices above domain.DefaultRiskThreshold are held for review.
+func (s *InvoiceService) SetRiskService(risk *RiskService) {
+	s.risk = risk
+}
+
+// assessRisk runs the risk rules in effect on a new invoice, on the amount
+// charged to the card including installment interest. The card velocity is
+// only counted when a rule needs it.
+func (s *InvoiceService) assessRisk(ctx context.Context, account *AccountOutput, i *domain.Invoice) (domain.RiskAssessment, error) {
+	if s.risk == nil {
+		return domain.RiskAssessment{Decision: domain.RiskApprove}, nil
+	}
+	rules := s.risk.Rules()
+	in := domain.RiskInput{
+		AccountID:        account.ID,
+		AccountCreatedAt: account.CreatedAt,
+		Amount:           i.TotalAmount,
+		PaymentType:      i.PaymentType,
+		CardLastDigits:   i.CardLastDigits,
+		Now:              i.CreatedAt,
+	}
+	if i.CardLastDigits != "" && domain.UsesCardVelocity(rules) {
+		n, err := s.repo.CountByCardSince(ctx, account.ID, i.CardLastDigits, i.CreatedAt.Add(-time.Hour))
+		if err != nil {
+			return domain.RiskAssessment{}, err
+		}
+		in.CardUsesLastHour = n
+	}
+	return domain.EvaluateRisk(rules, in), nil
+}
+
+// feePlan returns the plan of the account for a payment type, falling back
+// to the default plan.
+func (s *InvoiceService) feePlan(ctx context.Context, accountID, paymentType string) (domain.FeePlan, error) {
+	p, err := s.fees.GetPlan(ctx, accountID, paymentType)
+	if errors.Is(err, domain.ErrFeePlanNotFound) {
+		return s.defaultFee, nil
+	}
+	if err != nil {
+		return domain.FeePlan{}, err
+	}
+	return *p, nil
+}
+
+// installmentRules returns the installment rules of the account, falling
+// back to the default ones.
+func (s *InvoiceService) installmentRules(ctx context.Context, accountID string) (domain.InstallmentRules, error) {
+	r, err := s.installments.Get(ctx, accountID)
+	if errors.Is(err, domain.ErrInstallmentRulesNotFound) {
+		return domain.DefaultInstallmentRules(accountID), nil
+	}
+	if err != nil {
+		return domain.InstallmentRules{}, err
+	}
+	return *r, nil
+}
+
+// GetInstallmentRules returns the installment rules of the account behind
+// apiKey.
+func (s *InvoiceService) GetInstallmentRules(ctx context.Context, apiKey string) (*InstallmentRulesOutput, error) {
+	account, err := s.accountService.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AccountStatus(account.Status).CanRead(); err != nil {
+		return nil, err
+	}
+	r, err := s.installmentRules(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toInstallmentRulesOutput(r), nil
+}
+
+// SaveInstallmentRules replaces the installment rules of the account behind
+// apiKey. Invoices already created keep their schedule.
+func (s *InvoiceService) SaveInstallmentRules(ctx context.Context, in InstallmentRulesInput) (*InstallmentRulesOutput, error) {
+	account, err := s.accountService.GetByAPIKey(ctx, in.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AccountStatus(account.Status).CanWrite(); err != nil {
+		return nil, err
+	}
+	r, err := domain.NewInstallmentRules(account.ID, in.MaxInstallments, in.FreeInstallments, in.MonthlyInterest, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.installments.Save(ctx, r); err != nil {
+		return nil, err
+	}
+	return toInstallmentRulesOutput(*r), nil
+}
+
 // Create creates a new invoice from input DTO and returns an output DTO.
+// With the idempotency key of an earlier invoice it returns that one.
 func (s *InvoiceService) Create(ctx context.Context, in InvoiceCreateInput) (*InvoiceOutput, error) {
+	out, _, err := s.create(ctx, in)
+	return out, err
+}
+
+// create creates an invoice, or replays the one created with its
+// idempotency key; the flag tells which.
+func (s *InvoiceService) create(ctx context.Context, in InvoiceCreateInput) (*InvoiceOutput, bool, error) {
 	accountOutput, err := s.accountService.GetByAPIKey(ctx, in.APIKey)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	// Suspended accounts keep read access but cannot charge
+	if err := domain.AccountStatus(accountOutput.Status).CanWrite(); err != nil {
+		return nil, false, err
+	}
+
+	if in.IdempotencyKey != "" {
+		out, replayed, err := s.replay(ctx, accountOutput.ID, in)
+		if err == nil || !errors.Is(err, domain.ErrInvoiceNotFound) {
+			return out, replayed, err
+		}
 	}
 
 	var invoice *domain.Invoice
@@ -56,38 +225,260 @@ func (s *InvoiceService) Create(ctx context.Context, in InvoiceCreateInput) (*In
 
 	if s.processor != nil {
 		// Use custom processor for testing
-		invoice, err2 = domain.NewInvoiceWithProcessor(accountOutput.ID, in.Description, in.PaymentType, in.Amount, in.CardLastDigits, s.processor)
+		invoice, err2 = domain.NewInvoiceWithProcessor(accountOutput.ID, in.Description, in.PaymentType, in.Amount, in.CardLastDigits, s.processor, s.clock)
 	} else {
 		// Use default processor
-		invoice, err2 = domain.NewInvoice(accountOutput.ID, in.Description, in.PaymentType, in.Amount, in.CardLastDigits)
+		invoice, err2 = domain.NewInvoice(accountOutput.ID, in.Description, in.PaymentType, in.Amount, in.CardLastDigits, s.clock)
 	}
 
 	if err2 != nil {
-		return nil, err2
+		return nil, false, err2
 	}
 
-	if err := invoice.Process(); err != nil {
-		return nil, err
+	if in.IdempotencyKey != "" {
+		if err := invoice.SetIdempotencyKey(in.IdempotencyKey); err != nil {
+			return nil, false, err
+		}
+	}
+	if in.CustomerID != "" || in.SavedCardID != "" {
+		if err := s.setCustomer(ctx, invoice, in.CustomerID, in.SavedCardID); err != nil {
+			return nil, false, err
+		}
+	}
+	if len(in.Items) > 0 || len(in.Metadata) > 0 || in.ExternalReference != "" {
+		if err := s.setDetails(ctx, invoice, in); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// A single payment needs no rules
+	if in.Installments != 0 && in.Installments != 1 {
+		rules, err := s.installmentRules(ctx, accountOutput.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := invoice.SetInstallments(in.Installments, rules); err != nil {
+			return nil, false, err
+		}
 	}
 
-	// Para transações aprovadas, atualizar o saldo
+	assessment, err := s.assessRisk(ctx, accountOutput, invoice)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := invoice.ApplyRisk(assessment); err != nil {
+		return nil, false, err
+	}
+
+	// Only invoices cleared by the risk rules reach the processor; those
+	// under review stay pending
+	if invoice.RiskDecision == domain.RiskApprove {
+		if err := invoice.Process(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// Para transações aprovadas, descontar a taxa e creditar o valor líquido
+	var charge domain.InvoiceCharge
 	if invoice.Status == domain.StatusApproved {
-		err = s.accountService.UpdateBalance(ctx, in.APIKey, invoice.Amount)
+		plan, err := s.feePlan(ctx, accountOutput.ID, invoice.PaymentType)
 		if err != nil {
-			return nil, err
+			return nil, false, err
+		}
+		if err := invoice.ApplyFee(plan); err != nil {
+			return nil, false, err
+		}
+		// A fee can take the whole amount; there is nothing to credit then
+		if invoice.NetAmount > 0 {
+			// Each installment settles on its own date; D+N money is held in
+			// the pending balance
+			for _, st := range domain.NewInstallmentSettlements(invoice, s.schedule) {
+				if st.AvailableAt.After(invoice.UpdatedAt) {
+					charge.Settlements = append(charge.Settlements, st)
+				} else {
+					charge.Credit += st.Amount
+				}
+			}
+			charge.Credit = FromCents(ToCents(charge.Credit))
 		}
+		if invoice.Fee > 0 {
+			charge.Fee = domain.NewInvoiceFee(invoice, s.clock)
+		}
+	}
+
+	// The invoice and its charge are stored together, so a reference or key
+	// taken meanwhile by another request moves no money
+	err = s.repo.CreateWithCharge(ctx, invoice, charge)
+	if errors.Is(err, domain.ErrIdempotencyKeyInUse) {
+		return s.replay(ctx, accountOutput.ID, in)
+	}
+	if err != nil {
+		return nil, false, err
 	}
 
-	if err := s.repo.Create(ctx, invoice); err != nil {
+	return toInvoiceOutput(invoice), false, nil
+}
+
+// replay returns the invoice of the account created with the idempotency
+// key of in, or domain.ErrInvoiceNotFound when there is none yet.
+func (s *InvoiceService) replay(ctx context.Context, accountID string, in InvoiceCreateInput) (*InvoiceOutput, bool, error) {
+	prior, err := s.repo.GetByIdempotencyKey(ctx, accountID, in.IdempotencyKey)
+	if err != nil {
+		return nil, false, err
+	}
+	if !sameInvoiceRequest(prior, in) {
+		return nil, false, domain.ErrIdempotencyKeyReused
+	}
+	return toInvoiceOutput(prior), true, nil
+}
+
+// sameInvoiceRequest reports whether in asks for the invoice prior was
+// created from, so reusing its idempotency key is a retry and not a mistake.
+// Every field of the request is compared as the invoice stored it.
+func sameInvoiceRequest(prior *domain.Invoice, in InvoiceCreateInput) bool {
+	installments := in.Installments
+	if installments == 0 {
+		installments = 1
+	}
+	// A saved card fills in the digits when the request leaves them out
+	card := in.CardLastDigits == prior.CardLastDigits || (in.SavedCardID != "" && in.CardLastDigits == "")
+	if len(prior.Items) != len(in.Items) {
+		return false
+	}
+	for k, it := range in.Items {
+		if prior.Items[k] != (domain.LineItem{SKU: it.SKU, Quantity: it.Quantity, UnitPrice: it.UnitPrice}) {
+			return false
+		}
+	}
+	return prior.Amount == in.Amount && prior.PaymentType == in.PaymentType && prior.Description == in.Description &&
+		card && prior.Installments == installments &&
+		prior.CustomerID == in.CustomerID && prior.SavedCardID == in.SavedCardID &&
+		prior.ExternalReference == in.ExternalReference && maps.Equal(prior.Metadata, in.Metadata)
+}
+
+// CreateBatch creates the invoices of a batch for the account behind apiKey,
+// at most batchWorkers at a time. Results follow the order of items and an
+// invoice that fails only fails its own result; the batch as a whole fails
+// only when it is empty, too large or the account cannot charge.
+func (s *InvoiceService) CreateBatch(ctx context.Context, apiKey string, items []InvoiceBatchItem) ([]InvoiceBatchResult, error) {
+	if len(items) == 0 {
+		return nil, domain.ErrEmptyBatch
+	}
+	if len(items) > s.batchMaxItems {
+		return nil, domain.ErrBatchTooLarge
+	}
+	account, err := s.accountService.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AccountStatus(account.Status).CanWrite(); err != nil {
 		return nil, err
 	}
 
-	return toInvoiceOutput(invoice), nil
+	results := make([]InvoiceBatchResult, len(items))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(s.batchWorkers, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range jobs {
+				in := items[k].Input
+				in.APIKey = apiKey
+				out, replayed, err := s.create(ctx, in)
+				results[k] = InvoiceBatchResult{Invoice: out, Replayed: replayed, Err: err}
+			}
+		}()
+	}
+
+	// Two items with one key would race to create the same invoice
+	keys := make(map[string]bool)
+	for k, item := range items {
+		if item.Err != nil {
+			results[k].Err = item.Err
+			continue
+		}
+		if key := item.Input.IdempotencyKey; key != "" {
+			if keys[key] {
+				verr := &domain.ValidationError{}
+				verr.Add("idempotency_key", domain.ErrDuplicateIdempotencyKey)
+				results[k].Err = verr
+				continue
+			}
+			keys[key] = true
+		}
+		jobs <- k
+	}
+	close(jobs)
+	wg.Wait()
+	return results, nil
+}
+
+// setCustomer attaches the payer of a new invoice and, with savedCardID, one
+// of its saved cards. Unknown references are reported on the field holding
+// them, like any other invalid input.
+func (s *InvoiceService) setCustomer(ctx context.Context, invoice *domain.Invoice, customerID, savedCardID string) error {
+	verr := &domain.ValidationError{}
+	if customerID == "" {
+		verr.Add("customer_id", domain.ErrCustomerRequired)
+		return verr
+	}
+	customer, err := s.customers.GetByID(ctx, invoice.AccountID, customerID)
+	if errors.Is(err, domain.ErrCustomerNotFound) {
+		verr.Add("customer_id", domain.ErrUnknownCustomer)
+		return verr
+	}
+	if err != nil {
+		return err
+	}
+
+	var card *domain.SavedCard
+	if savedCardID != "" {
+		card, err = s.customers.GetCard(ctx, invoice.AccountID, customer.ID, savedCardID)
+		if errors.Is(err, domain.ErrSavedCardNotFound) {
+			verr.Add("saved_card_id", domain.ErrUnknownSavedCard)
+			return verr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return invoice.SetCustomer(customer, card)
+}
+
+// setDetails records the line items, metadata and merchant reference of a new
+// invoice. A reused reference is rejected up front; one taken meanwhile by a
+// concurrent request fails the insert, which rolls back the credit with it.
+func (s *InvoiceService) setDetails(ctx context.Context, invoice *domain.Invoice, in InvoiceCreateInput) error {
+	items := make([]domain.LineItem, len(in.Items))
+	for k, it := range in.Items {
+		items[k] = domain.LineItem{SKU: it.SKU, Quantity: it.Quantity, UnitPrice: it.UnitPrice}
+	}
+	if err := invoice.SetDetails(items, in.Metadata, in.ExternalReference); err != nil {
+		return err
+	}
+	if in.ExternalReference == "" {
+		return nil
+	}
+	_, err := s.repo.GetByExternalReference(ctx, invoice.AccountID, in.ExternalReference)
+	switch {
+	case err == nil:
+		return domain.ErrExternalReferenceExists
+	case errors.Is(err, domain.ErrInvoiceNotFound):
+		return nil
+	default:
+		return err
+	}
 }
 
-// GetByID retrieves an invoice by ID and returns an output DTO.
-func (s *InvoiceService) GetByID(ctx context.Context, id string) (*InvoiceOutput, error) {
-	invoice, err := s.repo.GetByID(ctx, id)
+// GetByID retrieves an invoice of the account behind apiKey. Invoices of
+// other accounts are reported as domain.ErrInvoiceNotFound.
+func (s *InvoiceService) GetByID(ctx context.Context, apiKey, id string) (*InvoiceOutput, error) {
+	account, err := s.accountService.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := s.repo.GetByIDForAccount(ctx, account.ID, id)
 	if err != nil {
 		return nil, err
 	}
@@ -95,14 +486,19 @@ func (s *InvoiceService) GetByID(ctx context.Context, id string) (*InvoiceOutput
 	return toInvoiceOutput(invoice), nil
 }
 
-// GetByAccountID retrieves all invoices for an account and returns output DTOs.
-func (s *InvoiceService) GetByAccountID(ctx context.Context, accountID string) ([]*InvoiceOutput, error) {
-	invoices, err := s.repo.GetByAccountID(ctx, accountID)
+// List retrieves the invoices of the account behind apiKey.
+func (s *InvoiceService) List(ctx context.Context, apiKey string) ([]*InvoiceOutput, error) {
+	account, err := s.accountService.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	invoices, err := s.repo.GetByAccountID(ctx, account.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	var outputs []*InvoiceOutput
+	// Never nil so an empty list is encoded as [] instead of null
+	outputs := make([]*InvoiceOutput, 0, len(invoices))
 	for _, invoice := range invoices {
 		outputs = append(outputs, toInvoiceOutput(invoice))
 	}
@@ -110,27 +506,100 @@ func (s *InvoiceService) GetByAccountID(ctx context.Context, accountID string) (
 	return outputs, nil
 }
 
+// ListByExternalReference returns the invoice of the account behind apiKey
+// with the merchant reference, as a list that is empty when there is none.
+func (s *InvoiceService) ListByExternalReference(ctx context.Context, apiKey, ref string) ([]*InvoiceOutput, error) {
+	account, err := s.accountService.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	invoice, err := s.repo.GetByExternalReference(ctx, account.ID, ref)
+	if errors.Is(err, domain.ErrInvoiceNotFound) {
+		return []*InvoiceOutput{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []*InvoiceOutput{toInvoiceOutput(invoice)}, nil
+}
+
 // GetAccountByAPIKey retrieves an account by API key and returns an output DTO.
 func (s *InvoiceService) GetAccountByAPIKey(ctx context.Context, apiKey string) (*AccountOutput, error) {
 	return s.accountService.GetByAPIKey(ctx, apiKey)
 }
 
-// UpdateStatus updates the status of an invoice.
-func (s *InvoiceService) UpdateStatus(ctx context.Context, id string, status domain.Status) error {
+// UpdateStatus updates the status of an invoice of the account behind apiKey.
+func (s *InvoiceService) UpdateStatus(ctx context.Context, apiKey, id string, status domain.Status) error {
+	account, err := s.accountService.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return err
+	}
+	// Ownership never changes, so checking it before the update is enough
+	if _, err := s.repo.GetByIDForAccount(ctx, account.ID, id); err != nil {
+		return err
+	}
 	return s.repo.UpdateStatus(ctx, id, status)
 }
 
 // toInvoiceOutput maps domain.Invoice to output DTO.
 func toInvoiceOutput(i *domain.Invoice) *InvoiceOutput {
 	return &InvoiceOutput{
-		ID:             i.ID,
-		AccountID:      i.AccountID,
-		Amount:         i.Amount,
-		Status:         string(i.Status),
-		Description:    i.Description,
-		PaymentType:    i.PaymentType,
-		CardLastDigits: i.CardLastDigits,
-		CreatedAt:      i.CreatedAt,
-		UpdatedAt:      i.UpdatedAt,
+		ID:                i.ID,
+		AccountID:         i.AccountID,
+		Amount:            i.Amount,
+		Installments:      i.Installments,
+		TotalAmount:       i.TotalAmount,
+		Schedule:          toInstallmentOutputs(i.Schedule),
+		Fee:               i.Fee,
+		NetAmount:         i.NetAmount,
+		Status:            string(i.Status),
+		Description:       i.Description,
+		PaymentType:       i.PaymentType,
+		CardLastDigits:    i.CardLastDigits,
+		CustomerID:        i.CustomerID,
+		SavedCardID:       i.SavedCardID,
+		Items:             toLineItems(i.Items),
+		Metadata:          i.Metadata,
+		ExternalReference: i.ExternalReference,
+		IdempotencyKey:    i.IdempotencyKey,
+		RiskDecision:      string(i.RiskDecision),
+		RiskReasons:       i.RiskReasons,
+		CreatedAt:         i.CreatedAt,
+		UpdatedAt:         i.UpdatedAt,
+	}
+}
+
+func toInstallmentOutputs(schedule []domain.Installment) []InstallmentOutput {
+	if len(schedule) == 0 {
+		return nil
+	}
+	out := make([]InstallmentOutput, len(schedule))
+	for k, in := range schedule {
+		out[k] = InstallmentOutput{Number: in.Number, Amount: in.Amount, DueAt: in.DueAt}
+	}
+	return out
+}
+
+func toLineItems(items []domain.LineItem) []LineItem {
+	if len(items) == 0 {
+		return nil
+	}
+	out := make([]LineItem, len(items))
+	for k, it := range items {
+		out[k] = LineItem{SKU: it.SKU, Quantity: it.Quantity, UnitPrice: it.UnitPrice}
+	}
+	return out
+}
+
+func toInstallmentRulesOutput(r domain.InstallmentRules) *InstallmentRulesOutput {
+	out := &InstallmentRulesOutput{
+		MaxInstallments:  r.MaxInstallments,
+		FreeInstallments: r.FreeInstallments,
+		MonthlyInterest:  r.MonthlyInterest,
+	}
+	if !r.UpdatedAt.IsZero() {
+		t := r.UpdatedAt
+		out.UpdatedAt = &t
 	}
+	return out
 }
@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
 	"github.com/devfullcycle/imersao22/go-gateway/internal/repository/memory"
@@ -36,11 +39,6 @@ func (m *mockAccountService) GetByAPIKey(ctx context.Context, apiKey string) (*A
 	return nil, domain.ErrAccountNotFound
 }
 
-func (m *mockAccountService) UpdateBalance(ctx context.Context, apiKey string, amount float64) error {
-	// Not needed for invoice tests
-	return nil
-}
-
 func (m *mockAccountService) addTestAccount(apiKey, accountID string) {
 	m.accounts[apiKey] = &AccountOutput{
 		ID:      accountID,
@@ -48,6 +46,7 @@ func (m *mockAccountService) addTestAccount(apiKey, accountID string) {
 		Email:   "test@example.com",
 		APIKey:  apiKey,
 		Balance: 1000.0,
+		Status:  string(domain.AccountActive),
 	}
 }
 
@@ -62,6 +61,7 @@ func TestInvoiceService_Create(t *testing.T) {
 
 	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
 	svc.repo = repo // Override the repo to use memory instead of postgres
+	svc.fees = memory.NewFeeRepositoryMemory()
 
 	// Test with controlled processor for approval
 	t.Run("valid invoice with approval", func(t *testing.T) {
@@ -320,6 +320,7 @@ func TestInvoiceService_GetByID(t *testing.T) {
 
 	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
 	svc.repo = repo // Override the repo to use memory instead of postgres
+	svc.fees = memory.NewFeeRepositoryMemory()
 
 	// Create a test invoice first with controlled processor
 	testProcessor := domain.NewTestInvoiceProcessor()
@@ -346,7 +347,7 @@ func TestInvoiceService_GetByID(t *testing.T) {
 	}
 
 	// Test getting by ID
-	retrieved, err := svc.GetByID(context.Background(), created.ID)
+	retrieved, err := svc.GetByID(context.Background(), testAPIKey, created.ID)
 	if err != nil {
 		t.Errorf("failed to get invoice by ID: %v", err)
 	}
@@ -356,13 +357,13 @@ func TestInvoiceService_GetByID(t *testing.T) {
 	}
 
 	// Test getting non-existent invoice
-	_, err = svc.GetByID(context.Background(), "non-existent-id")
+	_, err = svc.GetByID(context.Background(), testAPIKey, "non-existent-id")
 	if err != domain.ErrInvoiceNotFound {
 		t.Errorf("expected ErrInvoiceNotFound, got %v", err)
 	}
 }
 
-func TestInvoiceService_GetByAccountID(t *testing.T) {
+func TestInvoiceService_List(t *testing.T) {
 	repo := memory.NewInvoiceRepositoryMemory()
 	mockAccountSvc := newMockAccountService()
 
@@ -376,6 +377,7 @@ func TestInvoiceService_GetByAccountID(t *testing.T) {
 
 	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
 	svc.repo = repo // Override the repo to use memory instead of postgres
+	svc.fees = memory.NewFeeRepositoryMemory()
 
 	// Create test invoices for different accounts with controlled processors
 	inputs := []InvoiceCreateInput{
@@ -418,9 +420,9 @@ func TestInvoiceService_GetByAccountID(t *testing.T) {
 	}
 
 	// Test getting invoices for account-1
-	invoices, err := svc.GetByAccountID(context.Background(), "account-1")
+	invoices, err := svc.List(context.Background(), testAPIKey1)
 	if err != nil {
-		t.Errorf("failed to get invoices by account ID: %v", err)
+		t.Errorf("failed to list invoices: %v", err)
 	}
 
 	if len(invoices) != 2 {
@@ -435,9 +437,9 @@ func TestInvoiceService_GetByAccountID(t *testing.T) {
 	}
 
 	// Test getting invoices for account-2
-	invoices, err = svc.GetByAccountID(context.Background(), "account-2")
+	invoices, err = svc.List(context.Background(), testAPIKey2)
 	if err != nil {
-		t.Errorf("failed to get invoices by account ID: %v", err)
+		t.Errorf("failed to list invoices: %v", err)
 	}
 
 	if len(invoices) != 1 {
@@ -449,14 +451,9 @@ func TestInvoiceService_GetByAccountID(t *testing.T) {
 		t.Errorf("invoice should not be pending after processing, got status: %s", invoices[0].Status)
 	}
 
-	// Test getting invoices for non-existent account
-	invoices, err = svc.GetByAccountID(context.Background(), "non-existent-account")
-	if err != nil {
-		t.Errorf("unexpected error: %v", err)
-	}
-
-	if len(invoices) != 0 {
-		t.Errorf("expected 0 invoices for non-existent account, got %d", len(invoices))
+	// Test listing with an unknown API key
+	if _, err = svc.List(context.Background(), "non-existent-key"); err != domain.ErrAccountNotFound {
+		t.Errorf("expected ErrAccountNotFound, got %v", err)
 	}
 }
 
@@ -471,6 +468,7 @@ func TestInvoiceService_UpdateStatus(t *testing.T) {
 
 	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
 	svc.repo = repo // Override the repo to use memory instead of postgres
+	svc.fees = memory.NewFeeRepositoryMemory()
 
 	// Create a test invoice first with controlled processor
 	testProcessor := domain.NewTestInvoiceProcessor()
@@ -497,13 +495,13 @@ func TestInvoiceService_UpdateStatus(t *testing.T) {
 	}
 
 	// Test updating status to approved
-	err = svc.UpdateStatus(context.Background(), created.ID, domain.StatusApproved)
+	err = svc.UpdateStatus(context.Background(), testAPIKey, created.ID, domain.StatusApproved)
 	if err != nil {
 		t.Errorf("failed to update status to approved: %v", err)
 	}
 
 	// Verify the status was updated
-	retrieved, err := svc.GetByID(context.Background(), created.ID)
+	retrieved, err := svc.GetByID(context.Background(), testAPIKey, created.ID)
 	if err != nil {
 		t.Errorf("failed to get updated invoice: %v", err)
 	}
@@ -513,13 +511,13 @@ func TestInvoiceService_UpdateStatus(t *testing.T) {
 	}
 
 	// Test updating status to rejected
-	err = svc.UpdateStatus(context.Background(), created.ID, domain.StatusRejected)
+	err = svc.UpdateStatus(context.Background(), testAPIKey, created.ID, domain.StatusRejected)
 	if err != nil {
 		t.Errorf("failed to update status to rejected: %v", err)
 	}
 
 	// Verify the status was updated again
-	retrieved, err = svc.GetByID(context.Background(), created.ID)
+	retrieved, err = svc.GetByID(context.Background(), testAPIKey, created.ID)
 	if err != nil {
 		t.Errorf("failed to get updated invoice: %v", err)
 	}
@@ -529,12 +527,48 @@ func TestInvoiceService_UpdateStatus(t *testing.T) {
 	}
 
 	// Test updating non-existent invoice
-	err = svc.UpdateStatus(context.Background(), "non-existent-id", domain.StatusApproved)
+	err = svc.UpdateStatus(context.Background(), testAPIKey, "non-existent-id", domain.StatusApproved)
 	if err != domain.ErrInvoiceNotFound {
 		t.Errorf("expected ErrInvoiceNotFound, got %v", err)
 	}
 }
 
+func TestInvoiceService_TenantIsolation(t *testing.T) {
+	mockAccountSvc := newMockAccountService()
+	mockAccountSvc.addTestAccount("key-owner", "acc-owner")
+	mockAccountSvc.addTestAccount("key-other", "acc-other")
+
+	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
+	svc.repo = memory.NewInvoiceRepositoryMemory()
+	svc.fees = memory.NewFeeRepositoryMemory()
+	processor := domain.NewTestInvoiceProcessor()
+	processor.SetNextStatus(domain.StatusRejected)
+	svc.SetProcessor(processor)
+
+	created, err := svc.Create(context.Background(), InvoiceCreateInput{
+		APIKey: "key-owner", Amount: 100, Description: "Owner invoice", PaymentType: "pix",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Another account sees the invoice as missing, not forbidden
+	if _, err := svc.GetByID(context.Background(), "key-other", created.ID); err != domain.ErrInvoiceNotFound {
+		t.Fatalf("expected ErrInvoiceNotFound reading another account's invoice, got %v", err)
+	}
+	if err := svc.UpdateStatus(context.Background(), "key-other", created.ID, domain.StatusApproved); err != domain.ErrInvoiceNotFound {
+		t.Fatalf("expected ErrInvoiceNotFound updating another account's invoice, got %v", err)
+	}
+	if list, err := svc.List(context.Background(), "key-other"); err != nil || len(list) != 0 {
+		t.Fatalf("expected no invoices for the other account, got %v %v", list, err)
+	}
+
+	got, err := svc.GetByID(context.Background(), "key-owner", created.ID)
+	if err != nil || got.Status != "rejected" {
+		t.Fatalf("expected the owner to see the unchanged invoice, got %+v %v", got, err)
+	}
+}
+
 func TestInvoiceService_GetAccountByAPIKey(t *testing.T) {
 	repo := memory.NewInvoiceRepositoryMemory()
 	mockAccountSvc := newMockAccountService()
@@ -546,6 +580,7 @@ func TestInvoiceService_GetAccountByAPIKey(t *testing.T) {
 
 	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
 	svc.repo = repo
+	svc.fees = memory.NewFeeRepositoryMemory()
 
 	// Test successful retrieval
 	account, err := svc.GetAccountByAPIKey(context.Background(), testAPIKey)
@@ -571,12 +606,14 @@ func TestInvoiceService_GetAccountByAPIKey(t *testing.T) {
 func TestInvoiceService_GetByID_NotFound(t *testing.T) {
 	repo := memory.NewInvoiceRepositoryMemory()
 	mockAccountSvc := newMockAccountService()
+	mockAccountSvc.addTestAccount("test-api-key-123", "test-account-id")
 
 	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
 	svc.repo = repo
+	svc.fees = memory.NewFeeRepositoryMemory()
 
 	// Test getting non-existent invoice
-	_, err := svc.GetByID(context.Background(), "non-existent-id")
+	_, err := svc.GetByID(context.Background(), "test-api-key-123", "non-existent-id")
 	if err != domain.ErrInvoiceNotFound {
 		t.Errorf("expected ErrInvoiceNotFound, got %v", err)
 	}
@@ -593,6 +630,7 @@ func TestInvoiceService_GetByID_Success(t *testing.T) {
 
 	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
 	svc.repo = repo
+	svc.fees = memory.NewFeeRepositoryMemory()
 
 	// Create a test invoice first
 	testProcessor := domain.NewTestInvoiceProcessor()
@@ -613,7 +651,7 @@ func TestInvoiceService_GetByID_Success(t *testing.T) {
 	}
 
 	// Test getting the created invoice
-	retrieved, err := svc.GetByID(context.Background(), created.ID)
+	retrieved, err := svc.GetByID(context.Background(), testAPIKey, created.ID)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -637,6 +675,7 @@ func TestInvoiceService_Create_AccountNotFound(t *testing.T) {
 
 	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
 	svc.repo = repo
+	svc.fees = memory.NewFeeRepositoryMemory()
 
 	input := InvoiceCreateInput{
 		APIKey:         "non-existent-api-key",
@@ -663,6 +702,7 @@ func TestInvoiceService_Create_InvalidInput(t *testing.T) {
 
 	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
 	svc.repo = repo
+	svc.fees = memory.NewFeeRepositoryMemory()
 
 	// Test with negative amount
 	input1 := InvoiceCreateInput{
@@ -674,7 +714,7 @@ func TestInvoiceService_Create_InvalidInput(t *testing.T) {
 	}
 
 	_, err := svc.Create(context.Background(), input1)
-	if err != domain.ErrInvoiceNegativeValue {
+	if !errors.Is(err, domain.ErrInvoiceNegativeValue) {
 		t.Errorf("expected ErrInvoiceNegativeValue, got %v", err)
 	}
 
@@ -688,7 +728,7 @@ func TestInvoiceService_Create_InvalidInput(t *testing.T) {
 	}
 
 	_, err = svc.Create(context.Background(), input2)
-	if err != domain.ErrInvoiceNegativeValue {
+	if !errors.Is(err, domain.ErrInvoiceNegativeValue) {
 		t.Errorf("expected ErrInvoiceNegativeValue, got %v", err)
 	}
 
@@ -702,7 +742,7 @@ func TestInvoiceService_Create_InvalidInput(t *testing.T) {
 	}
 
 	_, err = svc.Create(context.Background(), input3)
-	if err != domain.ErrInvalidDescription {
+	if !errors.Is(err, domain.ErrInvalidDescription) {
 		t.Errorf("expected ErrInvalidDescription, got %v", err)
 	}
 
@@ -716,7 +756,7 @@ func TestInvoiceService_Create_InvalidInput(t *testing.T) {
 	}
 
 	_, err = svc.Create(context.Background(), input4)
-	if err != domain.ErrInvalidPaymentType {
+	if !errors.Is(err, domain.ErrInvalidPaymentType) {
 		t.Errorf("expected ErrInvalidPaymentType, got %v", err)
 	}
 }
@@ -735,6 +775,7 @@ func TestInvoiceService_Create_RepositoryError(t *testing.T) {
 
 	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
 	svc.repo = mockRepo // Override the repo to use our mock
+	svc.fees = memory.NewFeeRepositoryMemory()
 
 	// Create a test invoice with controlled processor
 	testProcessor := domain.NewTestInvoiceProcessor()
@@ -755,6 +796,185 @@ func TestInvoiceService_Create_RepositoryError(t *testing.T) {
 	}
 }
 
+func TestInvoiceService_Create_AccountStatus(t *testing.T) {
+	mockAccountSvc := newMockAccountService()
+	mockAccountSvc.addTestAccount("suspended-key", "acc-1")
+	mockAccountSvc.accounts["suspended-key"].Status = string(domain.AccountSuspended)
+
+	repo := memory.NewInvoiceRepositoryMemory()
+	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
+	svc.repo = repo
+	svc.fees = memory.NewFeeRepositoryMemory()
+
+	_, err := svc.Create(context.Background(), InvoiceCreateInput{
+		APIKey:      "suspended-key",
+		Amount:      100,
+		Description: "Test invoice",
+		PaymentType: "pix",
+	})
+	if !errors.Is(err, domain.ErrAccountSuspended) {
+		t.Fatalf("expected ErrAccountSuspended, got %v", err)
+	}
+
+	// Reads keep working
+	list, err := svc.List(context.Background(), "suspended-key")
+	if err != nil || len(list) != 0 {
+		t.Fatalf("expected empty list, got %v %v", list, err)
+	}
+}
+
+func TestInvoiceService_Create_Fees(t *testing.T) {
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(context.Background(), a)
+	mockAccountSvc := newMockAccountService()
+	mockAccountSvc.addTestAccount("key-1", a.ID)
+
+	fees := memory.NewFeeRepositoryMemory()
+	pix, _ := domain.NewFeePlan(a.ID, "pix", 1, 0, domain.SystemClock)
+	_ = fees.SavePlan(context.Background(), pix)
+
+	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
+	svc.repo = memory.NewInvoiceRepositoryMemoryWithLedger(accounts, memory.NewSettlementRepositoryMemory(accounts), fees)
+	svc.fees = fees
+	svc.SetDefaultFeePlan(domain.FeePlan{Percent: 3.99, Fixed: 0.39})
+	processor := domain.NewTestInvoiceProcessor()
+	svc.SetProcessor(processor)
+
+	create := func(paymentType string) *InvoiceOutput {
+		t.Helper()
+		out, err := svc.Create(context.Background(), InvoiceCreateInput{
+			APIKey: "key-1", Amount: 100, Description: "Order", PaymentType: paymentType,
+		})
+		if err != nil {
+			t.Fatalf("create %s: %v", paymentType, err)
+		}
+		return out
+	}
+
+	card := create("credit_card") // default plan
+	if card.Amount != 100 || card.Fee != 4.38 || card.NetAmount != 95.62 {
+		t.Fatalf("expected gross 100, fee 4.38, net 95.62, got %+v", card)
+	}
+	if out := create("pix"); out.Fee != 1 || out.NetAmount != 99 {
+		t.Fatalf("expected the account pix plan, got %+v", out)
+	}
+	processor.SetNextStatus(domain.StatusRejected)
+	if out := create("pix"); out.Fee != 0 || out.NetAmount != 0 {
+		t.Fatalf("expected no fee on a rejected invoice, got %+v", out)
+	}
+
+	if a.Balance != 194.62 {
+		t.Fatalf("expected only the net amounts credited, got %v", a.Balance)
+	}
+
+	stored, _ := svc.GetByID(context.Background(), "key-1", card.ID)
+	if stored.Fee != 4.38 || stored.NetAmount != 95.62 {
+		t.Fatalf("expected fee stored on the invoice, got %+v", stored)
+	}
+
+	revenue, _ := fees.Revenue(context.Background(), time.Time{}, time.Now().Add(time.Minute))
+	if len(revenue) != 2 || revenue[0].Fees != 4.38 || revenue[1].Fees != 1 {
+		t.Fatalf("expected the fees recorded for revenue, got %+v", revenue)
+	}
+}
+
+func TestInvoiceService_Create_Settlement(t *testing.T) {
+	ctx := context.Background()
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(ctx, a)
+	mockAccountSvc := newMockAccountService()
+	mockAccountSvc.addTestAccount("key-1", a.ID)
+
+	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
+	fees := memory.NewFeeRepositoryMemory()
+	svc.fees = fees
+	svc.repo = memory.NewInvoiceRepositoryMemoryWithLedger(accounts, memory.NewSettlementRepositoryMemory(accounts), fees)
+	svc.SetProcessor(domain.NewTestInvoiceProcessor())
+	svc.SetSettlementSchedule(domain.SettlementSchedule{Delays: map[string]int{"credit_card": 30}})
+
+	for _, paymentType := range []string{"credit_card", "pix"} {
+		if _, err := svc.Create(ctx, InvoiceCreateInput{APIKey: "key-1", Amount: 100, Description: "Order", PaymentType: paymentType}); err != nil {
+			t.Fatalf("create %s: %v", paymentType, err)
+		}
+	}
+
+	// Card money waits for D+30, PIX (D+0) is credited right away
+	if a.PendingBalance != 100 {
+		t.Fatalf("expected the card amount pending, got %v", a.PendingBalance)
+	}
+	if a.Balance != 100 {
+		t.Fatalf("expected only the pix amount credited, got %v", a.Balance)
+	}
+}
+
+func TestInvoiceService_Create_Risk(t *testing.T) {
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(context.Background(), a)
+	mockAccountSvc := newMockAccountService()
+	mockAccountSvc.addTestAccount("key-1", a.ID)
+
+	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
+	fees := memory.NewFeeRepositoryMemory()
+	svc.fees = fees
+	svc.repo = memory.NewInvoiceRepositoryMemoryWithLedger(accounts, memory.NewSettlementRepositoryMemory(accounts), fees)
+	svc.SetRiskService(NewRiskService(StaticRiskRules{
+		{Kind: domain.RiskAmountThreshold, MaxAmount: 1000, Action: domain.RiskReview},
+		{Kind: domain.RiskCardVelocity, MaxPerHour: 2, Action: domain.RiskReject},
+		{Kind: domain.RiskPaymentTypeBlock, PaymentType: "boleto", Action: domain.RiskReject},
+	}))
+	if err := svc.risk.Reload(context.Background()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	processor := domain.NewTestInvoiceProcessor()
+	processor.SetNextStatus(domain.StatusApproved)
+	svc.SetProcessor(processor)
+
+	create := func(amount float64, paymentType, card string) *InvoiceOutput {
+		t.Helper()
+		out, err := svc.Create(context.Background(), InvoiceCreateInput{
+			APIKey: "key-1", Amount: amount, Description: "Risk test", PaymentType: paymentType, CardLastDigits: card,
+		})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		return out
+	}
+
+	if out := create(100, "credit_card", "1234"); out.Status != "approved" || out.RiskDecision != "approve" || len(out.RiskReasons) != 0 {
+		t.Fatalf("expected an approved invoice, got %+v", out)
+	}
+
+	review := create(1500, "credit_card", "1234")
+	if review.Status != "pending" || review.RiskDecision != "review" || len(review.RiskReasons) != 1 {
+		t.Fatalf("expected a pending invoice under review, got %+v", review)
+	}
+
+	// Third use of the card within the hour
+	velocity := create(50, "credit_card", "1234")
+	if velocity.Status != "rejected" || velocity.RiskDecision != "reject" {
+		t.Fatalf("expected the card velocity to reject, got %+v", velocity)
+	}
+	if out := create(50, "credit_card", "5678"); out.Status != "approved" {
+		t.Fatalf("expected another card to be approved, got %+v", out)
+	}
+
+	if out := create(50, "boleto", ""); out.Status != "rejected" || out.RiskReasons[0] != "payment_type_block: payment type boleto is blocked" {
+		t.Fatalf("expected the blocked payment type to reject, got %+v", out)
+	}
+
+	// Reasons are stored with the invoice
+	stored, err := svc.GetByID(context.Background(), "key-1", review.ID)
+	if err != nil || stored.RiskDecision != "review" || stored.RiskReasons[0] != review.RiskReasons[0] {
+		t.Fatalf("expected the stored assessment, got %+v %v", stored, err)
+	}
+	if a.Balance != 150 {
+		t.Fatalf("expected only the approved invoices credited, got %v", a.Balance)
+	}
+}
+
 // Mock repository for testing repository errors
 type mockInvoiceRepository struct {
 	createError error
@@ -764,14 +984,390 @@ func (m *mockInvoiceRepository) Create(ctx context.Context, invoice *domain.Invo
 	return m.createError
 }
 
+func (m *mockInvoiceRepository) CreateWithCharge(ctx context.Context, invoice *domain.Invoice, charge domain.InvoiceCharge) error {
+	return m.createError
+}
+
 func (m *mockInvoiceRepository) GetByID(ctx context.Context, id string) (*domain.Invoice, error) {
 	return nil, domain.ErrInvoiceNotFound
 }
 
+func (m *mockInvoiceRepository) GetByIDForAccount(ctx context.Context, accountID, id string) (*domain.Invoice, error) {
+	return nil, domain.ErrInvoiceNotFound
+}
+
 func (m *mockInvoiceRepository) GetByAccountID(ctx context.Context, accountID string) ([]*domain.Invoice, error) {
 	return nil, nil
 }
 
+func (m *mockInvoiceRepository) GetByCustomer(ctx context.Context, accountID, customerID string) ([]*domain.Invoice, error) {
+	return nil, nil
+}
+
+func (m *mockInvoiceRepository) GetByExternalReference(ctx context.Context, accountID, ref string) (*domain.Invoice, error) {
+	return nil, domain.ErrInvoiceNotFound
+}
+
+func (m *mockInvoiceRepository) GetByIdempotencyKey(ctx context.Context, accountID, key string) (*domain.Invoice, error) {
+	return nil, domain.ErrInvoiceNotFound
+}
+
 func (m *mockInvoiceRepository) UpdateStatus(ctx context.Context, id string, status domain.Status) error {
 	return domain.ErrInvoiceNotFound
 }
+
+func (m *mockInvoiceRepository) CountByCardSince(ctx context.Context, accountID, cardLastDigits string, since time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestInvoiceService_Create_Installments(t *testing.T) {
+	ctx := context.Background()
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(ctx, a)
+	mockAccountSvc := newMockAccountService()
+	mockAccountSvc.addTestAccount("key-1", a.ID)
+
+	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
+	fees := memory.NewFeeRepositoryMemory()
+	svc.fees = fees
+	svc.repo = memory.NewInvoiceRepositoryMemoryWithLedger(accounts, memory.NewSettlementRepositoryMemory(accounts), fees)
+	svc.installments = memory.NewInstallmentRulesRepositoryMemory()
+	svc.SetProcessor(domain.NewTestInvoiceProcessor())
+
+	rules, err := svc.GetInstallmentRules(ctx, "key-1")
+	if err != nil || rules.MaxInstallments != 12 || rules.FreeInstallments != 12 || rules.UpdatedAt != nil {
+		t.Fatalf("expected the default rules, got %+v %v", rules, err)
+	}
+	if _, err := svc.SaveInstallmentRules(ctx, InstallmentRulesInput{APIKey: "key-1", MaxInstallments: 6, FreeInstallments: 1, MonthlyInterest: 1.99}); err != nil {
+		t.Fatalf("save rules: %v", err)
+	}
+
+	out, err := svc.Create(ctx, InvoiceCreateInput{APIKey: "key-1", Amount: 90, Description: "Order", PaymentType: "credit_card", CardLastDigits: "1234", Installments: 3})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if out.Installments != 3 || out.TotalAmount != 93.6 || out.NetAmount != 93.6 || len(out.Schedule) != 3 || out.Schedule[2].Amount != 31.2 {
+		t.Fatalf("expected 3 x 31.20 with interest, got %+v", out)
+	}
+
+	// D+0: the first installment is credited now, the others on their due date
+	if a.Balance != 31.2 {
+		t.Fatalf("expected only the first installment credited, got %v", a.Balance)
+	}
+	if a.PendingBalance != 62.4 {
+		t.Fatalf("expected two installments pending, got %v", a.PendingBalance)
+	}
+
+	stored, _ := svc.GetByID(ctx, "key-1", out.ID)
+	if stored.TotalAmount != 93.6 || len(stored.Schedule) != 3 {
+		t.Fatalf("expected the schedule stored, got %+v", stored)
+	}
+
+	for _, in := range []InvoiceCreateInput{
+		{APIKey: "key-1", Amount: 90, Description: "Order", PaymentType: "pix", Installments: 2},
+		{APIKey: "key-1", Amount: 90, Description: "Order", PaymentType: "credit_card", Installments: 7},
+		{APIKey: "key-1", Amount: 90, Description: "Order", PaymentType: "credit_card", Installments: -1},
+		{APIKey: "key-1", Amount: domain.MaxInvoiceAmount, Description: "Order", PaymentType: "credit_card", CardLastDigits: "1234", Installments: 6},
+	} {
+		var verr *domain.ValidationError
+		if _, err := svc.Create(ctx, in); !errors.As(err, &verr) || verr.Fields[0].Field != "installments" {
+			t.Errorf("expected an installments validation error for %+v, got %v", in, err)
+		}
+	}
+}
+
+func TestInvoiceService_Create_RiskOnInstallmentTotal(t *testing.T) {
+	ctx := context.Background()
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(ctx, a)
+	mockAccountSvc := newMockAccountService()
+	mockAccountSvc.addTestAccount("key-1", a.ID)
+
+	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
+	fees := memory.NewFeeRepositoryMemory()
+	svc.fees = fees
+	svc.repo = memory.NewInvoiceRepositoryMemoryWithLedger(accounts, memory.NewSettlementRepositoryMemory(accounts), fees)
+	svc.installments = memory.NewInstallmentRulesRepositoryMemory()
+	svc.SetRiskService(NewRiskService(StaticRiskRules{
+		{Kind: domain.RiskAmountThreshold, MaxAmount: 92, Action: domain.RiskReview},
+	}))
+	if err := svc.risk.Reload(ctx); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	processor := domain.NewTestInvoiceProcessor()
+	processor.SetNextStatus(domain.StatusApproved)
+	svc.SetProcessor(processor)
+	if _, err := svc.SaveInstallmentRules(ctx, InstallmentRulesInput{APIKey: "key-1", MaxInstallments: 6, FreeInstallments: 1, MonthlyInterest: 1.99}); err != nil {
+		t.Fatalf("save rules: %v", err)
+	}
+
+	// 90 is below the threshold, but 3 x 31.20 with interest is not
+	out, err := svc.Create(ctx, InvoiceCreateInput{APIKey: "key-1", Amount: 90, Description: "Order", PaymentType: "credit_card", CardLastDigits: "1234", Installments: 3})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if out.TotalAmount != 93.6 || out.RiskDecision != "review" || out.Status != "pending" {
+		t.Fatalf("expected the total held for review, got %+v", out)
+	}
+}
+
+func TestInvoiceService_Create_SavedCard(t *testing.T) {
+	ctx := context.Background()
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(ctx, a)
+	mockAccountSvc := newMockAccountService()
+	mockAccountSvc.addTestAccount("key-1", a.ID)
+
+	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
+	fees := memory.NewFeeRepositoryMemory()
+	svc.fees = fees
+	svc.repo = memory.NewInvoiceRepositoryMemoryWithLedger(accounts, memory.NewSettlementRepositoryMemory(accounts), fees)
+	svc.installments = memory.NewInstallmentRulesRepositoryMemory()
+	customers := memory.NewCustomerRepositoryMemory()
+	svc.customers = customers
+	svc.SetProcessor(domain.NewTestInvoiceProcessor())
+
+	c, _ := domain.NewCustomer(a.ID, "Maria Silva", "maria@example.com", "52998224725", time.Now())
+	_ = customers.Create(ctx, c)
+	card, _ := domain.NewSavedCard(c, "tok_12345678", "4242", time.Now())
+	_ = customers.AddCard(ctx, card)
+
+	out, err := svc.Create(ctx, InvoiceCreateInput{APIKey: "key-1", Amount: 50, Description: "Order", PaymentType: "credit_card", CustomerID: c.ID, SavedCardID: card.ID})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if out.CustomerID != c.ID || out.SavedCardID != card.ID || out.CardLastDigits != "4242" {
+		t.Fatalf("expected the invoice charged to the saved card, got %+v", out)
+	}
+
+	for _, tc := range []struct {
+		in    InvoiceCreateInput
+		field string
+	}{
+		{InvoiceCreateInput{APIKey: "key-1", Amount: 50, Description: "Order", PaymentType: "credit_card", SavedCardID: card.ID}, "customer_id"},
+		{InvoiceCreateInput{APIKey: "key-1", Amount: 50, Description: "Order", PaymentType: "credit_card", CustomerID: "missing"}, "customer_id"},
+		{InvoiceCreateInput{APIKey: "key-1", Amount: 50, Description: "Order", PaymentType: "credit_card", CustomerID: c.ID, SavedCardID: "missing"}, "saved_card_id"},
+		{InvoiceCreateInput{APIKey: "key-1", Amount: 50, Description: "Order", PaymentType: "pix", CustomerID: c.ID, SavedCardID: card.ID}, "payment_type"},
+	} {
+		var verr *domain.ValidationError
+		if _, err := svc.Create(ctx, tc.in); !errors.As(err, &verr) || verr.Fields[0].Field != tc.field {
+			t.Errorf("expected a %s validation error for %+v, got %v", tc.field, tc.in, err)
+		}
+	}
+}
+
+func TestInvoiceService_Create_Details(t *testing.T) {
+	ctx := context.Background()
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(ctx, a)
+	mockAccountSvc := newMockAccountService()
+	mockAccountSvc.addTestAccount("key-1", a.ID)
+
+	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
+	fees := memory.NewFeeRepositoryMemory()
+	svc.fees = fees
+	svc.repo = memory.NewInvoiceRepositoryMemoryWithLedger(accounts, memory.NewSettlementRepositoryMemory(accounts), fees)
+	svc.installments = memory.NewInstallmentRulesRepositoryMemory()
+	svc.SetProcessor(domain.NewTestInvoiceProcessor())
+
+	in := InvoiceCreateInput{
+		APIKey: "key-1", Amount: 50, Description: "Order", PaymentType: "credit_card", CardLastDigits: "1111",
+		Items:             []LineItem{{SKU: "tee", Quantity: 2, UnitPrice: 15}, {SKU: "cap", Quantity: 1, UnitPrice: 20}},
+		Metadata:          map[string]string{"channel": "web"},
+		ExternalReference: "order-42",
+	}
+	out, err := svc.Create(ctx, in)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if len(out.Items) != 2 || out.Items[1].SKU != "cap" || out.Metadata["channel"] != "web" || out.ExternalReference != "order-42" {
+		t.Fatalf("expected the details on the output, got %+v", out)
+	}
+
+	found, err := svc.ListByExternalReference(ctx, "key-1", "order-42")
+	if err != nil || len(found) != 1 || found[0].ID != out.ID {
+		t.Fatalf("expected the invoice found by reference, got %+v %v", found, err)
+	}
+	if found, err := svc.ListByExternalReference(ctx, "key-1", "order-99"); err != nil || len(found) != 0 {
+		t.Fatalf("expected no invoice for an unknown reference, got %+v %v", found, err)
+	}
+
+	// A reused reference is rejected before the balance is credited
+	balance := a.Balance
+	if _, err := svc.Create(ctx, in); !errors.Is(err, domain.ErrExternalReferenceExists) {
+		t.Fatalf("expected ErrExternalReferenceExists, got %v", err)
+	}
+	if a.Balance != balance {
+		t.Fatalf("expected no credit for the rejected invoice, got %v", a.Balance)
+	}
+
+	// A reference taken between the check and the insert fails the insert,
+	// and the credit with it
+	svc.repo = staleReferenceRepository{svc.repo}
+	in.CardLastDigits = "2222"
+	if _, err := svc.Create(ctx, in); !errors.Is(err, domain.ErrExternalReferenceExists) {
+		t.Fatalf("expected ErrExternalReferenceExists, got %v", err)
+	}
+	if a.Balance != balance {
+		t.Fatalf("expected no credit for the duplicate invoice, got %v", a.Balance)
+	}
+
+	in.ExternalReference = ""
+	in.Items[0].Quantity = 1
+	var verr *domain.ValidationError
+	if _, err := svc.Create(ctx, in); !errors.As(err, &verr) || verr.Fields[0].Field != "items" {
+		t.Fatalf("expected an items validation error, got %v", err)
+	}
+}
+
+// staleReferenceRepository misses the invoices of a reference, like a check
+// made before a concurrent request stored one.
+type staleReferenceRepository struct {
+	domain.InvoiceRepository
+}
+
+func (staleReferenceRepository) GetByExternalReference(ctx context.Context, accountID, ref string) (*domain.Invoice, error) {
+	return nil, domain.ErrInvoiceNotFound
+}
+
+func TestInvoiceService_Create_IdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(ctx, a)
+	mockAccountSvc := newMockAccountService()
+	mockAccountSvc.addTestAccount("key-1", a.ID)
+
+	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
+	fees := memory.NewFeeRepositoryMemory()
+	svc.fees = fees
+	svc.repo = memory.NewInvoiceRepositoryMemoryWithLedger(accounts, memory.NewSettlementRepositoryMemory(accounts), fees)
+	svc.installments = memory.NewInstallmentRulesRepositoryMemory()
+	svc.SetProcessor(domain.NewTestInvoiceProcessor())
+
+	in := InvoiceCreateInput{APIKey: "key-1", Amount: 50, Description: "Order", PaymentType: "pix", IdempotencyKey: "order-42"}
+	first, err := svc.Create(ctx, in)
+	if err != nil || first.IdempotencyKey != "order-42" {
+		t.Fatalf("create: %+v %v", first, err)
+	}
+	again, err := svc.Create(ctx, in)
+	if err != nil || again.ID != first.ID {
+		t.Fatalf("expected the first invoice replayed, got %+v %v", again, err)
+	}
+	if a.Balance != 50 {
+		t.Fatalf("expected a single credit, got %v", a.Balance)
+	}
+
+	for name, change := range map[string]func(*InvoiceCreateInput){
+		"amount":             func(in *InvoiceCreateInput) { in.Amount = 60 },
+		"card":               func(in *InvoiceCreateInput) { in.CardLastDigits = "9999" },
+		"installments":       func(in *InvoiceCreateInput) { in.Installments = 2 },
+		"customer":           func(in *InvoiceCreateInput) { in.CustomerID = "cus-1" },
+		"saved card":         func(in *InvoiceCreateInput) { in.SavedCardID = "card-1" },
+		"items":              func(in *InvoiceCreateInput) { in.Items = []LineItem{{SKU: "tee", Quantity: 1, UnitPrice: 50}} },
+		"metadata":           func(in *InvoiceCreateInput) { in.Metadata = map[string]string{"channel": "web"} },
+		"external reference": func(in *InvoiceCreateInput) { in.ExternalReference = "order-42" },
+	} {
+		changed := in
+		change(&changed)
+		if _, err := svc.Create(ctx, changed); !errors.Is(err, domain.ErrIdempotencyKeyReused) {
+			t.Errorf("%s: expected ErrIdempotencyKeyReused, got %v", name, err)
+		}
+	}
+
+	// Defaults and empty values ask for the same invoice
+	same := in
+	same.Installments = 1
+	same.Metadata = map[string]string{}
+	if again, err := svc.Create(ctx, same); err != nil || again.ID != first.ID {
+		t.Fatalf("expected the first invoice replayed, got %+v %v", again, err)
+	}
+}
+
+func TestInvoiceService_CreateBatch(t *testing.T) {
+	ctx := context.Background()
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(ctx, a)
+	mockAccountSvc := newMockAccountService()
+	mockAccountSvc.addTestAccount("key-1", a.ID)
+
+	svc := NewInvoiceServiceWithAccountService(nil, mockAccountSvc)
+	fees := memory.NewFeeRepositoryMemory()
+	svc.fees = fees
+	svc.repo = memory.NewInvoiceRepositoryMemoryWithLedger(accounts, memory.NewSettlementRepositoryMemory(accounts), fees)
+	svc.installments = memory.NewInstallmentRulesRepositoryMemory()
+	svc.SetProcessor(domain.NewTestInvoiceProcessor())
+	svc.SetBatchLimits(50, 4)
+
+	if _, err := svc.Create(ctx, InvoiceCreateInput{APIKey: "key-1", Amount: 10, Description: "Earlier", PaymentType: "pix", IdempotencyKey: "key-earlier"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	errUnreadable := errors.New("unreadable")
+	items := make([]InvoiceBatchItem, 0, 41)
+	for k := range 36 {
+		items = append(items, InvoiceBatchItem{Input: InvoiceCreateInput{Amount: 10, Description: fmt.Sprintf("Order %d", k), PaymentType: "pix", IdempotencyKey: fmt.Sprintf("key-%d", k)}})
+	}
+	items = append(items,
+		InvoiceBatchItem{Input: InvoiceCreateInput{Amount: -1, Description: "Invalid", PaymentType: "pix"}},
+		InvoiceBatchItem{Input: InvoiceCreateInput{Amount: 10, Description: "Repeated", PaymentType: "pix", IdempotencyKey: "key-0"}},
+		InvoiceBatchItem{Input: InvoiceCreateInput{Amount: 10, Description: "Earlier", PaymentType: "pix", IdempotencyKey: "key-earlier"}},
+		// The API key always comes from the caller
+		InvoiceBatchItem{Input: InvoiceCreateInput{APIKey: "key-2", Amount: 10, Description: "Order", PaymentType: "pix"}},
+		InvoiceBatchItem{Err: errUnreadable},
+	)
+
+	results, err := svc.CreateBatch(ctx, "key-1", items)
+	if err != nil || len(results) != len(items) {
+		t.Fatalf("expected %d results, got %d %v", len(items), len(results), err)
+	}
+	ids := make(map[string]bool)
+	for k, r := range results[:36] {
+		if r.Err != nil || r.Invoice.Description != items[k].Input.Description || r.Replayed {
+			t.Fatalf("item %d: expected the invoice created in order, got %+v", k, r)
+		}
+		ids[r.Invoice.ID] = true
+	}
+	if len(ids) != 36 {
+		t.Fatalf("expected 36 distinct invoices, got %d", len(ids))
+	}
+	var verr *domain.ValidationError
+	if !errors.As(results[36].Err, &verr) || verr.Fields[0].Field != "amount" {
+		t.Errorf("expected an amount validation error, got %+v", results[36])
+	}
+	if !errors.Is(results[37].Err, domain.ErrDuplicateIdempotencyKey) {
+		t.Errorf("expected ErrDuplicateIdempotencyKey, got %+v", results[37])
+	}
+	if r := results[38]; r.Err != nil || !r.Replayed || r.Invoice.Description != "Earlier" {
+		t.Errorf("expected the earlier invoice replayed, got %+v", r)
+	}
+	if r := results[39]; r.Err != nil || r.Invoice.AccountID != a.ID {
+		t.Errorf("expected the invoice created for the caller, got %+v", r)
+	}
+	if r := results[40]; !errors.Is(r.Err, errUnreadable) || r.Invoice != nil {
+		t.Errorf("expected the caller's error kept, got %+v", r)
+	}
+	// 38 invoices of 10 credited by concurrent workers, none lost
+	if a.Balance != 380 {
+		t.Fatalf("expected 38 credits, got %v", a.Balance)
+	}
+
+	for _, tc := range []struct {
+		apiKey string
+		items  []InvoiceBatchItem
+		want   error
+	}{
+		{"key-1", nil, domain.ErrEmptyBatch},
+		{"key-1", make([]InvoiceBatchItem, 51), domain.ErrBatchTooLarge},
+		{"missing", items[:1], domain.ErrAccountNotFound},
+	} {
+		if _, err := svc.CreateBatch(ctx, tc.apiKey, tc.items); !errors.Is(err, tc.want) {
+			t.Errorf("expected %v, got %v", tc.want, err)
+		}
+	}
+}
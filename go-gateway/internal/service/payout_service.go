@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	pg "github.com/devfullcycle/imersao22/go-gateway/internal/repository/postgres"
+)
+
+// DefaultPayoutBatchSize is how many pending payouts a processing run picks.
+const DefaultPayoutBatchSize = 50
+
+// PayoutService lets merchants register bank accounts and withdraw their
+// balance, and sends pending payouts through the bank rail.
+type PayoutService struct {
+	repo     domain.PayoutRepository
+	accounts domain.AccountRepository
+	rail     domain.BankRail
+	policy   domain.PayoutPolicy
+	clock    domain.Clock
+}
+
+func NewPayoutService(db *sql.DB, rail domain.BankRail, policy domain.PayoutPolicy) *PayoutService {
+	return &PayoutService{
+		repo:     pg.NewPostgresPayoutRepository(db),
+		accounts: pg.NewPostgresAccountRepository(db),
+		rail:     rail,
+		policy:   policy,
+		clock:    domain.SystemClock,
+	}
+}
+
+// SetClock sets the clock that stamps bank accounts and payouts and decides
+// which payouts Reconcile picks.
+func (s *PayoutService) SetClock(c domain.Clock) {
+	s.clock = c
+}
+
+// RegisterBankAccount adds a destination for the payouts of the account.
+func (s *PayoutService) RegisterBankAccount(ctx context.Context, in BankAccountInput) (*BankAccountOutput, error) {
+	account, err := writableAccount(ctx, s.accounts, in.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	b, err := domain.NewBankAccount(account.ID, in.BankCode, in.Branch, in.Number, in.HolderName, s.clock)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateBankAccount(ctx, b); err != nil {
+		return nil, err
+	}
+	return toBankAccountOutput(b), nil
+}
+
+func (s *PayoutService) ListBankAccounts(ctx context.Context, apiKey string) ([]*BankAccountOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.repo.ListBankAccounts(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*BankAccountOutput, 0, len(list))
+	for _, b := range list {
+		out = append(out, toBankAccountOutput(b))
+	}
+	return out, nil
+}
+
+// Create debits the balance and queues a payout to one of the account's bank
+// accounts. The amount must respect the policy minimum and reserve.
+func (s *PayoutService) Create(ctx context.Context, in PayoutCreateInput) (*PayoutOutput, error) {
+	account, err := writableAccount(ctx, s.accounts, in.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	p, err := domain.NewPayout(account.ID, in.BankAccountID, in.Amount, s.clock)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetBankAccount(ctx, account.ID, in.BankAccountID); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.Create(ctx, p, s.policy); err != nil {
+		return nil, err
+	}
+	return toPayoutOutput(p), nil
+}
+
+func (s *PayoutService) GetByID(ctx context.Context, apiKey, id string) (*PayoutOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.repo.GetByID(ctx, account.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toPayoutOutput(p), nil
+}
+
+func (s *PayoutService) List(ctx context.Context, apiKey string) ([]*PayoutOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.repo.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*PayoutOutput, 0, len(list))
+	for _, p := range list {
+		out = append(out, toPayoutOutput(p))
+	}
+	return out, nil
+}
+
+// PayoutReconcileAfter is how long a payout may stay processing before it is
+// sent again. The bank rail deduplicates transfers by payout ID, so a payout
+// whose transfer went through is only marked paid.
+const PayoutReconcileAfter = 10 * time.Minute
+
+// ProcessPending sends up to limit pending payouts through the bank rail and
+// returns how many were handled. Payouts rejected by the bank are failed and
+// refunded.
+func (s *PayoutService) ProcessPending(ctx context.Context, limit int) (int, error) {
+	pending, err := s.repo.ListByStatus(ctx, domain.PayoutPending, limit)
+	if err != nil {
+		return 0, err
+	}
+	return s.each(ctx, pending, s.process)
+}
+
+// Reconcile sends again up to limit payouts left processing for longer than
+// PayoutReconcileAfter, e.g. by a worker that stopped between the transfer
+// and storing its outcome, and returns how many were settled.
+func (s *PayoutService) Reconcile(ctx context.Context, limit int) (int, error) {
+	stuck, err := s.repo.ListStuck(ctx, s.clock.Now().Add(-PayoutReconcileAfter), limit)
+	if err != nil {
+		return 0, err
+	}
+	return s.each(ctx, stuck, s.transfer)
+}
+
+// each runs fn on the payouts, skipping those moved by another replica.
+func (s *PayoutService) each(ctx context.Context, payouts []*domain.Payout, fn func(context.Context, *domain.Payout) error) (int, error) {
+	done := 0
+	for _, p := range payouts {
+		if ctx.Err() != nil {
+			break
+		}
+		p.SetClock(s.clock)
+		err := fn(ctx, p)
+		if errors.Is(err, domain.ErrInvalidPayoutTransition) {
+			continue // taken by another replica
+		}
+		if err != nil {
+			return done, err
+		}
+		done++
+	}
+	return done, nil
+}
+
+func (s *PayoutService) process(ctx context.Context, p *domain.Payout) error {
+	if err := p.StartProcessing(); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateStatus(ctx, p, domain.PayoutPending); err != nil {
+		return err
+	}
+	return s.transfer(ctx, p)
+}
+
+// transfer sends a processing payout and stores the outcome: paid with the
+// transfer reference, or failed and refunded when the bank rejects it. Any
+// other error, e.g. a timeout, may hide a transfer that went through, so the
+// payout stays processing for Reconcile.
+func (s *PayoutService) transfer(ctx context.Context, p *domain.Payout) error {
+	b, err := s.repo.GetBankAccount(ctx, p.AccountID, p.BankAccountID)
+	if err != nil {
+		return err
+	}
+	ref, err := s.rail.Transfer(ctx, p, b)
+	var rejected *domain.TransferRejectedError
+	if errors.As(err, &rejected) {
+		if err := p.MarkFailed(rejected.Reason); err != nil {
+			return err
+		}
+		return s.repo.Fail(ctx, p, domain.PayoutProcessing)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := p.MarkPaid(ref); err != nil {
+		return err
+	}
+	return s.repo.UpdateStatus(ctx, p, domain.PayoutProcessing)
+}
+
+// Run processes pending payouts and reconciles stuck ones every interval
+// until ctx is canceled.
+func (s *PayoutService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.ProcessPending(ctx, DefaultPayoutBatchSize); err != nil {
+				log.Printf("payouts: processed %d: %v", n, err)
+			}
+			if n, err := s.Reconcile(ctx, DefaultPayoutBatchSize); err != nil {
+				log.Printf("payouts: reconciled %d: %v", n, err)
+			}
+		}
+	}
+}
+
+func toBankAccountOutput(b *domain.BankAccount) *BankAccountOutput {
+	return &BankAccountOutput{
+		ID:         b.ID,
+		BankCode:   b.BankCode,
+		Branch:     b.Branch,
+		Number:     b.Number,
+		HolderName: b.HolderName,
+		CreatedAt:  b.CreatedAt,
+	}
+}
+
+func toPayoutOutput(p *domain.Payout) *PayoutOutput {
+	return &PayoutOutput{
+		ID:            p.ID,
+		BankAccountID: p.BankAccountID,
+		Amount:        p.Amount,
+		Status:        string(p.Status),
+		FailureReason: p.FailureReason,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
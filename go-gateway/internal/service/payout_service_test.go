@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/repository/memory"
+)
+
+func newPayoutServiceWithBalance(t *testing.T, balance float64) (*PayoutService, *domain.Account, *domain.SimulatedBankRail) {
+	t.Helper()
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	if balance > 0 {
+		_ = a.AddBalance(balance)
+	}
+	_ = accounts.Create(context.Background(), a)
+
+	rail := domain.NewSimulatedBankRail()
+	svc := &PayoutService{
+		repo:     memory.NewPayoutRepositoryMemory(accounts),
+		accounts: accounts,
+		rail:     rail,
+		policy:   domain.PayoutPolicy{MinAmount: 10, ReserveRate: 0.1},
+		clock:    domain.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)),
+	}
+	return svc, a, rail
+}
+
+func registerBankAccount(t *testing.T, svc *PayoutService, apiKey, number string) *BankAccountOutput {
+	t.Helper()
+	b, err := svc.RegisterBankAccount(context.Background(), BankAccountInput{
+		APIKey: apiKey, BankCode: "341", Branch: "0001", Number: number, HolderName: "Acme",
+	})
+	if err != nil {
+		t.Fatalf("register bank account: %v", err)
+	}
+	return b
+}
+
+func TestPayoutService_CreateAndProcess(t *testing.T) {
+	svc, a, rail := newPayoutServiceWithBalance(t, 200)
+	ctx := context.Background()
+	ok := registerBankAccount(t, svc, a.APIKey, "11111-1")
+	bad := registerBankAccount(t, svc, a.APIKey, "99999-9")
+	rail.Fail("99999-9", "account does not exist")
+
+	paid, err := svc.Create(ctx, PayoutCreateInput{APIKey: a.APIKey, BankAccountID: ok.ID, Amount: 100})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	failed, err := svc.Create(ctx, PayoutCreateInput{APIKey: a.APIKey, BankAccountID: bad.ID, Amount: 50})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if a.Balance != 50 || paid.Status != "pending" {
+		t.Fatalf("expected debit on creation, got balance %v status %s", a.Balance, paid.Status)
+	}
+
+	n, err := svc.ProcessPending(ctx, 10)
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 processed, got %d %v", n, err)
+	}
+
+	got, _ := svc.GetByID(ctx, a.APIKey, paid.ID)
+	if got.Status != "paid" {
+		t.Fatalf("expected paid, got %s", got.Status)
+	}
+	got, _ = svc.GetByID(ctx, a.APIKey, failed.ID)
+	if got.Status != "failed" || got.FailureReason != "account does not exist" {
+		t.Fatalf("expected failed with reason, got %+v", got)
+	}
+	if a.Balance != 100 {
+		t.Fatalf("expected failed payout refunded, got balance %v", a.Balance)
+	}
+
+	// Nothing left to process
+	if n, _ := svc.ProcessPending(ctx, 10); n != 0 {
+		t.Fatalf("expected nothing pending, got %d", n)
+	}
+}
+
+func TestPayoutService_Create_Errors(t *testing.T) {
+	svc, a, _ := newPayoutServiceWithBalance(t, 100)
+	ctx := context.Background()
+	b := registerBankAccount(t, svc, a.APIKey, "11111-1")
+
+	tests := []struct {
+		name string
+		in   PayoutCreateInput
+		want error
+	}{
+		{"below minimum", PayoutCreateInput{APIKey: a.APIKey, BankAccountID: b.ID, Amount: 5}, domain.ErrPayoutBelowMinimum},
+		{"reserve", PayoutCreateInput{APIKey: a.APIKey, BankAccountID: b.ID, Amount: 95}, domain.ErrInsufficientFunds},
+		{"unknown bank account", PayoutCreateInput{APIKey: a.APIKey, BankAccountID: "other", Amount: 20}, domain.ErrBankAccountNotFound},
+		{"missing bank account", PayoutCreateInput{APIKey: a.APIKey, Amount: 20}, domain.ErrBankAccountRequired},
+		{"unknown api key", PayoutCreateInput{APIKey: "nope", BankAccountID: b.ID, Amount: 20}, domain.ErrAccountNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.Create(ctx, tt.in); !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+	if a.Balance != 100 {
+		t.Fatalf("expected balance untouched, got %v", a.Balance)
+	}
+
+	_ = a.Suspend()
+	if _, err := svc.Create(ctx, PayoutCreateInput{APIKey: a.APIKey, BankAccountID: b.ID, Amount: 20}); !errors.Is(err, domain.ErrAccountSuspended) {
+		t.Fatalf("expected suspended accounts not to withdraw, got %v", err)
+	}
+	if list, err := svc.List(ctx, a.APIKey); err != nil || len(list) != 0 {
+		t.Fatalf("expected suspended accounts to read, got %v %v", list, err)
+	}
+}
+
+// flakyPayoutRepository fails the first update of a payout leaving processing,
+// like a worker that stops between the transfer and storing its outcome.
+type flakyPayoutRepository struct {
+	domain.PayoutRepository
+	failed bool
+}
+
+func (r *flakyPayoutRepository) UpdateStatus(ctx context.Context, p *domain.Payout, from domain.PayoutStatus) error {
+	if from == domain.PayoutProcessing && !r.failed {
+		r.failed = true
+		// The memory repository stores p itself, so undo what was not stored
+		p.Status, p.TransferReference = domain.PayoutProcessing, ""
+		return errors.New("connection reset")
+	}
+	return r.PayoutRepository.UpdateStatus(ctx, p, from)
+}
+
+func TestPayoutService_ReconcileStuckPayout(t *testing.T) {
+	svc, a, rail := newPayoutServiceWithBalance(t, 200)
+	ctx := context.Background()
+	svc.repo = &flakyPayoutRepository{PayoutRepository: svc.repo}
+	b := registerBankAccount(t, svc, a.APIKey, "11111-1")
+
+	created, err := svc.Create(ctx, PayoutCreateInput{APIKey: a.APIKey, BankAccountID: b.ID, Amount: 100})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := svc.ProcessPending(ctx, 10); err == nil {
+		t.Fatalf("expected the outcome not to be stored")
+	}
+	p, _ := svc.repo.GetByID(ctx, a.ID, created.ID)
+	if p.Status != domain.PayoutProcessing || rail.Transfers() != 1 {
+		t.Fatalf("expected a transferred payout left processing, got %s after %d transfers", p.Status, rail.Transfers())
+	}
+
+	// Too recent: it may still be in flight
+	if n, err := svc.Reconcile(ctx, 10); err != nil || n != 0 {
+		t.Fatalf("expected nothing to reconcile yet, got %d %v", n, err)
+	}
+
+	svc.clock.(*domain.FakeClock).Advance(PayoutReconcileAfter + time.Minute)
+	if n, err := svc.Reconcile(ctx, 10); err != nil || n != 1 {
+		t.Fatalf("expected the stuck payout reconciled, got %d %v", n, err)
+	}
+	p, _ = svc.repo.GetByID(ctx, a.ID, created.ID)
+	if p.Status != domain.PayoutPaid || p.TransferReference == "" || !p.UpdatedAt.Equal(svc.clock.Now()) {
+		t.Fatalf("expected paid with the transfer reference, got %+v", p)
+	}
+	// The bank paid once and the money was not refunded
+	if rail.Transfers() != 1 || a.Balance != 100 {
+		t.Fatalf("expected a single transfer and no refund, got %d transfers, balance %v", rail.Transfers(), a.Balance)
+	}
+}
+
+// timeoutRail makes the bank transfer, then times out before answering, once.
+type timeoutRail struct {
+	*domain.SimulatedBankRail
+	timedOut bool
+}
+
+func (r *timeoutRail) Transfer(ctx context.Context, p *domain.Payout, to *domain.BankAccount) (string, error) {
+	ref, err := r.SimulatedBankRail.Transfer(ctx, p, to)
+	if err != nil || r.timedOut {
+		return ref, err
+	}
+	r.timedOut = true
+	return "", context.DeadlineExceeded
+}
+
+func TestPayoutService_RailTimeoutIsNotRefunded(t *testing.T) {
+	svc, a, rail := newPayoutServiceWithBalance(t, 200)
+	ctx := context.Background()
+	svc.rail = &timeoutRail{SimulatedBankRail: rail}
+	b := registerBankAccount(t, svc, a.APIKey, "11111-1")
+
+	created, err := svc.Create(ctx, PayoutCreateInput{APIKey: a.APIKey, BankAccountID: b.ID, Amount: 100})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := svc.ProcessPending(ctx, 10); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the timeout, got %v", err)
+	}
+	// The transfer may have gone through, so the money stays out
+	p, _ := svc.repo.GetByID(ctx, a.ID, created.ID)
+	if p.Status != domain.PayoutProcessing || a.Balance != 100 {
+		t.Fatalf("expected the payout left processing without a refund, got %s, balance %v", p.Status, a.Balance)
+	}
+
+	svc.clock.(*domain.FakeClock).Advance(PayoutReconcileAfter + time.Minute)
+	if n, err := svc.Reconcile(ctx, 10); err != nil || n != 1 {
+		t.Fatalf("expected the payout reconciled, got %d %v", n, err)
+	}
+	p, _ = svc.repo.GetByID(ctx, a.ID, created.ID)
+	if p.Status != domain.PayoutPaid || rail.Transfers() != 1 || a.Balance != 100 {
+		t.Fatalf("expected paid once without a refund, got %s after %d transfers, balance %v", p.Status, rail.Transfers(), a.Balance)
+	}
+}
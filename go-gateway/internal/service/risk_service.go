@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// StaticRiskRules is a fixed rule set, e.g. read once from the configuration.
+type StaticRiskRules []domain.RiskRule
+
+func (s StaticRiskRules) ListRules(ctx context.Context) ([]domain.RiskRule, error) {
+	return s, nil
+}
+
+// RiskRuleFunc adapts a function, e.g. one that re-reads the configuration
+// file, to domain.RiskRuleRepository.
+type RiskRuleFunc func(ctx context.Context) ([]domain.RiskRule, error)
+
+func (f RiskRuleFunc) ListRules(ctx context.Context) ([]domain.RiskRule, error) {
+	return f(ctx)
+}
+
+// RiskService keeps the risk rules in memory and reloads them from their
+// source, so rule changes apply without a restart.
+type RiskService struct {
+	source domain.RiskRuleRepository
+	rules  atomic.Pointer[[]domain.RiskRule]
+}
+
+// NewRiskService evaluates domain.DefaultRiskRules until the first Reload.
+func NewRiskService(source domain.RiskRuleRepository) *RiskService {
+	s := &RiskService{source: source}
+	rules := domain.DefaultRiskRules()
+	s.rules.Store(&rules)
+	return s
+}
+
+// Rules returns the rules in effect. Callers must not modify them.
+func (s *RiskService) Rules() []domain.RiskRule {
+	return *s.rules.Load()
+}
+
+// Reload replaces the rules with those of the source. When any of them is
+// invalid the current rules are kept.
+func (s *RiskService) Reload(ctx context.Context) error {
+	rules, err := s.source.ListRules(ctx)
+	if err != nil {
+		return err
+	}
+	for i, r := range rules {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("risk rule %d (%s): %w", i, r.Kind, err)
+		}
+	}
+	rules = append([]domain.RiskRule{}, rules...)
+	s.rules.Store(&rules)
+	return nil
+}
+
+// Run reloads the rules every interval until ctx is canceled.
+func (s *RiskService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				log.Printf("risk: keeping the current rules: %v", err)
+			}
+		}
+	}
+}
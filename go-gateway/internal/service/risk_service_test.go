@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+func TestRiskService_Reload(t *testing.T) {
+	rules := []domain.RiskRule{{Kind: domain.RiskPaymentTypeBlock, PaymentType: "boleto", Action: domain.RiskReject}}
+	var loadErr error
+	svc := NewRiskService(RiskRuleFunc(func(ctx context.Context) ([]domain.RiskRule, error) {
+		return rules, loadErr
+	}))
+
+	if got := svc.Rules(); len(got) != 1 || got[0].Kind != domain.RiskAmountThreshold {
+		t.Fatalf("expected the default rules before the first reload, got %+v", got)
+	}
+
+	if err := svc.Reload(context.Background()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := svc.Rules(); len(got) != 1 || got[0].PaymentType != "boleto" {
+		t.Fatalf("expected the loaded rules, got %+v", got)
+	}
+
+	// Invalid rules and source errors keep the rules in effect
+	rules = []domain.RiskRule{{Kind: domain.RiskCardVelocity, Action: domain.RiskReject}}
+	if err := svc.Reload(context.Background()); !errors.Is(err, domain.ErrInvalidRiskLimit) {
+		t.Fatalf("expected ErrInvalidRiskLimit, got %v", err)
+	}
+	loadErr = errors.New("db down")
+	if err := svc.Reload(context.Background()); !errors.Is(err, loadErr) {
+		t.Fatalf("expected the source error, got %v", err)
+	}
+	if got := svc.Rules(); len(got) != 1 || got[0].PaymentType != "boleto" {
+		t.Fatalf("expected the previous rules, got %+v", got)
+	}
+
+	// An empty rule set approves everything
+	rules, loadErr = nil, nil
+	if err := svc.Reload(context.Background()); err != nil || len(svc.Rules()) != 0 {
+		t.Fatalf("expected no rules, got %+v %v", svc.Rules(), err)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	pg "github.com/devfullcycle/imersao22/go-gateway/internal/repository/postgres"
+)
+
+// DefaultSettlementBatchSize is how many due settlements a release run picks.
+const DefaultSettlementBatchSize = 100
+
+// SettlementService releases approved money to the available balance once
+// its settlement date is reached.
+type SettlementService struct {
+	repo  domain.SettlementRepository
+	clock domain.Clock
+}
+
+func NewSettlementService(db *sql.DB) *SettlementService {
+	return &SettlementService{repo: pg.NewPostgresSettlementRepository(db), clock: domain.SystemClock}
+}
+
+// SetClock sets the clock that decides which settlements Run releases.
+func (s *SettlementService) SetClock(c domain.Clock) {
+	s.clock = c
+}
+
+// ReleaseDue releases up to limit settlements due at now and returns how
+// many were released.
+func (s *SettlementService) ReleaseDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	due, err := s.repo.ListDue(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for _, st := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := st.MarkSettled(now); err != nil {
+			return done, err
+		}
+		err := s.repo.Release(ctx, st)
+		if errors.Is(err, domain.ErrSettlementNotPending) {
+			continue // released by another replica
+		}
+		if err != nil {
+			return done, err
+		}
+		done++
+	}
+	return done, nil
+}
+
+// Run releases due settlements every interval until ctx is canceled.
+func (s *SettlementService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.ReleaseDue(ctx, s.clock.Now(), DefaultSettlementBatchSize); err != nil {
+				log.Printf("settlements: released %d: %v", n, err)
+			}
+		}
+	}
+}
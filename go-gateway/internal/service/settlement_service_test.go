@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/repository/memory"
+)
+
+func TestSettlementService_ReleaseDue(t *testing.T) {
+	ctx := context.Background()
+	accounts := memory.NewInMemoryAccountRepository()
+	repo := memory.NewSettlementRepositoryMemory(accounts)
+	svc := &SettlementService{repo: repo}
+
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(ctx, a)
+	approved := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	schedule := domain.SettlementSchedule{Delays: map[string]int{"credit_card": 30, "debit_card": 1}}
+	for _, tt := range []struct {
+		paymentType string
+		amount      float64
+	}{{"credit_card", 95.62}, {"debit_card", 49}} {
+		i, _ := domain.NewInvoiceWithProcessor(a.ID, "Order", tt.paymentType, tt.amount, "", domain.NewTestInvoiceProcessor(), domain.SystemClock)
+		_ = i.Process()
+		_ = i.ApplyFee(domain.FeePlan{})
+		_ = repo.Create(ctx, domain.NewSettlement(i, schedule.AvailableAt(tt.paymentType, approved)))
+	}
+
+	n, err := svc.ReleaseDue(ctx, approved.Add(time.Hour), 10)
+	if err != nil || n != 0 {
+		t.Fatalf("expected nothing due on the approval day, got %d %v", n, err)
+	}
+	n, _ = svc.ReleaseDue(ctx, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), 10)
+	if n != 1 || a.Balance != 49 || a.PendingBalance != 95.62 {
+		t.Fatalf("expected the D+1 amount released, got %d %v %v", n, a.Balance, a.PendingBalance)
+	}
+	n, _ = svc.ReleaseDue(ctx, time.Date(2026, 11, 17, 0, 0, 0, 0, time.UTC), 10)
+	if n != 1 || a.Balance != 144.62 || a.PendingBalance != 0 {
+		t.Fatalf("expected the D+30 amount released, got %d %v %v", n, a.Balance, a.PendingBalance)
+	}
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	pg "github.com/devfullcycle/imersao22/go-gateway/internal/repository/postgres"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+// DefaultSubscriptionBatchSize is how many due subscriptions a billing run
+// picks.
+const DefaultSubscriptionBatchSize = 50
+
+// subscriptionClaimLease is how long a claimed cycle is hidden from other
+// replicas. A replica that dies mid-charge leaves the cycle due again after it.
+const subscriptionClaimLease = 10 * time.Minute
+
+// InvoiceCreator creates the invoices of subscription cycles. It matches
+// InvoiceService.Create, so cycles go through the same risk, fee and
+// settlement rules as any other invoice.
+type InvoiceCreator interface {
+	Create(ctx context.Context, in InvoiceCreateInput) (*InvoiceOutput, error)
+}
+
+// SubscriptionService lets merchants sell plans and bills their
+// subscriptions at every cycle.
+type SubscriptionService struct {
+	repo     domain.SubscriptionRepository
+	accounts domain.AccountRepository
+	invoices InvoiceCreator
+	dunning  domain.DunningPolicy
+	clock    domain.Clock
+}
+
+func NewSubscriptionService(db *sql.DB, invoices InvoiceCreator, dunning domain.DunningPolicy) *SubscriptionService {
+	return &SubscriptionService{
+		repo:     pg.NewPostgresSubscriptionRepository(db),
+		accounts: pg.NewPostgresAccountRepository(db),
+		invoices: invoices,
+		dunning:  dunning,
+		clock:    domain.SystemClock,
+	}
+}
+
+// SetClock sets the clock that stamps plans and subscriptions and decides
+// which cycles Run bills.
+func (s *SubscriptionService) SetClock(c domain.Clock) {
+	s.clock = c
+}
+
+func (s *SubscriptionService) CreatePlan(ctx context.Context, in PlanInput) (*PlanOutput, error) {
+	account, err := writableAccount(ctx, s.accounts, in.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if in.IntervalCount == 0 {
+		in.IntervalCount = 1
+	}
+	p, err := domain.NewPlan(account.ID, in.Name, in.Amount, domain.BillingInterval(in.Interval), in.IntervalCount, in.TrialDays, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreatePlan(ctx, p); err != nil {
+		return nil, err
+	}
+	return toPlanOutput(p), nil
+}
+
+func (s *SubscriptionService) ListPlans(ctx context.Context, apiKey string) ([]*PlanOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.repo.ListPlans(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*PlanOutput, 0, len(list))
+	for _, p := range list {
+		out = append(out, toPlanOutput(p))
+	}
+	return out, nil
+}
+
+// Subscribe subscribes a tokenized card to one of the account's plans. The
+// first cycle is billed by the next billing run after it starts.
+func (s *SubscriptionService) Subscribe(ctx context.Context, in SubscriptionInput) (*SubscriptionOutput, error) {
+	account, err := writableAccount(ctx, s.accounts, in.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	var plan *domain.Plan
+	if in.PlanID != "" {
+		if plan, err = s.repo.GetPlan(ctx, account.ID, in.PlanID); err != nil {
+			return nil, err
+		}
+	}
+	var anchor time.Time
+	if in.AnchorDate != nil {
+		anchor = in.AnchorDate.UTC()
+	}
+	sub, err := domain.NewSubscription(plan, in.CardToken, in.CardLastDigits, anchor, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, sub); err != nil {
+		return nil, err
+	}
+	return toSubscriptionOutput(sub), nil
+}
+
+func (s *SubscriptionService) GetByID(ctx context.Context, apiKey, id string) (*SubscriptionOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := s.repo.GetByID(ctx, account.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toSubscriptionOutput(sub), nil
+}
+
+func (s *SubscriptionService) List(ctx context.Context, apiKey string) ([]*SubscriptionOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.repo.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*SubscriptionOutput, 0, len(list))
+	for _, sub := range list {
+		out = append(out, toSubscriptionOutput(sub))
+	}
+	return out, nil
+}
+
+// Cancel stops billing a subscription. Suspended accounts may still cancel,
+// since it only stops charges.
+func (s *SubscriptionService) Cancel(ctx context.Context, apiKey, id string) (*SubscriptionOutput, error) {
+	account, err := readableAccount(ctx, s.accounts, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := s.repo.GetByID(ctx, account.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := sub.Cancel(s.clock.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, sub); err != nil {
+		return nil, err
+	}
+	return toSubscriptionOutput(sub), nil
+}
+
+// BillDue charges up to limit subscriptions due at now and returns how many
+// were charged, successfully or not. Each cycle is claimed first so replicas
+// never charge it twice.
+func (s *SubscriptionService) BillDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	due, err := s.repo.ListDue(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for _, sub := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		err := s.bill(tenant.WithAccount(ctx, sub.AccountID), sub, now)
+		if errors.Is(err, domain.ErrSubscriptionClaimed) {
+			continue // billed by another replica
+		}
+		if errors.Is(err, domain.ErrInvalidSubscriptionTransition) {
+			continue // canceled while being charged; the cancellation stands
+		}
+		if err != nil {
+			return done, err
+		}
+		done++
+	}
+	return done, nil
+}
+
+// bill charges the current cycle of sub as its account. A charge the
+// account or the card cannot make is a failed attempt handled by dunning;
+// an invoice held for review counts as charged, so the cycle is not billed
+// twice.
+func (s *SubscriptionService) bill(ctx context.Context, sub *domain.Subscription, now time.Time) error {
+	if err := s.repo.Claim(ctx, sub, now.Add(subscriptionClaimLease)); err != nil {
+		return err
+	}
+	account, err := s.accounts.GetByID(ctx, sub.AccountID)
+	if err != nil {
+		return err
+	}
+
+	invoice, err := s.invoices.Create(ctx, InvoiceCreateInput{
+		APIKey:         account.APIKey,
+		Amount:         sub.Amount,
+		Description:    sub.Description,
+		PaymentType:    domain.SubscriptionPaymentType,
+		CardLastDigits: sub.CardLastDigits,
+		IdempotencyKey: sub.ChargeKey(),
+	})
+	var invoiceID string
+	paid := false
+	switch {
+	case err == nil:
+		invoiceID = invoice.ID
+		paid = invoice.Status != string(domain.StatusRejected)
+	case chargeRefused(err):
+	default:
+		return err
+	}
+
+	if err := sub.RecordCharge(invoiceID, paid, s.dunning, now); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, sub)
+}
+
+// Run bills due subscriptions every interval until ctx is canceled.
+func (s *SubscriptionService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.BillDue(ctx, s.clock.Now(), DefaultSubscriptionBatchSize); err != nil {
+				log.Printf("subscriptions: billed %d: %v", n, err)
+			}
+		}
+	}
+}
+
+func toPlanOutput(p *domain.Plan) *PlanOutput {
+	return &PlanOutput{
+		ID:            p.ID,
+		Name:          p.Name,
+		Amount:        p.Amount,
+		Interval:      string(p.Interval),
+		IntervalCount: p.IntervalCount,
+		TrialDays:     p.TrialDays,
+		CreatedAt:     p.CreatedAt,
+	}
+}
+
+func toSubscriptionOutput(s *domain.Subscription) *SubscriptionOutput {
+	out := &SubscriptionOutput{
+		ID:             s.ID,
+		PlanID:         s.PlanID,
+		Description:    s.Description,
+		Amount:         s.Amount,
+		Interval:       string(s.Interval),
+		IntervalCount:  s.IntervalCount,
+		CardLastDigits: s.CardLastDigits,
+		Status:         string(s.Status),
+		AnchorAt:       s.AnchorAt,
+		TrialEndsAt:    s.TrialEndsAt,
+		FailedAttempts: s.FailedAttempts,
+		LastInvoiceID:  s.LastInvoiceID,
+		CanceledAt:     s.CanceledAt,
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
+	}
+	if s.Status != domain.SubscriptionCanceled {
+		next := s.NextBillingAt
+		out.NextBillingAt = &next
+	}
+	return out
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/repository/memory"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+// fakeInvoiceCreator answers each charge with the next status, or with err.
+// A charge with the idempotency key of an earlier one replays its invoice.
+// onCreate, when set, runs before each charge.
+type fakeInvoiceCreator struct {
+	statuses []domain.Status
+	err      error
+	calls    []InvoiceCreateInput
+	tenants  []string
+	byKey    map[string]*InvoiceOutput
+	onCreate func()
+}
+
+func (f *fakeInvoiceCreator) Create(ctx context.Context, in InvoiceCreateInput) (*InvoiceOutput, error) {
+	if f.onCreate != nil {
+		f.onCreate()
+	}
+	f.calls = append(f.calls, in)
+	f.tenants = append(f.tenants, tenant.AccountID(ctx))
+	if f.err != nil {
+		return nil, f.err
+	}
+	if out, ok := f.byKey[in.IdempotencyKey]; ok {
+		return out, nil
+	}
+	status := f.statuses[0]
+	f.statuses = f.statuses[1:]
+	out := &InvoiceOutput{ID: "inv-" + string(rune('0'+len(f.calls))), Amount: in.Amount, Status: string(status)}
+	if in.IdempotencyKey != "" {
+		if f.byKey == nil {
+			f.byKey = make(map[string]*InvoiceOutput)
+		}
+		f.byKey[in.IdempotencyKey] = out
+	}
+	return out, nil
+}
+
+func newSubscriptionService(t *testing.T, now time.Time) (*SubscriptionService, *domain.Account, *fakeInvoiceCreator) {
+	t.Helper()
+	accounts := memory.NewInMemoryAccountRepository()
+	a, _ := domain.NewAccount("Acme", "acme@example.com", domain.SystemClock)
+	_ = accounts.Create(context.Background(), a)
+
+	invoices := &fakeInvoiceCreator{}
+	svc := &SubscriptionService{
+		repo:     memory.NewSubscriptionRepositoryMemory(),
+		accounts: accounts,
+		invoices: invoices,
+		dunning:  domain.DunningPolicy{RetryDelays: []time.Duration{24 * time.Hour, 72 * time.Hour}},
+		clock:    domain.NewFakeClock(now),
+	}
+	return svc, a, invoices
+}
+
+func subscribe(t *testing.T, svc *SubscriptionService, apiKey string, plan PlanInput) *SubscriptionOutput {
+	t.Helper()
+	plan.APIKey = apiKey
+	p, err := svc.CreatePlan(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("create plan: %v", err)
+	}
+	sub, err := svc.Subscribe(context.Background(), SubscriptionInput{APIKey: apiKey, PlanID: p.ID, CardToken: "tok_12345678", CardLastDigits: "4242"})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	return sub
+}
+
+func TestSubscriptionService_BillsEachCycle(t *testing.T) {
+	now := time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC)
+	svc, a, invoices := newSubscriptionService(t, now)
+	ctx := tenant.WithPrivileged(context.Background())
+	sub := subscribe(t, svc, a.APIKey, PlanInput{Name: "Pro", Amount: 49.9, Interval: "month"})
+	invoices.statuses = []domain.Status{domain.StatusApproved, domain.StatusApproved}
+
+	if n, err := svc.BillDue(ctx, now, 10); err != nil || n != 1 {
+		t.Fatalf("expected one charge, got %d %v", n, err)
+	}
+	in := invoices.calls[0]
+	if in.APIKey != a.APIKey || in.Amount != 49.9 || in.Description != "Pro" || in.PaymentType != "credit_card" || in.CardLastDigits != "4242" {
+		t.Fatalf("unexpected invoice input %+v", in)
+	}
+	if in.IdempotencyKey != "subscription/"+sub.ID+"/0/0" {
+		t.Fatalf("expected the charge keyed by subscription, cycle and attempt, got %q", in.IdempotencyKey)
+	}
+	if invoices.tenants[0] != a.ID {
+		t.Fatalf("expected the invoice to be created as the account, got %q", invoices.tenants[0])
+	}
+
+	// Nothing more is due until the next cycle
+	if n, _ := svc.BillDue(ctx, now.AddDate(0, 0, 27), 10); n != 0 {
+		t.Fatalf("expected nothing due, got %d", n)
+	}
+	got, _ := svc.GetByID(context.Background(), a.APIKey, sub.ID)
+	if got.Status != "active" || got.LastInvoiceID != "inv-1" || !got.NextBillingAt.Equal(time.Date(2026, 2, 28, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the next cycle on February 28, got %+v", got)
+	}
+
+	if n, _ := svc.BillDue(ctx, *got.NextBillingAt, 10); n != 1 || len(invoices.calls) != 2 {
+		t.Fatalf("expected the second cycle to be charged, got %d", n)
+	}
+}
+
+func TestSubscriptionService_Dunning(t *testing.T) {
+	now := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	svc, a, invoices := newSubscriptionService(t, now)
+	ctx := tenant.WithPrivileged(context.Background())
+	sub := subscribe(t, svc, a.APIKey, PlanInput{Name: "Pro", Amount: 49.9, Interval: "month"})
+	invoices.statuses = []domain.Status{domain.StatusRejected, domain.StatusRejected, domain.StatusRejected}
+
+	_, _ = svc.BillDue(ctx, now, 10)
+	got, _ := svc.GetByID(context.Background(), a.APIKey, sub.ID)
+	if got.Status != "past_due" || got.FailedAttempts != 1 || !got.NextBillingAt.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("expected a retry in 1 day, got %+v", got)
+	}
+
+	retry := now.Add(24 * time.Hour)
+	_, _ = svc.BillDue(ctx, retry, 10)
+	got, _ = svc.GetByID(context.Background(), a.APIKey, sub.ID)
+	if got.Status != "past_due" || got.FailedAttempts != 2 || !got.NextBillingAt.Equal(retry.Add(72*time.Hour)) {
+		t.Fatalf("expected a retry in 3 days, got %+v", got)
+	}
+
+	_, _ = svc.BillDue(ctx, retry.Add(72*time.Hour), 10)
+	got, _ = svc.GetByID(context.Background(), a.APIKey, sub.ID)
+	if got.Status != "canceled" || got.CanceledAt == nil || got.NextBillingAt != nil {
+		t.Fatalf("expected the subscription to be canceled, got %+v", got)
+	}
+	if n, _ := svc.BillDue(ctx, now.AddDate(1, 0, 0), 10); n != 0 || len(invoices.calls) != 3 {
+		t.Fatalf("expected no charge after canceling, got %d", n)
+	}
+}
+
+func TestSubscriptionService_ChargeErrors(t *testing.T) {
+	now := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	svc, a, invoices := newSubscriptionService(t, now)
+	ctx := tenant.WithPrivileged(context.Background())
+	sub := subscribe(t, svc, a.APIKey, PlanInput{Name: "Pro", Amount: 49.9, Interval: "week"})
+
+	// The account cannot charge: a failed attempt, not a job error
+	invoices.err = domain.ErrAccountSuspended
+	if n, err := svc.BillDue(ctx, now, 10); err != nil || n != 1 {
+		t.Fatalf("expected a failed attempt, got %d %v", n, err)
+	}
+	got, _ := svc.GetByID(context.Background(), a.APIKey, sub.ID)
+	if got.Status != "past_due" || got.LastInvoiceID != "" {
+		t.Fatalf("expected past due without an invoice, got %+v", got)
+	}
+
+	// Infrastructure errors stop the run and leave the cycle claimed until
+	// the lease expires
+	boom := errors.New("db down")
+	invoices.err = boom
+	retry := *got.NextBillingAt
+	if _, err := svc.BillDue(ctx, retry, 10); !errors.Is(err, boom) {
+		t.Fatalf("expected the error to be returned, got %v", err)
+	}
+	if n, _ := svc.BillDue(ctx, retry, 10); n != 0 {
+		t.Fatalf("expected the claimed cycle to be skipped, got %d", n)
+	}
+	invoices.err = nil
+	invoices.statuses = []domain.Status{domain.StatusPending}
+	if n, _ := svc.BillDue(ctx, retry.Add(subscriptionClaimLease), 10); n != 1 {
+		t.Fatalf("expected the cycle to be billed after the lease, got %d", n)
+	}
+	// The charge after the lease replays the one that may have gone through
+	if k := invoices.calls[2].IdempotencyKey; k != invoices.calls[1].IdempotencyKey || k == invoices.calls[0].IdempotencyKey {
+		t.Fatalf("expected the retried charge to reuse its key, got %q", k)
+	}
+	got, _ = svc.GetByID(context.Background(), a.APIKey, sub.ID)
+	if got.Status != "active" || got.FailedAttempts != 0 {
+		t.Fatalf("expected an invoice under review to count as charged, got %+v", got)
+	}
+}
+
+func TestSubscriptionService_TrialAndCancel(t *testing.T) {
+	now := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	svc, a, invoices := newSubscriptionService(t, now)
+	ctx := tenant.WithPrivileged(context.Background())
+	sub := subscribe(t, svc, a.APIKey, PlanInput{Name: "Pro", Amount: 49.9, Interval: "month", TrialDays: 14})
+	if sub.Status != "trialing" || sub.TrialEndsAt == nil || !sub.NextBillingAt.Equal(now.AddDate(0, 1, 0)) {
+		t.Fatalf("expected a trial until the next cycle, got %+v", sub)
+	}
+	if n, _ := svc.BillDue(ctx, now.AddDate(0, 0, 20), 10); n != 0 {
+		t.Fatalf("expected nothing due during the trial, got %d", n)
+	}
+
+	out, err := svc.Cancel(context.Background(), a.APIKey, sub.ID)
+	if err != nil || out.Status != "canceled" {
+		t.Fatalf("cancel: %+v %v", out, err)
+	}
+	if _, err := svc.Cancel(context.Background(), a.APIKey, sub.ID); !errors.Is(err, domain.ErrInvalidSubscriptionTransition) {
+		t.Fatalf("expected ErrInvalidSubscriptionTransition, got %v", err)
+	}
+	if n, _ := svc.BillDue(ctx, now.AddDate(0, 2, 0), 10); n != 0 || len(invoices.calls) != 0 {
+		t.Fatalf("expected no charge, got %d", n)
+	}
+}
+
+func TestSubscriptionService_CancelWhileBilling(t *testing.T) {
+	now := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	svc, a, invoices := newSubscriptionService(t, now)
+	ctx := tenant.WithPrivileged(context.Background())
+	sub := subscribe(t, svc, a.APIKey, PlanInput{Name: "Pro", Amount: 49.9, Interval: "month"})
+	invoices.statuses = []domain.Status{domain.StatusApproved}
+
+	// The merchant cancels after the worker loaded and claimed the cycle
+	invoices.onCreate = func() {
+		if _, err := svc.Cancel(context.Background(), a.APIKey, sub.ID); err != nil {
+			t.Errorf("cancel: %v", err)
+		}
+	}
+	if n, err := svc.BillDue(ctx, now, 10); err != nil || n != 0 {
+		t.Fatalf("expected the canceled charge to be skipped, got %d %v", n, err)
+	}
+	got, _ := svc.GetByID(context.Background(), a.APIKey, sub.ID)
+	if got.Status != "canceled" || got.CanceledAt == nil || got.LastInvoiceID != "" {
+		t.Fatalf("expected the cancellation to stand, got %+v", got)
+	}
+}
+
+func TestSubscriptionService_TenantIsolation(t *testing.T) {
+	now := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	svc, a, _ := newSubscriptionService(t, now)
+	other, _ := domain.NewAccount("Other", "other@example.com", domain.SystemClock)
+	_ = svc.accounts.Create(context.Background(), other)
+	sub := subscribe(t, svc, a.APIKey, PlanInput{Name: "Pro", Amount: 49.9, Interval: "month"})
+
+	if _, err := svc.GetByID(context.Background(), other.APIKey, sub.ID); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+		t.Fatalf("expected ErrSubscriptionNotFound, got %v", err)
+	}
+	if _, err := svc.Cancel(context.Background(), other.APIKey, sub.ID); !errors.Is(err, domain.ErrSubscriptionNotFound) {
+		t.Fatalf("expected ErrSubscriptionNotFound, got %v", err)
+	}
+	_, err := svc.Subscribe(context.Background(), SubscriptionInput{APIKey: other.APIKey, PlanID: sub.PlanID, CardToken: "tok_12345678", CardLastDigits: "4242"})
+	if !errors.Is(err, domain.ErrPlanNotFound) {
+		t.Fatalf("expected ErrPlanNotFound, got %v", err)
+	}
+	if list, _ := svc.ListPlans(context.Background(), other.APIKey); len(list) != 0 {
+		t.Fatalf("expected no plans, got %+v", list)
+	}
+	if list, _ := svc.List(context.Background(), other.APIKey); len(list) != 0 {
+		t.Fatalf("expected no subscriptions, got %+v", list)
+	}
+}
@@ -0,0 +1,36 @@
+// Package tenant carries the account a request acts for, so the storage layer
+// can enforce the tenant boundary independently of the application checks.
+package tenant
+
+import "context"
+
+type contextKey int
+
+const (
+	accountKey contextKey = iota
+	privilegedKey
+)
+
+// WithAccount binds ctx to the account authenticated for the request. It
+// drops any privilege of ctx, so a background job can act for one account.
+func WithAccount(ctx context.Context, accountID string) context.Context {
+	return context.WithValue(context.WithValue(ctx, privilegedKey, false), accountKey, accountID)
+}
+
+// AccountID returns the account bound to ctx, or "" when there is none.
+func AccountID(ctx context.Context) string {
+	id, _ := ctx.Value(accountKey).(string)
+	return id
+}
+
+// WithPrivileged marks ctx as acting for the platform rather than an account,
+// e.g. the admin API and background jobs, which may see every account.
+func WithPrivileged(ctx context.Context) context.Context {
+	return context.WithValue(ctx, privilegedKey, true)
+}
+
+// Privileged reports whether ctx was marked by WithPrivileged.
+func Privileged(ctx context.Context) bool {
+	p, _ := ctx.Value(privilegedKey).(bool)
+	return p
+}
@@ -0,0 +1,27 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if AccountID(ctx) != "" || Privileged(ctx) {
+		t.Fatal("expected an empty context to have no account and no privilege")
+	}
+
+	ctx = WithAccount(ctx, "acc-1")
+	if AccountID(ctx) != "acc-1" || Privileged(ctx) {
+		t.Fatalf("expected account acc-1 without privilege, got %q %v", AccountID(ctx), Privileged(ctx))
+	}
+
+	if !Privileged(WithPrivileged(context.Background())) {
+		t.Fatal("expected a privileged context")
+	}
+
+	ctx = WithAccount(WithPrivileged(context.Background()), "acc-2")
+	if AccountID(ctx) != "acc-2" || Privileged(ctx) {
+		t.Fatalf("expected an account to drop the privilege, got %q %v", AccountID(ctx), Privileged(ctx))
+	}
+}
@@ -0,0 +1,69 @@
+// Package testdb opens the Postgres database of TEST_DATABASE_URL for
+// integration tests, both as its owner and as the role the gateway runs as.
+package testdb
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/migrate"
+	"github.com/devfullcycle/imersao22/go-gateway/migrations"
+	_ "github.com/lib/pq"
+)
+
+// AppRole is the login the integration tests connect as: a member of
+// gateway_app, like the gateway itself, so its grants and row-level
+// security apply.
+const AppRole = "gateway_rls_test"
+
+// Open migrates the database of TEST_DATABASE_URL, which must connect as a
+// superuser, and returns a connection as the owner and one as AppRole. The
+// test is skipped without TEST_DATABASE_URL.
+func Open(t *testing.T) (owner, app *sql.DB) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+
+	owner, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open owner: %v", err)
+	}
+	t.Cleanup(func() { owner.Close() })
+
+	m, err := migrate.New(owner, migrations.FS)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	_, err = owner.ExecContext(ctx, `
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = '`+AppRole+`') THEN
+				CREATE ROLE `+AppRole+` LOGIN PASSWORD '`+AppRole+`' NOSUPERUSER NOBYPASSRLS IN ROLE gateway_app;
+			END IF;
+		END
+		$$`)
+	if err != nil {
+		t.Fatalf("create app role: %v", err)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		t.Fatalf("TEST_DATABASE_URL must be a postgres:// URL, got %q", dsn)
+	}
+	u.User = url.UserPassword(AppRole, AppRole)
+	app, err = sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatalf("open app: %v", err)
+	}
+	t.Cleanup(func() { app.Close() })
+	return owner, app
+}
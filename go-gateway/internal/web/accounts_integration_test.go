@@ -29,8 +29,8 @@ func TestAccount_CreateAndGet_Success(t *testing.T) {
 	defer ts.Close()
 	defer db.Close()
 
-	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO accounts (id, name, email, api_key, balance, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)")).
-		WithArgs(sqlmock.AnyArg(), "John Doe", "john@example.com", sqlmock.AnyArg(), 0.0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO accounts (id, name, email, api_key, balance, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)")).
+		WithArgs(sqlmock.AnyArg(), "John Doe", "john@example.com", sqlmock.AnyArg(), 0.0, "active", sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	body := bytes.NewBufferString(`{"name":"John Doe","email":"john@example.com"}`)
@@ -50,9 +50,9 @@ func TestAccount_CreateAndGet_Success(t *testing.T) {
 	}
 
 	now := time.Now().UTC()
-	rows := sqlmock.NewRows([]string{"id", "name", "email", "api_key", "balance", "created_at", "updated_at"}).
-		AddRow(created["id"], created["name"], created["email"], apiKey, 0.0, now, now)
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, created_at, updated_at FROM accounts WHERE api_key = $1")).
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "api_key", "balance", "pending_balance", "status", "created_at", "updated_at"}).
+		AddRow(created["id"], created["name"], created["email"], apiKey, 0.0, 0.0, "active", now, now)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE api_key = $1")).
 		WithArgs(apiKey).WillReturnRows(rows)
 
 	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/accounts", nil)
@@ -98,8 +98,8 @@ func TestAccount_Create_InvalidName(t *testing.T) {
 	if err != nil {
 		t.Fatalf("post: %v", err)
 	}
-	if resp.StatusCode != http.StatusBadRequest {
-		t.Fatalf("expected 400 got %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 got %d", resp.StatusCode)
 	}
 	resp.Body.Close()
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -132,7 +132,7 @@ func TestAccount_Get_NotFound(t *testing.T) {
 	defer db.Close()
 
 	apiKey := "does-not-exist"
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, created_at, updated_at FROM accounts WHERE api_key = $1")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE api_key = $1")).
 		WithArgs(apiKey).WillReturnError(sql.ErrNoRows)
 
 	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/accounts", nil)
@@ -149,3 +149,106 @@ func TestAccount_Get_NotFound(t *testing.T) {
 		t.Fatalf("unmet expectations: %v", err)
 	}
 }
+
+func TestAccount_RequestIDEchoedInProblem(t *testing.T) {
+	ts, _, db := newTestServer(t)
+	defer ts.Close()
+	defer db.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/accounts", nil)
+	req.Header.Set("X-Request-Id", "req-123")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("X-Request-Id") != "req-123" {
+		t.Fatalf("expected request id header, got %q", resp.Header.Get("X-Request-Id"))
+	}
+	var problem map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&problem)
+	if problem["request_id"] != "req-123" || problem["code"] != "auth.missing_api_key" {
+		t.Fatalf("unexpected problem: %v", problem)
+	}
+}
+
+func TestAccount_StatusEnforcement(t *testing.T) {
+	now := time.Now().UTC()
+	accountRow := func(mock sqlmock.Sqlmock, status string) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE api_key = $1")).
+			WithArgs("key-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "api_key", "balance", "pending_balance", "status", "created_at", "updated_at"}).
+				AddRow("acc-1", "Acme", "acme@example.com", "key-1", 10.0, 0.0, status, now, now))
+	}
+
+	tests := []struct {
+		name   string
+		status string
+		method string
+		path   string
+		body   string
+		want   int
+		code   string
+		expect func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name: "suspended can read account", status: "suspended", method: http.MethodGet, path: "/v1/accounts", want: http.StatusOK,
+		},
+		{
+			name: "suspended can list invoices", status: "suspended", method: http.MethodGet, path: "/v1/invoices", want: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "suspended")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM invoices WHERE account_id`).WillReturnRows(sqlmock.NewRows(invoiceColumns))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "suspended cannot charge", status: "suspended", method: http.MethodPost, path: "/v1/invoices",
+			body: `{"amount":10,"description":"Test invoice","payment_type":"pix"}`, want: http.StatusForbidden, code: "account.suspended",
+		},
+		{
+			name: "closed cannot authenticate", status: "closed", method: http.MethodGet, path: "/v1/invoices",
+			want: http.StatusForbidden, code: "account.closed",
+		},
+		{
+			name: "closed cannot read account", status: "closed", method: http.MethodGet, path: "/v1/accounts",
+			want: http.StatusForbidden, code: "account.closed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, mock, db := newTestServer(t)
+			defer ts.Close()
+			defer db.Close()
+
+			accountRow(mock, tt.status)
+			if tt.expect != nil {
+				tt.expect(mock)
+			}
+
+			req, _ := http.NewRequest(tt.method, ts.URL+tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("X-API-KEY", "key-1")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Fatalf("expected %d got %d", tt.want, resp.StatusCode)
+			}
+			if tt.code != "" {
+				var problem map[string]any
+				_ = json.NewDecoder(resp.Body).Decode(&problem)
+				if problem["code"] != tt.code {
+					t.Fatalf("expected code %s got %v", tt.code, problem["code"])
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,884 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+const (
+	accountQuery = `SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE api_key = \$1`
+	invoiceCols  = "id, account_id, amount, installments, total_amount, fee, net_amount, status, description, payment_type, card_last_digits, customer_id, saved_card_id, items, metadata, external_reference, idempotency_key, risk_decision, risk_reasons, created_at, updated_at"
+	adminToken   = "contract-admin-token-0123456789abcdef"
+)
+
+var (
+	accountColumns      = []string{"id", "name", "email", "api_key", "balance", "pending_balance", "status", "created_at", "updated_at"}
+	invoiceColumns      = strings.Split(invoiceCols, ", ")
+	planColumns         = []string{"id", "account_id", "name", "amount", "billing_interval", "interval_count", "trial_days", "created_at"}
+	subscriptionColumns = []string{"id", "account_id", "plan_id", "description", "amount", "billing_interval", "interval_count", "card_token", "card_last_digits",
+		"status", "anchor_at", "trial_ends_at", "cycle", "next_billing_at", "failed_attempts", "last_invoice_id", "canceled_at", "created_at", "updated_at"}
+	checkoutSessionColumns = []string{"id", "account_id", "amount", "description", "payment_types", "success_url", "cancel_url", "status",
+		"invoice_id", "payment_key", "expires_at", "completed_at", "created_at", "updated_at"}
+	installmentRulesColumns = []string{"account_id", "max_installments", "free_installments", "monthly_interest", "updated_at"}
+	disputeColumns          = []string{"id", "account_id", "invoice_id", "amount", "reason", "status", "evidence", "evidence_due_by",
+		"evidence_submitted_at", "resolved_at", "created_at", "updated_at"}
+	customerColumns = []string{"id", "account_id", "name", "email", "document", "created_at"}
+)
+
+// TestContract_RoutesMatchSpec fails when a route is added to the router
+// without being documented, or documented without being served.
+func TestContract_RoutesMatchSpec(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	var served []string
+	walk := func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		served = append(served, method+" "+route)
+		return nil
+	}
+	if err := chi.Walk(ConfigureRoutes(db).(chi.Routes), walk); err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	sort.Strings(served)
+
+	documented := APISpec().Operations()
+	if strings.Join(served, "\n") != strings.Join(documented, "\n") {
+		t.Fatalf("router and spec drifted\nserved:\n  %s\ndocumented:\n  %s",
+			strings.Join(served, "\n  "), strings.Join(documented, "\n  "))
+	}
+}
+
+// TestContract_ResponsesMatchSpec exercises every route and validates the
+// actual responses against the documented schemas.
+func TestContract_ResponsesMatchSpec(t *testing.T) {
+	now := time.Now().UTC()
+	accountRow := func(mock sqlmock.Sqlmock, apiKey string) {
+		mock.ExpectQuery(accountQuery).WithArgs(apiKey).
+			WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", apiKey, 10.0, 0.0, "active", now, now))
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		apiKey string
+		admin  bool
+		body   string
+		status int
+		expect func(mock sqlmock.Sqlmock)
+	}{
+		{name: "healthz", method: http.MethodGet, path: "/healthz", status: http.StatusOK},
+		{name: "openapi", method: http.MethodGet, path: "/openapi.json", status: http.StatusOK},
+		{
+			name: "create account", method: http.MethodPost, path: "/accounts",
+			body: `{"name":"John Doe","email":"john@example.com"}`, status: http.StatusCreated,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO accounts`).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "create account invalid", method: http.MethodPost, path: "/accounts",
+			body: `{"name":"J","email":"nope"}`, status: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "get account", method: http.MethodGet, path: "/accounts", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) { accountRow(mock, "key-1") },
+		},
+		{
+			name: "get account unauthorized", method: http.MethodGet, path: "/accounts", status: http.StatusUnauthorized,
+		},
+		{
+			name: "create invoice", method: http.MethodPost, path: "/invoices", apiKey: "key-1",
+			body:   `{"amount":100.50,"description":"Test invoice","payment_type":"credit_card","card_last_digits":"1234"}`,
+			status: http.StatusCreated,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1") // auth middleware
+				accountRow(mock, "key-1") // service
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`INSERT INTO invoices`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "create invoice in installments", method: http.MethodPost, path: "/v1/invoices", apiKey: "key-1",
+			body:   `{"amount":90,"description":"Test invoice","payment_type":"credit_card","card_last_digits":"1234","installments":3}`,
+			status: http.StatusCreated,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM installment_rules WHERE account_id = \$1`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(installmentRulesColumns).AddRow("acc-1", 6, 1, 1.99, now))
+				mock.ExpectCommit()
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`INSERT INTO invoices`).
+					WithArgs(sqlmock.AnyArg(), "acc-1", 90.0, 3, 93.6, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				for n := 1; n <= 3; n++ {
+					mock.ExpectExec(`INSERT INTO invoice_installments`).WithArgs(sqlmock.AnyArg(), "acc-1", n, 31.2, sqlmock.AnyArg()).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "create invoice installments above limit", method: http.MethodPost, path: "/v2/invoices", apiKey: "key-1",
+			body:   `{"amount_cents":9000,"description":"Test invoice","payment_type":"credit_card","installments":12}`,
+			status: http.StatusUnprocessableEntity,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM installment_rules WHERE account_id = \$1`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(installmentRulesColumns).AddRow("acc-1", 6, 1, 1.99, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "get invoice in installments", method: http.MethodGet, path: "/v2/invoices/inv-1", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`SELECT `+invoiceCols+` FROM invoices WHERE id`).WithArgs("inv-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows(invoiceColumns).
+						AddRow("inv-1", "acc-1", 90.0, 2, 91.8, 0.0, 0.0, "rejected", "Test invoice", "credit_card", "1234", nil, nil, "[]", "{}", nil, nil, "approve", "{}", now, now))
+				mock.ExpectQuery(`SELECT number, amount, due_at FROM invoice_installments WHERE invoice_id = \$1`).WithArgs("inv-1").
+					WillReturnRows(sqlmock.NewRows([]string{"number", "amount", "due_at"}).
+						AddRow(1, 45.9, now).AddRow(2, 45.9, now.AddDate(0, 1, 0)))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "get installment rules default", method: http.MethodGet, path: "/v1/installment-rules", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM installment_rules WHERE account_id = \$1`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(installmentRulesColumns))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "put installment rules", method: http.MethodPut, path: "/v2/installment-rules", apiKey: "key-1",
+			body: `{"max_installments":6,"free_installments":1,"monthly_interest":1.99}`, status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`INSERT INTO installment_rules`).WithArgs("acc-1", 6, 1, 1.99, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "put installment rules invalid", method: http.MethodPut, path: "/installment-rules", apiKey: "key-1",
+			body: `{"max_installments":6,"free_installments":8,"monthly_interest":1.99}`, status: http.StatusUnprocessableEntity,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+			},
+		},
+		{
+			name: "list invoices empty", method: http.MethodGet, path: "/invoices", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`SELECT ` + invoiceCols + ` FROM invoices WHERE account_id`).
+					WillReturnRows(sqlmock.NewRows(invoiceColumns))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "list invoices", method: http.MethodGet, path: "/invoices", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`SELECT ` + invoiceCols + ` FROM invoices WHERE account_id`).
+					WillReturnRows(sqlmock.NewRows(invoiceColumns).
+						AddRow("inv-1", "acc-1", 100.5, 1, 100.5, 4.4, 96.1, "approved", "Test invoice", "credit_card", "1234", nil, nil, "[]", "{}", nil, nil, "approve", "{}", now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "list invoices by external reference", method: http.MethodGet, path: "/invoices?external_reference=order-42", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`SELECT `+invoiceCols+` FROM invoices WHERE account_id = \$1 AND external_reference = \$2`).WithArgs("acc-1", "order-42").
+					WillReturnRows(sqlmock.NewRows(invoiceColumns).
+						AddRow("inv-1", "acc-1", 100.5, 1, 100.5, 4.4, 96.1, "approved", "Test invoice", "credit_card", "1234", nil, nil,
+							`[{"sku":"tee","quantity":2,"unit_price":50.25}]`, `{"channel":"web"}`, "order-42", nil, "approve", "{}", now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "get invoice", method: http.MethodGet, path: "/invoices/inv-1", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`SELECT `+invoiceCols+` FROM invoices WHERE id`).WithArgs("inv-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows(invoiceColumns).
+						AddRow("inv-1", "acc-1", 100.5, 1, 100.5, 0.0, 0.0, "pending", "Test invoice", "pix", "", nil, nil, "[]", "{}", nil, nil, "review", `{"amount_threshold: amount 100.50 above the limit of 100.00"}`, now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "get invoice not found", method: http.MethodGet, path: "/invoices/missing", apiKey: "key-1", status: http.StatusNotFound,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`SELECT `+invoiceCols+` FROM invoices WHERE id`).WithArgs("missing", "acc-1").
+					WillReturnRows(sqlmock.NewRows(invoiceColumns))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "v1 get invoice", method: http.MethodGet, path: "/v1/invoices/inv-1", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`SELECT `+invoiceCols+` FROM invoices WHERE id`).WithArgs("inv-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows(invoiceColumns).
+						AddRow("inv-1", "acc-1", 100.5, 1, 100.5, 0.0, 0.0, "pending", "Test invoice", "pix", "", nil, nil, "[]", "{}", nil, nil, "review", `{"amount_threshold: amount 100.50 above the limit of 100.00"}`, now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "v2 get account", method: http.MethodGet, path: "/v2/accounts", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) { accountRow(mock, "key-1") },
+		},
+		{
+			name: "v2 create invoice", method: http.MethodPost, path: "/v2/invoices", apiKey: "key-1",
+			body:   `{"amount_cents":10050,"description":"Test invoice","payment_type":"pix"}`,
+			status: http.StatusCreated,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`INSERT INTO invoices`).
+					WithArgs(sqlmock.AnyArg(), "acc-1", 100.5, 1, 100.5, 0.0, 0.0, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "v2 create invoice decimal amount", method: http.MethodPost, path: "/v2/invoices", apiKey: "key-1",
+			body: `{"amount":100.5,"description":"Test invoice","payment_type":"pix"}`, status: http.StatusUnprocessableEntity,
+			expect: func(mock sqlmock.Sqlmock) { accountRow(mock, "key-1") },
+		},
+		{
+			name: "create invoice batch", method: http.MethodPost, path: "/v1/invoices/batch", apiKey: "key-1",
+			body: `[{"amount":100.5,"description":"Test invoice","payment_type":"pix","idempotency_key":"batch-1/1"},
+				{"amount":100.5,"description":"Other tenant","payment_type":"pix","api_key":"key-2"}]`,
+			status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1") // auth middleware
+				accountRow(mock, "key-1") // batch
+				accountRow(mock, "key-1") // invoice
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`SELECT `+invoiceCols+` FROM invoices WHERE account_id = \$1 AND idempotency_key = \$2`).WithArgs("acc-1", "batch-1/1").
+					WillReturnRows(sqlmock.NewRows(invoiceColumns))
+				mock.ExpectRollback()
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`INSERT INTO invoices`).
+					WithArgs(sqlmock.AnyArg(), "acc-1", 100.5, 1, 100.5, 0.0, 0.0, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "batch-1/1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "v2 create invoice batch replayed", method: http.MethodPost, path: "/v2/invoices/batch", apiKey: "key-1",
+			body:   `[{"amount_cents":10050,"description":"Test invoice","payment_type":"pix","idempotency_key":"batch-1/1"}]`,
+			status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`SELECT `+invoiceCols+` FROM invoices WHERE account_id = \$1 AND idempotency_key = \$2`).WithArgs("acc-1", "batch-1/1").
+					WillReturnRows(sqlmock.NewRows(invoiceColumns).
+						AddRow("inv-1", "acc-1", 100.5, 1, 100.5, 0.0, 0.0, "pending", "Test invoice", "pix", "", nil, nil, "[]", "{}", nil, "batch-1/1", "review", `{"amount_threshold: amount 100.50 above the limit of 100.00"}`, now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "create invoice batch empty", method: http.MethodPost, path: "/invoices/batch", apiKey: "key-1",
+			body: `[]`, status: http.StatusUnprocessableEntity,
+			expect: func(mock sqlmock.Sqlmock) { accountRow(mock, "key-1") },
+		},
+		{
+			name: "v2 list invoices", method: http.MethodGet, path: "/v2/invoices", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`SELECT ` + invoiceCols + ` FROM invoices WHERE account_id`).
+					WillReturnRows(sqlmock.NewRows(invoiceColumns).
+						AddRow("inv-1", "acc-1", 100.5, 1, 100.5, 4.4, 96.1, "approved", "Test invoice", "credit_card", "1234", nil, nil, "[]", "{}", nil, nil, "approve", "{}", now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "create bank account", method: http.MethodPost, path: "/v1/bank-accounts", apiKey: "key-1",
+			body:   `{"bank_code":"341","branch":"0001","number":"12345-6","holder_name":"John Doe"}`,
+			status: http.StatusCreated,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`INSERT INTO bank_accounts`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "create bank account invalid", method: http.MethodPost, path: "/v1/bank-accounts", apiKey: "key-1",
+			body:   `{"bank_code":"3","branch":"0001","number":"12345-6","holder_name":"John Doe"}`,
+			status: http.StatusUnprocessableEntity,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+			},
+		},
+		{
+			name: "list bank accounts", method: http.MethodGet, path: "/v2/bank-accounts", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM bank_accounts WHERE account_id`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "bank_code", "branch", "number", "holder_name", "created_at"}).
+						AddRow("bank-1", "acc-1", "341", "0001", "12345-6", "John Doe", now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "create payout", method: http.MethodPost, path: "/v1/payouts", apiKey: "key-1",
+			body: `{"bank_account_id":"bank-1","amount":20}`, status: http.StatusCreated,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM bank_accounts WHERE id`).WithArgs("bank-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "bank_code", "branch", "number", "holder_name", "created_at"}).
+						AddRow("bank-1", "acc-1", "341", "0001", "12345-6", "John Doe", now))
+				mock.ExpectCommit()
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM accounts WHERE id = \$1 FOR UPDATE`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 100.0, 0.0, "active", now, now))
+				mock.ExpectExec(`UPDATE accounts SET balance`).WithArgs(80.0, sqlmock.AnyArg(), "acc-1").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`INSERT INTO payouts`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "v2 create payout insufficient funds", method: http.MethodPost, path: "/v2/payouts", apiKey: "key-1",
+			body: `{"bank_account_id":"bank-1","amount_cents":2000}`, status: http.StatusUnprocessableEntity,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM bank_accounts WHERE id`).WithArgs("bank-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "bank_code", "branch", "number", "holder_name", "created_at"}).
+						AddRow("bank-1", "acc-1", "341", "0001", "12345-6", "John Doe", now))
+				mock.ExpectCommit()
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM accounts WHERE id = \$1 FOR UPDATE`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 10.0, 0.0, "active", now, now))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "list payouts", method: http.MethodGet, path: "/v1/payouts", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM payouts WHERE account_id`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "bank_account_id", "amount", "status", "failure_reason", "transfer_reference", "created_at", "updated_at"}).
+						AddRow("pay-1", "acc-1", "bank-1", 20.0, "failed", "account closed", "", now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "v2 get payout", method: http.MethodGet, path: "/v2/payouts/pay-1", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM payouts WHERE id`).WithArgs("pay-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "bank_account_id", "amount", "status", "failure_reason", "transfer_reference", "created_at", "updated_at"}).
+						AddRow("pay-1", "acc-1", "bank-1", 20.0, "paid", "", "ref-1", now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "get payout not found", method: http.MethodGet, path: "/v1/payouts/missing", apiKey: "key-1", status: http.StatusNotFound,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM payouts WHERE id`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "create plan", method: http.MethodPost, path: "/v1/plans", apiKey: "key-1",
+			body: `{"name":"Pro","amount":49.9,"interval":"month","trial_days":7}`, status: http.StatusCreated,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`INSERT INTO plans`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "v2 create plan invalid interval", method: http.MethodPost, path: "/v2/plans", apiKey: "key-1",
+			body: `{"name":"Pro","amount_cents":4990,"interval":"fortnight"}`, status: http.StatusUnprocessableEntity,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+			},
+		},
+		{
+			name: "v2 list plans", method: http.MethodGet, path: "/v2/plans", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM plans WHERE account_id`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(planColumns).AddRow("plan-1", "acc-1", "Pro", 49.9, "month", 1, 7, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "create subscription", method: http.MethodPost, path: "/v1/subscriptions", apiKey: "key-1",
+			body:   `{"plan_id":"plan-1","card_token":"tok_12345678","card_last_digits":"4242"}`,
+			status: http.StatusCreated,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM plans WHERE id`).WithArgs("plan-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows(planColumns).AddRow("plan-1", "acc-1", "Pro", 49.9, "month", 1, 7, now))
+				mock.ExpectCommit()
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`INSERT INTO subscriptions`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "v2 get subscription", method: http.MethodGet, path: "/v2/subscriptions/sub-1", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM subscriptions WHERE id`).WithArgs("sub-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows(subscriptionColumns).AddRow("sub-1", "acc-1", "plan-1", "Pro", 49.9, "month", 1, "tok_12345678", "4242",
+						"past_due", now, nil, 1, now, 1, "inv-1", nil, now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "cancel canceled subscription", method: http.MethodPost, path: "/v1/subscriptions/sub-1/cancel", apiKey: "key-1",
+			status: http.StatusConflict,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM subscriptions WHERE id`).WithArgs("sub-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows(subscriptionColumns).AddRow("sub-1", "acc-1", "plan-1", "Pro", 49.9, "month", 1, "tok_12345678", "4242",
+						"canceled", now, nil, 1, now, 0, "", now, now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "create checkout session", method: http.MethodPost, path: "/v1/checkout-sessions", apiKey: "key-1",
+			body:   `{"amount":99.9,"description":"Order #42","payment_types":["pix"],"success_url":"https://shop.example.com/ok"}`,
+			status: http.StatusCreated,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`INSERT INTO checkout_sessions`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "v2 get checkout session", method: http.MethodGet, path: "/v2/checkout-sessions/cs-1", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM checkout_sessions WHERE id`).WithArgs("cs-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows(checkoutSessionColumns).AddRow("cs-1", "acc-1", 99.9, "Order #42", "{pix}", "https://shop.example.com/ok", "",
+						"completed", "inv-1", "checkout/cs-1/k1", now.Add(time.Hour), now, now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "public checkout", method: http.MethodGet, path: "/v1/checkout/cs-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectCheckoutLookup(mock, now.Add(time.Hour))
+				mock.ExpectQuery(`FROM accounts WHERE id = \$1`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 10.0, 0.0, "active", now, now))
+			},
+		},
+		{
+			name: "v2 pay checkout rejected", method: http.MethodPost, path: "/v2/checkout/cs-1/pay",
+			body: `{"payment_type":"pix"}`, status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectCheckoutLookup(mock, now.Add(time.Hour))
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`UPDATE checkout_sessions SET status = \$1, completed_at`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`FROM accounts WHERE id = \$1`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 10.0, 0.0, "active", now, now))
+				accountRow(mock, "key-1")
+				// The invoice is keyed by the claim
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM invoices WHERE account_id = \$1 AND idempotency_key = \$2`).WithArgs("acc-1", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows(invoiceColumns))
+				mock.ExpectRollback()
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`INSERT INTO invoices`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				// The rejected payment reopens the session
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`UPDATE checkout_sessions SET status = \$1, invoice_id`).WithArgs("open", "", "", nil, sqlmock.AnyArg(), "cs-1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "pay expired checkout", method: http.MethodPost, path: "/v1/checkout/cs-1/pay",
+			body: `{"payment_type":"pix"}`, status: http.StatusGone,
+			expect: func(mock sqlmock.Sqlmock) { expectCheckoutLookup(mock, now.Add(-time.Minute)) },
+		},
+		{
+			name: "admin list accounts", method: http.MethodGet, path: "/admin/accounts?q=john&limit=10", admin: true, status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM accounts`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectQuery(`FROM accounts .* LIMIT`).WithArgs("%john%", "", 10, 0).
+					WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 10.0, 0.0, "active", now, now))
+			},
+		},
+		{
+			name: "admin list accounts invalid limit", method: http.MethodGet, path: "/admin/accounts?limit=0", admin: true,
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "admin list accounts unauthorized", method: http.MethodGet, path: "/admin/accounts", status: http.StatusUnauthorized,
+		},
+		{
+			name: "admin suspend account", method: http.MethodPost, path: "/admin/accounts/acc-1/suspend", admin: true, status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM accounts WHERE id = \$1`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 10.0, 0.0, "active", now, now))
+				mock.ExpectExec(`UPDATE accounts SET status`).WithArgs("suspended", sqlmock.AnyArg(), "acc-1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "admin close account", method: http.MethodPost, path: "/admin/accounts/acc-1/close", admin: true, status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM accounts WHERE id = \$1`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 10.0, 0.0, "suspended", now, now))
+				mock.ExpectExec(`UPDATE accounts SET status`).WithArgs("closed", sqlmock.AnyArg(), "acc-1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "admin set fee plan", method: http.MethodPut, path: "/admin/accounts/acc-1/fee-plans/credit_card", admin: true,
+			body: `{"percent":3.99,"fixed":0.39}`, status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM accounts WHERE id = \$1`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 10.0, 0.0, "active", now, now))
+				expectPrivilegedTx(mock)
+				mock.ExpectExec(`INSERT INTO fee_plans`).WithArgs("acc-1", "credit_card", 3.99, 0.39, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "admin set fee plan invalid", method: http.MethodPut, path: "/admin/accounts/acc-1/fee-plans/pix", admin: true,
+			body: `{"percent":-1,"fixed":0}`, status: http.StatusUnprocessableEntity,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM accounts WHERE id = \$1`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 10.0, 0.0, "active", now, now))
+			},
+		},
+		{
+			name: "admin list fee plans", method: http.MethodGet, path: "/admin/accounts/acc-1/fee-plans", admin: true, status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM accounts WHERE id = \$1`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 10.0, 0.0, "active", now, now))
+				expectPrivilegedTx(mock)
+				mock.ExpectQuery(`FROM fee_plans WHERE account_id`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows([]string{"account_id", "payment_type", "percent", "fixed", "updated_at"}).
+						AddRow("acc-1", "credit_card", 3.99, 0.39, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "admin revenue", method: http.MethodGet, path: "/admin/revenue?from=2026-10-01&to=2026-11-01", admin: true, status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectPrivilegedTx(mock)
+				mock.ExpectQuery(`FROM invoice_fees`).
+					WillReturnRows(sqlmock.NewRows([]string{"payment_type", "count", "gross", "fees"}).
+						AddRow("credit_card", 2, 200.0, 8.76))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "admin revenue invalid period", method: http.MethodGet, path: "/admin/revenue?from=2026-11-01&to=2026-10-01", admin: true,
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "list disputes", method: http.MethodGet, path: "/v2/disputes", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM disputes WHERE account_id = \$1`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(disputeColumns).
+						AddRow("dsp-1", "acc-1", "inv-1", 80.0, "fraudulent", "lost", "", now, nil, now, now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "submit dispute evidence", method: http.MethodPost, path: "/v1/disputes/dsp-1/evidence", apiKey: "key-1",
+			body: `{"evidence":"tracking code BR123456789"}`, status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM disputes WHERE id = \$1 AND account_id = \$2`).WithArgs("dsp-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows(disputeColumns).
+						AddRow("dsp-1", "acc-1", "inv-1", 80.0, "fraudulent", "needs_response", "", now.Add(time.Hour), nil, nil, now, now))
+				mock.ExpectCommit()
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`UPDATE disputes SET status = \$1, evidence = \$2`).
+					WithArgs("under_review", "tracking code BR123456789", sqlmock.AnyArg(), sqlmock.AnyArg(), "dsp-1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "submit dispute evidence late", method: http.MethodPost, path: "/disputes/dsp-1/evidence", apiKey: "key-1",
+			body: `{"evidence":"tracking code BR123456789"}`, status: http.StatusConflict,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM disputes WHERE id = \$1 AND account_id = \$2`).WithArgs("dsp-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows(disputeColumns).
+						AddRow("dsp-1", "acc-1", "inv-1", 80.0, "fraudulent", "needs_response", "", now.Add(-time.Hour), nil, nil, now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "admin open dispute", method: http.MethodPost, path: "/admin/invoices/inv-1/disputes", admin: true,
+			body: `{"reason":"fraudulent"}`, status: http.StatusCreated,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectPrivilegedTx(mock)
+				mock.ExpectQuery(`FROM invoices WHERE id = \$1`).WithArgs("inv-1").
+					WillReturnRows(sqlmock.NewRows(invoiceColumns).
+						AddRow("inv-1", "acc-1", 80.0, 1, 80.0, 0.0, 80.0, "approved", "Test invoice", "credit_card", "1234", nil, nil, "[]", "{}", nil, nil, "approve", "{}", now, now))
+				mock.ExpectCommit()
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`INSERT INTO disputes`).
+					WithArgs(sqlmock.AnyArg(), "acc-1", "inv-1", 80.0, "fraudulent", "needs_response", "", sqlmock.AnyArg(), nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE accounts SET balance = balance - \$1`).WithArgs(80.0, sqlmock.AnyArg(), "acc-1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "admin resolve dispute invalid outcome", method: http.MethodPost, path: "/admin/disputes/dsp-1/resolve", admin: true,
+			body: `{"outcome":"refunded"}`, status: http.StatusUnprocessableEntity,
+			expect: func(mock sqlmock.Sqlmock) {
+				expectPrivilegedTx(mock)
+				mock.ExpectQuery(`FROM disputes WHERE id = \$1$`).WithArgs("dsp-1").
+					WillReturnRows(sqlmock.NewRows(disputeColumns).
+						AddRow("dsp-1", "acc-1", "inv-1", 80.0, "fraudulent", "under_review", "receipt", now, now, nil, now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "create customer", method: http.MethodPost, path: "/v1/customers", apiKey: "key-1",
+			body: `{"name":"Maria Silva","email":"maria@example.com","document":"529.982.247-25"}`, status: http.StatusCreated,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectExec(`INSERT INTO customers`).
+					WithArgs(sqlmock.AnyArg(), "acc-1", "Maria Silva", "maria@example.com", "52998224725", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "create customer invalid CPF", method: http.MethodPost, path: "/v1/customers", apiKey: "key-1",
+			body: `{"name":"Maria Silva","email":"maria@example.com","document":"111.111.111-11"}`, status: http.StatusUnprocessableEntity,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+			},
+		},
+		{
+			name: "list customer invoices", method: http.MethodGet, path: "/v2/customers/cus-1/invoices", apiKey: "key-1", status: http.StatusOK,
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock, "key-1")
+				accountRow(mock, "key-1")
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`FROM customers WHERE id = \$1 AND account_id = \$2`).WithArgs("cus-1", "acc-1").
+					WillReturnRows(sqlmock.NewRows(customerColumns).AddRow("cus-1", "acc-1", "Maria Silva", "maria@example.com", "52998224725", now))
+				mock.ExpectCommit()
+				expectTenantTx(mock, "acc-1")
+				mock.ExpectQuery(`SELECT `+invoiceCols+` FROM invoices WHERE account_id = \$1 AND customer_id = \$2`).WithArgs("acc-1", "cus-1").
+					WillReturnRows(sqlmock.NewRows(invoiceColumns).
+						AddRow("inv-1", "acc-1", 50.0, 1, 50.0, 0.0, 50.0, "approved", "Test invoice", "credit_card", "4242", "cus-1", "card-1", "[]", "{}", nil, nil, "approve", "{}", now, now))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "create invoice suspended", method: http.MethodPost, path: "/v1/invoices", apiKey: "key-1",
+			body: `{"amount":10,"description":"Test invoice","payment_type":"pix"}`, status: http.StatusForbidden,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(accountQuery).WithArgs("key-1").
+					WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 10.0, 0.0, "suspended", now, now))
+			},
+		},
+		{
+			name: "admin reactivate active account", method: http.MethodPost, path: "/admin/accounts/acc-1/reactivate", admin: true,
+			status: http.StatusConflict,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM accounts WHERE id = \$1`).WithArgs("acc-1").
+					WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 10.0, 0.0, "active", now, now))
+			},
+		},
+	}
+
+	spec := APISpec()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock: %v", err)
+			}
+			defer db.Close()
+			if tt.expect != nil {
+				tt.expect(mock)
+			}
+
+			processor := domain.NewTestInvoiceProcessor()
+			processor.SetNextStatus(domain.StatusRejected) // no balance update
+			ts := httptest.NewServer(ConfigureRoutes(db, WithInvoiceProcessor(processor), WithAdminToken(adminToken)))
+			defer ts.Close()
+
+			req, _ := http.NewRequest(tt.method, ts.URL+tt.path, bytes.NewBufferString(tt.body))
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-KEY", tt.apiKey)
+			}
+			if tt.admin {
+				req.Header.Set("Authorization", "Bearer "+adminToken)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, resp.StatusCode, body)
+			}
+			if err := spec.ValidateResponse(tt.method, tt.path, resp.StatusCode, resp.Header.Get("Content-Type"), body); err != nil {
+				t.Fatal(err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenAPIEndpoint(t *testing.T) {
+	ts, _, db := newTestServer(t)
+	defer ts.Close()
+	defer db.Close()
+
+	resp, err := http.Get(ts.URL + "/openapi.json")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Fatalf("expected openapi 3.1.0 got %q", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/invoices/{id}"]["get"]; !ok {
+		t.Fatalf("expected GET /invoices/{id} documented, got %v", doc.Paths)
+	}
+}
+
+// expectTenantTx expects the transaction that binds the invoice queries of a
+// request to accountID for row-level security.
+func expectTenantTx(mock sqlmock.Sqlmock, accountID string) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT set_config\('app.account_id', \$1, true\)`).WithArgs(accountID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+// expectPrivilegedTx expects a transaction as gateway_admin, which the admin
+// API and the workers use to see every account.
+func expectPrivilegedTx(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`SET LOCAL ROLE gateway_admin`).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+// expectCheckoutLookup expects the public lookup of the open session cs-1 of
+// acc-1, which needs the privileged role since the caller has no account.
+func expectCheckoutLookup(mock sqlmock.Sqlmock, expiresAt time.Time) {
+	now := time.Now().UTC()
+	expectPrivilegedTx(mock)
+	mock.ExpectQuery(`FROM checkout_sessions WHERE id = \$1$`).WithArgs("cs-1").
+		WillReturnRows(sqlmock.NewRows(checkoutSessionColumns).AddRow("cs-1", "acc-1", 99.9, "Order #42", "{pix}", "https://shop.example.com/ok", "",
+			"open", "", "", expiresAt, nil, now, now))
+	mock.ExpectCommit()
+}
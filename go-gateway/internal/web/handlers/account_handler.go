@@ -3,11 +3,10 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 
-	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
 	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
 )
 
 // AccountServicePort defines only the methods needed by the handler.
@@ -50,50 +49,36 @@ func (h *AccountHandler) handleAccounts(w http.ResponseWriter, r *http.Request)
 	case http.MethodGet:
 		apiKey := r.Header.Get("X-API-KEY")
 		if apiKey == "" {
-			w.WriteHeader(http.StatusUnauthorized)
-			_ = json.NewEncoder(w).Encode(map[string]string{"error": "missing X-API-KEY header"})
+			httperror.Write(w, r, httperror.ErrMissingAPIKey)
 			return
 		}
 		out, err := h.svc.GetByAPIKey(r.Context(), apiKey)
 		if err != nil {
-			status := http.StatusInternalServerError
-			if errors.Is(err, domain.ErrAccountNotFound) {
-				status = http.StatusNotFound
-			}
-			w.WriteHeader(status)
-			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			httperror.Write(w, r, err)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(out)
 	default:
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "method not allowed"})
+		httperror.Write(w, r, httperror.ErrMethodNotAllowed)
 	}
 }
 
 // GET /accounts/{id}
 func (h *AccountHandler) handleAccountByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "method not allowed"})
+		httperror.Write(w, r, httperror.ErrMethodNotAllowed)
 		return
 	}
 	apiKey := r.Header.Get("X-API-KEY")
 	if apiKey == "" {
-		w.WriteHeader(http.StatusUnauthorized)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "missing X-API-KEY header"})
+		httperror.Write(w, r, httperror.ErrMissingAPIKey)
 		return
 	}
 	out, err := h.svc.GetByAPIKey(r.Context(), apiKey)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, domain.ErrAccountNotFound) {
-			status = http.StatusNotFound
-		}
-		w.WriteHeader(status)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		httperror.Write(w, r, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -104,23 +89,13 @@ func (h *AccountHandler) handleAccountByID(w http.ResponseWriter, r *http.Reques
 func (h *AccountHandler) createAccount(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	var in service.AccountCreateInput
-	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+	if err := decodeJSON(r.Body, &in); err != nil {
+		httperror.Write(w, r, err)
 		return
 	}
 	out, err := h.svc.Create(r.Context(), in)
 	if err != nil {
-		status := http.StatusBadRequest
-		// Domain validation errors should map to 400; others 500
-		switch {
-		case errors.Is(err, domain.ErrInvalidName), errors.Is(err, domain.ErrInvalidEmail):
-			status = http.StatusBadRequest
-		default:
-			status = http.StatusInternalServerError
-		}
-		w.WriteHeader(status)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		httperror.Write(w, r, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -103,3 +103,36 @@ func TestAccountHandler_Create_InvalidJSON(t *testing.T) {
 		t.Fatalf("expected 400, got %d", rec.Code)
 	}
 }
+
+func TestAccountHandler_Create_UnknownField(t *testing.T) {
+	svc := &fakeSvc{
+		create: func(ctx context.Context, in service.AccountCreateInput) (*service.AccountOutput, error) {
+			return nil, errors.New("should not be called")
+		},
+	}
+	h := NewAccountHandler(svc)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	body := `{"name":"Acme","email":"acme@example.com","balance":1000}`
+	req := httptest.NewRequest(http.MethodPost, "/accounts", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", rec.Code)
+	}
+	var problem struct {
+		Errors []struct {
+			Field string `json:"field"`
+			Code  string `json:"code"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "balance" || problem.Errors[0].Code != "request.unknown_field" {
+		t.Fatalf("unexpected field errors: %+v", problem.Errors)
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+)
+
+// AdminServicePort defines the methods needed by the admin handler.
+// It matches methods in service.AdminService.
+type AdminServicePort interface {
+	ListAccounts(ctx context.Context, in service.AccountListInput) (*service.AccountListOutput, error)
+	GetAccount(ctx context.Context, id string) (*service.AdminAccountOutput, error)
+	SuspendAccount(ctx context.Context, id string) (*service.AdminAccountOutput, error)
+	ReactivateAccount(ctx context.Context, id string) (*service.AdminAccountOutput, error)
+	CloseAccount(ctx context.Context, id string) (*service.AdminAccountOutput, error)
+	AdjustBalance(ctx context.Context, in service.BalanceAdjustmentInput) (*service.BalanceAdjustmentOutput, error)
+	ListAdjustments(ctx context.Context, accountID string) ([]*service.BalanceAdjustmentOutput, error)
+	ListFeePlans(ctx context.Context, accountID string) ([]*service.FeePlanOutput, error)
+	SetFeePlan(ctx context.Context, in service.FeePlanInput) (*service.FeePlanOutput, error)
+	Revenue(ctx context.Context, in service.RevenueInput) (*service.RevenueOutput, error)
+}
+
+// AdminActorHeader identifies the operator performing an admin operation.
+const AdminActorHeader = "X-Admin-Actor"
+
+// AdminHandler serves the operations API under /admin.
+type AdminHandler struct {
+	svc AdminServicePort
+}
+
+func NewAdminHandler(svc AdminServicePort) *AdminHandler {
+	return &AdminHandler{svc: svc}
+}
+
+// ListAccounts returns a handler for GET /admin/accounts?q=&status=&limit=&offset=
+func (h *AdminHandler) ListAccounts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		in := service.AccountListInput{Query: q.Get("q"), Status: q.Get("status")}
+
+		var err error
+		if in.Limit, err = queryInt(q.Get("limit"), 1, service.MaxPageSize); err != nil {
+			httperror.Write(w, r, httperror.InvalidField("limit", "request.invalid_query", "limit must be an integer between 1 and 100"))
+			return
+		}
+		if in.Offset, err = queryInt(q.Get("offset"), 0, -1); err != nil {
+			httperror.Write(w, r, httperror.InvalidField("offset", "request.invalid_query", "offset must be a non-negative integer"))
+			return
+		}
+
+		out, err := h.svc.ListAccounts(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// GetAccount returns a handler for GET /admin/accounts/{id}
+func (h *AdminHandler) GetAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.GetAccount(r.Context(), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// SuspendAccount returns a handler for POST /admin/accounts/{id}/suspend
+func (h *AdminHandler) SuspendAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.SuspendAccount(r.Context(), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// ReactivateAccount returns a handler for POST /admin/accounts/{id}/reactivate
+func (h *AdminHandler) ReactivateAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.ReactivateAccount(r.Context(), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// CloseAccount returns a handler for POST /admin/accounts/{id}/close
+func (h *AdminHandler) CloseAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.CloseAccount(r.Context(), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// PostAdjustment returns a handler for POST /admin/accounts/{id}/balance-adjustments
+func (h *AdminHandler) PostAdjustment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.BalanceAdjustmentInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.AccountID = r.PathValue("id")
+		in.Actor = r.Header.Get(AdminActorHeader)
+
+		out, err := h.svc.AdjustBalance(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, out)
+	}
+}
+
+// ListAdjustments returns a handler for GET /admin/accounts/{id}/balance-adjustments
+func (h *AdminHandler) ListAdjustments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.ListAdjustments(r.Context(), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// ListFeePlans returns a handler for GET /admin/accounts/{id}/fee-plans
+func (h *AdminHandler) ListFeePlans() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.ListFeePlans(r.Context(), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// PutFeePlan returns a handler for PUT /admin/accounts/{id}/fee-plans/{payment_type}
+func (h *AdminHandler) PutFeePlan() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.FeePlanInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.AccountID = r.PathValue("id")
+		in.PaymentType = r.PathValue("payment_type")
+
+		out, err := h.svc.SetFeePlan(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// Revenue returns a handler for GET /admin/revenue?from=&to=. Both are dates
+// (YYYY-MM-DD, UTC) and to is exclusive; by default the current month.
+func (h *AdminHandler) Revenue() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var in service.RevenueInput
+		var err error
+		if raw := q.Get("from"); raw != "" {
+			if in.From, err = time.Parse(time.DateOnly, raw); err != nil {
+				httperror.Write(w, r, httperror.InvalidField("from", "request.invalid_query", "from must be a date (YYYY-MM-DD)"))
+				return
+			}
+		}
+		if raw := q.Get("to"); raw != "" {
+			if in.To, err = time.Parse(time.DateOnly, raw); err != nil {
+				httperror.Write(w, r, httperror.InvalidField("to", "request.invalid_query", "to must be a date (YYYY-MM-DD)"))
+				return
+			}
+		}
+
+		out, err := h.svc.Revenue(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// queryInt parses an optional integer query parameter within [min, max]
+// (max < 0 means unbounded). Missing values return 0.
+func queryInt(raw string, min, max int) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, err
+	}
+	if n < min || (max >= 0 && n > max) {
+		return 0, strconv.ErrRange
+	}
+	return n, nil
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+)
+
+// fakeAdminService records the inputs it receives.
+type fakeAdminService struct {
+	listIn    service.AccountListInput
+	adjustIn  service.BalanceAdjustmentInput
+	feeIn     service.FeePlanInput
+	revenueIn service.RevenueInput
+}
+
+func (f *fakeAdminService) ListAccounts(_ context.Context, in service.AccountListInput) (*service.AccountListOutput, error) {
+	f.listIn = in
+	return &service.AccountListOutput{Items: []*service.AdminAccountOutput{}, Limit: in.Limit, Offset: in.Offset}, nil
+}
+
+func (f *fakeAdminService) GetAccount(context.Context, string) (*service.AdminAccountOutput, error) {
+	return nil, domain.ErrAccountNotFound
+}
+
+func (f *fakeAdminService) SuspendAccount(_ context.Context, id string) (*service.AdminAccountOutput, error) {
+	return &service.AdminAccountOutput{ID: id, Status: "suspended"}, nil
+}
+
+func (f *fakeAdminService) ReactivateAccount(context.Context, string) (*service.AdminAccountOutput, error) {
+	return nil, domain.ErrInvalidStatusTransition
+}
+
+func (f *fakeAdminService) CloseAccount(_ context.Context, id string) (*service.AdminAccountOutput, error) {
+	return &service.AdminAccountOutput{ID: id, Status: "closed"}, nil
+}
+
+func (f *fakeAdminService) AdjustBalance(_ context.Context, in service.BalanceAdjustmentInput) (*service.BalanceAdjustmentOutput, error) {
+	f.adjustIn = in
+	return &service.BalanceAdjustmentOutput{AccountID: in.AccountID, Amount: in.Amount, Reason: in.Reason, Actor: in.Actor}, nil
+}
+
+func (f *fakeAdminService) ListAdjustments(context.Context, string) ([]*service.BalanceAdjustmentOutput, error) {
+	return []*service.BalanceAdjustmentOutput{}, nil
+}
+
+func (f *fakeAdminService) ListFeePlans(context.Context, string) ([]*service.FeePlanOutput, error) {
+	return []*service.FeePlanOutput{}, nil
+}
+
+func (f *fakeAdminService) SetFeePlan(_ context.Context, in service.FeePlanInput) (*service.FeePlanOutput, error) {
+	f.feeIn = in
+	return &service.FeePlanOutput{AccountID: in.AccountID, PaymentType: in.PaymentType, Percent: in.Percent, Fixed: in.Fixed}, nil
+}
+
+func (f *fakeAdminService) Revenue(_ context.Context, in service.RevenueInput) (*service.RevenueOutput, error) {
+	f.revenueIn = in
+	return &service.RevenueOutput{From: in.From, To: in.To, Items: []*service.RevenueLineOutput{}}, nil
+}
+
+func TestAdminHandler_ListAccounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"defaults", "", http.StatusOK},
+		{"filters", "?q=acme&status=active&limit=5&offset=10", http.StatusOK},
+		{"limit too large", "?limit=500", http.StatusUnprocessableEntity},
+		{"limit not a number", "?limit=abc", http.StatusUnprocessableEntity},
+		{"negative offset", "?offset=-1", http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeAdminService{}
+			rr := httptest.NewRecorder()
+			NewAdminHandler(svc).ListAccounts()(rr, httptest.NewRequest(http.MethodGet, "/admin/accounts"+tt.query, nil))
+			if rr.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	svc := &fakeAdminService{}
+	NewAdminHandler(svc).ListAccounts()(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/admin/accounts?q=acme&status=active&limit=5&offset=10", nil))
+	if svc.listIn != (service.AccountListInput{Query: "acme", Status: "active", Limit: 5, Offset: 10}) {
+		t.Fatalf("unexpected input: %+v", svc.listIn)
+	}
+}
+
+func TestAdminHandler_PostAdjustment(t *testing.T) {
+	svc := &fakeAdminService{}
+	req := httptest.NewRequest(http.MethodPost, "/admin/accounts/acc-1/balance-adjustments",
+		bytes.NewBufferString(`{"amount":-10.5,"reason":"chargeback"}`))
+	req.SetPathValue("id", "acc-1")
+	req.Header.Set(AdminActorHeader, "ops@example.com")
+	rr := httptest.NewRecorder()
+
+	NewAdminHandler(svc).PostAdjustment()(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d: %s", rr.Code, rr.Body.String())
+	}
+	if svc.adjustIn.AccountID != "acc-1" || svc.adjustIn.Actor != "ops@example.com" || svc.adjustIn.Amount != -10.5 {
+		t.Fatalf("unexpected input: %+v", svc.adjustIn)
+	}
+
+	// The actor comes from the header only
+	req = httptest.NewRequest(http.MethodPost, "/admin/accounts/acc-1/balance-adjustments",
+		bytes.NewBufferString(`{"amount":1,"reason":"goodwill","actor":"someone"}`))
+	rr = httptest.NewRecorder()
+	NewAdminHandler(svc).PostAdjustment()(rr, req)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 got %d", rr.Code)
+	}
+}
+
+func TestAdminHandler_StatusErrors(t *testing.T) {
+	h := NewAdminHandler(&fakeAdminService{})
+
+	rr := httptest.NewRecorder()
+	h.ReactivateAccount()(rr, httptest.NewRequest(http.MethodPost, "/admin/accounts/acc-1/reactivate", nil))
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.GetAccount()(rr, httptest.NewRequest(http.MethodGet, "/admin/accounts/missing", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 got %d", rr.Code)
+	}
+}
+
+func TestAdminHandler_PutFeePlan(t *testing.T) {
+	svc := &fakeAdminService{}
+	req := httptest.NewRequest(http.MethodPut, "/admin/accounts/acc-1/fee-plans/pix", bytes.NewBufferString(`{"percent":0.99,"fixed":0.1}`))
+	req.SetPathValue("id", "acc-1")
+	req.SetPathValue("payment_type", "pix")
+	rr := httptest.NewRecorder()
+
+	NewAdminHandler(svc).PutFeePlan()(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rr.Code, rr.Body.String())
+	}
+	if svc.feeIn != (service.FeePlanInput{AccountID: "acc-1", PaymentType: "pix", Percent: 0.99, Fixed: 0.1}) {
+		t.Fatalf("unexpected input: %+v", svc.feeIn)
+	}
+}
+
+func TestAdminHandler_Revenue(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		from   time.Time
+		to     time.Time
+	}{
+		{"period", "?from=2026-09-15&to=2026-10-01", http.StatusOK,
+			time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		// The service fills in the missing bounds from its clock
+		{"only from", "?from=2026-09-01", http.StatusOK, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), time.Time{}},
+		{"default period", "", http.StatusOK, time.Time{}, time.Time{}},
+		{"invalid from", "?from=yesterday", http.StatusUnprocessableEntity, time.Time{}, time.Time{}},
+		{"invalid to", "?to=2026-13-01", http.StatusUnprocessableEntity, time.Time{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeAdminService{}
+			rr := httptest.NewRecorder()
+			NewAdminHandler(svc).Revenue()(rr, httptest.NewRequest(http.MethodGet, "/admin/revenue"+tt.query, nil))
+			if rr.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if !svc.revenueIn.From.Equal(tt.from) || !svc.revenueIn.To.Equal(tt.to) {
+				t.Fatalf("unexpected period %+v", svc.revenueIn)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+)
+
+// CheckoutServicePort defines the methods needed by the checkout handler.
+// It matches methods in service.CheckoutService.
+type CheckoutServicePort interface {
+	CreateSession(ctx context.Context, in service.CheckoutSessionInput) (*service.CheckoutSessionOutput, error)
+	GetSession(ctx context.Context, apiKey, id string) (*service.CheckoutSessionOutput, error)
+	Get(ctx context.Context, id string) (*service.CheckoutOutput, error)
+	Pay(ctx context.Context, in service.CheckoutPayInput) (*service.CheckoutPayOutput, error)
+}
+
+// CheckoutHandler handles payment links: the merchant routes behind the API
+// key and the public hosted checkout, which only knows the session ID.
+type CheckoutHandler struct {
+	svc CheckoutServicePort
+}
+
+func NewCheckoutHandler(svc CheckoutServicePort) *CheckoutHandler {
+	return &CheckoutHandler{svc: svc}
+}
+
+// PostCheckoutSessions returns a handler for POST /checkout-sessions
+func (h *CheckoutHandler) PostCheckoutSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.CheckoutSessionInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.APIKey = r.Header.Get("X-API-KEY")
+		out, err := h.svc.CreateSession(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, out)
+	}
+}
+
+// GetCheckoutSessionByID returns a handler for GET /checkout-sessions/{id}
+func (h *CheckoutHandler) GetCheckoutSessionByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.GetSession(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// GetCheckout returns a handler for the public GET /checkout/{id}
+func (h *CheckoutHandler) GetCheckout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.Get(r.Context(), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// PayCheckout returns a handler for the public POST /checkout/{id}/pay
+func (h *CheckoutHandler) PayCheckout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.CheckoutPayInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.SessionID = r.PathValue("id")
+		out, err := h.svc.Pay(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
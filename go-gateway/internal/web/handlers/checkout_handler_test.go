@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+)
+
+// fakeCheckoutService records the inputs it receives.
+type fakeCheckoutService struct {
+	sessionIn service.CheckoutSessionInput
+	payIn     service.CheckoutPayInput
+}
+
+func (f *fakeCheckoutService) CreateSession(_ context.Context, in service.CheckoutSessionInput) (*service.CheckoutSessionOutput, error) {
+	f.sessionIn = in
+	return &service.CheckoutSessionOutput{ID: "cs-1", CheckoutPath: "/v1/checkout/cs-1", Amount: in.Amount, PaymentTypes: in.PaymentTypes, Status: "open"}, nil
+}
+
+func (f *fakeCheckoutService) GetSession(context.Context, string, string) (*service.CheckoutSessionOutput, error) {
+	return nil, domain.ErrCheckoutSessionNotFound
+}
+
+func (f *fakeCheckoutService) Get(_ context.Context, id string) (*service.CheckoutOutput, error) {
+	return &service.CheckoutOutput{ID: id, MerchantName: "Acme", Amount: 99.9, Status: "open"}, nil
+}
+
+func (f *fakeCheckoutService) Pay(_ context.Context, in service.CheckoutPayInput) (*service.CheckoutPayOutput, error) {
+	f.payIn = in
+	if in.PaymentType != "pix" {
+		return nil, domain.ErrPaymentTypeNotAllowed
+	}
+	return &service.CheckoutPayOutput{SessionID: in.SessionID, InvoiceID: "inv-1", Status: "approved"}, nil
+}
+
+func TestCheckoutHandler_PostCheckoutSessions(t *testing.T) {
+	svc := &fakeCheckoutService{}
+	body := `{"amount":99.9,"description":"Order #42","payment_types":["pix"],"success_url":"https://shop.example.com/ok","expires_at":"2026-11-01T00:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/checkout-sessions", bytes.NewBufferString(body))
+	req.Header.Set("X-API-KEY", "key-1")
+	rr := httptest.NewRecorder()
+
+	NewCheckoutHandler(svc).PostCheckoutSessions()(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201 got %d: %s", rr.Code, rr.Body.String())
+	}
+	if svc.sessionIn.APIKey != "key-1" || svc.sessionIn.ExpiresAt == nil || len(svc.sessionIn.PaymentTypes) != 1 {
+		t.Fatalf("expected API key from header and the body fields, got %+v", svc.sessionIn)
+	}
+}
+
+func TestCheckoutHandler_PayCheckout(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"paid", `{"payment_type":"pix"}`, http.StatusOK},
+		{"payment type not allowed", `{"payment_type":"boleto"}`, http.StatusUnprocessableEntity},
+		{"unknown field", `{"payment_type":"pix","amount":1}`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeCheckoutService{}
+			req := httptest.NewRequest(http.MethodPost, "/checkout/cs-1/pay", bytes.NewBufferString(tt.body))
+			req.SetPathValue("id", "cs-1")
+			rr := httptest.NewRecorder()
+
+			NewCheckoutHandler(svc).PayCheckout()(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if tt.status == http.StatusOK && svc.payIn.SessionID != "cs-1" {
+				t.Fatalf("expected the session ID from the path, got %+v", svc.payIn)
+			}
+		})
+	}
+}
+
+func TestV2Handler_GetCheckout(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v2/checkout/cs-1", nil)
+	req.SetPathValue("id", "cs-1")
+	rr := httptest.NewRecorder()
+
+	NewV2Handler(nil, nil, nil, nil, &fakeCheckoutService{}, nil, nil).GetCheckout()(rr, req)
+
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"amount_cents":9990`) {
+		t.Fatalf("expected the checkout in cents, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
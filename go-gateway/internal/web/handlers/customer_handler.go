@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+)
+
+// CustomerServicePort defines the methods needed by the customer handler. It
+// matches methods in service.CustomerService.
+type CustomerServicePort interface {
+	Create(ctx context.Context, in service.CustomerInput) (*service.CustomerOutput, error)
+	List(ctx context.Context, apiKey string) ([]*service.CustomerOutput, error)
+	GetByID(ctx context.Context, apiKey, id string) (*service.CustomerOutput, error)
+	ListInvoices(ctx context.Context, apiKey, id string) ([]*service.InvoiceOutput, error)
+	AddCard(ctx context.Context, in service.SavedCardInput) (*service.SavedCardOutput, error)
+	ListCards(ctx context.Context, apiKey, customerID string) ([]*service.SavedCardOutput, error)
+	DeleteCard(ctx context.Context, apiKey, customerID, id string) error
+}
+
+// CustomerHandler handles the customers of the authenticated account and
+// their saved cards.
+type CustomerHandler struct {
+	svc CustomerServicePort
+}
+
+func NewCustomerHandler(svc CustomerServicePort) *CustomerHandler {
+	return &CustomerHandler{svc: svc}
+}
+
+// PostCustomers returns a handler for POST /customers
+func (h *CustomerHandler) PostCustomers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.CustomerInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.APIKey = r.Header.Get("X-API-KEY")
+		out, err := h.svc.Create(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, out)
+	}
+}
+
+// GetCustomers returns a handler for GET /customers
+func (h *CustomerHandler) GetCustomers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.List(r.Context(), r.Header.Get("X-API-KEY"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// GetCustomerByID returns a handler for GET /customers/{id}
+func (h *CustomerHandler) GetCustomerByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.GetByID(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// GetCustomerInvoices returns a handler for GET /customers/{id}/invoices
+func (h *CustomerHandler) GetCustomerInvoices() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.ListInvoices(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// PostCards returns a handler for POST /customers/{id}/cards
+func (h *CustomerHandler) PostCards() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.SavedCardInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.APIKey = r.Header.Get("X-API-KEY")
+		in.CustomerID = r.PathValue("id")
+		out, err := h.svc.AddCard(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, out)
+	}
+}
+
+// GetCards returns a handler for GET /customers/{id}/cards
+func (h *CustomerHandler) GetCards() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.ListCards(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// DeleteCard returns a handler for DELETE /customers/{id}/cards/{card_id}
+func (h *CustomerHandler) DeleteCard() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := h.svc.DeleteCard(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"), r.PathValue("card_id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+)
+
+// fakeCustomerService knows a single customer, cus-1, with card card-1 and
+// one invoice of 50.00.
+type fakeCustomerService struct {
+	cardIn service.SavedCardInput
+}
+
+func (f *fakeCustomerService) Create(_ context.Context, in service.CustomerInput) (*service.CustomerOutput, error) {
+	c, err := domain.NewCustomer("acc-1", in.Name, in.Email, in.Document, domain.SystemClock.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &service.CustomerOutput{ID: c.ID, Name: c.Name, Email: c.Email, Document: c.Document}, nil
+}
+
+func (f *fakeCustomerService) List(context.Context, string) ([]*service.CustomerOutput, error) {
+	return []*service.CustomerOutput{{ID: "cus-1"}}, nil
+}
+
+func (f *fakeCustomerService) GetByID(_ context.Context, _, id string) (*service.CustomerOutput, error) {
+	if id != "cus-1" {
+		return nil, domain.ErrCustomerNotFound
+	}
+	return &service.CustomerOutput{ID: id}, nil
+}
+
+func (f *fakeCustomerService) ListInvoices(_ context.Context, _, id string) ([]*service.InvoiceOutput, error) {
+	if id != "cus-1" {
+		return nil, domain.ErrCustomerNotFound
+	}
+	return []*service.InvoiceOutput{{ID: "inv-1", CustomerID: id, Amount: 50}}, nil
+}
+
+func (f *fakeCustomerService) AddCard(_ context.Context, in service.SavedCardInput) (*service.SavedCardOutput, error) {
+	f.cardIn = in
+	return &service.SavedCardOutput{ID: "card-2", CustomerID: in.CustomerID, CardLastDigits: in.CardLastDigits}, nil
+}
+
+func (f *fakeCustomerService) ListCards(context.Context, string, string) ([]*service.SavedCardOutput, error) {
+	return []*service.SavedCardOutput{{ID: "card-1", CustomerID: "cus-1", CardLastDigits: "4242"}}, nil
+}
+
+func (f *fakeCustomerService) DeleteCard(_ context.Context, _, _, id string) error {
+	if id != "card-1" {
+		return domain.ErrSavedCardNotFound
+	}
+	return nil
+}
+
+func TestCustomerHandler_PostCustomers(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"created", `{"name":"Maria Silva","email":"maria@example.com","document":"529.982.247-25"}`, http.StatusCreated},
+		{"invalid CPF", `{"name":"Maria Silva","email":"maria@example.com","document":"529.982.247-26"}`, http.StatusUnprocessableEntity},
+		{"unknown field", `{"name":"Maria Silva","email":"maria@example.com","document":"52998224725","id":"x"}`, http.StatusUnprocessableEntity},
+		{"malformed", `{`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/customers", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			NewCustomerHandler(&fakeCustomerService{}).PostCustomers()(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCustomerHandler_Cards(t *testing.T) {
+	svc := &fakeCustomerService{}
+	req := httptest.NewRequest(http.MethodPost, "/customers/cus-1/cards", bytes.NewBufferString(`{"card_token":"tok_12345678","card_last_digits":"4242"}`))
+	req.SetPathValue("id", "cus-1")
+	req.Header.Set("X-API-KEY", "key-1")
+	rr := httptest.NewRecorder()
+	NewCustomerHandler(svc).PostCards()(rr, req)
+	if rr.Code != http.StatusCreated || svc.cardIn.CustomerID != "cus-1" || svc.cardIn.APIKey != "key-1" {
+		t.Fatalf("expected a card on the customer in the path, got %d %+v", rr.Code, svc.cardIn)
+	}
+	if bytes.Contains(rr.Body.Bytes(), []byte("tok_12345678")) {
+		t.Fatalf("expected the card token never returned, got %s", rr.Body.String())
+	}
+
+	for _, tc := range []struct {
+		cardID string
+		status int
+	}{
+		{"card-1", http.StatusNoContent},
+		{"card-9", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodDelete, "/customers/cus-1/cards/"+tc.cardID, nil)
+		req.SetPathValue("id", "cus-1")
+		req.SetPathValue("card_id", tc.cardID)
+		rr := httptest.NewRecorder()
+		NewCustomerHandler(svc).DeleteCard()(rr, req)
+		if rr.Code != tc.status {
+			t.Errorf("delete %s: expected %d, got %d %s", tc.cardID, tc.status, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestCustomerHandler_GetCustomerInvoices(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/customers/cus-9/invoices", nil)
+	req.SetPathValue("id", "cus-9")
+	rr := httptest.NewRecorder()
+	NewCustomerHandler(&fakeCustomerService{}).GetCustomerInvoices()(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v2/customers/cus-1/invoices", nil)
+	req.SetPathValue("id", "cus-1")
+	rr = httptest.NewRecorder()
+	NewV2Handler(nil, nil, nil, nil, nil, nil, &fakeCustomerService{}).GetCustomerInvoices()(rr, req)
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"amount_cents":5000`)) {
+		t.Fatalf("expected amounts in cents, got %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+)
+
+// decodeJSON decodes a request body into dst, rejecting unknown fields and
+// trailing data.
+func decodeJSON(r io.Reader, dst any) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if dec.More() {
+		return httperror.ErrInvalidJSON
+	}
+	return nil
+}
+
+// decodeError maps a JSON body decoding failure to its HTTP error.
+func decodeError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return httperror.ErrBodyTooLarge
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return httperror.InvalidField(typeErr.Field, "request.invalid_type", "must be a "+typeErr.Type.String())
+	}
+
+	// encoding/json has no typed error for unknown fields
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return httperror.InvalidField(strings.Trim(name, `"`), "request.unknown_field", "unknown field")
+	}
+
+	return httperror.ErrInvalidJSON
+}
+
+// writeJSON encodes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
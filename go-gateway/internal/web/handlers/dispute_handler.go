@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+)
+
+// DisputeServicePort defines the methods needed by the dispute handler. It
+// matches methods in service.DisputeService.
+type DisputeServicePort interface {
+	List(ctx context.Context, apiKey string) ([]*service.DisputeOutput, error)
+	GetByID(ctx context.Context, apiKey, id string) (*service.DisputeOutput, error)
+	SubmitEvidence(ctx context.Context, in service.DisputeEvidenceInput) (*service.DisputeOutput, error)
+	Open(ctx context.Context, in service.DisputeOpenInput) (*service.DisputeOutput, error)
+	Resolve(ctx context.Context, in service.DisputeResolveInput) (*service.DisputeOutput, error)
+}
+
+// DisputeHandler handles the disputes of the authenticated account and the
+// admin endpoints that simulate the card network.
+type DisputeHandler struct {
+	svc DisputeServicePort
+}
+
+func NewDisputeHandler(svc DisputeServicePort) *DisputeHandler {
+	return &DisputeHandler{svc: svc}
+}
+
+// GetDisputes returns a handler for GET /disputes
+func (h *DisputeHandler) GetDisputes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.List(r.Context(), r.Header.Get("X-API-KEY"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// GetDisputeByID returns a handler for GET /disputes/{id}
+func (h *DisputeHandler) GetDisputeByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.GetByID(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// PostEvidence returns a handler for POST /disputes/{id}/evidence
+func (h *DisputeHandler) PostEvidence() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		in, ok := decodeEvidence(w, r)
+		if !ok {
+			return
+		}
+		out, err := h.svc.SubmitEvidence(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// OpenDispute returns a handler for POST /admin/invoices/{id}/disputes
+func (h *DisputeHandler) OpenDispute() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.DisputeOpenInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.InvoiceID = r.PathValue("id")
+		out, err := h.svc.Open(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, out)
+	}
+}
+
+// ResolveDispute returns a handler for POST /admin/disputes/{id}/resolve
+func (h *DisputeHandler) ResolveDispute() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.DisputeResolveInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.ID = r.PathValue("id")
+		out, err := h.svc.Resolve(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// decodeEvidence reads the evidence of the dispute in the path, writing the
+// error response when the body is invalid.
+func decodeEvidence(w http.ResponseWriter, r *http.Request) (service.DisputeEvidenceInput, bool) {
+	var in service.DisputeEvidenceInput
+	if err := decodeJSON(r.Body, &in); err != nil {
+		httperror.Write(w, r, err)
+		return in, false
+	}
+	in.APIKey = r.Header.Get("X-API-KEY")
+	in.ID = r.PathValue("id")
+	return in, true
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+)
+
+// fakeDisputeService knows a single open dispute, dsp-1 of 80.00.
+type fakeDisputeService struct {
+	evidenceIn service.DisputeEvidenceInput
+	openIn     service.DisputeOpenInput
+}
+
+func (f *fakeDisputeService) List(context.Context, string) ([]*service.DisputeOutput, error) {
+	return []*service.DisputeOutput{{ID: "dsp-1", Amount: 80, Status: "needs_response"}}, nil
+}
+
+func (f *fakeDisputeService) GetByID(_ context.Context, _, id string) (*service.DisputeOutput, error) {
+	if id != "dsp-1" {
+		return nil, domain.ErrDisputeNotFound
+	}
+	return &service.DisputeOutput{ID: id, Amount: 80, Status: "needs_response"}, nil
+}
+
+func (f *fakeDisputeService) SubmitEvidence(_ context.Context, in service.DisputeEvidenceInput) (*service.DisputeOutput, error) {
+	f.evidenceIn = in
+	d := &domain.Dispute{ID: in.ID, Amount: 80, Status: domain.DisputeNeedsResponse, EvidenceDueBy: domain.SystemClock.Now().Add(time.Hour)}
+	if err := d.SubmitEvidence(in.Evidence, domain.SystemClock.Now()); err != nil {
+		return nil, err
+	}
+	return &service.DisputeOutput{ID: d.ID, Amount: d.Amount, Status: string(d.Status), Evidence: d.Evidence}, nil
+}
+
+func (f *fakeDisputeService) Open(_ context.Context, in service.DisputeOpenInput) (*service.DisputeOutput, error) {
+	f.openIn = in
+	return &service.DisputeOutput{ID: "dsp-2", InvoiceID: in.InvoiceID, Amount: in.Amount, Reason: in.Reason, Status: "needs_response"}, nil
+}
+
+func (f *fakeDisputeService) Resolve(_ context.Context, in service.DisputeResolveInput) (*service.DisputeOutput, error) {
+	if in.Outcome != "won" && in.Outcome != "lost" {
+		return nil, domain.ErrInvalidDisputeOutcome
+	}
+	return &service.DisputeOutput{ID: in.ID, Status: in.Outcome}, nil
+}
+
+func TestDisputeHandler_PostEvidence(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"submitted", `{"evidence":"tracking code BR123456789"}`, http.StatusOK},
+		{"too short", `{"evidence":"trust me"}`, http.StatusUnprocessableEntity},
+		{"unknown field", `{"evidence":"tracking code BR123456789","status":"won"}`, http.StatusUnprocessableEntity},
+		{"malformed", `{`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeDisputeService{}
+			req := httptest.NewRequest(http.MethodPost, "/disputes/dsp-1/evidence", bytes.NewBufferString(tt.body))
+			req.SetPathValue("id", "dsp-1")
+			req.Header.Set("X-API-KEY", "key-1")
+			rr := httptest.NewRecorder()
+
+			NewDisputeHandler(svc).PostEvidence()(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if tt.status == http.StatusOK && (svc.evidenceIn.APIKey != "key-1" || svc.evidenceIn.ID != "dsp-1") {
+				t.Fatalf("expected API key and ID from the request, got %+v", svc.evidenceIn)
+			}
+		})
+	}
+}
+
+func TestDisputeHandler_GetDisputeByID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/disputes/dsp-9", nil)
+	req.SetPathValue("id", "dsp-9")
+	rr := httptest.NewRecorder()
+	NewDisputeHandler(&fakeDisputeService{}).GetDisputeByID()(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestDisputeHandler_Admin(t *testing.T) {
+	svc := &fakeDisputeService{}
+	req := httptest.NewRequest(http.MethodPost, "/admin/invoices/inv-1/disputes", bytes.NewBufferString(`{"amount":80,"reason":"fraudulent"}`))
+	req.SetPathValue("id", "inv-1")
+	rr := httptest.NewRecorder()
+	NewDisputeHandler(svc).OpenDispute()(rr, req)
+	if rr.Code != http.StatusCreated || svc.openIn.InvoiceID != "inv-1" {
+		t.Fatalf("expected a dispute on the invoice in the path, got %d %+v", rr.Code, svc.openIn)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/disputes/dsp-1/resolve", bytes.NewBufferString(`{"outcome":"maybe"}`))
+	req.SetPathValue("id", "dsp-1")
+	rr = httptest.NewRecorder()
+	NewDisputeHandler(svc).ResolveDispute()(rr, req)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for an invalid outcome, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestV2Handler_GetDisputes(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewV2Handler(nil, nil, nil, nil, nil, &fakeDisputeService{}, nil).GetDisputes()(rr, httptest.NewRequest(http.MethodGet, "/v2/disputes", nil))
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"amount_cents":8000`)) {
+		t.Fatalf("expected amounts in cents, got %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// DatabaseCheck pings the database connection pool.
+func DatabaseCheck(db *sql.DB) HealthCheck {
+	return HealthCheck{
+		Name: "database",
+		Check: func(ctx context.Context) (map[string]any, error) {
+			return nil, db.PingContext(ctx)
+		},
+	}
+}
+
+// MigrationCheck reports the schema version recorded by golang-migrate and
+// fails when the last migration left the schema dirty.
+func MigrationCheck(db *sql.DB) HealthCheck {
+	return HealthCheck{
+		Name: "migrations",
+		Check: func(ctx context.Context) (map[string]any, error) {
+			const q = `SELECT version, dirty FROM schema_migrations LIMIT 1`
+			var version int64
+			var dirty bool
+			if err := db.QueryRowContext(ctx, q).Scan(&version, &dirty); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return nil, errors.New("no migrations applied")
+				}
+				return nil, err
+			}
+			details := map[string]any{"version": version, "dirty": dirty}
+			if dirty {
+				return details, fmt.Errorf("migration %d is dirty", version)
+			}
+			return details, nil
+		},
+	}
+}
+
+// BrokerCheck verifies TCP connectivity to at least one of the given brokers.
+func BrokerCheck(brokers []string) HealthCheck {
+	return HealthCheck{
+		Name: "broker",
+		Check: func(ctx context.Context) (map[string]any, error) {
+			var d net.Dialer
+			var lastErr error
+			for _, addr := range brokers {
+				conn, err := d.DialContext(ctx, "tcp", addr)
+				if err != nil {
+					lastErr = err
+					continue
+				}
+				_ = conn.Close()
+				return map[string]any{"broker": addr}, nil
+			}
+			if lastErr == nil {
+				lastErr = errors.New("no brokers configured")
+			}
+			return nil, lastErr
+		},
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// defaultCheckTimeout bounds how long a single dependency check may take.
+const defaultCheckTimeout = 2 * time.Second
+
+// HealthCheck probes a single dependency. Check returns optional details that
+// are reported alongside the status (e.g. migration version).
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) (map[string]any, error)
+}
+
+// HealthHandler serves liveness and readiness probes.
+type HealthHandler struct {
+	checks       []HealthCheck
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks, timeout: defaultCheckTimeout}
+}
+
+// AddCheck registers an additional readiness check.
+func (h *HealthHandler) AddCheck(c HealthCheck) {
+	h.checks = append(h.checks, c)
+}
+
+// SetShuttingDown flips readiness to false so orchestrators stop routing
+// traffic while in-flight requests drain.
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Healthz returns a handler for GET /healthz (process alive)
+func (h *HealthHandler) Healthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	}
+}
+
+// Readyz returns a handler for GET /readyz (dependencies reachable)
+func (h *HealthHandler) Readyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ready := true
+		results := make(map[string]map[string]any, len(h.checks))
+		for _, c := range h.checks {
+			res := h.run(r.Context(), c)
+			if res["status"] != "up" {
+				ready = false
+			}
+			results[c.Name] = res
+		}
+
+		status := "ready"
+		code := http.StatusOK
+		if h.shuttingDown.Load() {
+			status = "shutting_down"
+			code = http.StatusServiceUnavailable
+		} else if !ready {
+			status = "not_ready"
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"status": status,
+			"checks": results,
+		})
+	}
+}
+
+// run executes a check with a timeout and builds its JSON breakdown.
+func (h *HealthHandler) run(ctx context.Context, c HealthCheck) map[string]any {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := c.Check(ctx)
+	res := map[string]any{"latency_ms": time.Since(start).Milliseconds()}
+	for k, v := range details {
+		res[k] = v
+	}
+	if err != nil {
+		res["status"] = "down"
+		res["error"] = err.Error()
+		return res
+	}
+	res["status"] = "up"
+	return res
+}
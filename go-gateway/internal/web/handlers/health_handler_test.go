@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type readyResponse struct {
+	Status string                    `json:"status"`
+	Checks map[string]map[string]any `json:"checks"`
+}
+
+func doReadyz(t *testing.T, h *HealthHandler) (int, readyResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	h.Readyz()(w, req)
+
+	var body readyResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return w.Code, body
+}
+
+func TestHealthHandler_Healthz(t *testing.T) {
+	h := NewHealthHandler()
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	h.Healthz()(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestHealthHandler_Readyz_AllUp(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectPing()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, dirty FROM schema_migrations LIMIT 1")).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false))
+
+	h := NewHealthHandler(DatabaseCheck(db), MigrationCheck(db))
+	code, body := doReadyz(t, h)
+
+	if code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if body.Status != "ready" {
+		t.Errorf("expected status 'ready', got '%s'", body.Status)
+	}
+	if body.Checks["database"]["status"] != "up" {
+		t.Errorf("expected database up, got %v", body.Checks["database"])
+	}
+	if body.Checks["migrations"]["version"] != float64(1) {
+		t.Errorf("expected migration version 1, got %v", body.Checks["migrations"]["version"])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestHealthHandler_Readyz_DatabaseDown(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+
+	h := NewHealthHandler(DatabaseCheck(db))
+	code, body := doReadyz(t, h)
+
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, code)
+	}
+	if body.Checks["database"]["status"] != "down" {
+		t.Errorf("expected database down, got %v", body.Checks["database"])
+	}
+}
+
+func TestHealthHandler_Readyz_DirtyMigration(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, dirty FROM schema_migrations LIMIT 1")).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, true))
+
+	h := NewHealthHandler(MigrationCheck(db))
+	code, body := doReadyz(t, h)
+
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, code)
+	}
+	if body.Checks["migrations"]["dirty"] != true {
+		t.Errorf("expected dirty flag, got %v", body.Checks["migrations"])
+	}
+}
+
+func TestHealthHandler_Readyz_ShuttingDown(t *testing.T) {
+	h := NewHealthHandler(HealthCheck{
+		Name:  "noop",
+		Check: func(ctx context.Context) (map[string]any, error) { return nil, nil },
+	})
+	h.SetShuttingDown()
+
+	code, body := doReadyz(t, h)
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, code)
+	}
+	if body.Status != "shutting_down" {
+		t.Errorf("expected status 'shutting_down', got '%s'", body.Status)
+	}
+}
+
+func TestBrokerCheck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	if _, err := BrokerCheck([]string{ln.Addr().String()}).Check(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := BrokerCheck(nil).Check(context.Background()); err == nil {
+		t.Error("expected error without brokers")
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/testdb"
+)
+
+func TestHealthHandler_Readyz_AsAppRole_Integration(t *testing.T) {
+	_, app := testdb.Open(t)
+
+	h := NewHealthHandler(DatabaseCheck(app), MigrationCheck(app))
+	code, body := doReadyz(t, h)
+
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %+v", http.StatusOK, code, body.Checks)
+	}
+	if body.Checks["migrations"]["dirty"] != false {
+		t.Errorf("expected a clean schema version, got %v", body.Checks["migrations"])
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+)
+
+// InstallmentServicePort defines the methods needed by the installment
+// handler. It matches methods in service.InvoiceService.
+type InstallmentServicePort interface {
+	GetInstallmentRules(ctx context.Context, apiKey string) (*service.InstallmentRulesOutput, error)
+	SaveInstallmentRules(ctx context.Context, in service.InstallmentRulesInput) (*service.InstallmentRulesOutput, error)
+}
+
+// InstallmentHandler handles the installment rules of the authenticated
+// account. They carry no money amounts, so every API version shares it.
+type InstallmentHandler struct {
+	svc InstallmentServicePort
+}
+
+func NewInstallmentHandler(svc InstallmentServicePort) *InstallmentHandler {
+	return &InstallmentHandler{svc: svc}
+}
+
+// GetInstallmentRules returns a handler for GET /installment-rules
+func (h *InstallmentHandler) GetInstallmentRules() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.GetInstallmentRules(r.Context(), r.Header.Get("X-API-KEY"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// PutInstallmentRules returns a handler for PUT /installment-rules
+func (h *InstallmentHandler) PutInstallmentRules() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.InstallmentRulesInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.APIKey = r.Header.Get("X-API-KEY")
+		out, err := h.svc.SaveInstallmentRules(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+)
+
+// fakeInstallmentService validates rules like the domain does.
+type fakeInstallmentService struct {
+	saveIn service.InstallmentRulesInput
+}
+
+func (f *fakeInstallmentService) GetInstallmentRules(context.Context, string) (*service.InstallmentRulesOutput, error) {
+	return &service.InstallmentRulesOutput{MaxInstallments: 12, FreeInstallments: 12}, nil
+}
+
+func (f *fakeInstallmentService) SaveInstallmentRules(_ context.Context, in service.InstallmentRulesInput) (*service.InstallmentRulesOutput, error) {
+	f.saveIn = in
+	r, err := domain.NewInstallmentRules("acc-1", in.MaxInstallments, in.FreeInstallments, in.MonthlyInterest, domain.SystemClock.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &service.InstallmentRulesOutput{MaxInstallments: r.MaxInstallments, FreeInstallments: r.FreeInstallments, MonthlyInterest: r.MonthlyInterest}, nil
+}
+
+func TestInstallmentHandler_PutInstallmentRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"saved", `{"max_installments":10,"free_installments":3,"monthly_interest":1.99}`, http.StatusOK},
+		{"too many installments", `{"max_installments":13,"free_installments":3,"monthly_interest":1.99}`, http.StatusUnprocessableEntity},
+		{"free above max", `{"max_installments":3,"free_installments":6,"monthly_interest":0}`, http.StatusUnprocessableEntity},
+		{"unknown field", `{"max_installments":10,"free_installments":3,"account_id":"x"}`, http.StatusUnprocessableEntity},
+		{"malformed", `{`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeInstallmentService{}
+			req := httptest.NewRequest(http.MethodPut, "/installment-rules", bytes.NewBufferString(tt.body))
+			req.Header.Set("X-API-KEY", "key-1")
+			rr := httptest.NewRecorder()
+
+			NewInstallmentHandler(svc).PutInstallmentRules()(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if tt.status == http.StatusOK && svc.saveIn.APIKey != "key-1" {
+				t.Fatalf("expected API key from header, got %+v", svc.saveIn)
+			}
+		})
+	}
+}
+
+func TestInstallmentHandler_GetInstallmentRules(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewInstallmentHandler(&fakeInstallmentService{}).GetInstallmentRules()(rr, httptest.NewRequest(http.MethodGet, "/installment-rules", nil))
+	if rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"max_installments":12`)) {
+		t.Fatalf("expected the rules, got %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+)
+
+// BatchSummary counts the outcomes of a batch.
+type BatchSummary struct {
+	Created  int `json:"created"`
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
+
+// BatchInvoiceResult is the outcome of one invoice of POST /invoices/batch:
+// the invoice, or the problem that failed it. Status is the one a single
+// POST /invoices would have answered.
+type BatchInvoiceResult struct {
+	Index   int                    `json:"index"`
+	Status  int                    `json:"status"`
+	Invoice *service.InvoiceOutput `json:"invoice,omitempty"`
+	Error   *httperror.Problem     `json:"error,omitempty"`
+}
+
+// BatchInvoicesResponse is the body of POST /invoices/batch. Results follow
+// the order of the request.
+type BatchInvoicesResponse struct {
+	Summary BatchSummary         `json:"summary"`
+	Results []BatchInvoiceResult `json:"results"`
+}
+
+// BatchInvoiceResultV2 is the /v2 form of a BatchInvoiceResult.
+type BatchInvoiceResultV2 struct {
+	Index   int                      `json:"index"`
+	Status  int                      `json:"status"`
+	Invoice *service.InvoiceOutputV2 `json:"invoice,omitempty"`
+	Error   *httperror.Problem       `json:"error,omitempty"`
+}
+
+// BatchInvoicesResponseV2 is the body of POST /v2/invoices/batch.
+type BatchInvoicesResponseV2 struct {
+	Summary BatchSummary           `json:"summary"`
+	Results []BatchInvoiceResultV2 `json:"results"`
+}
+
+// PostInvoicesBatch returns a handler for POST /invoices/batch
+func (h *InvoiceHandler) PostInvoicesBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		results, ok := createBatch(w, r, h.svc, func(raw json.RawMessage) (service.InvoiceCreateInput, error) {
+			var in service.InvoiceCreateInput
+			err := decodeJSON(bytes.NewReader(raw), &in)
+			return in, err
+		})
+		if !ok {
+			return
+		}
+		resp := BatchInvoicesResponse{Results: make([]BatchInvoiceResult, len(results))}
+		for k, res := range results {
+			status, problem := batchOutcome(res, &resp.Summary)
+			resp.Results[k] = BatchInvoiceResult{Index: k, Status: status, Invoice: res.Invoice, Error: problem}
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// PostInvoicesBatch returns a handler for POST /v2/invoices/batch
+func (h *V2Handler) PostInvoicesBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		results, ok := createBatch(w, r, h.invoices, func(raw json.RawMessage) (service.InvoiceCreateInput, error) {
+			var in service.InvoiceCreateInputV2
+			if err := decodeJSON(bytes.NewReader(raw), &in); err != nil {
+				return service.InvoiceCreateInput{}, err
+			}
+			return in.ToV1(""), nil
+		})
+		if !ok {
+			return
+		}
+		resp := BatchInvoicesResponseV2{Results: make([]BatchInvoiceResultV2, len(results))}
+		for k, res := range results {
+			status, problem := batchOutcome(res, &resp.Summary)
+			item := BatchInvoiceResultV2{Index: k, Status: status, Error: problem}
+			if res.Invoice != nil {
+				item.Invoice = service.NewInvoiceOutputV2(res.Invoice)
+			}
+			resp.Results[k] = item
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// createBatch reads the invoices of a batch and creates them. An invoice
+// that cannot be decoded only fails its own result; errors of the batch as a
+// whole are written to w and reported by ok.
+func createBatch(w http.ResponseWriter, r *http.Request, svc InvoiceServicePort, decode func(json.RawMessage) (service.InvoiceCreateInput, error)) (results []service.InvoiceBatchResult, ok bool) {
+	apiKey := r.Header.Get("X-API-KEY")
+	if apiKey == "" {
+		httperror.Write(w, r, httperror.ErrMissingAPIKey)
+		return nil, false
+	}
+	raws, err := decodeBatch(r)
+	if err != nil {
+		httperror.Write(w, r, err)
+		return nil, false
+	}
+	items := make([]service.InvoiceBatchItem, len(raws))
+	for k, raw := range raws {
+		items[k].Input, items[k].Err = decode(raw)
+	}
+	results, err = svc.CreateBatch(r.Context(), apiKey, items)
+	if err != nil {
+		httperror.Write(w, r, err)
+		return nil, false
+	}
+	return results, true
+}
+
+// decodeBatch splits the body into the raw invoices of the batch: a JSON
+// array, or NDJSON (one invoice per line) when the Content-Type says so.
+func decodeBatch(r *http.Request) ([]json.RawMessage, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-ndjson" && mediaType != "application/ndjson" {
+		var raws []json.RawMessage
+		if err := decodeJSON(r.Body, &raws); err != nil {
+			return nil, err
+		}
+		return raws, nil
+	}
+
+	var raws []json.RawMessage
+	dec := json.NewDecoder(r.Body)
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return raws, nil
+		}
+		if err != nil {
+			return nil, decodeError(err)
+		}
+		raws = append(raws, raw)
+	}
+}
+
+// batchOutcome returns the status of one result of a batch and, when it
+// failed, its problem, counting it in summary.
+func batchOutcome(res service.InvoiceBatchResult, summary *BatchSummary) (int, *httperror.Problem) {
+	switch {
+	case res.Err != nil:
+		summary.Failed++
+		p := httperror.FromError(res.Err)
+		return p.Status, &p
+	case res.Replayed:
+		summary.Replayed++
+		return http.StatusOK, nil
+	default:
+		summary.Created++
+		return http.StatusCreated, nil
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+)
+
+func TestInvoiceHandler_PostInvoicesBatch(t *testing.T) {
+	mockSvc := NewMockInvoiceService()
+	mockSvc.accounts["test-api-key"] = &service.AccountOutput{ID: "test-account-id", APIKey: "test-api-key"}
+	handler := NewInvoiceHandler(mockSvc)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		statuses    []int
+		summary     BatchSummary
+	}{
+		{
+			name:        "json array",
+			contentType: "application/json",
+			body: `[{"amount":10,"description":"Order 1","payment_type":"pix"},
+				{"amount":-1,"description":"Order 2","payment_type":"pix"},
+				{"amount":10,"description":"Order 3","payment_type":"pix","api_key":"other"},
+				{"amount":10,"description":"Order 4","payment_type":"pix","idempotency_key":"replayed"}]`,
+			statuses: []int{http.StatusCreated, http.StatusUnprocessableEntity, http.StatusUnprocessableEntity, http.StatusOK},
+			summary:  BatchSummary{Created: 1, Replayed: 1, Failed: 2},
+		},
+		{
+			name:        "ndjson",
+			contentType: "application/x-ndjson; charset=utf-8",
+			body: `{"amount":10,"description":"Order 1","payment_type":"pix"}
+{"amount":"ten","description":"Order 2","payment_type":"pix"}
+
+{"amount":20,"description":"Order 3","payment_type":"pix"}
+`,
+			statuses: []int{http.StatusCreated, http.StatusUnprocessableEntity, http.StatusCreated},
+			summary:  BatchSummary{Created: 2, Failed: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/invoices/batch", strings.NewReader(tt.body))
+			req.Header.Set("X-API-KEY", "test-api-key")
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+			handler.PostInvoicesBatch()(rr, req)
+
+			var resp BatchInvoicesResponse
+			if rr.Code != http.StatusOK || json.NewDecoder(rr.Body).Decode(&resp) != nil {
+				t.Fatalf("expected 200, got %d %s", rr.Code, rr.Body.String())
+			}
+			if resp.Summary != tt.summary || len(resp.Results) != len(tt.statuses) {
+				t.Fatalf("expected %+v over %d results, got %+v", tt.summary, len(tt.statuses), resp)
+			}
+			for k, r := range resp.Results {
+				if r.Index != k || r.Status != tt.statuses[k] || (r.Invoice == nil) == (r.Error == nil) {
+					t.Errorf("result %d: expected status %d with an invoice or an error, got %+v", k, tt.statuses[k], r)
+				}
+			}
+		})
+	}
+}
+
+func TestInvoiceHandler_PostInvoicesBatch_Rejected(t *testing.T) {
+	mockSvc := NewMockInvoiceService()
+	mockSvc.accounts["test-api-key"] = &service.AccountOutput{ID: "test-account-id", APIKey: "test-api-key"}
+	handler := NewInvoiceHandler(mockSvc)
+
+	for _, tt := range []struct {
+		body   string
+		apiKey string
+		status int
+	}{
+		{`[]`, "test-api-key", http.StatusUnprocessableEntity},
+		{`{"amount":10}`, "test-api-key", http.StatusBadRequest},
+		{`[{"amount":10}`, "test-api-key", http.StatusBadRequest},
+		{`[{"amount":10,"description":"Order","payment_type":"pix"}]`, "", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/invoices/batch", strings.NewReader(tt.body))
+		if tt.apiKey != "" {
+			req.Header.Set("X-API-KEY", tt.apiKey)
+		}
+		rr := httptest.NewRecorder()
+		handler.PostInvoicesBatch()(rr, req)
+		if rr.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d %s", tt.body, tt.status, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestV2Handler_PostInvoicesBatch(t *testing.T) {
+	mockSvc := NewMockInvoiceService()
+	mockSvc.accounts["test-api-key"] = &service.AccountOutput{ID: "test-account-id", APIKey: "test-api-key"}
+
+	req := httptest.NewRequest(http.MethodPost, "/v2/invoices/batch",
+		strings.NewReader(`[{"amount_cents":1050,"description":"Order 1","payment_type":"pix"},{"amount":10.5,"description":"Order 2","payment_type":"pix"}]`))
+	req.Header.Set("X-API-KEY", "test-api-key")
+	rr := httptest.NewRecorder()
+	NewV2Handler(nil, mockSvc, nil, nil, nil, nil, nil).PostInvoicesBatch()(rr, req)
+
+	var resp BatchInvoicesResponseV2
+	if rr.Code != http.StatusOK || json.NewDecoder(rr.Body).Decode(&resp) != nil || len(resp.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d %s", rr.Code, rr.Body.String())
+	}
+	if inv := resp.Results[0].Invoice; inv == nil || inv.AmountCents != 1050 {
+		t.Errorf("expected the invoice in cents, got %+v", resp.Results[0])
+	}
+	// v1 money fields are unknown to /v2
+	if e := resp.Results[1].Error; e == nil || e.Errors[0].Field != "amount" || e.Errors[0].Code != "request.unknown_field" {
+		t.Errorf("expected an unknown field error, got %+v", resp.Results[1])
+	}
+}
@@ -3,21 +3,24 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"strings"
 
-	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
 	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
 )
 
+var errInvalidInvoiceID = httperror.New(http.StatusBadRequest, "invoice.invalid_id", "invoice ID is required")
+
 // InvoiceServicePort defines only the methods needed by the handler.
 // It matches methods in service.InvoiceService.
 type InvoiceServicePort interface {
 	Create(ctx context.Context, in service.InvoiceCreateInput) (*service.InvoiceOutput, error)
-	GetByID(ctx context.Context, id string) (*service.InvoiceOutput, error)
-	GetByAccountID(ctx context.Context, accountID string) ([]*service.InvoiceOutput, error)
-	GetAccountByAPIKey(ctx context.Context, apiKey string) (*service.AccountOutput, error)
+	// GetByID and List only see the invoices of the account behind apiKey.
+	GetByID(ctx context.Context, apiKey, id string) (*service.InvoiceOutput, error)
+	List(ctx context.Context, apiKey string) ([]*service.InvoiceOutput, error)
+	ListByExternalReference(ctx context.Context, apiKey, ref string) ([]*service.InvoiceOutput, error)
+	CreateBatch(ctx context.Context, apiKey string, items []service.InvoiceBatchItem) ([]service.InvoiceBatchResult, error)
 }
 
 // InvoiceHandler handles HTTP requests for invoices.
@@ -58,31 +61,13 @@ func (h *InvoiceHandler) handleInvoices(w http.ResponseWriter, r *http.Request)
 	case http.MethodGet:
 		apiKey := r.Header.Get("X-API-KEY")
 		if apiKey == "" {
-			w.WriteHeader(http.StatusUnauthorized)
-			_ = json.NewEncoder(w).Encode(map[string]string{"error": "missing X-API-KEY header"})
-			return
-		}
-
-		// Get account from API key and then get invoices for that account
-		accountOutput, err := h.svc.GetAccountByAPIKey(r.Context(), apiKey)
-		if err != nil {
-			status := http.StatusInternalServerError
-			if errors.Is(err, domain.ErrAccountNotFound) {
-				status = http.StatusNotFound
-			}
-			w.WriteHeader(status)
-			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			httperror.Write(w, r, httperror.ErrMissingAPIKey)
 			return
 		}
 
-		out, err := h.svc.GetByAccountID(r.Context(), accountOutput.ID)
+		out, err := listInvoices(r, h.svc, apiKey)
 		if err != nil {
-			status := http.StatusInternalServerError
-			if errors.Is(err, domain.ErrInvoiceNotFound) {
-				status = http.StatusNotFound
-			}
-			w.WriteHeader(status)
-			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			httperror.Write(w, r, err)
 			return
 		}
 
@@ -90,49 +75,50 @@ func (h *InvoiceHandler) handleInvoices(w http.ResponseWriter, r *http.Request)
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(out)
 	default:
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "method not allowed"})
+		httperror.Write(w, r, httperror.ErrMethodNotAllowed)
+	}
+}
+
+// listInvoices lists the invoices of the account, narrowed to the one with
+// the merchant reference when ?external_reference= is given.
+func listInvoices(r *http.Request, svc InvoiceServicePort, apiKey string) ([]*service.InvoiceOutput, error) {
+	if ref := r.URL.Query().Get("external_reference"); ref != "" {
+		return svc.ListByExternalReference(r.Context(), apiKey, ref)
 	}
+	return svc.List(r.Context(), apiKey)
 }
 
 // GET /invoices/{id}
 func (h *InvoiceHandler) handleInvoiceByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "method not allowed"})
+		httperror.Write(w, r, httperror.ErrMethodNotAllowed)
 		return
 	}
 
 	apiKey := r.Header.Get("X-API-KEY")
 	if apiKey == "" {
-		w.WriteHeader(http.StatusUnauthorized)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "missing X-API-KEY header"})
+		httperror.Write(w, r, httperror.ErrMissingAPIKey)
 		return
 	}
 
-	// Extract ID from URL path
-	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 3 {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid invoice ID"})
-		return
+	// Routed by chi the ID is a path value; on a plain mux take it from the path
+	invoiceID := r.PathValue("id")
+	if invoiceID == "" {
+		pathParts := strings.Split(r.URL.Path, "/")
+		if len(pathParts) < 3 {
+			httperror.Write(w, r, errInvalidInvoiceID)
+			return
+		}
+		invoiceID = pathParts[2]
 	}
-
-	invoiceID := pathParts[2]
 	if invoiceID == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invoice ID is required"})
+		httperror.Write(w, r, errInvalidInvoiceID)
 		return
 	}
 
-	out, err := h.svc.GetByID(r.Context(), invoiceID)
+	out, err := h.svc.GetByID(r.Context(), apiKey, invoiceID)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, domain.ErrInvoiceNotFound) {
-			status = http.StatusNotFound
-		}
-		w.WriteHeader(status)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		httperror.Write(w, r, err)
 		return
 	}
 
@@ -147,15 +133,13 @@ func (h *InvoiceHandler) createInvoice(w http.ResponseWriter, r *http.Request) {
 	// Extract API key from header
 	apiKey := r.Header.Get("X-API-KEY")
 	if apiKey == "" {
-		w.WriteHeader(http.StatusUnauthorized)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "missing X-API-KEY header"})
+		httperror.Write(w, r, httperror.ErrMissingAPIKey)
 		return
 	}
 
 	var in service.InvoiceCreateInput
-	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+	if err := decodeJSON(r.Body, &in); err != nil {
+		httperror.Write(w, r, err)
 		return
 	}
 
@@ -164,18 +148,7 @@ func (h *InvoiceHandler) createInvoice(w http.ResponseWriter, r *http.Request) {
 
 	out, err := h.svc.Create(r.Context(), in)
 	if err != nil {
-		status := http.StatusBadRequest
-		// Domain validation errors should map to 400; others 500
-		switch {
-		case errors.Is(err, domain.ErrInvalidDescription),
-			errors.Is(err, domain.ErrInvalidPaymentType),
-			errors.Is(err, domain.ErrInvoiceNegativeValue):
-			status = http.StatusBadRequest
-		default:
-			status = http.StatusInternalServerError
-		}
-		w.WriteHeader(status)
-		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		httperror.Write(w, r, err)
 		return
 	}
 
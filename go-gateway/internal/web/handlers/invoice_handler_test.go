@@ -16,11 +16,11 @@ import (
 
 // MockInvoiceService is a mock implementation of InvoiceServicePort for testing
 type MockInvoiceService struct {
-	invoices            map[string]*service.InvoiceOutput
-	accounts            map[string]*service.AccountOutput
-	createError         error
-	getByIDError        error
-	getByAccountIDError error
+	invoices     map[string]*service.InvoiceOutput
+	accounts     map[string]*service.AccountOutput
+	createError  error
+	getByIDError error
+	listError    error
 }
 
 func NewMockInvoiceService() *MockInvoiceService {
@@ -36,6 +36,10 @@ func (m *MockInvoiceService) Create(ctx context.Context, in service.InvoiceCreat
 		return nil, m.createError
 	}
 
+	if _, exists := m.accounts[in.APIKey]; !exists {
+		return nil, domain.ErrAccountNotFound
+	}
+
 	// Simulate domain validation
 	if in.Amount <= 0 {
 		return nil, domain.ErrInvoiceNegativeValue
@@ -62,30 +66,70 @@ func (m *MockInvoiceService) Create(ctx context.Context, in service.InvoiceCreat
 	return invoice, nil
 }
 
-func (m *MockInvoiceService) GetByID(ctx context.Context, id string) (*service.InvoiceOutput, error) {
+func (m *MockInvoiceService) GetByID(ctx context.Context, apiKey, id string) (*service.InvoiceOutput, error) {
 	if m.getByIDError != nil {
 		return nil, m.getByIDError
 	}
+	account, err := m.GetAccountByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
 	invoice, exists := m.invoices[id]
-	if !exists {
+	if !exists || invoice.AccountID != account.ID {
 		return nil, domain.ErrInvoiceNotFound
 	}
 	return invoice, nil
 }
 
-func (m *MockInvoiceService) GetByAccountID(ctx context.Context, accountID string) ([]*service.InvoiceOutput, error) {
-	if m.getByAccountIDError != nil {
-		return nil, m.getByAccountIDError
+func (m *MockInvoiceService) List(ctx context.Context, apiKey string) ([]*service.InvoiceOutput, error) {
+	if m.listError != nil {
+		return nil, m.listError
+	}
+	account, err := m.GetAccountByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, err
 	}
 	var invoices []*service.InvoiceOutput
 	for _, invoice := range m.invoices {
-		if invoice.AccountID == accountID {
+		if invoice.AccountID == account.ID {
 			invoices = append(invoices, invoice)
 		}
 	}
 	return invoices, nil
 }
 
+func (m *MockInvoiceService) ListByExternalReference(ctx context.Context, apiKey, ref string) ([]*service.InvoiceOutput, error) {
+	list, err := m.List(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	invoices := []*service.InvoiceOutput{}
+	for _, invoice := range list {
+		if invoice.ExternalReference == ref {
+			invoices = append(invoices, invoice)
+		}
+	}
+	return invoices, nil
+}
+
+func (m *MockInvoiceService) CreateBatch(ctx context.Context, apiKey string, items []service.InvoiceBatchItem) ([]service.InvoiceBatchResult, error) {
+	if len(items) == 0 {
+		return nil, domain.ErrEmptyBatch
+	}
+	results := make([]service.InvoiceBatchResult, len(items))
+	for k, item := range items {
+		if item.Err != nil {
+			results[k].Err = item.Err
+			continue
+		}
+		in := item.Input
+		in.APIKey = apiKey
+		out, err := m.Create(ctx, in)
+		results[k] = service.InvoiceBatchResult{Invoice: out, Replayed: in.IdempotencyKey == "replayed", Err: err}
+	}
+	return results, nil
+}
+
 func (m *MockInvoiceService) GetAccountByAPIKey(ctx context.Context, apiKey string) (*service.AccountOutput, error) {
 	account, exists := m.accounts[apiKey]
 	if !exists {
@@ -121,7 +165,7 @@ func TestInvoiceHandler_CreateInvoice(t *testing.T) {
 				Description: "Test invoice",
 				PaymentType: "credit_card",
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 			expectedError:  true,
 		},
 		{
@@ -132,7 +176,7 @@ func TestInvoiceHandler_CreateInvoice(t *testing.T) {
 				Description: "Te",
 				PaymentType: "credit_card",
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 			expectedError:  true,
 		},
 	}
@@ -140,6 +184,7 @@ func TestInvoiceHandler_CreateInvoice(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockSvc := NewMockInvoiceService()
+			mockSvc.accounts["test-api-key-123"] = &service.AccountOutput{ID: "test-account-id", APIKey: "test-api-key-123"}
 			handler := NewInvoiceHandler(mockSvc)
 
 			inputJSON, _ := json.Marshal(tt.input)
@@ -221,6 +266,26 @@ func TestInvoiceHandler_GetInvoicesByAccountID(t *testing.T) {
 	}
 }
 
+func TestInvoiceHandler_GetInvoicesByExternalReference(t *testing.T) {
+	mockSvc := NewMockInvoiceService()
+	handler := NewInvoiceHandler(mockSvc)
+	mockSvc.accounts["test-api-key"] = &service.AccountOutput{ID: "test-account-id", APIKey: "test-api-key"}
+	mockSvc.invoices["inv-1"] = &service.InvoiceOutput{ID: "inv-1", AccountID: "test-account-id", ExternalReference: "order-42"}
+	mockSvc.invoices["inv-2"] = &service.InvoiceOutput{ID: "inv-2", AccountID: "test-account-id"}
+
+	for ref, want := range map[string]int{"order-42": 1, "order-99": 0} {
+		req := httptest.NewRequest(http.MethodGet, "/invoices?external_reference="+ref, nil)
+		req.Header.Set("X-API-KEY", "test-api-key")
+		w := httptest.NewRecorder()
+		handler.GetInvoices()(w, req)
+
+		var response []*service.InvoiceOutput
+		if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&response) != nil || len(response) != want {
+			t.Errorf("%s: expected %d invoices, got %d %s", ref, want, w.Code, w.Body.String())
+		}
+	}
+}
+
 func TestInvoiceHandler_GetInvoiceByID(t *testing.T) {
 	mockSvc := NewMockInvoiceService()
 	handler := NewInvoiceHandler(mockSvc)
@@ -236,6 +301,7 @@ func TestInvoiceHandler_GetInvoiceByID(t *testing.T) {
 		CardLastDigits: "1234",
 	}
 	mockSvc.invoices[testInvoice.ID] = testInvoice
+	mockSvc.accounts["test-api-key"] = &service.AccountOutput{ID: "test-account-id", APIKey: "test-api-key"}
 
 	req := httptest.NewRequest(http.MethodGet, "/invoices/test-invoice-id", nil)
 	req.Header.Set("X-API-KEY", "test-api-key")
@@ -436,8 +502,8 @@ func TestInvoiceHandler_CreateInvoice_DomainValidationError(t *testing.T) {
 	w := httptest.NewRecorder()
 	handler.PostInvoices()(w, req)
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
 	}
 }
 
@@ -521,8 +587,8 @@ func TestInvoiceHandler_GetInvoices_ServiceError(t *testing.T) {
 		Balance: 1000.0,
 	}
 	mockSvc.accounts["test-api-key"] = testAccount
-	// Set service to return error for GetByAccountID
-	mockSvc.getByAccountIDError = errors.New("service error")
+	// Set service to return error for List
+	mockSvc.listError = errors.New("service error")
 	handler := NewInvoiceHandler(mockSvc)
 
 	req := httptest.NewRequest(http.MethodGet, "/invoices?account_id=test-account-id", nil)
@@ -566,3 +632,31 @@ func TestInvoiceHandler_GetInvoices_MethodNotAllowed(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
 	}
 }
+
+func TestInvoiceHandler_CreateInvoice_ProblemJSON(t *testing.T) {
+	mockSvc := NewMockInvoiceService()
+	mockSvc.accounts["test-api-key"] = &service.AccountOutput{ID: "test-account-id", APIKey: "test-api-key"}
+	mockSvc.createError = domain.ErrAccountIDRequired
+	handler := NewInvoiceHandler(mockSvc)
+
+	req := httptest.NewRequest(http.MethodPost, "/invoices", bytes.NewBufferString(`{"amount":100.00,"description":"Test invoice","payment_type":"credit_card"}`))
+	req.Header.Set("X-API-KEY", "test-api-key")
+
+	w := httptest.NewRecorder()
+	handler.PostInvoices()(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("expected problem+json, got %s", ct)
+	}
+
+	var problem map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if problem["code"] != "invoice.account_required" {
+		t.Errorf("expected code 'invoice.account_required', got %v", problem["code"])
+	}
+}
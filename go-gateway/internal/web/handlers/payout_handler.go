@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+)
+
+// PayoutServicePort defines the methods needed by the payout handler.
+// It matches methods in service.PayoutService.
+type PayoutServicePort interface {
+	RegisterBankAccount(ctx context.Context, in service.BankAccountInput) (*service.BankAccountOutput, error)
+	ListBankAccounts(ctx context.Context, apiKey string) ([]*service.BankAccountOutput, error)
+	Create(ctx context.Context, in service.PayoutCreateInput) (*service.PayoutOutput, error)
+	GetByID(ctx context.Context, apiKey, id string) (*service.PayoutOutput, error)
+	List(ctx context.Context, apiKey string) ([]*service.PayoutOutput, error)
+}
+
+// PayoutHandler handles bank accounts and payouts of the authenticated account.
+type PayoutHandler struct {
+	svc PayoutServicePort
+}
+
+func NewPayoutHandler(svc PayoutServicePort) *PayoutHandler {
+	return &PayoutHandler{svc: svc}
+}
+
+// PostBankAccounts returns a handler for POST /bank-accounts
+func (h *PayoutHandler) PostBankAccounts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.BankAccountInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.APIKey = r.Header.Get("X-API-KEY")
+		out, err := h.svc.RegisterBankAccount(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, out)
+	}
+}
+
+// GetBankAccounts returns a handler for GET /bank-accounts
+func (h *PayoutHandler) GetBankAccounts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.ListBankAccounts(r.Context(), r.Header.Get("X-API-KEY"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// PostPayouts returns a handler for POST /payouts
+func (h *PayoutHandler) PostPayouts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.PayoutCreateInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.APIKey = r.Header.Get("X-API-KEY")
+		out, err := h.svc.Create(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, out)
+	}
+}
+
+// GetPayouts returns a handler for GET /payouts
+func (h *PayoutHandler) GetPayouts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.List(r.Context(), r.Header.Get("X-API-KEY"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// GetPayoutByID returns a handler for GET /payouts/{id}
+func (h *PayoutHandler) GetPayoutByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.GetByID(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+)
+
+// fakePayoutService records the payout input it receives.
+type fakePayoutService struct {
+	createIn service.PayoutCreateInput
+}
+
+func (f *fakePayoutService) RegisterBankAccount(_ context.Context, in service.BankAccountInput) (*service.BankAccountOutput, error) {
+	return &service.BankAccountOutput{ID: "bank-1", BankCode: in.BankCode}, nil
+}
+
+func (f *fakePayoutService) ListBankAccounts(context.Context, string) ([]*service.BankAccountOutput, error) {
+	return []*service.BankAccountOutput{}, nil
+}
+
+func (f *fakePayoutService) Create(_ context.Context, in service.PayoutCreateInput) (*service.PayoutOutput, error) {
+	f.createIn = in
+	if in.Amount > 100 {
+		return nil, domain.ErrInsufficientFunds
+	}
+	return &service.PayoutOutput{ID: "pay-1", BankAccountID: in.BankAccountID, Amount: in.Amount, Status: "pending"}, nil
+}
+
+func (f *fakePayoutService) GetByID(context.Context, string, string) (*service.PayoutOutput, error) {
+	return nil, domain.ErrPayoutNotFound
+}
+
+func (f *fakePayoutService) List(context.Context, string) ([]*service.PayoutOutput, error) {
+	return []*service.PayoutOutput{}, nil
+}
+
+func TestPayoutHandler_PostPayouts(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"created", `{"bank_account_id":"bank-1","amount":50}`, http.StatusCreated},
+		{"insufficient funds", `{"bank_account_id":"bank-1","amount":500}`, http.StatusUnprocessableEntity},
+		{"unknown field", `{"bank_account_id":"bank-1","amount":50,"api_key":"x"}`, http.StatusUnprocessableEntity},
+		{"malformed", `{`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakePayoutService{}
+			req := httptest.NewRequest(http.MethodPost, "/payouts", bytes.NewBufferString(tt.body))
+			req.Header.Set("X-API-KEY", "key-1")
+			rr := httptest.NewRecorder()
+
+			NewPayoutHandler(svc).PostPayouts()(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if tt.status == http.StatusCreated && svc.createIn.APIKey != "key-1" {
+				t.Fatalf("expected API key from header, got %+v", svc.createIn)
+			}
+		})
+	}
+}
+
+func TestPayoutHandler_GetPayoutByID_NotFound(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewPayoutHandler(&fakePayoutService{}).GetPayoutByID()(rr, httptest.NewRequest(http.MethodGet, "/payouts/missing", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 got %d", rr.Code)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+)
+
+// SubscriptionServicePort defines the methods needed by the subscription
+// handler. It matches methods in service.SubscriptionService.
+type SubscriptionServicePort interface {
+	CreatePlan(ctx context.Context, in service.PlanInput) (*service.PlanOutput, error)
+	ListPlans(ctx context.Context, apiKey string) ([]*service.PlanOutput, error)
+	Subscribe(ctx context.Context, in service.SubscriptionInput) (*service.SubscriptionOutput, error)
+	GetByID(ctx context.Context, apiKey, id string) (*service.SubscriptionOutput, error)
+	List(ctx context.Context, apiKey string) ([]*service.SubscriptionOutput, error)
+	Cancel(ctx context.Context, apiKey, id string) (*service.SubscriptionOutput, error)
+}
+
+// SubscriptionHandler handles plans and subscriptions of the authenticated
+// account.
+type SubscriptionHandler struct {
+	svc SubscriptionServicePort
+}
+
+func NewSubscriptionHandler(svc SubscriptionServicePort) *SubscriptionHandler {
+	return &SubscriptionHandler{svc: svc}
+}
+
+// PostPlans returns a handler for POST /plans
+func (h *SubscriptionHandler) PostPlans() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.PlanInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.APIKey = r.Header.Get("X-API-KEY")
+		out, err := h.svc.CreatePlan(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, out)
+	}
+}
+
+// GetPlans returns a handler for GET /plans
+func (h *SubscriptionHandler) GetPlans() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.ListPlans(r.Context(), r.Header.Get("X-API-KEY"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// PostSubscriptions returns a handler for POST /subscriptions
+func (h *SubscriptionHandler) PostSubscriptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.SubscriptionInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.APIKey = r.Header.Get("X-API-KEY")
+		out, err := h.svc.Subscribe(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, out)
+	}
+}
+
+// GetSubscriptions returns a handler for GET /subscriptions
+func (h *SubscriptionHandler) GetSubscriptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.List(r.Context(), r.Header.Get("X-API-KEY"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// GetSubscriptionByID returns a handler for GET /subscriptions/{id}
+func (h *SubscriptionHandler) GetSubscriptionByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.GetByID(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// CancelSubscription returns a handler for POST /subscriptions/{id}/cancel
+func (h *SubscriptionHandler) CancelSubscription() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.svc.Cancel(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
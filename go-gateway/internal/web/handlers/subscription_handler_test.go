@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+)
+
+// fakeSubscriptionService records the inputs it receives.
+type fakeSubscriptionService struct {
+	planIn service.PlanInput
+	subIn  service.SubscriptionInput
+}
+
+func (f *fakeSubscriptionService) CreatePlan(_ context.Context, in service.PlanInput) (*service.PlanOutput, error) {
+	f.planIn = in
+	return &service.PlanOutput{ID: "plan-1", Name: in.Name, Amount: in.Amount, Interval: in.Interval, IntervalCount: 1}, nil
+}
+
+func (f *fakeSubscriptionService) ListPlans(context.Context, string) ([]*service.PlanOutput, error) {
+	return []*service.PlanOutput{}, nil
+}
+
+func (f *fakeSubscriptionService) Subscribe(_ context.Context, in service.SubscriptionInput) (*service.SubscriptionOutput, error) {
+	f.subIn = in
+	if in.PlanID != "plan-1" {
+		return nil, domain.ErrPlanNotFound
+	}
+	return &service.SubscriptionOutput{ID: "sub-1", PlanID: in.PlanID, Amount: 49.9, CardLastDigits: in.CardLastDigits, Status: "active"}, nil
+}
+
+func (f *fakeSubscriptionService) GetByID(context.Context, string, string) (*service.SubscriptionOutput, error) {
+	return nil, domain.ErrSubscriptionNotFound
+}
+
+func (f *fakeSubscriptionService) List(context.Context, string) ([]*service.SubscriptionOutput, error) {
+	return []*service.SubscriptionOutput{}, nil
+}
+
+func (f *fakeSubscriptionService) Cancel(context.Context, string, string) (*service.SubscriptionOutput, error) {
+	return nil, domain.ErrInvalidSubscriptionTransition
+}
+
+func TestSubscriptionHandler_PostSubscriptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"created", `{"plan_id":"plan-1","card_token":"tok_12345678","card_last_digits":"4242","anchor_date":"2026-11-01T00:00:00Z"}`, http.StatusCreated},
+		{"unknown plan", `{"plan_id":"plan-2","card_token":"tok_12345678","card_last_digits":"4242"}`, http.StatusUnprocessableEntity},
+		{"unknown field", `{"plan_id":"plan-1","card_number":"4242424242424242"}`, http.StatusUnprocessableEntity},
+		{"malformed", `{`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeSubscriptionService{}
+			req := httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewBufferString(tt.body))
+			req.Header.Set("X-API-KEY", "key-1")
+			rr := httptest.NewRecorder()
+
+			NewSubscriptionHandler(svc).PostSubscriptions()(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if tt.status == http.StatusCreated && (svc.subIn.APIKey != "key-1" || svc.subIn.AnchorDate == nil) {
+				t.Fatalf("expected API key from header and the anchor date, got %+v", svc.subIn)
+			}
+			if strings.Contains(rr.Body.String(), "tok_12345678") {
+				t.Fatalf("response echoes the card token: %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestV2Handler_PostPlans(t *testing.T) {
+	svc := &fakeSubscriptionService{}
+	req := httptest.NewRequest(http.MethodPost, "/v2/plans", bytes.NewBufferString(`{"name":"Pro","amount_cents":4990,"interval":"month"}`))
+	req.Header.Set("X-API-KEY", "key-1")
+	rr := httptest.NewRecorder()
+
+	NewV2Handler(nil, nil, nil, svc, nil, nil, nil).PostPlans()(rr, req)
+
+	if rr.Code != http.StatusCreated || !strings.Contains(rr.Body.String(), `"amount_cents":4990`) {
+		t.Fatalf("expected a plan in cents, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if svc.planIn.Amount != 49.9 || svc.planIn.APIKey != "key-1" {
+		t.Fatalf("expected the amount converted from cents, got %+v", svc.planIn)
+	}
+}
+
+func TestSubscriptionHandler_Errors(t *testing.T) {
+	h := NewSubscriptionHandler(&fakeSubscriptionService{})
+
+	rr := httptest.NewRecorder()
+	h.GetSubscriptionByID()(rr, httptest.NewRequest(http.MethodGet, "/subscriptions/missing", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.CancelSubscription()(rr, httptest.NewRequest(http.MethodPost, "/subscriptions/sub-1/cancel", nil))
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 got %d", rr.Code)
+	}
+}
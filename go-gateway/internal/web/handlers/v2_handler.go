@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+)
+
+// V2Handler serves the /v2 API. It reuses the v1 services and only changes
+// the wire format: money is exchanged as integer cents.
+type V2Handler struct {
+	accounts      AccountServicePort
+	invoices      InvoiceServicePort
+	payouts       PayoutServicePort
+	subscriptions SubscriptionServicePort
+	checkout      CheckoutServicePort
+	disputes      DisputeServicePort
+	customers     CustomerServicePort
+}
+
+func NewV2Handler(accounts AccountServicePort, invoices InvoiceServicePort, payouts PayoutServicePort, subscriptions SubscriptionServicePort, checkout CheckoutServicePort, disputes DisputeServicePort, customers CustomerServicePort) *V2Handler {
+	return &V2Handler{accounts: accounts, invoices: invoices, payouts: payouts, subscriptions: subscriptions, checkout: checkout, disputes: disputes, customers: customers}
+}
+
+// PostAccounts returns a handler for POST /v2/accounts
+func (h *V2Handler) PostAccounts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.AccountCreateInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		out, err := h.accounts.Create(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, service.NewAccountOutputV2(out))
+	}
+}
+
+// GetAccounts returns a handler for GET /v2/accounts (via X-API-KEY)
+func (h *V2Handler) GetAccounts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiKey := r.Header.Get("X-API-KEY")
+		if apiKey == "" {
+			httperror.Write(w, r, httperror.ErrMissingAPIKey)
+			return
+		}
+		out, err := h.accounts.GetByAPIKey(r.Context(), apiKey)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, service.NewAccountOutputV2(out))
+	}
+}
+
+// PostInvoices returns a handler for POST /v2/invoices
+func (h *V2Handler) PostInvoices() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiKey := r.Header.Get("X-API-KEY")
+		if apiKey == "" {
+			httperror.Write(w, r, httperror.ErrMissingAPIKey)
+			return
+		}
+		var in service.InvoiceCreateInputV2
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		out, err := h.invoices.Create(r.Context(), in.ToV1(apiKey))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, service.NewInvoiceOutputV2(out))
+	}
+}
+
+// GetInvoices returns a handler for GET /v2/invoices
+func (h *V2Handler) GetInvoices() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiKey := r.Header.Get("X-API-KEY")
+		if apiKey == "" {
+			httperror.Write(w, r, httperror.ErrMissingAPIKey)
+			return
+		}
+		list, err := listInvoices(r, h.invoices, apiKey)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		out := make([]*service.InvoiceOutputV2, 0, len(list))
+		for _, inv := range list {
+			out = append(out, service.NewInvoiceOutputV2(inv))
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// GetInvoiceByID returns a handler for GET /v2/invoices/{id}
+func (h *V2Handler) GetInvoiceByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiKey := r.Header.Get("X-API-KEY")
+		if apiKey == "" {
+			httperror.Write(w, r, httperror.ErrMissingAPIKey)
+			return
+		}
+		id := r.PathValue("id")
+		if id == "" {
+			httperror.Write(w, r, errInvalidInvoiceID)
+			return
+		}
+		out, err := h.invoices.GetByID(r.Context(), apiKey, id)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, service.NewInvoiceOutputV2(out))
+	}
+}
+
+// PostPayouts returns a handler for POST /v2/payouts
+func (h *V2Handler) PostPayouts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.PayoutCreateInputV2
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		out, err := h.payouts.Create(r.Context(), in.ToV1(r.Header.Get("X-API-KEY")))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, service.NewPayoutOutputV2(out))
+	}
+}
+
+// GetPayouts returns a handler for GET /v2/payouts
+func (h *V2Handler) GetPayouts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := h.payouts.List(r.Context(), r.Header.Get("X-API-KEY"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		out := make([]*service.PayoutOutputV2, 0, len(list))
+		for _, p := range list {
+			out = append(out, service.NewPayoutOutputV2(p))
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// GetPayoutByID returns a handler for GET /v2/payouts/{id}
+func (h *V2Handler) GetPayoutByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.payouts.GetByID(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, service.NewPayoutOutputV2(out))
+	}
+}
+
+// PostPlans returns a handler for POST /v2/plans
+func (h *V2Handler) PostPlans() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.PlanInputV2
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		out, err := h.subscriptions.CreatePlan(r.Context(), in.ToV1(r.Header.Get("X-API-KEY")))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, service.NewPlanOutputV2(out))
+	}
+}
+
+// GetPlans returns a handler for GET /v2/plans
+func (h *V2Handler) GetPlans() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := h.subscriptions.ListPlans(r.Context(), r.Header.Get("X-API-KEY"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		out := make([]*service.PlanOutputV2, 0, len(list))
+		for _, p := range list {
+			out = append(out, service.NewPlanOutputV2(p))
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// PostSubscriptions returns a handler for POST /v2/subscriptions
+func (h *V2Handler) PostSubscriptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.SubscriptionInput
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		in.APIKey = r.Header.Get("X-API-KEY")
+		out, err := h.subscriptions.Subscribe(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, service.NewSubscriptionOutputV2(out))
+	}
+}
+
+// GetSubscriptions returns a handler for GET /v2/subscriptions
+func (h *V2Handler) GetSubscriptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := h.subscriptions.List(r.Context(), r.Header.Get("X-API-KEY"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		out := make([]*service.SubscriptionOutputV2, 0, len(list))
+		for _, sub := range list {
+			out = append(out, service.NewSubscriptionOutputV2(sub))
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// GetSubscriptionByID returns a handler for GET /v2/subscriptions/{id}
+func (h *V2Handler) GetSubscriptionByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.subscriptions.GetByID(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, service.NewSubscriptionOutputV2(out))
+	}
+}
+
+// CancelSubscription returns a handler for POST /v2/subscriptions/{id}/cancel
+func (h *V2Handler) CancelSubscription() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.subscriptions.Cancel(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, service.NewSubscriptionOutputV2(out))
+	}
+}
+
+// PostCheckoutSessions returns a handler for POST /v2/checkout-sessions
+func (h *V2Handler) PostCheckoutSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in service.CheckoutSessionInputV2
+		if err := decodeJSON(r.Body, &in); err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		out, err := h.checkout.CreateSession(r.Context(), in.ToV1(r.Header.Get("X-API-KEY")))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, service.NewCheckoutSessionOutputV2(out))
+	}
+}
+
+// GetCheckoutSessionByID returns a handler for GET /v2/checkout-sessions/{id}
+func (h *V2Handler) GetCheckoutSessionByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.checkout.GetSession(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, service.NewCheckoutSessionOutputV2(out))
+	}
+}
+
+// GetCheckout returns a handler for the public GET /v2/checkout/{id}
+func (h *V2Handler) GetCheckout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.checkout.Get(r.Context(), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, service.NewCheckoutOutputV2(out))
+	}
+}
+
+// GetDisputes returns a handler for GET /v2/disputes
+func (h *V2Handler) GetDisputes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := h.disputes.List(r.Context(), r.Header.Get("X-API-KEY"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		out := make([]*service.DisputeOutputV2, 0, len(list))
+		for _, d := range list {
+			out = append(out, service.NewDisputeOutputV2(d))
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+// GetDisputeByID returns a handler for GET /v2/disputes/{id}
+func (h *V2Handler) GetDisputeByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out, err := h.disputes.GetByID(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, service.NewDisputeOutputV2(out))
+	}
+}
+
+// PostDisputeEvidence returns a handler for POST /v2/disputes/{id}/evidence
+func (h *V2Handler) PostDisputeEvidence() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		in, ok := decodeEvidence(w, r)
+		if !ok {
+			return
+		}
+		out, err := h.disputes.SubmitEvidence(r.Context(), in)
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, service.NewDisputeOutputV2(out))
+	}
+}
+
+// GetCustomerInvoices returns a handler for GET /v2/customers/{id}/invoices
+func (h *V2Handler) GetCustomerInvoices() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := h.customers.ListInvoices(r.Context(), r.Header.Get("X-API-KEY"), r.PathValue("id"))
+		if err != nil {
+			httperror.Write(w, r, err)
+			return
+		}
+		out := make([]*service.InvoiceOutputV2, 0, len(list))
+		for _, inv := range list {
+			out = append(out, service.NewInvoiceOutputV2(inv))
+		}
+		writeJSON(w, http.StatusOK, out)
+	}
+}
@@ -0,0 +1,136 @@
+// Package httperror renders errors as RFC 7807 problem+json documents with
+// stable machine-readable codes. Domain errors are translated in one place so
+// handlers and middleware never expose raw internal messages.
+package httperror
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// ContentType is the media type of problem documents.
+const ContentType = "application/problem+json"
+
+// RequestIDHeader carries the request ID. The RequestID middleware sets it on
+// the response before handlers run, so problems can echo it back.
+const RequestIDHeader = "X-Request-Id"
+
+// typeBase prefixes the problem type URI built from the code.
+const typeBase = "https://gateway.dev/problems/"
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a validation failure on a single input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error is an HTTP-level error raised by handlers or middleware (bad JSON,
+// missing credentials...) that does not come from the domain.
+type Error struct {
+	Status int
+	Code   string
+	Detail string
+	Fields []FieldError
+}
+
+func (e *Error) Error() string { return e.Detail }
+
+// New builds an HTTP-level error.
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// Common HTTP-level errors.
+var (
+	ErrInvalidJSON      = New(http.StatusBadRequest, "request.invalid_json", "request body is not valid JSON")
+	ErrBodyTooLarge     = New(http.StatusRequestEntityTooLarge, "request.too_large", "request body too large")
+	ErrMethodNotAllowed = New(http.StatusMethodNotAllowed, "request.method_not_allowed", "method not allowed")
+	ErrRouteNotFound    = New(http.StatusNotFound, "request.not_found", "route not found")
+	ErrMissingAPIKey    = New(http.StatusUnauthorized, "auth.missing_api_key", "X-API-KEY header is required")
+	ErrInvalidAPIKey    = New(http.StatusUnauthorized, "auth.invalid_api_key", "Invalid API key")
+	ErrMissingAdminAuth = New(http.StatusUnauthorized, "admin.missing_credentials", "Authorization: Bearer <admin token> is required")
+	ErrInvalidAdminAuth = New(http.StatusUnauthorized, "admin.invalid_credentials", "invalid admin credentials")
+)
+
+// InvalidField builds a 422 error for a single request field.
+func InvalidField(field, code, message string) *Error {
+	return &Error{
+		Status: http.StatusUnprocessableEntity,
+		Code:   "validation.failed",
+		Detail: "request validation failed",
+		Fields: []FieldError{{Field: field, Code: code, Message: message}},
+	}
+}
+
+// FromError translates err into a problem. Unknown errors become a generic 500
+// and are logged, since their message may leak internals.
+func FromError(err error) Problem {
+	var httpErr *Error
+	if errors.As(err, &httpErr) {
+		p := newProblem(httpErr.Status, httpErr.Code, httpErr.Detail)
+		p.Errors = httpErr.Fields
+		return p
+	}
+
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		p := newProblem(http.StatusUnprocessableEntity, "validation.failed", "request validation failed")
+		for _, f := range verr.Fields {
+			fe := FieldError{Field: f.Field, Code: "validation.invalid", Message: f.Err.Error()}
+			if m, ok := lookup(f.Err); ok {
+				fe.Code, fe.Message = m.code, m.detail
+			}
+			p.Errors = append(p.Errors, fe)
+		}
+		return p
+	}
+
+	if m, ok := lookup(err); ok {
+		p := newProblem(m.status, m.code, m.detail)
+		if m.field != "" {
+			p.Errors = []FieldError{{Field: m.field, Code: m.code, Message: m.detail}}
+		}
+		return p
+	}
+
+	log.Printf("unhandled error: %v", err)
+	return newProblem(http.StatusInternalServerError, "internal.error", "internal server error")
+}
+
+// Write renders err as problem+json.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	p := FromError(err)
+	p.Instance = r.URL.Path
+	p.RequestID = w.Header().Get(RequestIDHeader)
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+func newProblem(status int, code, detail string) Problem {
+	return Problem{
+		Type:   typeBase + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
@@ -0,0 +1,89 @@
+package httperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		field  string
+	}{
+		{"account not found", domain.ErrAccountNotFound, http.StatusNotFound, "account.not_found", ""},
+		{"wrapped invoice not found", fmt.Errorf("get: %w", domain.ErrInvoiceNotFound), http.StatusNotFound, "invoice.not_found", ""},
+		{"invalid amount", domain.ErrInvoiceNegativeValue, http.StatusUnprocessableEntity, "invoice.invalid_amount", "amount"},
+		{"account id required", domain.ErrAccountIDRequired, http.StatusUnprocessableEntity, "invoice.account_required", "account_id"},
+		{"http error", ErrMissingAPIKey, http.StatusUnauthorized, "auth.missing_api_key", ""},
+		{"unknown", errors.New("pq: connection refused"), http.StatusInternalServerError, "internal.error", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := FromError(tt.err)
+			if p.Status != tt.status || p.Code != tt.code {
+				t.Fatalf("expected %d %s, got %d %s", tt.status, tt.code, p.Status, p.Code)
+			}
+			if tt.field != "" && (len(p.Errors) != 1 || p.Errors[0].Field != tt.field) {
+				t.Fatalf("expected field error on %s, got %+v", tt.field, p.Errors)
+			}
+		})
+	}
+}
+
+func TestFromError_DoesNotLeakInternalMessages(t *testing.T) {
+	p := FromError(errors.New("pq: password authentication failed for user postgres"))
+	if p.Detail != "internal server error" {
+		t.Fatalf("expected generic detail, got %q", p.Detail)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/invoices/123", nil)
+	w := httptest.NewRecorder()
+	w.Header().Set(RequestIDHeader, "req-1")
+
+	Write(w, req, domain.ErrInvoiceNotFound)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ContentType {
+		t.Fatalf("expected content type %s got %s", ContentType, ct)
+	}
+	var p Problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if p.RequestID != "req-1" || p.Instance != "/invoices/123" || p.Type == "" || p.Title != "Not Found" {
+		t.Fatalf("unexpected problem: %+v", p)
+	}
+}
+
+func TestFromError_ValidationError(t *testing.T) {
+	verr := &domain.ValidationError{}
+	verr.Add("name", domain.ErrInvalidName)
+	verr.Add("email", domain.ErrInvalidEmail)
+
+	p := FromError(verr)
+	if p.Status != http.StatusUnprocessableEntity || p.Code != "validation.failed" {
+		t.Fatalf("expected 422 validation.failed, got %d %s", p.Status, p.Code)
+	}
+	if len(p.Errors) != 2 {
+		t.Fatalf("expected 2 field errors got %+v", p.Errors)
+	}
+	if p.Errors[0].Field != "name" || p.Errors[0].Code != "account.invalid_name" {
+		t.Fatalf("unexpected first field error: %+v", p.Errors[0])
+	}
+	if p.Errors[1].Field != "email" || p.Errors[1].Code != "account.invalid_email" {
+		t.Fatalf("unexpected second field error: %+v", p.Errors[1])
+	}
+}
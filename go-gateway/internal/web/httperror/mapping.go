@@ -0,0 +1,150 @@
+package httperror
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+)
+
+// mapping ties a domain error to its HTTP status and stable code. field is set
+// for validation errors that concern a single input field.
+type mapping struct {
+	err    error
+	status int
+	code   string
+	field  string
+	detail string
+}
+
+var mappings = []mapping{
+	// Accounts
+	{domain.ErrAccountNotFound, http.StatusNotFound, "account.not_found", "", "account not found"},
+	{domain.ErrInvalidName, http.StatusUnprocessableEntity, "account.invalid_name", "name", "name must have between 2 and 100 characters"},
+	{domain.ErrInvalidEmail, http.StatusUnprocessableEntity, "account.invalid_email", "email", "email is not a valid address"},
+	{domain.ErrNegativeValue, http.StatusUnprocessableEntity, "account.invalid_amount", "amount", "amount must be positive"},
+	{domain.ErrInvalidAccountStatus, http.StatusUnprocessableEntity, "account.invalid_status", "status", "status must be one of active, suspended, closed"},
+	{domain.ErrAccountSuspended, http.StatusForbidden, "account.suspended", "", "account is suspended and can only read data"},
+	{domain.ErrAccountClosed, http.StatusForbidden, "account.closed", "", "account is closed"},
+	{domain.ErrInvalidStatusTransition, http.StatusConflict, "account.invalid_status_transition", "", "account status does not allow this operation"},
+	{domain.ErrInsufficientFunds, http.StatusUnprocessableEntity, "account.insufficient_funds", "", "insufficient funds"},
+
+	// Balance adjustments
+	{domain.ErrInvalidAdjustmentAmount, http.StatusUnprocessableEntity, "adjustment.invalid_amount", "amount", "amount must not be zero"},
+	{domain.ErrInvalidReason, http.StatusUnprocessableEntity, "adjustment.invalid_reason", "reason", "reason must have between 3 and 255 characters"},
+	{domain.ErrInvalidActor, http.StatusUnprocessableEntity, "adjustment.invalid_actor", "actor", "X-Admin-Actor must have between 1 and 100 characters"},
+
+	// Bank accounts and payouts
+	{domain.ErrInvalidBankCode, http.StatusUnprocessableEntity, "bank_account.invalid_bank_code", "bank_code", "bank code must have 3 digits"},
+	{domain.ErrInvalidBranch, http.StatusUnprocessableEntity, "bank_account.invalid_branch", "branch", "branch must have 1 to 5 digits"},
+	{domain.ErrInvalidAccountNumber, http.StatusUnprocessableEntity, "bank_account.invalid_number", "number", "number must have up to 12 digits and an optional check digit (12345-6)"},
+	{domain.ErrInvalidHolderName, http.StatusUnprocessableEntity, "bank_account.invalid_holder_name", "holder_name", "holder name must have between 2 and 100 characters"},
+	{domain.ErrBankAccountRequired, http.StatusUnprocessableEntity, "payout.bank_account_required", "bank_account_id", "bank account ID is required"},
+	{domain.ErrBankAccountNotFound, http.StatusUnprocessableEntity, "payout.unknown_bank_account", "bank_account_id", "bank account is not registered for this account"},
+	{domain.ErrPayoutBelowMinimum, http.StatusUnprocessableEntity, "payout.below_minimum", "amount", "amount is below the payout minimum"},
+	{domain.ErrPayoutNotFound, http.StatusNotFound, "payout.not_found", "", "payout not found"},
+	{domain.ErrInvalidPayoutTransition, http.StatusConflict, "payout.invalid_status_transition", "", "payout status does not allow this operation"},
+
+	// Fees
+	{domain.ErrInvalidFeePercent, http.StatusUnprocessableEntity, "fee.invalid_percent", "percent", "percent must be between 0 and 100 with at most 2 decimal places"},
+	{domain.ErrInvalidFeeFixed, http.StatusUnprocessableEntity, "fee.invalid_fixed", "fixed", "fixed must be zero or positive with at most 2 decimal places"},
+	{domain.ErrInvalidFeePeriod, http.StatusUnprocessableEntity, "fee.invalid_period", "to", "to must be after from"},
+
+	// Invoices
+	{domain.ErrInvoiceNotFound, http.StatusNotFound, "invoice.not_found", "", "invoice not found"},
+	{domain.ErrAccountIDRequired, http.StatusUnprocessableEntity, "invoice.account_required", "account_id", "account ID is required"},
+	{domain.ErrInvalidAmount, http.StatusUnprocessableEntity, "invoice.invalid_amount", "amount", "amount is invalid"},
+	{domain.ErrInvoiceNegativeValue, http.StatusUnprocessableEntity, "invoice.invalid_amount", "amount", "amount must be positive"},
+	{domain.ErrAmountTooLarge, http.StatusUnprocessableEntity, "invoice.amount_too_large", "amount", "amount exceeds the maximum of 99999999.99"},
+	{domain.ErrAmountPrecision, http.StatusUnprocessableEntity, "invoice.invalid_amount_precision", "amount", "amount must have at most 2 decimal places"},
+	{domain.ErrInvalidDescription, http.StatusUnprocessableEntity, "invoice.invalid_description", "description", "description must have between 3 and 255 characters"},
+	{domain.ErrInvalidPaymentType, http.StatusUnprocessableEntity, "invoice.invalid_payment_type", "payment_type", "payment type is required"},
+	{domain.ErrInvalidCardDigits, http.StatusUnprocessableEntity, "invoice.invalid_card_last_digits", "card_last_digits", "card last digits must be exactly 4 digits"},
+	{domain.ErrInvalidStatus, http.StatusUnprocessableEntity, "invoice.invalid_status", "status", "status is invalid"},
+
+	// Installments
+	{domain.ErrInvalidInstallments, http.StatusUnprocessableEntity, "invoice.invalid_installments", "installments", "installments must be between 1 and 12"},
+	{domain.ErrInstallmentsNotAllowed, http.StatusUnprocessableEntity, "invoice.installments_not_allowed", "installments", "only credit_card invoices can be paid in installments"},
+	{domain.ErrInstallmentsAboveLimit, http.StatusUnprocessableEntity, "invoice.installments_above_limit", "installments", "installments exceed the max_installments of the account"},
+	{domain.ErrInstallmentTotalTooLarge, http.StatusUnprocessableEntity, "invoice.installment_total_too_large", "installments", "total with installment interest exceeds the maximum of 99999999.99"},
+	{domain.ErrInvalidMaxInstallments, http.StatusUnprocessableEntity, "installment.invalid_max_installments", "max_installments", "max installments must be between 1 and 12"},
+	{domain.ErrInvalidFreeInstallments, http.StatusUnprocessableEntity, "installment.invalid_free_installments", "free_installments", "free installments must be between 1 and max_installments"},
+	{domain.ErrInvalidInterestRate, http.StatusUnprocessableEntity, "installment.invalid_monthly_interest", "monthly_interest", "monthly interest must be between 0 and 10 with at most 2 decimal places"},
+
+	// Plans and subscriptions
+	{domain.ErrPlanNotFound, http.StatusUnprocessableEntity, "subscription.unknown_plan", "plan_id", "plan does not exist for this account"},
+	{domain.ErrInvalidPlanName, http.StatusUnprocessableEntity, "plan.invalid_name", "name", "name must have between 3 and 100 characters"},
+	{domain.ErrInvalidInterval, http.StatusUnprocessableEntity, "plan.invalid_interval", "interval", "interval must be one of day, week, month, year"},
+	{domain.ErrInvalidIntervalCount, http.StatusUnprocessableEntity, "plan.invalid_interval_count", "interval_count", "interval count must be between 1 and 12"},
+	{domain.ErrInvalidTrialDays, http.StatusUnprocessableEntity, "plan.invalid_trial_days", "trial_days", "trial days must be between 0 and 365"},
+	{domain.ErrPlanRequired, http.StatusUnprocessableEntity, "subscription.plan_required", "plan_id", "plan ID is required"},
+	{domain.ErrInvalidCardToken, http.StatusUnprocessableEntity, "subscription.invalid_card_token", "card_token", "card token must have between 8 and 255 characters"},
+	{domain.ErrCardDigitsRequired, http.StatusUnprocessableEntity, "subscription.invalid_card_last_digits", "card_last_digits", "card last digits must be exactly 4 digits"},
+	{domain.ErrInvalidAnchor, http.StatusUnprocessableEntity, "subscription.invalid_anchor_date", "anchor_date", "anchor date must not be before today"},
+	{domain.ErrSubscriptionNotFound, http.StatusNotFound, "subscription.not_found", "", "subscription not found"},
+	{domain.ErrInvalidSubscriptionTransition, http.StatusConflict, "subscription.invalid_status_transition", "", "subscription status does not allow this operation"},
+
+	// Checkout sessions
+	{domain.ErrInvalidPaymentTypes, http.StatusUnprocessableEntity, "checkout.invalid_payment_types", "payment_types", "payment types must list 1 to 10 distinct payment types"},
+	{domain.ErrInvalidSuccessURL, http.StatusUnprocessableEntity, "checkout.invalid_success_url", "success_url", "success URL must be an absolute http(s) URL"},
+	{domain.ErrInvalidCancelURL, http.StatusUnprocessableEntity, "checkout.invalid_cancel_url", "cancel_url", "cancel URL must be an absolute http(s) URL"},
+	{domain.ErrInvalidExpiry, http.StatusUnprocessableEntity, "checkout.invalid_expires_at", "expires_at", "expiry must be in the future and at most 7 days away"},
+	{domain.ErrPaymentTypeNotAllowed, http.StatusUnprocessableEntity, "checkout.payment_type_not_allowed", "payment_type", "payment type is not accepted by this checkout"},
+	{domain.ErrCheckoutSessionNotFound, http.StatusNotFound, "checkout.not_found", "", "checkout session not found"},
+	{domain.ErrCheckoutCompleted, http.StatusConflict, "checkout.completed", "", "checkout session was already paid"},
+	{domain.ErrCheckoutExpired, http.StatusGone, "checkout.expired", "", "checkout session has expired"},
+
+	// Disputes
+	{domain.ErrDisputeRequiresApproval, http.StatusConflict, "dispute.invoice_not_approved", "", "only approved invoices can be disputed"},
+	{domain.ErrInvalidDisputeAmount, http.StatusUnprocessableEntity, "dispute.invalid_amount", "amount", "amount must be positive, not above the invoice total and have at most 2 decimal places"},
+	{domain.ErrInvalidDisputeReason, http.StatusUnprocessableEntity, "dispute.invalid_reason", "reason", "reason must be one of fraudulent, duplicate, product_not_received, product_unacceptable, unrecognized, general"},
+	{domain.ErrInvalidEvidence, http.StatusUnprocessableEntity, "dispute.invalid_evidence", "evidence", "evidence must have between 10 and 5000 characters"},
+	{domain.ErrInvalidDisputeOutcome, http.StatusUnprocessableEntity, "dispute.invalid_outcome", "outcome", "outcome must be one of won, lost"},
+	{domain.ErrEvidenceDeadlinePassed, http.StatusConflict, "dispute.evidence_deadline_passed", "", "the deadline to submit evidence has passed"},
+	{domain.ErrInvalidDisputeTransition, http.StatusConflict, "dispute.invalid_status_transition", "", "dispute status does not allow this operation"},
+	{domain.ErrDisputeExists, http.StatusConflict, "dispute.already_exists", "", "invoice already has a dispute"},
+	{domain.ErrDisputeNotFound, http.StatusNotFound, "dispute.not_found", "", "dispute not found"},
+
+	// Customers and saved cards
+	{domain.ErrInvalidCustomerName, http.StatusUnprocessableEntity, "customer.invalid_name", "name", "name must have between 2 and 100 characters"},
+	{domain.ErrInvalidCustomerEmail, http.StatusUnprocessableEntity, "customer.invalid_email", "email", "email must be a valid address"},
+	{domain.ErrInvalidDocument, http.StatusUnprocessableEntity, "customer.invalid_document", "document", "document must be a valid CPF"},
+	{domain.ErrInvalidSavedCardToken, http.StatusUnprocessableEntity, "customer.invalid_card_token", "card_token", "card_token must have between 8 and 255 characters"},
+	{domain.ErrInvalidSavedCardDigits, http.StatusUnprocessableEntity, "customer.invalid_card_last_digits", "card_last_digits", "card_last_digits must be exactly 4 digits"},
+	{domain.ErrCustomerExists, http.StatusConflict, "customer.already_exists", "", "a customer with this document already exists"},
+	{domain.ErrCustomerNotFound, http.StatusNotFound, "customer.not_found", "", "customer not found"},
+	{domain.ErrSavedCardNotFound, http.StatusNotFound, "customer.saved_card_not_found", "", "saved card not found"},
+	{domain.ErrCustomerRequired, http.StatusUnprocessableEntity, "invoice.customer_required", "customer_id", "customer_id is required to charge a saved card"},
+	{domain.ErrUnknownCustomer, http.StatusUnprocessableEntity, "invoice.unknown_customer", "customer_id", "customer does not exist for this account"},
+	{domain.ErrUnknownSavedCard, http.StatusUnprocessableEntity, "invoice.unknown_saved_card", "saved_card_id", "saved card does not exist for this customer"},
+	{domain.ErrSavedCardPaymentType, http.StatusUnprocessableEntity, "invoice.saved_card_payment_type", "payment_type", "saved cards can only be charged as credit_card"},
+	{domain.ErrSavedCardMismatch, http.StatusUnprocessableEntity, "invoice.saved_card_mismatch", "card_last_digits", "card_last_digits does not match the saved card"},
+
+	// Invoice line items, metadata and merchant reference
+	{domain.ErrInvalidItemSKU, http.StatusUnprocessableEntity, "invoice.invalid_item_sku", "items", "item sku must have between 1 and 64 characters"},
+	{domain.ErrInvalidItemQuantity, http.StatusUnprocessableEntity, "invoice.invalid_item_quantity", "items", "item quantity must be between 1 and 10000"},
+	{domain.ErrInvalidItemUnitPrice, http.StatusUnprocessableEntity, "invoice.invalid_item_unit_price", "items", "item unit price must be positive with at most 2 decimal places"},
+	{domain.ErrTooManyItems, http.StatusUnprocessableEntity, "invoice.too_many_items", "items", "at most 100 items are allowed"},
+	{domain.ErrItemsTotalMismatch, http.StatusUnprocessableEntity, "invoice.items_total_mismatch", "items", "items total does not match the amount"},
+	{domain.ErrInvalidMetadata, http.StatusUnprocessableEntity, "invoice.invalid_metadata", "metadata", "metadata allows up to 50 keys of at most 40 characters with values of at most 500 characters"},
+	{domain.ErrInvalidExternalRef, http.StatusUnprocessableEntity, "invoice.invalid_external_reference", "external_reference", "external reference must have between 1 and 100 characters"},
+	{domain.ErrExternalReferenceExists, http.StatusConflict, "invoice.external_reference_exists", "external_reference", "another invoice of the account already uses this external reference"},
+
+	// Idempotency keys and invoice batches
+	{domain.ErrInvalidIdempotencyKey, http.StatusUnprocessableEntity, "invoice.invalid_idempotency_key", "idempotency_key", "idempotency key must have between 1 and 255 characters"},
+	{domain.ErrIdempotencyKeyReused, http.StatusConflict, "invoice.idempotency_key_reused", "idempotency_key", "idempotency key was already used for a different invoice"},
+	{domain.ErrIdempotencyKeyInUse, http.StatusConflict, "invoice.idempotency_key_in_use", "idempotency_key", "a concurrent request is using this idempotency key; retry to get its invoice"},
+	{domain.ErrDuplicateIdempotencyKey, http.StatusUnprocessableEntity, "batch.duplicate_idempotency_key", "idempotency_key", "idempotency key is repeated in the batch"},
+	{domain.ErrEmptyBatch, http.StatusUnprocessableEntity, "batch.empty", "", "the batch must have at least one invoice"},
+	{domain.ErrBatchTooLarge, http.StatusRequestEntityTooLarge, "batch.too_large", "", "the batch has more invoices than allowed"},
+}
+
+// lookup finds the mapping of a sentinel error.
+func lookup(err error) (mapping, bool) {
+	for _, m := range mappings {
+		if errors.Is(err, m.err) {
+			return m, true
+		}
+	}
+	return mapping{}, false
+}
@@ -38,8 +38,11 @@ func TestInvoice_Create_WithoutAuth(t *testing.T) {
 	_ = json.NewDecoder(invoiceResp.Body).Decode(&errorResp)
 	invoiceResp.Body.Close()
 
-	if errorResp["error"] != "X-API-KEY header is required" {
-		t.Errorf("expected error message 'X-API-KEY header is required', got: %v", errorResp["error"])
+	if errorResp["code"] != "auth.missing_api_key" {
+		t.Errorf("expected error code 'auth.missing_api_key', got: %v", errorResp["code"])
+	}
+	if errorResp["detail"] != "X-API-KEY header is required" {
+		t.Errorf("expected error detail 'X-API-KEY header is required', got: %v", errorResp["detail"])
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -53,7 +56,7 @@ func TestInvoice_Create_WithInvalidAuth(t *testing.T) {
 	defer db.Close()
 
 	// Mock GetByAPIKey call for auth middleware (falha com API key inválida)
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, created_at, updated_at FROM accounts WHERE api_key = $1")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE api_key = $1")).
 		WithArgs("invalid-api-key").WillReturnError(domain.ErrAccountNotFound)
 
 	// Create invoice with invalid API key
@@ -75,8 +78,11 @@ func TestInvoice_Create_WithInvalidAuth(t *testing.T) {
 	_ = json.NewDecoder(invoiceResp.Body).Decode(&errorResp)
 	invoiceResp.Body.Close()
 
-	if errorResp["error"] != "Invalid API key" {
-		t.Errorf("expected error message 'Invalid API key', got: %v", errorResp["error"])
+	if errorResp["code"] != "auth.invalid_api_key" {
+		t.Errorf("expected error code 'auth.invalid_api_key', got: %v", errorResp["code"])
+	}
+	if errorResp["detail"] != "Invalid API key" {
+		t.Errorf("expected error detail 'Invalid API key', got: %v", errorResp["detail"])
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -107,8 +113,11 @@ func TestInvoice_Get_WithoutAuth(t *testing.T) {
 	_ = json.NewDecoder(invoiceResp.Body).Decode(&errorResp)
 	invoiceResp.Body.Close()
 
-	if errorResp["error"] != "X-API-KEY header is required" {
-		t.Errorf("expected error message 'X-API-KEY header is required', got: %v", errorResp["error"])
+	if errorResp["code"] != "auth.missing_api_key" {
+		t.Errorf("expected error code 'auth.missing_api_key', got: %v", errorResp["code"])
+	}
+	if errorResp["detail"] != "X-API-KEY header is required" {
+		t.Errorf("expected error detail 'X-API-KEY header is required', got: %v", errorResp["detail"])
 	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
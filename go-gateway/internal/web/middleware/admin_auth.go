@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+)
+
+// AdminAuth protects the admin API with a bearer token that is separate from
+// the merchant API keys. An empty token rejects every request, so the admin
+// API is closed unless a credential is configured. Authenticated requests act
+// for the platform, so the storage layer shows them every account.
+func AdminAuth(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || got == "" {
+				httperror.Write(w, r, httperror.ErrMissingAdminAuth)
+				return
+			}
+			if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				httperror.Write(w, r, httperror.ErrInvalidAdminAuth)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(tenant.WithPrivileged(r.Context())))
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+)
+
+func TestAdminAuth(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !tenant.Privileged(r.Context()) {
+			t.Error("expected admin requests to act with a privileged context")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		status int
+	}{
+		{"valid", "s3cret", "Bearer s3cret", http.StatusNoContent},
+		{"missing", "s3cret", "", http.StatusUnauthorized},
+		{"wrong scheme", "s3cret", "Basic s3cret", http.StatusUnauthorized},
+		{"wrong token", "s3cret", "Bearer nope", http.StatusUnauthorized},
+		{"not configured", "", "Bearer anything", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/accounts", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			AdminAuth(tt.token)(ok).ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
@@ -1,11 +1,13 @@
 package middleware
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
 	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
 )
 
 type AuthMiddleware struct {
@@ -22,28 +24,39 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		apiKey := r.Header.Get("X-API-KEY")
 		if apiKey == "" {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(map[string]string{"error": "X-API-KEY header is required"})
+			httperror.Write(w, r, httperror.ErrMissingAPIKey)
 			return
 		}
 
-		_, err := m.accountService.GetByAPIKey(r.Context(), apiKey)
+		account, err := m.accountService.GetByAPIKey(r.Context(), apiKey)
 		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-
-			if err == domain.ErrAccountNotFound {
-				w.WriteHeader(http.StatusUnauthorized)
-				json.NewEncoder(w).Encode(map[string]string{"error": "Invalid API key"})
+			if errors.Is(err, domain.ErrAccountNotFound) {
+				httperror.Write(w, r, httperror.ErrInvalidAPIKey)
 				return
 			}
 
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Internal server error"})
+			httperror.Write(w, r, err)
 			return
 		}
 
-		// Authentication successful, proceed to next handler
-		next.ServeHTTP(w, r)
+		// Suspended accounts keep read-only access
+		if !isReadOnly(r.Method) {
+			if err := domain.AccountStatus(account.Status).CanWrite(); err != nil {
+				httperror.Write(w, r, err)
+				return
+			}
+		}
+
+		// Authentication successful; the storage layer scopes rows to the account
+		next.ServeHTTP(w, r.WithContext(tenant.WithAccount(r.Context(), account.ID)))
 	})
 }
+
+// isReadOnly reports whether method is safe (RFC 9110, section 9.2.1).
+func isReadOnly(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
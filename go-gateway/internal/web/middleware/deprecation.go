@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Deprecation describes a deprecated set of routes.
+type Deprecation struct {
+	Since     time.Time // Deprecation header (RFC 9745)
+	Sunset    time.Time // Sunset header (RFC 8594), omitted when zero
+	Successor string    // path prefix of the replacement, e.g. /v1
+}
+
+// Deprecated advertises that the wrapped routes are deprecated and, when a
+// successor is set, links to the equivalent route under it.
+func Deprecated(d Deprecation) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+			if !d.Sunset.IsZero() {
+				h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if d.Successor != "" {
+				h.Add("Link", "<"+d.Successor+r.URL.Path+`>; rel="successor-version"`)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeprecated(t *testing.T) {
+	d := Deprecation{
+		Since:     time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+		Sunset:    time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC),
+		Successor: "/v1",
+	}
+	h := Deprecated(d)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/invoices/123", nil))
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 got %d", w.Code)
+	}
+	if got := w.Header().Get("Deprecation"); got != "@1792281600" {
+		t.Errorf("unexpected Deprecation header %q", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Fri, 30 Apr 2027 00:00:00 GMT" {
+		t.Errorf("unexpected Sunset header %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `</v1/invoices/123>; rel="successor-version"` {
+		t.Errorf("unexpected Link header %q", got)
+	}
+}
+
+func TestDeprecated_NoSunset(t *testing.T) {
+	h := Deprecated(Deprecation{Since: time.Unix(0, 0)})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/accounts", nil))
+
+	if w.Header().Get("Sunset") != "" || w.Header().Get("Link") != "" {
+		t.Fatalf("expected only Deprecation header, got %v", w.Header())
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+)
+
+// RequestID propagates the caller's X-Request-Id or generates one and sets it
+// on the response, where httperror picks it up for problem documents.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(httperror.RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.New().String()
+		}
+		w.Header().Set(httperror.RequestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
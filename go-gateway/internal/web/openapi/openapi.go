@@ -0,0 +1,214 @@
+// Package openapi builds the OpenAPI 3.1 document of the gateway from a route
+// table and the Go types the handlers encode, and validates JSON payloads
+// against it so tests can catch drift between the spec and the handlers.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI version of the generated document.
+const Version = "3.1.0"
+
+// Document is the subset of the OpenAPI 3.1 object model used by the gateway.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to operations.
+type PathItem map[string]*Operation
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is a JSON Schema (2020-12) as used by OpenAPI 3.1.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+}
+
+// Route describes one operation served by the router. Request and the
+// Responses values are sample Go values (e.g. service.AccountOutput{}) whose
+// types are reflected into schemas; a *Schema is used as-is.
+type Route struct {
+	Method     string
+	Path       string // chi pattern, e.g. /invoices/{id}
+	ID         string
+	Summary    string
+	Tag        string
+	Auth       bool     // merchant X-API-KEY
+	Admin      bool     // admin bearer token
+	Headers    []string // required request headers
+	Deprecated bool
+	Request    any
+	Responses  map[int]any
+}
+
+// problemContentType is the media type of every error response.
+const problemContentType = "application/problem+json"
+
+// Build generates the document for routes. problem is the Go value encoded
+// for errors; every operation documents it as the default response.
+func Build(info Info, routes []Route, problem any) *Document {
+	g := newGenerator()
+	problemSchema := g.schemaFor(problem)
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: g.components,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-KEY"},
+				"adminToken": {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	for _, rt := range routes {
+		op := &Operation{
+			OperationID: rt.ID,
+			Summary:     rt.Summary,
+			Deprecated:  rt.Deprecated,
+			Responses:   make(map[string]Response),
+		}
+		if rt.Tag != "" {
+			op.Tags = []string{rt.Tag}
+		}
+		if rt.Auth {
+			op.Security = []map[string][]string{{"apiKey": {}}}
+		}
+		if rt.Admin {
+			op.Security = []map[string][]string{{"adminToken": {}}}
+		}
+		for _, name := range pathParams(rt.Path) {
+			op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+		for _, name := range rt.Headers {
+			op.Parameters = append(op.Parameters, Parameter{Name: name, In: "header", Required: true, Schema: &Schema{Type: "string"}})
+		}
+		if rt.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: g.schemaFor(rt.Request)}},
+			}
+		}
+		for status, body := range rt.Responses {
+			resp := Response{Description: http.StatusText(status)}
+			if body != nil {
+				resp.Content = map[string]MediaType{"application/json": {Schema: g.schemaFor(body)}}
+			}
+			op.Responses[strconv.Itoa(status)] = resp
+		}
+		op.Responses["default"] = Response{
+			Description: "Error",
+			Content:     map[string]MediaType{problemContentType: {Schema: problemSchema}},
+		}
+
+		item, ok := doc.Paths[rt.Path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[rt.Path] = item
+		}
+		item[strings.ToLower(rt.Method)] = op
+	}
+	return doc
+}
+
+// Operations lists the documented "METHOD path" pairs, sorted.
+func (d *Document) Operations() []string {
+	var out []string
+	for path, item := range d.Paths {
+		for method := range item {
+			out = append(out, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Handler serves the document as JSON.
+func Handler(doc *Document) http.HandlerFunc {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		panic("openapi: marshal document: " + err.Error())
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}
+}
+
+// pathParams extracts the {name} segments of a chi pattern.
+func pathParams(path string) []string {
+	var names []string
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(seg, "{"), "}"))
+		}
+	}
+	return names
+}
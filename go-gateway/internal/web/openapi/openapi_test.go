@@ -0,0 +1,109 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type item struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status" openapi:"enum=open|closed"`
+	Note      string    `json:"note,omitempty"`
+	Secret    string    `json:"secret" openapi:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type problem struct {
+	Code string `json:"code"`
+}
+
+func testDoc() *Document {
+	return Build(Info{Title: "test", Version: "1"}, []Route{
+		{Method: http.MethodGet, Path: "/items/{id}", ID: "getItem", Auth: true, Responses: map[int]any{http.StatusOK: item{}}},
+		{Method: http.MethodGet, Path: "/items", ID: "listItems", Responses: map[int]any{http.StatusOK: []item{}}},
+		{Method: http.MethodPost, Path: "/items", ID: "createItem", Request: item{}, Responses: map[int]any{http.StatusNoContent: nil}},
+	}, problem{})
+}
+
+func TestBuild_ReflectsStructs(t *testing.T) {
+	doc := testDoc()
+
+	s, ok := doc.Components.Schemas["item"]
+	if !ok {
+		t.Fatalf("expected item component, got %v", doc.Components.Schemas)
+	}
+	if strings.Join(s.Required, ",") != "id,status,created_at" {
+		t.Errorf("unexpected required fields: %v", s.Required)
+	}
+	if _, ok := s.Properties["secret"]; ok {
+		t.Error("expected openapi:\"-\" field to be skipped")
+	}
+	if s.Properties["created_at"].Format != "date-time" {
+		t.Errorf("expected date-time format, got %+v", s.Properties["created_at"])
+	}
+	if len(s.Properties["status"].Enum) != 2 {
+		t.Errorf("expected enum, got %+v", s.Properties["status"])
+	}
+
+	op := doc.Paths["/items/{id}"]["get"]
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" || len(op.Security) != 1 {
+		t.Errorf("unexpected operation: %+v", op)
+	}
+	if _, ok := op.Responses["default"]; !ok {
+		t.Error("expected default problem response")
+	}
+
+	want := []string{"GET /items", "GET /items/{id}", "POST /items"}
+	if got := doc.Operations(); strings.Join(got, ";") != strings.Join(want, ";") {
+		t.Errorf("expected %v got %v", want, got)
+	}
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	doc := testDoc()
+	const ok = `{"id":"1","status":"open","created_at":"2024-01-02T03:04:05Z"}`
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		status      int
+		contentType string
+		body        string
+		wantErr     string
+	}{
+		{"valid", "GET", "/items/1", 200, "application/json", ok, ""},
+		{"valid list", "GET", "/items", 200, "application/json", "[" + ok + "]", ""},
+		{"null list", "GET", "/items", 200, "application/json", "null", "expected array"},
+		{"missing field", "GET", "/items/1", 200, "application/json", `{"id":"1","created_at":"2024-01-02T03:04:05Z"}`, `missing required property "status"`},
+		{"extra field", "GET", "/items/1", 200, "application/json", `{"id":"1","status":"open","created_at":"2024-01-02T03:04:05Z","x":1}`, `undocumented property "x"`},
+		{"wrong type", "GET", "/items/1", 200, "application/json", `{"id":1,"status":"open","created_at":"2024-01-02T03:04:05Z"}`, "expected string"},
+		{"bad enum", "GET", "/items/1", 200, "application/json", `{"id":"1","status":"gone","created_at":"2024-01-02T03:04:05Z"}`, "is not one of"},
+		{"undocumented status", "GET", "/items/1", 202, "application/json", ok, "status 202 is not documented"},
+		{"problem", "GET", "/items/1", 404, "application/problem+json", `{"code":"item.not_found"}`, ""},
+		{"problem wrong content type", "GET", "/items/1", 404, "application/json", `{"code":"item.not_found"}`, "content type"},
+		{"no content", "POST", "/items", 204, "", "", ""},
+		{"undocumented route", "DELETE", "/items/1", 204, "", "", "is not documented"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := doc.ValidateResponse(tt.method, tt.path, tt.status, tt.contentType, []byte(tt.body))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// generator reflects Go types into schemas, registering named structs as
+// components so they are referenced instead of inlined.
+type generator struct {
+	components map[string]*Schema
+}
+
+func newGenerator() *generator {
+	return &generator{components: make(map[string]*Schema)}
+}
+
+func (g *generator) schemaFor(v any) *Schema {
+	if s, ok := v.(*Schema); ok {
+		return s
+	}
+	return g.schemaOf(reflect.TypeOf(v))
+}
+
+func (g *generator) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	}
+	// interface{} and anything else accepts any JSON value
+	return &Schema{}
+}
+
+// structRef registers t as a component and returns a reference to it.
+func (g *generator) structRef(t reflect.Type) *Schema {
+	name := t.Name()
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if _, ok := g.components[name]; ok {
+		return ref
+	}
+	// Register before walking the fields so recursive types terminate
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: false}
+	g.components[name] = s
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, omitempty, skip := jsonName(f)
+		if skip || f.Tag.Get("openapi") == "-" {
+			continue
+		}
+		fs := g.schemaOf(f.Type)
+		applyTag(fs, f.Tag.Get("openapi"))
+		s.Properties[name] = fs
+		if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return ref
+}
+
+// jsonName returns the encoded field name as encoding/json would.
+func jsonName(f reflect.StructField) (name string, omitempty, skip bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	for _, o := range strings.Split(opts, ",") {
+		if o == "omitempty" || o == "omitzero" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
+
+// applyTag applies `openapi:"format=email,enum=a|b"` options to s.
+func applyTag(s *Schema, tag string) {
+	for _, opt := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "format":
+			s.Format = value
+		case "enum":
+			s.Enum = strings.Split(value, "|")
+		}
+	}
+}
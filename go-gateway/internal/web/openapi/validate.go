@@ -0,0 +1,194 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateResponse checks that body is a documented response of the
+// operation matching method and a concrete request path.
+func (d *Document) ValidateResponse(method, path string, status int, contentType string, body []byte) error {
+	op, pattern := d.find(method, path)
+	if op == nil {
+		return fmt.Errorf("openapi: %s %s is not documented", method, path)
+	}
+
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if status < 400 {
+			return fmt.Errorf("openapi: %s %s: status %d is not documented", method, pattern, status)
+		}
+		resp = op.Responses["default"]
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	if len(resp.Content) == 0 {
+		if len(body) > 0 {
+			return fmt.Errorf("openapi: %s %s: %d: unexpected body", method, pattern, status)
+		}
+		return nil
+	}
+	mt, ok := resp.Content[strings.TrimSpace(mediaType)]
+	if !ok {
+		return fmt.Errorf("openapi: %s %s: %d: content type %q is not documented", method, pattern, status, contentType)
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Errorf("openapi: %s %s: %d: %w", method, pattern, status, err)
+	}
+	if err := d.validate(mt.Schema, v, "$"); err != nil {
+		return fmt.Errorf("openapi: %s %s: %d: %w", method, pattern, status, err)
+	}
+	return nil
+}
+
+// find resolves a concrete path (/invoices/abc) to its operation.
+func (d *Document) find(method, path string) (*Operation, string) {
+	path, _, _ = strings.Cut(path, "?")
+	for pattern, item := range d.Paths {
+		if op, ok := item[strings.ToLower(method)]; ok && matchPath(pattern, path) {
+			return op, pattern
+		}
+	}
+	return nil, ""
+}
+
+func matchPath(pattern, path string) bool {
+	ps := strings.Split(strings.Trim(pattern, "/"), "/")
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(ps) != len(segs) {
+		return false
+	}
+	for i := range ps {
+		if strings.HasPrefix(ps[i], "{") {
+			if segs[i] == "" {
+				return false
+			}
+			continue
+		}
+		if ps[i] != segs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Document) resolve(s *Schema) (*Schema, error) {
+	if s.Ref == "" {
+		return s, nil
+	}
+	name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+	target, ok := d.Components.Schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %s", s.Ref)
+	}
+	return target, nil
+}
+
+// validate checks v (decoded with encoding/json) against s.
+func (d *Document) validate(s *Schema, v any, at string) error {
+	s, err := d.resolve(s)
+	if err != nil {
+		return err
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string, got %T", at, v)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return fmt.Errorf("%s: invalid date-time %q", at, str)
+			}
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			return fmt.Errorf("%s: %q is not one of %v", at, str, s.Enum)
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return fmt.Errorf("%s: expected number, got %T", at, v)
+		}
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%s: expected integer, got %v", at, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", at, v)
+		}
+	case "array":
+		items, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", at, v)
+		}
+		for i, item := range items {
+			if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", at, v)
+		}
+		return d.validateObject(s, obj, at)
+	default:
+		return fmt.Errorf("%s: unsupported schema type %q", at, s.Type)
+	}
+	return nil
+}
+
+func (d *Document) validateObject(s *Schema, obj map[string]any, at string) error {
+	var errs []error
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s: missing required property %q", at, name))
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		prop, ok := s.Properties[k]
+		if !ok {
+			switch extra := s.AdditionalProperties.(type) {
+			case *Schema:
+				prop = extra
+			case bool:
+				if !extra {
+					errs = append(errs, fmt.Errorf("%s: undocumented property %q", at, k))
+				}
+				continue
+			default:
+				continue
+			}
+		}
+		if err := d.validate(prop, obj[k], at+"."+k); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package web
+
+import (
+	"time"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/handlers"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/middleware"
+)
+
+// Option customizes the dependencies wired by ConfigureRoutes and NewServer.
+type Option func(*options)
+
+type options struct {
+	checks     []handlers.HealthCheck
+	processor  domain.InvoiceProcessor
+	legacy     middleware.Deprecation
+	adminToken string
+	defaultFee domain.FeePlan
+
+	payoutRail     domain.BankRail
+	payoutPolicy   domain.PayoutPolicy
+	payoutInterval time.Duration
+
+	settlementSchedule domain.SettlementSchedule
+	settlementInterval time.Duration
+
+	risk         *service.RiskService
+	riskInterval time.Duration
+
+	dunning              domain.DunningPolicy
+	subscriptionInterval time.Duration
+
+	disputeInterval time.Duration
+
+	batchMaxItems int
+	batchWorkers  int
+
+	clock domain.Clock
+}
+
+// Default deprecation schedule of the unversioned legacy routes.
+var (
+	DefaultLegacyDeprecatedAt = time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	DefaultLegacySunset       = time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC)
+)
+
+func newOptions(opts []Option) options {
+	o := options{
+		legacy: middleware.Deprecation{
+			Since:     DefaultLegacyDeprecatedAt,
+			Sunset:    DefaultLegacySunset,
+			Successor: "/v1",
+		},
+		payoutPolicy:  domain.DefaultPayoutPolicy(),
+		dunning:       domain.DefaultDunningPolicy(),
+		batchMaxItems: domain.DefaultBatchMaxItems,
+		batchWorkers:  domain.DefaultBatchWorkers,
+		clock:         domain.SystemClock,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithHealthCheck adds a readiness check (e.g. the message broker).
+func WithHealthCheck(c handlers.HealthCheck) Option {
+	return func(o *options) { o.checks = append(o.checks, c) }
+}
+
+// WithInvoiceProcessor replaces the processor used for new invoices.
+func WithInvoiceProcessor(p domain.InvoiceProcessor) Option {
+	return func(o *options) { o.processor = p }
+}
+
+// WithLegacySunset sets when the unversioned routes were deprecated and when
+// they will be removed. A zero sunset omits the Sunset header.
+func WithLegacySunset(deprecatedAt, sunset time.Time) Option {
+	return func(o *options) {
+		o.legacy.Since = deprecatedAt
+		o.legacy.Sunset = sunset
+	}
+}
+
+// WithAdminToken sets the bearer token of the admin API. Without it every
+// admin request is rejected.
+func WithAdminToken(token string) Option {
+	return func(o *options) { o.adminToken = token }
+}
+
+// WithPayoutRail replaces the simulated bank rail used to send payouts.
+func WithPayoutRail(rail domain.BankRail) Option {
+	return func(o *options) { o.payoutRail = rail }
+}
+
+// WithPayoutPolicy sets the minimum amount and reserve of payouts.
+func WithPayoutPolicy(p domain.PayoutPolicy) Option {
+	return func(o *options) { o.payoutPolicy = p }
+}
+
+// WithPayoutProcessing makes NewServer run a worker that sends pending
+// payouts to the bank rail every interval.
+func WithPayoutProcessing(interval time.Duration) Option {
+	return func(o *options) { o.payoutInterval = interval }
+}
+
+// WithDefaultFeePlan sets the fee charged on approved invoices of accounts
+// without a plan for the payment type. Without it no fee is charged.
+func WithDefaultFeePlan(percent, fixed float64) Option {
+	return func(o *options) { o.defaultFee = domain.FeePlan{Percent: percent, Fixed: fixed} }
+}
+
+// WithSettlementSchedule sets the settlement delay (D+N) of each payment
+// type. Without it, approved money is available immediately.
+func WithSettlementSchedule(schedule domain.SettlementSchedule) Option {
+	return func(o *options) { o.settlementSchedule = schedule }
+}
+
+// WithSettlementRelease makes NewServer run a worker that releases due
+// settlements to the available balance every interval.
+func WithSettlementRelease(interval time.Duration) Option {
+	return func(o *options) { o.settlementInterval = interval }
+}
+
+// WithRiskRules replaces the risk rules evaluated before processing new
+// invoices and makes NewServer run a worker that reloads them from their
+// source every interval; zero keeps the rules loaded by the caller.
+func WithRiskRules(risk *service.RiskService, reloadInterval time.Duration) Option {
+	return func(o *options) {
+		o.risk = risk
+		o.riskInterval = reloadInterval
+	}
+}
+
+// WithSubscriptionBilling makes NewServer run a worker that charges due
+// subscriptions every interval, retrying failed charges per the dunning
+// policy.
+func WithSubscriptionBilling(interval time.Duration, dunning domain.DunningPolicy) Option {
+	return func(o *options) {
+		o.subscriptionInterval = interval
+		o.dunning = dunning
+	}
+}
+
+// WithDisputeExpiry makes NewServer run a worker that marks disputes left
+// without evidence past their deadline as lost every interval.
+func WithDisputeExpiry(interval time.Duration) Option {
+	return func(o *options) { o.disputeInterval = interval }
+}
+
+// WithInvoiceBatch sets how many invoices POST /invoices/batch accepts and
+// how many of them are created at a time.
+func WithInvoiceBatch(maxItems, workers int) Option {
+	return func(o *options) {
+		o.batchMaxItems = maxItems
+		o.batchWorkers = workers
+	}
+}
+
+// WithClock replaces the clock of the services, e.g. with a domain.FakeClock
+// to drive settlement and billing in tests.
+func WithClock(c domain.Clock) Option {
+	return func(o *options) { o.clock = c }
+}
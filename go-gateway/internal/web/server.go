@@ -3,21 +3,96 @@ package web
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/devfullcycle/imersao22/go-gateway/internal/domain"
 	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/tenant"
 	"github.com/devfullcycle/imersao22/go-gateway/internal/web/handlers"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
 	"github.com/devfullcycle/imersao22/go-gateway/internal/web/middleware"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/openapi"
 	"github.com/go-chi/chi/v5"
 )
 
 // ConfigureRoutes wires HTTP routes using chi mux and provided dependencies.
-func ConfigureRoutes(db *sql.DB) http.Handler {
+func ConfigureRoutes(db *sql.DB, opts ...Option) http.Handler {
+	o := newOptions(opts)
+	return configureRoutes(db, newHealthHandler(db, o.checks), o)
+}
+
+// newHealthHandler builds the health handler with the checks every deployment
+// needs plus any extra ones.
+func newHealthHandler(db *sql.DB, extra []handlers.HealthCheck) *handlers.HealthHandler {
+	h := handlers.NewHealthHandler(handlers.DatabaseCheck(db), handlers.MigrationCheck(db))
+	for _, c := range extra {
+		h.AddCheck(c)
+	}
+	return h
+}
+
+// newPayoutService builds the payout service with the configured rail and
+// policy. Without a rail, payouts go through the simulated one.
+func newPayoutService(db *sql.DB, o options) *service.PayoutService {
+	rail := o.payoutRail
+	if rail == nil {
+		rail = domain.NewSimulatedBankRail()
+	}
+	payoutSvc := service.NewPayoutService(db, rail, o.payoutPolicy)
+	payoutSvc.SetClock(o.clock)
+	return payoutSvc
+}
+
+// newInvoiceService builds the invoice service with the configured
+// processor, fees, settlement schedule and risk rules.
+func newInvoiceService(db *sql.DB, o options) *service.InvoiceService {
+	invoiceSvc := service.NewInvoiceService(db)
+	invoiceSvc.SetClock(o.clock)
+	if o.processor != nil {
+		invoiceSvc.SetProcessor(o.processor)
+	}
+	invoiceSvc.SetDefaultFeePlan(o.defaultFee)
+	invoiceSvc.SetSettlementSchedule(o.settlementSchedule)
+	invoiceSvc.SetBatchLimits(o.batchMaxItems, o.batchWorkers)
+	if o.risk != nil {
+		invoiceSvc.SetRiskService(o.risk)
+	}
+	return invoiceSvc
+}
+
+// newSubscriptionService builds the subscription service. Cycles are charged
+// through an invoice service configured like the one of the API.
+func newSubscriptionService(db *sql.DB, o options) *service.SubscriptionService {
+	subscriptionSvc := service.NewSubscriptionService(db, newInvoiceService(db, o), o.dunning)
+	subscriptionSvc.SetClock(o.clock)
+	return subscriptionSvc
+}
+
+// newCheckoutService builds the checkout service. Payments are charged
+// through an invoice service configured like the one of the API.
+func newCheckoutService(db *sql.DB, o options) *service.CheckoutService {
+	checkoutSvc := service.NewCheckoutService(db, newInvoiceService(db, o))
+	checkoutSvc.SetClock(o.clock)
+	return checkoutSvc
+}
+
+func configureRoutes(db *sql.DB, healthH *handlers.HealthHandler, o options) http.Handler {
 	r := chi.NewRouter()
 
 	// Services
 	accountSvc := service.NewAccountService(db)
-	invoiceSvc := service.NewInvoiceService(db)
+	accountSvc.SetClock(o.clock)
+	invoiceSvc := newInvoiceService(db, o)
+	payoutSvc := newPayoutService(db, o)
+	subscriptionSvc := newSubscriptionService(db, o)
+	checkoutSvc := newCheckoutService(db, o)
+	disputeSvc := service.NewDisputeService(db)
+	disputeSvc.SetClock(o.clock)
+	customerSvc := service.NewCustomerService(db)
+	customerSvc.SetClock(o.clock)
 
 	// Middleware
 	authMiddleware := middleware.NewAuthMiddleware(accountSvc)
@@ -25,54 +100,374 @@ func ConfigureRoutes(db *sql.DB) http.Handler {
 	// Handlers
 	accountH := handlers.NewAccountHandler(accountSvc)
 	invoiceH := handlers.NewInvoiceHandler(invoiceSvc)
+	payoutH := handlers.NewPayoutHandler(payoutSvc)
+	subscriptionH := handlers.NewSubscriptionHandler(subscriptionSvc)
+	checkoutH := handlers.NewCheckoutHandler(checkoutSvc)
+	installmentH := handlers.NewInstallmentHandler(invoiceSvc)
+	disputeH := handlers.NewDisputeHandler(disputeSvc)
+	customerH := handlers.NewCustomerHandler(customerSvc)
+	v2H := handlers.NewV2Handler(accountSvc, invoiceSvc, payoutSvc, subscriptionSvc, checkoutSvc, disputeSvc, customerSvc)
+	adminSvc := service.NewAdminService(db)
+	adminSvc.SetClock(o.clock)
+	adminH := handlers.NewAdminHandler(adminSvc)
 
-	// Routes
-	r.Route("/accounts", func(r chi.Router) {
-		r.Post("/", accountH.PostAccounts()) // POST /accounts
-		r.Get("/", accountH.GetAccounts())   // GET /accounts
+	// v1 keeps the original wire format (money as decimal numbers)
+	v1 := func(r chi.Router) {
+		r.Route("/accounts", func(r chi.Router) {
+			r.Post("/", accountH.PostAccounts()) // POST /accounts
+			r.Get("/", accountH.GetAccounts())   // GET /accounts
+		})
+
+		// Rotas de invoice COM autenticação
+		r.Route("/invoices", func(r chi.Router) {
+			// Aplicar auth middleware apenas nas rotas de invoice
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", invoiceH.PostInvoices())           // POST /invoices
+			r.Post("/batch", invoiceH.PostInvoicesBatch()) // POST /invoices/batch
+			r.Get("/", invoiceH.GetInvoices())             // GET /invoices
+			r.Get("/{id}", invoiceH.GetInvoiceByID())      // GET /invoices/{id}
+		})
+		r.Route("/installment-rules", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Get("/", installmentH.GetInstallmentRules()) // GET /installment-rules
+			r.Put("/", installmentH.PutInstallmentRules()) // PUT /installment-rules
+		})
+
+		r.Route("/bank-accounts", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", payoutH.PostBankAccounts()) // POST /bank-accounts
+			r.Get("/", payoutH.GetBankAccounts())   // GET /bank-accounts
+		})
+		r.Route("/payouts", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", payoutH.PostPayouts())      // POST /payouts
+			r.Get("/", payoutH.GetPayouts())        // GET /payouts
+			r.Get("/{id}", payoutH.GetPayoutByID()) // GET /payouts/{id}
+		})
+
+		r.Route("/plans", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", subscriptionH.PostPlans()) // POST /plans
+			r.Get("/", subscriptionH.GetPlans())   // GET /plans
+		})
+		r.Route("/subscriptions", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", subscriptionH.PostSubscriptions())             // POST /subscriptions
+			r.Get("/", subscriptionH.GetSubscriptions())               // GET /subscriptions
+			r.Get("/{id}", subscriptionH.GetSubscriptionByID())        // GET /subscriptions/{id}
+			r.Post("/{id}/cancel", subscriptionH.CancelSubscription()) // POST /subscriptions/{id}/cancel
+		})
+
+		r.Route("/checkout-sessions", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", checkoutH.PostCheckoutSessions())      // POST /checkout-sessions
+			r.Get("/{id}", checkoutH.GetCheckoutSessionByID()) // GET /checkout-sessions/{id}
+		})
+		// Hosted checkout: public, the session ID is the credential
+		r.Route("/checkout", func(r chi.Router) {
+			r.Get("/{id}", checkoutH.GetCheckout())      // GET /checkout/{id}
+			r.Post("/{id}/pay", checkoutH.PayCheckout()) // POST /checkout/{id}/pay
+		})
+
+		r.Route("/disputes", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Get("/", disputeH.GetDisputes())                // GET /disputes
+			r.Get("/{id}", disputeH.GetDisputeByID())         // GET /disputes/{id}
+			r.Post("/{id}/evidence", disputeH.PostEvidence()) // POST /disputes/{id}/evidence
+		})
+
+		r.Route("/customers", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", customerH.PostCustomers())                    // POST /customers
+			r.Get("/", customerH.GetCustomers())                      // GET /customers
+			r.Get("/{id}", customerH.GetCustomerByID())               // GET /customers/{id}
+			r.Get("/{id}/invoices", customerH.GetCustomerInvoices())  // GET /customers/{id}/invoices
+			r.Post("/{id}/cards", customerH.PostCards())              // POST /customers/{id}/cards
+			r.Get("/{id}/cards", customerH.GetCards())                // GET /customers/{id}/cards
+			r.Delete("/{id}/cards/{card_id}", customerH.DeleteCard()) // DELETE /customers/{id}/cards/{card_id}
+		})
+	}
+
+	// v2 exchanges money as integer cents
+	v2 := func(r chi.Router) {
+		r.Route("/accounts", func(r chi.Router) {
+			r.Post("/", v2H.PostAccounts())
+			r.Get("/", v2H.GetAccounts())
+		})
+		r.Route("/invoices", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", v2H.PostInvoices())
+			r.Post("/batch", v2H.PostInvoicesBatch())
+			r.Get("/", v2H.GetInvoices())
+			r.Get("/{id}", v2H.GetInvoiceByID())
+		})
+		r.Route("/installment-rules", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Get("/", installmentH.GetInstallmentRules())
+			r.Put("/", installmentH.PutInstallmentRules())
+		})
+		r.Route("/bank-accounts", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", payoutH.PostBankAccounts())
+			r.Get("/", payoutH.GetBankAccounts())
+		})
+		r.Route("/payouts", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", v2H.PostPayouts())
+			r.Get("/", v2H.GetPayouts())
+			r.Get("/{id}", v2H.GetPayoutByID())
+		})
+		r.Route("/plans", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", v2H.PostPlans())
+			r.Get("/", v2H.GetPlans())
+		})
+		r.Route("/subscriptions", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", v2H.PostSubscriptions())
+			r.Get("/", v2H.GetSubscriptions())
+			r.Get("/{id}", v2H.GetSubscriptionByID())
+			r.Post("/{id}/cancel", v2H.CancelSubscription())
+		})
+		r.Route("/checkout-sessions", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", v2H.PostCheckoutSessions())
+			r.Get("/{id}", v2H.GetCheckoutSessionByID())
+		})
+		r.Route("/checkout", func(r chi.Router) {
+			r.Get("/{id}", v2H.GetCheckout())
+			r.Post("/{id}/pay", checkoutH.PayCheckout())
+		})
+		r.Route("/disputes", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Get("/", v2H.GetDisputes())
+			r.Get("/{id}", v2H.GetDisputeByID())
+			r.Post("/{id}/evidence", v2H.PostDisputeEvidence())
+		})
+		r.Route("/customers", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+
+			r.Post("/", customerH.PostCustomers())
+			r.Get("/", customerH.GetCustomers())
+			r.Get("/{id}", customerH.GetCustomerByID())
+			r.Get("/{id}/invoices", v2H.GetCustomerInvoices())
+			r.Post("/{id}/cards", customerH.PostCards())
+			r.Get("/{id}/cards", customerH.GetCards())
+			r.Delete("/{id}/cards/{card_id}", customerH.DeleteCard())
+		})
+	}
+
+	r.Use(middleware.RequestID)
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		httperror.Write(w, r, httperror.ErrRouteNotFound)
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		httperror.Write(w, r, httperror.ErrMethodNotAllowed)
 	})
 
-	// Rotas de invoice COM autenticação
-	r.Route("/invoices", func(r chi.Router) {
-		// Aplicar auth middleware apenas nas rotas de invoice
-		r.Use(authMiddleware.Authenticate)
+	// Routes
+	r.Get("/healthz", healthH.Healthz())               // GET /healthz
+	r.Get("/readyz", healthH.Readyz())                 // GET /readyz
+	r.Get("/openapi.json", openapi.Handler(APISpec())) // GET /openapi.json
+
+	// New versions are mounted side by side; see apiVersions in spec.go
+	r.Route("/v1", v1)
+	r.Route("/v2", v2)
+
+	// Operations API, cross-tenant, behind its own credential
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(middleware.AdminAuth(o.adminToken))
+
+		r.Route("/accounts", func(r chi.Router) {
+			r.Get("/", adminH.ListAccounts())                            // GET /admin/accounts
+			r.Get("/{id}", adminH.GetAccount())                          // GET /admin/accounts/{id}
+			r.Post("/{id}/suspend", adminH.SuspendAccount())             // POST /admin/accounts/{id}/suspend
+			r.Post("/{id}/reactivate", adminH.ReactivateAccount())       // POST /admin/accounts/{id}/reactivate
+			r.Post("/{id}/close", adminH.CloseAccount())                 // POST /admin/accounts/{id}/close
+			r.Post("/{id}/balance-adjustments", adminH.PostAdjustment()) // POST /admin/accounts/{id}/balance-adjustments
+			r.Get("/{id}/balance-adjustments", adminH.ListAdjustments()) // GET /admin/accounts/{id}/balance-adjustments
+			r.Get("/{id}/fee-plans", adminH.ListFeePlans())              // GET /admin/accounts/{id}/fee-plans
+			r.Put("/{id}/fee-plans/{payment_type}", adminH.PutFeePlan()) // PUT /admin/accounts/{id}/fee-plans/{payment_type}
+		})
+		// Card network simulator: disputes are opened and resolved here
+		r.Post("/invoices/{id}/disputes", disputeH.OpenDispute())   // POST /admin/invoices/{id}/disputes
+		r.Post("/disputes/{id}/resolve", disputeH.ResolveDispute()) // POST /admin/disputes/{id}/resolve
+		r.Get("/revenue", adminH.Revenue())                         // GET /admin/revenue
+	})
 
-		r.Post("/", invoiceH.PostInvoices())      // POST /invoices
-		r.Get("/", invoiceH.GetInvoices())        // GET /invoices
-		r.Get("/{id}", invoiceH.GetInvoiceByID()) // GET /invoices/{id}
+	// Unversioned routes behave like v1 until their sunset
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Deprecated(o.legacy))
+		v1(r)
 	})
 
 	return r
 }
 
+// ServerConfig holds the HTTP server timeouts and request limits.
+type ServerConfig struct {
+	Port              string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxBodyBytes      int64
+	// ShutdownDelay is how long Stop keeps serving after readiness starts
+	// failing, so load balancers stop routing before the listener closes.
+	ShutdownDelay time.Duration
+}
+
+// DefaultServerConfig returns conservative defaults for the given port.
+func DefaultServerConfig(port string) ServerConfig {
+	return ServerConfig{
+		Port:              port,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+		MaxBodyBytes:      1 << 20, // 1 MiB
+	}
+}
+
+// Worker is a background task that runs until its context is canceled. Its
+// context is privileged: jobs act for the platform, not for one account.
+type Worker func(ctx context.Context)
+
 // Server wraps the HTTP server and router configuration.
 type Server struct {
-	port   string
-	router http.Handler
-	server *http.Server
+	server  *http.Server
+	health  *handlers.HealthHandler
+	workers []Worker
+	delay   time.Duration // between failing readiness and closing the listener
+
+	mu      sync.Mutex
+	stopped bool
+	ctx     context.Context // canceled by Stop to drain workers
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
 }
 
-// NewServer builds a Server with routes configured using the provided DB and port.
-func NewServer(db *sql.DB, port string) *Server {
-	return &Server{
-		port:   port,
-		router: ConfigureRoutes(db),
+// NewServer builds a Server with routes configured using the provided DB, config
+// and options.
+func NewServer(db *sql.DB, cfg ServerConfig, opts ...Option) *Server {
+	o := newOptions(opts)
+	health := newHealthHandler(db, o.checks)
+
+	var router http.Handler = configureRoutes(db, health, o)
+	if cfg.MaxBodyBytes > 0 {
+		router = middleware.MaxBodySize(cfg.MaxBodyBytes)(router)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &Server{
+		ctx:    ctx,
+		cancel: cancel,
+		server: &http.Server{
+			Addr:              ":" + cfg.Port,
+			Handler:           router,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		health: health,
+		delay:  cfg.ShutdownDelay,
+	}
+	if o.payoutInterval > 0 {
+		payouts := newPayoutService(db, o)
+		srv.AddWorker(func(ctx context.Context) { payouts.Run(ctx, o.payoutInterval) })
+	}
+	if o.settlementInterval > 0 {
+		settlements := service.NewSettlementService(db)
+		settlements.SetClock(o.clock)
+		srv.AddWorker(func(ctx context.Context) { settlements.Run(ctx, o.settlementInterval) })
+	}
+	if o.subscriptionInterval > 0 {
+		subscriptions := newSubscriptionService(db, o)
+		srv.AddWorker(func(ctx context.Context) { subscriptions.Run(ctx, o.subscriptionInterval) })
 	}
+	if o.disputeInterval > 0 {
+		disputes := service.NewDisputeService(db)
+		disputes.SetClock(o.clock)
+		srv.AddWorker(func(ctx context.Context) { disputes.Run(ctx, o.disputeInterval) })
+	}
+	if o.risk != nil && o.riskInterval > 0 {
+		srv.AddWorker(func(ctx context.Context) { o.risk.Run(ctx, o.riskInterval) })
+	}
+	return srv
 }
 
-// Start starts the HTTP server and blocks until it exits.
+// AddWorker registers a background worker started by Start and drained by Stop.
+func (s *Server) AddWorker(w Worker) {
+	s.workers = append(s.workers, w)
+}
+
+// Start starts the background workers and the HTTP server and blocks until
+// the server exits. It returns nil after a graceful Stop.
 func (s *Server) Start() error {
-	s.server = &http.Server{
-		Addr:    ":" + s.port,
-		Handler: s.router,
+	s.mu.Lock()
+	if !s.stopped {
+		for _, w := range s.workers {
+			s.wg.Add(1)
+			go func(w Worker) {
+				defer s.wg.Done()
+				w(tenant.WithPrivileged(s.ctx))
+			}(w)
+		}
 	}
-	return s.server.ListenAndServe()
+	s.mu.Unlock()
+
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
-// Stop gracefully shuts down the server.
+// Stop gracefully shuts down the server. Readiness reports not-ready from
+// this point on; new requests are still served for the shutdown delay, then
+// in-flight requests are drained and background workers are canceled and
+// awaited until ctx expires.
 func (s *Server) Stop(ctx context.Context) error {
-	if s.server == nil {
-		return nil
+	s.health.SetShuttingDown()
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+	}
+	err := s.server.Shutdown(ctx)
+
+	s.mu.Lock()
+	s.stopped = true
+	s.cancel()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if err == nil {
+			err = ctx.Err()
+		}
 	}
-	return s.server.Shutdown(ctx)
+	return err
 }
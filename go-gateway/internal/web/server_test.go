@@ -0,0 +1,150 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func freePort(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	return port
+}
+
+func waitForServer(t *testing.T, url string) {
+	t.Helper()
+	for i := 0; i < 50; i++ {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("server did not start")
+}
+
+func TestServer_StopDrainsWorkersAndFlipsReadiness(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	cfg := DefaultServerConfig(freePort(t))
+	srv := NewServer(db, cfg)
+
+	workerDone := make(chan struct{})
+	srv.AddWorker(func(ctx context.Context) {
+		<-ctx.Done()
+		close(workerDone)
+	})
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Start() }()
+
+	base := "http://127.0.0.1:" + cfg.Port
+	waitForServer(t, base+"/healthz")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := srv.Stop(ctx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("start returned error after graceful stop: %v", err)
+	}
+
+	select {
+	case <-workerDone:
+	default:
+		t.Fatalf("expected worker to be drained")
+	}
+
+	rec := httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 after stop got %d", rec.Code)
+	}
+}
+
+func TestServer_StopServesNotReadyDuringDelay(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	cfg := DefaultServerConfig(freePort(t))
+	cfg.ShutdownDelay = 300 * time.Millisecond
+	srv := NewServer(db, cfg)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Start() }()
+
+	base := "http://127.0.0.1:" + cfg.Port
+	waitForServer(t, base+"/healthz")
+
+	stopped := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		stopped <- srv.Stop(ctx)
+	}()
+
+	// The listener stays open while readiness fails
+	time.Sleep(100 * time.Millisecond)
+	resp, err := http.Get(base + "/readyz")
+	if err != nil {
+		t.Fatalf("expected the server to keep serving during the delay: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 during the delay got %d", resp.StatusCode)
+	}
+
+	if err := <-stopped; err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("start returned error after graceful stop: %v", err)
+	}
+	if _, err := http.Get(base + "/healthz"); err == nil {
+		t.Fatalf("expected the listener to be closed after the delay")
+	}
+}
+
+func TestServer_MaxBodySize(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	cfg := DefaultServerConfig("0")
+	cfg.MaxBodyBytes = 16
+	srv := NewServer(db, cfg)
+
+	body := `{"name":"` + strings.Repeat("a", 64) + `","email":"a@b.com"}`
+	rec := httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/accounts", bytes.NewBufferString(body)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 got %d", rec.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,512 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/devfullcycle/imersao22/go-gateway/internal/service"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/handlers"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/httperror"
+	"github.com/devfullcycle/imersao22/go-gateway/internal/web/openapi"
+)
+
+// healthSchema documents the body of /healthz and /readyz.
+var healthSchema = &openapi.Schema{
+	Type:     "object",
+	Required: []string{"status"},
+	Properties: map[string]*openapi.Schema{
+		"status": {Type: "string", Enum: []string{"ok", "ready", "not_ready", "shutting_down"}},
+		"checks": {Type: "object", AdditionalProperties: &openapi.Schema{Type: "object"}},
+	},
+}
+
+// infraRoutes are unversioned and never deprecated.
+var infraRoutes = []openapi.Route{
+	{
+		Method: http.MethodGet, Path: "/healthz", ID: "healthz", Tag: "health",
+		Summary:   "Liveness probe",
+		Responses: map[int]any{http.StatusOK: healthSchema},
+	},
+	{
+		Method: http.MethodGet, Path: "/readyz", ID: "readyz", Tag: "health",
+		Summary:   "Readiness probe",
+		Responses: map[int]any{http.StatusOK: healthSchema, http.StatusServiceUnavailable: healthSchema},
+	},
+	{
+		Method: http.MethodGet, Path: "/openapi.json", ID: "getOpenAPI", Tag: "meta",
+		Summary:   "This document",
+		Responses: map[int]any{http.StatusOK: &openapi.Schema{Type: "object"}},
+	},
+}
+
+// v1Routes documents the v1 API, relative to its prefix.
+var v1Routes = []openapi.Route{
+	{
+		Method: http.MethodPost, Path: "/accounts", ID: "createAccount", Tag: "accounts",
+		Summary:   "Create an account and issue its API key",
+		Request:   service.AccountCreateInput{},
+		Responses: map[int]any{http.StatusCreated: service.AccountOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/accounts", ID: "getAccount", Tag: "accounts", Auth: true,
+		Summary:   "Get the account of the API key",
+		Responses: map[int]any{http.StatusOK: service.AccountOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/invoices", ID: "createInvoice", Tag: "invoices", Auth: true,
+		Summary:   "Create and process an invoice",
+		Request:   service.InvoiceCreateInput{},
+		Responses: map[int]any{http.StatusCreated: service.InvoiceOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/invoices/batch", ID: "createInvoiceBatch", Tag: "invoices", Auth: true,
+		Summary:   "Create up to batch.max_items invoices at once (JSON array, or NDJSON with Content-Type application/x-ndjson)",
+		Request:   []service.InvoiceCreateInput{},
+		Responses: map[int]any{http.StatusOK: handlers.BatchInvoicesResponse{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/invoices", ID: "listInvoices", Tag: "invoices", Auth: true,
+		Summary:   "List the account invoices, or the one matching ?external_reference=",
+		Responses: map[int]any{http.StatusOK: []service.InvoiceOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/invoices/{id}", ID: "getInvoice", Tag: "invoices", Auth: true,
+		Summary:   "Get an invoice by ID",
+		Responses: map[int]any{http.StatusOK: service.InvoiceOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/installment-rules", ID: "getInstallmentRules", Tag: "invoices", Auth: true,
+		Summary:   "Get the installment rules of the account",
+		Responses: map[int]any{http.StatusOK: service.InstallmentRulesOutput{}},
+	},
+	{
+		Method: http.MethodPut, Path: "/installment-rules", ID: "putInstallmentRules", Tag: "invoices", Auth: true,
+		Summary:   "Set how many installments buyers may choose and their interest",
+		Request:   service.InstallmentRulesInput{},
+		Responses: map[int]any{http.StatusOK: service.InstallmentRulesOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/bank-accounts", ID: "createBankAccount", Tag: "payouts", Auth: true,
+		Summary:   "Register a bank account to receive payouts",
+		Request:   service.BankAccountInput{},
+		Responses: map[int]any{http.StatusCreated: service.BankAccountOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/bank-accounts", ID: "listBankAccounts", Tag: "payouts", Auth: true,
+		Summary:   "List the registered bank accounts",
+		Responses: map[int]any{http.StatusOK: []service.BankAccountOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/payouts", ID: "createPayout", Tag: "payouts", Auth: true,
+		Summary:   "Withdraw from the balance to a registered bank account",
+		Request:   service.PayoutCreateInput{},
+		Responses: map[int]any{http.StatusCreated: service.PayoutOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/payouts", ID: "listPayouts", Tag: "payouts", Auth: true,
+		Summary:   "List the account payouts",
+		Responses: map[int]any{http.StatusOK: []service.PayoutOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/payouts/{id}", ID: "getPayout", Tag: "payouts", Auth: true,
+		Summary:   "Get a payout by ID",
+		Responses: map[int]any{http.StatusOK: service.PayoutOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/plans", ID: "createPlan", Tag: "subscriptions", Auth: true,
+		Summary:   "Create a subscription plan",
+		Request:   service.PlanInput{},
+		Responses: map[int]any{http.StatusCreated: service.PlanOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/plans", ID: "listPlans", Tag: "subscriptions", Auth: true,
+		Summary:   "List the account plans",
+		Responses: map[int]any{http.StatusOK: []service.PlanOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/subscriptions", ID: "createSubscription", Tag: "subscriptions", Auth: true,
+		Summary:   "Subscribe a tokenized card to a plan",
+		Request:   service.SubscriptionInput{},
+		Responses: map[int]any{http.StatusCreated: service.SubscriptionOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/subscriptions", ID: "listSubscriptions", Tag: "subscriptions", Auth: true,
+		Summary:   "List the account subscriptions",
+		Responses: map[int]any{http.StatusOK: []service.SubscriptionOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/subscriptions/{id}", ID: "getSubscription", Tag: "subscriptions", Auth: true,
+		Summary:   "Get a subscription by ID",
+		Responses: map[int]any{http.StatusOK: service.SubscriptionOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/subscriptions/{id}/cancel", ID: "cancelSubscription", Tag: "subscriptions", Auth: true,
+		Summary:   "Stop billing a subscription",
+		Responses: map[int]any{http.StatusOK: service.SubscriptionOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/checkout-sessions", ID: "createCheckoutSession", Tag: "checkout", Auth: true,
+		Summary:   "Create a payment link",
+		Request:   service.CheckoutSessionInput{},
+		Responses: map[int]any{http.StatusCreated: service.CheckoutSessionOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/checkout-sessions/{id}", ID: "getCheckoutSession", Tag: "checkout", Auth: true,
+		Summary:   "Get a payment link by ID",
+		Responses: map[int]any{http.StatusOK: service.CheckoutSessionOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/checkout/{id}", ID: "getCheckout", Tag: "checkout",
+		Summary:   "Show a payment link to the payer",
+		Responses: map[int]any{http.StatusOK: service.CheckoutOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/checkout/{id}/pay", ID: "payCheckout", Tag: "checkout",
+		Summary:   "Pay a payment link on behalf of its merchant",
+		Request:   service.CheckoutPayInput{},
+		Responses: map[int]any{http.StatusOK: service.CheckoutPayOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/disputes", ID: "listDisputes", Tag: "disputes", Auth: true,
+		Summary:   "List the disputes (chargebacks) of the account",
+		Responses: map[int]any{http.StatusOK: []service.DisputeOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/disputes/{id}", ID: "getDispute", Tag: "disputes", Auth: true,
+		Summary:   "Get a dispute by ID",
+		Responses: map[int]any{http.StatusOK: service.DisputeOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/disputes/{id}/evidence", ID: "submitDisputeEvidence", Tag: "disputes", Auth: true,
+		Summary:   "Answer a dispute with evidence before its deadline",
+		Request:   service.DisputeEvidenceInput{},
+		Responses: map[int]any{http.StatusOK: service.DisputeOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/customers", ID: "createCustomer", Tag: "customers", Auth: true,
+		Summary:   "Register a payer of the account, identified by CPF",
+		Request:   service.CustomerInput{},
+		Responses: map[int]any{http.StatusCreated: service.CustomerOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/customers", ID: "listCustomers", Tag: "customers", Auth: true,
+		Summary:   "List the customers of the account",
+		Responses: map[int]any{http.StatusOK: []service.CustomerOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/customers/{id}", ID: "getCustomer", Tag: "customers", Auth: true,
+		Summary:   "Get a customer by ID",
+		Responses: map[int]any{http.StatusOK: service.CustomerOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/customers/{id}/invoices", ID: "listCustomerInvoices", Tag: "customers", Auth: true,
+		Summary:   "List the invoices of a customer, newest first",
+		Responses: map[int]any{http.StatusOK: []service.InvoiceOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/customers/{id}/cards", ID: "createSavedCard", Tag: "customers", Auth: true,
+		Summary:   "Save a tokenized card for a customer",
+		Request:   service.SavedCardInput{},
+		Responses: map[int]any{http.StatusCreated: service.SavedCardOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/customers/{id}/cards", ID: "listSavedCards", Tag: "customers", Auth: true,
+		Summary:   "List the saved cards of a customer",
+		Responses: map[int]any{http.StatusOK: []service.SavedCardOutput{}},
+	},
+	{
+		Method: http.MethodDelete, Path: "/customers/{id}/cards/{card_id}", ID: "deleteSavedCard", Tag: "customers", Auth: true,
+		Summary:   "Delete a saved card; invoices charged to it are kept",
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+}
+
+// v2Routes documents the v2 API, which exchanges money as integer cents.
+var v2Routes = []openapi.Route{
+	{
+		Method: http.MethodPost, Path: "/accounts", ID: "createAccount", Tag: "accounts",
+		Summary:   "Create an account and issue its API key",
+		Request:   service.AccountCreateInput{},
+		Responses: map[int]any{http.StatusCreated: service.AccountOutputV2{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/accounts", ID: "getAccount", Tag: "accounts", Auth: true,
+		Summary:   "Get the account of the API key",
+		Responses: map[int]any{http.StatusOK: service.AccountOutputV2{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/invoices", ID: "createInvoice", Tag: "invoices", Auth: true,
+		Summary:   "Create and process an invoice",
+		Request:   service.InvoiceCreateInputV2{},
+		Responses: map[int]any{http.StatusCreated: service.InvoiceOutputV2{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/invoices/batch", ID: "createInvoiceBatch", Tag: "invoices", Auth: true,
+		Summary:   "Create up to batch.max_items invoices at once (JSON array, or NDJSON with Content-Type application/x-ndjson)",
+		Request:   []service.InvoiceCreateInputV2{},
+		Responses: map[int]any{http.StatusOK: handlers.BatchInvoicesResponseV2{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/invoices", ID: "listInvoices", Tag: "invoices", Auth: true,
+		Summary:   "List the account invoices, or the one matching ?external_reference=",
+		Responses: map[int]any{http.StatusOK: []service.InvoiceOutputV2{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/invoices/{id}", ID: "getInvoice", Tag: "invoices", Auth: true,
+		Summary:   "Get an invoice by ID",
+		Responses: map[int]any{http.StatusOK: service.InvoiceOutputV2{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/installment-rules", ID: "getInstallmentRules", Tag: "invoices", Auth: true,
+		Summary:   "Get the installment rules of the account",
+		Responses: map[int]any{http.StatusOK: service.InstallmentRulesOutput{}},
+	},
+	{
+		Method: http.MethodPut, Path: "/installment-rules", ID: "putInstallmentRules", Tag: "invoices", Auth: true,
+		Summary:   "Set how many installments buyers may choose and their interest",
+		Request:   service.InstallmentRulesInput{},
+		Responses: map[int]any{http.StatusOK: service.InstallmentRulesOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/bank-accounts", ID: "createBankAccount", Tag: "payouts", Auth: true,
+		Summary:   "Register a bank account to receive payouts",
+		Request:   service.BankAccountInput{},
+		Responses: map[int]any{http.StatusCreated: service.BankAccountOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/bank-accounts", ID: "listBankAccounts", Tag: "payouts", Auth: true,
+		Summary:   "List the registered bank accounts",
+		Responses: map[int]any{http.StatusOK: []service.BankAccountOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/payouts", ID: "createPayout", Tag: "payouts", Auth: true,
+		Summary:   "Withdraw from the balance to a registered bank account",
+		Request:   service.PayoutCreateInputV2{},
+		Responses: map[int]any{http.StatusCreated: service.PayoutOutputV2{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/payouts", ID: "listPayouts", Tag: "payouts", Auth: true,
+		Summary:   "List the account payouts",
+		Responses: map[int]any{http.StatusOK: []service.PayoutOutputV2{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/payouts/{id}", ID: "getPayout", Tag: "payouts", Auth: true,
+		Summary:   "Get a payout by ID",
+		Responses: map[int]any{http.StatusOK: service.PayoutOutputV2{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/plans", ID: "createPlan", Tag: "subscriptions", Auth: true,
+		Summary:   "Create a subscription plan",
+		Request:   service.PlanInputV2{},
+		Responses: map[int]any{http.StatusCreated: service.PlanOutputV2{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/plans", ID: "listPlans", Tag: "subscriptions", Auth: true,
+		Summary:   "List the account plans",
+		Responses: map[int]any{http.StatusOK: []service.PlanOutputV2{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/subscriptions", ID: "createSubscription", Tag: "subscriptions", Auth: true,
+		Summary:   "Subscribe a tokenized card to a plan",
+		Request:   service.SubscriptionInput{},
+		Responses: map[int]any{http.StatusCreated: service.SubscriptionOutputV2{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/subscriptions", ID: "listSubscriptions", Tag: "subscriptions", Auth: true,
+		Summary:   "List the account subscriptions",
+		Responses: map[int]any{http.StatusOK: []service.SubscriptionOutputV2{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/subscriptions/{id}", ID: "getSubscription", Tag: "subscriptions", Auth: true,
+		Summary:   "Get a subscription by ID",
+		Responses: map[int]any{http.StatusOK: service.SubscriptionOutputV2{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/subscriptions/{id}/cancel", ID: "cancelSubscription", Tag: "subscriptions", Auth: true,
+		Summary:   "Stop billing a subscription",
+		Responses: map[int]any{http.StatusOK: service.SubscriptionOutputV2{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/checkout-sessions", ID: "createCheckoutSession", Tag: "checkout", Auth: true,
+		Summary:   "Create a payment link",
+		Request:   service.CheckoutSessionInputV2{},
+		Responses: map[int]any{http.StatusCreated: service.CheckoutSessionOutputV2{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/checkout-sessions/{id}", ID: "getCheckoutSession", Tag: "checkout", Auth: true,
+		Summary:   "Get a payment link by ID",
+		Responses: map[int]any{http.StatusOK: service.CheckoutSessionOutputV2{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/checkout/{id}", ID: "getCheckout", Tag: "checkout",
+		Summary:   "Show a payment link to the payer",
+		Responses: map[int]any{http.StatusOK: service.CheckoutOutputV2{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/checkout/{id}/pay", ID: "payCheckout", Tag: "checkout",
+		Summary:   "Pay a payment link on behalf of its merchant",
+		Request:   service.CheckoutPayInput{},
+		Responses: map[int]any{http.StatusOK: service.CheckoutPayOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/disputes", ID: "listDisputes", Tag: "disputes", Auth: true,
+		Summary:   "List the disputes (chargebacks) of the account",
+		Responses: map[int]any{http.StatusOK: []service.DisputeOutputV2{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/disputes/{id}", ID: "getDispute", Tag: "disputes", Auth: true,
+		Summary:   "Get a dispute by ID",
+		Responses: map[int]any{http.StatusOK: service.DisputeOutputV2{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/disputes/{id}/evidence", ID: "submitDisputeEvidence", Tag: "disputes", Auth: true,
+		Summary:   "Answer a dispute with evidence before its deadline",
+		Request:   service.DisputeEvidenceInput{},
+		Responses: map[int]any{http.StatusOK: service.DisputeOutputV2{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/customers", ID: "createCustomer", Tag: "customers", Auth: true,
+		Summary:   "Register a payer of the account, identified by CPF",
+		Request:   service.CustomerInput{},
+		Responses: map[int]any{http.StatusCreated: service.CustomerOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/customers", ID: "listCustomers", Tag: "customers", Auth: true,
+		Summary:   "List the customers of the account",
+		Responses: map[int]any{http.StatusOK: []service.CustomerOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/customers/{id}", ID: "getCustomer", Tag: "customers", Auth: true,
+		Summary:   "Get a customer by ID",
+		Responses: map[int]any{http.StatusOK: service.CustomerOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/customers/{id}/invoices", ID: "listCustomerInvoices", Tag: "customers", Auth: true,
+		Summary:   "List the invoices of a customer, newest first",
+		Responses: map[int]any{http.StatusOK: []service.InvoiceOutputV2{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/customers/{id}/cards", ID: "createSavedCard", Tag: "customers", Auth: true,
+		Summary:   "Save a tokenized card for a customer",
+		Request:   service.SavedCardInput{},
+		Responses: map[int]any{http.StatusCreated: service.SavedCardOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/customers/{id}/cards", ID: "listSavedCards", Tag: "customers", Auth: true,
+		Summary:   "List the saved cards of a customer",
+		Responses: map[int]any{http.StatusOK: []service.SavedCardOutput{}},
+	},
+	{
+		Method: http.MethodDelete, Path: "/customers/{id}/cards/{card_id}", ID: "deleteSavedCard", Tag: "customers", Auth: true,
+		Summary:   "Delete a saved card; invoices charged to it are kept",
+		Responses: map[int]any{http.StatusNoContent: nil},
+	},
+}
+
+// adminRoutes documents the unversioned operations API.
+var adminRoutes = []openapi.Route{
+	{
+		Method: http.MethodGet, Path: "/admin/accounts", ID: "admin.listAccounts", Tag: "admin", Admin: true,
+		Summary:   "Search accounts (query params q, status, limit, offset)",
+		Responses: map[int]any{http.StatusOK: service.AccountListOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/accounts/{id}", ID: "admin.getAccount", Tag: "admin", Admin: true,
+		Summary:   "Get any account",
+		Responses: map[int]any{http.StatusOK: service.AdminAccountOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/accounts/{id}/suspend", ID: "admin.suspendAccount", Tag: "admin", Admin: true,
+		Summary:   "Suspend an active account",
+		Responses: map[int]any{http.StatusOK: service.AdminAccountOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/accounts/{id}/reactivate", ID: "admin.reactivateAccount", Tag: "admin", Admin: true,
+		Summary:   "Reactivate a suspended account",
+		Responses: map[int]any{http.StatusOK: service.AdminAccountOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/accounts/{id}/close", ID: "admin.closeAccount", Tag: "admin", Admin: true,
+		Summary:   "Permanently close an account",
+		Responses: map[int]any{http.StatusOK: service.AdminAccountOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/accounts/{id}/balance-adjustments", ID: "admin.adjustBalance", Tag: "admin", Admin: true,
+		Summary:   "Credit or debit an account balance",
+		Headers:   []string{"X-Admin-Actor"},
+		Request:   service.BalanceAdjustmentInput{},
+		Responses: map[int]any{http.StatusCreated: service.BalanceAdjustmentOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/accounts/{id}/balance-adjustments", ID: "admin.listAdjustments", Tag: "admin", Admin: true,
+		Summary:   "List the balance adjustments of an account",
+		Responses: map[int]any{http.StatusOK: []service.BalanceAdjustmentOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/accounts/{id}/fee-plans", ID: "admin.listFeePlans", Tag: "admin", Admin: true,
+		Summary:   "List the fee plans of an account",
+		Responses: map[int]any{http.StatusOK: []service.FeePlanOutput{}},
+	},
+	{
+		Method: http.MethodPut, Path: "/admin/accounts/{id}/fee-plans/{payment_type}", ID: "admin.setFeePlan", Tag: "admin", Admin: true,
+		Summary:   "Set the fee plan of an account for a payment type",
+		Request:   service.FeePlanInput{},
+		Responses: map[int]any{http.StatusOK: service.FeePlanOutput{}},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/revenue", ID: "admin.revenue", Tag: "admin", Admin: true,
+		Summary:   "Fees collected per payment type (query params from, to as YYYY-MM-DD)",
+		Responses: map[int]any{http.StatusOK: service.RevenueOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/invoices/{id}/disputes", ID: "admin.openDispute", Tag: "admin", Admin: true,
+		Summary:   "Open a dispute on an approved invoice, freezing the disputed amount",
+		Request:   service.DisputeOpenInput{},
+		Responses: map[int]any{http.StatusCreated: service.DisputeOutput{}},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/disputes/{id}/resolve", ID: "admin.resolveDispute", Tag: "admin", Admin: true,
+		Summary:   "Resolve a dispute: won releases the frozen amount, lost keeps it debited",
+		Request:   service.DisputeResolveInput{},
+		Responses: map[int]any{http.StatusOK: service.DisputeOutput{}},
+	},
+}
+
+// apiVersions lists the API versions mounted by configureRoutes. A new
+// version is mounted under its prefix there and listed here.
+var apiVersions = []struct {
+	prefix string
+	routes []openapi.Route
+}{
+	{"/v1", v1Routes},
+	{"/v2", v2Routes},
+}
+
+// apiRoutes documents every route registered by configureRoutes. The contract
+// test fails when this table and the router disagree.
+func apiRoutes() []openapi.Route {
+	routes := append([]openapi.Route(nil), infraRoutes...)
+	routes = append(routes, adminRoutes...)
+	for _, v := range apiVersions {
+		for _, rt := range v.routes {
+			rt.Path = v.prefix + rt.Path
+			rt.ID = v.prefix[1:] + "." + rt.ID
+			routes = append(routes, rt)
+		}
+	}
+	// Legacy unversioned aliases of v1
+	for _, rt := range v1Routes {
+		rt.ID = "legacy." + rt.ID
+		rt.Deprecated = true
+		routes = append(routes, rt)
+	}
+	return routes
+}
+
+// APISpec returns the OpenAPI document of the gateway API.
+func APISpec() *openapi.Document {
+	return openapi.Build(openapi.Info{
+		Title:       "Payment Gateway API",
+		Version:     "1.0.0",
+		Description: "Accounts and invoices of the payment gateway. Unversioned routes are deprecated aliases of /v1.",
+	}, apiRoutes(), httperror.Problem{})
+}
@@ -0,0 +1,393 @@
+package web
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestTenantIsolation calls every authenticated route as account acc-b with
+// identifiers owned by account acc-a. Each case asserts that the queries are
+// scoped to acc-b, that invoice transactions are bound to acc-b for row-level
+// security and that nothing of acc-a is disclosed. Adding an
+// authenticated route without a case here fails the test.
+func TestTenantIsolation(t *testing.T) {
+	now := time.Now().UTC()
+	accountRow := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(accountQuery).WithArgs("key-b").
+			WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-b", "Tenant B", "b@example.com", "key-b", 10.0, 0.0, "active", now, now))
+	}
+	bankColumns := []string{"id", "account_id", "bank_code", "branch", "number", "holder_name", "created_at"}
+	payoutColumns := []string{"id", "account_id", "bank_account_id", "amount", "status", "failure_reason", "transfer_reference", "created_at", "updated_at"}
+	// subscriptionByID expects the lookup of another tenant's subscription
+	subscriptionByID := func(mock sqlmock.Sqlmock) {
+		accountRow(mock)
+		accountRow(mock)
+		expectTenantTx(mock, "acc-b")
+		mock.ExpectQuery(`FROM subscriptions WHERE id = \$1 AND account_id = \$2`).WithArgs("sub-a", "acc-b").
+			WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+		mock.ExpectRollback()
+	}
+	// disputeByID expects the lookup of another tenant's dispute
+	disputeByID := func(mock sqlmock.Sqlmock) {
+		accountRow(mock)
+		accountRow(mock)
+		expectTenantTx(mock, "acc-b")
+		mock.ExpectQuery(`FROM disputes WHERE id = \$1 AND account_id = \$2`).WithArgs("dsp-a", "acc-b").
+			WillReturnRows(sqlmock.NewRows(disputeColumns))
+		mock.ExpectRollback()
+	}
+	customerColumns := []string{"id", "account_id", "name", "email", "document", "created_at"}
+	// customerByID expects the lookup of another tenant's customer
+	customerByID := func(mock sqlmock.Sqlmock) {
+		accountRow(mock)
+		accountRow(mock)
+		expectTenantTx(mock, "acc-b")
+		mock.ExpectQuery(`FROM customers WHERE id = \$1 AND account_id = \$2`).WithArgs("cus-a", "acc-b").
+			WillReturnRows(sqlmock.NewRows(customerColumns))
+		mock.ExpectRollback()
+	}
+
+	type isolationCase struct {
+		path   string // relative to the version prefix
+		body   func(prefix string) string
+		status int
+		expect func(mock sqlmock.Sqlmock)
+		// reject, when set, must not appear in the response body.
+		reject string
+	}
+	cases := map[string]isolationCase{
+		"GET /accounts": {
+			path: "/accounts", status: http.StatusOK, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) { accountRow(mock) },
+		},
+		"GET /invoices": {
+			path: "/invoices", status: http.StatusOK, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`SELECT ` + invoiceCols + ` FROM invoices WHERE account_id = \$1`).WithArgs("acc-b").
+					WillReturnRows(sqlmock.NewRows(invoiceColumns))
+				mock.ExpectCommit()
+			},
+		},
+		"GET /invoices/{id}": {
+			path: "/invoices/inv-a", status: http.StatusNotFound, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`SELECT `+invoiceCols+` FROM invoices WHERE id = \$1 AND account_id = \$2`).WithArgs("inv-a", "acc-b").
+					WillReturnRows(sqlmock.NewRows(invoiceColumns))
+				mock.ExpectRollback()
+			},
+		},
+		"POST /invoices": {
+			path: "/invoices", status: http.StatusUnprocessableEntity,
+			body: func(prefix string) string {
+				if prefix == "/v2" {
+					return `{"amount_cents":10000,"description":"Other tenant","payment_type":"pix","api_key":"key-a"}`
+				}
+				return `{"amount":100,"description":"Other tenant","payment_type":"pix","api_key":"key-a"}`
+			},
+			expect: func(mock sqlmock.Sqlmock) { accountRow(mock) },
+		},
+		"POST /invoices/batch": {
+			// An item naming another account fails alone; the batch runs as acc-b
+			path: "/invoices/batch", status: http.StatusOK, reject: "acc-a",
+			body: func(prefix string) string {
+				if prefix == "/v2" {
+					return `[{"amount_cents":10000,"description":"Other tenant","payment_type":"pix","api_key":"key-a"}]`
+				}
+				return `[{"amount":100,"description":"Other tenant","payment_type":"pix","api_key":"key-a"}]`
+			},
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+			},
+		},
+		"GET /bank-accounts": {
+			path: "/bank-accounts", status: http.StatusOK, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`FROM bank_accounts WHERE account_id = \$1`).WithArgs("acc-b").
+					WillReturnRows(sqlmock.NewRows(bankColumns))
+				mock.ExpectCommit()
+			},
+		},
+		"POST /bank-accounts": {
+			path: "/bank-accounts", status: http.StatusUnprocessableEntity,
+			body: func(string) string {
+				return `{"account_id":"acc-a","bank_code":"341","branch":"0001","number":"12345-6","holder_name":"John Doe"}`
+			},
+			expect: func(mock sqlmock.Sqlmock) { accountRow(mock) },
+		},
+		"GET /payouts": {
+			path: "/payouts", status: http.StatusOK, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`FROM payouts WHERE account_id = \$1`).WithArgs("acc-b").
+					WillReturnRows(sqlmock.NewRows(payoutColumns))
+				mock.ExpectCommit()
+			},
+		},
+		"GET /payouts/{id}": {
+			path: "/payouts/pay-a", status: http.StatusNotFound, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`FROM payouts WHERE id = \$1 AND account_id = \$2`).WithArgs("pay-a", "acc-b").
+					WillReturnRows(sqlmock.NewRows(payoutColumns))
+				mock.ExpectRollback()
+			},
+		},
+		"POST /payouts": {
+			path: "/payouts", status: http.StatusUnprocessableEntity, reject: "acc-a",
+			body: func(prefix string) string {
+				if prefix == "/v2" {
+					return `{"bank_account_id":"bank-a","amount_cents":500}`
+				}
+				return `{"bank_account_id":"bank-a","amount":5}`
+			},
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`FROM bank_accounts WHERE id = \$1 AND account_id = \$2`).WithArgs("bank-a", "acc-b").
+					WillReturnRows(sqlmock.NewRows(bankColumns))
+				mock.ExpectRollback()
+			},
+		},
+		"GET /plans": {
+			path: "/plans", status: http.StatusOK, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`FROM plans WHERE account_id = \$1`).WithArgs("acc-b").
+					WillReturnRows(sqlmock.NewRows(planColumns))
+				mock.ExpectCommit()
+			},
+		},
+		"POST /plans": {
+			path: "/plans", status: http.StatusUnprocessableEntity,
+			body: func(prefix string) string {
+				if prefix == "/v2" {
+					return `{"account_id":"acc-a","name":"Pro","amount_cents":4990,"interval":"month"}`
+				}
+				return `{"account_id":"acc-a","name":"Pro","amount":49.9,"interval":"month"}`
+			},
+			expect: func(mock sqlmock.Sqlmock) { accountRow(mock) },
+		},
+		"GET /subscriptions": {
+			path: "/subscriptions", status: http.StatusOK, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`FROM subscriptions WHERE account_id = \$1`).WithArgs("acc-b").
+					WillReturnRows(sqlmock.NewRows(subscriptionColumns))
+				mock.ExpectCommit()
+			},
+		},
+		"GET /subscriptions/{id}": {
+			path: "/subscriptions/sub-a", status: http.StatusNotFound, reject: "acc-a",
+			expect: subscriptionByID,
+		},
+		"POST /subscriptions/{id}/cancel": {
+			path: "/subscriptions/sub-a/cancel", status: http.StatusNotFound, reject: "acc-a",
+			expect: subscriptionByID,
+		},
+		"POST /subscriptions": {
+			path: "/subscriptions", status: http.StatusUnprocessableEntity, reject: "acc-a",
+			body: func(string) string {
+				return `{"plan_id":"plan-a","card_token":"tok_12345678","card_last_digits":"4242"}`
+			},
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`FROM plans WHERE id = \$1 AND account_id = \$2`).WithArgs("plan-a", "acc-b").
+					WillReturnRows(sqlmock.NewRows(planColumns))
+				mock.ExpectRollback()
+			},
+		},
+		"GET /disputes": {
+			path: "/disputes", status: http.StatusOK, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`FROM disputes WHERE account_id = \$1`).WithArgs("acc-b").
+					WillReturnRows(sqlmock.NewRows(disputeColumns))
+				mock.ExpectCommit()
+			},
+		},
+		"GET /disputes/{id}": {
+			path: "/disputes/dsp-a", status: http.StatusNotFound, reject: "acc-a",
+			expect: disputeByID,
+		},
+		"POST /disputes/{id}/evidence": {
+			path: "/disputes/dsp-a/evidence", status: http.StatusNotFound, reject: "acc-a",
+			body:   func(string) string { return `{"evidence":"tracking code BR123456789"}` },
+			expect: disputeByID,
+		},
+		"GET /installment-rules": {
+			path: "/installment-rules", status: http.StatusOK, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`FROM installment_rules WHERE account_id = \$1`).WithArgs("acc-b").
+					WillReturnRows(sqlmock.NewRows(installmentRulesColumns))
+				mock.ExpectRollback()
+			},
+		},
+		"PUT /installment-rules": {
+			path: "/installment-rules", status: http.StatusUnprocessableEntity,
+			body: func(string) string {
+				return `{"account_id":"acc-a","max_installments":12,"free_installments":12,"monthly_interest":0}`
+			},
+			expect: func(mock sqlmock.Sqlmock) { accountRow(mock) },
+		},
+		"GET /checkout-sessions/{id}": {
+			path: "/checkout-sessions/cs-a", status: http.StatusNotFound, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`FROM checkout_sessions WHERE id = \$1 AND account_id = \$2`).WithArgs("cs-a", "acc-b").
+					WillReturnRows(sqlmock.NewRows(checkoutSessionColumns))
+				mock.ExpectRollback()
+			},
+		},
+		"POST /checkout-sessions": {
+			path: "/checkout-sessions", status: http.StatusUnprocessableEntity,
+			body: func(prefix string) string {
+				if prefix == "/v2" {
+					return `{"account_id":"acc-a","amount_cents":10000,"description":"Other tenant","payment_types":["pix"],"success_url":"https://example.com"}`
+				}
+				return `{"account_id":"acc-a","amount":100,"description":"Other tenant","payment_types":["pix"],"success_url":"https://example.com"}`
+			},
+			expect: func(mock sqlmock.Sqlmock) { accountRow(mock) },
+		},
+		"POST /customers": {
+			path: "/customers", status: http.StatusUnprocessableEntity,
+			body: func(string) string {
+				return `{"account_id":"acc-a","name":"Maria Silva","email":"maria@example.com","document":"52998224725"}`
+			},
+			expect: func(mock sqlmock.Sqlmock) { accountRow(mock) },
+		},
+		"GET /customers": {
+			path: "/customers", status: http.StatusOK, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectQuery(`FROM customers WHERE account_id = \$1`).WithArgs("acc-b").
+					WillReturnRows(sqlmock.NewRows(customerColumns))
+				mock.ExpectCommit()
+			},
+		},
+		"GET /customers/{id}": {
+			path: "/customers/cus-a", status: http.StatusNotFound, reject: "acc-a",
+			expect: customerByID,
+		},
+		"GET /customers/{id}/invoices": {
+			path: "/customers/cus-a/invoices", status: http.StatusNotFound, reject: "acc-a",
+			expect: customerByID,
+		},
+		"POST /customers/{id}/cards": {
+			path: "/customers/cus-a/cards", status: http.StatusNotFound, reject: "acc-a",
+			body:   func(string) string { return `{"card_token":"tok_12345678","card_last_digits":"4242"}` },
+			expect: customerByID,
+		},
+		"GET /customers/{id}/cards": {
+			path: "/customers/cus-a/cards", status: http.StatusNotFound, reject: "acc-a",
+			expect: customerByID,
+		},
+		"DELETE /customers/{id}/cards/{card_id}": {
+			path: "/customers/cus-a/cards/card-a", status: http.StatusNotFound, reject: "acc-a",
+			expect: func(mock sqlmock.Sqlmock) {
+				accountRow(mock)
+				accountRow(mock)
+				expectTenantTx(mock, "acc-b")
+				mock.ExpectExec(`DELETE FROM saved_cards WHERE id = \$1 AND account_id = \$2 AND customer_id = \$3`).WithArgs("card-a", "acc-b", "cus-a").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	covered := map[string]bool{}
+	for _, rt := range apiRoutes() {
+		if !rt.Auth {
+			continue
+		}
+		prefix := ""
+		for _, p := range []string{"/v1", "/v2"} {
+			if strings.HasPrefix(rt.Path, p+"/") {
+				prefix = p
+			}
+		}
+		key := rt.Method + " " + strings.TrimPrefix(rt.Path, prefix)
+		tc, ok := cases[key]
+		if !ok {
+			t.Errorf("authenticated route %s %s has no tenant isolation case", rt.Method, rt.Path)
+			continue
+		}
+		covered[key] = true
+
+		t.Run(rt.Method+" "+prefix+tc.path, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock: %v", err)
+			}
+			defer db.Close()
+			tc.expect(mock)
+
+			ts := httptest.NewServer(ConfigureRoutes(db))
+			defer ts.Close()
+
+			var body string
+			if tc.body != nil {
+				body = tc.body(prefix)
+			}
+			req, _ := http.NewRequest(rt.Method, ts.URL+prefix+tc.path, bytes.NewBufferString(body))
+			req.Header.Set("X-API-KEY", "key-b")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			got, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.status {
+				t.Fatalf("expected %d got %d: %s", tc.status, resp.StatusCode, got)
+			}
+			if tc.reject != "" && strings.Contains(string(got), tc.reject) {
+				t.Fatalf("response discloses %s: %s", tc.reject, got)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("unmet expectations: %v", err)
+			}
+		})
+	}
+	for key := range cases {
+		if !covered[key] {
+			t.Errorf("tenant isolation case %s matches no route", key)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestVersioning_LegacyRoutesAreDeprecated(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	sunset := time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)
+	h := ConfigureRoutes(db, WithLegacySunset(DefaultLegacyDeprecatedAt, sunset))
+
+	tests := []struct {
+		path       string
+		deprecated bool
+	}{
+		{"/accounts", true},
+		{"/invoices/abc", true},
+		{"/v1/accounts", false},
+		{"/v2/accounts", false},
+		{"/healthz", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			got := w.Header().Get("Deprecation") != ""
+			if got != tt.deprecated {
+				t.Fatalf("expected deprecated=%v, headers %v", tt.deprecated, w.Header())
+			}
+			if !tt.deprecated {
+				return
+			}
+			if s := w.Header().Get("Sunset"); s != "Thu, 31 Jan 2030 00:00:00 GMT" {
+				t.Errorf("unexpected Sunset %q", s)
+			}
+			if l := w.Header().Get("Link"); l != "</v1"+tt.path+`>; rel="successor-version"` {
+				t.Errorf("unexpected Link %q", l)
+			}
+		})
+	}
+}
+
+func TestVersioning_V2UsesCents(t *testing.T) {
+	ts, mock, db := newTestServer(t)
+	defer ts.Close()
+	defer db.Close()
+
+	now := time.Now().UTC()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, api_key, balance, pending_balance, status, created_at, updated_at FROM accounts WHERE api_key = $1")).
+		WithArgs("key-1").
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow("acc-1", "John Doe", "john@example.com", "key-1", 1234.56, 0.0, "active", now, now))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/v2/accounts", nil)
+	req.Header.Set("X-API-KEY", "key-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body["balance_cents"] != float64(123456) {
+		t.Fatalf("expected balance_cents 123456, got %v", body)
+	}
+	if _, ok := body["balance"]; ok {
+		t.Fatalf("v2 must not expose decimal balance: %v", body)
+	}
+}
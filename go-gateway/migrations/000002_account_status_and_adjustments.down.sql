@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS balance_adjustments;
+
+DROP INDEX IF EXISTS idx_accounts_created_at;
+DROP INDEX IF EXISTS idx_accounts_status;
+
+ALTER TABLE accounts DROP COLUMN IF EXISTS status;
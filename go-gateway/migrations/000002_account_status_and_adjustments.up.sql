@@ -0,0 +1,16 @@
+ALTER TABLE accounts ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active';
+
+CREATE INDEX idx_accounts_status ON accounts(status);
+CREATE INDEX idx_accounts_created_at ON accounts(created_at);
+
+CREATE TABLE IF NOT EXISTS balance_adjustments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    amount DECIMAL(10,2) NOT NULL,
+    balance_after DECIMAL(10,2) NOT NULL,
+    reason TEXT NOT NULL,
+    actor VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_balance_adjustments_account_id ON balance_adjustments(account_id, created_at);
@@ -0,0 +1 @@
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS chk_accounts_status;
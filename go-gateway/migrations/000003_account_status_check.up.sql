@@ -0,0 +1,2 @@
+ALTER TABLE accounts
+    ADD CONSTRAINT chk_accounts_status CHECK (status IN ('active', 'suspended', 'closed'));
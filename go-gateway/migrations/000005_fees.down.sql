@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS invoice_fees;
+DROP TABLE IF EXISTS fee_plans;
+ALTER TABLE invoices DROP COLUMN IF EXISTS net_amount, DROP COLUMN IF EXISTS fee;
@@ -0,0 +1,27 @@
+ALTER TABLE invoices
+    ADD COLUMN fee DECIMAL(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN net_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+-- Invoices approved before fees were introduced credited the full amount
+UPDATE invoices SET net_amount = amount WHERE status = 'approved';
+
+CREATE TABLE IF NOT EXISTS fee_plans (
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    payment_type VARCHAR(50) NOT NULL,
+    percent DECIMAL(5,2) NOT NULL CHECK (percent >= 0 AND percent <= 100),
+    fixed DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (fixed >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, payment_type)
+);
+
+CREATE TABLE IF NOT EXISTS invoice_fees (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    invoice_id UUID NOT NULL UNIQUE REFERENCES invoices(id),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    payment_type VARCHAR(50) NOT NULL,
+    gross DECIMAL(10,2) NOT NULL,
+    fee DECIMAL(10,2) NOT NULL CHECK (fee > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_invoice_fees_created_at ON invoice_fees(created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS settlements;
+ALTER TABLE accounts DROP COLUMN IF EXISTS pending_balance;
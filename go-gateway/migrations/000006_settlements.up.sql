@@ -0,0 +1,15 @@
+ALTER TABLE accounts
+    ADD COLUMN pending_balance DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS settlements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    invoice_id UUID NOT NULL UNIQUE REFERENCES invoices(id),
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'settled')),
+    available_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    settled_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_settlements_status_available_at ON settlements(status, available_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS risk_rules;
+DROP INDEX IF EXISTS idx_invoices_card_velocity;
+ALTER TABLE invoices DROP COLUMN IF EXISTS risk_reasons, DROP COLUMN IF EXISTS risk_decision;
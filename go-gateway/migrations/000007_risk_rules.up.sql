@@ -0,0 +1,27 @@
+ALTER TABLE invoices
+    ADD COLUMN risk_decision VARCHAR(10) NOT NULL DEFAULT 'approve' CHECK (risk_decision IN ('approve', 'review', 'reject')),
+    ADD COLUMN risk_reasons TEXT[] NOT NULL DEFAULT '{}';
+
+-- Invoices held by the former hard-coded threshold
+UPDATE invoices
+SET risk_decision = 'review', risk_reasons = ARRAY['amount_threshold: amount above the limit of 10000.00']
+WHERE status = 'pending' AND amount > 10000;
+
+CREATE INDEX idx_invoices_card_velocity ON invoices(account_id, card_last_digits, created_at);
+
+-- Unused columns of a rule kind stay NULL
+CREATE TABLE IF NOT EXISTS risk_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('amount_threshold', 'card_velocity', 'new_account', 'payment_type_block')),
+    account_id UUID NULL REFERENCES accounts(id),
+    payment_type VARCHAR(50) NULL,
+    max_amount DECIMAL(10,2) NULL CHECK (max_amount > 0),
+    max_per_hour INTEGER NULL CHECK (max_per_hour > 0),
+    account_age_days INTEGER NULL CHECK (account_age_days > 0),
+    action VARCHAR(10) NOT NULL CHECK (action IN ('review', 'reject')),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Same behavior as before when RISK_SOURCE=database
+INSERT INTO risk_rules (kind, max_amount, action) VALUES ('amount_threshold', 10000, 'review');
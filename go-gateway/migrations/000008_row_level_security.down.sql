@@ -0,0 +1,8 @@
+DROP POLICY IF EXISTS invoices_admin ON invoices;
+DROP POLICY IF EXISTS invoices_tenant ON invoices;
+ALTER TABLE invoices NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE invoices DISABLE ROW LEVEL SECURITY;
+
+-- Roles belong to the cluster and may serve other databases, so
+-- gateway_admin is kept
+REVOKE ALL ON invoices FROM gateway_admin;
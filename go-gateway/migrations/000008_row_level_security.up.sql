@@ -0,0 +1,25 @@
+-- Role assumed with SET LOCAL ROLE by the admin API and background jobs
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'gateway_admin') THEN
+        CREATE ROLE gateway_admin NOLOGIN;
+    END IF;
+END
+$$;
+GRANT gateway_admin TO CURRENT_USER;
+GRANT SELECT, INSERT, UPDATE, DELETE ON invoices TO gateway_admin;
+
+-- FORCE applies the policies to the table owner too, which the gateway
+-- usually connects as. Superusers and BYPASSRLS roles still skip them.
+ALTER TABLE invoices ENABLE ROW LEVEL SECURITY;
+ALTER TABLE invoices FORCE ROW LEVEL SECURITY;
+
+-- Rows of the account bound with set_config('app.account_id', ..., true);
+-- an unset or empty account matches no row
+CREATE POLICY invoices_tenant ON invoices
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY invoices_admin ON invoices TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
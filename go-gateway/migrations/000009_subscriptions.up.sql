@@ -0,0 +1,63 @@
+CREATE TABLE IF NOT EXISTS plans (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    name VARCHAR(100) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    billing_interval VARCHAR(10) NOT NULL CHECK (billing_interval IN ('day', 'week', 'month', 'year')),
+    interval_count INTEGER NOT NULL CHECK (interval_count BETWEEN 1 AND 12),
+    trial_days INTEGER NOT NULL DEFAULT 0 CHECK (trial_days BETWEEN 0 AND 365),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_plans_account_id ON plans(account_id);
+
+-- The price of the plan is copied when subscribing, so editing a plan never
+-- changes running subscriptions
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    plan_id UUID NOT NULL REFERENCES plans(id),
+    description VARCHAR(100) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    billing_interval VARCHAR(10) NOT NULL,
+    interval_count INTEGER NOT NULL,
+    card_token VARCHAR(255) NOT NULL,
+    card_last_digits VARCHAR(4) NOT NULL,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('trialing', 'active', 'past_due', 'canceled')),
+    anchor_at TIMESTAMP NOT NULL,
+    trial_ends_at TIMESTAMP NULL,
+    cycle INTEGER NOT NULL DEFAULT 0,
+    next_billing_at TIMESTAMP NOT NULL,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    last_invoice_id UUID NULL REFERENCES invoices(id),
+    canceled_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_subscriptions_account_id ON subscriptions(account_id);
+CREATE INDEX idx_subscriptions_due ON subscriptions(status, next_billing_at);
+
+-- Same tenant boundary as invoices (000008)
+GRANT SELECT, INSERT, UPDATE, DELETE ON plans, subscriptions TO gateway_admin;
+
+ALTER TABLE plans ENABLE ROW LEVEL SECURITY;
+ALTER TABLE plans FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY plans_tenant ON plans
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY plans_admin ON plans TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
+
+CREATE POLICY subscriptions_tenant ON subscriptions
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY subscriptions_admin ON subscriptions TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
@@ -0,0 +1,34 @@
+-- Payment links. Expiry is not stored as a status: an open session past
+-- expires_at is expired
+CREATE TABLE IF NOT EXISTS checkout_sessions (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    description VARCHAR(255) NOT NULL,
+    payment_types TEXT[] NOT NULL,
+    success_url VARCHAR(2048) NOT NULL,
+    cancel_url VARCHAR(2048) NOT NULL DEFAULT '',
+    status VARCHAR(10) NOT NULL CHECK (status IN ('open', 'completed')),
+    invoice_id UUID NULL REFERENCES invoices(id),
+    expires_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_checkout_sessions_account_id ON checkout_sessions(account_id);
+
+-- Same tenant boundary as invoices (000008). The public checkout looks
+-- sessions up by ID as gateway_admin, then acts as the owning account
+GRANT SELECT, INSERT, UPDATE, DELETE ON checkout_sessions TO gateway_admin;
+
+ALTER TABLE checkout_sessions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE checkout_sessions FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY checkout_sessions_tenant ON checkout_sessions
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY checkout_sessions_admin ON checkout_sessions TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
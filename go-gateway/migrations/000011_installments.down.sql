@@ -0,0 +1,12 @@
+-- Later installments cannot be kept once an invoice has a single settlement
+DELETE FROM settlements WHERE installment > 1;
+ALTER TABLE settlements DROP CONSTRAINT IF EXISTS settlements_invoice_id_installment_key;
+ALTER TABLE settlements ADD CONSTRAINT settlements_invoice_id_key UNIQUE (invoice_id);
+ALTER TABLE settlements DROP COLUMN IF EXISTS installment;
+
+DROP TABLE IF EXISTS installment_rules;
+DROP TABLE IF EXISTS invoice_installments;
+
+ALTER TABLE invoices
+    DROP COLUMN IF EXISTS total_amount,
+    DROP COLUMN IF EXISTS installments;
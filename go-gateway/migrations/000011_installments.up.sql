@@ -0,0 +1,57 @@
+-- Card invoices paid in installments (parcelamento). total_amount is what the
+-- customer pays: the amount plus the interest of the installments
+ALTER TABLE invoices
+    ADD COLUMN installments INTEGER NOT NULL DEFAULT 1 CHECK (installments BETWEEN 1 AND 12),
+    ADD COLUMN total_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+-- Invoices created before installments were paid at once
+UPDATE invoices SET total_amount = amount;
+
+-- Only invoices with more than one installment have a schedule
+CREATE TABLE IF NOT EXISTS invoice_installments (
+    invoice_id UUID NOT NULL REFERENCES invoices(id),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    number INTEGER NOT NULL CHECK (number BETWEEN 1 AND 12),
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    due_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (invoice_id, number)
+);
+
+-- Accounts without rules accept up to 12 interest-free installments
+CREATE TABLE IF NOT EXISTS installment_rules (
+    account_id UUID PRIMARY KEY REFERENCES accounts(id),
+    max_installments INTEGER NOT NULL CHECK (max_installments BETWEEN 1 AND 12),
+    free_installments INTEGER NOT NULL CHECK (free_installments BETWEEN 1 AND max_installments),
+    monthly_interest DECIMAL(4,2) NOT NULL CHECK (monthly_interest >= 0 AND monthly_interest <= 10),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Each installment of an invoice settles on its own date
+ALTER TABLE settlements ADD COLUMN installment INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE settlements DROP CONSTRAINT IF EXISTS settlements_invoice_id_key;
+ALTER TABLE settlements ADD CONSTRAINT settlements_invoice_id_installment_key UNIQUE (invoice_id, installment);
+
+-- Same tenant boundary as invoices (000008)
+GRANT SELECT, INSERT, UPDATE, DELETE ON invoice_installments TO gateway_admin;
+GRANT SELECT, INSERT, UPDATE, DELETE ON installment_rules TO gateway_admin;
+
+ALTER TABLE invoice_installments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE invoice_installments FORCE ROW LEVEL SECURITY;
+ALTER TABLE installment_rules ENABLE ROW LEVEL SECURITY;
+ALTER TABLE installment_rules FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY invoice_installments_tenant ON invoice_installments
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY invoice_installments_admin ON invoice_installments TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
+
+CREATE POLICY installment_rules_tenant ON installment_rules
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY installment_rules_admin ON installment_rules TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
@@ -0,0 +1,35 @@
+-- Chargebacks opened by cardholders. The disputed amount leaves the available
+-- balance when the dispute opens (it may go negative) and comes back only
+-- when the merchant wins; a single dispute per invoice
+CREATE TABLE IF NOT EXISTS disputes (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    invoice_id UUID NOT NULL UNIQUE REFERENCES invoices(id),
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    reason VARCHAR(30) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('needs_response', 'under_review', 'won', 'lost')),
+    evidence TEXT NOT NULL DEFAULT '',
+    evidence_due_by TIMESTAMP NOT NULL,
+    evidence_submitted_at TIMESTAMP NULL,
+    resolved_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_disputes_account_id ON disputes(account_id);
+CREATE INDEX idx_disputes_overdue ON disputes(evidence_due_by) WHERE status = 'needs_response';
+
+-- Same tenant boundary as invoices (000008). The admin API and the expiry job
+-- look disputes up as gateway_admin, then move funds as the owning account
+GRANT SELECT, INSERT, UPDATE, DELETE ON disputes TO gateway_admin;
+
+ALTER TABLE disputes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE disputes FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY disputes_tenant ON disputes
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY disputes_admin ON disputes TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
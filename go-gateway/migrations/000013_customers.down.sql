@@ -0,0 +1,6 @@
+ALTER TABLE invoices
+    DROP COLUMN IF EXISTS saved_card_id,
+    DROP COLUMN IF EXISTS customer_id;
+
+DROP TABLE IF EXISTS saved_cards;
+DROP TABLE IF EXISTS customers;
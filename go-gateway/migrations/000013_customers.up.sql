@@ -0,0 +1,54 @@
+-- Payers of a merchant, identified by CPF (digits only)
+CREATE TABLE IF NOT EXISTS customers (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    name VARCHAR(100) NOT NULL,
+    email VARCHAR(254) NOT NULL,
+    document VARCHAR(11) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (account_id, document)
+);
+
+-- Cards on file. Only the vault token is stored, never the card number
+CREATE TABLE IF NOT EXISTS saved_cards (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    card_token VARCHAR(255) NOT NULL,
+    card_last_digits VARCHAR(4) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_saved_cards_customer_id ON saved_cards(customer_id);
+
+-- Deleting a saved card keeps the invoices charged to it, with their last
+-- digits
+ALTER TABLE invoices
+    ADD COLUMN customer_id UUID NULL REFERENCES customers(id),
+    ADD COLUMN saved_card_id UUID NULL REFERENCES saved_cards(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_invoices_customer_id ON invoices(account_id, customer_id);
+
+-- Same tenant boundary as invoices (000008)
+GRANT SELECT, INSERT, UPDATE, DELETE ON customers, saved_cards TO gateway_admin;
+
+ALTER TABLE customers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE customers FORCE ROW LEVEL SECURITY;
+ALTER TABLE saved_cards ENABLE ROW LEVEL SECURITY;
+ALTER TABLE saved_cards FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY customers_tenant ON customers
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY customers_admin ON customers TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
+
+CREATE POLICY saved_cards_tenant ON saved_cards
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY saved_cards_admin ON saved_cards TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_invoices_external_reference;
+ALTER TABLE invoices
+    DROP COLUMN IF EXISTS external_reference,
+    DROP COLUMN IF EXISTS metadata,
+    DROP COLUMN IF EXISTS items;
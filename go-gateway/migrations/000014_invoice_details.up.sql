@@ -0,0 +1,11 @@
+-- Merchant details of an invoice, written once at creation. items and
+-- metadata are snapshots read with the invoice, so they are kept as JSON
+-- instead of child tables; external_reference is the merchant's own ID,
+-- unique per account
+ALTER TABLE invoices
+    ADD COLUMN items JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN external_reference VARCHAR(100) NULL;
+
+CREATE UNIQUE INDEX idx_invoices_external_reference ON invoices(account_id, external_reference)
+    WHERE external_reference IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_invoices_idempotency_key;
+ALTER TABLE invoices DROP COLUMN IF EXISTS idempotency_key;
@@ -0,0 +1,6 @@
+-- Client key of bulk and single invoice creation: repeating a request with
+-- the same key returns the invoice it created instead of charging again
+ALTER TABLE invoices ADD COLUMN idempotency_key VARCHAR(255) NULL;
+
+CREATE UNIQUE INDEX idx_invoices_idempotency_key ON invoices(account_id, idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
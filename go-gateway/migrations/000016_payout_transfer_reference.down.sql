@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_payouts_processing;
+ALTER TABLE payouts DROP COLUMN IF EXISTS transfer_reference;
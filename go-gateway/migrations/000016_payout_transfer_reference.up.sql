@@ -0,0 +1,6 @@
+-- Reference the bank rail gave the transfer, stored with the paid status so a
+-- payout is never marked paid without it
+ALTER TABLE payouts ADD COLUMN transfer_reference VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Payouts left processing by a crashed worker are picked up again
+CREATE INDEX idx_payouts_processing ON payouts(updated_at) WHERE status = 'processing';
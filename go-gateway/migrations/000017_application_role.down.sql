@@ -0,0 +1,13 @@
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    REVOKE USAGE, SELECT ON SEQUENCES FROM gateway_app, gateway_admin;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    REVOKE SELECT, INSERT, UPDATE, DELETE ON TABLES FROM gateway_app, gateway_admin;
+
+REVOKE ALL ON ALL SEQUENCES IN SCHEMA public FROM gateway_app;
+REVOKE ALL ON ALL TABLES IN SCHEMA public FROM gateway_app;
+REVOKE USAGE ON SCHEMA public FROM gateway_app, gateway_admin;
+REVOKE gateway_admin FROM gateway_app;
+
+-- Keep what 000008 to 000013 granted gateway_admin on the tables with
+-- row-level security
+REVOKE ALL ON accounts, balance_adjustments, payouts, bank_accounts, fee_plans, invoice_fees, settlements, risk_rules FROM gateway_admin;
@@ -0,0 +1,27 @@
+-- Role the gateway connects as, through a LOGIN role member of it. It owns no
+-- table, so FORCE ROW LEVEL SECURITY is not the only thing between it and
+-- the policies, and it never gets BYPASSRLS.
+--
+-- NOINHERIT keeps gateway_admin out of its privileges: a policy TO
+-- gateway_admin applies to every role inheriting from it, so an inherited
+-- membership would show every account's rows. The app still assumes the
+-- role explicitly with SET LOCAL ROLE.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'gateway_app') THEN
+        CREATE ROLE gateway_app NOLOGIN NOINHERIT;
+    END IF;
+END
+$$;
+GRANT gateway_admin TO gateway_app;
+
+GRANT USAGE ON SCHEMA public TO gateway_app, gateway_admin;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO gateway_app, gateway_admin;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO gateway_app, gateway_admin;
+-- Migrations stay with the owner
+REVOKE ALL ON schema_migrations FROM gateway_app, gateway_admin;
+
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO gateway_app, gateway_admin;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    GRANT USAGE, SELECT ON SEQUENCES TO gateway_app, gateway_admin;
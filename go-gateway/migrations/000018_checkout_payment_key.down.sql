@@ -0,0 +1 @@
+ALTER TABLE checkout_sessions DROP COLUMN payment_key;
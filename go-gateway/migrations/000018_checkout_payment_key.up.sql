@@ -0,0 +1,3 @@
+-- Idempotency key of the invoice of the current claim, so a payment whose
+-- invoice was charged but not linked gets the same invoice when retried
+ALTER TABLE checkout_sessions ADD COLUMN payment_key VARCHAR(100) NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+REVOKE SELECT ON schema_migrations FROM gateway_app;
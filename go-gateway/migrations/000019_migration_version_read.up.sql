@@ -0,0 +1,3 @@
+-- /readyz reads the schema version as the app role; writing it stays with
+-- the owner that runs the migrations
+GRANT SELECT ON schema_migrations TO gateway_app;
@@ -0,0 +1,34 @@
+DROP POLICY IF EXISTS risk_rules_admin ON risk_rules;
+DROP POLICY IF EXISTS risk_rules_tenant ON risk_rules;
+ALTER TABLE risk_rules NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE risk_rules DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS settlements_admin ON settlements;
+DROP POLICY IF EXISTS settlements_tenant ON settlements;
+ALTER TABLE settlements NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE settlements DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS invoice_fees_admin ON invoice_fees;
+DROP POLICY IF EXISTS invoice_fees_tenant ON invoice_fees;
+ALTER TABLE invoice_fees NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE invoice_fees DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS fee_plans_admin ON fee_plans;
+DROP POLICY IF EXISTS fee_plans_tenant ON fee_plans;
+ALTER TABLE fee_plans NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE fee_plans DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS payouts_admin ON payouts;
+DROP POLICY IF EXISTS payouts_tenant ON payouts;
+ALTER TABLE payouts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE payouts DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS bank_accounts_admin ON bank_accounts;
+DROP POLICY IF EXISTS bank_accounts_tenant ON bank_accounts;
+ALTER TABLE bank_accounts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE bank_accounts DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS balance_adjustments_admin ON balance_adjustments;
+DROP POLICY IF EXISTS balance_adjustments_tenant ON balance_adjustments;
+ALTER TABLE balance_adjustments NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE balance_adjustments DISABLE ROW LEVEL SECURITY;
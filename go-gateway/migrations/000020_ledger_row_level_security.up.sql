@@ -0,0 +1,75 @@
+-- Same tenant boundary as invoices (000008) for the remaining tables with an
+-- account_id. gateway_app and gateway_admin already hold the DML grants
+-- (000017); the workers and the admin API read them as gateway_admin
+ALTER TABLE balance_adjustments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE balance_adjustments FORCE ROW LEVEL SECURITY;
+ALTER TABLE bank_accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE bank_accounts FORCE ROW LEVEL SECURITY;
+ALTER TABLE payouts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE payouts FORCE ROW LEVEL SECURITY;
+ALTER TABLE fee_plans ENABLE ROW LEVEL SECURITY;
+ALTER TABLE fee_plans FORCE ROW LEVEL SECURITY;
+ALTER TABLE invoice_fees ENABLE ROW LEVEL SECURITY;
+ALTER TABLE invoice_fees FORCE ROW LEVEL SECURITY;
+ALTER TABLE settlements ENABLE ROW LEVEL SECURITY;
+ALTER TABLE settlements FORCE ROW LEVEL SECURITY;
+ALTER TABLE risk_rules ENABLE ROW LEVEL SECURITY;
+ALTER TABLE risk_rules FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY balance_adjustments_tenant ON balance_adjustments
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY bank_accounts_tenant ON bank_accounts
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY payouts_tenant ON payouts
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY fee_plans_tenant ON fee_plans
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY invoice_fees_tenant ON invoice_fees
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY settlements_tenant ON settlements
+    USING (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+-- Global rules (NULL account_id) apply to every account, which reads them
+-- but writes only its own
+CREATE POLICY risk_rules_tenant ON risk_rules
+    USING (account_id IS NULL OR account_id = NULLIF(current_setting('app.account_id', true), '')::uuid)
+    WITH CHECK (account_id = NULLIF(current_setting('app.account_id', true), '')::uuid);
+
+CREATE POLICY balance_adjustments_admin ON balance_adjustments TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
+
+CREATE POLICY bank_accounts_admin ON bank_accounts TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
+
+CREATE POLICY payouts_admin ON payouts TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
+
+CREATE POLICY fee_plans_admin ON fee_plans TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
+
+CREATE POLICY invoice_fees_admin ON invoice_fees TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
+
+CREATE POLICY settlements_admin ON settlements TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
+
+CREATE POLICY risk_rules_admin ON risk_rules TO gateway_admin
+    USING (true)
+    WITH CHECK (true);
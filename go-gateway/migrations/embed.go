@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations so the gateway binary can apply
+// them without the external migrate CLI.
+package migrations
+
+import "embed"
+
+// FS holds the NNNNNN_name.{up,down}.sql files of this directory.
+//
+//go:embed *.sql
+var FS embed.FS